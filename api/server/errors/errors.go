@@ -95,6 +95,74 @@ var (
 		Code: http.StatusConflict,
 		Err:  errors.ErrAuditExists,
 	}
+	ErrDNSNotEnabled = Error{
+		Code: http.StatusNotAcceptable,
+		Err:  errors.ErrDNSNotEnabled,
+	}
+	ErrDNSRecordNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrDNSRecordNotFound,
+	}
+	ErrDNSRecordExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrDNSRecordExists,
+	}
+	ErrPipelineNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrPipelineNotFound,
+	}
+	ErrPipelineExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrPipelineExists,
+	}
+	ErrPipelineRunNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrPipelineRunNotFound,
+	}
+	ErrPropagationNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrPropagationNotFound,
+	}
+	ErrPropagationExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrPropagationExists,
+	}
+	ErrFleetNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrFleetNotFound,
+	}
+	ErrFleetExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrFleetExists,
+	}
+	ErrScalingNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrScalingNotFound,
+	}
+	ErrNamespacePolicyNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrNamespacePolicyNotFound,
+	}
+	ErrNamespacePolicyExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrNamespacePolicyExists,
+	}
+	ErrTemplateNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrTemplateNotFound,
+	}
+	ErrTemplateExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrTemplateExists,
+	}
+	ErrReplicationNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrReplicationNotFound,
+	}
+	ErrReplicationExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrReplicationExists,
+	}
 	ErrRBACPolicyExists = Error{
 		Code: http.StatusConflict,
 		Err:  errors.PolicyExistError,
@@ -111,4 +179,124 @@ var (
 		Code: http.StatusNotFound,
 		Err:  errors.PolicyNotExistError,
 	}
+	ErrHelmSecretNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrHelmSecretNotFound,
+	}
+	ErrHelmSecretExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrHelmSecretExists,
+	}
+	ErrKubeConfigNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrKubeConfigNotFound,
+	}
+	ErrKubeConfigRevoked = Error{
+		Code: http.StatusGone,
+		Err:  errors.ErrKubeConfigRevoked,
+	}
+	ErrPlanNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrPlanNotFound,
+	}
+	ErrPlanNodeNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrPlanNodeNotFound,
+	}
+	ErrPlanConfigNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrPlanConfigNotFound,
+	}
+	ErrCloudAccountNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrCloudAccountNotFound,
+	}
+	ErrCloudAccountExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrCloudAccountExists,
+	}
+	ErrCloudClusterImported = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrCloudClusterImported,
+	}
+	ErrSLONotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrSLONotFound,
+	}
+	ErrSLOExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrSLOExists,
+	}
+	ErrPrecheckNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrPrecheckNotFound,
+	}
+	ErrNotificationNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrNotificationNotFound,
+	}
+	ErrNamingPolicyNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrNamingPolicyNotFound,
+	}
+	ErrNamingPolicyExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrNamingPolicyExists,
+	}
+	ErrSidecarTemplateNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrSidecarTemplateNotFound,
+	}
+	ErrSidecarTemplateExists = Error{
+		Code: http.StatusConflict,
+		Err:  errors.ErrSidecarTemplateExists,
+	}
+	ErrInjectionNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrInjectionNotFound,
+	}
+	ErrRecycleNotEnabled = Error{
+		Code: http.StatusNotAcceptable,
+		Err:  errors.ErrRecycleNotEnabled,
+	}
+	ErrRecycledObjectNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrRecycledObjectNotFound,
+	}
+	ErrAnnouncementNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrAnnouncementNotFound,
+	}
+	ErrLogBufferDisabled = Error{
+		Code: http.StatusNotAcceptable,
+		Err:  errors.ErrLogBufferDisabled,
+	}
+	ErrQueryStatsDisabled = Error{
+		Code: http.StatusNotAcceptable,
+		Err:  errors.ErrQueryStatsDisabled,
+	}
+	ErrBootstrapTokenInvalid = Error{
+		Code: http.StatusUnauthorized,
+		Err:  errors.ErrBootstrapTokenInvalid,
+	}
+	ErrNodePoolNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrNodePoolNotFound,
+	}
+	ErrPortForwardNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrPortForwardNotFound,
+	}
+	ErrSessionNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrSessionNotFound,
+	}
+	ErrInspectionNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrInspectionNotFound,
+	}
+	ErrInspectionReportNotFound = Error{
+		Code: http.StatusNotFound,
+		Err:  errors.ErrInspectionReportNotFound,
+	}
 )
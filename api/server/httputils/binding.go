@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputils
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// defaultTag 字段的默认值，请求中未指定该字段时生效，例如 `form:"limit" default:"20"`
+const defaultTag = "default"
+
+// BindSources 请求中需要绑定的各个部分，为 nil 时不绑定
+type BindSources struct {
+	JSON   interface{}
+	URI    interface{}
+	Query  interface{}
+	Header interface{} // 通过 `header:"X-Request-ID"` 绑定请求头
+}
+
+// ShouldBind 先设置 default tag 的默认值，再依次绑定 uri，query，header 和 json
+// 校验失败的字段合并为一个 validator.ValidationErrors 返回，其他错误直接返回
+func ShouldBind(c *gin.Context, sources BindSources) error {
+	binders := []struct {
+		obj  interface{}
+		bind func(interface{}) error
+	}{
+		{sources.URI, c.ShouldBindUri},
+		{sources.Query, c.ShouldBindQuery},
+		{sources.Header, c.ShouldBindHeader},
+		{sources.JSON, c.ShouldBindJSON},
+	}
+
+	var errs validator.ValidationErrors
+	for _, binder := range binders {
+		if binder.obj == nil {
+			continue
+		}
+		if err := setDefaults(binder.obj); err != nil {
+			return err
+		}
+		err := binder.bind(binder.obj)
+		if err == nil {
+			continue
+		}
+		ve, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return err
+		}
+		errs = append(errs, ve...)
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+// setDefaults 为零值字段设置 default tag 中的值，支持嵌入的结构体
+func setDefaults(obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return setStructDefaults(v.Elem())
+}
+
+func setStructDefaults(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if !value.CanSet() {
+			continue
+		}
+		if field.Anonymous && value.Kind() == reflect.Struct {
+			if err := setStructDefaults(value); err != nil {
+				return err
+			}
+			continue
+		}
+		def, ok := field.Tag.Lookup(defaultTag)
+		if !ok || !value.IsZero() {
+			continue
+		}
+		if err := setValue(value, def); err != nil {
+			return fmt.Errorf("invalid default value %q of field %s: %v", def, field.Name, err)
+		}
+	}
+	return nil
+}
+
+func setValue(value reflect.Value, def string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(def)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(def)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(def)
+			if err != nil {
+				return err
+			}
+			value.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(def, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(def, 10, 64)
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(def, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported kind %s", value.Kind())
+	}
+	return nil
+}
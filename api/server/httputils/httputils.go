@@ -21,20 +21,25 @@ import (
 	goerrors "errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
+	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 
 	"github.com/caoyingjunz/pixiu/api/server/errors"
 	validatorutil "github.com/caoyingjunz/pixiu/api/server/validator"
+	"github.com/caoyingjunz/pixiu/pkg/db"
 	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
 )
 
 type Response struct {
 	Code    int         `json:"code"`              // 返回的状态码
 	Result  interface{} `json:"result,omitempty"`  // 正常返回时的数据，可以为任意数据结构
 	Message string      `json:"message,omitempty"` // 异常返回时的错误信息
+	Meta    *Meta       `json:"meta,omitempty"`    // 分页，告警以及请求耗时等附加信息
 }
 
 func (r *Response) SetCode(c int) {
@@ -76,6 +81,7 @@ func NewResponse() *Response {
 func SetSuccess(c *gin.Context, r *Response) {
 	_ = contextBind(c).withResponseCode(http.StatusOK)
 	r.SetMessageWithCode("success", http.StatusOK)
+	r.Meta = buildMeta(c, r.Result)
 	c.JSON(http.StatusOK, r)
 }
 
@@ -95,12 +101,14 @@ func SetFailed(c *gin.Context, r *Response, err error) {
 func setFailedWithCode(c *gin.Context, r *Response, code int, err error) {
 	_ = contextBind(c).withResponseCode(code).withRawError(err)
 	r.SetMessageWithCode(err, code)
+	r.Meta = buildMeta(c, nil)
 	c.JSON(http.StatusOK, r)
 }
 
 func setFailedWithValidationError(c *gin.Context, r *Response, e string) {
 	_ = contextBind(c).withResponseCode(http.StatusBadRequest).withRawError(goerrors.New(e))
 	r.SetMessageWithCode(e, http.StatusBadRequest)
+	r.Meta = buildMeta(c, nil)
 	c.JSON(http.StatusOK, r)
 }
 
@@ -113,24 +121,9 @@ func AbortFailedWithCode(c *gin.Context, code int, err error) {
 	c.Abort()
 }
 
+// ShouldBindAny 绑定 json，uri 和 query，需要绑定请求头时使用 ShouldBind
 func ShouldBindAny(c *gin.Context, jsonObject interface{}, uriObject interface{}, queryObject interface{}) error {
-	var err error
-	if jsonObject != nil {
-		if err = c.ShouldBindJSON(jsonObject); err != nil {
-			return err
-		}
-	}
-	if uriObject != nil {
-		if err = c.ShouldBindUri(uriObject); err != nil {
-			return err
-		}
-	}
-	if queryObject != nil {
-		if err = c.ShouldBindQuery(queryObject); err != nil {
-			return err
-		}
-	}
-	return nil
+	return ShouldBind(c, BindSources{JSON: jsonObject, URI: uriObject, Query: queryObject})
 }
 
 const userKey = "user"
@@ -158,6 +151,23 @@ func GetUserIdFromContext(ctx context.Context) (int64, error) {
 
 func SetUserToContext(c *gin.Context, user *model.User) {
 	c.Set(userKey, user)
+	if isTenantUser(user) {
+		c.Set(db.TenantContextKey, user.TenantId)
+	}
+}
+
+// WithUser 为非 http 请求的调用方注入用户，例如后台控制器
+func WithUser(ctx context.Context, user *model.User) context.Context {
+	ctx = context.WithValue(ctx, userKey, user)
+	if isTenantUser(user) {
+		ctx = db.WithTenantContext(ctx, user.TenantId)
+	}
+	return ctx
+}
+
+// isTenantUser 绑定了租户的普通用户，其数据访问限定在所属租户内
+func isTenantUser(user *model.User) bool {
+	return user.Role == model.RoleUser && user.TenantId != 0
 }
 
 func GetObjectFromRequest(c *gin.Context) (string, string, bool) {
@@ -248,3 +258,76 @@ func GetRawError(ctx context.Context) (err error) {
 	err = val.(error)
 	return
 }
+
+const (
+	// ChangeTicketHeader 和 ChangeReasonHeader 用于变更操作携带变更单号和变更原因，值可以 url 编码
+	ChangeTicketHeader = "X-Pixiu-Change-Ticket"
+	ChangeReasonHeader = "X-Pixiu-Change-Reason"
+
+	changeTicketKey = "change_ticket"
+	changeReasonKey = "change_reason"
+)
+
+// SetChangeToContext 保存请求体中携带的变更单号和变更原因，优先于请求头
+func SetChangeToContext(c *gin.Context, ticket, reason string) {
+	if len(ticket) != 0 {
+		c.Set(changeTicketKey, ticket)
+	}
+	if len(reason) != 0 {
+		c.Set(changeReasonKey, reason)
+	}
+}
+
+// GetChangeFromRequest 获取请求的变更单号和变更原因
+func GetChangeFromRequest(c *gin.Context) (ticket string, reason string) {
+	ticket = c.GetString(changeTicketKey)
+	if len(ticket) == 0 {
+		ticket = getHeaderValue(c, ChangeTicketHeader)
+	}
+	reason = c.GetString(changeReasonKey)
+	if len(reason) == 0 {
+		reason = getHeaderValue(c, ChangeReasonHeader)
+	}
+	return
+}
+
+func getHeaderValue(c *gin.Context, key string) string {
+	val := c.GetHeader(key)
+	if unescaped, err := url.QueryUnescape(val); err == nil {
+		return unescaped
+	}
+	return val
+}
+
+// NewAuditFromRequest 使用请求的 ID，客户端 IP 和操作人构造审计记录，用于控制器主动记录 GET 等不会被审计中间件记录的请求
+func NewAuditFromRequest(ctx context.Context, obj model.ObjectType, path string) *model.Audit {
+	audit := &model.Audit{
+		Action:     http.MethodGet,
+		Operator:   "unknown",
+		Path:       path,
+		ObjectType: obj,
+		Status:     model.AuditOpSuccess,
+	}
+	if user, err := GetUserFromRequest(ctx); err == nil && user != nil {
+		audit.Operator = user.Name
+	}
+	if c, ok := ctx.(*gin.Context); ok {
+		audit.RequestId = requestid.Get(c)
+		audit.Ip = c.ClientIP()
+		audit.Action = c.Request.Method
+	}
+	return audit
+}
+
+// NewSessionFromRequest 使用请求的用户和客户端 IP 构造长连接会话，用于会话的记录和转发
+func NewSessionFromRequest(ctx context.Context, kind string) *types.Session {
+	s := &types.Session{Kind: kind}
+	if user, err := GetUserFromRequest(ctx); err == nil && user != nil {
+		s.User = user.Name
+		s.UserId = user.Id
+	}
+	if c, ok := ctx.(*gin.Context); ok {
+		s.RemoteAddr = c.ClientIP()
+	}
+	return s
+}
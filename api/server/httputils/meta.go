@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	requestStartKey = "requestStart"
+	warningsKey     = "warnings"
+)
+
+// Meta 返回值的附加信息，分页查询时包含分页属性，部分失败时包含告警信息，以及请求耗时
+type Meta struct {
+	Total    *int     `json:"total,omitempty"`
+	Page     int      `json:"page,omitempty"`
+	Limit    int      `json:"limit,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	TookMs   int64    `json:"took_ms"`
+}
+
+// Warnings 请求处理过程中不影响结果的告警，例如跳过了无法访问的集群
+type Warnings struct {
+	sync.Mutex
+	items []string
+}
+
+func (w *Warnings) add(msg string) {
+	w.Lock()
+	defer w.Unlock()
+	w.items = append(w.items, msg)
+}
+
+func (w *Warnings) list() []string {
+	w.Lock()
+	defer w.Unlock()
+	return append([]string(nil), w.items...)
+}
+
+// InitResponseMeta 记录请求的开始时间并初始化告警，由日志中间件在请求开始时调用
+func InitResponseMeta(c *gin.Context) {
+	c.Set(requestStartKey, time.Now())
+	c.Set(warningsKey, new(Warnings))
+}
+
+// AddWarning 追加告警信息，并发调用安全，ctx 不是 http 请求时忽略
+func AddWarning(ctx context.Context, format string, args ...interface{}) {
+	w, ok := ctx.Value(warningsKey).(*Warnings)
+	if !ok {
+		return
+	}
+	w.add(fmt.Sprintf(format, args...))
+}
+
+// buildMeta 根据返回结果和上下文构造附加信息，未初始化时返回 nil
+func buildMeta(c *gin.Context, result interface{}) *Meta {
+	start, ok := c.Value(requestStartKey).(time.Time)
+	if !ok {
+		return nil
+	}
+	meta := &Meta{TookMs: time.Since(start).Milliseconds()}
+	if w, ok := c.Value(warningsKey).(*Warnings); ok {
+		meta.Warnings = w.list()
+	}
+
+	var page *types.PageResponse
+	switch v := result.(type) {
+	case types.PageResponse:
+		page = &v
+	case *types.PageResponse:
+		page = v
+	}
+	if page != nil {
+		total := page.Total
+		meta.Total, meta.Page, meta.Limit = &total, page.Page, page.Limit
+	}
+	return meta
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputils
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const ContentTypeNDJSON = "application/x-ndjson"
+
+// WantsNDJSON 请求头 Accept 为 application/x-ndjson 或者指定 format=ndjson 时以流的形式返回
+func WantsNDJSON(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), ContentTypeNDJSON) || c.Query("format") == "ndjson"
+}
+
+// StreamNDJSON 每行返回一个 json 对象，每次 emit 后立即 flush，客户端可以边接收边处理
+// 开始返回后无法修改状态码，因此 fn 返回错误时追加一行 {"error": "..."}
+func StreamNDJSON(c *gin.Context, fn func(emit func(v interface{}) error) error) {
+	_ = contextBind(c).withResponseCode(http.StatusOK)
+	c.Header("Content-Type", ContentTypeNDJSON)
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	emit := func(v interface{}) error {
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	if err := fn(emit); err != nil {
+		_ = contextBind(c).withRawError(err)
+		_ = emit(map[string]string{"error": err.Error()})
+	}
+}
+
+// WriteNDJSON 逐行返回已获取的列表，result 为切片或者 types.PageResponse
+func WriteNDJSON(c *gin.Context, result interface{}) {
+	if page, ok := result.(types.PageResponse); ok {
+		result = page.Items
+	}
+	if page, ok := result.(*types.PageResponse); ok {
+		result = page.Items
+	}
+
+	StreamNDJSON(c, func(emit func(v interface{}) error) error {
+		v := reflect.ValueOf(result)
+		if v.Kind() != reflect.Slice {
+			return emit(result)
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := emit(v.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
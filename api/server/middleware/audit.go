@@ -78,6 +78,9 @@ func (w *auditWriter) asyncAudit(c *gin.Context) {
 		ObjectType: model.ObjectType(obj),
 		Status:     getAuditStatus(c),
 	}
+	ticket, reason := httputils.GetChangeFromRequest(c)
+	audit.ChangeTicket = truncate(ticket, 128)
+	audit.ChangeReason = truncate(reason, 512)
 	if _, err := w.opts.Factory.Audit().Create(context.TODO(), audit); err != nil {
 		klog.Errorf("failed to create audit record [%s]: %v", audit.String(), err)
 	}
@@ -106,6 +109,15 @@ func getAuditStatus(c *gin.Context) model.AuditOperationStatus {
 	return model.AuditOpFail
 }
 
+// truncate 按字符截断，避免超出字段长度导致审计记录写入失败
+func truncate(s string, n int) string {
+	rs := []rune(s)
+	if len(rs) <= n {
+		return s
+	}
+	return string(rs[:n])
+}
+
 func responseOK(code int) bool {
 	return code == http.StatusOK ||
 		code == http.StatusCreated ||
@@ -58,6 +58,11 @@ func Authentication(o *options.Options) gin.HandlerFunc {
 }
 
 func validate(c *gin.Context, o *options.Options, keyBytes []byte) error {
+	// 服务间调用使用客户端证书认证，证书的 CommonName 为用户名
+	if len(c.GetHeader("Authorization")) == 0 && c.Request.TLS != nil && len(c.Request.TLS.VerifiedChains) != 0 {
+		return validateClientCert(c, o)
+	}
+
 	token, err := extractToken(c, false)
 	if err != nil {
 		return err
@@ -87,6 +92,23 @@ func validate(c *gin.Context, o *options.Options, keyBytes []byte) error {
 	return nil
 }
 
+func validateClientCert(c *gin.Context, o *options.Options) error {
+	name := c.Request.TLS.VerifiedChains[0][0].Subject.CommonName
+	if len(name) == 0 {
+		return fmt.Errorf("客户端证书未指定用户")
+	}
+	user, err := o.Factory.User().GetUserByName(c, name)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.ErrUnauthorized
+	}
+	httputils.SetUserToContext(c, user)
+
+	return nil
+}
+
 // 从请求头中获取 token
 func extractToken(c *gin.Context, ws bool) (string, error) {
 	emptyFunc := func(t string) bool { return len(t) == 0 }
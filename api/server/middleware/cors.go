@@ -21,13 +21,15 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
 )
 
 func Cors() gin.HandlerFunc {
 	c := cors.Config{
 		AllowAllOrigins: true,
 		AllowMethods:    []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
-		AllowHeaders:    []string{"Content-Type", "Access-Token", "Authorization"},
+		AllowHeaders:    []string{"Content-Type", "Access-Token", "Authorization", httputils.ChangeTicketHeader, httputils.ChangeReasonHeader},
 		MaxAge:          6 * time.Hour,
 	}
 
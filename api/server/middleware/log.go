@@ -29,11 +29,13 @@ func Logger(cfg *logutil.LogOptions) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		l := logutil.NewLogger(cfg)
 		c.Set(db.SQLContextKey, new(db.SQLs)) // set SQL context key
+		httputils.InitResponseMeta(c)
 
 		// 处理请求操作
 		c.Next()
 
 		l.WithLogFields(map[string]interface{}{
+			"module":                  "api",
 			"request_id":              requestid.Get(c),
 			"method":                  c.Request.Method,
 			"uri":                     c.Request.RequestURI,
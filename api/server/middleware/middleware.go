@@ -31,7 +31,7 @@ import (
 var alwaysAllowPath sets.String
 
 func init() {
-	alwaysAllowPath = sets.NewString("/pixiu/users/login")
+	alwaysAllowPath = sets.NewString("/pixiu/users/login", "/pixiu/clusters/register", "/pixiu/clusters/tunnel")
 }
 
 // 允许特定请求不经过验证
@@ -51,8 +51,16 @@ func allowCustomRequest(c *gin.Context) bool {
 }
 
 func InstallMiddlewares(o *options.Options) {
+	o.HttpEngine.Use(handlers(o)...)
+	// 管理端口同样需要验证和鉴权
+	if o.AdminEngine != nil {
+		o.AdminEngine.Use(handlers(o)...)
+	}
+}
+
+func handlers(o *options.Options) []gin.HandlerFunc {
 	// 依次进行跨域，日志，单用户限速，总量限速，验证，鉴权和审计
-	o.HttpEngine.Use(
+	return []gin.HandlerFunc{
 		requestid.New(requestid.WithGenerator(func() string {
 			return util.GenerateRequestID()
 		})),
@@ -64,5 +72,5 @@ func InstallMiddlewares(o *options.Options) {
 		Authorization(o),
 		Admission(),
 		Audit(o),
-	)
+	}
 }
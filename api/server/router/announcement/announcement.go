@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package announcement
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type announcementRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &announcementRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (a *announcementRouter) initRoutes(ginEngine *gin.Engine) {
+	announcementRoute := ginEngine.Group("/pixiu/announcements")
+	{
+		announcementRoute.POST("", a.createAnnouncement)
+		announcementRoute.PUT("/:announcementId", a.updateAnnouncement)
+		announcementRoute.DELETE("/:announcementId", a.deleteAnnouncement)
+		announcementRoute.GET("/:announcementId", a.getAnnouncement)
+		announcementRoute.GET("", a.listAnnouncements)
+	}
+
+	// 当前生效的公告，所有登陆用户均可获取
+	ginEngine.GET("/pixiu/users/me/announcements", a.listActiveAnnouncements)
+}
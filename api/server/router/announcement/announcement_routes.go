@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package announcement
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type announcementMeta struct {
+	AnnouncementId int64 `uri:"announcementId" binding:"required"`
+}
+
+func (a *announcementRouter) createAnnouncement(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.CreateAnnouncementRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = a.c.Announcement().Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (a *announcementRouter) updateAnnouncement(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt announcementMeta
+		req types.UpdateAnnouncementRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = a.c.Announcement().Update(c, opt.AnnouncementId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (a *announcementRouter) deleteAnnouncement(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt announcementMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = a.c.Announcement().Delete(c, opt.AnnouncementId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (a *announcementRouter) getAnnouncement(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt announcementMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = a.c.Announcement().Get(c, opt.AnnouncementId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (a *announcementRouter) listAnnouncements(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = a.c.Announcement().List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (a *announcementRouter) listActiveAnnouncements(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = a.c.Announcement().ListActive(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -39,6 +39,8 @@ func (a *auditRouter) initRoutes(httpEngine *gin.Engine) {
 	{
 		// get 日志
 		auditRoute.GET("/:auditId", a.getAudit)
+		// 校验审计记录的哈希链
+		auditRoute.GET("/verify", a.verifyAudits)
 		auditRoute.GET("", a.listAudits)
 	}
 }
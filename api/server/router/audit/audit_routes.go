@@ -50,14 +50,32 @@ func (a *auditRouter) listAudits(c *gin.Context) {
 	r := httputils.NewResponse()
 
 	var (
-		listOption types.ListOptions // 分页设置
-		err        error
+		opts types.ListAuditOptions // 过滤条件和分页设置
+		err  error
 	)
-	if err = httputils.ShouldBindAny(c, nil, nil, &listOption); err != nil {
+	if err = httputils.ShouldBindAny(c, nil, nil, &opts); err != nil {
 		httputils.SetFailed(c, r, err)
 		return
 	}
-	if r.Result, err = a.c.Audit().List(c, listOption); err != nil {
+	if httputils.WantsNDJSON(c) {
+		httputils.StreamNDJSON(c, func(emit func(v interface{}) error) error {
+			return a.c.Audit().Stream(c, opts, func(audit types.Audit) error { return emit(audit) })
+		})
+		return
+	}
+	if r.Result, err = a.c.Audit().List(c, opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (a *auditRouter) verifyAudits(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = a.c.Audit().Verify(c); err != nil {
 		httputils.SetFailed(c, r, err)
 		return
 	}
@@ -53,5 +53,15 @@ func (a *authRouter) initRoutes(ge *gin.Engine) {
 		bindingRoute.POST("", a.createBinding)
 		bindingRoute.DELETE("", a.deleteBinding)
 		bindingRoute.GET("", a.listBindings)
+		// 批量设置用户所属的用户组
+		bindingRoute.PUT("/users/:userId", a.setBindings)
 	}
+	{
+		// 以 yaml 的形式导出和导入 RBAC 配置
+		authRoute.GET("/export", a.exportRBAC)
+		authRoute.POST("/import", a.importRBAC)
+	}
+
+	// 当前用户可见的菜单，所有登陆用户均可获取
+	ge.GET("/pixiu/users/me/menus", a.listMenus)
 }
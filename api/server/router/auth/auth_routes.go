@@ -17,8 +17,12 @@ limitations under the License.
 package auth
 
 import (
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"sigs.k8s.io/yaml"
 
+	"github.com/caoyingjunz/pixiu/api/server/errors"
 	"github.com/caoyingjunz/pixiu/api/server/httputils"
 	"github.com/caoyingjunz/pixiu/pkg/types"
 )
@@ -27,6 +31,10 @@ type IdMeta struct {
 	PolicyId int64 `uri:"policyId" binding:"required"`
 }
 
+type UserMeta struct {
+	UserId int64 `uri:"userId" binding:"required"`
+}
+
 func (a *authRouter) listPolicies(c *gin.Context) {
 	r := httputils.NewResponse()
 	var (
@@ -122,3 +130,81 @@ func (a *authRouter) deleteBinding(c *gin.Context) {
 
 	httputils.SetSuccess(c, r)
 }
+
+func (a *authRouter) setBindings(c *gin.Context) {
+	r := httputils.NewResponse()
+	var (
+		idMeta UserMeta
+		req    types.SetGroupBindingsRequest
+	)
+	if err := httputils.ShouldBindAny(c, &req, &idMeta, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := a.c.Auth().SetGroupBindings(c, idMeta.UserId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (a *authRouter) exportRBAC(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	doc, err := a.c.Auth().ExportRBAC(c)
+	if err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="pixiu-rbac.yaml"`)
+	c.Data(http.StatusOK, "application/yaml", data)
+}
+
+// importRBAC 请求体为 yaml 或者 json 格式的 RBAC 文档，未知字段视为错误
+func (a *authRouter) importRBAC(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.ImportRBACOptions
+		doc  types.RBACDocument
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	data, err := c.GetRawData()
+	if err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = yaml.UnmarshalStrict(data, &doc); err != nil {
+		httputils.SetFailed(c, r, errors.NewError(err, http.StatusBadRequest))
+		return
+	}
+	if r.Result, err = a.c.Auth().ImportRBAC(c, &doc, &opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (a *authRouter) listMenus(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = a.c.Auth().ListMenus(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
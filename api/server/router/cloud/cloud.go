@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type cloudRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &cloudRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (cr *cloudRouter) initRoutes(ginEngine *gin.Engine) {
+	cloudRoute := ginEngine.Group("/pixiu/cloudaccounts")
+	{
+		cloudRoute.POST("", cr.createAccount)
+		cloudRoute.DELETE("/:accountId", cr.deleteAccount)
+		cloudRoute.GET("/:accountId", cr.getAccount)
+		cloudRoute.GET("", cr.listAccounts)
+
+		// 发现并导入云账号下的托管集群
+		cloudRoute.GET("/:accountId/clusters", cr.listClusters)
+		cloudRoute.POST("/:accountId/clusters/import", cr.importCluster)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type accountMeta struct {
+	AccountId int64 `uri:"accountId" binding:"required"`
+}
+
+func (cr *cloudRouter) createAccount(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var req types.CreateCloudAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := cr.c.Cloud().Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *cloudRouter) deleteAccount(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var opt accountMeta
+	if err := c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := cr.c.Cloud().Delete(c, opt.AccountId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *cloudRouter) getAccount(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt accountMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cloud().Get(c, opt.AccountId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *cloudRouter) listAccounts(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = cr.c.Cloud().List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *cloudRouter) listClusters(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt accountMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cloud().ListClusters(c, opt.AccountId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *cloudRouter) importCluster(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt accountMeta
+		req types.ImportCloudClusterRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cloud().Import(c, opt.AccountId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+func (cr *clusterRouter) createArgoApplication(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		req  types.CreateArgoApplicationRequest
+		err  error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opts, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.ArgoCD(opts.Cluster).Create(c, opts.Namespace, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) deleteArgoApplication(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.ArgoCD(opts.Cluster).Delete(c, opts.Namespace, opts.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) getArgoApplication(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.ArgoCD(opts.Cluster).Get(c, opts.Namespace, opts.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listArgoApplications(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.ArgoCD(opts.Cluster).List(c, opts.Namespace); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) syncArgoApplication(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		req  types.SyncArgoApplicationRequest
+		err  error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opts, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.ArgoCD(opts.Cluster).Sync(c, opts.Namespace, opts.Name, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -51,6 +51,18 @@ func (cr *clusterRouter) initRoutes(httpEngine *gin.Engine) {
 		clusterRoute.GET("/:clusterId", cr.getCluster)
 		clusterRoute.GET("", cr.listClusters)
 
+		// 获取所有集群对外暴露的访问入口
+		clusterRoute.GET("/exposures", cr.listExposures)
+		// 检索持久化的集群事件
+		clusterRoute.GET("/events", cr.searchEvents)
+		// 获取所有租户的 GPU 使用量和配额
+		clusterRoute.GET("/gpus/tenants", cr.listTenantGPUUsages)
+
+		// 通过 agent 导入集群，agent 使用引导 token 认证
+		clusterRoute.POST("/bootstraps", cr.createClusterBootstrap)
+		clusterRoute.POST("/register", cr.registerCluster)
+		clusterRoute.GET("/tunnel", cr.serveClusterTunnel)
+
 		// 检查 kubernetes 的连通性
 		clusterRoute.POST("/ping", cr.pingCluster)
 
@@ -63,17 +75,161 @@ func (cr *clusterRouter) initRoutes(httpEngine *gin.Engine) {
 	{
 		// 获取指定对象的日志
 		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/pods/:pod/log", cr.watchPodLog)
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/pods/:pod/logs", cr.streamPodLogs)
+		// 下载工作负载或者命名空间的诊断包
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/supportbundle", cr.downloadSupportBundle)
 		// Deprecated 聚合 events
 		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/name/:name/kind/:kind/events", cr.aggregateEvents)
 		// 获取指定对象的 events，支持事件聚合
 		kubeRoute.GET("/clusters/:cluster/api/v1/events", cr.getEventList)
+		// 获取命名空间或者指定对象的事件，支持按 type 和 reason 过滤
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/events", cr.listNamespaceEvents)
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/events/:kind/:name", cr.listObjectEvents)
+		// 获取 deployment 的变更时间线，合并 spec 变更，滚动发布和事件
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/deployments/:name/timeline", cr.getDeploymentTimeline)
+		// 修改 deployment 容器的环境变量，envFrom 引用以及挂载
+		kubeRoute.PUT("/clusters/:cluster/namespaces/:namespace/deployments/:name/containers/:container/env", cr.setDeploymentEnv)
+		kubeRoute.DELETE("/clusters/:cluster/namespaces/:namespace/deployments/:name/containers/:container/env/:env", cr.removeDeploymentEnv)
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/deployments/:name/containers/:container/envfrom", cr.addDeploymentEnvFrom)
+		kubeRoute.DELETE("/clusters/:cluster/namespaces/:namespace/deployments/:name/containers/:container/envfrom/:kind/:ref", cr.removeDeploymentEnvFrom)
+		kubeRoute.PUT("/clusters/:cluster/namespaces/:namespace/deployments/:name/containers/:container/volumemounts", cr.setDeploymentVolumeMount)
+		kubeRoute.DELETE("/clusters/:cluster/namespaces/:namespace/deployments/:name/containers/:container/volumemounts", cr.removeDeploymentVolumeMount)
+		// deployment 的滚动重启，发布历史以及回滚，等同于 kubectl rollout restart/history/undo
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/deployments/:name/rollout/restart", cr.restartDeployment)
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/deployments/:name/rollout/history", cr.getDeploymentRolloutHistory)
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/deployments/:name/rollout/undo", cr.undoDeployment)
+		// 由 CRD 的 schema 生成自定义资源的表单描述
+		kubeRoute.GET("/clusters/:cluster/crds/:name/form", cr.getCRDForm)
+		// 获取节点的 GPU 分配情况以及使用 GPU 的 pod
+		kubeRoute.GET("/clusters/:cluster/gpus/nodes", cr.listGPUNodes)
+		kubeRoute.GET("/clusters/:cluster/gpus/pods", cr.listGPUPods)
+		// 节点池，支持批量设置标签和污点以及滚动驱逐
+		kubeRoute.GET("/clusters/:cluster/nodepools", cr.listNodePools)
+		kubeRoute.GET("/clusters/:cluster/nodepools/:pool", cr.getNodePool)
+		kubeRoute.PUT("/clusters/:cluster/nodepools/:pool/labels", cr.updateNodePoolLabels)
+		kubeRoute.PUT("/clusters/:cluster/nodepools/:pool/taints", cr.updateNodePoolTaints)
+		kubeRoute.POST("/clusters/:cluster/nodepools/:pool/drain", cr.drainNodePool)
+		// 升级或者排空节点前的集群巡检
+		kubeRoute.POST("/clusters/:cluster/prechecks", cr.runPrecheck)
+		kubeRoute.GET("/clusters/:cluster/prechecks/:precheckId", cr.getPrecheck)
+		kubeRoute.GET("/clusters/:cluster/prechecks", cr.listPrechecks)
+		// 扫描升级前需要迁移的废弃 API
+		kubeRoute.GET("/clusters/:cluster/deprecations", cr.scanDeprecatedAPIs)
 
 		// pod ws
 		kubeRoute.GET("/ws", cr.webShell)
 		// node ws
 		kubeRoute.GET("/nodes/ws", cr.nodeWebShell)
+		// web kubectl ws，以及会话记录
+		kubeRoute.GET("/clusters/:cluster/kubectl/ws", cr.kubectlShell)
+		kubeRoute.GET("/clusters/:cluster/kubectl/sessions", cr.listKubectlSessions)
+		// 端口转发会话，通过 pixiu 代理 pod 或者 service 的 http 和 tcp 流量
+		kubeRoute.POST("/clusters/:cluster/portforwards", cr.createPortForward)
+		kubeRoute.DELETE("/clusters/:cluster/portforwards/:sessionId", cr.deletePortForward)
+		kubeRoute.GET("/clusters/:cluster/portforwards", cr.listPortForwards)
+		kubeRoute.Any("/clusters/:cluster/portforwards/:sessionId/proxy/*path", cr.proxyPortForward)
+		kubeRoute.GET("/clusters/:cluster/portforwards/:sessionId/tcp", cr.tcpPortForward)
 		// 重启Job action=rerun
 		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/jobs/:name", cr.ReRunJob)
+		// 定时任务，查询使用 indexer 接口，支持手动触发以及暂停和恢复调度
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/cronjobs", cr.createCronJob)
+		kubeRoute.PUT("/clusters/:cluster/namespaces/:namespace/cronjobs/:name", cr.updateCronJob)
+		kubeRoute.DELETE("/clusters/:cluster/namespaces/:namespace/cronjobs/:name", cr.deleteCronJob)
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/cronjobs/:name/trigger", cr.triggerCronJob)
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/cronjobs/:name/suspend", cr.suspendCronJob)
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/cronjobs/:name/resume", cr.resumeCronJob)
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/cronjobs/:name/jobs", cr.listCronJobRuns)
+		// service 的增删改查，获取详情时返回后端的就绪情况
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/services", cr.createService)
+		kubeRoute.PUT("/clusters/:cluster/namespaces/:namespace/services/:name", cr.updateService)
+		kubeRoute.DELETE("/clusters/:cluster/namespaces/:namespace/services/:name", cr.deleteService)
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/services/:name", cr.getService)
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/services", cr.listServices)
+		// 存储类，pv 以及 pvc 的管理，pvc 详情返回绑定的 pv 和使用量，仅允许扩容的存储类支持 resize
+		kubeRoute.GET("/clusters/:cluster/storageclasses", cr.listStorageClasses)
+		kubeRoute.GET("/clusters/:cluster/persistentvolumes/:name", cr.getPersistentVolume)
+		kubeRoute.GET("/clusters/:cluster/persistentvolumes", cr.listPersistentVolumes)
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/persistentvolumeclaims", cr.createPersistentVolumeClaim)
+		kubeRoute.PUT("/clusters/:cluster/namespaces/:namespace/persistentvolumeclaims/:name", cr.updatePersistentVolumeClaim)
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/persistentvolumeclaims/:name/resize", cr.resizePersistentVolumeClaim)
+		kubeRoute.DELETE("/clusters/:cluster/namespaces/:namespace/persistentvolumeclaims/:name", cr.deletePersistentVolumeClaim)
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/persistentvolumeclaims/:name", cr.getPersistentVolumeClaim)
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/persistentvolumeclaims", cr.listPersistentVolumeClaims)
+		// secret 默认隐藏明文，reveal=true 时需要具备集群的 secrets 读权限
+		kubeRoute.POST("/clusters/:cluster/namespaces/:namespace/secrets", cr.createSecret)
+		kubeRoute.PUT("/clusters/:cluster/namespaces/:namespace/secrets/:name", cr.updateSecret)
+		kubeRoute.DELETE("/clusters/:cluster/namespaces/:namespace/secrets/:name", cr.deleteSecret)
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/secrets/:name", cr.getSecret)
+		kubeRoute.GET("/clusters/:cluster/namespaces/:namespace/secrets", cr.listSecrets)
+		// 提交 manifest，等同于 kubectl apply --server-side，支持 dry-run 预览
+		kubeRoute.POST("/clusters/:cluster/apply", cr.applyManifest)
+
+		// 对外暴露对象的 DNS 解析记录
+		kubeRoute.POST("/clusters/:cluster/dns/records", cr.publishDNSRecord)
+		kubeRoute.DELETE("/clusters/:cluster/dns/records/:recordId", cr.unpublishDNSRecord)
+		kubeRoute.GET("/clusters/:cluster/dns/records/:recordId", cr.getDNSRecord)
+		kubeRoute.GET("/clusters/:cluster/dns/records", cr.listDNSRecords)
+
+		// 工作负载回收站，通过 pixiu 删除的工作负载在保留期内可以恢复
+		kubeRoute.POST("/clusters/:cluster/recycles/:recycleId/restore", cr.restoreRecycledObject)
+		kubeRoute.DELETE("/clusters/:cluster/recycles/:recycleId", cr.deleteRecycledObject)
+		kubeRoute.GET("/clusters/:cluster/recycles/:recycleId", cr.getRecycledObject)
+		kubeRoute.GET("/clusters/:cluster/recycles", cr.listRecycledObjects)
+
+		// Argo CD Application，namespace 为 Argo CD 所在的命名空间
+		kubeRoute.POST("/clusters/:cluster/argocd/namespaces/:namespace/applications", cr.createArgoApplication)
+		kubeRoute.DELETE("/clusters/:cluster/argocd/namespaces/:namespace/applications/:name", cr.deleteArgoApplication)
+		kubeRoute.GET("/clusters/:cluster/argocd/namespaces/:namespace/applications/:name", cr.getArgoApplication)
+		kubeRoute.GET("/clusters/:cluster/argocd/namespaces/:namespace/applications", cr.listArgoApplications)
+		// 触发 Application 同步
+		kubeRoute.POST("/clusters/:cluster/argocd/namespaces/:namespace/applications/:name/sync", cr.syncArgoApplication)
+
+		// KubeVirt 虚拟机
+		kubeRoute.GET("/clusters/:cluster/kubevirt/namespaces/:namespace/virtualmachines/:name", cr.getVirtualMachine)
+		kubeRoute.GET("/clusters/:cluster/kubevirt/namespaces/:namespace/virtualmachines", cr.listVirtualMachines)
+		kubeRoute.POST("/clusters/:cluster/kubevirt/namespaces/:namespace/virtualmachines/:name/start", cr.startVirtualMachine)
+		kubeRoute.POST("/clusters/:cluster/kubevirt/namespaces/:namespace/virtualmachines/:name/stop", cr.stopVirtualMachine)
+		kubeRoute.POST("/clusters/:cluster/kubevirt/namespaces/:namespace/virtualmachines/:name/restart", cr.restartVirtualMachine)
+		// 虚拟机控制台 ws，type=vnc 或者 serial
+		kubeRoute.GET("/clusters/:cluster/kubevirt/namespaces/:namespace/virtualmachines/:name/console", cr.virtualMachineConsole)
+
+		// Karmada 成员集群和分发策略，cluster 为 karmada-apiserver
+		kubeRoute.POST("/clusters/:cluster/karmada/members", cr.registerKarmadaMember)
+		kubeRoute.DELETE("/clusters/:cluster/karmada/members/:name", cr.unregisterKarmadaMember)
+		kubeRoute.GET("/clusters/:cluster/karmada/members", cr.listKarmadaMembers)
+		kubeRoute.GET("/clusters/:cluster/karmada/clusterpropagationpolicies", cr.listKarmadaClusterPolicies)
+		kubeRoute.GET("/clusters/:cluster/karmada/namespaces/:namespace/propagationpolicies", cr.listKarmadaPolicies)
+		// 资源在成员集群中的分发结果
+		kubeRoute.GET("/clusters/:cluster/karmada/namespaces/:namespace/resourcebindings", cr.listKarmadaBindings)
+
+		// deployment 定时伸缩计划
+		kubeRoute.POST("/clusters/:cluster/scaling/schedules", cr.createScalingSchedule)
+		kubeRoute.PUT("/clusters/:cluster/scaling/schedules/:scheduleId", cr.updateScalingSchedule)
+		kubeRoute.DELETE("/clusters/:cluster/scaling/schedules/:scheduleId", cr.deleteScalingSchedule)
+		kubeRoute.GET("/clusters/:cluster/scaling/schedules/:scheduleId", cr.getScalingSchedule)
+		kubeRoute.GET("/clusters/:cluster/scaling/schedules", cr.listScalingSchedules)
+		// 伸缩计划的执行记录
+		kubeRoute.GET("/clusters/:cluster/scaling/schedules/:scheduleId/histories", cr.listScalingHistories)
+
+		// 集群定期巡检
+		kubeRoute.POST("/clusters/:cluster/inspections", cr.createInspection)
+		kubeRoute.PUT("/clusters/:cluster/inspections/:inspectionId", cr.updateInspection)
+		kubeRoute.DELETE("/clusters/:cluster/inspections/:inspectionId", cr.deleteInspection)
+		kubeRoute.GET("/clusters/:cluster/inspections/:inspectionId", cr.getInspection)
+		kubeRoute.GET("/clusters/:cluster/inspections", cr.listInspections)
+		// 立即执行巡检
+		kubeRoute.POST("/clusters/:cluster/inspections/:inspectionId/run", cr.runInspection)
+		// 巡检报告
+		kubeRoute.GET("/clusters/:cluster/inspections/:inspectionId/reports", cr.listInspectionReports)
+		kubeRoute.GET("/clusters/:cluster/inspections/:inspectionId/reports/:reportId", cr.getInspectionReport)
+
+		// 临时命名空间的过期清理策略
+		kubeRoute.POST("/clusters/:cluster/cleanup/namespaces/:namespace", cr.createNamespacePolicy)
+		kubeRoute.DELETE("/clusters/:cluster/cleanup/namespaces/:namespace", cr.deleteNamespacePolicy)
+		kubeRoute.GET("/clusters/:cluster/cleanup/namespaces/:namespace", cr.getNamespacePolicy)
+		kubeRoute.GET("/clusters/:cluster/cleanup/namespaces", cr.listNamespacePolicies)
+		// 延长过期时间
+		kubeRoute.POST("/clusters/:cluster/cleanup/namespaces/:namespace/extend", cr.extendNamespacePolicy)
 	}
 
 	// 从 pixiu 缓存中获取 kubernetes 对象
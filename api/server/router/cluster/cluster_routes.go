@@ -17,6 +17,11 @@ limitations under the License.
 package cluster
 
 import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/caoyingjunz/pixiu/api/server/httputils"
@@ -76,15 +81,10 @@ func (cr *clusterRouter) updateCluster(c *gin.Context) {
 	r := httputils.NewResponse()
 	var (
 		idMeta IdMeta
+		req    types.UpdateClusterRequest
 		err    error
 	)
-	if err = c.ShouldBindUri(&idMeta); err != nil {
-		httputils.SetFailed(c, r, err)
-		return
-	}
-
-	var req types.UpdateClusterRequest
-	if err = c.ShouldBindJSON(&req); err != nil {
+	if err = httputils.ShouldBindAny(c, &req, &idMeta, nil); err != nil {
 		httputils.SetFailed(c, r, err)
 		return
 	}
@@ -189,6 +189,63 @@ func (cr *clusterRouter) listClusters(c *gin.Context) {
 	httputils.SetSuccess(c, r)
 }
 
+// ListExposures godoc
+//
+//	@Summary      List exposures
+//	@Description  List all externally exposed entry points (LoadBalancer, NodePort, ExternalIP, Ingress) across clusters
+//	@Tags         Clusters
+//	@Accept       json
+//	@Produce      json
+//	@Param        fleet  query     string  false  "Fleet name"
+//	@Success      200  {array}   httputils.Response{result=[]types.Exposure}
+//	@Failure      400  {object}  httputils.Response
+//	@Failure      500  {object}  httputils.Response
+//	@Router       /pixiu/clusters/exposures [get]
+//	@Security     Bearer
+func (cr *clusterRouter) listExposures(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt types.FleetOptions
+		err error
+	)
+	if err = c.ShouldBindQuery(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().ListExposures(c, opt.Fleet); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) searchEvents(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.SearchEventOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if httputils.WantsNDJSON(c) {
+		httputils.StreamNDJSON(c, func(emit func(v interface{}) error) error {
+			return cr.c.Cluster().StreamEvents(c, &opts, func(event types.ClusterEvent) error { return emit(event) })
+		})
+		return
+	}
+	if r.Result, err = cr.c.Cluster().SearchEvents(c, &opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
 // PingCluster godoc
 //
 //	@Summary      Ping cluster
@@ -214,7 +271,7 @@ func (cr *clusterRouter) pingCluster(c *gin.Context) {
 		httputils.SetFailed(c, r, err)
 		return
 	}
-	if err = cr.c.Cluster().Ping(c, cluster.KubeConfig); err != nil {
+	if err = cr.c.Cluster().Ping(c, cluster.KubeConfig, cluster.Proxy); err != nil {
 		httputils.SetFailed(c, r, err)
 		return
 	}
@@ -222,6 +279,66 @@ func (cr *clusterRouter) pingCluster(c *gin.Context) {
 	httputils.SetSuccess(c, r)
 }
 
+func (cr *clusterRouter) createClusterBootstrap(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.CreateClusterBootstrapRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	// 未指定时 agent 使用当前请求的地址回调
+	if len(req.PixiuURL) == 0 {
+		scheme := "http"
+		if c.Request.TLS != nil {
+			scheme = "https"
+		}
+		if proto := c.GetHeader("X-Forwarded-Proto"); len(proto) != 0 {
+			scheme = proto
+		}
+		req.PixiuURL = scheme + "://" + c.Request.Host
+	}
+	if r.Result, err = cr.c.Cluster().CreateBootstrap(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) registerCluster(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.RegisterClusterRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().Register(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+// serveClusterTunnel agent 使用引导 token 建立反向隧道，连接在 agent 断开前不会返回
+func (cr *clusterRouter) serveClusterTunnel(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if err := cr.c.Cluster().ServeTunnel(c, token, c.Writer, c.Request); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+}
+
 func (cr *clusterRouter) protectCluster(c *gin.Context) {
 	r := httputils.NewResponse()
 	var (
@@ -286,6 +403,55 @@ func (cr *clusterRouter) getEventList(c *gin.Context) {
 	httputils.SetSuccess(c, r)
 }
 
+func (cr *clusterRouter) listNamespaceEvents(c *gin.Context) {
+	r := httputils.NewResponse()
+	var (
+		opts struct {
+			Cluster   string `uri:"cluster" binding:"required"`
+			Namespace string `uri:"namespace" binding:"required"`
+		}
+		eventOpt types.EventOptions
+		err      error
+	)
+	if err = httputils.ShouldBindAny(c, nil, &opts, &eventOpt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	eventOpt.Namespace = opts.Namespace
+	if r.Result, err = cr.c.Cluster().GetEventList(c, opts.Cluster, eventOpt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+// listObjectEvents kind 为对象的类型，比如 Pod，Deployment
+func (cr *clusterRouter) listObjectEvents(c *gin.Context) {
+	r := httputils.NewResponse()
+	var (
+		opts struct {
+			Cluster   string `uri:"cluster" binding:"required"`
+			Namespace string `uri:"namespace" binding:"required"`
+			Kind      string `uri:"kind" binding:"required"`
+			Name      string `uri:"name" binding:"required"`
+		}
+		eventOpt types.EventOptions
+		err      error
+	)
+	if err = httputils.ShouldBindAny(c, nil, &opts, &eventOpt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	eventOpt.Namespace, eventOpt.Kind, eventOpt.Name = opts.Namespace, opts.Kind, opts.Name
+	if r.Result, err = cr.c.Cluster().GetEventList(c, opts.Cluster, eventOpt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
 func (cr *clusterRouter) watchPodLog(c *gin.Context) {
 	r := httputils.NewResponse()
 
@@ -308,3 +474,78 @@ func (cr *clusterRouter) watchPodLog(c *gin.Context) {
 		return
 	}
 }
+
+// streamPodLogs 获取 pod 日志，支持 follow，tailLines，sinceSeconds 和 container
+// 请求头 Accept 为 text/event-stream 时以 SSE 的形式返回，否则返回 chunked 文本
+func (cr *clusterRouter) streamPodLogs(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts struct {
+			Cluster   string `uri:"cluster" binding:"required"`
+			Namespace string `uri:"namespace" binding:"required"`
+			Pod       string `uri:"pod" binding:"required"`
+		}
+		logOpt types.PodLogOptions
+		err    error
+	)
+	if err = httputils.ShouldBindAny(c, nil, &opts, &logOpt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	sse := strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+	if err = cr.c.Cluster().StreamPodLogs(c, opts.Cluster, opts.Namespace, opts.Pod, logOpt, sse, c.Writer, c.Request); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+}
+
+func (cr *clusterRouter) downloadSupportBundle(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts struct {
+			Cluster   string `uri:"cluster" binding:"required"`
+			Namespace string `uri:"namespace" binding:"required"`
+		}
+		bundleOpt types.SupportBundleOptions
+		err       error
+	)
+	if err = httputils.ShouldBindAny(c, nil, &opts, &bundleOpt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	data, err := cr.c.Cluster().GenerateSupportBundle(c, opts.Cluster, opts.Namespace, bundleOpt)
+	if err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	parts := []string{opts.Cluster, opts.Namespace}
+	if len(bundleOpt.Name) != 0 {
+		parts = append(parts, bundleOpt.Name)
+	}
+	filename := fmt.Sprintf("%s-%s.tar.gz", strings.Join(parts, "-"), time.Now().Format("20060102150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/gzip", data)
+}
+
+func (cr *clusterRouter) getDeploymentTimeline(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts     types.PixiuObjectMeta
+		pageOpts types.PageRequest
+		err      error
+	)
+	if err = httputils.ShouldBindAny(c, nil, &opts, &pageOpts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().GetDeploymentTimeline(c, opts.Cluster, opts.Namespace, opts.Name, pageOpts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
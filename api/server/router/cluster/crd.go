@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type CRDMeta struct {
+	Cluster string `uri:"cluster" binding:"required"`
+	Name    string `uri:"name" binding:"required"`
+}
+
+func (cr *clusterRouter) getCRDForm(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt  CRDMeta
+		opts types.GetCRDFormOptions
+		err  error
+	)
+	if err = httputils.ShouldBindAny(c, nil, &opt, &opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().GetCRDForm(c, opt.Cluster, opt.Name, opts.Version); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type CronJobMeta struct {
+	Cluster   string `uri:"cluster" binding:"required"`
+	Namespace string `uri:"namespace" binding:"required"`
+	Name      string `uri:"name" binding:"required"`
+}
+
+func (cr *clusterRouter) createCronJob(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt types.PixiuObjectMeta
+		req types.CreateCronJobRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().CreateCronJob(c, opt.Cluster, opt.Namespace, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) updateCronJob(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt CronJobMeta
+		req types.UpdateCronJobRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().UpdateCronJob(c, opt.Cluster, opt.Namespace, opt.Name, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) deleteCronJob(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt CronJobMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	// 删除前保存到回收站，保存失败时不允许删除
+	if err = cr.c.Recycle(opt.Cluster).Snapshot(c, batchv1.SchemeGroupVersion.WithResource("cronjobs"), opt.Namespace, opt.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().DeleteCronJob(c, opt.Cluster, opt.Namespace, opt.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) suspendCronJob(c *gin.Context) {
+	cr.setCronJobSuspend(c, true)
+}
+
+func (cr *clusterRouter) resumeCronJob(c *gin.Context) {
+	cr.setCronJobSuspend(c, false)
+}
+
+func (cr *clusterRouter) setCronJobSuspend(c *gin.Context, suspend bool) {
+	r := httputils.NewResponse()
+
+	var (
+		opt CronJobMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().SuspendCronJob(c, opt.Cluster, opt.Namespace, opt.Name, suspend); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) triggerCronJob(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt CronJobMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().TriggerCronJob(c, opt.Cluster, opt.Namespace, opt.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listCronJobRuns(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt CronJobMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().ListCronJobRuns(c, opt.Cluster, opt.Namespace, opt.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type DeploymentMeta struct {
+	Cluster   string `uri:"cluster" binding:"required"`
+	Namespace string `uri:"namespace" binding:"required"`
+	Name      string `uri:"name" binding:"required"`
+}
+
+type DeploymentContainerMeta struct {
+	Cluster   string `uri:"cluster" binding:"required"`
+	Namespace string `uri:"namespace" binding:"required"`
+	Name      string `uri:"name" binding:"required"`
+	Container string `uri:"container" binding:"required"`
+}
+
+type DeploymentEnvMeta struct {
+	DeploymentContainerMeta
+
+	Env string `uri:"env" binding:"required"`
+}
+
+type DeploymentEnvFromMeta struct {
+	DeploymentContainerMeta
+
+	Kind string `uri:"kind" binding:"required,oneof=configmap secret"`
+	Ref  string `uri:"ref" binding:"required"`
+}
+
+func (cr *clusterRouter) setDeploymentEnv(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt DeploymentContainerMeta
+		req types.SetDeploymentEnvRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().SetDeploymentEnv(c, opt.Cluster, opt.Namespace, opt.Name, opt.Container, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) removeDeploymentEnv(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt DeploymentEnvMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().RemoveDeploymentEnv(c, opt.Cluster, opt.Namespace, opt.Name, opt.Container, opt.Env); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) addDeploymentEnvFrom(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt DeploymentContainerMeta
+		req types.AddDeploymentEnvFromRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().AddDeploymentEnvFrom(c, opt.Cluster, opt.Namespace, opt.Name, opt.Container, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) removeDeploymentEnvFrom(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt DeploymentEnvFromMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().RemoveDeploymentEnvFrom(c, opt.Cluster, opt.Namespace, opt.Name, opt.Container, opt.Kind, opt.Ref); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) setDeploymentVolumeMount(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt DeploymentContainerMeta
+		req types.SetDeploymentVolumeMountRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().SetDeploymentVolumeMount(c, opt.Cluster, opt.Namespace, opt.Name, opt.Container, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) removeDeploymentVolumeMount(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt  DeploymentContainerMeta
+		opts types.RemoveDeploymentVolumeMountOptions
+		err  error
+	)
+	if err = httputils.ShouldBindAny(c, nil, &opt, &opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().RemoveDeploymentVolumeMount(c, opt.Cluster, opt.Namespace, opt.Name, opt.Container, opts.MountPath); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) restartDeployment(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt DeploymentMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().RestartDeployment(c, opt.Cluster, opt.Namespace, opt.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) getDeploymentRolloutHistory(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt DeploymentMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().GetDeploymentRolloutHistory(c, opt.Cluster, opt.Namespace, opt.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) undoDeployment(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt DeploymentMeta
+		req types.UndoDeploymentRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().UndoDeployment(c, opt.Cluster, opt.Namespace, opt.Name, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
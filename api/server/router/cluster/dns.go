@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type ClusterNameMeta struct {
+	Cluster string `uri:"cluster" binding:"required"`
+}
+
+type DNSRecordMeta struct {
+	Cluster  string `uri:"cluster" binding:"required"`
+	RecordId int64  `uri:"recordId" binding:"required"`
+}
+
+func (cr *clusterRouter) publishDNSRecord(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt ClusterNameMeta
+		req types.PublishDNSRecordRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.DNS(opt.Cluster).Publish(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) unpublishDNSRecord(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt DNSRecordMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.DNS(opt.Cluster).Unpublish(c, opt.RecordId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) getDNSRecord(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt DNSRecordMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.DNS(opt.Cluster).Get(c, opt.RecordId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listDNSRecords(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt ClusterNameMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.DNS(opt.Cluster).List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
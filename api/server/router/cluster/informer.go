@@ -65,6 +65,11 @@ func (cr *clusterRouter) listIndexerResources(c *gin.Context) {
 		httputils.SetFailed(c, r, err)
 		return
 	}
+	// 对象已在 informer 缓存中，逐行返回以减少客户端的内存占用
+	if httputils.WantsNDJSON(c) {
+		httputils.WriteNDJSON(c, r.Result)
+		return
+	}
 
 	httputils.SetSuccess(c, r)
 }
@@ -0,0 +1,189 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type InspectionMeta struct {
+	Cluster      string `uri:"cluster" binding:"required"`
+	InspectionId int64  `uri:"inspectionId" binding:"required"`
+}
+
+type InspectionReportMeta struct {
+	InspectionMeta `json:",inline"`
+
+	ReportId int64 `uri:"reportId" binding:"required"`
+}
+
+func (cr *clusterRouter) createInspection(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt ClusterNameMeta
+		req types.CreateInspectionRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Inspection(opt.Cluster).Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) updateInspection(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt InspectionMeta
+		req types.UpdateInspectionRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Inspection(opt.Cluster).Update(c, opt.InspectionId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) deleteInspection(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt InspectionMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Inspection(opt.Cluster).Delete(c, opt.InspectionId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) getInspection(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt InspectionMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Inspection(opt.Cluster).Get(c, opt.InspectionId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listInspections(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt ClusterNameMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Inspection(opt.Cluster).List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) runInspection(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt InspectionMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Inspection(opt.Cluster).Run(c, opt.InspectionId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listInspectionReports(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt InspectionMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Inspection(opt.Cluster).ListReports(c, opt.InspectionId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) getInspectionReport(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt InspectionReportMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Inspection(opt.Cluster).GetReport(c, opt.InspectionId, opt.ReportId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+// karmadaMeta 不区分命名空间的 Karmada 对象
+type karmadaMeta struct {
+	Cluster string `uri:"cluster" binding:"required"`
+	Name    string `uri:"name"`
+}
+
+func (cr *clusterRouter) registerKarmadaMember(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts karmadaMeta
+		req  types.RegisterKarmadaMemberRequest
+		err  error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opts, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Karmada(opts.Cluster).RegisterMember(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) unregisterKarmadaMember(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts karmadaMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Karmada(opts.Cluster).UnregisterMember(c, opts.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listKarmadaMembers(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts karmadaMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Karmada(opts.Cluster).ListMembers(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listKarmadaClusterPolicies(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts karmadaMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Karmada(opts.Cluster).ListPolicies(c, ""); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listKarmadaPolicies(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Karmada(opts.Cluster).ListPolicies(c, opts.Namespace); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listKarmadaBindings(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Karmada(opts.Cluster).ListBindings(c, opts.Namespace); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+func (cr *clusterRouter) getVirtualMachine(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.KubeVirt(opts.Cluster).Get(c, opts.Namespace, opts.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listVirtualMachines(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.KubeVirt(opts.Cluster).List(c, opts.Namespace); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) startVirtualMachine(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.KubeVirt(opts.Cluster).Start(c, opts.Namespace, opts.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) stopVirtualMachine(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.KubeVirt(opts.Cluster).Stop(c, opts.Namespace, opts.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) restartVirtualMachine(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		err  error
+	)
+	if err = c.ShouldBindUri(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.KubeVirt(opts.Cluster).Restart(c, opts.Namespace, opts.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+// virtualMachineConsole 通过 websocket 连接虚拟机的 vnc 或者串口控制台
+func (cr *clusterRouter) virtualMachineConsole(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.PixiuObjectMeta
+		opt  types.VirtualMachineConsoleOptions
+		err  error
+	)
+	if err = httputils.ShouldBindAny(c, nil, &opts, &opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.KubeVirt(opts.Cluster).Console(c, opts.Namespace, opts.Name, &opt, c.Writer, c.Request); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+}
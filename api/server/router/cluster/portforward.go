@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	sessionutil "github.com/caoyingjunz/pixiu/pkg/util/session"
+)
+
+type PortForwardMeta struct {
+	Cluster   string `uri:"cluster" binding:"required"`
+	SessionId string `uri:"sessionId" binding:"required"`
+}
+
+func (cr *clusterRouter) createPortForward(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt ClusterNameMeta
+		req types.CreatePortForwardRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.PortForward(opt.Cluster).Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) deletePortForward(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt PortForwardMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	// 会话在其他实例时转发到会话所在的实例
+	if sessionutil.HandOff(c.Writer, c.Request, opt.SessionId) {
+		return
+	}
+	if err = cr.c.PortForward(opt.Cluster).Delete(c, opt.SessionId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listPortForwards(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt ClusterNameMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.PortForward(opt.Cluster).List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+// proxyPortForward 转发 http 请求，后端的响应原样返回
+func (cr *clusterRouter) proxyPortForward(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt struct {
+			PortForwardMeta `json:",inline"`
+			Path            string `uri:"path"`
+		}
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	// 会话在其他实例时转发到会话所在的实例
+	if sessionutil.HandOff(c.Writer, c.Request, opt.SessionId) {
+		return
+	}
+	if err = cr.c.PortForward(opt.Cluster).ProxyHTTP(c, opt.SessionId, opt.Path, c.Writer, c.Request); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+}
+
+// tcpPortForward 通过 websocket 转发 tcp 流量
+func (cr *clusterRouter) tcpPortForward(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt PortForwardMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	// 会话在其他实例时转发到会话所在的实例
+	if sessionutil.HandOff(c.Writer, c.Request, opt.SessionId) {
+		return
+	}
+	if err = cr.c.PortForward(opt.Cluster).ProxyTCP(c, opt.SessionId, c.Writer, c.Request); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+}
@@ -46,3 +46,23 @@ func (cr *clusterRouter) ReRunJob(c *gin.Context) {
 
 	httputils.SetSuccess(c, r)
 }
+
+func (cr *clusterRouter) applyManifest(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt ClusterNameMeta
+		req types.ApplyManifestRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().Apply(c, opt.Cluster, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
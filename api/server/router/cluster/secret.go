@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type SecretMeta struct {
+	Cluster   string `uri:"cluster" binding:"required"`
+	Namespace string `uri:"namespace" binding:"required"`
+	Name      string `uri:"name" binding:"required"`
+}
+
+func (cr *clusterRouter) createSecret(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt types.PixiuObjectMeta
+		req types.CreateSecretRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().CreateSecret(c, opt.Cluster, opt.Namespace, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) updateSecret(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt SecretMeta
+		req types.UpdateSecretRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().UpdateSecret(c, opt.Cluster, opt.Namespace, opt.Name, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) deleteSecret(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt SecretMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = cr.c.Cluster().DeleteSecret(c, opt.Cluster, opt.Namespace, opt.Name); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) getSecret(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt  SecretMeta
+		opts types.SecretOptions
+		err  error
+	)
+	if err = httputils.ShouldBindAny(c, nil, &opt, &opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().GetSecret(c, opt.Cluster, opt.Namespace, opt.Name, opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (cr *clusterRouter) listSecrets(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt  types.PixiuObjectMeta
+		opts types.SecretOptions
+		err  error
+	)
+	if err = httputils.ShouldBindAny(c, nil, &opt, &opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = cr.c.Cluster().ListSecrets(c, opt.Cluster, opt.Namespace, opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+// debugRouter 服务自身的调试接口，仅管理员可以访问
+type debugRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &debugRouter{
+		c: o.Controller,
+	}
+	// 开启管理端口时，调试接口仅在管理端口提供
+	engine := o.HttpEngine
+	if o.AdminEngine != nil {
+		engine = o.AdminEngine
+	}
+	router.initRoutes(engine)
+}
+
+func (d *debugRouter) initRoutes(httpEngine *gin.Engine) {
+	debugRoute := httpEngine.Group("/pixiu/debug")
+	{
+		// 查询服务最近的日志
+		debugRoute.GET("/logs", d.listLogs)
+
+		// 运行时调整日志级别，无需重启服务
+		debugRoute.GET("/loglevel", d.getLogLevel)
+		debugRoute.PUT("/loglevel", d.updateLogLevel)
+
+		// 运行时诊断，包括 pprof，expvar 以及 goroutine 和 heap 的快照下载
+		debugRoute.Any("/pprof/*name", d.pprof)
+		debugRoute.GET("/vars", d.vars)
+		debugRoute.GET("/snapshots/:kind", d.downloadSnapshot)
+
+		// 最慢的数据库语句，各表的耗时通过 /vars 的 db_queries 获取
+		debugRoute.GET("/slow-queries", d.listSlowQueries)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+// listLogs 查询服务最近的日志，用于排查接口失败的原因
+func (d *debugRouter) listLogs(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts logutil.QueryOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	buffer := logutil.DefaultBuffer()
+	if buffer == nil {
+		httputils.SetFailed(c, r, errors.ErrLogBufferDisabled)
+		return
+	}
+	if r.Result, err = buffer.Query(opts); err != nil {
+		httputils.SetFailed(c, r, errors.NewError(err, http.StatusBadRequest))
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+type slowQueryOptions struct {
+	Limit int `form:"limit" default:"20" binding:"min=1,max=500"`
+}
+
+// listSlowQueries 按最大耗时倒序获取慢查询，默认返回前 20 条
+func (d *debugRouter) listSlowQueries(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var opts slowQueryOptions
+	if err := httputils.ShouldBind(c, httputils.BindSources{Query: &opts}); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	stats := db.DefaultQueryStats()
+	if stats == nil {
+		httputils.SetFailed(c, r, errors.ErrQueryStatsDisabled)
+		return
+	}
+	r.Result = stats.SlowQueries(opts.Limit)
+
+	httputils.SetSuccess(c, r)
+}
+
+func (d *debugRouter) getLogLevel(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = d.c.Debug().GetLogLevel(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (d *debugRouter) updateLogLevel(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.UpdateLogLevelRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = d.c.Debug().UpdateLogLevel(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+)
+
+func init() {
+	// 已缓存 informer 的集群，用于排查多集群 informer 导致的内存增长
+	expvar.Publish("clusters", expvar.Func(func() interface{} {
+		names := make([]string, 0)
+		for name := range cluster.ClusterIndexer.List() {
+			names = append(names, name)
+		}
+		return names
+	}))
+	expvar.Publish("goroutines", expvar.Func(func() interface{} {
+		return runtime.NumGoroutine()
+	}))
+}
+
+// 支持下载的运行时快照
+var snapshotKinds = map[string]int{
+	"goroutine":    2, // 文本格式的完整堆栈
+	"heap":         0,
+	"allocs":       0,
+	"threadcreate": 0,
+	"block":        0,
+	"mutex":        0,
+}
+
+// pprof 接口挂载在 /pixiu/debug/pprof 下，net/http/pprof 的 Index 仅识别 /debug/pprof 前缀，因此按名称分发
+func (d *debugRouter) pprof(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("name"), "/")
+	switch name {
+	case "":
+		pprof.Index(c.Writer, c.Request)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+func (d *debugRouter) vars(c *gin.Context) {
+	expvar.Handler().ServeHTTP(c.Writer, c.Request)
+}
+
+type SnapshotMeta struct {
+	Kind string `uri:"kind" binding:"required"`
+}
+
+// downloadSnapshot 下载 goroutine 或者 heap 等运行时快照，heap 等为 pprof 格式，可以通过 go tool pprof 分析
+func (d *debugRouter) downloadSnapshot(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt SnapshotMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	level, ok := snapshotKinds[opt.Kind]
+	profile := rpprof.Lookup(opt.Kind)
+	if !ok || profile == nil {
+		httputils.SetFailed(c, r, errors.NewError(fmt.Errorf("unsupported snapshot kind %s", opt.Kind), http.StatusBadRequest))
+		return
+	}
+	if opt.Kind == "heap" {
+		// 获取最新的内存数据
+		runtime.GC()
+	}
+
+	ext := "pb.gz"
+	if level != 0 {
+		ext = "txt"
+	}
+	filename := fmt.Sprintf("pixiu-%s-%s.%s", opt.Kind, time.Now().Format("20060102150405"), ext)
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if err = profile.WriteTo(c.Writer, level); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fleet
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type fleetRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &fleetRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (f *fleetRouter) initRoutes(ginEngine *gin.Engine) {
+	fleetRoute := ginEngine.Group("/pixiu/fleets")
+	{
+		fleetRoute.POST("", f.createFleet)
+		fleetRoute.PUT("/:fleetId", f.updateFleet)
+		fleetRoute.DELETE("/:fleetId", f.deleteFleet)
+		fleetRoute.GET("/:fleetId", f.getFleet)
+		fleetRoute.GET("", f.listFleets)
+
+		// 获取分组当前包含的集群
+		fleetRoute.GET("/:fleetId/clusters", f.listFleetClusters)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fleet
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type fleetMeta struct {
+	FleetId int64 `uri:"fleetId" binding:"required"`
+}
+
+func (f *fleetRouter) createFleet(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var req types.CreateFleetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := f.c.Fleet().Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (f *fleetRouter) updateFleet(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt fleetMeta
+		req types.UpdateFleetRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = f.c.Fleet().Update(c, opt.FleetId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (f *fleetRouter) deleteFleet(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt fleetMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = f.c.Fleet().Delete(c, opt.FleetId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (f *fleetRouter) getFleet(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt fleetMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = f.c.Fleet().Get(c, opt.FleetId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (f *fleetRouter) listFleets(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = f.c.Fleet().List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (f *fleetRouter) listFleetClusters(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt fleetMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = f.c.Fleet().ListClusters(c, opt.FleetId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
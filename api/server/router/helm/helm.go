@@ -53,6 +53,13 @@ func (hr *helmRouter) initRoutes(httpEngine *gin.Engine) {
 		helmRoute.GET("/repositories/charts", hr.getRepoChartsByURL)
 		helmRoute.GET("/repositories/values", hr.getChartValues)
 
+		// helm values 引用的敏感变量
+		helmRoute.POST("/secrets", hr.createSecret)
+		helmRoute.PUT("/secrets/:secretId", hr.updateSecret)
+		helmRoute.DELETE("/secrets/:secretId", hr.deleteSecret)
+		helmRoute.GET("/secrets/:secretId", hr.getSecret)
+		helmRoute.GET("/secrets", hr.listSecrets)
+
 		// Helm Release
 		helmRoute.POST("/clusters/:cluster/namespaces/:namespace/releases", hr.InstallRelease)
 		helmRoute.PUT("/clusters/:cluster/namespaces/:namespace/releases", hr.UpgradeRelease)
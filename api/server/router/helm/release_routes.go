@@ -114,6 +114,7 @@ func (hr *helmRouter) InstallRelease(c *gin.Context) {
 		httputils.SetFailed(c, r, err)
 		return
 	}
+	httputils.SetChangeToContext(c, releaseOpt.ChangeTicket, releaseOpt.ChangeReason)
 
 	if r.Result, err = hr.c.Helm().Release(helmMeta.Cluster, helmMeta.Namespace).Install(c, &releaseOpt); err != nil {
 		httputils.SetFailed(c, r, err)
@@ -183,6 +184,7 @@ func (hr *helmRouter) UpgradeRelease(c *gin.Context) {
 		httputils.SetFailed(c, r, err)
 		return
 	}
+	httputils.SetChangeToContext(c, releaseOpt.ChangeTicket, releaseOpt.ChangeReason)
 
 	if r.Result, err = hr.c.Helm().Release(helmMeta.Cluster, helmMeta.Namespace).Upgrade(c, &releaseOpt); err != nil {
 		httputils.SetFailed(c, r, err)
@@ -160,15 +160,23 @@ func (hr *helmRouter) getRepository(c *gin.Context) {
 // @Tags repositories
 // @Accept json
 // @Produce json
+// @Param cluster query string false "return global repositories and the ones bound to this cluster"
 // @Success 200 {object} httputils.Response{result=[]types.Repository}
 // @Failure 400 {object} httputils.Response
 // @Failure 500 {object} httputils.Response
 // @Router /repositories [get]
 func (hr *helmRouter) listRepositories(c *gin.Context) {
 	r := httputils.NewResponse()
-	var err error
 
-	if r.Result, err = hr.c.Helm().Repository().List(c); err != nil {
+	var (
+		opts types.ListRepositoryOptions
+		err  error
+	)
+	if err = httputils.ShouldBindAny(c, nil, nil, &opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = hr.c.Helm().Repository().List(c, opts); err != nil {
 		httputils.SetFailed(c, r, err)
 		return
 	}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+func (hr *helmRouter) createSecret(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		err error
+		req types.CreateHelmSecretRequest
+	)
+	if err = httputils.ShouldBindAny(c, &req, nil, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = hr.c.Helm().Secret().Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (hr *helmRouter) updateSecret(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		err error
+		opt types.HelmSecretMeta
+		req types.UpdateHelmSecretRequest
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = hr.c.Helm().Secret().Update(c, opt.Id, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (hr *helmRouter) deleteSecret(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		err error
+		opt types.HelmSecretMeta
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = hr.c.Helm().Secret().Delete(c, opt.Id); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (hr *helmRouter) getSecret(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		err error
+		opt types.HelmSecretMeta
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = hr.c.Helm().Secret().Get(c, opt.Id); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (hr *helmRouter) listSecrets(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = hr.c.Helm().Secret().List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
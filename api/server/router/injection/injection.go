@@ -0,0 +1,56 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package injection
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type injectionRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &injectionRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (i *injectionRouter) initRoutes(ginEngine *gin.Engine) {
+	templateRoute := ginEngine.Group("/pixiu/sidecar-templates")
+	{
+		templateRoute.POST("", i.createSidecarTemplate)
+		templateRoute.PUT("/:templateId", i.updateSidecarTemplate)
+		templateRoute.DELETE("/:templateId", i.deleteSidecarTemplate)
+		templateRoute.GET("/:templateId", i.getSidecarTemplate)
+		templateRoute.GET("", i.listSidecarTemplates)
+	}
+
+	injectionRoute := ginEngine.Group("/pixiu/injections")
+	{
+		injectionRoute.POST("", i.injectSidecar)
+		// 预览注入前后 pod template 的差异
+		injectionRoute.POST("/preview", i.previewInjection)
+		injectionRoute.GET("/:injectionId", i.getInjection)
+		injectionRoute.GET("", i.listInjections)
+		injectionRoute.POST("/:injectionId/rollback", i.rollbackInjection)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package injection
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type templateMeta struct {
+	TemplateId int64 `uri:"templateId" binding:"required"`
+}
+
+type injectionMeta struct {
+	InjectionId int64 `uri:"injectionId" binding:"required"`
+}
+
+func (i *injectionRouter) createSidecarTemplate(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var req types.CreateSidecarTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := i.c.Injection().CreateTemplate(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (i *injectionRouter) updateSidecarTemplate(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt templateMeta
+		req types.UpdateSidecarTemplateRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = i.c.Injection().UpdateTemplate(c, opt.TemplateId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (i *injectionRouter) deleteSidecarTemplate(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt templateMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = i.c.Injection().DeleteTemplate(c, opt.TemplateId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (i *injectionRouter) getSidecarTemplate(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt templateMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = i.c.Injection().GetTemplate(c, opt.TemplateId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (i *injectionRouter) listSidecarTemplates(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = i.c.Injection().ListTemplates(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (i *injectionRouter) injectSidecar(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.InjectSidecarRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = i.c.Injection().Inject(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (i *injectionRouter) previewInjection(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.InjectSidecarRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = i.c.Injection().Preview(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (i *injectionRouter) getInjection(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt injectionMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = i.c.Injection().Get(c, opt.InjectionId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (i *injectionRouter) listInjections(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.ListInjectionOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = i.c.Injection().List(c, opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (i *injectionRouter) rollbackInjection(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt injectionMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = i.c.Injection().Rollback(c, opt.InjectionId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type kubeConfigRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &kubeConfigRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (k *kubeConfigRouter) initRoutes(ginEngine *gin.Engine) {
+	// 管理员签发和管理全部用户的 kubeconfig
+	kubeConfigRoute := ginEngine.Group("/pixiu/kubeconfigs")
+	{
+		kubeConfigRoute.POST("", k.createKubeConfig)
+		// 批量签发，返回每个 kubeconfig 的签发结果
+		kubeConfigRoute.POST("/batch", k.batchCreateKubeConfigs)
+		kubeConfigRoute.DELETE("/:kubeconfigId", k.deleteKubeConfig)
+		kubeConfigRoute.GET("/:kubeconfigId", k.getKubeConfig)
+		kubeConfigRoute.GET("", k.listKubeConfigs)
+		// 以文件的形式下载 kubeconfig
+		kubeConfigRoute.GET("/:kubeconfigId/download", k.downloadKubeConfig)
+		// 吊销 kubeconfig，已签发的 token 全部失效
+		kubeConfigRoute.POST("/:kubeconfigId/revoke", k.revokeKubeConfig)
+
+		// 集群中未清理干净的 ServiceAccount 和绑定关系
+		kubeConfigRoute.GET("/orphans", k.listOrphans)
+		kubeConfigRoute.DELETE("/orphans", k.purgeOrphans)
+
+		// 签发时可以绑定的 ClusterRole 和 Role
+		kubeConfigRoute.GET("/roles", k.listRoles)
+
+		// 自助签发的限制
+		kubeConfigRoute.GET("/policy", k.getPolicy)
+		kubeConfigRoute.PUT("/policy", k.updatePolicy)
+	}
+
+	// 用户自助签发和管理自己的 kubeconfig
+	selfRoute := ginEngine.Group("/pixiu/users/me/kubeconfigs")
+	{
+		selfRoute.POST("", k.issueKubeConfig)
+		selfRoute.DELETE("/:kubeconfigId", k.deleteMyKubeConfig)
+		selfRoute.GET("", k.listMyKubeConfigs)
+		selfRoute.GET("/:kubeconfigId/download", k.downloadMyKubeConfig)
+		// 下载合并后的 kubeconfig，通过 kubectl config use-context 切换集群
+		selfRoute.GET("/merged", k.downloadMergedKubeConfig)
+		selfRoute.GET("/policy", k.getPolicy)
+		selfRoute.GET("/roles", k.listMyRoles)
+	}
+}
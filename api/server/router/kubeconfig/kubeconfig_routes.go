@@ -0,0 +1,355 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type kubeConfigMeta struct {
+	KubeConfigId int64 `uri:"kubeconfigId" binding:"required"`
+}
+
+func (k *kubeConfigRouter) createKubeConfig(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.CreateKubeConfigRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = k.c.KubeConfig().Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) batchCreateKubeConfigs(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.BatchCreateKubeConfigRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = k.c.KubeConfig().BatchCreate(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) deleteKubeConfig(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var opt kubeConfigMeta
+	if err := c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := k.c.KubeConfig().Delete(c, opt.KubeConfigId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) revokeKubeConfig(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var opt kubeConfigMeta
+	if err := c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := k.c.KubeConfig().Revoke(c, opt.KubeConfigId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) getKubeConfig(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt kubeConfigMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = k.c.KubeConfig().Get(c, opt.KubeConfigId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) listKubeConfigs(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.ListKubeConfigOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = k.c.KubeConfig().List(c, opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+type orphanOptions struct {
+	Cluster string `form:"cluster" binding:"required"`
+}
+
+func (k *kubeConfigRouter) listOrphans(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts orphanOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = k.c.KubeConfig().ListOrphans(c, opts.Cluster); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) purgeOrphans(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts orphanOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = k.c.KubeConfig().PurgeOrphans(c, opts.Cluster); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) listRoles(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.ListKubeConfigRolesOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = k.c.KubeConfig().ListRoles(c, opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) listMyRoles(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.ListKubeConfigRolesOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = k.c.KubeConfig().ListMyRoles(c, opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) getPolicy(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = k.c.KubeConfig().GetPolicy(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) updatePolicy(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var req types.UpdateKubeConfigPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := k.c.KubeConfig().UpdatePolicy(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) issueKubeConfig(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.CreateKubeConfigRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = k.c.KubeConfig().Issue(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) deleteMyKubeConfig(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var opt kubeConfigMeta
+	if err := c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := k.c.KubeConfig().DeleteMine(c, opt.KubeConfigId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) listMyKubeConfigs(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.ListKubeConfigOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = k.c.KubeConfig().ListMine(c, opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (k *kubeConfigRouter) downloadKubeConfig(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var opt kubeConfigMeta
+	if err := c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	kc, err := k.c.KubeConfig().Get(c, opt.KubeConfigId)
+	if err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	writeKubeConfig(c, kc)
+}
+
+func (k *kubeConfigRouter) downloadMyKubeConfig(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var opt kubeConfigMeta
+	if err := c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	kc, err := k.c.KubeConfig().GetMine(c, opt.KubeConfigId)
+	if err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	writeKubeConfig(c, kc)
+}
+
+func (k *kubeConfigRouter) downloadMergedKubeConfig(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	data, err := k.c.KubeConfig().GetMerged(c)
+	if err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="pixiu.kubeconfig"`)
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
+
+// writeKubeConfig 以附件的形式返回 kubeconfig，文件名为 集群名-id.kubeconfig
+func writeKubeConfig(c *gin.Context, kc *types.KubeConfig) {
+	filename := fmt.Sprintf("%s-%d.kubeconfig", kc.Cluster, kc.Id)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/octet-stream", []byte(kc.Config))
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package naming
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type namingRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &namingRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (n *namingRouter) initRoutes(ginEngine *gin.Engine) {
+	namingRoute := ginEngine.Group("/pixiu/naming-policies")
+	{
+		namingRoute.POST("", n.createNamingPolicy)
+		namingRoute.PUT("/:policyId", n.updateNamingPolicy)
+		namingRoute.DELETE("/:policyId", n.deleteNamingPolicy)
+		namingRoute.GET("/:policyId", n.getNamingPolicy)
+		namingRoute.GET("", n.listNamingPolicies)
+
+		// 测试名称是否符合命名规范
+		namingRoute.POST("/test", n.testNamingPolicy)
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package naming
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type namingMeta struct {
+	PolicyId int64 `uri:"policyId" binding:"required"`
+}
+
+func (n *namingRouter) createNamingPolicy(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var req types.CreateNamingPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := n.c.NamingPolicy().Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (n *namingRouter) updateNamingPolicy(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt namingMeta
+		req types.UpdateNamingPolicyRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = n.c.NamingPolicy().Update(c, opt.PolicyId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (n *namingRouter) deleteNamingPolicy(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt namingMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = n.c.NamingPolicy().Delete(c, opt.PolicyId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (n *namingRouter) getNamingPolicy(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt namingMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = n.c.NamingPolicy().Get(c, opt.PolicyId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (n *namingRouter) listNamingPolicies(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.ListNamingPolicyOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = n.c.NamingPolicy().List(c, opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (n *namingRouter) testNamingPolicy(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.TestNamingPolicyRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = n.c.NamingPolicy().Test(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
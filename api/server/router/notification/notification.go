@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type notificationRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &notificationRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+// 当前登陆用户的通知，无需额外授权
+func (n *notificationRouter) initRoutes(ginEngine *gin.Engine) {
+	notificationRoute := ginEngine.Group("/pixiu/users/me/notifications")
+	{
+		notificationRoute.GET("", n.listNotifications)
+		notificationRoute.GET("/unread", n.countUnread)
+		notificationRoute.POST("/read", n.markRead)
+		notificationRoute.DELETE("/:notificationId", n.deleteNotification)
+
+		// 邮件和 webhook 渠道设置
+		notificationRoute.GET("/preference", n.getPreference)
+		notificationRoute.PUT("/preference", n.updatePreference)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type notificationMeta struct {
+	NotificationId int64 `uri:"notificationId" binding:"required"`
+}
+
+func (n *notificationRouter) listNotifications(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opts types.ListNotificationOptions
+		err  error
+	)
+	if err = c.ShouldBindQuery(&opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = n.c.Notification().List(c, &opts); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (n *notificationRouter) countUnread(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = n.c.Notification().CountUnread(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (n *notificationRouter) markRead(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var req types.MarkNotificationsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := n.c.Notification().MarkRead(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (n *notificationRouter) deleteNotification(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var opt notificationMeta
+	if err := c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := n.c.Notification().Delete(c, opt.NotificationId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (n *notificationRouter) getPreference(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = n.c.Notification().GetPreference(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (n *notificationRouter) updatePreference(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var req types.UpdateNotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := n.c.Notification().UpdatePreference(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type pipelineRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &pipelineRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (p *pipelineRouter) initRoutes(ginEngine *gin.Engine) {
+	pipelineRoute := ginEngine.Group("/pixiu/pipelines")
+	{
+		pipelineRoute.POST("", p.createPipeline)
+		pipelineRoute.PUT("/:pipelineId", p.updatePipeline)
+		pipelineRoute.DELETE("/:pipelineId", p.deletePipeline)
+		pipelineRoute.GET("/:pipelineId", p.getPipeline)
+		pipelineRoute.GET("", p.listPipelines)
+
+		// 触发流水线
+		pipelineRoute.POST("/:pipelineId/runs", p.triggerPipeline)
+		// 查询流水线运行记录
+		pipelineRoute.GET("/:pipelineId/runs", p.listPipelineRuns)
+		pipelineRoute.GET("/:pipelineId/runs/:runId", p.getPipelineRun)
+	}
+}
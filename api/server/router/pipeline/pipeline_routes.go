@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type pipelineMeta struct {
+	PipelineId int64 `uri:"pipelineId" binding:"required"`
+}
+
+type pipelineRunMeta struct {
+	PipelineId int64 `uri:"pipelineId" binding:"required"`
+	RunId      int64 `uri:"runId" binding:"required"`
+}
+
+func (p *pipelineRouter) createPipeline(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var req types.CreatePipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := p.c.Pipeline().Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (p *pipelineRouter) updatePipeline(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt pipelineMeta
+		req types.UpdatePipelineRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = p.c.Pipeline().Update(c, opt.PipelineId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (p *pipelineRouter) deletePipeline(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt pipelineMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = p.c.Pipeline().Delete(c, opt.PipelineId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (p *pipelineRouter) getPipeline(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt pipelineMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = p.c.Pipeline().Get(c, opt.PipelineId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (p *pipelineRouter) listPipelines(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = p.c.Pipeline().List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+// triggerPipeline 触发流水线，返回本次运行记录
+func (p *pipelineRouter) triggerPipeline(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt pipelineMeta
+		req types.TriggerPipelineRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = p.c.Pipeline().Trigger(c, opt.PipelineId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (p *pipelineRouter) listPipelineRuns(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt pipelineMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = p.c.Pipeline().ListRuns(c, opt.PipelineId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (p *pipelineRouter) getPipelineRun(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt pipelineRunMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = p.c.Pipeline().GetRun(c, opt.PipelineId, opt.RunId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
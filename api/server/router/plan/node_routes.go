@@ -125,3 +125,22 @@ func (t *planRouter) listPlanNodes(c *gin.Context) {
 
 	httputils.SetSuccess(c, r)
 }
+
+func (t *planRouter) retryPlanNode(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt planNodeMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = t.c.Plan().RetryNode(c, opt.PlanId, opt.NodeId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
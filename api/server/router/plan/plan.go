@@ -49,6 +49,8 @@ func (t *planRouter) initRoutes(ginEngine *gin.Engine) {
 		planRoute.POST("/:planId/start", t.startPlan)
 		// 终止部署任务
 		planRoute.POST("/:planId/stop", t.stopPlan)
+		// 从失败的任务继续执行部署
+		planRoute.POST("/:planId/resume", t.resumePlan)
 
 		// 部署计划的节点API
 		planRoute.POST("/:planId/nodes", t.createPlanNode)
@@ -56,6 +58,8 @@ func (t *planRouter) initRoutes(ginEngine *gin.Engine) {
 		planRoute.DELETE("/:planId/nodes/:nodeId", t.deletePlanNode)
 		planRoute.GET("/:planId/nodes/:nodeId", t.getPlanNode)
 		planRoute.GET("/:planId/nodes", t.listPlanNodes)
+		// 对指定节点重试失败的任务
+		planRoute.POST("/:planId/nodes/:nodeId/retry", t.retryPlanNode)
 
 		// 部署计划的部署配置
 		planRoute.POST("/:planId/configs", t.createPlanConfig)
@@ -70,6 +74,14 @@ func (t *planRouter) initRoutes(ginEngine *gin.Engine) {
 		// 实时查询任务进度
 		planRoute.GET("/:planId/tasks/:taskId/logs", t.watchTaskLog)
 
+		// 通过管理集群中的 Cluster API 部署，状态通过 GET capi 获取
+		planRoute.POST("/capi", t.createCAPIPlan)
+		planRoute.PUT("/:planId/capi/scale", t.scaleCAPIPlan)
+		planRoute.DELETE("/:planId/capi", t.deleteCAPIPlan)
+		planRoute.GET("/:planId/capi", t.getCAPIPlan)
+		// 集群创建完成后注册到 pixiu
+		planRoute.POST("/:planId/capi/import", t.importCAPIPlan)
+
 		// 获取 os 与 os version
 		planRoute.GET("/distributions", t.getDistributions)
 	}
@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package propagation
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type propagationRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &propagationRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (p *propagationRouter) initRoutes(ginEngine *gin.Engine) {
+	propagationRoute := ginEngine.Group("/pixiu/propagations")
+	{
+		propagationRoute.POST("", p.createPropagation)
+		propagationRoute.DELETE("/:propagationId", p.deletePropagation)
+		propagationRoute.GET("/:propagationId", p.getPropagation)
+		propagationRoute.GET("", p.listPropagations)
+
+		// 重新分发失败的集群
+		propagationRoute.POST("/:propagationId/retry", p.retryPropagation)
+	}
+}
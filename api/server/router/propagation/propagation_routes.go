@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package propagation
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type propagationMeta struct {
+	PropagationId int64 `uri:"propagationId" binding:"required"`
+}
+
+func (p *propagationRouter) createPropagation(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		req types.CreatePropagationRequest
+		err error
+	)
+	if err = c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = p.c.Propagation().Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (p *propagationRouter) deletePropagation(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt propagationMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = p.c.Propagation().Delete(c, opt.PropagationId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (p *propagationRouter) getPropagation(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt propagationMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = p.c.Propagation().Get(c, opt.PropagationId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (p *propagationRouter) listPropagations(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = p.c.Propagation().List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (p *propagationRouter) retryPropagation(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt propagationMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = p.c.Propagation().Retry(c, opt.PropagationId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
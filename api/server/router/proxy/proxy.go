@@ -17,30 +17,55 @@ limitations under the License.
 package proxy
 
 import (
-	"context"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/proxy"
 	"k8s.io/client-go/rest"
 
 	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
 	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/client"
 	"github.com/caoyingjunz/pixiu/pkg/controller"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
 )
 
 const (
 	proxyBaseURL = "/pixiu/proxy"
 )
 
+var (
+	deploymentsPath = regexp.MustCompile(`^/apis/apps/v1/namespaces/[^/]+/deployments/?$`)
+	// 命名空间级或者集群级资源的集合以及对象，子资源不做校验
+	apiResourcePath = regexp.MustCompile(`^/apis/([^/]+)/([^/]+)/(?:namespaces/[^/]+/)?([^/]+)(?:/[^/]+)?/?$`)
+	// deployment 和 statefulset 的集合，对象以及 scale 子资源，创建，更新和扩容时校验租户配额
+	workloadAdmitPath = regexp.MustCompile(`^/apis/apps/v1/namespaces/([^/]+)/(deployments|statefulsets)(?:/([^/]+)(?:/(scale))?)?/?$`)
+	// 删除前需要保存快照的工作负载
+	workloadPath = regexp.MustCompile(`^/apis/(apps|batch)/(v1)/namespaces/([^/]+)/(deployments|statefulsets|daemonsets|jobs|cronjobs)/([^/]+)/?$`)
+)
+
 type proxyRouter struct {
-	c controller.PixiuInterface
+	cc config.Config
+	c  controller.PixiuInterface
 }
 
 func NewRouter(o *options.Options) {
 	s := &proxyRouter{
-		c: o.Controller,
+		cc: o.ComponentConfig,
+		c:  o.Controller,
 	}
 	s.initRoutes(o.HttpEngine)
 }
@@ -64,11 +89,13 @@ func (p *proxyRouter) proxyHandler(c *gin.Context) {
 	}
 
 	name := cluster.Name
-	config, err := p.c.Cluster().GetKubeConfigByName(context.TODO(), name)
+	config, err := p.c.Cluster().GetUserKubeConfigByName(c, name)
 	if err != nil {
 		httputils.SetFailed(c, resp, fmt.Errorf("failed to get cluster %q kubeconfig", name))
 		return
 	}
+	// 不允许客户端自行指定模拟的用户
+	removeImpersonateHeaders(c.Request.Header)
 
 	transport, err := rest.TransportFor(config)
 	if err != nil {
@@ -80,12 +107,147 @@ func (p *proxyRouter) proxyHandler(c *gin.Context) {
 		httputils.SetFailed(c, resp, err)
 		return
 	}
+	if err = p.validateNaming(c, target.Path); err != nil {
+		httputils.SetFailed(c, resp, err)
+		return
+	}
+	if err = p.validateAPIVersion(c, name, target.Path); err != nil {
+		httputils.SetFailed(c, resp, err)
+		return
+	}
+	if err = p.admitWorkload(c, name, target.Path); err != nil {
+		httputils.SetFailed(c, resp, err)
+		return
+	}
+	if err = p.snapshotWorkload(c, name, target.Path); err != nil {
+		httputils.SetFailed(c, resp, err)
+		return
+	}
 
 	httpProxy := proxy.NewUpgradeAwareHandler(target, transport, false, false, nil)
 	httpProxy.UpgradeTransport = proxy.NewUpgradeRequestRoundTripper(transport, transport)
 	httpProxy.ServeHTTP(c.Writer, c.Request)
 }
 
+// validateNaming 创建 namespace 和 deployment 时校验名称是否符合命名规范
+func (p *proxyRouter) validateNaming(c *gin.Context, path string) error {
+	if c.Request.Method != http.MethodPost || c.Request.Body == nil {
+		return nil
+	}
+	var resourceType string
+	switch {
+	case strings.TrimSuffix(path, "/") == "/api/v1/namespaces":
+		resourceType = model.NamingResourceNamespace
+	case deploymentsPath.MatchString(path):
+		resourceType = model.NamingResourceDeployment
+	default:
+		return nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var object metav1.PartialObjectMetadata
+	// 非 json 格式或者仅指定 generateName 的请求交由 apiserver 处理
+	if err = json.Unmarshal(body, &object); err != nil || len(object.Name) == 0 {
+		return nil
+	}
+	return p.c.NamingPolicy().Validate(c, resourceType, object.Name)
+}
+
+// validateAPIVersion 创建和更新对象时校验集群是否提供请求的 apiVersion，不提供时提示可用的 apiVersion
+// 避免集群升级后使用已移除的 apiVersion 时 apiserver 直接返回 404
+func (p *proxyRouter) validateAPIVersion(c *gin.Context, cluster string, path string) error {
+	if c.Request.Method != http.MethodPost && c.Request.Method != http.MethodPut {
+		return nil
+	}
+	matches := apiResourcePath.FindStringSubmatch(path)
+	if matches == nil {
+		return nil
+	}
+
+	cs, err := p.c.Cluster().GetClusterSetByName(c, cluster)
+	if err != nil {
+		return err
+	}
+	return client.CheckAPIResource(cs.Client.Discovery(), schema.GroupVersion{Group: matches[1], Version: matches[2]}, matches[3])
+}
+
+// admitWorkload 创建，更新，patch 和扩容 deployment 以及 statefulset 时校验租户的资源配额
+// 超出配额时根据配置拒绝请求或者仅返回告警，由 AdmitObject 和 AdmitPatch 统一处理
+func (p *proxyRouter) admitWorkload(c *gin.Context, cluster string, path string) error {
+	method := c.Request.Method
+	if (method != http.MethodPost && method != http.MethodPut && method != http.MethodPatch) || c.Request.Body == nil {
+		return nil
+	}
+	matches := workloadAdmitPath.FindStringSubmatch(path)
+	if matches == nil {
+		return nil
+	}
+	namespace, resource, name, subresource := matches[1], matches[2], matches[3], matches[4]
+	// 集合仅校验创建，对象和 scale 子资源仅校验更新
+	if (len(name) == 0) != (method == http.MethodPost) {
+		return nil
+	}
+	kind := "Deployment"
+	if resource == "statefulsets" {
+		kind = "StatefulSet"
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	switch {
+	case method == http.MethodPatch:
+		patchType := types.PatchType(strings.TrimSpace(strings.Split(c.GetHeader("Content-Type"), ";")[0]))
+		return p.c.Cluster().AdmitPatch(c, cluster, kind, namespace, name, subresource, patchType, body)
+	case len(subresource) != 0:
+		// 更新 scale 子资源时请求体为完整的 scale 对象，按 merge patch 处理
+		return p.c.Cluster().AdmitPatch(c, cluster, kind, namespace, name, subresource, types.MergePatchType, body)
+	}
+
+	object := &unstructured.Unstructured{}
+	// 非 json 格式的请求交由 apiserver 处理
+	if err = json.Unmarshal(body, &object.Object); err != nil {
+		return nil
+	}
+	if len(object.GetKind()) == 0 {
+		object.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind(kind))
+	}
+	if len(name) != 0 {
+		object.SetName(name)
+	}
+	return p.c.Cluster().AdmitObject(c, cluster, namespace, object)
+}
+
+// snapshotWorkload 删除工作负载前保存到回收站，保存失败时不允许删除
+func (p *proxyRouter) snapshotWorkload(c *gin.Context, cluster string, path string) error {
+	if c.Request.Method != http.MethodDelete {
+		return nil
+	}
+	matches := workloadPath.FindStringSubmatch(path)
+	if matches == nil {
+		return nil
+	}
+
+	gvr := schema.GroupVersionResource{Group: matches[1], Version: matches[2], Resource: matches[4]}
+	return p.c.Recycle(cluster).Snapshot(c, gvr, matches[3], matches[5])
+}
+
+func removeImpersonateHeaders(header http.Header) {
+	for key := range header {
+		if strings.HasPrefix(key, "Impersonate-") {
+			header.Del(key)
+		}
+	}
+}
+
 func (p *proxyRouter) parseTarget(target url.URL, host string, name string) (*url.URL, error) {
 	kubeURL, err := url.Parse(host)
 	if err != nil {
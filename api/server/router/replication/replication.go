@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type replicationRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &replicationRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (rr *replicationRouter) initRoutes(ginEngine *gin.Engine) {
+	replicationRoute := ginEngine.Group("/pixiu/replications")
+	{
+		replicationRoute.POST("", rr.createReplication)
+		replicationRoute.PUT("/:replicationId", rr.updateReplication)
+		replicationRoute.DELETE("/:replicationId", rr.deleteReplication)
+		replicationRoute.GET("/:replicationId", rr.getReplication)
+		replicationRoute.GET("", rr.listReplications)
+
+		// 立即触发一次同步
+		replicationRoute.POST("/:replicationId/sync", rr.syncReplication)
+	}
+}
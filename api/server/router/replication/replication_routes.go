@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type replicationMeta struct {
+	ReplicationId int64 `uri:"replicationId" binding:"required"`
+}
+
+func (rr *replicationRouter) createReplication(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var req types.CreateReplicationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := rr.c.Replication().Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (rr *replicationRouter) updateReplication(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt replicationMeta
+		req types.UpdateReplicationRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = rr.c.Replication().Update(c, opt.ReplicationId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (rr *replicationRouter) deleteReplication(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt replicationMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = rr.c.Replication().Delete(c, opt.ReplicationId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (rr *replicationRouter) getReplication(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt replicationMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = rr.c.Replication().Get(c, opt.ReplicationId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (rr *replicationRouter) listReplications(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = rr.c.Replication().List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (rr *replicationRouter) syncReplication(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt replicationMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = rr.c.Replication().Sync(c, opt.ReplicationId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -29,12 +29,28 @@ import (
 	_ "github.com/caoyingjunz/pixiu/api/server/validator"
 
 	"github.com/caoyingjunz/pixiu/api/server/middleware"
+	"github.com/caoyingjunz/pixiu/api/server/router/announcement"
 	"github.com/caoyingjunz/pixiu/api/server/router/audit"
 	"github.com/caoyingjunz/pixiu/api/server/router/auth"
+	"github.com/caoyingjunz/pixiu/api/server/router/cloud"
 	"github.com/caoyingjunz/pixiu/api/server/router/cluster"
+	"github.com/caoyingjunz/pixiu/api/server/router/debug"
+	"github.com/caoyingjunz/pixiu/api/server/router/fleet"
 	"github.com/caoyingjunz/pixiu/api/server/router/helm"
+	"github.com/caoyingjunz/pixiu/api/server/router/injection"
+	"github.com/caoyingjunz/pixiu/api/server/router/kubeconfig"
+	"github.com/caoyingjunz/pixiu/api/server/router/naming"
+	"github.com/caoyingjunz/pixiu/api/server/router/notification"
+	"github.com/caoyingjunz/pixiu/api/server/router/pipeline"
 	"github.com/caoyingjunz/pixiu/api/server/router/plan"
+	"github.com/caoyingjunz/pixiu/api/server/router/preference"
+	"github.com/caoyingjunz/pixiu/api/server/router/propagation"
 	"github.com/caoyingjunz/pixiu/api/server/router/proxy"
+	"github.com/caoyingjunz/pixiu/api/server/router/replication"
+	"github.com/caoyingjunz/pixiu/api/server/router/session"
+	"github.com/caoyingjunz/pixiu/api/server/router/slo"
+	"github.com/caoyingjunz/pixiu/api/server/router/system"
+	"github.com/caoyingjunz/pixiu/api/server/router/template"
 	"github.com/caoyingjunz/pixiu/api/server/router/tenant"
 	"github.com/caoyingjunz/pixiu/api/server/router/user"
 	"github.com/caoyingjunz/pixiu/cmd/app/options"
@@ -57,6 +73,22 @@ func InstallRouters(o *options.Options) {
 		plan.NewRouter,
 		audit.NewRouter,
 		auth.NewRouter,
+		pipeline.NewRouter,
+		propagation.NewRouter,
+		fleet.NewRouter,
+		template.NewRouter,
+		replication.NewRouter,
+		kubeconfig.NewRouter,
+		cloud.NewRouter,
+		slo.NewRouter,
+		notification.NewRouter,
+		preference.NewRouter,
+		announcement.NewRouter,
+		system.NewRouter,
+		naming.NewRouter,
+		injection.NewRouter,
+		debug.NewRouter,
+		session.NewRouter,
 	}
 
 	install(o, fs...)
@@ -64,8 +96,12 @@ func InstallRouters(o *options.Options) {
 	// StaticFiles 启用前端集成
 	o.HttpEngine.Use(static.Serve("/", static.LocalFile(o.ComponentConfig.Default.StaticFiles, true)))
 
-	// 启动健康检查
-	o.HttpEngine.GET("/healthz", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	// 启动健康检查，开启管理端口时同时在管理端口提供
+	healthz := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+	o.HttpEngine.GET("/healthz", healthz)
+	if o.AdminEngine != nil {
+		o.AdminEngine.GET("/healthz", healthz)
+	}
 	// 启动 APIs 服务
 	o.HttpEngine.GET("/api-ref/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 }
@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slo
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type sloRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &sloRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (s *sloRouter) initRoutes(ginEngine *gin.Engine) {
+	sloRoute := ginEngine.Group("/pixiu/slos")
+	{
+		sloRoute.POST("", s.createSLO)
+		sloRoute.PUT("/:sloId", s.updateSLO)
+		sloRoute.DELETE("/:sloId", s.deleteSLO)
+		sloRoute.GET("/:sloId", s.getSLO)
+		sloRoute.GET("", s.listSLOs)
+
+		// 达成率，剩余错误预算以及 burn rate
+		sloRoute.GET("/status", s.listSLOStatus)
+		sloRoute.GET("/:sloId/status", s.getSLOStatus)
+	}
+}
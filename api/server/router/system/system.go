@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type systemRouter struct {
+	c       controller.PixiuInterface
+	version string
+}
+
+func NewRouter(o *options.Options) {
+	router := &systemRouter{
+		c:       o.Controller,
+		version: o.Version,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (s *systemRouter) initRoutes(ginEngine *gin.Engine) {
+	systemRoute := ginEngine.Group("/pixiu/system")
+	{
+		// 开启的模块，认证方式以及限制
+		systemRoute.GET("/capabilities", s.getCapabilities)
+	}
+}
+
+func (s *systemRouter) getCapabilities(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	capabilities, err := s.c.System().GetCapabilities(c)
+	if err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	capabilities.Version = s.version
+	r.Result = capabilities
+
+	httputils.SetSuccess(c, r)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/options"
+	"github.com/caoyingjunz/pixiu/pkg/controller"
+)
+
+type templateRouter struct {
+	c controller.PixiuInterface
+}
+
+func NewRouter(o *options.Options) {
+	router := &templateRouter{
+		c: o.Controller,
+	}
+	router.initRoutes(o.HttpEngine)
+}
+
+func (t *templateRouter) initRoutes(ginEngine *gin.Engine) {
+	templateRoute := ginEngine.Group("/pixiu/templates")
+	{
+		templateRoute.POST("", t.createTemplate)
+		templateRoute.PUT("/:templateId", t.updateTemplate)
+		templateRoute.DELETE("/:templateId", t.deleteTemplate)
+		templateRoute.GET("/:templateId", t.getTemplate)
+		templateRoute.GET("", t.listTemplates)
+
+		// 使用变量渲染模板，仅返回渲染结果
+		templateRoute.POST("/:templateId/render", t.renderTemplate)
+		// 渲染模板并提交到集群，dry_run 时仅预览
+		templateRoute.POST("/:templateId/instantiate", t.instantiateTemplate)
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type templateMeta struct {
+	TemplateId int64 `uri:"templateId" binding:"required"`
+}
+
+func (t *templateRouter) createTemplate(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var req types.CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err := t.c.Template().Create(c, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (t *templateRouter) updateTemplate(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt templateMeta
+		req types.UpdateTemplateRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = t.c.Template().Update(c, opt.TemplateId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (t *templateRouter) deleteTemplate(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt templateMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if err = t.c.Template().Delete(c, opt.TemplateId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (t *templateRouter) getTemplate(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt templateMeta
+		err error
+	)
+	if err = c.ShouldBindUri(&opt); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = t.c.Template().Get(c, opt.TemplateId); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (t *templateRouter) listTemplates(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var err error
+	if r.Result, err = t.c.Template().List(c); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (t *templateRouter) renderTemplate(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt templateMeta
+		req types.RenderTemplateRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = t.c.Template().Render(c, opt.TemplateId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
+
+func (t *templateRouter) instantiateTemplate(c *gin.Context) {
+	r := httputils.NewResponse()
+
+	var (
+		opt templateMeta
+		req types.InstantiateTemplateRequest
+		err error
+	)
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+	if r.Result, err = t.c.Template().Instantiate(c, opt.TemplateId, &req); err != nil {
+		httputils.SetFailed(c, r, err)
+		return
+	}
+
+	httputils.SetSuccess(c, r)
+}
@@ -48,14 +48,10 @@ func (t *tenantRouter) updateTenant(c *gin.Context) {
 
 	var (
 		opt TenantMeta
+		req types.UpdateTenantRequest
 		err error
 	)
-	if err = c.ShouldBindUri(&opt); err != nil {
-		httputils.SetFailed(c, r, err)
-		return
-	}
-	var req types.UpdateTenantRequest
-	if err = c.ShouldBindJSON(&req); err != nil {
+	if err = httputils.ShouldBindAny(c, &req, &opt, nil); err != nil {
 		httputils.SetFailed(c, r, err)
 		return
 	}
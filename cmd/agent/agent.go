@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/tunnel"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+var (
+	pixiuURL    = flag.String("pixiu-url", os.Getenv("PIXIU_URL"), "The address of pixiu server")
+	token       = flag.String("bootstrap-token", os.Getenv("BOOTSTRAP_TOKEN"), "The bootstrap token generated by pixiu")
+	credentials = flag.String("credentials-dir", "/var/run/pixiu", "The directory of the agent service account token and ca.crt")
+	insecure    = flag.Bool("insecure-skip-verify", os.Getenv("PIXIU_INSECURE") == "true", "Skip verifying the certificate of pixiu server")
+)
+
+// pixiu-agent 运行在 pixiu 无法直接访问的集群中，主动连接 pixiu 建立反向隧道，并将隧道中的连接转发到集群的 API server
+func main() {
+	klog.InitFlags(nil)
+	flag.Parse()
+
+	if len(*pixiuURL) == 0 || len(*token) == 0 {
+		klog.Fatal("pixiu-url and bootstrap-token are required")
+	}
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if len(host) == 0 || len(port) == 0 {
+		klog.Fatal("unable to load in-cluster configuration, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be defined")
+	}
+
+	agent := &tunnel.Agent{
+		PixiuURL:           strings.TrimSuffix(*pixiuURL, "/"),
+		Token:              *token,
+		Target:             net.JoinHostPort(host, port),
+		InsecureSkipVerify: *insecure,
+		OnConnected:        register,
+	}
+	agent.Run(context.Background())
+}
+
+// register 使用 agent ServiceAccount 的凭证注册集群，集群已注册时 pixiu 直接返回成功
+func register(ctx context.Context) error {
+	saToken, err := ioutil.ReadFile(filepath.Join(*credentials, "token"))
+	if err != nil {
+		return err
+	}
+	caData, err := ioutil.ReadFile(filepath.Join(*credentials, "ca.crt"))
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(types.RegisterClusterRequest{
+		BootstrapToken: *token,
+		Token:          strings.TrimSpace(string(saToken)),
+		CAData:         base64.StdEncoding.EncodeToString(caData),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(*pixiuURL, "/")+"/pixiu/clusters/register", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: *insecure},
+		},
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Code != http.StatusOK {
+		return fmt.Errorf("failed to register cluster: %s", result.Message)
+	}
+	klog.Infof("cluster registered to %s", *pixiuURL)
+	return nil
+}
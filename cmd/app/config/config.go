@@ -17,10 +17,23 @@ limitations under the License.
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/caoyingjunz/pixiu/pkg/jobmanager"
+	"github.com/caoyingjunz/pixiu/pkg/notifier"
+	"github.com/caoyingjunz/pixiu/pkg/util"
+	"github.com/caoyingjunz/pixiu/pkg/util/dns"
 	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+	"github.com/caoyingjunz/pixiu/pkg/util/session"
+	"github.com/caoyingjunz/pixiu/pkg/util/trace"
 )
 
 type Mode string
@@ -34,12 +47,35 @@ func (m Mode) InDebug() bool {
 	return m == DebugMode
 }
 
+const (
+	// DefaultJWTKey 未配置 jwt_key 时使用的默认值，仅允许在 debug 模式下使用
+	DefaultJWTKey = "pixiu"
+	// 与 HS256 的摘要长度保持一致
+	minJWTKeyLength = 32
+)
+
 type Config struct {
-	Default DefaultOptions          `yaml:"default"`
-	Mysql   MysqlOptions            `yaml:"mysql"`
-	Worker  WorkerOptions           `yaml:"worker"`
-	Audit   jobmanager.AuditOptions `yaml:"audit"`
-	TLS     *TLS                    `yaml:"tls"`
+	Default      DefaultOptions            `yaml:"default"`
+	Mysql        MysqlOptions              `yaml:"mysql"`
+	Worker       WorkerOptions             `yaml:"worker"`
+	Audit        jobmanager.AuditOptions   `yaml:"audit"`
+	TLS          *TLS                      `yaml:"tls"`
+	DNS          dns.Options               `yaml:"dns"`
+	Operator     OperatorOptions           `yaml:"operator"`
+	Kubectl      KubectlOptions            `yaml:"kubectl"`
+	Agent        AgentOptions              `yaml:"agent"`
+	KubeConfig   KubeConfigOptions         `yaml:"kubeconfig"`
+	Admin        AdminOptions              `yaml:"admin"`
+	Bootstrap    BootstrapOptions          `yaml:"bootstrap"`
+	Event        jobmanager.EventOptions   `yaml:"event"`
+	Trace        trace.Options             `yaml:"trace"`
+	Notification notifier.Options          `yaml:"notification"`
+	Recycle      jobmanager.RecycleOptions `yaml:"recycle"`
+	Session      session.Options           `yaml:"session"`
+	Quota        QuotaOptions              `yaml:"quota"`
+
+	WorkloadChange     jobmanager.WorkloadChangeOptions     `yaml:"workload_change"`
+	KubeConfigRotation jobmanager.KubeConfigRotationOptions `yaml:"kubeconfig_rotation"`
 }
 
 type DefaultOptions struct {
@@ -47,7 +83,7 @@ type DefaultOptions struct {
 	Listen int    `yaml:"listen"`
 	JWTKey string `yaml:"jwt_key"`
 
-	// 自动创建指定模型的数据库表结构，不会更新已存在的数据库表
+	// 自动创建指定模型的数据库表结构，已存在的表添加缺少的字段和索引，不修改或删除已有的字段
 	AutoMigrate bool `yaml:"auto_migrate"`
 
 	logutil.LogOptions `yaml:",inline"`
@@ -56,8 +92,47 @@ type DefaultOptions struct {
 }
 
 func (o DefaultOptions) Valid() error {
+	var errs []error
+	switch o.Mode {
+	case "", DebugMode, ReleaseMode:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported mode %q, must be debug or release", o.Mode))
+	}
+	if err := validPort(o.Listen); err != nil {
+		errs = append(errs, fmt.Errorf("listen: %v", err))
+	}
+	switch {
+	case o.JWTKey == DefaultJWTKey:
+		if !o.Mode.InDebug() {
+			errs = append(errs, fmt.Errorf("jwt_key must not be the built-in default %q outside debug mode", DefaultJWTKey))
+		}
+	case len(o.JWTKey) < minJWTKeyLength:
+		errs = append(errs, fmt.Errorf("jwt_key is too short, must be at least %d characters", minJWTKeyLength))
+	}
 	if err := o.LogOptions.Valid(); err != nil {
-		return err
+		errs = append(errs, fmt.Errorf("%v %q, must be json or text", err, o.LogFormat))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func validPort(port int) error {
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port %d, must be in range 1-65535", port)
+	}
+	return nil
+}
+
+// validFile 校验配置中引用的文件存在且不是目录
+func validFile(name string, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s %s not found", name, path)
+		}
+		return fmt.Errorf("%s %s is not accessible: %v", name, path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s %s is a directory", name, path)
 	}
 	return nil
 }
@@ -72,57 +147,332 @@ type MysqlOptions struct {
 }
 
 func (o MysqlOptions) Valid() error {
-	// TODO
-	return nil
+	var errs []error
+	if len(o.Host) == 0 {
+		errs = append(errs, fmt.Errorf("no host found"))
+	}
+	if err := validPort(o.Port); err != nil {
+		errs = append(errs, err)
+	}
+	if len(o.User) == 0 {
+		errs = append(errs, fmt.Errorf("no user found"))
+	}
+	if len(o.Name) == 0 {
+		errs = append(errs, fmt.Errorf("no database name found"))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// DSN 返回数据库的连接地址
+func (o MysqlOptions) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=True&loc=Local",
+		o.User,
+		o.Password,
+		o.Host,
+		o.Port,
+		o.Name)
 }
 
 type WorkerOptions struct {
 	WorkDir string   `yaml:"work_dir"`
-	Engines []Engine `yaml:"engines"`
+	Engines []Engine `yaml:"engines" override:"-"`
 }
 
 type Engine struct {
 	Image       string   `yaml:"image"`
 	OSSupported []string `yaml:"os_supported"`
+	// 支持部署的节点架构，为空时仅支持 amd64
+	ArchSupported []string `yaml:"arch_supported"`
 }
 
 func (w WorkerOptions) Valid() error {
-	// TODO
-	return nil
+	var errs []error
+	if info, err := os.Stat(w.WorkDir); err == nil && !info.IsDir() {
+		errs = append(errs, fmt.Errorf("work_dir %s is not a directory", w.WorkDir))
+	}
+	for i, engine := range w.Engines {
+		if len(engine.Image) == 0 {
+			errs = append(errs, fmt.Errorf("engines[%d]: no image found", i))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
 }
 
+const (
+	// ClientAuthVerifyIfGiven 客户端提供证书时校验，未提供时仍然使用 token 认证
+	ClientAuthVerifyIfGiven = "verify_if_given"
+	// ClientAuthRequire 所有请求都必须提供合法的客户端证书
+	ClientAuthRequire = "require"
+)
+
 type TLS struct {
 	CertFile string `yaml:"cert_file"`
 	KeyFile  string `yaml:"key_file"`
+
+	// 校验客户端证书的 CA，用于服务间调用的双向认证，证书的 CommonName 为平台的用户名
+	ClientCAFile string `yaml:"client_ca_file"`
+	// 客户端证书的校验方式，可选 verify_if_given 和 require，默认为 verify_if_given
+	ClientAuth string `yaml:"client_auth"`
+	// 将 http 请求重定向到 https 的监听端口，为 0 时不开启
+	RedirectListen int `yaml:"redirect_listen"`
+}
+
+// ClientAuthType 返回客户端证书的校验方式，未配置 CA 时不校验
+func (t *TLS) ClientAuthType() tls.ClientAuthType {
+	if len(t.ClientCAFile) == 0 {
+		return tls.NoClientCert
+	}
+	if t.ClientAuth == ClientAuthRequire {
+		return tls.RequireAndVerifyClientCert
+	}
+	return tls.VerifyClientCertIfGiven
 }
 
 func (t *TLS) Valid() error {
-	if t != nil {
-		if len(t.CertFile) == 0 {
-			return fmt.Errorf("listen on tls, no cert_file found")
+	if t == nil {
+		return nil
+	}
+
+	var errs []error
+	if len(t.CertFile) == 0 {
+		errs = append(errs, fmt.Errorf("listen on tls, no cert_file found"))
+	} else if err := validFile("cert_file", t.CertFile); err != nil {
+		errs = append(errs, err)
+	}
+	if len(t.KeyFile) == 0 {
+		errs = append(errs, fmt.Errorf("listen on tls, no key_file found"))
+	} else if err := validFile("key_file", t.KeyFile); err != nil {
+		errs = append(errs, err)
+	}
+	if len(t.ClientCAFile) != 0 {
+		if err := validFile("client_ca_file", t.ClientCAFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	switch t.ClientAuth {
+	case "", ClientAuthVerifyIfGiven, ClientAuthRequire:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported client_auth %q, must be verify_if_given or require", t.ClientAuth))
+	}
+	if t.RedirectListen != 0 {
+		if err := validPort(t.RedirectListen); err != nil {
+			errs = append(errs, fmt.Errorf("redirect_listen: %v", err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// OperatorOptions operator 模式，监听管理集群中的 pixiu CRD 并同步到平台
+type OperatorOptions struct {
+	Enable bool `yaml:"enable"`
+	// 管理集群的 kubeconfig 文件，为空时使用 in-cluster 配置
+	KubeConfig string `yaml:"kube_config"`
+	// 监听的命名空间，为空时监听所有命名空间
+	Namespace string `yaml:"namespace"`
+	// 以该用户的身份创建平台对象
+	User string `yaml:"user"`
+}
+
+func (o OperatorOptions) Valid() error {
+	if !o.Enable {
+		return nil
+	}
+
+	var errs []error
+	if len(o.User) == 0 {
+		errs = append(errs, fmt.Errorf("operator is enabled, no user found"))
+	}
+	if len(o.KubeConfig) != 0 {
+		if err := validFile("kube_config", o.KubeConfig); err != nil {
+			errs = append(errs, err)
 		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// KubectlOptions web kubectl 的配置，每个会话在目标集群中启动一个临时 pod
+type KubectlOptions struct {
+	// 预装 kubectl 的镜像
+	Image string `yaml:"image"`
+	// 临时 pod 所在的命名空间
+	Namespace string `yaml:"namespace"`
+}
+
+// AgentOptions 通过引导 token 注册集群时部署到目标集群的 agent 配置
+type AgentOptions struct {
+	// tunnel 模式下常驻集群的 agent 镜像
+	Image string `yaml:"image"`
+	// 回调注册集群的 Job 使用的镜像
+	RegisterImage string `yaml:"register_image"`
+	// agent 的 ServiceAccount 绑定的 ClusterRole，pixiu 保存该 ServiceAccount 不过期的 token 作为集群的凭证
+	// 该角色即为 pixiu 在集群中的权限，默认为 cluster-admin，可以指定预先在集群中创建的权限更小的角色
+	ClusterRole string `yaml:"cluster_role"`
+}
+
+const (
+	QuotaEnforcementReject = "reject"
+	QuotaEnforcementWarn   = "warn"
+)
+
+// QuotaOptions 通过 pixiu 创建，更新和扩容 deployment 和 statefulset 时校验租户的资源配额
+// daemonset，job 以及 helm 安装的工作负载不做校验
+type QuotaOptions struct {
+	// 超出配额时的处理方式，reject 拒绝请求，warn 仅返回告警，默认为 reject
+	Enforcement string `yaml:"enforcement"`
+}
 
-		if len(t.KeyFile) == 0 {
-			return fmt.Errorf("listen on tls, no key_file found")
+func (o QuotaOptions) Valid() error {
+	switch o.Enforcement {
+	case "", QuotaEnforcementReject, QuotaEnforcementWarn:
+		return nil
+	}
+	return fmt.Errorf("unsupported enforcement %q", o.Enforcement)
+}
+
+func (o QuotaOptions) Warn() bool {
+	return o.Enforcement == QuotaEnforcementWarn
+}
+
+// KubeConfigOptions 签发 kubeconfig 的配置
+type KubeConfigOptions struct {
+	// ServiceAccount 所在的命名空间，不存在时自动创建
+	Namespace string `yaml:"namespace"`
+	// 按集群指定 ServiceAccount 所在的命名空间，key 为集群名称
+	ClusterNamespaces map[string]string `yaml:"cluster_namespaces"`
+
+	// 签发时允许绑定的 ClusterRole 和 Role，支持通配符，例如 pixiu:*
+	// 未设置时允许除 system: 开头以外的全部角色
+	AllowedClusterRoles []string `yaml:"allowed_cluster_roles"`
+	AllowedRoles        []string `yaml:"allowed_roles"`
+}
+
+func (o KubeConfigOptions) Valid() error {
+	var errs []error
+	for _, pattern := range append(append([]string{}, o.AllowedClusterRoles...), o.AllowedRoles...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid role pattern %q: %v", pattern, err))
 		}
 	}
+	return utilerrors.NewAggregate(errs)
+}
 
-	return nil
+// AllowClusterRole 判断签发时是否允许绑定该 ClusterRole
+func (o KubeConfigOptions) AllowClusterRole(name string) bool {
+	return allowRole(o.AllowedClusterRoles, name)
 }
 
-func (c *Config) Valid() (err error) {
-	if err = c.Default.Valid(); err != nil {
-		return
+// AllowRole 判断签发时是否允许绑定该 Role
+func (o KubeConfigOptions) AllowRole(name string) bool {
+	return allowRole(o.AllowedRoles, name)
+}
+
+func allowRole(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return !strings.HasPrefix(name, "system:")
 	}
-	if err = c.Mysql.Valid(); err != nil {
-		return
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
 	}
-	if err = c.Worker.Valid(); err != nil {
-		return
+	return false
+}
+
+// ServiceAccountNamespace 返回集群中 ServiceAccount 所在的命名空间
+func (o KubeConfigOptions) ServiceAccountNamespace(cluster string) string {
+	if namespace, ok := o.ClusterNamespaces[cluster]; ok && len(namespace) != 0 {
+		return namespace
 	}
-	if err = c.TLS.Valid(); err != nil {
-		return err
+	return o.Namespace
+}
+
+// AdminOptions 管理端口的配置，开启后调试接口和健康检查仅在管理端口提供
+type AdminOptions struct {
+	// 管理端口，为 0 时不开启，管理接口和业务接口共用端口
+	Listen int `yaml:"listen"`
+	// 管理端口监听的地址，例如 127.0.0.1，为空时监听所有地址
+	Address string `yaml:"address"`
+}
+
+func (o AdminOptions) Enabled() bool {
+	return o.Listen != 0
+}
+
+func (o AdminOptions) Addr() string {
+	return net.JoinHostPort(o.Address, strconv.Itoa(o.Listen))
+}
+
+func (o AdminOptions) Valid() error {
+	if !o.Enabled() {
+		return nil
 	}
+	var errs []error
+	if err := validPort(o.Listen); err != nil {
+		errs = append(errs, fmt.Errorf("listen: %v", err))
+	}
+	if len(o.Address) != 0 && net.ParseIP(o.Address) == nil {
+		errs = append(errs, fmt.Errorf("invalid address %q, must be an ip", o.Address))
+	}
+	return utilerrors.NewAggregate(errs)
+}
 
-	return
+// BootstrapOptions 首次启动时创建的默认管理员
+type BootstrapOptions struct {
+	AdminUser string `yaml:"admin_user"`
+	// 默认管理员的初始密码，为空时随机生成，首次登陆后必须修改
+	AdminPassword string `yaml:"admin_password"`
+	// 服务启动时随机生成的初始密码写入该文件，权限为 0600，执行 init 时直接打印到标准输出
+	PasswordFile string `yaml:"password_file"`
+}
+
+func (o BootstrapOptions) Valid() error {
+	if len(o.AdminPassword) != 0 && !util.ValidateStrongPassword(o.AdminPassword) {
+		return fmt.Errorf("admin_password is too weak, must contain upper and lower case letters and numbers, at least 8 characters")
+	}
+	return nil
+}
+
+// Valid 校验全部配置，汇总返回所有的错误，而不是在首次使用时才失败
+func (c *Config) Valid() error {
+	sections := []struct {
+		name  string
+		valid func() error
+	}{
+		{"default", c.Default.Valid},
+		{"mysql", c.Mysql.Valid},
+		{"worker", c.Worker.Valid},
+		{"tls", c.TLS.Valid},
+		{"dns", c.DNS.Valid},
+		{"operator", c.Operator.Valid},
+		{"admin", c.Admin.Valid},
+		{"bootstrap", c.Bootstrap.Valid},
+		{"event", c.Event.Valid},
+		{"trace", c.Trace.Valid},
+		{"notification", c.Notification.Valid},
+		{"kubeconfig", c.KubeConfig.Valid},
+		{"kubeconfig_rotation", c.KubeConfigRotation.Valid},
+		{"recycle", c.Recycle.Valid},
+		{"session", c.Session.Valid},
+		{"quota", c.Quota.Valid},
+		{"workload_change", c.WorkloadChange.Valid},
+	}
+
+	var errs []error
+	for _, section := range sections {
+		err := section.valid()
+		if err == nil {
+			continue
+		}
+		if agg, ok := err.(utilerrors.Aggregate); ok {
+			for _, e := range agg.Errors() {
+				errs = append(errs, fmt.Errorf("%s: %v", section.name, e))
+			}
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s: %v", section.name, err))
+	}
+	if c.Admin.Enabled() && c.Admin.Listen == c.Default.Listen {
+		errs = append(errs, fmt.Errorf("admin: listen %d conflicts with default.listen", c.Admin.Listen))
+	}
+	return utilerrors.NewAggregate(errs)
 }
@@ -17,8 +17,10 @@ limitations under the License.
 package options
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/casbin/casbin/v2"
@@ -26,16 +28,24 @@ import (
 	gormadapter "github.com/casbin/gorm-adapter/v3"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"k8s.io/klog/v2"
 
 	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/bootstrap"
 	"github.com/caoyingjunz/pixiu/pkg/controller"
+	clusterctrl "github.com/caoyingjunz/pixiu/pkg/controller/cluster"
 	pixiudb "github.com/caoyingjunz/pixiu/pkg/db"
 	pixiuModel "github.com/caoyingjunz/pixiu/pkg/db/model"
 	"github.com/caoyingjunz/pixiu/pkg/jobmanager"
+	"github.com/caoyingjunz/pixiu/pkg/notifier"
+	"github.com/caoyingjunz/pixiu/pkg/util/dns"
 	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+	sessionutil "github.com/caoyingjunz/pixiu/pkg/util/session"
+	"github.com/caoyingjunz/pixiu/pkg/util/trace"
 	pixiuConfig "github.com/caoyingjunz/pixiulib/config"
 )
 
@@ -44,12 +54,26 @@ const (
 	maxOpenConns = 100
 
 	defaultListen     = 8080
-	defaultTokenKey   = "pixiu"
 	defaultConfigFile = "/etc/pixiu/config.yaml"
 	defaultLogFormat  = logutil.LogFormatJson
 	defaultWorkDir    = "/etc/pixiu"
 	defaultStaticDir  = "/static"
 
+	defaultOperatorUser = "admin"
+	defaultAdminUser    = "admin"
+
+	defaultAdminPasswordFile = "initial_admin_password"
+
+	defaultKubectlImage     = "bitnami/kubectl:latest"
+	defaultKubectlNamespace = "pixiu-system"
+
+	defaultAgentImage         = "jacky06/pixiu-agent:v0.1"
+	defaultAgentRegisterImage = "curlimages/curl:8.5.0"
+	defaultAgentClusterRole   = "cluster-admin"
+	// 签发的 ServiceAccount 默认创建在平台管理的命名空间中，避免污染 kube-system
+	// 已签发的 kubeconfig 记录了各自的命名空间，轮换和回收不受影响
+	defaultKubeConfigNamespace = "pixiu-system"
+
 	defaultSlowSQLDuration = 1 * time.Second
 
 	rulesTableName = "rules"
@@ -60,6 +84,8 @@ type Options struct {
 	// The default values.
 	ComponentConfig config.Config
 	HttpEngine      *gin.Engine
+	// 管理端口的路由，未开启管理端口时为空
+	AdminEngine *gin.Engine
 
 	// 数据库接口
 	db      *gorm.DB
@@ -69,11 +95,18 @@ type Options struct {
 
 	// ConfigFile is the location of the pixiu server's configuration file.
 	ConfigFile string
+	// ValidateConfig 仅校验配置文件，校验完成后退出
+	ValidateConfig bool
+	// 覆盖配置文件的命令行参数
+	flags *pflag.FlagSet
 
 	// Authorization enforcement and policy management
 	Enforcer *casbin.SyncedEnforcer
 
 	JobManager *jobmanager.Manager
+
+	// 服务的版本，编译时注入
+	Version string
 }
 
 func NewOptions() (*Options, error) {
@@ -85,6 +118,84 @@ func NewOptions() (*Options, error) {
 
 // Complete completes all the required options
 func (o *Options) Complete() error {
+	if err := o.completeConfig(); err != nil {
+		return err
+	}
+
+	o.ComponentConfig.Default.LogOptions.Init()
+	// 开启后将多步骤的操作以 trace 的形式导出到 OTLP 服务
+	trace.Setup(o.ComponentConfig.Trace)
+	// 多副本部署时通过 redis 共享终端，日志和端口转发的会话
+	if err := sessionutil.Setup(o.ComponentConfig.Session); err != nil {
+		return err
+	}
+
+	if o.ComponentConfig.Admin.Enabled() {
+		o.AdminEngine = gin.New()
+		o.AdminEngine.Use(gin.Recovery())
+	}
+
+	// 注册依赖组件
+	if err := o.register(); err != nil {
+		return err
+	}
+	// 首次启动时初始化内置角色，菜单和默认管理员，随机生成的密码仅写入文件，避免出现在日志中
+	bc := o.ComponentConfig.Bootstrap
+	password, err := bootstrap.Seed(context.TODO(), bc, o.Factory, o.Enforcer)
+	if err != nil {
+		return err
+	}
+	if len(password) != 0 {
+		if err = bootstrap.WritePassword(bc.PasswordFile, bc.AdminUser, password); err != nil {
+			return fmt.Errorf("failed to write the initial admin password: %v", err)
+		}
+		klog.Warningf("the initial password of admin user %s is written to %s, please change it after login", bc.AdminUser, bc.PasswordFile)
+	}
+
+	o.Controller = controller.New(o.ComponentConfig, o.Factory, o.Enforcer)
+	// 加载运行时调整过的日志级别，失败时使用配置文件的日志级别
+	if err := o.Controller.Debug().LoadLogLevel(context.TODO()); err != nil {
+		klog.Warningf("failed to load log level setting: %v", err)
+	}
+
+	jobs := []jobmanager.Job{
+		jobmanager.NewAuditsCleaner(o.ComponentConfig.Audit, o.Factory),
+		jobmanager.NewClusterSyncer(o.Factory),
+		jobmanager.NewPipelineSyncer(o.Factory),
+		jobmanager.NewScalingScheduler(o.Factory, o.Controller.Cluster().AdmitDeployment),
+		jobmanager.NewInspectionScheduler(o.Factory, notifier.New(o.Factory, o.ComponentConfig.Notification)),
+		jobmanager.NewNamespaceCleaner(o.Factory, notifier.New(o.Factory, o.ComponentConfig.Notification)),
+		jobmanager.NewCloudCredentialRefresher(o.Factory, clusterctrl.ClusterIndexer.Delete),
+		jobmanager.NewSLOEvaluator(o.Factory),
+		jobmanager.NewKubeConfigExpiryNotifier(o.Factory, notifier.New(o.Factory, o.ComponentConfig.Notification)),
+		jobmanager.NewWorkloadChangesCleaner(o.ComponentConfig.WorkloadChange, o.Factory),
+	}
+	// 开启事件持久化时，定期清理过期的事件
+	if o.ComponentConfig.Event.Enable {
+		jobs = append(jobs, jobmanager.NewEventsCleaner(o.ComponentConfig.Event, o.Factory))
+	}
+	// 开启自动轮换时，在 kubeconfig 过期前重新签发 token
+	if o.ComponentConfig.KubeConfigRotation.Enable {
+		jobs = append(jobs, jobmanager.NewKubeConfigRotator(o.ComponentConfig.KubeConfigRotation, o.Factory))
+	}
+	// 开启回收站时，定期清理过期的对象
+	if o.ComponentConfig.Recycle.Enable {
+		jobs = append(jobs, jobmanager.NewRecycleCleaner(o.Factory))
+	}
+	// 开启 DNS 集成时，定期清理失效的解析记录
+	if o.ComponentConfig.DNS.Enable {
+		provider, err := dns.NewProvider(o.ComponentConfig.DNS)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, jobmanager.NewDNSCleaner(provider, o.Factory))
+	}
+	o.JobManager = jobmanager.NewManager(&o.ComponentConfig.Default.LogOptions, jobs...)
+	return nil
+}
+
+// completeConfig 读取配置文件，设置默认值并校验
+func (o *Options) completeConfig() error {
 	// 配置文件优先级: 默认配置，环境变量，命令行
 	if len(o.ConfigFile) == 0 {
 		// Try to read config file path from env.
@@ -95,10 +206,16 @@ func (o *Options) Complete() error {
 		}
 	}
 
-	c := pixiuConfig.New()
-	c.SetConfigFile(o.ConfigFile)
-	c.SetConfigType("yaml")
-	if err := c.Binding(&o.ComponentConfig); err != nil {
+	// 使用默认路径且文件不存在时，允许仅通过环境变量和命令行参数配置，便于容器化部署
+	if _, err := os.Stat(o.ConfigFile); err == nil || o.ConfigFile != defaultConfigFile {
+		c := pixiuConfig.New()
+		c.SetConfigFile(o.ConfigFile)
+		c.SetConfigType("yaml")
+		if err := c.Binding(&o.ComponentConfig); err != nil {
+			return err
+		}
+	}
+	if err := o.applyOverrides(); err != nil {
 		return err
 	}
 
@@ -107,11 +224,14 @@ func (o *Options) Complete() error {
 		o.ComponentConfig.Default.Listen = defaultListen
 	}
 	if len(o.ComponentConfig.Default.JWTKey) == 0 {
-		o.ComponentConfig.Default.JWTKey = defaultTokenKey
+		o.ComponentConfig.Default.JWTKey = config.DefaultJWTKey
 	}
 	if o.ComponentConfig.Default.LogFormat == "" {
 		o.ComponentConfig.Default.LogFormat = defaultLogFormat
 	}
+	if o.ComponentConfig.Default.LogBufferSize == 0 {
+		o.ComponentConfig.Default.LogBufferSize = logutil.DefaultLogBufferSize
+	}
 	if o.ComponentConfig.Worker.WorkDir == "" {
 		o.ComponentConfig.Worker.WorkDir = defaultWorkDir
 	}
@@ -125,30 +245,90 @@ func (o *Options) Complete() error {
 		o.ComponentConfig.Audit.DaysReserved = jobmanager.DefaultDaysReserved
 	}
 
-	if err := o.ComponentConfig.Valid(); err != nil {
-		return err
+	if len(o.ComponentConfig.Operator.User) == 0 {
+		o.ComponentConfig.Operator.User = defaultOperatorUser
+	}
+	if len(o.ComponentConfig.Bootstrap.AdminUser) == 0 {
+		o.ComponentConfig.Bootstrap.AdminUser = defaultAdminUser
+	}
+	if len(o.ComponentConfig.Bootstrap.PasswordFile) == 0 {
+		o.ComponentConfig.Bootstrap.PasswordFile = filepath.Join(o.ComponentConfig.Worker.WorkDir, defaultAdminPasswordFile)
+	}
+	if len(o.ComponentConfig.Kubectl.Image) == 0 {
+		o.ComponentConfig.Kubectl.Image = defaultKubectlImage
+	}
+	if len(o.ComponentConfig.Kubectl.Namespace) == 0 {
+		o.ComponentConfig.Kubectl.Namespace = defaultKubectlNamespace
+	}
+	if len(o.ComponentConfig.Agent.Image) == 0 {
+		o.ComponentConfig.Agent.Image = defaultAgentImage
+	}
+	if len(o.ComponentConfig.Agent.RegisterImage) == 0 {
+		o.ComponentConfig.Agent.RegisterImage = defaultAgentRegisterImage
+	}
+	if len(o.ComponentConfig.Agent.ClusterRole) == 0 {
+		o.ComponentConfig.Agent.ClusterRole = defaultAgentClusterRole
+	}
+	if len(o.ComponentConfig.KubeConfig.Namespace) == 0 {
+		o.ComponentConfig.KubeConfig.Namespace = defaultKubeConfigNamespace
+	}
+	if o.ComponentConfig.Event.DaysReserved == 0 {
+		o.ComponentConfig.Event.DaysReserved = jobmanager.DefaultEventDaysReserved
+	}
+	if o.ComponentConfig.Event.NormalSampleRate == 0 {
+		o.ComponentConfig.Event.NormalSampleRate = jobmanager.DefaultEventSampleRate
+	}
+	if o.ComponentConfig.KubeConfigRotation.Window == 0 {
+		o.ComponentConfig.KubeConfigRotation.Window = jobmanager.DefaultKubeConfigRotateWindow
+	}
+	if o.ComponentConfig.Recycle.DaysReserved == 0 {
+		o.ComponentConfig.Recycle.DaysReserved = jobmanager.DefaultRecycleDaysReserved
 	}
+	if o.ComponentConfig.WorkloadChange.DaysReserved == 0 {
+		o.ComponentConfig.WorkloadChange.DaysReserved = jobmanager.DefaultWorkloadChangeDaysReserved
+	}
+
+	return o.ComponentConfig.Valid()
+}
 
+// Bootstrap 创建数据库表结构，初始化内置角色，菜单和默认管理员，可以重复执行
+// 随机生成默认管理员的密码时返回该密码
+func (o *Options) Bootstrap() (string, error) {
+	if err := o.completeConfig(); err != nil {
+		return "", err
+	}
 	o.ComponentConfig.Default.LogOptions.Init()
 
-	// 注册依赖组件
+	// 初始化时总是创建数据库表结构
+	o.ComponentConfig.Default.AutoMigrate = true
 	if err := o.register(); err != nil {
-		return err
+		return "", err
 	}
+	return bootstrap.Seed(context.TODO(), o.ComponentConfig.Bootstrap, o.Factory, o.Enforcer)
+}
 
-	o.Controller = controller.New(o.ComponentConfig, o.Factory, o.Enforcer)
+// ValidateConfigFile 校验配置文件以及数据库的连通性，不启动服务
+func (o *Options) ValidateConfigFile() error {
+	if err := o.completeConfig(); err != nil {
+		return err
+	}
 
-	o.JobManager = jobmanager.NewManager(
-		&o.ComponentConfig.Default.LogOptions,
-		jobmanager.NewAuditsCleaner(o.ComponentConfig.Audit, o.Factory),
-		jobmanager.NewClusterSyncer(o.Factory),
-	)
+	db, err := gorm.Open(mysql.Open(o.ComponentConfig.Mysql.DSN()), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return fmt.Errorf("mysql: failed to connect %s:%d: %v", o.ComponentConfig.Mysql.Host, o.ComponentConfig.Mysql.Port, err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
 	return nil
 }
 
 // BindFlags binds the pixiu Configuration struct fields
-func (o *Options) BindFlags(cmd *cobra.Command) {
-	cmd.Flags().StringVar(&o.ConfigFile, "configfile", defaultConfigFile, "The location of the pixiu configuration file")
+func (o *Options) BindFlags(cmd *cobra.Command) error {
+	// 子命令同样需要读取配置文件
+	cmd.PersistentFlags().StringVar(&o.ConfigFile, "configfile", defaultConfigFile, "The location of the pixiu configuration file")
+	cmd.Flags().BoolVar(&o.ValidateConfig, "validate-config", false, "Validate the configuration file and the database connection, then exit")
+	return o.bindConfigFlags(cmd.PersistentFlags())
 }
 
 func (o *Options) register() error {
@@ -188,22 +368,18 @@ func (o *Options) registerEnforcer() error {
 }
 
 func (o *Options) registerDatabase() error {
-	sqlConfig := o.ComponentConfig.Mysql
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8&parseTime=True&loc=Local",
-		sqlConfig.User,
-		sqlConfig.Password,
-		sqlConfig.Host,
-		sqlConfig.Port,
-		sqlConfig.Name)
-
 	opt := &gorm.Config{
 		Logger: pixiudb.NewLogger(logger.Info, defaultSlowSQLDuration),
 	}
-	db, err := gorm.Open(mysql.Open(dsn), opt)
+	db, err := gorm.Open(mysql.Open(o.ComponentConfig.Mysql.DSN()), opt)
 	if err != nil {
 		return err
 	}
 	o.db = db
+	// 记录语句的执行耗时，用于排查慢查询
+	if err = pixiudb.RegisterQueryStats(db, defaultSlowSQLDuration); err != nil {
+		return err
+	}
 
 	// 设置数据库连接池
 	sqlDB, err := db.DB()
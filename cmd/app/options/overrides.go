@@ -0,0 +1,283 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// 配置的优先级由低到高依次为: 默认值，配置文件，PIXIU_* 环境变量，命令行参数
+// 环境变量和命令行参数由配置文件的 yaml 字段生成，例如 mysql.host 对应 PIXIU_MYSQL_HOST 和 --mysql-host
+// 无法通过字符串设置的字段(例如结构体切片)需要标记 override:"-"，只能在配置文件中设置
+const envPrefix = "PIXIU"
+
+// configField 配置中可覆盖的字段
+type configField struct {
+	path []string
+	kind reflect.Type
+}
+
+func (f configField) envName() string {
+	return envPrefix + "_" + strings.ToUpper(strings.Join(f.path, "_"))
+}
+
+func (f configField) flagName() string {
+	return strings.ReplaceAll(strings.Join(f.path, "-"), "_", "-")
+}
+
+// yamlName 返回字段的 yaml 名称，inline 时返回空
+func yamlName(sf reflect.StructField) (name string, inline bool, ok bool) {
+	tag := sf.Tag.Get("yaml")
+	if tag == "-" || !sf.IsExported() || sf.Tag.Get("override") == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	for _, p := range parts[1:] {
+		if p == "inline" {
+			return "", true, true
+		}
+	}
+	name = parts[0]
+	if len(name) == 0 {
+		name = strings.ToLower(sf.Name)
+	}
+	return name, false, true
+}
+
+// isLeaf 可以通过字符串设置的字段，包括基础类型，字符串切片，字符串 map 以及实现了 TextUnmarshaler 的类型
+func isLeaf(t reflect.Type) bool {
+	// 结构体需要继续遍历，内嵌字段可能会使结构体实现 TextUnmarshaler
+	if t.Kind() == reflect.Struct {
+		return false
+	}
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// listConfigFields 遍历配置结构体的全部可覆盖字段，存在不支持的字段类型时返回错误
+func listConfigFields(t reflect.Type, prefix []string) ([]configField, error) {
+	var fields []configField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, inline, ok := yamlName(sf)
+		if !ok {
+			continue
+		}
+		path := prefix
+		if !inline {
+			path = append(append([]string{}, prefix...), name)
+		}
+
+		// 指针在设置时自动初始化
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch {
+		case isLeaf(ft):
+			fields = append(fields, configField{path: path, kind: ft})
+		case ft.Kind() == reflect.Struct:
+			sub, err := listConfigFields(ft, path)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, sub...)
+		default:
+			return nil, fmt.Errorf("config %s has unsupported type %s, mark it with override:\"-\" if it can only be set in the configuration file", strings.Join(path, "."), sf.Type)
+		}
+	}
+	return fields, nil
+}
+
+// lookupField 根据路径获取字段，指针为空时自动初始化
+func lookupField(v reflect.Value, path []string) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if len(path) == 0 {
+		return v, true
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, inline, ok := yamlName(sf)
+		if !ok {
+			continue
+		}
+		fv := v.Field(i)
+		if inline {
+			if found, ok := lookupField(fv, path); ok {
+				return found, true
+			}
+			continue
+		}
+		if name == path[0] {
+			return lookupField(fv, path[1:])
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setFieldValue(v reflect.Value, value string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(splitItems(value)).Convert(v.Type()))
+	case reflect.Map:
+		// 格式为 key1=value1,key2=value2，整体替换配置文件中的值
+		m := reflect.MakeMap(v.Type())
+		for _, item := range splitItems(value) {
+			kv := strings.SplitN(item, "=", 2)
+			if len(kv) != 2 || len(strings.TrimSpace(kv[0])) == 0 {
+				return fmt.Errorf("invalid item %q, must be key=value", item)
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(kv[0])).Convert(v.Type().Key()), reflect.ValueOf(strings.TrimSpace(kv[1])).Convert(v.Type().Elem()))
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// splitItems 按逗号拆分并忽略空白项
+func splitItems(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) != 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (o *Options) setConfigField(f configField, value string) error {
+	v, ok := lookupField(reflect.ValueOf(&o.ComponentConfig), f.path)
+	if !ok {
+		return fmt.Errorf("unknown config %s", strings.Join(f.path, "."))
+	}
+	return setFieldValue(v, value)
+}
+
+// bindConfigFlags 为每个配置字段注册命令行参数
+func (o *Options) bindConfigFlags(fs *pflag.FlagSet) error {
+	fields, err := listConfigFields(reflect.TypeOf(o.ComponentConfig), nil)
+	if err != nil {
+		return err
+	}
+	o.flags = fs
+	for _, f := range fields {
+		fs.String(f.flagName(), "", fmt.Sprintf("Override %s in the configuration file, also settable by env %s", strings.Join(f.path, "."), f.envName()))
+		if f.kind.Kind() == reflect.Bool {
+			fs.Lookup(f.flagName()).NoOptDefVal = "true"
+		}
+	}
+	return nil
+}
+
+// applyOverrides 依次使用环境变量和命令行参数覆盖配置文件
+func (o *Options) applyOverrides() error {
+	fields, err := listConfigFields(reflect.TypeOf(o.ComponentConfig), nil)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		value, ok := os.LookupEnv(f.envName())
+		if !ok {
+			continue
+		}
+		if err := o.setConfigField(f, value); err != nil {
+			return fmt.Errorf("invalid env %s=%q: %v", f.envName(), value, err)
+		}
+	}
+
+	if o.flags == nil {
+		return nil
+	}
+	for _, f := range fields {
+		flag := o.flags.Lookup(f.flagName())
+		if flag == nil || !flag.Changed {
+			continue
+		}
+		if err := o.setConfigField(f, flag.Value.String()); err != nil {
+			return fmt.Errorf("invalid flag --%s=%q: %v", f.flagName(), flag.Value.String(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+)
+
+type InlineOptions struct {
+	Level int `yaml:"level"`
+}
+
+type testNested struct {
+	Host    string        `yaml:"host"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type testConfig struct {
+	InlineOptions `yaml:",inline"`
+
+	Name     string            `yaml:"name"`
+	Enable   bool              `yaml:"enable"`
+	Ratio    float64           `yaml:"ratio"`
+	Tags     []string          `yaml:"tags"`
+	Headers  map[string]string `yaml:"headers"`
+	Nested   testNested        `yaml:"nested"`
+	NestedP  *testNested       `yaml:"nested_p"`
+	Replicas *int              `yaml:"replicas"`
+	Interval *time.Duration    `yaml:"interval"`
+	Skipped  []testNested      `yaml:"skipped" override:"-"`
+	Ignored  string            `yaml:"-"`
+}
+
+func TestListConfigFields(t *testing.T) {
+	fields, err := listConfigFields(reflect.TypeOf(testConfig{}), nil)
+	if err != nil {
+		t.Fatalf("listConfigFields() error: %v", err)
+	}
+	var got []string
+	for _, f := range fields {
+		got = append(got, f.envName()+" --"+f.flagName())
+	}
+	want := []string{
+		"PIXIU_LEVEL --level",
+		"PIXIU_NAME --name",
+		"PIXIU_ENABLE --enable",
+		"PIXIU_RATIO --ratio",
+		"PIXIU_TAGS --tags",
+		"PIXIU_HEADERS --headers",
+		"PIXIU_NESTED_HOST --nested-host",
+		"PIXIU_NESTED_TIMEOUT --nested-timeout",
+		"PIXIU_NESTED_P_HOST --nested-p-host",
+		"PIXIU_NESTED_P_TIMEOUT --nested-p-timeout",
+		"PIXIU_REPLICAS --replicas",
+		"PIXIU_INTERVAL --interval",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("listConfigFields() = %v, want %v", got, want)
+	}
+}
+
+func TestListConfigFieldsUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  interface{}
+		want string
+	}{
+		{
+			name: "int map",
+			typ: struct {
+				Limits map[string]int `yaml:"limits"`
+			}{},
+			want: "limits",
+		},
+		{
+			name: "struct slice",
+			typ: struct {
+				Nested struct {
+					Items []testNested `yaml:"items"`
+				} `yaml:"nested"`
+			}{},
+			want: "nested.items",
+		},
+		{
+			name: "interface",
+			typ: struct {
+				Any interface{} `yaml:"any"`
+			}{},
+			want: "any",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := listConfigFields(reflect.TypeOf(tt.typ), nil)
+			if err == nil {
+				t.Fatalf("listConfigFields() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("listConfigFields() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	replicas := 3
+	interval := 30 * time.Second
+	tests := []struct {
+		name    string
+		path    string
+		value   string
+		want    testConfig
+		wantErr bool
+	}{
+		{name: "string", path: "name", value: "pixiu", want: testConfig{Name: "pixiu"}},
+		{name: "bool", path: "enable", value: "true", want: testConfig{Enable: true}},
+		{name: "invalid bool", path: "enable", value: "yes!", wantErr: true},
+		{name: "float", path: "ratio", value: "0.5", want: testConfig{Ratio: 0.5}},
+		{name: "inline", path: "level", value: "4", want: testConfig{InlineOptions: InlineOptions{Level: 4}}},
+		{name: "string slice", path: "tags", value: "a, b,,c", want: testConfig{Tags: []string{"a", "b", "c"}}},
+		{name: "string map", path: "headers", value: "a=1, b = 2", want: testConfig{Headers: map[string]string{"a": "1", "b": "2"}}},
+		{name: "invalid map item", path: "headers", value: "a", wantErr: true},
+		{name: "nested struct", path: "nested.host", value: "localhost", want: testConfig{Nested: testNested{Host: "localhost"}}},
+		{name: "nested duration", path: "nested.timeout", value: "1m30s", want: testConfig{Nested: testNested{Timeout: 90 * time.Second}}},
+		{name: "invalid duration", path: "nested.timeout", value: "90", wantErr: true},
+		{name: "nil struct pointer", path: "nested_p.host", value: "localhost", want: testConfig{NestedP: &testNested{Host: "localhost"}}},
+		{name: "int pointer", path: "replicas", value: "3", want: testConfig{Replicas: &replicas}},
+		{name: "duration pointer", path: "interval", value: "30s", want: testConfig{Interval: &interval}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testConfig
+			v, ok := lookupField(reflect.ValueOf(&got), strings.Split(tt.path, "."))
+			if !ok {
+				t.Fatalf("lookupField(%s) not found", tt.path)
+			}
+			err := setFieldValue(v, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setFieldValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("setFieldValue() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	t.Setenv("PIXIU_MYSQL_HOST", "env-host")
+	t.Setenv("PIXIU_MYSQL_PORT", "3307")
+	t.Setenv("PIXIU_TRACE_HEADERS", "authorization=token")
+
+	o := &Options{ComponentConfig: config.Config{}}
+	o.ComponentConfig.Mysql.Host = "file-host"
+	if err := o.applyOverrides(); err != nil {
+		t.Fatalf("applyOverrides() error: %v", err)
+	}
+	if o.ComponentConfig.Mysql.Host != "env-host" || o.ComponentConfig.Mysql.Port != 3307 {
+		t.Errorf("applyOverrides() mysql = %s:%d, want env-host:3307", o.ComponentConfig.Mysql.Host, o.ComponentConfig.Mysql.Port)
+	}
+	if got := o.ComponentConfig.Trace.Headers["authorization"]; got != "token" {
+		t.Errorf("applyOverrides() trace headers = %v", o.ComponentConfig.Trace.Headers)
+	}
+}
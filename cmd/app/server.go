@@ -18,17 +18,24 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
 
 	"github.com/caoyingjunz/pixiu/api/server/router"
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
 	"github.com/caoyingjunz/pixiu/cmd/app/options"
 )
 
@@ -37,13 +44,22 @@ func NewServerCommand(version string) *cobra.Command {
 	if err != nil {
 		klog.Fatalf("unable to initialize command options: %v", err)
 	}
+	opts.Version = version
 
 	cmd := &cobra.Command{
 		Use:  "pixiu-server",
 		Long: "The pixiu server controller is a daemon that embeds the core control loops.",
 		Run: func(cmd *cobra.Command, args []string) {
+			if opts.ValidateConfig {
+				if err = opts.ValidateConfigFile(); err != nil {
+					printError(err)
+					os.Exit(1)
+				}
+				fmt.Printf("configuration file %s is valid\n", opts.ConfigFile)
+				return
+			}
 			if err = opts.Complete(); err != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", err)
+				printError(err)
 				os.Exit(1)
 			}
 			if err = opts.Validate(); err != nil {
@@ -66,7 +82,9 @@ func NewServerCommand(version string) *cobra.Command {
 	}
 
 	// 绑定命令行参数
-	opts.BindFlags(cmd)
+	if err = opts.BindFlags(cmd); err != nil {
+		klog.Fatalf("unable to bind command flags: %v", err)
+	}
 
 	verCmd := &cobra.Command{
 		Use:   "version",
@@ -76,10 +94,80 @@ func NewServerCommand(version string) *cobra.Command {
 			fmt.Println(version)
 		},
 	}
-	cmd.AddCommand(verCmd)
+	initCmd := &cobra.Command{
+		Use:   "init",
+		Short: "Initialize the database",
+		Long:  "Create the database tables, the built-in roles, the menus and the default admin user, it is safe to run repeatedly.",
+		Run: func(cmd *cobra.Command, args []string) {
+			password, err := opts.Bootstrap()
+			if err != nil {
+				printError(err)
+				os.Exit(1)
+			}
+			// 随机生成的初始密码只在这里输出一次
+			if len(password) != 0 {
+				fmt.Printf("created default admin user %s with password %s, please change it after login\n", opts.ComponentConfig.Bootstrap.AdminUser, password)
+			}
+			fmt.Println("pixiu is initialized")
+		},
+	}
+	cmd.AddCommand(verCmd, initCmd)
 	return cmd
 }
 
+// printError 逐行打印汇总的错误，便于一次修复所有的配置问题
+func printError(err error) {
+	agg, ok := err.(utilerrors.Aggregate)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "found %d configuration errors:\n", len(agg.Errors()))
+	for _, e := range agg.Errors() {
+		fmt.Fprintf(os.Stderr, "  - %v\n", e)
+	}
+}
+
+// newTLSConfig 配置客户端证书的 CA 时，开启双向认证
+func newTLSConfig(t *config.TLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(t.ClientCAFile) == 0 {
+		return tlsConfig, nil
+	}
+
+	data, err := os.ReadFile(t.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no valid certificate found in client_ca_file %s", t.ClientCAFile)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = t.ClientAuthType()
+	return tlsConfig, nil
+}
+
+// newRedirectServer 将 http 请求重定向到 https 端口
+func newRedirectServer(redirectListen int, listen int) *http.Server {
+	return &http.Server{
+		Addr: fmt.Sprintf(":%d", redirectListen),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.Host)
+			if err != nil {
+				host = r.Host
+			}
+			target := url.URL{
+				Scheme:   "https",
+				Host:     net.JoinHostPort(host, strconv.Itoa(listen)),
+				Path:     r.URL.Path,
+				RawQuery: r.URL.RawQuery,
+			}
+			http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+		}),
+	}
+}
+
 // Run 优雅启动貔貅服务
 func Run(opt *options.Options) error {
 	srv := &http.Server{
@@ -87,9 +175,27 @@ func Run(opt *options.Options) error {
 		Handler: opt.HttpEngine,
 	}
 
+	var redirectSrv, adminSrv *http.Server
+	if opt.AdminEngine != nil {
+		adminSrv = &http.Server{
+			Addr:    opt.ComponentConfig.Admin.Addr(),
+			Handler: opt.AdminEngine,
+		}
+	}
+	if t := opt.ComponentConfig.TLS; t != nil {
+		tlsConfig, err := newTLSConfig(t)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = tlsConfig
+		if t.RedirectListen != 0 {
+			redirectSrv = newRedirectServer(t.RedirectListen, opt.ComponentConfig.Default.Listen)
+		}
+	}
+
 	// TODO: 暂未设置优雅退出
 	// 启动集群相关控制器
-	runers := []func(context.Context, int) error{opt.Controller.Plan().Run, opt.Controller.Cluster().Run}
+	runers := []func(context.Context, int) error{opt.Controller.Plan().Run, opt.Controller.Cluster().Run, opt.Controller.Replication().Run, opt.Controller.Operator().Run}
 	for _, runner := range runers {
 		if err := runner(context.TODO(), 5); err != nil {
 			klog.Fatal("failed to start manager: ", err)
@@ -113,12 +219,28 @@ func Run(opt *options.Options) error {
 			klog.Fatal("failed to listen pixiu server: ", err)
 		}
 	}()
+	if adminSrv != nil {
+		go func() {
+			klog.Infof("starting pixiu admin server on %s", adminSrv.Addr)
+			if err := adminSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				klog.Fatal("failed to listen pixiu admin server: ", err)
+			}
+		}()
+	}
+	if redirectSrv != nil {
+		go func() {
+			klog.Infof("redirecting http requests on %s to https", redirectSrv.Addr)
+			if err := redirectSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				klog.Fatal("failed to listen pixiu redirect server: ", err)
+			}
+		}()
+	}
 
 	klog.Info("starting job manager")
 	opt.JobManager.Run()
 
 	// Wait for interrupt signal to gracefully shut down the server with a timeout of 5 seconds.
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	klog.Info("shutting pixiu server down ...")
@@ -127,6 +249,16 @@ func Run(opt *options.Options) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			klog.Errorf("pixiu admin server forced to shutdown: %v", err)
+		}
+	}
+	if redirectSrv != nil {
+		if err := redirectSrv.Shutdown(ctx); err != nil {
+			klog.Errorf("pixiu redirect server forced to shutdown: %v", err)
+		}
+	}
 	if err := srv.Shutdown(ctx); err != nil {
 		klog.Fatalf("pixiu server forced to shutdown: %v", err)
 	}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/casbin/casbin/v2"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util"
+)
+
+const (
+	// ReadonlyGroup 内置的只读角色，可以查看除用户和调试接口以外的全部资源
+	ReadonlyGroup = "readonly"
+
+	passwordLetters = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	passwordLength  = 16
+)
+
+// readonlyObjects 只读角色可以查看的资源
+var readonlyObjects = []model.ObjectType{
+	model.ObjectCluster,
+	model.ObjectTenant,
+	model.ObjectPlan,
+	model.ObjectPipeline,
+	model.ObjectPropagation,
+	model.ObjectFleet,
+	model.ObjectTemplate,
+	model.ObjectReplication,
+}
+
+// Seed 初始化内置角色，菜单和默认管理员，可以重复执行
+// 随机生成默认管理员的密码时返回该密码，由调用方决定输出的方式，不会写入日志
+func Seed(ctx context.Context, cfg config.BootstrapOptions, f db.ShareDaoFactory, enforcer *casbin.SyncedEnforcer) (string, error) {
+	if err := seedRoles(enforcer); err != nil {
+		return "", fmt.Errorf("failed to seed roles: %v", err)
+	}
+	if err := seedMenus(ctx, f); err != nil {
+		return "", fmt.Errorf("failed to seed menus: %v", err)
+	}
+	password, err := seedAdmin(ctx, cfg, f, enforcer)
+	if err != nil {
+		return "", fmt.Errorf("failed to seed admin user: %v", err)
+	}
+	return password, nil
+}
+
+// WritePassword 将随机生成的初始密码写入仅属主可读的文件
+func WritePassword(path string, user string, password string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(fmt.Sprintf("%s:%s\n", user, password)), 0600)
+}
+
+func seedRoles(enforcer *casbin.SyncedEnforcer) error {
+	policies := [][]string{model.AdminPolicy.Raw()}
+	for _, obj := range readonlyObjects {
+		policies = append(policies, model.NewGroupPolicy(ReadonlyGroup, obj, model.SidAll, model.OpRead).Raw())
+	}
+	// 批量写入，已存在的策略直接忽略
+	_, err := enforcer.AddPoliciesEx(policies)
+	return err
+}
+
+// seedAdmin 不存在超级管理员时创建默认管理员，首次登陆后必须修改密码
+func seedAdmin(ctx context.Context, cfg config.BootstrapOptions, f db.ShareDaoFactory, enforcer *casbin.SyncedEnforcer) (string, error) {
+	root, err := f.User().GetRoot(ctx)
+	if err != nil {
+		return "", err
+	}
+	if root != nil {
+		return "", nil
+	}
+	object, err := f.User().GetUserByName(ctx, cfg.AdminUser)
+	if err != nil {
+		return "", err
+	}
+	if object != nil {
+		return "", fmt.Errorf("user %s already exists but is not root", cfg.AdminUser)
+	}
+
+	password := cfg.AdminPassword
+	generated := len(password) == 0
+	if generated {
+		if password, err = randomPassword(); err != nil {
+			return "", err
+		}
+	}
+	encrypt, err := util.EncryptUserPassword(password)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = f.User().Create(ctx, &model.User{
+		Name:                   cfg.AdminUser,
+		Password:               encrypt,
+		Role:                   model.RoleRoot,
+		Description:            "默认管理员",
+		PasswordChangeRequired: true,
+	}, func() error {
+		_, err := enforcer.AddGroupingPolicy(model.NewGroupBinding(cfg.AdminUser, model.AdminGroup).Raw())
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	klog.Infof("created default admin user %s, please change the password after login", cfg.AdminUser)
+	if generated {
+		return password, nil
+	}
+	return "", nil
+}
+
+// randomPassword 生成满足密码强度要求的随机密码，包含大小写字母和数字
+func randomPassword() (string, error) {
+	for {
+		b := make([]byte, passwordLength)
+		for i := range b {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(passwordLetters))))
+			if err != nil {
+				return "", err
+			}
+			b[i] = passwordLetters[n.Int64()]
+		}
+		if password := string(b); util.ValidateStrongPassword(password) {
+			return password, nil
+		}
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+// defaultMenus 内置的菜单树，父菜单需要排在子菜单之前
+var defaultMenus = []model.Menu{
+	{Name: "overview", Title: "概览", Path: "/overview", Icon: "dashboard", Sort: 10},
+	{Name: "clusters", Title: "容器服务", Path: "/clusters", Icon: "cluster", ObjectType: model.ObjectCluster, Sort: 20},
+	{Name: "plans", Title: "部署计划", Path: "/plans", Icon: "plan", ObjectType: model.ObjectPlan, Sort: 30},
+	{Name: "tenants", Title: "租户管理", Path: "/tenants", Icon: "tenant", ObjectType: model.ObjectTenant, Sort: 40},
+	{Name: "pipelines", Title: "流水线", Path: "/pipelines", Icon: "pipeline", ObjectType: model.ObjectPipeline, Sort: 50},
+	{Name: "apps", Title: "应用中心", Path: "/apps", Icon: "app", Sort: 60},
+	{Name: "templates", Title: "应用模板", Path: "/apps/templates", Parent: "apps", ObjectType: model.ObjectTemplate, Sort: 61},
+	{Name: "propagations", Title: "多集群分发", Path: "/apps/propagations", Parent: "apps", ObjectType: model.ObjectPropagation, Sort: 62},
+	{Name: "replications", Title: "资源复制", Path: "/apps/replications", Parent: "apps", ObjectType: model.ObjectReplication, Sort: 63},
+	{Name: "kubeconfigs", Title: "访问凭证", Path: "/kubeconfigs", Icon: "key", ObjectType: model.ObjectKubeConfig, Sort: 70},
+	{Name: "system", Title: "系统管理", Path: "/system", Icon: "setting", Sort: 80},
+	{Name: "users", Title: "用户管理", Path: "/system/users", Parent: "system", ObjectType: model.ObjectUser, Sort: 81},
+	{Name: "auth", Title: "权限管理", Path: "/system/auth", Parent: "system", ObjectType: model.ObjectAuth, Sort: 82},
+	{Name: "announcements", Title: "系统公告", Path: "/system/announcements", Parent: "system", ObjectType: model.ObjectAnnouncement, Sort: 83},
+}
+
+// seedMenus 创建不存在的内置菜单，已存在的菜单保持不变，便于在数据库中调整
+func seedMenus(ctx context.Context, f db.ShareDaoFactory) error {
+	for _, m := range defaultMenus {
+		object, err := f.Menu().GetByName(ctx, m.Name)
+		if err != nil {
+			return err
+		}
+		if object != nil {
+			continue
+		}
+		menu := m
+		if _, err = f.Menu().Create(ctx, &menu); err != nil {
+			return err
+		}
+	}
+	return nil
+}
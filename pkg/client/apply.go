@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery"
+	memory "k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/restmapper"
+)
+
+// FieldManager pixiu 使用 server-side apply 时的字段管理者
+const FieldManager = "pixiu"
+
+// DecodeManifest 解析多文档的 yaml 或者 json，跳过空文档
+func DecodeManifest(manifest []byte) ([]*unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+
+	objects := make([]*unstructured.Unstructured, 0)
+	for {
+		var raw map[string]interface{}
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(raw) == 0 {
+			continue
+		}
+		object := &unstructured.Unstructured{Object: raw}
+		if len(object.GetKind()) == 0 || len(object.GetName()) == 0 {
+			return nil, fmt.Errorf("kind and metadata.name are required")
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// ApplyManifest 通过 server-side apply 将 manifest 中的对象提交到集群，等同于 kubectl apply --server-side
+// namespace 为未指定命名空间的命名空间级对象的默认命名空间，dryRun 时仅由 apiserver 校验并返回结果，不会持久化
+func ApplyManifest(ctx context.Context, cs ClusterSet, namespace string, manifest []byte, dryRun bool) ([]*unstructured.Unstructured, error) {
+	objects, err := DecodeManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyObjects(ctx, cs, namespace, objects, dryRun)
+}
+
+// ApplyObjects 提交已解析的对象，用于提交前需要修改对象的场景
+func ApplyObjects(ctx context.Context, cs ClusterSet, namespace string, objects []*unstructured.Unstructured, dryRun bool) ([]*unstructured.Unstructured, error) {
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cs.Config)
+	if err != nil {
+		return nil, err
+	}
+	cachedClient := memory.NewMemCacheClient(discoveryClient)
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(cachedClient)
+
+	// 提交前校验所有对象的 apiVersion，避免部分对象提交后失败
+	for _, object := range objects {
+		if err = CheckAPIKind(cachedClient, object.GroupVersionKind()); err != nil {
+			return nil, fmt.Errorf("%s %s: %v", object.GetKind(), object.GetName(), err)
+		}
+	}
+
+	applied := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, object := range objects {
+		gvk := object.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return applied, fmt.Errorf("%s %s: %v", gvk.Kind, object.GetName(), err)
+		}
+
+		data, err := json.Marshal(object)
+		if err != nil {
+			return applied, err
+		}
+
+		resource := cs.Dynamic.Resource(mapping.Resource)
+		force := true
+		options := metav1.PatchOptions{FieldManager: FieldManager, Force: &force}
+		if dryRun {
+			options.DryRun = []string{metav1.DryRunAll}
+		}
+
+		var result *unstructured.Unstructured
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			ns := object.GetNamespace()
+			if len(ns) == 0 {
+				ns = namespace
+			}
+			result, err = resource.Namespace(ns).Patch(ctx, object.GetName(), types.ApplyPatchType, data, options)
+		} else {
+			result, err = resource.Patch(ctx, object.GetName(), types.ApplyPatchType, data, options)
+		}
+		if err != nil {
+			return applied, fmt.Errorf("%s %s: %v", gvk.Kind, object.GetName(), err)
+		}
+		applied = append(applied, result)
+	}
+
+	return applied, nil
+}
@@ -21,11 +21,13 @@ import (
 	"sync"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	appsv1 "k8s.io/client-go/listers/apps/v1"
 	batchv1 "k8s.io/client-go/listers/batch/v1"
 	v1 "k8s.io/client-go/listers/core/v1"
+	networkingv1 "k8s.io/client-go/listers/networking/v1"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	resourceclient "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
@@ -35,9 +37,15 @@ var (
 	groupVersionResources = []schema.GroupVersionResource{
 		{Group: "", Version: "v1", Resource: "pods"},
 		{Group: "", Version: "v1", Resource: "nodes"},
+		{Group: "", Version: "v1", Resource: "namespaces"},
+		{Group: "", Version: "v1", Resource: "services"},
+		{Group: "", Version: "v1", Resource: "secrets"},
+		{Group: "", Version: "v1", Resource: "configmaps"},
 		{Group: "apps", Version: "v1", Resource: "deployments"},
 		{Group: "apps", Version: "v1", Resource: "statefulsets"},
 		{Group: "apps", Version: "v1", Resource: "daemonsets"},
+		{Group: "apps", Version: "v1", Resource: "replicasets"},
+		{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
 		{Group: "batch", Version: "v1", Resource: "cronjobs"},
 		{Group: "batch", Version: "v1", Resource: "jobs"},
 	}
@@ -60,6 +68,18 @@ func (p PixiuInformer) NamespacesLister() v1.NamespaceLister {
 	return p.Shared.Core().V1().Namespaces().Lister()
 }
 
+func (p PixiuInformer) ServicesLister() v1.ServiceLister {
+	return p.Shared.Core().V1().Services().Lister()
+}
+
+func (p PixiuInformer) SecretsLister() v1.SecretLister {
+	return p.Shared.Core().V1().Secrets().Lister()
+}
+
+func (p PixiuInformer) ConfigMapsLister() v1.ConfigMapLister {
+	return p.Shared.Core().V1().ConfigMaps().Lister()
+}
+
 func (p PixiuInformer) DeploymentsLister() appsv1.DeploymentLister {
 	return p.Shared.Apps().V1().Deployments().Lister()
 }
@@ -72,6 +92,14 @@ func (p *PixiuInformer) DaemonSetsLister() appsv1.DaemonSetLister {
 	return p.Shared.Apps().V1().DaemonSets().Lister()
 }
 
+func (p *PixiuInformer) ReplicaSetsLister() appsv1.ReplicaSetLister {
+	return p.Shared.Apps().V1().ReplicaSets().Lister()
+}
+
+func (p *PixiuInformer) IngressesLister() networkingv1.IngressLister {
+	return p.Shared.Networking().V1().Ingresses().Lister()
+}
+
 func (p *PixiuInformer) CronJobsLister() batchv1.CronJobLister {
 	return p.Shared.Batch().V1().CronJobs().Lister()
 }
@@ -82,20 +110,29 @@ type ClusterSet struct {
 	Client   *kubernetes.Clientset
 	Config   *restclient.Config
 	Metric   *resourceclient.MetricsV1beta1Client
+	Dynamic  dynamic.Interface
 	Informer *PixiuInformer
 }
 
-func (cs *ClusterSet) Complete(cfg []byte) error {
+func (cs *ClusterSet) Complete(cfg []byte, opts ...Option) error {
 	var err error
 	if cs.Config, err = clientcmd.RESTConfigFromKubeConfig(cfg); err != nil {
 		return err
 	}
+	for _, opt := range opts {
+		if err = opt(cs.Config); err != nil {
+			return err
+		}
+	}
 	if cs.Client, err = kubernetes.NewForConfig(cs.Config); err != nil {
 		return err
 	}
 	if cs.Metric, err = resourceclient.NewForConfig(cs.Config); err != nil {
 		return err
 	}
+	if cs.Dynamic, err = dynamic.NewForConfig(cs.Config); err != nil {
+		return err
+	}
 
 	sharedInformer, cancel, err := NewSharedInformers(cs.Config)
 	if err != nil {
@@ -32,32 +32,37 @@ func ParseKubeConfigBytes(cfg string) ([]byte, error) {
 	return kubeConfigBytes, err
 }
 
-func NewClientSetFromBytes(data []byte) (*kubernetes.Clientset, error) {
+func NewClientSetFromBytes(data []byte, opts ...Option) (*kubernetes.Clientset, error) {
 	config, err := clientcmd.RESTConfigFromKubeConfig(data)
 	if err != nil {
 		return nil, err
 	}
+	for _, opt := range opts {
+		if err = opt(config); err != nil {
+			return nil, err
+		}
+	}
 
 	return kubernetes.NewForConfig(config)
 }
 
-func NewClientSetFromString(cfg string) (*kubernetes.Clientset, error) {
+func NewClientSetFromString(cfg string, opts ...Option) (*kubernetes.Clientset, error) {
 	kubeConfigBytes, err := ParseKubeConfigBytes(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewClientSetFromBytes(kubeConfigBytes)
+	return NewClientSetFromBytes(kubeConfigBytes, opts...)
 }
 
-func NewClusterSet(cfg string) (*ClusterSet, error) {
+func NewClusterSet(cfg string, opts ...Option) (*ClusterSet, error) {
 	kubeConfigBytes, err := ParseKubeConfigBytes(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	cs := &ClusterSet{}
-	if err = cs.Complete(kubeConfigBytes); err != nil {
+	if err = cs.Complete(kubeConfigBytes, opts...); err != nil {
 		return nil, err
 	}
 
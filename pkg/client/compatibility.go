@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	memory "k8s.io/client-go/discovery/cached"
+)
+
+// APIVersionError 集群不提供指定 apiVersion 的资源，例如 1.25 及以上版本的 batch/v1beta1 CronJob
+type APIVersionError struct {
+	GroupVersion  schema.GroupVersion
+	Name          string
+	ServerVersion string
+	// 集群中提供该资源的 apiVersion
+	Suggestions []string
+}
+
+func (e *APIVersionError) Error() string {
+	msg := fmt.Sprintf("集群 %s 不支持 %s %s", e.ServerVersion, e.GroupVersion.String(), e.Name)
+	if len(e.Suggestions) != 0 {
+		msg += fmt.Sprintf("，请使用 apiVersion: %s", strings.Join(e.Suggestions, " 或 "))
+	}
+	return msg
+}
+
+// CheckAPIKind 校验集群是否提供指定 group/version 的 kind
+func CheckAPIKind(dc discovery.DiscoveryInterface, gvk schema.GroupVersionKind) error {
+	return checkAPI(dc, gvk.GroupVersion(), gvk.Kind, func(r metav1.APIResource) bool {
+		return r.Kind == gvk.Kind
+	})
+}
+
+// CheckAPIResource 校验集群是否提供指定 group/version 的资源，resource 为复数形式，例如 cronjobs
+func CheckAPIResource(dc discovery.DiscoveryInterface, gv schema.GroupVersion, resource string) error {
+	return checkAPI(dc, gv, resource, func(r metav1.APIResource) bool {
+		return r.Name == resource
+	})
+}
+
+func checkAPI(dc discovery.DiscoveryInterface, gv schema.GroupVersion, name string, match func(metav1.APIResource) bool) error {
+	resources, err := dc.ServerResourcesForGroupVersion(gv.String())
+	if err == nil {
+		if hasAPIResource(resources, match) {
+			return nil
+		}
+	} else if !apierrors.IsNotFound(err) && err != memory.ErrCacheNotFound {
+		return err
+	}
+
+	e := &APIVersionError{GroupVersion: gv, Name: name, ServerVersion: "unknown"}
+	if info, err := dc.ServerVersion(); err == nil {
+		e.ServerVersion = info.GitVersion
+	}
+	// 部分 group 获取失败时仍返回可用的结果
+	preferred, _ := dc.ServerPreferredResources()
+	for _, list := range preferred {
+		if list.GroupVersion != gv.String() && hasAPIResource(list, match) {
+			e.Suggestions = append(e.Suggestions, list.GroupVersion)
+		}
+	}
+	return e
+}
+
+func hasAPIResource(list *metav1.APIResourceList, match func(metav1.APIResource) bool) bool {
+	if list == nil {
+		return false
+	}
+	for _, r := range list.APIResources {
+		// 跳过子资源，例如 deployments/scale
+		if strings.Contains(r.Name, "/") {
+			continue
+		}
+		if match(r) {
+			return true
+		}
+	}
+	return false
+}
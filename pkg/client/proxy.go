@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	restclient "k8s.io/client-go/rest"
+
+	"github.com/caoyingjunz/pixiu/pkg/tunnel"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+// Option 构造客户端前对 rest.Config 的设置
+type Option func(config *restclient.Config) error
+
+// WithProxy 通过集群配置的代理访问 API server，proxy 为 types.ClusterProxy 的 json 字符串，为空时直连
+// 注意: exec 和 port-forward 使用的 SPDY 连接仅支持 http 代理，不支持通过 socks5，ssh 和 agent 访问
+func WithProxy(proxy string) Option {
+	return func(config *restclient.Config) error {
+		if len(proxy) == 0 {
+			return nil
+		}
+		var p types.ClusterProxy
+		if err := p.Unmarshal(proxy); err != nil {
+			return err
+		}
+		return applyProxy(config, &p)
+	}
+}
+
+func applyProxy(config *restclient.Config, p *types.ClusterProxy) error {
+	switch p.Type {
+	case "":
+		return nil
+	case types.ClusterProxyTypeHTTP, types.ClusterProxyTypeSOCKS5:
+		u, err := url.Parse(p.URL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy url: %v", err)
+		}
+		if len(u.Host) == 0 {
+			return fmt.Errorf("proxy url %s has no host", u.Redacted())
+		}
+		if p.Type == types.ClusterProxyTypeSOCKS5 && u.Scheme != "socks5" {
+			return fmt.Errorf("unsupported socks5 proxy scheme %q", u.Scheme)
+		}
+		if p.Type == types.ClusterProxyTypeHTTP && u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("unsupported http proxy scheme %q", u.Scheme)
+		}
+		// net/http 的 Transport 原生支持 socks5 代理
+		config.Proxy = http.ProxyURL(u)
+	case types.ClusterProxyTypeAgent:
+		name := p.Agent
+		config.Dial = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			t, ok := tunnel.Agents.Get(name)
+			if !ok {
+				return nil, fmt.Errorf("agent of cluster %s is not connected", name)
+			}
+			return t.DialContext(ctx, network, addr)
+		}
+	case types.ClusterProxyTypeSSH:
+		tunnel, err := newSSHTunnel(p.SSH)
+		if err != nil {
+			return err
+		}
+		config.Dial = tunnel.DialContext
+	default:
+		return fmt.Errorf("unsupported proxy type %q", p.Type)
+	}
+	return nil
+}
+
+// sshTunnel 复用到跳板机的 ssh 连接，连接断开后在下次拨号时重建
+type sshTunnel struct {
+	addr   string
+	config *ssh.ClientConfig
+
+	lock   sync.Mutex
+	client *ssh.Client
+}
+
+func newSSHTunnel(s *types.ClusterProxySSH) (*sshTunnel, error) {
+	if s == nil || len(s.Host) == 0 || len(s.User) == 0 {
+		return nil, fmt.Errorf("ssh proxy requires host and user")
+	}
+	var auth []ssh.AuthMethod
+	if len(s.PrivateKey) != 0 {
+		signer, err := ssh.ParsePrivateKey([]byte(s.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ssh private key: %v", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if len(s.Password) != 0 {
+		auth = append(auth, ssh.Password(s.Password))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("ssh proxy requires password or private key")
+	}
+
+	port := s.Port
+	if port == 0 {
+		port = 22
+	}
+	return &sshTunnel{
+		addr: net.JoinHostPort(s.Host, strconv.Itoa(port)),
+		config: &ssh.ClientConfig{
+			User:            s.User,
+			Auth:            auth,
+			Timeout:         10 * time.Second,
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 忽略 know_hosts 检查
+		},
+	}, nil
+}
+
+func (t *sshTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	c, err := t.getClient()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := c.Dial(network, addr)
+	if err == nil {
+		return conn, nil
+	}
+
+	// ssh 连接可能已失效，重建后重试一次
+	t.reset(c)
+	if c, err = t.getClient(); err != nil {
+		return nil, err
+	}
+	return c.Dial(network, addr)
+}
+
+func (t *sshTunnel) getClient() (*ssh.Client, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.client != nil {
+		return t.client, nil
+	}
+	c, err := ssh.Dial("tcp", t.addr, t.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect ssh bastion %s: %v", t.addr, err)
+	}
+	t.client = c
+	return c, nil
+}
+
+func (t *sshTunnel) reset(c *ssh.Client) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.client == c {
+		_ = t.client.Close()
+		t.client = nil
+	}
+}
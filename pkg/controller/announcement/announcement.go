@@ -0,0 +1,222 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package announcement
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/notifier"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type AnnouncementGetter interface {
+	Announcement() Interface
+}
+
+// Interface 管理员发布的平台公告，例如维护通知
+type Interface interface {
+	Create(ctx context.Context, req *types.CreateAnnouncementRequest) (*types.Announcement, error)
+	Update(ctx context.Context, aid int64, req *types.UpdateAnnouncementRequest) error
+	Delete(ctx context.Context, aid int64) error
+	Get(ctx context.Context, aid int64) (*types.Announcement, error)
+	List(ctx context.Context) ([]types.Announcement, error)
+
+	// ListActive 获取当前生效的公告，供前端轮询
+	ListActive(ctx context.Context) ([]types.Announcement, error)
+}
+
+type announcement struct {
+	cc      config.Config
+	factory db.ShareDaoFactory
+}
+
+func (a *announcement) Create(ctx context.Context, req *types.CreateAnnouncementRequest) (*types.Announcement, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	object, err := a.factory.Announcement().Create(ctx, &model.Announcement{
+		Title:     req.Title,
+		Content:   req.Content,
+		Severity:  req.Severity,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		Publisher: user.Name,
+	})
+	if err != nil {
+		klog.Errorf("failed to create announcement %s: %v", req.Title, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	if req.Notify {
+		go a.broadcast(*object)
+	}
+	return model2Type(object), nil
+}
+
+// broadcast 通过通知渠道推送给所有用户，用户较多时耗时较长，因此异步执行
+func (a *announcement) broadcast(object model.Announcement) {
+	ctx := context.TODO()
+	users, err := a.factory.User().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list users for announcement(%d): %v", object.Id, err)
+		return
+	}
+
+	userIds := make([]int64, len(users))
+	for i, user := range users {
+		userIds[i] = user.Id
+	}
+	if err = notifier.New(a.factory, a.cc.Notification).Broadcast(ctx, userIds, notifier.Message{
+		Kind:    notifier.KindAnnouncement,
+		Title:   object.Title,
+		Content: object.Content,
+		Ref:     fmt.Sprintf("announcement/%d", object.Id),
+	}); err != nil {
+		klog.Errorf("failed to broadcast announcement(%d): %v", object.Id, err)
+	}
+}
+
+func (a *announcement) Update(ctx context.Context, aid int64, req *types.UpdateAnnouncementRequest) error {
+	object, err := a.get(ctx, aid)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Title != nil {
+		updates["title"] = *req.Title
+	}
+	if req.Content != nil {
+		updates["content"] = *req.Content
+	}
+	if req.Severity != nil {
+		updates["severity"] = *req.Severity
+	}
+	start, end := object.StartTime, object.EndTime
+	if req.StartTime != nil {
+		start = *req.StartTime
+		updates["start_time"] = start
+	}
+	if req.EndTime != nil {
+		end = *req.EndTime
+		updates["end_time"] = end
+	}
+	if len(updates) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if !end.After(start) {
+		return errors.NewError(fmt.Errorf("结束时间需晚于开始时间"), http.StatusBadRequest)
+	}
+
+	if err = a.factory.Announcement().Update(ctx, aid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update announcement(%d): %v", aid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (a *announcement) Delete(ctx context.Context, aid int64) error {
+	if _, err := a.get(ctx, aid); err != nil {
+		return err
+	}
+	if err := a.factory.Announcement().Delete(ctx, aid); err != nil {
+		klog.Errorf("failed to delete announcement(%d): %v", aid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (a *announcement) Get(ctx context.Context, aid int64) (*types.Announcement, error) {
+	object, err := a.get(ctx, aid)
+	if err != nil {
+		return nil, err
+	}
+	return model2Type(object), nil
+}
+
+func (a *announcement) get(ctx context.Context, aid int64) (*model.Announcement, error) {
+	object, err := a.factory.Announcement().Get(ctx, aid)
+	if err != nil {
+		klog.Errorf("failed to get announcement(%d): %v", aid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrAnnouncementNotFound
+	}
+	return object, nil
+}
+
+func (a *announcement) List(ctx context.Context) ([]types.Announcement, error) {
+	return a.list(ctx, db.WithOrderByDesc())
+}
+
+func (a *announcement) ListActive(ctx context.Context) ([]types.Announcement, error) {
+	return a.list(ctx, db.WithActiveAt(time.Now()), db.WithOrderByDesc())
+}
+
+func (a *announcement) list(ctx context.Context, opts ...db.Options) ([]types.Announcement, error) {
+	objects, err := a.factory.Announcement().List(ctx, opts...)
+	if err != nil {
+		klog.Errorf("failed to list announcements: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	announcements := make([]types.Announcement, len(objects))
+	for i, object := range objects {
+		announcements[i] = *model2Type(&object)
+	}
+	return announcements, nil
+}
+
+func model2Type(o *model.Announcement) *types.Announcement {
+	now := time.Now()
+	return &types.Announcement{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Title:     o.Title,
+		Content:   o.Content,
+		Severity:  o.Severity,
+		StartTime: o.StartTime,
+		EndTime:   o.EndTime,
+		Publisher: o.Publisher,
+		Active:    !o.StartTime.After(now) && o.EndTime.After(now),
+	}
+}
+
+func NewAnnouncement(cfg config.Config, f db.ShareDaoFactory) *announcement {
+	return &announcement{
+		cc:      cfg,
+		factory: f,
+	}
+}
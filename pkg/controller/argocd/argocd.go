@@ -0,0 +1,234 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	defaultProject        = "default"
+	defaultTargetRevision = "HEAD"
+	defaultServer         = "https://kubernetes.default.svc"
+
+	// 触发同步时的操作发起人
+	operationInitiator = "pixiu"
+)
+
+var applicationGVR = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "applications"}
+
+type ArgoCDGetter interface {
+	ArgoCD(cluster string) Interface
+}
+
+// Interface 管理运行在 pixiu 集群中的 Argo CD Application
+// namespace 为 Argo CD 所在的命名空间，通常为 argocd
+type Interface interface {
+	Create(ctx context.Context, namespace string, req *types.CreateArgoApplicationRequest) (*types.ArgoApplication, error)
+	Delete(ctx context.Context, namespace string, name string) error
+	Get(ctx context.Context, namespace string, name string) (*types.ArgoApplication, error)
+	List(ctx context.Context, namespace string) ([]types.ArgoApplication, error)
+
+	// Sync 触发 Application 的同步操作，等同于 argocd app sync
+	Sync(ctx context.Context, namespace string, name string, req *types.SyncArgoApplicationRequest) error
+}
+
+type argocd struct {
+	cluster       string
+	clusterGetter cluster.Interface
+}
+
+func (a *argocd) client(ctx context.Context, namespace string) (dynamic.ResourceInterface, error) {
+	cs, err := a.clusterGetter.GetClusterSetByName(ctx, a.cluster)
+	if err != nil {
+		return nil, err
+	}
+	return cs.Dynamic.Resource(applicationGVR).Namespace(namespace), nil
+}
+
+func (a *argocd) Create(ctx context.Context, namespace string, req *types.CreateArgoApplicationRequest) (*types.ArgoApplication, error) {
+	c, err := a.client(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	project := req.Project
+	if len(project) == 0 {
+		project = defaultProject
+	}
+	revision := req.TargetRevision
+	if len(revision) == 0 {
+		revision = defaultTargetRevision
+	}
+	server := req.DestinationServer
+	if len(server) == 0 {
+		server = defaultServer
+	}
+
+	source := map[string]interface{}{
+		"repoURL":        req.RepoURL,
+		"targetRevision": revision,
+	}
+	if len(req.Path) != 0 {
+		source["path"] = req.Path
+	}
+	if len(req.Chart) != 0 {
+		source["chart"] = req.Chart
+	}
+	spec := map[string]interface{}{
+		"project": project,
+		"source":  source,
+		"destination": map[string]interface{}{
+			"server":    server,
+			"namespace": req.DestinationNamespace,
+		},
+	}
+	if req.AutoSync {
+		spec["syncPolicy"] = map[string]interface{}{
+			"automated": map[string]interface{}{},
+		}
+	}
+
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": applicationGVR.GroupVersion().String(),
+		"kind":       "Application",
+		"metadata": map[string]interface{}{
+			"name":      req.Name,
+			"namespace": namespace,
+		},
+		"spec": spec,
+	}}
+	created, err := c.Create(ctx, object, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("failed to create argo application %s/%s: %v", namespace, req.Name, err)
+		return nil, err
+	}
+
+	return parseApplication(created), nil
+}
+
+func (a *argocd) Delete(ctx context.Context, namespace string, name string) error {
+	c, err := a.client(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	return c.Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+func (a *argocd) Get(ctx context.Context, namespace string, name string) (*types.ArgoApplication, error) {
+	c, err := a.client(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	object, err := c.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseApplication(object), nil
+}
+
+func (a *argocd) List(ctx context.Context, namespace string) ([]types.ArgoApplication, error) {
+	c, err := a.client(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := c.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("failed to list argo applications in %s: %v", namespace, err)
+		return nil, err
+	}
+
+	apps := make([]types.ArgoApplication, len(objects.Items))
+	for i := range objects.Items {
+		apps[i] = *parseApplication(&objects.Items[i])
+	}
+	return apps, nil
+}
+
+// Sync 通过设置 Application 的 operation 字段触发同步，Argo CD 的 controller 会执行并清理该字段
+func (a *argocd) Sync(ctx context.Context, namespace string, name string, req *types.SyncArgoApplicationRequest) error {
+	c, err := a.client(ctx, namespace)
+	if err != nil {
+		return err
+	}
+
+	sync := map[string]interface{}{
+		"prune": req.Prune,
+	}
+	if len(req.Revision) != 0 {
+		sync["revision"] = req.Revision
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"operation": map[string]interface{}{
+			"initiatedBy": map[string]interface{}{
+				"username": operationInitiator,
+			},
+			"sync": sync,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err = c.Patch(ctx, name, apitypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		klog.Errorf("failed to sync argo application %s/%s: %v", namespace, name, err)
+		return err
+	}
+	return nil
+}
+
+func parseApplication(object *unstructured.Unstructured) *types.ArgoApplication {
+	app := &types.ArgoApplication{
+		Name:              object.GetName(),
+		Namespace:         object.GetNamespace(),
+		CreationTimestamp: object.GetCreationTimestamp().Time,
+	}
+
+	app.Project, _, _ = unstructured.NestedString(object.Object, "spec", "project")
+	app.RepoURL, _, _ = unstructured.NestedString(object.Object, "spec", "source", "repoURL")
+	app.Path, _, _ = unstructured.NestedString(object.Object, "spec", "source", "path")
+	app.Chart, _, _ = unstructured.NestedString(object.Object, "spec", "source", "chart")
+	app.TargetRevision, _, _ = unstructured.NestedString(object.Object, "spec", "source", "targetRevision")
+	app.DestinationServer, _, _ = unstructured.NestedString(object.Object, "spec", "destination", "server")
+	app.DestinationNamespace, _, _ = unstructured.NestedString(object.Object, "spec", "destination", "namespace")
+	_, app.AutoSync, _ = unstructured.NestedMap(object.Object, "spec", "syncPolicy", "automated")
+
+	app.SyncStatus, _, _ = unstructured.NestedString(object.Object, "status", "sync", "status")
+	app.HealthStatus, _, _ = unstructured.NestedString(object.Object, "status", "health", "status")
+	app.Revision, _, _ = unstructured.NestedString(object.Object, "status", "sync", "revision")
+	app.OperationPhase, _, _ = unstructured.NestedString(object.Object, "status", "operationState", "phase")
+	return app
+}
+
+func NewArgoCD(clusterName string, c cluster.Interface) *argocd {
+	return &argocd{
+		cluster:       clusterName,
+		clusterGetter: c,
+	}
+}
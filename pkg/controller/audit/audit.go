@@ -33,8 +33,14 @@ type AuditGetter interface {
 }
 
 type Interface interface {
-	List(ctx context.Context, listOption types.ListOptions) (interface{}, error)
+	// List 按条件分页查询审计记录，过滤条件和分页均在数据库中执行
+	List(ctx context.Context, opts types.ListAuditOptions) (interface{}, error)
 	Get(ctx context.Context, aid int64) (*types.Audit, error)
+	// Stream 按 id 升序分批读取符合条件的审计记录，逐条交给 fn 处理，fn 返回错误时停止
+	Stream(ctx context.Context, opts types.ListAuditOptions, fn func(types.Audit) error) error
+
+	// Verify 校验审计记录的哈希链，检测记录是否被篡改或者删除
+	Verify(ctx context.Context) (*types.AuditVerifyResult, error)
 }
 
 type audit struct {
@@ -54,16 +60,23 @@ func (a *audit) Get(ctx context.Context, aid int64) (*types.Audit, error) {
 	return a.model2Type(object), nil
 }
 
-func (a *audit) List(ctx context.Context, listOption types.ListOptions) (interface{}, error) {
+func (a *audit) List(ctx context.Context, opts types.ListAuditOptions) (interface{}, error) {
+	filters := auditFilters(opts)
 	// 获取对象总数量
-	total, err := a.factory.Audit().Count(ctx)
+	total, err := a.factory.Audit().Count(ctx, filters...)
 	if err != nil {
 		klog.Errorf("failed to get audits count: %v", err)
 		return nil, err
 	}
 
 	// 获取偏移列表
-	objects, err := a.factory.Audit().List(ctx, db.WithOffset(listOption.Page-1), db.WithLimit(int(listOption.Limit)), db.WithOrderByDesc())
+	listOption := opts.ListOptions
+	limit := int(listOption.Limit)
+	var offset int
+	if listOption.Page > 1 {
+		offset = (listOption.Page - 1) * limit
+	}
+	objects, err := a.factory.Audit().List(ctx, append(filters, db.WithOffset(offset), db.WithLimit(limit), db.WithOrderByDesc())...)
 	if err != nil {
 		klog.Errorf("failed to get audit events: %v", err)
 		return nil, errors.ErrServerInternal
@@ -80,6 +93,122 @@ func (a *audit) List(ctx context.Context, listOption types.ListOptions) (interfa
 	}, nil
 }
 
+// 哈希链校验和流式返回时每批读取的记录数量
+const verifyBatchSize = 500
+
+func (a *audit) Stream(ctx context.Context, opts types.ListAuditOptions, fn func(types.Audit) error) error {
+	filters := auditFilters(opts)
+	var lastId int64
+	for {
+		objects, err := a.factory.Audit().List(ctx, append(filters, db.WithIdAfter(lastId), db.WithOrderByASC(), db.WithLimit(verifyBatchSize))...)
+		if err != nil {
+			klog.Errorf("failed to list audits after %d: %v", lastId, err)
+			return errors.ErrServerInternal
+		}
+		for _, object := range objects {
+			lastId = object.Id
+			if err = fn(*a.model2Type(&object)); err != nil {
+				return err
+			}
+		}
+		if len(objects) < verifyBatchSize {
+			return nil
+		}
+	}
+}
+
+func auditFilters(opts types.ListAuditOptions) []db.Options {
+	return []db.Options{
+		db.WithAuditFields(map[string]string{
+			"operator":      opts.Operator,
+			"action":        opts.Action,
+			"resource_type": opts.ResourceType,
+		}),
+		db.WithAuditStatus(opts.Status),
+		db.WithAuditCluster(opts.Cluster),
+		db.WithCreatedBetween(opts.Start, opts.End),
+	}
+}
+
+func (a *audit) Verify(ctx context.Context) (*types.AuditVerifyResult, error) {
+	result := &types.AuditVerifyResult{}
+
+	// 先读取哈希链状态，只校验状态中最新一条记录及之前的记录，避免与并发写入的记录比对
+	chain, err := a.factory.Audit().GetChain(ctx)
+	if err != nil {
+		klog.Errorf("failed to get audit chain: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	opts := []db.Options{db.WithOrderByASC(), db.WithLimit(verifyBatchSize)}
+	if chain != nil && chain.HeadId != 0 {
+		opts = append(opts, db.WithIdNotAfter(chain.HeadId))
+	}
+
+	var (
+		lastId   int64
+		prevHash string
+		chained  bool
+		anchor   string
+		headId   int64
+		hashed   int64
+	)
+	for {
+		objects, err := a.factory.Audit().List(ctx, append(opts, db.WithIdAfter(lastId))...)
+		if err != nil {
+			klog.Errorf("failed to list audits after %d: %v", lastId, err)
+			return nil, errors.ErrServerInternal
+		}
+
+		for _, object := range objects {
+			lastId = object.Id
+			if len(object.Hash) == 0 {
+				// 启用哈希链之前的历史记录
+				if !chained {
+					result.Unchained++
+					continue
+				}
+				result.Checked++
+				result.Issues = append(result.Issues, types.AuditChainIssue{Id: object.Id, Reason: types.AuditChainMissingHash})
+				continue
+			}
+
+			result.Checked++
+			hashed++
+			// 集群名称不参与哈希计算，由请求路径重新解析后比对
+			if object.ComputeHash() != object.Hash || object.Cluster != model.AuditClusterFromPath(object.Path) {
+				result.Issues = append(result.Issues, types.AuditChainIssue{Id: object.Id, Reason: types.AuditChainModified})
+			}
+			if !chained {
+				chained = true
+				result.AnchorId = object.Id
+				anchor = object.PrevHash
+			} else if object.PrevHash != prevHash {
+				result.Issues = append(result.Issues, types.AuditChainIssue{Id: object.Id, Reason: types.AuditChainBroken})
+			}
+			prevHash = object.Hash
+			headId = object.Id
+		}
+		if len(objects) < verifyBatchSize {
+			break
+		}
+	}
+
+	if chain != nil {
+		if anchor != chain.AnchorPrevHash {
+			result.Issues = append(result.Issues, types.AuditChainIssue{Id: result.AnchorId, Reason: types.AuditChainHeadLost})
+		}
+		if headId != chain.HeadId || prevHash != chain.HeadHash {
+			result.Issues = append(result.Issues, types.AuditChainIssue{Id: chain.HeadId, Reason: types.AuditChainTailLost})
+		}
+		if hashed != chain.Records {
+			result.Issues = append(result.Issues, types.AuditChainIssue{Reason: types.AuditChainCountDiffer})
+		}
+	}
+
+	result.Verified = len(result.Issues) == 0
+	return result, nil
+}
+
 func (a *audit) model2Type(o *model.Audit) *types.Audit {
 	return &types.Audit{
 		PixiuMeta: types.PixiuMeta{
@@ -90,12 +219,16 @@ func (a *audit) model2Type(o *model.Audit) *types.Audit {
 			GmtCreate:   o.GmtCreate,
 			GmtModified: o.GmtModified,
 		},
-		Ip:         o.Ip,
-		Action:     o.Action,
-		Status:     o.Status,
-		Operator:   o.Operator,
-		Path:       o.Path,
-		ObjectType: o.ObjectType,
+		Ip:           o.Ip,
+		Action:       o.Action,
+		Status:       o.Status,
+		Operator:     o.Operator,
+		Path:         o.Path,
+		ObjectType:   o.ObjectType,
+		ChangeTicket: o.ChangeTicket,
+		ChangeReason: o.ChangeReason,
+		PrevHash:     o.PrevHash,
+		Hash:         o.Hash,
 	}
 }
 
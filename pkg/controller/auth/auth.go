@@ -22,6 +22,7 @@ import (
 	"net/http"
 
 	"github.com/casbin/casbin/v2"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"github.com/caoyingjunz/pixiu/api/server/errors"
@@ -43,7 +44,17 @@ type (
 
 		CreateGroupBinding(ctx context.Context, req *types.GroupBindingRequest) error
 		DeleteGroupBinding(ctx context.Context, req *types.GroupBindingRequest) error
+		// SetGroupBindings 批量绑定和解除绑定用户的用户组
+		SetGroupBindings(ctx context.Context, uid int64, req *types.SetGroupBindingsRequest) error
 		ListGroupBindings(ctx context.Context, req *types.ListGroupBindingRequest) ([]types.RBACPolicy, error)
+
+		// ExportRBAC 导出用户组，用户策略以及绑定关系
+		ExportRBAC(ctx context.Context) (*types.RBACDocument, error)
+		// ImportRBAC 声明式导入 RBAC 配置，支持 prune 和 dry-run
+		ImportRBAC(ctx context.Context, doc *types.RBACDocument, opts *types.ImportRBACOptions) (*types.RBACImportResult, error)
+
+		// ListMenus 返回当前用户有权限查看的菜单树
+		ListMenus(ctx context.Context) ([]types.Menu, error)
 	}
 )
 
@@ -222,6 +233,35 @@ func (a *auth) DeleteGroupBinding(ctx context.Context, req *types.GroupBindingRe
 	return nil
 }
 
+func (a *auth) SetGroupBindings(ctx context.Context, uid int64, req *types.SetGroupBindingsRequest) error {
+	user, err := a.factory.User().Get(ctx, uid)
+	if err != nil {
+		klog.Errorf("failed to get user(%d): %v", uid, err)
+		return errors.ErrServerInternal
+	}
+	if user == nil {
+		return errors.ErrUserNotFound
+	}
+	// 与声明式导入一致，管理员用户组 root 由平台维护
+	groups := sets.NewString(req.GroupNames...).Delete(model.AdminGroup)
+	for _, group := range groups.List() {
+		policy, err := ctrlutil.GetGroupPolicy(a.enforcer, group)
+		if err != nil {
+			klog.Errorf("failed to get group(%s): %v", group, err)
+			return errors.ErrServerInternal
+		}
+		if policy == nil {
+			return errors.NewError(fmt.Errorf("group(%s) is not found", group), http.StatusBadRequest)
+		}
+	}
+
+	if err = ctrlutil.SetGroupBindings(a.enforcer, user.Name, groups.List()...); err != nil {
+		klog.Errorf("failed to set user %s group bindings: %v", user.Name, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
 func (a *auth) ListGroupBindings(ctx context.Context, req *types.ListGroupBindingRequest) ([]types.RBACPolicy, error) {
 	conds := make([]ctrlutil.BindingQueryCondition, 0)
 	if req.UserId != nil {
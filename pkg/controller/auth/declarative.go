@@ -0,0 +1,318 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	RBACDocumentAPIVersion = "pixiu.io/v1"
+	RBACDocumentKind       = "RBAC"
+)
+
+// ExportRBAC 导出全部用户组，用户的策略以及用户和用户组的绑定关系
+// 策略的主体为已存在的用户时视为用户策略，否则视为用户组策略
+func (a *auth) ExportRBAC(ctx context.Context) (*types.RBACDocument, error) {
+	userNames, err := a.listUserNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policies, bindings, err := a.currentRules()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string]*types.RBACGroup)
+	users := make(map[string]*types.RBACUser)
+	getUser := func(name string) *types.RBACUser {
+		if _, ok := users[name]; !ok {
+			users[name] = &types.RBACUser{Name: name}
+		}
+		return users[name]
+	}
+	for _, p := range policies {
+		permit := types.RBACPermit{ObjectType: model.ObjectType(p[1]), SID: p[2], Operation: model.Operation(p[3])}
+		if userNames.Has(p[0]) {
+			u := getUser(p[0])
+			u.Policies = append(u.Policies, permit)
+			continue
+		}
+		if _, ok := groups[p[0]]; !ok {
+			groups[p[0]] = &types.RBACGroup{Name: p[0]}
+		}
+		groups[p[0]].Policies = append(groups[p[0]].Policies, permit)
+	}
+	for _, b := range bindings {
+		u := getUser(b[0])
+		u.Groups = append(u.Groups, b[1])
+	}
+
+	doc := &types.RBACDocument{APIVersion: RBACDocumentAPIVersion, Kind: RBACDocumentKind}
+	for _, g := range groups {
+		doc.Groups = append(doc.Groups, *g)
+	}
+	for _, u := range users {
+		sort.Strings(u.Groups)
+		doc.Users = append(doc.Users, *u)
+	}
+	sort.Slice(doc.Groups, func(i, j int) bool { return doc.Groups[i].Name < doc.Groups[j].Name })
+	sort.Slice(doc.Users, func(i, j int) bool { return doc.Users[i].Name < doc.Users[j].Name })
+	return doc, nil
+}
+
+// ImportRBAC 按文档补齐缺失的策略和绑定关系，重复导入不会产生变更
+// prune 时删除文档中未声明的策略和绑定关系，root 用户组的策略和绑定关系始终保留
+func (a *auth) ImportRBAC(ctx context.Context, doc *types.RBACDocument, opts *types.ImportRBACOptions) (*types.RBACImportResult, error) {
+	userNames, err := a.listUserNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	desiredPolicies, desiredBindings, err := parseDocument(doc, userNames)
+	if err != nil {
+		return nil, err
+	}
+	policies, bindings, err := a.currentRules()
+	if err != nil {
+		return nil, err
+	}
+	currentPolicies := make(map[string][]string)
+	for _, p := range policies {
+		currentPolicies[ruleKey(p)] = p
+	}
+	currentBindings := make(map[string][]string)
+	for _, b := range bindings {
+		currentBindings[ruleKey(b)] = b
+	}
+
+	var addPolicies, removePolicies, addBindings, removeBindings [][]string
+	for key, p := range desiredPolicies {
+		if _, ok := currentPolicies[key]; !ok {
+			addPolicies = append(addPolicies, p)
+		}
+	}
+	for key, b := range desiredBindings {
+		if _, ok := currentBindings[key]; !ok {
+			addBindings = append(addBindings, b)
+		}
+	}
+	if opts.Prune {
+		for key, p := range currentPolicies {
+			if _, ok := desiredPolicies[key]; !ok {
+				removePolicies = append(removePolicies, p)
+			}
+		}
+		for key, b := range currentBindings {
+			if _, ok := desiredBindings[key]; !ok {
+				removeBindings = append(removeBindings, b)
+			}
+		}
+	}
+	sortRules(addPolicies, removePolicies, addBindings, removeBindings)
+
+	result := &types.RBACImportResult{
+		DryRun:  opts.DryRun,
+		Added:   append(policies2Type(addPolicies, userNames), bindings2Type(addBindings)...),
+		Removed: append(policies2Type(removePolicies, userNames), bindings2Type(removeBindings)...),
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	// 先新增后删除，避免导入过程中用户短暂失去权限
+	if len(addPolicies) != 0 {
+		if _, err = a.enforcer.AddPolicies(addPolicies); err != nil {
+			klog.Errorf("failed to add rbac policies: %v", err)
+			return nil, errors.ErrServerInternal
+		}
+	}
+	if len(addBindings) != 0 {
+		if _, err = a.enforcer.AddGroupingPolicies(addBindings); err != nil {
+			klog.Errorf("failed to add group bindings: %v", err)
+			return nil, errors.ErrServerInternal
+		}
+	}
+	if len(removeBindings) != 0 {
+		if _, err = a.enforcer.RemoveGroupingPolicies(removeBindings); err != nil {
+			klog.Errorf("failed to remove group bindings: %v", err)
+			return nil, errors.ErrServerInternal
+		}
+	}
+	if len(removePolicies) != 0 {
+		if _, err = a.enforcer.RemovePolicies(removePolicies); err != nil {
+			klog.Errorf("failed to remove rbac policies: %v", err)
+			return nil, errors.ErrServerInternal
+		}
+	}
+	return result, nil
+}
+
+// parseDocument 校验文档并转换为 casbin 的策略和绑定关系，文档中的用户必须已存在
+func parseDocument(doc *types.RBACDocument, userNames sets.String) (map[string][]string, map[string][]string, error) {
+	if len(doc.Kind) != 0 && doc.Kind != RBACDocumentKind {
+		return nil, nil, invalidDocument("unsupported kind %s", doc.Kind)
+	}
+	var err error
+
+	policies := make(map[string][]string)
+	addPermits := func(subject string, permits []types.RBACPermit) error {
+		for _, permit := range permits {
+			sid := permit.SID
+			if len(sid) == 0 {
+				sid = model.SidAll
+			}
+			if _, ok := model.ObjectTypeMap[permit.ObjectType]; !ok {
+				return invalidDocument("%s: unsupported object type %s", subject, permit.ObjectType)
+			}
+			if _, ok := model.OperationMap[permit.Operation]; !ok {
+				return invalidDocument("%s: unsupported operation %s", subject, permit.Operation)
+			}
+			p := []string{subject, permit.ObjectType.String(), sid, permit.Operation.String()}
+			policies[ruleKey(p)] = p
+		}
+		return nil
+	}
+
+	groups := sets.NewString()
+	for _, g := range doc.Groups {
+		if len(g.Name) == 0 {
+			return nil, nil, invalidDocument("group name is required")
+		}
+		if g.Name == model.AdminGroup {
+			return nil, nil, invalidDocument("group %s is managed by pixiu", model.AdminGroup)
+		}
+		if userNames.Has(g.Name) {
+			return nil, nil, invalidDocument("group %s conflicts with an existing user", g.Name)
+		}
+		if len(g.Policies) == 0 {
+			return nil, nil, invalidDocument("group %s has no policies", g.Name)
+		}
+		groups.Insert(g.Name)
+		if err = addPermits(g.Name, g.Policies); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	bindings := make(map[string][]string)
+	for _, u := range doc.Users {
+		if !userNames.Has(u.Name) {
+			return nil, nil, invalidDocument("user %s is not found", u.Name)
+		}
+		if err = addPermits(u.Name, u.Policies); err != nil {
+			return nil, nil, err
+		}
+		for _, group := range u.Groups {
+			// 用户组需在文档中声明，root 用户组的绑定关系不通过导入修改
+			if !groups.Has(group) {
+				return nil, nil, invalidDocument("user %s: group %s is not declared", u.Name, group)
+			}
+			b := []string{u.Name, group}
+			bindings[ruleKey(b)] = b
+		}
+	}
+	return policies, bindings, nil
+}
+
+// currentRules 获取除 root 用户组以外的全部策略和绑定关系
+func (a *auth) currentRules() ([][]string, [][]string, error) {
+	rp, err := a.enforcer.GetFilteredNamedPolicy("p", 0)
+	if err != nil {
+		klog.Errorf("failed to list rbac policies: %v", err)
+		return nil, nil, errors.ErrServerInternal
+	}
+	rb, err := a.enforcer.GetFilteredNamedGroupingPolicy("g", 0)
+	if err != nil {
+		klog.Errorf("failed to list group bindings: %v", err)
+		return nil, nil, errors.ErrServerInternal
+	}
+
+	var policies, bindings [][]string
+	for _, p := range rp {
+		if len(p) == 4 && p[0] != model.AdminGroup {
+			policies = append(policies, p)
+		}
+	}
+	for _, b := range rb {
+		if len(b) == 2 && b[1] != model.AdminGroup {
+			bindings = append(bindings, b)
+		}
+	}
+	sortRules(policies, bindings)
+	return policies, bindings, nil
+}
+
+func (a *auth) listUserNames(ctx context.Context) (sets.String, error) {
+	users, err := a.factory.User().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list users: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	names := sets.NewString()
+	for _, user := range users {
+		names.Insert(user.Name)
+	}
+	return names, nil
+}
+
+func invalidDocument(format string, args ...interface{}) error {
+	return errors.NewError(fmt.Errorf(format, args...), http.StatusBadRequest)
+}
+
+func ruleKey(rule []string) string {
+	return strings.Join(rule, "\x00")
+}
+
+func sortRules(groups ...[][]string) {
+	for _, rules := range groups {
+		sort.Slice(rules, func(i, j int) bool {
+			return ruleKey(rules[i]) < ruleKey(rules[j])
+		})
+	}
+}
+
+func policies2Type(rules [][]string, userNames sets.String) []types.RBACPolicy {
+	objects := make([]types.RBACPolicy, 0, len(rules))
+	for _, rule := range rules {
+		var policy model.Policy
+		if userNames.Has(rule[0]) {
+			policy = model.NewUserPolicy(rule[0], model.ObjectType(rule[1]), rule[2], model.Operation(rule[3]))
+		} else {
+			policy = model.NewGroupPolicy(rule[0], model.ObjectType(rule[1]), rule[2], model.Operation(rule[3]))
+		}
+		objects = append(objects, *model2Type(policy))
+	}
+	return objects
+}
+
+func bindings2Type(rules [][]string) []types.RBACPolicy {
+	objects := make([]types.RBACPolicy, 0, len(rules))
+	for _, rule := range rules {
+		objects = append(objects, *model2Type(model.NewGroupBinding(rule[0], rule[1])))
+	}
+	return objects
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+func (a *auth) ListMenus(ctx context.Context) ([]types.Menu, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, errors.ErrServerInternal
+	}
+	objects, err := a.factory.Menu().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list menus: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	// 包含用户直接拥有以及通过用户组继承的权限
+	permissions, err := a.enforcer.GetImplicitPermissionsForUser(user.Name)
+	if err != nil {
+		klog.Errorf("failed to get permissions of user %s: %v", user.Name, err)
+		return nil, errors.ErrServerInternal
+	}
+	allowed := sets.NewString()
+	for _, p := range permissions {
+		if len(p) > 1 {
+			allowed.Insert(p[1])
+		}
+	}
+	visible := func(m model.Menu) bool {
+		return user.Role == model.RoleRoot || len(m.ObjectType) == 0 ||
+			allowed.HasAny(model.ObjectAll.String(), m.ObjectType.String())
+	}
+
+	return buildMenuTree(objects, visible), nil
+}
+
+// buildMenuTree 按 parent 构建菜单树，父菜单本身不需要权限，但没有可见的子菜单时不返回
+func buildMenuTree(objects []model.Menu, visible func(model.Menu) bool) []types.Menu {
+	children := make(map[string][]types.Menu)
+	for _, object := range objects {
+		if len(object.Parent) != 0 && visible(object) {
+			children[object.Parent] = append(children[object.Parent], menu2Type(object))
+		}
+	}
+
+	menus := make([]types.Menu, 0)
+	for _, object := range objects {
+		if len(object.Parent) != 0 {
+			continue
+		}
+		m := menu2Type(object)
+		m.Children = children[object.Name]
+		if len(m.Children) == 0 && (hasChildren(objects, object.Name) || !visible(object)) {
+			continue
+		}
+		menus = append(menus, m)
+	}
+	return menus
+}
+
+func hasChildren(objects []model.Menu, name string) bool {
+	for _, object := range objects {
+		if object.Parent == name {
+			return true
+		}
+	}
+	return false
+}
+
+func menu2Type(object model.Menu) types.Menu {
+	return types.Menu{
+		Name:  object.Name,
+		Title: object.Title,
+		Path:  object.Path,
+		Icon:  object.Icon,
+	}
+}
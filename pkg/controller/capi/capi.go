@@ -0,0 +1,335 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capi
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	utilerrors "github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+var clusterGVR = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "clusters"}
+
+type CAPIGetter interface {
+	CAPI() Interface
+}
+
+// Interface 通过管理集群中的 Cluster API 管理集群的生命周期，作为 ssh 部署之外的另一种部署方式
+// 集群使用 ClusterClass 的 topology 描述，因此与具体的 infrastructure provider 无关
+type Interface interface {
+	// Create 创建部署方式为 capi 的部署计划，并在管理集群中创建 Cluster 对象
+	Create(ctx context.Context, req *types.CreateCAPIPlanRequest) error
+	// Scale 调整控制面和 worker 的副本数
+	Scale(ctx context.Context, pid int64, req *types.ScaleCAPIPlanRequest) error
+	// Delete 删除管理集群中的 Cluster 对象，由 Cluster API 回收集群资源，然后删除部署计划
+	Delete(ctx context.Context, pid int64) error
+	Get(ctx context.Context, pid int64) (*types.CAPICluster, error)
+
+	// Import 集群创建完成后，使用 Cluster API 生成的 kubeconfig 将集群注册到 pixiu
+	Import(ctx context.Context, pid int64, req *types.ImportCAPIPlanRequest) error
+}
+
+type capi struct {
+	factory db.ShareDaoFactory
+
+	clusterGetter cluster.Interface
+}
+
+func (c *capi) client(ctx context.Context, managementCluster string, namespace string) (dynamic.ResourceInterface, client.ClusterSet, error) {
+	cs, err := c.clusterGetter.GetClusterSetByName(ctx, managementCluster)
+	if err != nil {
+		return nil, client.ClusterSet{}, err
+	}
+	return cs.Dynamic.Resource(clusterGVR).Namespace(namespace), cs, nil
+}
+
+// getPlan 获取部署方式为 capi 的部署计划
+func (c *capi) getPlan(ctx context.Context, pid int64) (*model.Plan, error) {
+	object, err := c.factory.Plan().Get(ctx, pid)
+	if err != nil {
+		if utilerrors.IsRecordNotFound(err) {
+			return nil, errors.ErrPlanNotFound
+		}
+		klog.Errorf("failed to get plan(%d): %v", pid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object.Backend != model.PlanBackendCAPI {
+		return nil, errors.NewError(fmt.Errorf("部署计划(%d)不是通过 Cluster API 部署", pid), http.StatusBadRequest)
+	}
+	return object, nil
+}
+
+func (c *capi) Create(ctx context.Context, req *types.CreateCAPIPlanRequest) error {
+	if msgs := validation.IsDNS1123Label(req.Name); len(msgs) != 0 {
+		return errors.NewError(fmt.Errorf("invalid name %s: %s", req.Name, strings.Join(msgs, ",")), http.StatusBadRequest)
+	}
+	dc, _, err := c.client(ctx, req.ManagementCluster, req.Namespace)
+	if err != nil {
+		return err
+	}
+
+	plan, err := c.factory.Plan().Create(ctx, &model.Plan{
+		Name:              req.Name,
+		Description:       req.Description,
+		Backend:           model.PlanBackendCAPI,
+		ManagementCluster: req.ManagementCluster,
+		Namespace:         req.Namespace,
+	})
+	if err != nil {
+		klog.Errorf("failed to create plan %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+
+	workers := make([]interface{}, 0, len(req.Workers))
+	for _, worker := range req.Workers {
+		workers = append(workers, map[string]interface{}{
+			"class":    worker.Class,
+			"name":     worker.Name,
+			"replicas": worker.Replicas,
+		})
+	}
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": clusterGVR.GroupVersion().String(),
+		"kind":       "Cluster",
+		"metadata": map[string]interface{}{
+			"name":      req.Name,
+			"namespace": req.Namespace,
+		},
+		"spec": map[string]interface{}{
+			"topology": map[string]interface{}{
+				"class":   req.ClusterClass,
+				"version": req.Version,
+				"controlPlane": map[string]interface{}{
+					"replicas": req.ControlPlaneReplicas,
+				},
+				"workers": map[string]interface{}{
+					"machineDeployments": workers,
+				},
+			},
+		},
+	}}
+	if _, err = dc.Create(ctx, object, metav1.CreateOptions{}); err != nil {
+		klog.Errorf("failed to create capi cluster %s/%s in cluster(%s): %v", req.Namespace, req.Name, req.ManagementCluster, err)
+		if _, delErr := c.factory.Plan().Delete(ctx, plan.Id); delErr != nil {
+			klog.Errorf("failed to rollback plan(%d): %v", plan.Id, delErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *capi) Scale(ctx context.Context, pid int64, req *types.ScaleCAPIPlanRequest) error {
+	plan, err := c.getPlan(ctx, pid)
+	if err != nil {
+		return err
+	}
+	dc, _, err := c.client(ctx, plan.ManagementCluster, plan.Namespace)
+	if err != nil {
+		return err
+	}
+	object, err := dc.Get(ctx, plan.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if req.ControlPlaneReplicas != nil {
+		if err = unstructured.SetNestedField(object.Object, *req.ControlPlaneReplicas, "spec", "topology", "controlPlane", "replicas"); err != nil {
+			return err
+		}
+	}
+	if len(req.Workers) != 0 {
+		deployments, _, err := unstructured.NestedSlice(object.Object, "spec", "topology", "workers", "machineDeployments")
+		if err != nil {
+			return err
+		}
+		for _, worker := range req.Workers {
+			found := false
+			for i := range deployments {
+				md, ok := deployments[i].(map[string]interface{})
+				if !ok || md["name"] != worker.Name {
+					continue
+				}
+				md["replicas"] = worker.Replicas
+				found = true
+			}
+			// 不存在时新增 worker，需要指定 class
+			if !found {
+				if len(worker.Class) == 0 {
+					return errors.NewError(fmt.Errorf("worker %s 不存在，新增时需要指定 class", worker.Name), http.StatusBadRequest)
+				}
+				deployments = append(deployments, map[string]interface{}{
+					"class":    worker.Class,
+					"name":     worker.Name,
+					"replicas": worker.Replicas,
+				})
+			}
+		}
+		if err = unstructured.SetNestedSlice(object.Object, deployments, "spec", "topology", "workers", "machineDeployments"); err != nil {
+			return err
+		}
+	}
+
+	if _, err = dc.Update(ctx, object, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("failed to scale capi cluster %s/%s: %v", plan.Namespace, plan.Name, err)
+		return err
+	}
+	return nil
+}
+
+func (c *capi) Delete(ctx context.Context, pid int64) error {
+	plan, err := c.getPlan(ctx, pid)
+	if err != nil {
+		return err
+	}
+	dc, _, err := c.client(ctx, plan.ManagementCluster, plan.Namespace)
+	if err != nil {
+		return err
+	}
+	if err = dc.Delete(ctx, plan.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("failed to delete capi cluster %s/%s: %v", plan.Namespace, plan.Name, err)
+		return err
+	}
+
+	if _, err = c.factory.Plan().Delete(ctx, pid); err != nil {
+		klog.Errorf("failed to delete plan(%d): %v", pid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (c *capi) Get(ctx context.Context, pid int64) (*types.CAPICluster, error) {
+	plan, err := c.getPlan(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+	dc, _, err := c.client(ctx, plan.ManagementCluster, plan.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	object, err := dc.Get(ctx, plan.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCluster(object), nil
+}
+
+func (c *capi) Import(ctx context.Context, pid int64, req *types.ImportCAPIPlanRequest) error {
+	plan, err := c.getPlan(ctx, pid)
+	if err != nil {
+		return err
+	}
+	dc, cs, err := c.client(ctx, plan.ManagementCluster, plan.Namespace)
+	if err != nil {
+		return err
+	}
+	object, err := dc.Get(ctx, plan.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if status := parseCluster(object); !status.ControlPlaneReady {
+		return errors.NewError(fmt.Errorf("集群 %s 的控制面尚未就绪", plan.Name), http.StatusBadRequest)
+	}
+
+	// Cluster API 将集群的 kubeconfig 保存在 <name>-kubeconfig 的 secret 中
+	secret, err := cs.Client.CoreV1().Secrets(plan.Namespace).Get(ctx, plan.Name+"-kubeconfig", metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("failed to get kubeconfig secret of capi cluster %s/%s: %v", plan.Namespace, plan.Name, err)
+		return err
+	}
+	data, ok := secret.Data["value"]
+	if !ok {
+		return fmt.Errorf("kubeconfig secret of capi cluster %s/%s has no value", plan.Namespace, plan.Name)
+	}
+
+	aliasName := req.AliasName
+	if len(aliasName) == 0 {
+		aliasName = plan.Name
+	}
+	return c.clusterGetter.Create(ctx, &types.CreateClusterRequest{
+		AliasName:   aliasName,
+		KubeConfig:  base64.StdEncoding.EncodeToString(data),
+		Description: req.Description,
+	})
+}
+
+func parseCluster(object *unstructured.Unstructured) *types.CAPICluster {
+	c := &types.CAPICluster{
+		Name:      object.GetName(),
+		Namespace: object.GetNamespace(),
+		Workers:   make([]types.CAPIWorkerReplica, 0),
+	}
+
+	c.ClusterClass, _, _ = unstructured.NestedString(object.Object, "spec", "topology", "class")
+	c.Version, _, _ = unstructured.NestedString(object.Object, "spec", "topology", "version")
+	c.ControlPlaneReplicas, _, _ = unstructured.NestedInt64(object.Object, "spec", "topology", "controlPlane", "replicas")
+	deployments, _, _ := unstructured.NestedSlice(object.Object, "spec", "topology", "workers", "machineDeployments")
+	for _, item := range deployments {
+		md, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		worker := types.CAPIWorkerReplica{}
+		worker.Name, _, _ = unstructured.NestedString(md, "name")
+		worker.Class, _, _ = unstructured.NestedString(md, "class")
+		worker.Replicas, _, _ = unstructured.NestedInt64(md, "replicas")
+		c.Workers = append(c.Workers, worker)
+	}
+
+	c.Phase, _, _ = unstructured.NestedString(object.Object, "status", "phase")
+	c.InfrastructureReady, _, _ = unstructured.NestedBool(object.Object, "status", "infrastructureReady")
+	c.ControlPlaneReady, _, _ = unstructured.NestedBool(object.Object, "status", "controlPlaneReady")
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, item := range conditions {
+		cond, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		condition := types.CAPICondition{}
+		condition.Type, _, _ = unstructured.NestedString(cond, "type")
+		condition.Status, _, _ = unstructured.NestedString(cond, "status")
+		condition.Reason, _, _ = unstructured.NestedString(cond, "reason")
+		condition.Message, _, _ = unstructured.NestedString(cond, "message")
+		c.Conditions = append(c.Conditions, condition)
+	}
+	return c
+}
+
+func NewCAPI(f db.ShareDaoFactory, c cluster.Interface) *capi {
+	return &capi{
+		factory:       f,
+		clusterGetter: c,
+	}
+}
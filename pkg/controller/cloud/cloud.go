@@ -0,0 +1,285 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloud
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/controller/naming"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	"github.com/caoyingjunz/pixiu/pkg/util/cloudprovider"
+	"github.com/caoyingjunz/pixiu/pkg/util/uuid"
+)
+
+type CloudGetter interface {
+	Cloud() Interface
+}
+
+// Interface 管理云账号，发现并导入云厂商托管的 kubernetes 集群
+type Interface interface {
+	Create(ctx context.Context, req *types.CreateCloudAccountRequest) error
+	Delete(ctx context.Context, aid int64) error
+	Get(ctx context.Context, aid int64) (*types.CloudAccount, error)
+	List(ctx context.Context) ([]types.CloudAccount, error)
+
+	// ListClusters 获取云账号下的托管集群，并标记已导入的集群
+	ListClusters(ctx context.Context, aid int64) ([]types.CloudCluster, error)
+	// Import 获取托管集群的 kubeconfig 并注册为 pixiu 集群
+	Import(ctx context.Context, aid int64, req *types.ImportCloudClusterRequest) error
+}
+
+type cloud struct {
+	factory       db.ShareDaoFactory
+	clusterGetter cluster.Interface
+}
+
+// newProvider 根据云账号构造云厂商的 provider
+func newProvider(account *model.CloudAccount) (cloudprovider.Provider, error) {
+	return cloudprovider.NewProvider(cloudprovider.Credential{
+		Provider:  account.Provider,
+		Region:    account.Region,
+		AccessKey: account.AccessKey,
+		SecretKey: account.SecretKey,
+		ProjectId: account.ProjectId,
+	})
+}
+
+func (c *cloud) Create(ctx context.Context, req *types.CreateCloudAccountRequest) error {
+	if err := naming.NewNaming(c.factory).Validate(ctx, model.NamingResourceCloud, req.Name); err != nil {
+		return err
+	}
+	object := &model.CloudAccount{
+		Name:        req.Name,
+		Provider:    req.Provider,
+		Region:      req.Region,
+		ProjectId:   req.ProjectId,
+		AccessKey:   req.AccessKey,
+		SecretKey:   req.SecretKey,
+		Description: req.Description,
+	}
+	// 提前校验凭证，避免保存无法使用的云账号
+	if _, err := newProvider(object); err != nil {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+
+	old, err := c.factory.CloudAccount().GetByName(ctx, req.Name)
+	if err != nil {
+		klog.Errorf("failed to get cloud account %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	if old != nil {
+		return errors.ErrCloudAccountExists
+	}
+
+	if _, err = c.factory.CloudAccount().Create(ctx, object); err != nil {
+		klog.Errorf("failed to create cloud account %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (c *cloud) Delete(ctx context.Context, aid int64) error {
+	if _, err := c.get(ctx, aid); err != nil {
+		return err
+	}
+	imported, err := c.importedClusters(ctx, aid)
+	if err != nil {
+		return err
+	}
+	// 已导入的集群依赖云账号刷新凭证
+	if len(imported) != 0 {
+		return errors.NewError(fmt.Errorf("云账号下仍有 %d 个已导入的集群", len(imported)), http.StatusBadRequest)
+	}
+
+	if err = c.factory.CloudAccount().Delete(ctx, aid); err != nil {
+		klog.Errorf("failed to delete cloud account(%d): %v", aid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (c *cloud) Get(ctx context.Context, aid int64) (*types.CloudAccount, error) {
+	object, err := c.get(ctx, aid)
+	if err != nil {
+		return nil, err
+	}
+	return c.model2Type(object), nil
+}
+
+func (c *cloud) get(ctx context.Context, aid int64) (*model.CloudAccount, error) {
+	object, err := c.factory.CloudAccount().Get(ctx, aid)
+	if err != nil {
+		klog.Errorf("failed to get cloud account(%d): %v", aid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrCloudAccountNotFound
+	}
+	return object, nil
+}
+
+func (c *cloud) List(ctx context.Context) ([]types.CloudAccount, error) {
+	objects, err := c.factory.CloudAccount().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list cloud accounts: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	accounts := make([]types.CloudAccount, len(objects))
+	for i, object := range objects {
+		accounts[i] = *c.model2Type(&object)
+	}
+	return accounts, nil
+}
+
+func (c *cloud) ListClusters(ctx context.Context, aid int64) ([]types.CloudCluster, error) {
+	object, err := c.get(ctx, aid)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := newProvider(object)
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+	managed, err := provider.ListClusters(ctx)
+	if err != nil {
+		klog.Errorf("failed to list %s clusters of cloud account(%d): %v", provider.Name(), aid, err)
+		return nil, errors.NewError(err, http.StatusBadGateway)
+	}
+	imported, err := c.importedClusters(ctx, aid)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make([]types.CloudCluster, len(managed))
+	for i, mc := range managed {
+		name, ok := imported[mc.Id]
+		clusters[i] = types.CloudCluster{
+			ManagedCluster: mc,
+			Imported:       ok,
+			Cluster:        name,
+		}
+	}
+	return clusters, nil
+}
+
+func (c *cloud) Import(ctx context.Context, aid int64, req *types.ImportCloudClusterRequest) error {
+	object, err := c.get(ctx, aid)
+	if err != nil {
+		return err
+	}
+	imported, err := c.importedClusters(ctx, aid)
+	if err != nil {
+		return err
+	}
+	if _, ok := imported[req.ClusterId]; ok {
+		return errors.ErrCloudClusterImported
+	}
+
+	provider, err := newProvider(object)
+	if err != nil {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+	kubeConfig, err := provider.GetKubeConfig(ctx, req.ClusterId)
+	if err != nil {
+		klog.Errorf("failed to get %s cluster %s kubeconfig: %v", provider.Name(), req.ClusterId, err)
+		return errors.NewError(err, http.StatusBadGateway)
+	}
+
+	// 预先生成集群名称，用于注册后关联云账号
+	name := req.Name
+	if len(name) == 0 {
+		name = uuid.NewRandName(8)
+	}
+	if err = c.clusterGetter.Create(ctx, &types.CreateClusterRequest{
+		Name:        name,
+		AliasName:   req.AliasName,
+		KubeConfig:  base64.StdEncoding.EncodeToString(kubeConfig.Data),
+		Description: req.Description,
+		Protected:   req.Protected,
+		Labels:      req.Labels,
+	}); err != nil {
+		return err
+	}
+
+	cs, err := c.factory.Cluster().GetClusterByName(ctx, name)
+	if err != nil || cs == nil {
+		klog.Errorf("failed to get imported cluster %s: %v", name, err)
+		return errors.ErrServerInternal
+	}
+	if err = c.factory.Cluster().InternalUpdate(ctx, cs.Id, map[string]interface{}{
+		"cloud_account_id":      aid,
+		"cloud_cluster_id":      req.ClusterId,
+		"credential_expiration": kubeConfig.ExpirationTimestamp,
+	}); err != nil {
+		klog.Errorf("failed to link cluster %s to cloud account(%d): %v", name, aid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+// importedClusters 获取云账号已导入的集群，key 为集群在云厂商中的标识，value 为 pixiu 中的集群名称
+func (c *cloud) importedClusters(ctx context.Context, aid int64) (map[string]string, error) {
+	objects, err := c.factory.Cluster().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list clusters: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	imported := make(map[string]string)
+	for _, object := range objects {
+		if object.CloudAccountId == aid {
+			imported[object.CloudClusterId] = object.Name
+		}
+	}
+	return imported, nil
+}
+
+func (c *cloud) model2Type(o *model.CloudAccount) *types.CloudAccount {
+	return &types.CloudAccount{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Name:        o.Name,
+		Provider:    o.Provider,
+		Region:      o.Region,
+		ProjectId:   o.ProjectId,
+		AccessKey:   o.AccessKey,
+		Description: o.Description,
+	}
+}
+
+func NewCloud(f db.ShareDaoFactory, c cluster.Interface) *cloud {
+	return &cloud{
+		factory:       f,
+		clusterGetter: c,
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+// Apply 通过 server-side apply 提交 manifest 中的对象，dry_run 时返回 apiserver 合并后的结果用于预览
+func (c *cluster) Apply(ctx context.Context, cluster string, req *types.ApplyManifestRequest) ([]*unstructured.Unstructured, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults, err := c.GetDefaults(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespace := req.Namespace
+	if len(namespace) == 0 {
+		namespace = defaults.Namespace
+	}
+
+	objects, err := client.DecodeManifest([]byte(req.Manifest))
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+	// 指定节点池时优先使用节点池的 nodeSelector
+	nodeSelector := defaults.NodeSelector
+	var tolerations []v1.Toleration
+	if len(req.NodePool) != 0 {
+		pool, err := c.GetNodePool(ctx, cluster, req.NodePool)
+		if err != nil {
+			return nil, err
+		}
+		nodeSelector = map[string]string{types.NodePoolLabelKey: pool.Name}
+		tolerations = taintsToTolerations(pool.Taints)
+	} else if !req.WithDefaultNodeSelector {
+		nodeSelector = nil
+	}
+	for _, object := range objects {
+		if len(nodeSelector) != 0 {
+			if err = setDefaultNodeSelector(object, nodeSelector); err != nil {
+				return nil, errors.NewError(err, http.StatusBadRequest)
+			}
+		}
+		if len(tolerations) != 0 {
+			if err = addTolerations(object, tolerations); err != nil {
+				return nil, errors.NewError(err, http.StatusBadRequest)
+			}
+		}
+		if err = c.AdmitObject(ctx, cluster, namespace, object); err != nil {
+			return nil, err
+		}
+	}
+
+	if objects, err = client.ApplyObjects(ctx, cs, namespace, objects, req.DryRun); err != nil {
+		klog.Errorf("failed to apply manifest to cluster %s: %v", cluster, err)
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+	return objects, nil
+}
+
+// podSpecFields 返回工作负载 pod spec 的字段路径，pod 直接使用 spec，其他工作负载使用 pod 模板，非工作负载返回 nil
+func podSpecFields(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		return []string{"spec", "template", "spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	}
+	return nil
+}
+
+// setDefaultNodeSelector 为未设置 nodeSelector 的工作负载设置 nodeSelector
+func setDefaultNodeSelector(object *unstructured.Unstructured, nodeSelector map[string]string) error {
+	fields := podSpecFields(object.GetKind())
+	if fields == nil {
+		return nil
+	}
+	fields = append(fields, "nodeSelector")
+
+	if _, found, err := unstructured.NestedStringMap(object.Object, fields...); err != nil || found {
+		return err
+	}
+	return unstructured.SetNestedStringMap(object.Object, nodeSelector, fields...)
+}
+
+// addTolerations 为工作负载追加尚未设置的容忍
+func addTolerations(object *unstructured.Unstructured, tolerations []v1.Toleration) error {
+	fields := podSpecFields(object.GetKind())
+	if fields == nil {
+		return nil
+	}
+	fields = append(fields, "tolerations")
+
+	existing, _, err := unstructured.NestedSlice(object.Object, fields...)
+	if err != nil {
+		return err
+	}
+	keys := sets.NewString()
+	for _, item := range existing {
+		if t, ok := item.(map[string]interface{}); ok {
+			keys.Insert(fmt.Sprintf("%v/%v", t["key"], t["effect"]))
+		}
+	}
+	for _, toleration := range tolerations {
+		if keys.Has(toleration.Key + "/" + string(toleration.Effect)) {
+			continue
+		}
+		item, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&toleration)
+		if err != nil {
+			return err
+		}
+		existing = append(existing, item)
+	}
+	return unstructured.SetNestedSlice(object.Object, existing, fields...)
+}
+
+func taintsToTolerations(taints []v1.Taint) []v1.Toleration {
+	tolerations := make([]v1.Toleration, 0, len(taints))
+	for _, taint := range taints {
+		tolerations = append(tolerations, v1.Toleration{
+			Key:      taint.Key,
+			Operator: v1.TolerationOpEqual,
+			Value:    taint.Value,
+			Effect:   taint.Effect,
+		})
+	}
+	return tolerations
+}
@@ -0,0 +1,344 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/tunnel"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	utilerrors "github.com/caoyingjunz/pixiu/pkg/util/errors"
+	kubeconfigutil "github.com/caoyingjunz/pixiu/pkg/util/kubeconfig"
+	"github.com/caoyingjunz/pixiu/pkg/util/uuid"
+)
+
+const (
+	defaultBootstrapTTL = 3600
+	bootstrapTokenBytes = 32
+
+	// tunnel 模式下 pixiu 通过隧道访问的 API server 地址
+	tunnelServer = "https://kubernetes.default.svc"
+)
+
+// agentRBACManifest 在目标集群中创建 agent 的 ServiceAccount，并绑定配置的 ClusterRole
+// pixiu 保存 ServiceAccount 不过期的 token 作为集群的凭证，绑定的角色即为 pixiu 在集群中的权限
+const agentRBACManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: pixiu-system
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: pixiu-agent
+  namespace: pixiu-system
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: pixiu-agent-token
+  namespace: pixiu-system
+  annotations:
+    kubernetes.io/service-account.name: pixiu-agent
+type: kubernetes.io/service-account-token
+---
+# pixiu 保存该 ServiceAccount 不过期的 token 管理集群，删除集群后可以删除该 Secret 吊销凭证
+# 绑定的 ClusterRole 即为 pixiu 在集群中的权限，通过 agent.cluster_role 配置
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: pixiu-agent
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: %s
+subjects:
+- kind: ServiceAccount
+  name: pixiu-agent
+  namespace: pixiu-system
+---
+`
+
+// agentRegisterManifest 由 Job 使用 ServiceAccount 的凭证回调 pixiu 注册集群
+// 返回的 code 不为 200 时 Job 失败并重试，直到 token 生成或者引导 token 过期
+const agentRegisterManifest = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: pixiu-agent-register
+  namespace: pixiu-system
+spec:
+  backoffLimit: 10
+  ttlSecondsAfterFinished: 3600
+  template:
+    spec:
+      restartPolicy: OnFailure
+      containers:
+      - name: register
+        image: %s
+        env:
+        - name: BOOTSTRAP_TOKEN
+          value: "%s"
+        - name: PIXIU_URL
+          value: "%s"
+        command: ["/bin/sh", "-c"]
+        args:
+        - |
+          TOKEN=$(cat /var/run/pixiu/token)
+          CA=$(base64 /var/run/pixiu/ca.crt | tr -d '\n')
+          RESP=$(curl -sS -X POST -H 'Content-Type: application/json' \
+            -d "{\"bootstrap_token\":\"${BOOTSTRAP_TOKEN}\",\"token\":\"${TOKEN}\",\"ca_data\":\"${CA}\"}" \
+            "${PIXIU_URL}/pixiu/clusters/register")
+          echo "${RESP}"
+          echo "${RESP}" | grep -q '"code":200'
+        volumeMounts:
+        - name: token
+          mountPath: /var/run/pixiu
+          readOnly: true
+      volumes:
+      - name: token
+        secret:
+          secretName: pixiu-agent-token
+`
+
+// agentTunnelManifest 常驻集群的 agent，主动连接 pixiu 建立反向隧道后使用 ServiceAccount 的凭证注册集群
+const agentTunnelManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: pixiu-agent
+  namespace: pixiu-system
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: pixiu-agent
+  template:
+    metadata:
+      labels:
+        app: pixiu-agent
+    spec:
+      serviceAccountName: pixiu-agent
+      containers:
+      - name: agent
+        image: %s
+        env:
+        - name: BOOTSTRAP_TOKEN
+          value: "%s"
+        - name: PIXIU_URL
+          value: "%s"
+        volumeMounts:
+        - name: token
+          mountPath: /var/run/pixiu
+          readOnly: true
+      volumes:
+      - name: token
+        secret:
+          secretName: pixiu-agent-token
+`
+
+// CreateBootstrap 生成一次性的引导 token 和 agent 的部署清单，数据库中仅保存 token 的摘要
+func (c *cluster) CreateBootstrap(ctx context.Context, req *types.CreateClusterBootstrapRequest) (*types.ClusterBootstrap, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusInternalServerError)
+	}
+
+	server := req.Server
+	if req.Tunnel {
+		server = tunnelServer
+	}
+	if len(server) == 0 {
+		return nil, errors.NewError(fmt.Errorf("非 tunnel 模式时必须指定 server"), http.StatusBadRequest)
+	}
+	name := req.Name
+	if len(name) == 0 {
+		name = uuid.NewRandName(8)
+	}
+	old, err := c.factory.Cluster().GetClusterByName(ctx, name)
+	if err != nil {
+		klog.Errorf("failed to get cluster %s: %v", name, err)
+		return nil, errors.ErrServerInternal
+	}
+	if old != nil {
+		return nil, errors.NewError(fmt.Errorf("集群 %s 已存在", name), http.StatusConflict)
+	}
+
+	token, err := newBootstrapToken()
+	if err != nil {
+		klog.Errorf("failed to generate bootstrap token: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = defaultBootstrapTTL
+	}
+	object, err := c.factory.ClusterBootstrap().Create(ctx, &model.ClusterBootstrap{
+		TokenHash:           hashBootstrapToken(token),
+		Name:                name,
+		AliasName:           req.AliasName,
+		Description:         req.Description,
+		Server:              server,
+		UserId:              user.Id,
+		Tunnel:              req.Tunnel,
+		ExpirationTimestamp: time.Now().Add(time.Duration(ttl) * time.Second),
+	})
+	if err != nil {
+		klog.Errorf("failed to create cluster %s bootstrap: %v", name, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	pixiuURL := strings.TrimSuffix(req.PixiuURL, "/")
+	agent := c.cc.Agent
+	manifest := fmt.Sprintf(agentRBACManifest, agent.ClusterRole) + fmt.Sprintf(agentRegisterManifest, agent.RegisterImage, token, pixiuURL)
+	if req.Tunnel {
+		manifest = fmt.Sprintf(agentRBACManifest, agent.ClusterRole) + fmt.Sprintf(agentTunnelManifest, agent.Image, token, pixiuURL)
+	}
+	return &types.ClusterBootstrap{
+		Name:                name,
+		Token:               token,
+		Tunnel:              req.Tunnel,
+		ClusterRole:         agent.ClusterRole,
+		ExpirationTimestamp: object.ExpirationTimestamp,
+		Manifest:            manifest,
+	}, nil
+}
+
+// Register agent 回调注册集群，使用 agent ServiceAccount 的凭证构造 kubeconfig，集群归属于生成 token 的用户
+// 注册失败时释放 token，agent 可以在过期前重试
+// tunnel 模式下集群通过 agent 建立的隧道访问，agent 重启后会再次注册，集群已存在时直接返回
+func (c *cluster) Register(ctx context.Context, req *types.RegisterClusterRequest) error {
+	object, err := c.factory.ClusterBootstrap().GetByTokenHash(ctx, hashBootstrapToken(req.BootstrapToken))
+	if err != nil {
+		klog.Errorf("failed to get cluster bootstrap: %v", err)
+		return errors.ErrServerInternal
+	}
+	if object != nil && object.Tunnel && object.RegisteredAt != nil {
+		return c.checkTunnelCluster(ctx, object)
+	}
+	if object == nil || object.RegisteredAt != nil || object.ExpirationTimestamp.Before(time.Now()) {
+		return errors.ErrBootstrapTokenInvalid
+	}
+	caData, err := base64.StdEncoding.DecodeString(req.CAData)
+	if err != nil {
+		return errors.ErrInvalidRequest
+	}
+	user, err := c.factory.User().Get(ctx, object.UserId)
+	if err != nil {
+		klog.Errorf("failed to get user(%d): %v", object.UserId, err)
+		return errors.ErrServerInternal
+	}
+	if user == nil {
+		return errors.ErrUserNotFound
+	}
+
+	if err = c.factory.ClusterBootstrap().Claim(ctx, object.Id); err != nil {
+		if utilerrors.IsNotUpdated(err) {
+			return errors.ErrBootstrapTokenInvalid
+		}
+		klog.Errorf("failed to claim cluster %s bootstrap: %v", object.Name, err)
+		return errors.ErrServerInternal
+	}
+
+	kubeConfig, err := kubeconfigutil.NewForToken(object.Name, object.Server, caData, req.Token)
+	if err == nil {
+		createReq := &types.CreateClusterRequest{
+			Name:        object.Name,
+			AliasName:   object.AliasName,
+			KubeConfig:  base64.StdEncoding.EncodeToString(kubeConfig),
+			Description: object.Description,
+		}
+		if object.Tunnel {
+			createReq.Proxy = &types.ClusterProxy{Type: types.ClusterProxyTypeAgent, Agent: object.Name}
+		}
+		err = c.Create(httputils.WithUser(ctx, user), createReq)
+	}
+	if err != nil {
+		klog.Errorf("failed to register cluster %s: %v", object.Name, err)
+		if releaseErr := c.factory.ClusterBootstrap().Release(ctx, object.Id); releaseErr != nil {
+			klog.Errorf("failed to release cluster %s bootstrap: %v", object.Name, releaseErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// ServeTunnel 校验引导 token 后接管连接作为集群的反向隧道，直到 agent 断开
+// 注册前 token 需在有效期内，注册后集群被删除时 token 失效
+func (c *cluster) ServeTunnel(ctx context.Context, token string, w http.ResponseWriter, r *http.Request) error {
+	object, err := c.factory.ClusterBootstrap().GetByTokenHash(ctx, hashBootstrapToken(token))
+	if err != nil {
+		klog.Errorf("failed to get cluster bootstrap: %v", err)
+		return errors.ErrServerInternal
+	}
+	if object == nil || !object.Tunnel {
+		return errors.ErrBootstrapTokenInvalid
+	}
+	if object.RegisteredAt == nil {
+		if object.ExpirationTimestamp.Before(time.Now()) {
+			return errors.ErrBootstrapTokenInvalid
+		}
+	} else if err = c.checkTunnelCluster(ctx, object); err != nil {
+		return err
+	}
+	if !strings.EqualFold(r.Header.Get("Upgrade"), tunnel.UpgradeProtocol) {
+		return errors.ErrInvalidRequest
+	}
+
+	if err = tunnel.Serve(object.Name, w, r); err != nil {
+		klog.Errorf("failed to serve cluster %s tunnel: %v", object.Name, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+// checkTunnelCluster 已注册的引导 token 对应的集群被删除后，token 不再有效
+func (c *cluster) checkTunnelCluster(ctx context.Context, object *model.ClusterBootstrap) error {
+	cluster, err := c.factory.Cluster().GetClusterByName(ctx, object.Name)
+	if err != nil {
+		klog.Errorf("failed to get cluster %s: %v", object.Name, err)
+		return errors.ErrServerInternal
+	}
+	if cluster == nil {
+		return errors.ErrBootstrapTokenInvalid
+	}
+	return nil
+}
+
+func newBootstrapToken() (string, error) {
+	b := make([]byte, bootstrapTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashBootstrapToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
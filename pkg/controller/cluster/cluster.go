@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,10 +31,12 @@ import (
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gorilla/websocket"
+	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	apitypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -48,8 +52,10 @@ import (
 	ctrlutil "github.com/caoyingjunz/pixiu/pkg/controller/util"
 	"github.com/caoyingjunz/pixiu/pkg/db"
 	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/tunnel"
 	"github.com/caoyingjunz/pixiu/pkg/types"
 	"github.com/caoyingjunz/pixiu/pkg/util"
+	sessionutil "github.com/caoyingjunz/pixiu/pkg/util/session"
 	"github.com/caoyingjunz/pixiu/pkg/util/uuid"
 )
 
@@ -64,8 +70,73 @@ type Interface interface {
 	Get(ctx context.Context, cid int64) (*types.Cluster, error)
 	List(ctx context.Context) ([]types.Cluster, error)
 
-	// Ping 检查和 k8s 集群的连通性
-	Ping(ctx context.Context, kubeConfig string) error
+	// GetDeploymentTimeline 获取 deployment 的变更时间线
+	GetDeploymentTimeline(ctx context.Context, cluster string, namespace string, name string, opts types.PageRequest) (interface{}, error)
+
+	// 按需修改 deployment 容器的环境变量，envFrom 引用以及挂载，无需提交完整的 spec
+	SetDeploymentEnv(ctx context.Context, cluster, namespace, name, container string, req *types.SetDeploymentEnvRequest) error
+	RemoveDeploymentEnv(ctx context.Context, cluster, namespace, name, container, env string) error
+	AddDeploymentEnvFrom(ctx context.Context, cluster, namespace, name, container string, req *types.AddDeploymentEnvFromRequest) error
+	RemoveDeploymentEnvFrom(ctx context.Context, cluster, namespace, name, container, kind, ref string) error
+	SetDeploymentVolumeMount(ctx context.Context, cluster, namespace, name, container string, req *types.SetDeploymentVolumeMountRequest) error
+	RemoveDeploymentVolumeMount(ctx context.Context, cluster, namespace, name, container, mountPath string) error
+
+	// RestartDeployment 滚动重启 deployment
+	RestartDeployment(ctx context.Context, cluster string, namespace string, name string) error
+	// GetDeploymentRolloutHistory 获取 deployment 的发布历史
+	GetDeploymentRolloutHistory(ctx context.Context, cluster string, namespace string, name string) ([]types.DeploymentRevision, error)
+	// UndoDeployment 回滚 deployment 到指定 revision
+	UndoDeployment(ctx context.Context, cluster string, namespace string, name string, req *types.UndoDeploymentRequest) error
+
+	// ListExposures 获取所有集群对外暴露的访问入口，用于安全暴露面审查
+	ListExposures(ctx context.Context, fleet string) ([]types.Exposure, error)
+	// SearchEvents 检索已持久化的集群事件，用于事后分析
+	SearchEvents(ctx context.Context, opts *types.SearchEventOptions) (*types.PageResponse, error)
+	// StreamEvents 逐条返回检索到的全部事件，用于导出大量事件
+	StreamEvents(ctx context.Context, opts *types.SearchEventOptions, fn func(types.ClusterEvent) error) error
+
+	// RunPrecheck 升级或者排空节点前巡检集群，返回 go/no-go 报告并记录
+	RunPrecheck(ctx context.Context, cluster string, req *types.RunClusterPrecheckRequest) (*types.ClusterPrecheck, error)
+	GetPrecheck(ctx context.Context, cluster string, id int64) (*types.ClusterPrecheck, error)
+	ListPrechecks(ctx context.Context, cluster string) ([]types.ClusterPrecheck, error)
+	// ScanDeprecatedAPIs 扫描使用了在目标版本前移除的 API 的对象，升级巡检时为阻断项
+	ScanDeprecatedAPIs(ctx context.Context, cluster string, opts *types.ScanDeprecationOptions) (*types.DeprecationReport, error)
+
+	// ListGPUNodes 获取节点的 GPU 容量和分配情况
+	ListGPUNodes(ctx context.Context, cluster string) ([]types.GPUNode, error)
+	// ListGPUPods 获取使用 GPU 的 pod
+	ListGPUPods(ctx context.Context, cluster string) ([]types.GPUPod, error)
+
+	// ListNodePools 获取集群的节点池及其容量
+	ListNodePools(ctx context.Context, cluster string) ([]types.NodePool, error)
+	GetNodePool(ctx context.Context, cluster string, pool string) (*types.NodePool, error)
+	// UpdateNodePoolLabels 批量设置节点池内节点的标签
+	UpdateNodePoolLabels(ctx context.Context, cluster string, pool string, req *types.UpdateNodePoolLabelsRequest) error
+	// UpdateNodePoolTaints 批量设置节点池内节点的污点
+	UpdateNodePoolTaints(ctx context.Context, cluster string, pool string, req *types.UpdateNodePoolTaintsRequest) error
+	// DrainNodePool 滚动驱逐节点池内的节点
+	DrainNodePool(ctx context.Context, cluster string, pool string, req *types.DrainNodePoolRequest) ([]types.NodeDrainResult, error)
+	// ListTenantGPUUsages 获取租户的 GPU 使用量和配额
+	ListTenantGPUUsages(ctx context.Context) ([]types.TenantGPUUsage, error)
+	// AdmitDeployment 创建或者扩容 deployment 前校验租户的 cpu，内存和 GPU 配额
+	AdmitDeployment(ctx context.Context, cluster string, old, cur *appsv1.Deployment) error
+	// AdmitObject 提交 deployment 和 statefulset 前校验租户的配额，其他类型的对象不做校验
+	AdmitObject(ctx context.Context, cluster string, namespace string, object *unstructured.Unstructured) error
+	// AdmitPatch 对 deployment 和 statefulset 或者其 scale 子资源 patch 前校验租户的配额
+	AdmitPatch(ctx context.Context, cluster string, kind string, namespace string, name string, subresource string, patchType apitypes.PatchType, patch []byte) error
+
+	// GetCRDForm 将 CRD 的 OpenAPI v3 schema 转换为表单描述，用于生成自定义资源的表单
+	GetCRDForm(ctx context.Context, cluster string, name string, version string) (*types.CRDForm, error)
+
+	// CreateBootstrap 生成通过 agent 导入集群的一次性引导 token 和部署清单
+	CreateBootstrap(ctx context.Context, req *types.CreateClusterBootstrapRequest) (*types.ClusterBootstrap, error)
+	// Register agent 使用引导 token 注册集群
+	Register(ctx context.Context, req *types.RegisterClusterRequest) error
+	// ServeTunnel 接管 agent 的连接作为集群的反向隧道，直到 agent 断开
+	ServeTunnel(ctx context.Context, token string, w http.ResponseWriter, r *http.Request) error
+
+	// Ping 检查和 k8s 集群的连通性，proxy 不为空时通过代理访问
+	Ping(ctx context.Context, kubeConfig string, proxy *types.ClusterProxy) error
 
 	// Protect 设置集群的保护策略
 	Protect(ctx context.Context, cid int64, req *types.ProtectClusterRequest) error
@@ -82,10 +153,57 @@ type Interface interface {
 
 	// WatchPodLog 实时获取 pod 的日志
 	WatchPodLog(ctx context.Context, cluster string, namespace string, podName string, containerName string, tailLine int64, w http.ResponseWriter, r *http.Request) error
+	// GenerateSupportBundle 生成工作负载或者命名空间的诊断包，格式为 tar.gz
+	GenerateSupportBundle(ctx context.Context, cluster string, namespace string, opts types.SupportBundleOptions) ([]byte, error)
+	// StreamPodLogs 通过 chunked 或者 SSE 返回 pod 的日志
+	StreamPodLogs(ctx context.Context, cluster string, namespace string, podName string, opts types.PodLogOptions, sse bool, w http.ResponseWriter, r *http.Request) error
+	// 定时任务的增删改，查询使用 indexer 接口
+	CreateCronJob(ctx context.Context, cluster string, namespace string, req *types.CreateCronJobRequest) (*batchv1.CronJob, error)
+	UpdateCronJob(ctx context.Context, cluster string, namespace string, name string, req *types.UpdateCronJobRequest) (*batchv1.CronJob, error)
+	DeleteCronJob(ctx context.Context, cluster string, namespace string, name string) error
+	// SuspendCronJob 暂停或者恢复定时任务
+	SuspendCronJob(ctx context.Context, cluster string, namespace string, name string, suspend bool) (*batchv1.CronJob, error)
+	// TriggerCronJob 使用定时任务的模板立即创建 job
+	TriggerCronJob(ctx context.Context, cluster string, namespace string, name string) (*batchv1.Job, error)
+	// ListCronJobRuns 获取定时任务创建的 job
+	ListCronJobRuns(ctx context.Context, cluster string, namespace string, name string) ([]batchv1.Job, error)
+	// secret 的增删改查，默认隐藏明文，reveal 时校验用户对集群 secrets 的读权限
+	CreateSecret(ctx context.Context, cluster string, namespace string, req *types.CreateSecretRequest) (*types.Secret, error)
+	UpdateSecret(ctx context.Context, cluster string, namespace string, name string, req *types.UpdateSecretRequest) (*types.Secret, error)
+	DeleteSecret(ctx context.Context, cluster string, namespace string, name string) error
+	GetSecret(ctx context.Context, cluster string, namespace string, name string, opts types.SecretOptions) (*types.Secret, error)
+	ListSecrets(ctx context.Context, cluster string, namespace string, opts types.SecretOptions) ([]types.Secret, error)
+	// service 的增删改查，支持 ClusterIP，NodePort 和 LoadBalancer
+	CreateService(ctx context.Context, cluster string, namespace string, req *types.CreateServiceRequest) (*v1.Service, error)
+	UpdateService(ctx context.Context, cluster string, namespace string, name string, req *types.UpdateServiceRequest) (*v1.Service, error)
+	DeleteService(ctx context.Context, cluster string, namespace string, name string) error
+	// GetService 获取 service 以及后端的就绪情况
+	GetService(ctx context.Context, cluster string, namespace string, name string) (*types.ServiceDetail, error)
+	ListServices(ctx context.Context, cluster string, namespace string) ([]v1.Service, error)
+	// ListStorageClasses 获取存储类，以及是否为默认存储类和是否允许扩容
+	ListStorageClasses(ctx context.Context, cluster string) ([]types.StorageClass, error)
+	CreatePersistentVolumeClaim(ctx context.Context, cluster string, namespace string, req *types.CreatePersistentVolumeClaimRequest) (*v1.PersistentVolumeClaim, error)
+	UpdatePersistentVolumeClaim(ctx context.Context, cluster string, namespace string, name string, req *types.UpdatePersistentVolumeClaimRequest) (*v1.PersistentVolumeClaim, error)
+	// ResizePersistentVolumeClaim 扩容 pvc，存储类需允许扩容
+	ResizePersistentVolumeClaim(ctx context.Context, cluster string, namespace string, name string, req *types.ResizePersistentVolumeClaimRequest) (*v1.PersistentVolumeClaim, error)
+	DeletePersistentVolumeClaim(ctx context.Context, cluster string, namespace string, name string) error
+	// GetPersistentVolumeClaim 获取 pvc，绑定的 pv 以及使用量
+	GetPersistentVolumeClaim(ctx context.Context, cluster string, namespace string, name string) (*types.PersistentVolumeClaimDetail, error)
+	ListPersistentVolumeClaims(ctx context.Context, cluster string, namespace string) ([]v1.PersistentVolumeClaim, error)
+	GetPersistentVolume(ctx context.Context, cluster string, name string) (*v1.PersistentVolume, error)
+	ListPersistentVolumes(ctx context.Context, cluster string) ([]v1.PersistentVolume, error)
 	// ReRunJob 重新执行指定任务
 	ReRunJob(ctx context.Context, cluster string, namespace string, jobName string, resourceVersion string) error
+	// Apply 提交 manifest 到集群，支持 dry-run 预览
+	Apply(ctx context.Context, cluster string, req *types.ApplyManifestRequest) ([]*unstructured.Unstructured, error)
 
 	GetKubeConfigByName(ctx context.Context, name string) (*restclient.Config, error)
+	// GetUserKubeConfigByName 获取代理当前用户请求时使用的配置，集群为模拟用户访问方式时设置模拟的用户和用户组
+	GetUserKubeConfigByName(ctx context.Context, name string) (*restclient.Config, error)
+	// GetDefaults 获取集群的默认设置，未设置时返回空值
+	GetDefaults(ctx context.Context, name string) (types.ClusterDefaults, error)
+	// GetClusterSetByName 获取指定集群的 clientSet 和 informer
+	GetClusterSetByName(ctx context.Context, name string) (client.ClusterSet, error)
 
 	GetIndexerResource(ctx context.Context, cluster string, resource string, namespace string, name string) (interface{}, error)
 	ListIndexerResources(ctx context.Context, cluster string, resource string, namespace string, listOption types.ListOptions) (interface{}, error)
@@ -123,7 +241,7 @@ type cluster struct {
 
 func (c *cluster) preCreate(ctx context.Context, req *types.CreateClusterRequest) error {
 	// 实际创建前，先创建集群的连通性
-	if err := c.Ping(ctx, req.KubeConfig); err != nil {
+	if err := c.Ping(ctx, req.KubeConfig, req.Proxy); err != nil {
 		return fmt.Errorf("尝试连接 kubernetes API 失败: %v", err)
 	}
 	return nil
@@ -138,14 +256,23 @@ func (c *cluster) Create(ctx context.Context, req *types.CreateClusterRequest) e
 	if err := c.preCreate(ctx, req); err != nil {
 		return errors.NewError(err, http.StatusBadRequest)
 	}
+	if req.TenantId != 0 {
+		if err := ctrlutil.ValidateTenantAssignment(ctx, c.factory, req.TenantId); err != nil {
+			return err
+		}
+	}
 	// TODO: 集群名称必须是由英文，数字组成
 	if len(req.Name) == 0 {
 		req.Name = uuid.NewRandName(8)
 	}
 
+	proxy, err := marshalClusterProxy(req.Proxy)
+	if err != nil {
+		return errors.ErrInvalidRequest
+	}
 	var cs *client.ClusterSet
 	var txFunc = func(cluster *model.Cluster) (err error) {
-		if cs, err = client.NewClusterSet(req.KubeConfig); err != nil {
+		if cs, err = client.NewClusterSet(req.KubeConfig, client.WithProxy(proxy)); err != nil {
 			return
 		}
 
@@ -157,6 +284,14 @@ func (c *cluster) Create(ctx context.Context, req *types.CreateClusterRequest) e
 
 	kubeNode := types.KubeNode{}
 	nodes, _ := kubeNode.Marshal()
+	labels, err := req.Labels.Marshal()
+	if err != nil {
+		return errors.ErrInvalidRequest
+	}
+	defaults, err := req.Defaults.Marshal()
+	if err != nil {
+		return errors.ErrInvalidRequest
+	}
 	if _, err := c.factory.Cluster().Create(ctx, &model.Cluster{
 		Name:        req.Name,
 		AliasName:   req.AliasName,
@@ -165,13 +300,18 @@ func (c *cluster) Create(ctx context.Context, req *types.CreateClusterRequest) e
 		KubeConfig:  req.KubeConfig,
 		Description: req.Description,
 		Nodes:       nodes,
+		Labels:      labels,
+		AccessMode:  req.AccessMode,
+		Defaults:    defaults,
+		Proxy:       proxy,
+		TenantId:    req.TenantId,
 	}, txFunc); err != nil {
 		klog.Errorf("failed to create cluster %s: %v", req.Name, err)
 		return errors.ErrServerInternal
 	}
 
 	// TODO: 暂时不做创建后动作
-	ClusterIndexer.Set(req.Name, *cs)
+	c.setClusterSet(req.Name, *cs)
 	return nil
 }
 
@@ -191,6 +331,46 @@ func (c *cluster) Update(ctx context.Context, cid int64, req *types.UpdateCluste
 	if req.Description != nil {
 		updates["description"] = *req.Description
 	}
+	if req.Labels != nil {
+		labels, err := req.Labels.Marshal()
+		if err != nil {
+			return errors.ErrInvalidRequest
+		}
+		updates["labels"] = labels
+	}
+	if req.AccessMode != nil {
+		updates["access_mode"] = *req.AccessMode
+	}
+	if req.TenantId != nil {
+		if err := ctrlutil.ValidateTenantAssignment(ctx, c.factory, *req.TenantId); err != nil {
+			return err
+		}
+		updates["tenant_id"] = *req.TenantId
+	}
+	if req.Defaults != nil {
+		defaults, err := req.Defaults.Marshal()
+		if err != nil {
+			return errors.ErrInvalidRequest
+		}
+		updates["defaults"] = defaults
+	}
+	// kubeconfig 或者代理变更时，使用变更后的配置检查连通性
+	kubeConfig, proxy := object.KubeConfig, object.Proxy
+	if req.KubeConfig != nil {
+		kubeConfig = *req.KubeConfig
+		updates["kube_config"] = kubeConfig
+	}
+	if req.Proxy != nil {
+		if proxy, err = marshalClusterProxy(req.Proxy); err != nil {
+			return errors.ErrInvalidRequest
+		}
+		updates["proxy"] = proxy
+	}
+	if req.KubeConfig != nil || req.Proxy != nil {
+		if err := c.ping(ctx, kubeConfig, proxy); err != nil {
+			return errors.NewError(fmt.Errorf("尝试连接 kubernetes API 失败: %v", err), http.StatusBadRequest)
+		}
+	}
 	if len(updates) == 0 {
 		return errors.ErrInvalidRequest
 	}
@@ -198,9 +378,32 @@ func (c *cluster) Update(ctx context.Context, cid int64, req *types.UpdateCluste
 		klog.Errorf("failed to update cluster(%d): %v", cid, err)
 		return errors.ErrServerInternal
 	}
+
+	if req.KubeConfig != nil || req.Proxy != nil {
+		c.refreshClusterSet(object.Name, kubeConfig, proxy)
+	}
 	return nil
 }
 
+// marshalClusterProxy 代理为空或者 type 为空时表示直连，返回空字符串
+func marshalClusterProxy(p *types.ClusterProxy) (string, error) {
+	if p == nil || len(p.Type) == 0 {
+		return "", nil
+	}
+	return p.Marshal()
+}
+
+// refreshClusterSet kubeconfig 或者代理变更后停止旧的 informer 并重建缓存，重建失败时在下次访问时再构造
+func (c *cluster) refreshClusterSet(name string, kubeConfig string, proxy string) {
+	ClusterIndexer.Delete(name)
+	cs, err := client.NewClusterSet(kubeConfig, client.WithProxy(proxy))
+	if err != nil {
+		klog.Errorf("failed to rebuild cluster(%s) clientSet: %v", name, err)
+		return
+	}
+	c.setClusterSet(name, *cs)
+}
+
 // 删除前置检查
 // 开启集群删除保护，则不允许删除
 func (c *cluster) preDelete(ctx context.Context, cid int64) (cluster *model.Cluster, err error) {
@@ -276,8 +479,16 @@ func (c *cluster) List(ctx context.Context) ([]types.Cluster, error) {
 // Ping 检查和 k8s 集群的连通性
 // 如果能获取到 k8s 接口的正常返回，则返回 nil，否则返回具体 error
 // kubeConfig 为 k8s 证书的 base64 字符串
-func (c *cluster) Ping(ctx context.Context, kubeConfig string) error {
-	clientSet, err := client.NewClientSetFromString(kubeConfig)
+func (c *cluster) Ping(ctx context.Context, kubeConfig string, proxy *types.ClusterProxy) error {
+	p, err := marshalClusterProxy(proxy)
+	if err != nil {
+		return err
+	}
+	return c.ping(ctx, kubeConfig, p)
+}
+
+func (c *cluster) ping(ctx context.Context, kubeConfig string, proxy string) error {
+	clientSet, err := client.NewClientSetFromString(kubeConfig, client.WithProxy(proxy))
 	if err != nil {
 		return err
 	}
@@ -312,6 +523,12 @@ func (c *cluster) GetEventList(ctx context.Context, cluster string, options type
 	}
 	opt := metav1.ListOptions{Limit: options.Limit}
 	fs := c.makeFieldSelector(apitypes.UID(options.Uid), options.Name, options.Namespace, options.Kind)
+	if len(options.Type) != 0 {
+		fs = joinFieldSelector(fs, "type="+options.Type)
+	}
+	if len(options.Reason) != 0 {
+		fs = joinFieldSelector(fs, "reason="+options.Reason)
+	}
 	if len(fs) != 0 {
 		opt.FieldSelector = fs
 	}
@@ -321,7 +538,22 @@ func (c *cluster) GetEventList(ctx context.Context, cluster string, options type
 		return nil, err
 	}
 
-	return clusterSet.Client.CoreV1().Events(options.Namespace).List(ctx, opt)
+	events, err := clusterSet.Client.CoreV1().Events(options.Namespace).List(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	// 最近发生的事件在前
+	sort.SliceStable(events.Items, func(i, j int) bool {
+		return eventTime(events.Items[i]).After(eventTime(events.Items[j]))
+	})
+	return events, nil
+}
+
+func joinFieldSelector(fs string, selector string) string {
+	if len(fs) == 0 {
+		return selector
+	}
+	return fs + "," + selector
 }
 
 // WatchPodLog streams the logs of a pod in a cluster to a websocket connection.
@@ -372,6 +604,7 @@ func (c *cluster) WatchPodLog(ctx context.Context, cluster string, namespace str
 		return err
 	}
 	defer conn.Close()
+	defer trackSession(ctx, sessionutil.KindPodLog, cluster, namespace, podName+"/"+containerName)()
 
 	for {
 		buf := make([]byte, 1024)
@@ -597,8 +830,62 @@ func (c *cluster) GetKubeConfigByName(ctx context.Context, name string) (*restcl
 	return cs.Config, nil
 }
 
+const (
+	ImpersonatePrefix     = "pixiu:"
+	ImpersonateUserGroup  = "pixiu:users"
+	ImpersonateAdminGroup = "pixiu:admins"
+)
+
+func (c *cluster) GetUserKubeConfigByName(ctx context.Context, name string) (*restclient.Config, error) {
+	object, err := c.factory.Cluster().GetClusterByName(ctx, name)
+	if err != nil {
+		klog.Errorf("failed to get cluster %s: %v", name, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrClusterNotFound
+	}
+	config, err := c.GetKubeConfigByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if object.AccessMode != model.ClusterAccessImpersonate {
+		return config, nil
+	}
+
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config = restclient.CopyConfig(config)
+	config.Impersonate = impersonationFor(user)
+	return config, nil
+}
+
+// impersonationFor 模拟的用户名和用户组均带有 pixiu: 前缀，集群管理员需为其绑定 RBAC 权限
+func impersonationFor(user *model.User) restclient.ImpersonationConfig {
+	groups := []string{ImpersonateUserGroup}
+	if user.Role == model.RoleAdmin || user.Role == model.RoleRoot {
+		groups = append(groups, ImpersonateAdminGroup)
+	}
+	return restclient.ImpersonationConfig{
+		UserName: ImpersonatePrefix + user.Name,
+		Groups:   groups,
+	}
+}
+
 // GetClusterSetByName 获取 ClusterSet， 缓存中不存在时，构建缓存再返回
 func (c *cluster) GetClusterSetByName(ctx context.Context, name string) (client.ClusterSet, error) {
+	// 缓存不区分租户，租户用户需先校验集群的归属
+	if _, scoped := db.TenantFromContext(ctx); scoped {
+		object, err := c.factory.Cluster().GetClusterByName(ctx, name)
+		if err != nil {
+			return client.ClusterSet{}, err
+		}
+		if object == nil {
+			return client.ClusterSet{}, errors.ErrClusterNotFound
+		}
+	}
 	cs, ok := ClusterIndexer.Get(name)
 	if ok {
 		klog.Infof("Get %s clusterSet from indexer", name)
@@ -614,16 +901,74 @@ func (c *cluster) GetClusterSetByName(ctx context.Context, name string) (client.
 	if object == nil {
 		return client.ClusterSet{}, errors.ErrClusterNotFound
 	}
-	newClusterSet, err := client.NewClusterSet(object.KubeConfig)
+	newClusterSet, err := client.NewClusterSet(object.KubeConfig, client.WithProxy(object.Proxy))
 	if err != nil {
 		return client.ClusterSet{}, err
 	}
 
 	klog.Infof("set %s clusterSet into indexer", name)
-	ClusterIndexer.Set(name, *newClusterSet)
+	c.setClusterSet(name, *newClusterSet)
 	return *newClusterSet, nil
 }
 
+func (c *cluster) GetDefaults(ctx context.Context, name string) (types.ClusterDefaults, error) {
+	object, err := c.factory.Cluster().GetClusterByName(ctx, name)
+	if err != nil {
+		klog.Errorf("failed to get cluster %s: %v", name, err)
+		return types.ClusterDefaults{}, errors.ErrServerInternal
+	}
+	if object == nil {
+		return types.ClusterDefaults{}, errors.ErrClusterNotFound
+	}
+	return parseClusterDefaults(object), nil
+}
+
+// parseClusterProxy 返回集群的代理设置，不包含代理地址中的密码以及跳板机的密码和私钥
+func parseClusterProxy(o *model.Cluster) *types.ClusterProxy {
+	if len(o.Proxy) == 0 {
+		return nil
+	}
+	proxy := &types.ClusterProxy{}
+	if err := proxy.Unmarshal(o.Proxy); err != nil {
+		klog.Warningf("failed to unmarshal cluster(%s) proxy: %v", o.Name, err)
+		return nil
+	}
+	if u, err := url.Parse(proxy.URL); err == nil {
+		proxy.URL = u.Redacted()
+	}
+	if proxy.SSH != nil {
+		proxy.SSH.Password = ""
+		proxy.SSH.PrivateKey = ""
+	}
+	return proxy
+}
+
+// agentConnectedAt 返回集群当前 agent 隧道的建立时间，agent 未连接时返回空
+func agentConnectedAt(name string) *time.Time {
+	t, ok := tunnel.Agents.Get(name)
+	if !ok {
+		return nil
+	}
+	return &t.ConnectedAt
+}
+
+func parseClusterDefaults(o *model.Cluster) types.ClusterDefaults {
+	var defaults types.ClusterDefaults
+	if len(o.Defaults) != 0 {
+		if err := defaults.Unmarshal(o.Defaults); err != nil {
+			klog.Warningf("failed to unmarshal cluster(%s) defaults: %v", o.Name, err)
+		}
+	}
+	return defaults
+}
+
+// setClusterSet 写入缓存，注册工作负载的变更监听，并按配置采集事件
+func (c *cluster) setClusterSet(name string, cs client.ClusterSet) {
+	c.collectEvents(name, cs)
+	ClusterIndexer.Set(name, cs)
+	c.trackWorkloadChanges(name, cs)
+}
+
 // GetKubernetesMeta
 // TODO：临时构造 client，后续通过 informer 的方式维护缓存
 func (c *cluster) GetKubernetesMeta(ctx context.Context, clusterName string) (*types.KubernetesMeta, error) {
@@ -761,6 +1106,13 @@ func (c *cluster) model2Type(o *model.Cluster) *types.Cluster {
 		// 非核心数据
 		klog.Warningf("failed to unmarshal cluster nodes: %v", err)
 	}
+	var labels types.ClusterLabels
+	if len(o.Labels) != 0 {
+		if err := labels.Unmarshal(o.Labels); err != nil {
+			klog.Warningf("failed to unmarshal cluster labels: %v", err)
+		}
+	}
+	defaults := parseClusterDefaults(o)
 
 	tc := &types.Cluster{
 		PixiuMeta: types.PixiuMeta{
@@ -771,15 +1123,25 @@ func (c *cluster) model2Type(o *model.Cluster) *types.Cluster {
 			GmtCreate:   o.GmtCreate,
 			GmtModified: o.GmtModified,
 		},
-		Name:              o.Name,
-		AliasName:         o.AliasName,
-		ClusterType:       o.ClusterType,
-		KubernetesVersion: o.KubernetesVersion,
-		Nodes:             nodes,
-		PlanId:            o.PlanId,
-		Status:            o.ClusterStatus, // 默认是运行中状态，自建集群会根据实际任务状态修改状态
-		Protected:         o.Protected,
-		Description:       o.Description,
+		Name:                 o.Name,
+		AliasName:            o.AliasName,
+		ClusterType:          o.ClusterType,
+		KubernetesVersion:    o.KubernetesVersion,
+		Nodes:                nodes,
+		PlanId:               o.PlanId,
+		Status:               o.ClusterStatus, // 默认是运行中状态，自建集群会根据实际任务状态修改状态
+		LastProbeTime:        o.LastProbeTime,
+		Protected:            o.Protected,
+		Description:          o.Description,
+		Labels:               labels,
+		AccessMode:           o.AccessMode,
+		Defaults:             defaults,
+		Proxy:                parseClusterProxy(o),
+		AgentConnectedAt:     agentConnectedAt(o.Name),
+		CloudAccountId:       o.CloudAccountId,
+		CloudClusterId:       o.CloudClusterId,
+		CredentialExpiration: o.CredentialExpiration,
+		TenantId:             o.TenantId,
 	}
 
 	//var (
@@ -849,7 +1211,23 @@ func (c *cluster) Run(ctx context.Context, workers int) error {
 }
 
 func (c *cluster) Sync(ctx context.Context) {
-	// TODO: 后续添加同步任务
+	objects, err := c.factory.Cluster().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list clusters: %v", err)
+		return
+	}
+
+	// 确保所有集群的缓存已构建，以便持续记录工作负载的变更和事件
+	for _, object := range objects {
+		if cs, ok := ClusterIndexer.Get(object.Name); ok {
+			c.trackWorkloadChanges(object.Name, cs)
+			c.collectEvents(object.Name, cs)
+			continue
+		}
+		if _, err = c.GetClusterSetByName(ctx, object.Name); err != nil {
+			klog.Warningf("failed to build cluster(%s) clusterSet: %v", object.Name, err)
+		}
+	}
 }
 
 func NewCluster(cfg config.Config, f db.ShareDaoFactory, e *casbin.SyncedEnforcer) *cluster {
@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	FormTypeIntOrString = "int-or-string"
+
+	// schema 嵌套的最大深度，超过时作为未定义结构的字段
+	maxFormDepth = 16
+)
+
+var crdGVR = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+// 由 apiserver 维护或者在表单之外编辑的顶层字段
+var ignoredFormFields = sets.NewString("apiVersion", "kind", "metadata", "status")
+
+// GetCRDForm 将 CRD 指定版本的 OpenAPI v3 schema 转换为表单描述
+func (c *cluster) GetCRDForm(ctx context.Context, cluster string, name string, version string) (*types.CRDForm, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	object, err := cs.Dynamic.Resource(crdGVR).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("failed to get crd %s from cluster(%s): %v", name, cluster, err)
+		return nil, err
+	}
+	var crd apiextensionsv1.CustomResourceDefinition
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &crd); err != nil {
+		klog.Errorf("failed to convert crd %s: %v", name, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	crdVersion := findCRDVersion(&crd, version)
+	if crdVersion == nil {
+		return nil, errors.NewError(fmt.Errorf("crd %s has no version %s", name, version), http.StatusBadRequest)
+	}
+	form := &types.CRDForm{
+		Group:      crd.Spec.Group,
+		Version:    crdVersion.Name,
+		Kind:       crd.Spec.Names.Kind,
+		Plural:     crd.Spec.Names.Plural,
+		Namespaced: crd.Spec.Scope == apiextensionsv1.NamespaceScoped,
+		Fields:     make([]types.FormField, 0),
+	}
+	if crdVersion.Schema == nil || crdVersion.Schema.OpenAPIV3Schema == nil {
+		return form, nil
+	}
+
+	root := crdVersion.Schema.OpenAPIV3Schema
+	required := sets.NewString(root.Required...)
+	for _, fieldName := range sortedProperties(root.Properties) {
+		if ignoredFormFields.Has(fieldName) {
+			continue
+		}
+		prop := root.Properties[fieldName]
+		form.Fields = append(form.Fields, parseFormField(fieldName, fieldName, &prop, required.Has(fieldName), 1))
+	}
+	return form, nil
+}
+
+// findCRDVersion 未指定版本时优先使用存储版本
+func findCRDVersion(crd *apiextensionsv1.CustomResourceDefinition, version string) *apiextensionsv1.CustomResourceDefinitionVersion {
+	for i := range crd.Spec.Versions {
+		v := &crd.Spec.Versions[i]
+		if len(version) != 0 {
+			if v.Name == version {
+				return v
+			}
+			continue
+		}
+		if v.Storage {
+			return v
+		}
+	}
+	return nil
+}
+
+func parseFormField(name string, path string, prop *apiextensionsv1.JSONSchemaProps, required bool, depth int) types.FormField {
+	field := types.FormField{
+		Name:        name,
+		Path:        path,
+		Type:        prop.Type,
+		Format:      prop.Format,
+		Description: prop.Description,
+		Required:    required,
+		Pattern:     prop.Pattern,
+		Minimum:     prop.Minimum,
+		Maximum:     prop.Maximum,
+	}
+	if prop.XIntOrString {
+		field.Type = FormTypeIntOrString
+	}
+	for _, e := range prop.Enum {
+		var value interface{}
+		if err := json.Unmarshal(e.Raw, &value); err == nil {
+			field.Enum = append(field.Enum, value)
+		}
+	}
+	if prop.Default != nil {
+		var value interface{}
+		if err := json.Unmarshal(prop.Default.Raw, &value); err == nil {
+			field.Default = value
+		}
+	}
+	if depth >= maxFormDepth {
+		field.FreeForm = field.Type == "object" || field.Type == "array"
+		return field
+	}
+
+	switch field.Type {
+	case "object":
+		if len(prop.Properties) != 0 {
+			requiredFields := sets.NewString(prop.Required...)
+			for _, fieldName := range sortedProperties(prop.Properties) {
+				child := prop.Properties[fieldName]
+				field.Fields = append(field.Fields, parseFormField(fieldName, path+"."+fieldName, &child, requiredFields.Has(fieldName), depth+1))
+			}
+		} else if prop.AdditionalProperties != nil && prop.AdditionalProperties.Schema != nil {
+			// map 类型，key 由用户填写
+			items := parseFormField("", path, prop.AdditionalProperties.Schema, false, depth+1)
+			field.Items = &items
+		} else {
+			field.FreeForm = true
+		}
+	case "array":
+		if prop.Items != nil && prop.Items.Schema != nil {
+			items := parseFormField("", path, prop.Items.Schema, false, depth+1)
+			field.Items = &items
+		} else {
+			field.FreeForm = true
+		}
+	case "":
+		// 未声明类型的字段，例如 x-kubernetes-preserve-unknown-fields
+		field.FreeForm = !prop.XIntOrString
+	}
+	if prop.XPreserveUnknownFields != nil && *prop.XPreserveUnknownFields && len(field.Fields) == 0 {
+		field.FreeForm = true
+	}
+	return field
+}
+
+func sortedProperties(properties map[string]apiextensionsv1.JSONSchemaProps) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
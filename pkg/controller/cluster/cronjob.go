@@ -0,0 +1,239 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+// 手动触发时为 job 设置的注解，与 kubectl create job --from=cronjob 保持一致
+const cronJobInstantiateAnnotation = "cronjob.kubernetes.io/instantiate"
+
+func (c *cluster) CreateCronJob(ctx context.Context, cluster string, namespace string, req *types.CreateCronJobRequest) (*batchv1.CronJob, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	restartPolicy := req.RestartPolicy
+	if len(restartPolicy) == 0 {
+		restartPolicy = v1.RestartPolicyOnFailure
+	}
+	concurrencyPolicy := batchv1.AllowConcurrent
+	if len(req.ConcurrencyPolicy) != 0 {
+		concurrencyPolicy = batchv1.ConcurrencyPolicy(req.ConcurrencyPolicy)
+	}
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      req.Name,
+			Namespace: namespace,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   req.Schedule,
+			ConcurrencyPolicy:          concurrencyPolicy,
+			Suspend:                    &req.Suspend,
+			SuccessfulJobsHistoryLimit: req.SuccessfulJobsHistoryLimit,
+			FailedJobsHistoryLimit:     req.FailedJobsHistoryLimit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: v1.PodTemplateSpec{
+						Spec: v1.PodSpec{
+							RestartPolicy: restartPolicy,
+							Containers: []v1.Container{{
+								Name:      req.Name,
+								Image:     req.Image,
+								Command:   req.Command,
+								Args:      req.Args,
+								Env:       req.Env,
+								Resources: req.Resources,
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+	object, err := cs.Client.BatchV1().CronJobs(namespace).Create(ctx, cronJob, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("failed to create cronjob %s/%s: %v", namespace, req.Name, err)
+		return nil, err
+	}
+	return object, nil
+}
+
+func (c *cluster) UpdateCronJob(ctx context.Context, cluster string, namespace string, name string, req *types.UpdateCronJobRequest) (*batchv1.CronJob, error) {
+	return c.updateCronJob(ctx, cluster, namespace, name, func(cronJob *batchv1.CronJob) error {
+		spec := &cronJob.Spec
+		if req.Schedule != nil {
+			spec.Schedule = *req.Schedule
+		}
+		if req.ConcurrencyPolicy != nil {
+			spec.ConcurrencyPolicy = batchv1.ConcurrencyPolicy(*req.ConcurrencyPolicy)
+		}
+		if req.SuccessfulJobsHistoryLimit != nil {
+			spec.SuccessfulJobsHistoryLimit = req.SuccessfulJobsHistoryLimit
+		}
+		if req.FailedJobsHistoryLimit != nil {
+			spec.FailedJobsHistoryLimit = req.FailedJobsHistoryLimit
+		}
+
+		containers := spec.JobTemplate.Spec.Template.Spec.Containers
+		if req.Image == nil && req.Command == nil && req.Args == nil && req.Env == nil {
+			return nil
+		}
+		if len(containers) == 0 {
+			return fmt.Errorf("cronjob %s/%s has no container", namespace, name)
+		}
+		if req.Image != nil {
+			containers[0].Image = *req.Image
+		}
+		if req.Command != nil {
+			containers[0].Command = *req.Command
+		}
+		if req.Args != nil {
+			containers[0].Args = *req.Args
+		}
+		if req.Env != nil {
+			containers[0].Env = *req.Env
+		}
+		return nil
+	})
+}
+
+// DeleteCronJob 删除定时任务，已创建的 job 及其 pod 同时在后台删除
+func (c *cluster) DeleteCronJob(ctx context.Context, cluster string, namespace string, name string) error {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	policy := metav1.DeletePropagationBackground
+	if err = cs.Client.BatchV1().CronJobs(namespace).Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &policy}); err != nil {
+		klog.Errorf("failed to delete cronjob %s/%s: %v", namespace, name, err)
+		return err
+	}
+	return nil
+}
+
+// SuspendCronJob 暂停或者恢复定时任务的调度，不影响正在运行的 job
+func (c *cluster) SuspendCronJob(ctx context.Context, cluster string, namespace string, name string, suspend bool) (*batchv1.CronJob, error) {
+	return c.updateCronJob(ctx, cluster, namespace, name, func(cronJob *batchv1.CronJob) error {
+		cronJob.Spec.Suspend = &suspend
+		return nil
+	})
+}
+
+// TriggerCronJob 使用定时任务的模板立即创建 job，等同于 kubectl create job --from=cronjob/<name>
+// 暂停的定时任务同样可以手动触发
+func (c *cluster) TriggerCronJob(ctx context.Context, cluster string, namespace string, name string) (*batchv1.Job, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	cronJob, err := cs.Client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := map[string]string{cronJobInstantiateAnnotation: "manual"}
+	for k, v := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-manual-%s", name, utilrand.String(5)),
+			Namespace:   namespace,
+			Labels:      cronJob.Spec.JobTemplate.Labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob")),
+			},
+		},
+		Spec: cronJob.Spec.JobTemplate.Spec,
+	}
+	object, err := cs.Client.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("failed to trigger cronjob %s/%s: %v", namespace, name, err)
+		return nil, err
+	}
+	return object, nil
+}
+
+// ListCronJobRuns 获取定时任务创建的 job，包括手动触发的 job，按创建时间倒序
+func (c *cluster) ListCronJobRuns(ctx context.Context, cluster string, namespace string, name string) ([]batchv1.Job, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	cronJob, err := cs.Informer.CronJobsLister().CronJobs(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := cs.Informer.JobsLister().Jobs(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]batchv1.Job, 0)
+	for _, job := range jobs {
+		if isOwnedBy(job.OwnerReferences, cronJob.UID) {
+			runs = append(runs, *job)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].CreationTimestamp.After(runs[j].CreationTimestamp.Time)
+	})
+	return runs, nil
+}
+
+// updateCronJob 获取最新的定时任务后修改，冲突时重试
+func (c *cluster) updateCronJob(ctx context.Context, cluster string, namespace string, name string, mutate func(cronJob *batchv1.CronJob) error) (*batchv1.CronJob, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var object *batchv1.CronJob
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cronJob, err := cs.Client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err = mutate(cronJob); err != nil {
+			return err
+		}
+		object, err = cs.Client.BatchV1().CronJobs(namespace).Update(ctx, cronJob, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Errorf("failed to update cronjob %s/%s: %v", namespace, name, err)
+		return nil, err
+	}
+	return object, nil
+}
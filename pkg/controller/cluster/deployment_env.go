@@ -0,0 +1,288 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	EnvFromConfigMap = "configmap"
+	EnvFromSecret    = "secret"
+)
+
+// deploymentContainer 待修改的容器，修改通过 strategic merge patch 提交，仅包含变更的字段
+type deploymentContainer struct {
+	cs         client.ClusterSet
+	deployment *appsv1.Deployment
+	container  *v1.Container
+}
+
+func (c *cluster) getDeploymentContainer(ctx context.Context, cluster, namespace, name, container string) (*deploymentContainer, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := cs.Client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("failed to get deployment %s/%s: %v", namespace, name, err)
+		return nil, err
+	}
+	for i := range deployment.Spec.Template.Spec.Containers {
+		if deployment.Spec.Template.Spec.Containers[i].Name == container {
+			return &deploymentContainer{cs: cs, deployment: deployment, container: &deployment.Spec.Template.Spec.Containers[i]}, nil
+		}
+	}
+	return nil, errors.NewError(fmt.Errorf("container %s not found in deployment %s/%s", container, namespace, name), http.StatusNotFound)
+}
+
+// patch 提交 pod template 的 strategic merge patch，containers 按 name 合并
+func (dc *deploymentContainer) patch(ctx context.Context, container map[string]interface{}, podSpec map[string]interface{}) error {
+	if podSpec == nil {
+		podSpec = make(map[string]interface{})
+	}
+	if container != nil {
+		container["name"] = dc.container.Name
+		podSpec["containers"] = []interface{}{container}
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": podSpec,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	namespace, name := dc.deployment.Namespace, dc.deployment.Name
+	if _, err = dc.cs.Client.AppsV1().Deployments(namespace).Patch(ctx, name, apitypes.StrategicMergePatchType, data, metav1.PatchOptions{}); err != nil {
+		klog.Errorf("failed to patch deployment %s/%s: %v", namespace, name, err)
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+	return nil
+}
+
+// SetDeploymentEnv 添加或者更新单个环境变量，value 和 valueFrom 互斥，设置其一时清理另一个
+func (c *cluster) SetDeploymentEnv(ctx context.Context, cluster, namespace, name, container string, req *types.SetDeploymentEnvRequest) error {
+	if len(req.Value) != 0 && req.ValueFrom != nil {
+		return errors.NewError(fmt.Errorf("value and value_from are mutually exclusive"), http.StatusBadRequest)
+	}
+	dc, err := c.getDeploymentContainer(ctx, cluster, namespace, name, container)
+	if err != nil {
+		return err
+	}
+
+	env := map[string]interface{}{
+		"name":      req.Name,
+		"value":     nil,
+		"valueFrom": nil,
+	}
+	if req.ValueFrom != nil {
+		env["valueFrom"] = req.ValueFrom
+	} else {
+		env["value"] = req.Value
+	}
+	return dc.patch(ctx, map[string]interface{}{"env": []interface{}{env}}, nil)
+}
+
+func (c *cluster) RemoveDeploymentEnv(ctx context.Context, cluster, namespace, name, container, env string) error {
+	dc, err := c.getDeploymentContainer(ctx, cluster, namespace, name, container)
+	if err != nil {
+		return err
+	}
+	var found bool
+	for _, e := range dc.container.Env {
+		if e.Name == env {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.NewError(fmt.Errorf("env %s not found in container %s", env, container), http.StatusNotFound)
+	}
+
+	return dc.patch(ctx, map[string]interface{}{
+		"env": []interface{}{
+			map[string]interface{}{"name": env, "$patch": "delete"},
+		},
+	}, nil)
+}
+
+// AddDeploymentEnvFrom 添加 configmap 或者 secret 的引用
+// envFrom 不支持按元素合并，因此提交修改后的完整列表
+func (c *cluster) AddDeploymentEnvFrom(ctx context.Context, cluster, namespace, name, container string, req *types.AddDeploymentEnvFromRequest) error {
+	dc, err := c.getDeploymentContainer(ctx, cluster, namespace, name, container)
+	if err != nil {
+		return err
+	}
+
+	source := v1.EnvFromSource{Prefix: req.Prefix}
+	ref := v1.LocalObjectReference{Name: req.Name}
+	switch req.Kind {
+	case EnvFromConfigMap:
+		source.ConfigMapRef = &v1.ConfigMapEnvSource{LocalObjectReference: ref, Optional: req.Optional}
+	case EnvFromSecret:
+		source.SecretRef = &v1.SecretEnvSource{LocalObjectReference: ref, Optional: req.Optional}
+	}
+
+	envFrom := make([]v1.EnvFromSource, 0, len(dc.container.EnvFrom)+1)
+	var replaced bool
+	for _, from := range dc.container.EnvFrom {
+		if matchEnvFrom(from, req.Kind, req.Name) {
+			envFrom = append(envFrom, source)
+			replaced = true
+			continue
+		}
+		envFrom = append(envFrom, from)
+	}
+	if !replaced {
+		envFrom = append(envFrom, source)
+	}
+	return dc.patch(ctx, map[string]interface{}{"envFrom": envFrom}, nil)
+}
+
+func (c *cluster) RemoveDeploymentEnvFrom(ctx context.Context, cluster, namespace, name, container, kind, ref string) error {
+	dc, err := c.getDeploymentContainer(ctx, cluster, namespace, name, container)
+	if err != nil {
+		return err
+	}
+
+	envFrom := make([]v1.EnvFromSource, 0, len(dc.container.EnvFrom))
+	for _, from := range dc.container.EnvFrom {
+		if !matchEnvFrom(from, kind, ref) {
+			envFrom = append(envFrom, from)
+		}
+	}
+	if len(envFrom) == len(dc.container.EnvFrom) {
+		return errors.NewError(fmt.Errorf("%s %s is not referenced by container %s", kind, ref, container), http.StatusNotFound)
+	}
+	return dc.patch(ctx, map[string]interface{}{"envFrom": envFrom}, nil)
+}
+
+func matchEnvFrom(from v1.EnvFromSource, kind, name string) bool {
+	switch kind {
+	case EnvFromConfigMap:
+		return from.ConfigMapRef != nil && from.ConfigMapRef.Name == name
+	case EnvFromSecret:
+		return from.SecretRef != nil && from.SecretRef.Name == name
+	}
+	return false
+}
+
+// SetDeploymentVolumeMount 添加或者更新挂载，指定 volume 时同时添加卷
+func (c *cluster) SetDeploymentVolumeMount(ctx context.Context, cluster, namespace, name, container string, req *types.SetDeploymentVolumeMountRequest) error {
+	dc, err := c.getDeploymentContainer(ctx, cluster, namespace, name, container)
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	for _, volume := range dc.deployment.Spec.Template.Spec.Volumes {
+		if volume.Name == req.Name {
+			exists = true
+			break
+		}
+	}
+	var podSpec map[string]interface{}
+	if req.Volume != nil {
+		// 不同类型的卷无法合并，已存在的卷需通过其他方式修改
+		if exists {
+			return errors.NewError(fmt.Errorf("volume %s already exists", req.Name), http.StatusConflict)
+		}
+		podSpec = map[string]interface{}{
+			"volumes": []interface{}{v1.Volume{Name: req.Name, VolumeSource: *req.Volume}},
+		}
+	} else if !exists {
+		return errors.NewError(fmt.Errorf("volume %s not found, volume is required", req.Name), http.StatusBadRequest)
+	}
+
+	mount := map[string]interface{}{
+		"name":      req.Name,
+		"mountPath": req.MountPath,
+		"readOnly":  req.ReadOnly,
+		"subPath":   nil,
+	}
+	if len(req.SubPath) != 0 {
+		mount["subPath"] = req.SubPath
+	}
+	return dc.patch(ctx, map[string]interface{}{"volumeMounts": []interface{}{mount}}, podSpec)
+}
+
+// RemoveDeploymentVolumeMount 删除挂载，卷不再被任何容器挂载时一并删除
+func (c *cluster) RemoveDeploymentVolumeMount(ctx context.Context, cluster, namespace, name, container, mountPath string) error {
+	dc, err := c.getDeploymentContainer(ctx, cluster, namespace, name, container)
+	if err != nil {
+		return err
+	}
+
+	var volumeName string
+	for _, mount := range dc.container.VolumeMounts {
+		if mount.MountPath == mountPath {
+			volumeName = mount.Name
+			break
+		}
+	}
+	if len(volumeName) == 0 {
+		return errors.NewError(fmt.Errorf("mount path %s not found in container %s", mountPath, container), http.StatusNotFound)
+	}
+
+	var podSpec map[string]interface{}
+	if !isVolumeMounted(dc.deployment.Spec.Template.Spec, volumeName, dc.container.Name, mountPath) {
+		podSpec = map[string]interface{}{
+			"volumes": []interface{}{
+				map[string]interface{}{"name": volumeName, "$patch": "delete"},
+			},
+		}
+	}
+	return dc.patch(ctx, map[string]interface{}{
+		"volumeMounts": []interface{}{
+			map[string]interface{}{"mountPath": mountPath, "$patch": "delete"},
+		},
+	}, podSpec)
+}
+
+// isVolumeMounted 判断除待删除的挂载外，卷是否仍被其他挂载使用
+func isVolumeMounted(spec v1.PodSpec, volume, container, mountPath string) bool {
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, ct := range containers {
+		for _, mount := range ct.VolumeMounts {
+			if mount.Name != volume {
+				continue
+			}
+			if ct.Name == container && mount.MountPath == mountPath {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	DeprecationSourceManagedFields = "managedFields"
+	DeprecationSourceLastApplied   = "last-applied-configuration"
+
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// removedAPI 已废弃并将在 removedIn 版本移除的 API，replacement 为空时表示没有替代的 API
+type removedAPI struct {
+	group       string
+	version     string
+	resource    string
+	kind        string
+	replacement string
+	removedIn   string
+}
+
+func (r removedAPI) apiVersion() string {
+	return schema.GroupVersion{Group: r.group, Version: r.version}.String()
+}
+
+// 参考 https://kubernetes.io/docs/reference/using-api/deprecation-guide/
+var removedAPIs = []removedAPI{
+	{"extensions", "v1beta1", "deployments", "Deployment", "apps/v1", "v1.16"},
+	{"extensions", "v1beta1", "daemonsets", "DaemonSet", "apps/v1", "v1.16"},
+	{"extensions", "v1beta1", "replicasets", "ReplicaSet", "apps/v1", "v1.16"},
+	{"extensions", "v1beta1", "networkpolicies", "NetworkPolicy", "networking.k8s.io/v1", "v1.16"},
+	{"apps", "v1beta1", "deployments", "Deployment", "apps/v1", "v1.16"},
+	{"apps", "v1beta1", "statefulsets", "StatefulSet", "apps/v1", "v1.16"},
+	{"apps", "v1beta2", "deployments", "Deployment", "apps/v1", "v1.16"},
+	{"apps", "v1beta2", "statefulsets", "StatefulSet", "apps/v1", "v1.16"},
+	{"apps", "v1beta2", "daemonsets", "DaemonSet", "apps/v1", "v1.16"},
+	{"apps", "v1beta2", "replicasets", "ReplicaSet", "apps/v1", "v1.16"},
+	{"extensions", "v1beta1", "ingresses", "Ingress", "networking.k8s.io/v1", "v1.22"},
+	{"networking.k8s.io", "v1beta1", "ingresses", "Ingress", "networking.k8s.io/v1", "v1.22"},
+	{"networking.k8s.io", "v1beta1", "ingressclasses", "IngressClass", "networking.k8s.io/v1", "v1.22"},
+	{"admissionregistration.k8s.io", "v1beta1", "mutatingwebhookconfigurations", "MutatingWebhookConfiguration", "admissionregistration.k8s.io/v1", "v1.22"},
+	{"admissionregistration.k8s.io", "v1beta1", "validatingwebhookconfigurations", "ValidatingWebhookConfiguration", "admissionregistration.k8s.io/v1", "v1.22"},
+	{"apiextensions.k8s.io", "v1beta1", "customresourcedefinitions", "CustomResourceDefinition", "apiextensions.k8s.io/v1", "v1.22"},
+	{"apiregistration.k8s.io", "v1beta1", "apiservices", "APIService", "apiregistration.k8s.io/v1", "v1.22"},
+	{"certificates.k8s.io", "v1beta1", "certificatesigningrequests", "CertificateSigningRequest", "certificates.k8s.io/v1", "v1.22"},
+	{"coordination.k8s.io", "v1beta1", "leases", "Lease", "coordination.k8s.io/v1", "v1.22"},
+	{"rbac.authorization.k8s.io", "v1beta1", "clusterroles", "ClusterRole", "rbac.authorization.k8s.io/v1", "v1.22"},
+	{"rbac.authorization.k8s.io", "v1beta1", "clusterrolebindings", "ClusterRoleBinding", "rbac.authorization.k8s.io/v1", "v1.22"},
+	{"rbac.authorization.k8s.io", "v1beta1", "roles", "Role", "rbac.authorization.k8s.io/v1", "v1.22"},
+	{"rbac.authorization.k8s.io", "v1beta1", "rolebindings", "RoleBinding", "rbac.authorization.k8s.io/v1", "v1.22"},
+	{"scheduling.k8s.io", "v1beta1", "priorityclasses", "PriorityClass", "scheduling.k8s.io/v1", "v1.22"},
+	{"storage.k8s.io", "v1beta1", "csidrivers", "CSIDriver", "storage.k8s.io/v1", "v1.22"},
+	{"storage.k8s.io", "v1beta1", "csinodes", "CSINode", "storage.k8s.io/v1", "v1.22"},
+	{"storage.k8s.io", "v1beta1", "storageclasses", "StorageClass", "storage.k8s.io/v1", "v1.22"},
+	{"storage.k8s.io", "v1beta1", "volumeattachments", "VolumeAttachment", "storage.k8s.io/v1", "v1.22"},
+	{"batch", "v1beta1", "cronjobs", "CronJob", "batch/v1", "v1.25"},
+	{"discovery.k8s.io", "v1beta1", "endpointslices", "EndpointSlice", "discovery.k8s.io/v1", "v1.25"},
+	{"events.k8s.io", "v1beta1", "events", "Event", "events.k8s.io/v1", "v1.25"},
+	{"autoscaling", "v2beta1", "horizontalpodautoscalers", "HorizontalPodAutoscaler", "autoscaling/v2", "v1.25"},
+	{"policy", "v1beta1", "poddisruptionbudgets", "PodDisruptionBudget", "policy/v1", "v1.25"},
+	{"policy", "v1beta1", "podsecuritypolicies", "PodSecurityPolicy", "", "v1.25"},
+	{"node.k8s.io", "v1beta1", "runtimeclasses", "RuntimeClass", "node.k8s.io/v1", "v1.25"},
+	{"autoscaling", "v2beta2", "horizontalpodautoscalers", "HorizontalPodAutoscaler", "autoscaling/v2", "v1.26"},
+	{"flowcontrol.apiserver.k8s.io", "v1beta1", "flowschemas", "FlowSchema", "flowcontrol.apiserver.k8s.io/v1", "v1.26"},
+	{"flowcontrol.apiserver.k8s.io", "v1beta1", "prioritylevelconfigurations", "PriorityLevelConfiguration", "flowcontrol.apiserver.k8s.io/v1", "v1.26"},
+	{"storage.k8s.io", "v1beta1", "csistoragecapacities", "CSIStorageCapacity", "storage.k8s.io/v1", "v1.27"},
+	{"flowcontrol.apiserver.k8s.io", "v1beta2", "flowschemas", "FlowSchema", "flowcontrol.apiserver.k8s.io/v1", "v1.29"},
+	{"flowcontrol.apiserver.k8s.io", "v1beta2", "prioritylevelconfigurations", "PriorityLevelConfiguration", "flowcontrol.apiserver.k8s.io/v1", "v1.29"},
+	{"flowcontrol.apiserver.k8s.io", "v1beta3", "flowschemas", "FlowSchema", "flowcontrol.apiserver.k8s.io/v1", "v1.32"},
+	{"flowcontrol.apiserver.k8s.io", "v1beta3", "prioritylevelconfigurations", "PriorityLevelConfiguration", "flowcontrol.apiserver.k8s.io/v1", "v1.32"},
+}
+
+// ScanDeprecatedAPIs 扫描集群中使用了在目标版本前移除的 API 的对象，类似 kubent 和 pluto
+// apiserver 会以所有支持的版本返回对象，因此通过 managedFields 和 last-applied-configuration 判断客户端使用的 apiVersion
+func (c *cluster) ScanDeprecatedAPIs(ctx context.Context, cluster string, opts *types.ScanDeprecationOptions) (*types.DeprecationReport, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeprecatedAPIs(ctx, cluster, cs, opts)
+}
+
+func scanDeprecatedAPIs(ctx context.Context, cluster string, cs client.ClusterSet, opts *types.ScanDeprecationOptions) (*types.DeprecationReport, error) {
+	info, err := cs.Client.Discovery().ServerVersion()
+	if err != nil {
+		klog.Errorf("failed to get cluster(%s) version: %v", cluster, err)
+		return nil, err
+	}
+	current, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return nil, err
+	}
+	target, err := parseTargetVersion(current, opts)
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+
+	report := &types.DeprecationReport{
+		Cluster:        cluster,
+		CurrentVersion: info.GitVersion,
+		TargetVersion:  fmt.Sprintf("v%d.%d", target.Major(), target.Minor()),
+		Items:          make([]types.DeprecatedAPI, 0),
+	}
+	for _, api := range removedAPIs {
+		removedIn := version.MustParseGeneric(api.removedIn)
+		// 仅扫描当前版本之后，目标版本及之前移除的 API
+		if !current.LessThan(removedIn) || target.LessThan(removedIn) {
+			continue
+		}
+		objects, err := listRemovedAPIObjects(ctx, cs, api)
+		if err != nil {
+			klog.Warningf("failed to list %s %s in cluster(%s): %v", api.apiVersion(), api.kind, cluster, err)
+			httputils.AddWarning(ctx, "%s %s skipped: %v", api.apiVersion(), api.kind, err)
+			continue
+		}
+		for i := range objects {
+			source, ok := usedAPIVersion(&objects[i], api.apiVersion())
+			if !ok {
+				continue
+			}
+			report.Items = append(report.Items, types.DeprecatedAPI{
+				Namespace:   objects[i].GetNamespace(),
+				Name:        objects[i].GetName(),
+				Kind:        api.kind,
+				APIVersion:  api.apiVersion(),
+				Replacement: api.replacement,
+				RemovedIn:   api.removedIn,
+				Source:      source,
+			})
+		}
+	}
+
+	sort.SliceStable(report.Items, func(i, j int) bool {
+		if report.Items[i].RemovedIn != report.Items[j].RemovedIn {
+			return version.MustParseGeneric(report.Items[i].RemovedIn).LessThan(version.MustParseGeneric(report.Items[j].RemovedIn))
+		}
+		if report.Items[i].Kind != report.Items[j].Kind {
+			return report.Items[i].Kind < report.Items[j].Kind
+		}
+		return report.Items[i].Namespace+"/"+report.Items[i].Name < report.Items[j].Namespace+"/"+report.Items[j].Name
+	})
+	report.Blocking = len(report.Items) != 0
+	return report, nil
+}
+
+// parseTargetVersion 未指定目标版本时使用当前版本之后的第 versions 个版本
+func parseTargetVersion(current *version.Version, opts *types.ScanDeprecationOptions) (*version.Version, error) {
+	if opts != nil && len(opts.TargetVersion) != 0 {
+		target, err := version.ParseGeneric(opts.TargetVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target version %s: %v", opts.TargetVersion, err)
+		}
+		if target.LessThan(current) {
+			return nil, fmt.Errorf("target version %s is lower than current version", opts.TargetVersion)
+		}
+		return target, nil
+	}
+
+	versions := 1
+	if opts != nil && opts.Versions != 0 {
+		versions = opts.Versions
+	}
+	return version.MustParseGeneric(fmt.Sprintf("v%d.%d", current.Major(), current.Minor()+uint(versions))), nil
+}
+
+// listRemovedAPIObjects 优先使用替代的 API 获取对象，集群尚不支持替代的 API 时使用废弃的 API
+func listRemovedAPIObjects(ctx context.Context, cs client.ClusterSet, api removedAPI) ([]unstructured.Unstructured, error) {
+	gvrs := make([]schema.GroupVersionResource, 0, 2)
+	if len(api.replacement) != 0 {
+		if gv, err := schema.ParseGroupVersion(api.replacement); err == nil {
+			gvrs = append(gvrs, gv.WithResource(api.resource))
+		}
+	}
+	gvrs = append(gvrs, schema.GroupVersionResource{Group: api.group, Version: api.version, Resource: api.resource})
+
+	var lastErr error
+	for _, gvr := range gvrs {
+		objects, err := cs.Dynamic.Resource(gvr).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if err == nil {
+			return objects.Items, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	// 集群不提供该资源
+	if apierrors.IsNotFound(lastErr) {
+		return nil, nil
+	}
+	return nil, lastErr
+}
+
+// usedAPIVersion 判断对象是否由客户端使用指定的 apiVersion 创建或者更新
+func usedAPIVersion(object *unstructured.Unstructured, apiVersion string) (string, bool) {
+	if data, ok := object.GetAnnotations()[lastAppliedAnnotation]; ok {
+		var applied metav1.TypeMeta
+		if err := json.Unmarshal([]byte(data), &applied); err == nil && applied.APIVersion == apiVersion {
+			return DeprecationSourceLastApplied, true
+		}
+	}
+	for _, field := range object.GetManagedFields() {
+		if field.APIVersion == apiVersion {
+			return DeprecationSourceManagedFields, true
+		}
+	}
+	return "", false
+}
+
+// checkDeprecatedAPIs 升级前存在使用已移除 API 的对象为阻断项
+func checkDeprecatedAPIs(ctx context.Context, cluster string, cs client.ClusterSet, targetVersion string) types.PrecheckItem {
+	item := types.PrecheckItem{Check: "deprecated_apis", Level: types.PrecheckPass}
+	report, err := scanDeprecatedAPIs(ctx, cluster, cs, &types.ScanDeprecationOptions{TargetVersion: targetVersion})
+	if err != nil {
+		item.Level = types.PrecheckWarning
+		item.Message = fmt.Sprintf("failed to scan deprecated apis: %v", err)
+		return item
+	}
+
+	item.Message = fmt.Sprintf("no objects use apis removed before %s", report.TargetVersion)
+	for _, api := range report.Items {
+		object := api.Kind + "/" + api.Name
+		if len(api.Namespace) != 0 {
+			object = fmt.Sprintf("%s/%s/%s", api.Kind, api.Namespace, api.Name)
+		}
+		item.Objects = append(item.Objects, fmt.Sprintf("%s (%s, removed in %s)", object, api.APIVersion, api.RemovedIn))
+	}
+	if report.Blocking {
+		item.Level = types.PrecheckBlocker
+		item.Message = fmt.Sprintf("%d objects use apis removed before %s", len(report.Items), report.TargetVersion)
+	}
+	return item
+}
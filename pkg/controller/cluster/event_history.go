@@ -0,0 +1,255 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	ctrlutil "github.com/caoyingjunz/pixiu/pkg/controller/util"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	defaultEventPageLimit = 50
+	// 流式返回时每批读取的事件数量
+	eventStreamBatchSize = 500
+)
+
+// 已启动事件采集的 informer，避免重复采集
+var collectedInformers sync.Map
+
+// collectEvents 将集群的 events 写入数据库，集群缓存移除时停止采集
+func (c *cluster) collectEvents(name string, cs client.ClusterSet) {
+	if !c.cc.Event.Enable || cs.Informer == nil {
+		return
+	}
+	if _, loaded := collectedInformers.LoadOrStore(cs.Informer, struct{}{}); loaded {
+		return
+	}
+
+	// events 数量较多且只需写入，单独构造 informer，不放入共享的 informer 缓存
+	ctx, cancel := context.WithCancel(context.Background())
+	informer := informers.NewSharedInformerFactory(cs.Client, 0).Core().V1().Events().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if event, ok := obj.(*v1.Event); ok {
+				c.recordEvent(name, event)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			old, ok := oldObj.(*v1.Event)
+			if !ok {
+				return
+			}
+			cur, ok := newObj.(*v1.Event)
+			if !ok {
+				return
+			}
+			// 仅次数或者消息变化时更新
+			if old.Count == cur.Count && old.Message == cur.Message {
+				return
+			}
+			c.recordEvent(name, cur)
+		},
+	})
+	go informer.Run(ctx.Done())
+
+	stop := cs.Informer.Cancel
+	cs.Informer.Cancel = func() {
+		cancel()
+		collectedInformers.Delete(cs.Informer)
+		if stop != nil {
+			stop()
+		}
+	}
+}
+
+func (c *cluster) recordEvent(cluster string, event *v1.Event) {
+	if !c.sampleEvent(event) {
+		return
+	}
+
+	first, last := event.FirstTimestamp.Time, event.LastTimestamp.Time
+	if first.IsZero() {
+		first = event.CreationTimestamp.Time
+	}
+	if last.IsZero() {
+		last = first
+	}
+	count := event.Count
+	if count == 0 {
+		count = 1
+	}
+	source := event.Source.Component
+	if len(source) == 0 {
+		source = event.ReportingController
+	}
+
+	if err := c.factory.ClusterEvent().Upsert(context.TODO(), &model.ClusterEvent{
+		Cluster:        cluster,
+		UID:            string(event.UID),
+		Namespace:      event.Namespace,
+		Kind:           event.InvolvedObject.Kind,
+		Name:           event.InvolvedObject.Name,
+		Type:           event.Type,
+		Reason:         event.Reason,
+		Message:        event.Message,
+		Source:         source,
+		Count:          count,
+		FirstTimestamp: first,
+		LastTimestamp:  last,
+	}); err != nil {
+		klog.Errorf("failed to record cluster(%s) event %s/%s: %v", cluster, event.Namespace, event.Name, err)
+	}
+}
+
+// sampleEvent 按 uid 对 Normal 类型的事件采样，同一事件的采样结果保持一致
+func (c *cluster) sampleEvent(event *v1.Event) bool {
+	rate := c.cc.Event.NormalSampleRate
+	if event.Type == v1.EventTypeWarning || rate >= 100 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(event.UID))
+	return int(h.Sum32()%100) < rate
+}
+
+func (c *cluster) SearchEvents(ctx context.Context, opts *types.SearchEventOptions) (*types.PageResponse, error) {
+	filters, err := c.eventFilters(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if filters == nil {
+		return &types.PageResponse{PageRequest: opts.PageRequest, Items: []types.ClusterEvent{}}, nil
+	}
+
+	total, err := c.factory.ClusterEvent().Count(ctx, filters...)
+	if err != nil {
+		klog.Errorf("failed to count cluster events: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	page, limit := opts.Page, opts.Limit
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = defaultEventPageLimit
+	}
+	events, err := c.factory.ClusterEvent().List(ctx, append(filters,
+		db.WithOrderByLastSeen(), db.WithOffset((page-1)*limit), db.WithLimit(limit))...)
+	if err != nil {
+		klog.Errorf("failed to search cluster events: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	items := make([]types.ClusterEvent, len(events))
+	for i := range events {
+		items[i] = event2Type(&events[i])
+	}
+	return &types.PageResponse{
+		PageRequest: types.PageRequest{Page: page, Limit: limit},
+		Total:       int(total),
+		Items:       items,
+	}, nil
+}
+
+// StreamEvents 按 id 升序分批读取检索到的全部事件，忽略分页参数，fn 返回错误时停止
+func (c *cluster) StreamEvents(ctx context.Context, opts *types.SearchEventOptions, fn func(types.ClusterEvent) error) error {
+	filters, err := c.eventFilters(ctx, opts)
+	if err != nil || filters == nil {
+		return err
+	}
+
+	var lastId int64
+	for {
+		events, err := c.factory.ClusterEvent().List(ctx, append(filters,
+			db.WithIdAfter(lastId), db.WithOrderByASC(), db.WithLimit(eventStreamBatchSize))...)
+		if err != nil {
+			klog.Errorf("failed to search cluster events after %d: %v", lastId, err)
+			return errors.ErrServerInternal
+		}
+		for i := range events {
+			lastId = events[i].Id
+			if err = fn(event2Type(&events[i])); err != nil {
+				return err
+			}
+		}
+		if len(events) < eventStreamBatchSize {
+			return nil
+		}
+	}
+}
+
+// eventFilters 检索事件的过滤条件，没有可检索的集群时返回 nil
+func (c *cluster) eventFilters(ctx context.Context, opts *types.SearchEventOptions) ([]db.Options, error) {
+	// 仅允许检索有权限访问的集群
+	objects, err := c.factory.Cluster().List(ctx, ctrlutil.MakeDbOptions(ctx)...)
+	if err != nil {
+		klog.Errorf("failed to list clusters: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	clusters := make([]string, 0, len(objects))
+	for _, object := range objects {
+		if len(opts.Cluster) == 0 || object.Name == opts.Cluster {
+			clusters = append(clusters, object.Name)
+		}
+	}
+	if len(clusters) == 0 {
+		return nil, nil
+	}
+
+	return []db.Options{
+		db.WithClusterIn(clusters...),
+		db.WithEventFields(map[string]string{
+			"namespace": opts.Namespace,
+			"kind":      opts.Kind,
+			"name":      opts.Name,
+			"reason":    opts.Reason,
+			"type":      opts.Type,
+		}),
+		db.WithLastSeenBetween(opts.Start, opts.End),
+	}, nil
+}
+
+func event2Type(e *model.ClusterEvent) types.ClusterEvent {
+	return types.ClusterEvent{
+		Id:             e.Id,
+		Cluster:        e.Cluster,
+		Namespace:      e.Namespace,
+		Kind:           e.Kind,
+		Name:           e.Name,
+		Type:           e.Type,
+		Reason:         e.Reason,
+		Message:        e.Message,
+		Source:         e.Source,
+		Count:          e.Count,
+		FirstTimestamp: e.FirstTimestamp,
+		LastTimestamp:  e.LastTimestamp,
+	}
+}
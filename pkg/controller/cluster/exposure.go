@@ -0,0 +1,267 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/controller/fleet"
+	ctrlutil "github.com/caoyingjunz/pixiu/pkg/controller/util"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	ExposureLoadBalancer = "LoadBalancer"
+	ExposureNodePort     = "NodePort"
+	ExposureExternalIP   = "ExternalIP"
+	ExposureIngress      = "Ingress"
+)
+
+// ListExposures 获取所有集群对外暴露的访问入口，包括 LoadBalancer，NodePort，ExternalIP 和 Ingress
+// 指定 fleet 时仅获取分组内的集群，单个集群获取失败时跳过，不影响其他集群的结果
+func (c *cluster) ListExposures(ctx context.Context, fleetName string) ([]types.Exposure, error) {
+	objects, err := c.factory.Cluster().List(ctx, ctrlutil.MakeDbOptions(ctx)...)
+	if err != nil {
+		klog.Errorf("failed to list clusters: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	var members sets.String
+	if len(fleetName) != 0 {
+		names, err := fleet.ResolveClusters(ctx, c.factory, fleetName)
+		if err != nil {
+			return nil, err
+		}
+		members = sets.NewString(names...)
+	}
+
+	exposures := make([]types.Exposure, 0)
+	for _, object := range objects {
+		if members != nil && !members.Has(object.Name) {
+			continue
+		}
+		cs, err := c.GetClusterSetByName(ctx, object.Name)
+		if err != nil {
+			klog.Warningf("failed to get cluster(%s) clientSet: %v", object.Name, err)
+			httputils.AddWarning(ctx, "cluster %s skipped: unreachable", object.Name)
+			continue
+		}
+		es, err := c.listClusterExposures(object.Name, cs.Informer)
+		if err != nil {
+			klog.Warningf("failed to list cluster(%s) exposures: %v", object.Name, err)
+			httputils.AddWarning(ctx, "cluster %s skipped: %v", object.Name, err)
+			continue
+		}
+		exposures = append(exposures, es...)
+	}
+
+	return exposures, nil
+}
+
+func (c *cluster) listClusterExposures(cluster string, informer *client.PixiuInformer) ([]types.Exposure, error) {
+	services, err := informer.ServicesLister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	ingresses, err := informer.IngressesLister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	tenants := c.namespaceTenants(informer)
+
+	exposures := make([]types.Exposure, 0)
+	for _, svc := range services {
+		kind, addresses, ports := parseServiceExposure(svc)
+		if len(kind) == 0 {
+			continue
+		}
+		exposures = append(exposures, types.Exposure{
+			Cluster:   cluster,
+			Namespace: svc.Namespace,
+			Tenant:    tenants[svc.Namespace],
+			Kind:      kind,
+			Name:      svc.Name,
+			Addresses: addresses,
+			Ports:     ports,
+			Owners:    c.getServiceOwners(informer, svc),
+		})
+	}
+
+	for _, ing := range ingresses {
+		exposures = append(exposures, types.Exposure{
+			Cluster:   cluster,
+			Namespace: ing.Namespace,
+			Tenant:    tenants[ing.Namespace],
+			Kind:      ExposureIngress,
+			Name:      ing.Name,
+			Addresses: parseLoadBalancerAddresses(ing.Status.LoadBalancer.Ingress),
+			Hosts:     parseIngressHosts(ing),
+			Owners:    c.getIngressOwners(informer, ing),
+		})
+	}
+
+	sort.SliceStable(exposures, func(i, j int) bool {
+		if exposures[i].Namespace != exposures[j].Namespace {
+			return exposures[i].Namespace < exposures[j].Namespace
+		}
+		return exposures[i].Name < exposures[j].Name
+	})
+	return exposures, nil
+}
+
+// namespaceTenants 通过命名空间的租户标签获取命名空间所属的租户
+func (c *cluster) namespaceTenants(informer *client.PixiuInformer) map[string]string {
+	tenants := make(map[string]string)
+	namespaces, err := informer.NamespacesLister().List(labels.Everything())
+	if err != nil {
+		klog.Warningf("failed to list namespaces: %v", err)
+		return tenants
+	}
+	for _, ns := range namespaces {
+		if tenant, ok := ns.Labels[types.TenantLabelKey]; ok {
+			tenants[ns.Name] = tenant
+		}
+	}
+	return tenants
+}
+
+// parseServiceExposure 返回 service 的暴露类型，访问地址和端口，未对外暴露时返回空
+func parseServiceExposure(svc *v1.Service) (string, []string, []string) {
+	var (
+		kind      string
+		addresses []string
+		ports     []string
+	)
+	switch svc.Spec.Type {
+	case v1.ServiceTypeLoadBalancer:
+		kind = ExposureLoadBalancer
+		addresses = parseLoadBalancerAddresses(svc.Status.LoadBalancer.Ingress)
+		for _, port := range svc.Spec.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+		}
+	case v1.ServiceTypeNodePort:
+		kind = ExposureNodePort
+		for _, port := range svc.Spec.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", port.NodePort, port.Protocol))
+		}
+	}
+
+	if len(svc.Spec.ExternalIPs) != 0 {
+		if len(kind) == 0 {
+			kind = ExposureExternalIP
+			for _, port := range svc.Spec.Ports {
+				ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+			}
+		}
+		addresses = append(addresses, svc.Spec.ExternalIPs...)
+	}
+	return kind, addresses, ports
+}
+
+func parseLoadBalancerAddresses(ingresses []v1.LoadBalancerIngress) []string {
+	var addresses []string
+	for _, ingress := range ingresses {
+		if len(ingress.IP) != 0 {
+			addresses = append(addresses, ingress.IP)
+		}
+		if len(ingress.Hostname) != 0 {
+			addresses = append(addresses, ingress.Hostname)
+		}
+	}
+	return addresses
+}
+
+func parseIngressHosts(ing *networkingv1.Ingress) []string {
+	hosts := sets.NewString()
+	for _, rule := range ing.Spec.Rules {
+		if len(rule.Host) != 0 {
+			hosts.Insert(rule.Host)
+		}
+	}
+	return hosts.List()
+}
+
+// getIngressOwners 通过 ingress 的后端 service 获取后端工作负载
+func (c *cluster) getIngressOwners(informer *client.PixiuInformer, ing *networkingv1.Ingress) []string {
+	serviceNames := sets.NewString()
+	if ing.Spec.DefaultBackend != nil && ing.Spec.DefaultBackend.Service != nil {
+		serviceNames.Insert(ing.Spec.DefaultBackend.Service.Name)
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service != nil {
+				serviceNames.Insert(path.Backend.Service.Name)
+			}
+		}
+	}
+
+	owners := sets.NewString()
+	for _, name := range serviceNames.List() {
+		svc, err := informer.ServicesLister().Services(ing.Namespace).Get(name)
+		if err != nil {
+			continue
+		}
+		owners.Insert(c.getServiceOwners(informer, svc)...)
+	}
+	return owners.List()
+}
+
+// getServiceOwners 通过 service 的 selector 获取后端 pod 所属的工作负载
+func (c *cluster) getServiceOwners(informer *client.PixiuInformer, svc *v1.Service) []string {
+	if len(svc.Spec.Selector) == 0 {
+		return nil
+	}
+	pods, err := informer.PodsLister().Pods(svc.Namespace).List(labels.SelectorFromSet(svc.Spec.Selector))
+	if err != nil {
+		return nil
+	}
+
+	owners := sets.NewString()
+	for _, pod := range pods {
+		for _, ref := range pod.OwnerReferences {
+			if ref.Controller == nil || !*ref.Controller {
+				continue
+			}
+			kind, name := ref.Kind, ref.Name
+			// ReplicaSet 需进一步获取所属的 Deployment
+			if kind == "ReplicaSet" {
+				if rs, err := informer.ReplicaSetsLister().ReplicaSets(pod.Namespace).Get(name); err == nil {
+					for _, rsRef := range rs.OwnerReferences {
+						if rsRef.Controller != nil && *rsRef.Controller {
+							kind, name = rsRef.Kind, rsRef.Name
+						}
+					}
+				}
+			}
+			owners.Insert(kind + "/" + name)
+		}
+	}
+	return owners.List()
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	ctrlutil "github.com/caoyingjunz/pixiu/pkg/controller/util"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	// nvidia.com/gpu 以及 MIG 切分后的 nvidia.com/mig-1g.5gb 等资源
+	gpuResourcePrefix = "nvidia.com/"
+	gpuProductLabel   = "nvidia.com/gpu.product"
+)
+
+func isGPUResource(name v1.ResourceName) bool {
+	return strings.HasPrefix(string(name), gpuResourcePrefix)
+}
+
+// podGPURequests 获取 pod 申请的 GPU 资源，扩展资源的 requests 与 limits 一致，未设置 requests 时使用 limits
+func podGPURequests(pod *v1.Pod) map[string]int64 {
+	requests := make(map[string]int64)
+	for _, container := range pod.Spec.Containers {
+		resources := container.Resources.Requests
+		if len(resources) == 0 {
+			resources = container.Resources.Limits
+		}
+		for name, quantity := range resources {
+			if isGPUResource(name) {
+				requests[string(name)] += quantity.Value()
+			}
+		}
+	}
+	return requests
+}
+
+func isPodTerminated(pod *v1.Pod) bool {
+	return pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
+
+// ListGPUNodes 获取集群中每个节点的 GPU 容量和已分配数量，不含 GPU 的节点不返回
+func (c *cluster) ListGPUNodes(ctx context.Context, cluster string) ([]types.GPUNode, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := cs.Informer.NodesLister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	pods, err := cs.Informer.PodsLister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	allocated := make(map[string]map[string]int64)
+	for _, pod := range pods {
+		if len(pod.Spec.NodeName) == 0 || isPodTerminated(pod) {
+			continue
+		}
+		for name, value := range podGPURequests(pod) {
+			if allocated[pod.Spec.NodeName] == nil {
+				allocated[pod.Spec.NodeName] = make(map[string]int64)
+			}
+			allocated[pod.Spec.NodeName][name] += value
+		}
+	}
+
+	gpuNodes := make([]types.GPUNode, 0)
+	for _, node := range nodes {
+		resources := make([]types.GPUResource, 0)
+		for name, quantity := range node.Status.Capacity {
+			if !isGPUResource(name) || quantity.Value() == 0 {
+				continue
+			}
+			allocatable := node.Status.Allocatable[name]
+			resources = append(resources, types.GPUResource{
+				Name:        string(name),
+				Capacity:    quantity.Value(),
+				Allocatable: allocatable.Value(),
+				Allocated:   allocated[node.Name][string(name)],
+			})
+		}
+		if len(resources) == 0 {
+			continue
+		}
+		sort.Slice(resources, func(i, j int) bool {
+			return resources[i].Name < resources[j].Name
+		})
+		gpuNodes = append(gpuNodes, types.GPUNode{
+			Name:      node.Name,
+			Product:   node.Labels[gpuProductLabel],
+			Resources: resources,
+		})
+	}
+
+	sort.Slice(gpuNodes, func(i, j int) bool {
+		return gpuNodes[i].Name < gpuNodes[j].Name
+	})
+	return gpuNodes, nil
+}
+
+// ListGPUPods 获取集群中申请了 GPU 资源且未结束的 pod
+func (c *cluster) ListGPUPods(ctx context.Context, cluster string) ([]types.GPUPod, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := cs.Informer.PodsLister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	tenants := c.namespaceTenants(cs.Informer)
+
+	gpuPods := make([]types.GPUPod, 0)
+	for _, pod := range pods {
+		if isPodTerminated(pod) {
+			continue
+		}
+		requests := podGPURequests(pod)
+		if len(requests) == 0 {
+			continue
+		}
+		gpuPods = append(gpuPods, types.GPUPod{
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			NodeName:  pod.Spec.NodeName,
+			Tenant:    tenants[pod.Namespace],
+			Phase:     string(pod.Status.Phase),
+			Resources: requests,
+		})
+	}
+
+	sort.SliceStable(gpuPods, func(i, j int) bool {
+		if gpuPods[i].Namespace != gpuPods[j].Namespace {
+			return gpuPods[i].Namespace < gpuPods[j].Namespace
+		}
+		return gpuPods[i].Name < gpuPods[j].Name
+	})
+	return gpuPods, nil
+}
+
+// ListTenantGPUUsages 汇总所有集群中各租户已使用的 GPU 数量，并与租户的 GPU 配额对比
+// 租户通过命名空间的租户标签关联，单个集群获取失败时跳过
+func (c *cluster) ListTenantGPUUsages(ctx context.Context) ([]types.TenantGPUUsage, error) {
+	tenantObjects, err := c.factory.Tenant().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list tenants: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	clusterObjects, err := c.factory.Cluster().List(ctx, ctrlutil.MakeDbOptions(ctx)...)
+	if err != nil {
+		klog.Errorf("failed to list clusters: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	usages := make(map[string]*types.TenantGPUUsage)
+	for _, object := range tenantObjects {
+		usages[object.Name] = &types.TenantGPUUsage{
+			Tenant:   object.Name,
+			Quota:    object.GPUQuota,
+			Clusters: make(map[string]int64),
+		}
+	}
+
+	for _, object := range clusterObjects {
+		pods, err := c.ListGPUPods(ctx, object.Name)
+		if err != nil {
+			klog.Warningf("failed to list cluster(%s) gpu pods: %v", object.Name, err)
+			httputils.AddWarning(ctx, "cluster %s skipped: %v", object.Name, err)
+			continue
+		}
+		for _, pod := range pods {
+			usage, ok := usages[pod.Tenant]
+			if !ok {
+				continue
+			}
+			for _, value := range pod.Resources {
+				usage.Used += value
+				usage.Clusters[object.Name] += value
+			}
+		}
+	}
+
+	result := make([]types.TenantGPUUsage, 0, len(usages))
+	for _, usage := range usages {
+		// 配额为 0 表示不限制
+		usage.Exceeded = usage.Quota != 0 && usage.Used > usage.Quota
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Tenant < result[j].Tenant
+	})
+	return result, nil
+}
@@ -0,0 +1,424 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const defaultNodeDrainTimeout = 300
+
+var summaryResources = []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory, v1.ResourcePods}
+
+// ListNodePools 按 pixiu.io/node-pool 标签汇总节点池，未设置标签的节点不属于任何节点池
+func (c *cluster) ListNodePools(ctx context.Context, cluster string) ([]types.NodePool, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := nodePoolSelector("")
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := cs.Informer.NodesLister().List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]*v1.Node)
+	for _, node := range nodes {
+		pool := node.Labels[types.NodePoolLabelKey]
+		groups[pool] = append(groups[pool], node)
+	}
+	requested, err := nodeRequests(cs.Informer)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := make([]types.NodePool, 0, len(groups))
+	for name, members := range groups {
+		pools = append(pools, summarizeNodePool(name, members, requested))
+	}
+	sort.Slice(pools, func(i, j int) bool {
+		return pools[i].Name < pools[j].Name
+	})
+	return pools, nil
+}
+
+func (c *cluster) GetNodePool(ctx context.Context, cluster string, pool string) (*types.NodePool, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := listPoolNodes(cs.Informer, pool)
+	if err != nil {
+		return nil, err
+	}
+	requested, err := nodeRequests(cs.Informer)
+	if err != nil {
+		return nil, err
+	}
+
+	object := summarizeNodePool(pool, nodes, requested)
+	return &object, nil
+}
+
+// UpdateNodePoolLabels 为节点池的每个节点设置或者删除标签，不允许修改节点池标签本身
+func (c *cluster) UpdateNodePoolLabels(ctx context.Context, cluster string, pool string, req *types.UpdateNodePoolLabelsRequest) error {
+	if _, ok := req.Labels[types.NodePoolLabelKey]; ok {
+		return errors.NewError(fmt.Errorf("label %s can not be changed through the pool", types.NodePoolLabelKey), http.StatusBadRequest)
+	}
+	patchLabels := make(map[string]interface{})
+	for k, v := range req.Labels {
+		patchLabels[k] = v
+	}
+	for _, k := range req.Remove {
+		if k == types.NodePoolLabelKey {
+			return errors.NewError(fmt.Errorf("label %s can not be removed through the pool", types.NodePoolLabelKey), http.StatusBadRequest)
+		}
+		patchLabels[k] = nil
+	}
+	if len(patchLabels) == 0 {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": patchLabels},
+	})
+	if err != nil {
+		return err
+	}
+
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	nodes, err := listPoolNodes(cs.Informer, pool)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if _, err = cs.Client.CoreV1().Nodes().Patch(ctx, node.Name, apitypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			klog.Errorf("failed to patch node(%s) labels: %v", node.Name, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateNodePoolTaints 为节点池的每个节点设置或者删除污点
+func (c *cluster) UpdateNodePoolTaints(ctx context.Context, cluster string, pool string, req *types.UpdateNodePoolTaintsRequest) error {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	nodes, err := listPoolNodes(cs.Informer, pool)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		if err = updateNodeTaints(ctx, cs.Client, node.Name, req); err != nil {
+			klog.Errorf("failed to update node(%s) taints: %v", node.Name, err)
+			return err
+		}
+	}
+	return nil
+}
+
+func updateNodeTaints(ctx context.Context, clientSet kubernetes.Interface, name string, req *types.UpdateNodePoolTaintsRequest) error {
+	removed := make(map[string]bool)
+	for _, key := range req.Remove {
+		removed[key] = true
+	}
+
+	return wait.ExponentialBackoff(wait.Backoff{Steps: Retries, Duration: 100 * time.Millisecond, Factor: 2}, func() (bool, error) {
+		node, err := clientSet.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		taints := make([]v1.Taint, 0)
+		for _, taint := range node.Spec.Taints {
+			if removed[taint.Key] || hasTaint(req.Taints, taint) {
+				continue
+			}
+			taints = append(taints, taint)
+		}
+		node.Spec.Taints = append(taints, req.Taints...)
+
+		if _, err = clientSet.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+			if apierrors.IsConflict(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+func hasTaint(taints []v1.Taint, target v1.Taint) bool {
+	for _, taint := range taints {
+		if taint.Key == target.Key && taint.Effect == target.Effect {
+			return true
+		}
+	}
+	return false
+}
+
+// DrainNodePool 逐个节点设置为不可调度并驱逐 pod，daemonSet 和 static pod 不驱逐
+// 驱逐遵循 PodDisruptionBudget，任一节点超时或者失败时停止，已驱逐的节点保持不可调度
+func (c *cluster) DrainNodePool(ctx context.Context, cluster string, pool string, req *types.DrainNodePoolRequest) ([]types.NodeDrainResult, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := listPoolNodes(cs.Informer, pool)
+	if err != nil {
+		return nil, err
+	}
+	timeout := req.Timeout
+	if timeout == 0 {
+		timeout = defaultNodeDrainTimeout
+	}
+
+	results := make([]types.NodeDrainResult, 0, len(nodes))
+	for _, node := range nodes {
+		evicted, err := drainNode(ctx, cs.Client, node.Name, req.GracePeriodSeconds, time.Duration(timeout)*time.Second)
+		result := types.NodeDrainResult{Node: node.Name, Evicted: evicted}
+		if err != nil {
+			klog.Errorf("failed to drain node(%s) of pool %s: %v", node.Name, pool, err)
+			result.Error = err.Error()
+			results = append(results, result)
+			break
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func drainNode(ctx context.Context, clientSet kubernetes.Interface, name string, gracePeriod *int64, timeout time.Duration) (int, error) {
+	patch := []byte(`{"spec":{"unschedulable":true}}`)
+	if _, err := clientSet.CoreV1().Nodes().Patch(ctx, name, apitypes.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return 0, err
+	}
+
+	pods, err := clientSet.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{FieldSelector: "spec.nodeName=" + name})
+	if err != nil {
+		return 0, err
+	}
+
+	var evicted int
+	// 受 PodDisruptionBudget 限制的 pod 会返回 429，在超时时间内重试
+	err = wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
+		remaining := 0
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if !shouldEvict(pod) {
+				continue
+			}
+			current, err := clientSet.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return false, err
+			}
+			if current.UID != pod.UID {
+				continue
+			}
+			remaining++
+			if current.DeletionTimestamp != nil {
+				continue
+			}
+
+			err = clientSet.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+				ObjectMeta:    metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+				DeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: gracePeriod},
+			})
+			if err == nil {
+				evicted++
+				continue
+			}
+			if !apierrors.IsTooManyRequests(err) && !apierrors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		return remaining == 0, nil
+	})
+	return evicted, err
+}
+
+// shouldEvict daemonSet 的 pod 会被重新调度到本节点，static pod 无法通过 apiserver 删除，已结束的 pod 无需驱逐
+func shouldEvict(pod *v1.Pod) bool {
+	if _, ok := pod.Annotations[v1.MirrorPodAnnotationKey]; ok {
+		return false
+	}
+	if isPodTerminated(pod) {
+		return false
+	}
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller && ref.Kind == "DaemonSet" {
+			return false
+		}
+	}
+	return true
+}
+
+// nodePoolSelector pool 为空时选择所有设置了节点池标签的节点
+func nodePoolSelector(pool string) (labels.Selector, error) {
+	var (
+		requirement *labels.Requirement
+		err         error
+	)
+	if len(pool) == 0 {
+		requirement, err = labels.NewRequirement(types.NodePoolLabelKey, selection.Exists, nil)
+	} else {
+		requirement, err = labels.NewRequirement(types.NodePoolLabelKey, selection.Equals, []string{pool})
+	}
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+	return labels.NewSelector().Add(*requirement), nil
+}
+
+func listPoolNodes(informer *client.PixiuInformer, pool string) ([]*v1.Node, error) {
+	selector, err := nodePoolSelector(pool)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := informer.NodesLister().List(selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, errors.ErrNodePoolNotFound
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+	return nodes, nil
+}
+
+// nodeRequests 统计每个节点上非终止状态 pod 的 requests
+func nodeRequests(informer *client.PixiuInformer) (map[string]v1.ResourceList, error) {
+	pods, err := informer.PodsLister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	requested := make(map[string]v1.ResourceList)
+	for _, pod := range pods {
+		if len(pod.Spec.NodeName) == 0 || isPodTerminated(pod) {
+			continue
+		}
+		list, ok := requested[pod.Spec.NodeName]
+		if !ok {
+			list = v1.ResourceList{}
+			requested[pod.Spec.NodeName] = list
+		}
+		for _, container := range pod.Spec.Containers {
+			for name, quantity := range container.Resources.Requests {
+				addQuantity(list, name, quantity)
+			}
+		}
+		addQuantity(list, v1.ResourcePods, *resource.NewQuantity(1, resource.DecimalSI))
+	}
+	return requested, nil
+}
+
+func summarizeNodePool(name string, nodes []*v1.Node, requested map[string]v1.ResourceList) types.NodePool {
+	pool := types.NodePool{Name: name, Nodes: make([]string, 0, len(nodes))}
+	capacity, allocatable, used := v1.ResourceList{}, v1.ResourceList{}, v1.ResourceList{}
+	for i, node := range nodes {
+		pool.Nodes = append(pool.Nodes, node.Name)
+		if isNodeReady(node) {
+			pool.ReadyNodes++
+		}
+		if node.Spec.Unschedulable {
+			pool.UnschedulableNodes++
+		}
+		// 仅保留所有节点共有的污点
+		if i == 0 {
+			pool.Taints = append(pool.Taints, node.Spec.Taints...)
+		} else {
+			common := make([]v1.Taint, 0)
+			for _, taint := range pool.Taints {
+				if hasTaint(node.Spec.Taints, taint) {
+					common = append(common, taint)
+				}
+			}
+			pool.Taints = common
+		}
+
+		for _, rn := range summaryResources {
+			addQuantity(capacity, rn, node.Status.Capacity[rn])
+			addQuantity(allocatable, rn, node.Status.Allocatable[rn])
+			addQuantity(used, rn, requested[node.Name][rn])
+		}
+	}
+	sort.Strings(pool.Nodes)
+
+	pool.Capacity = formatResources(capacity)
+	pool.Allocatable = formatResources(allocatable)
+	pool.Requested = formatResources(used)
+	return pool
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func addQuantity(list v1.ResourceList, name v1.ResourceName, quantity resource.Quantity) {
+	current := list[name]
+	current.Add(quantity)
+	list[name] = current
+}
+
+func formatResources(list v1.ResourceList) map[string]string {
+	out := make(map[string]string)
+	for _, name := range summaryResources {
+		quantity := list[name]
+		out[string(name)] = quantity.String()
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	sessionutil "github.com/caoyingjunz/pixiu/pkg/util/session"
+)
+
+// StreamPodLogs 以 chunked 的形式返回 pod 日志，follow 时持续输出直到容器退出或者客户端断开
+// sse 为 true 时每行日志作为一个 SSE 事件返回
+func (c *cluster) StreamPodLogs(ctx context.Context, cluster string, namespace string, podName string, opts types.PodLogOptions, sse bool, w http.ResponseWriter, r *http.Request) error {
+	clusterSet, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		klog.Errorf("failed to get cluster(%s) clientSet: %v", cluster, err)
+		return err
+	}
+
+	logOpts := &v1.PodLogOptions{
+		Container: opts.Container,
+		Follow:    opts.Follow,
+		Previous:  opts.Previous,
+	}
+	if opts.TailLines > 0 {
+		logOpts.TailLines = &opts.TailLines
+	}
+	if opts.SinceSeconds > 0 {
+		logOpts.SinceSeconds = &opts.SinceSeconds
+	}
+
+	// 客户端断开时结束日志流
+	reader, err := clusterSet.Client.CoreV1().Pods(namespace).GetLogs(podName, logOpts).Stream(r.Context())
+	if err != nil {
+		klog.Errorf("failed to get pod(%s/%s) log stream: %v", namespace, podName, err)
+		return err
+	}
+	defer reader.Close()
+	// 仅 follow 的日志为长连接
+	if opts.Follow {
+		defer trackSession(ctx, sessionutil.KindPodLog, cluster, namespace, podName+"/"+opts.Container)()
+	}
+
+	flusher, _ := w.(http.Flusher)
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	br := bufio.NewReader(reader)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) != 0 {
+			if sse {
+				_, err = fmt.Fprintf(w, "data: %s\n\n", trimLineEnding(line))
+			} else {
+				_, err = w.Write(line)
+			}
+			if err != nil {
+				// 客户端已断开
+				return nil
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+			continue
+		}
+		if err != nil {
+			if err != io.EOF && r.Context().Err() == nil {
+				klog.Warningf("failed to read pod(%s/%s) logs: %v", namespace, podName, err)
+			}
+			return nil
+		}
+	}
+}
+
+func trimLineEnding(line []byte) []byte {
+	for len(line) != 0 && (line[len(line)-1] == '\n' || line[len(line)-1] == '\r') {
+		line = line[:len(line)-1]
+	}
+	return line
+}
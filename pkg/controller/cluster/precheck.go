@@ -0,0 +1,469 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	PrecheckOperationUpgrade     = "upgrade"
+	PrecheckOperationDrain       = "drain"
+	PrecheckOperationMaintenance = "maintenance"
+)
+
+// precheckScope 巡检时获取的集群对象，nodes 为空时检查整个集群
+type precheckScope struct {
+	operation string
+	nodes     sets.String
+
+	allNodes     []v1.Node
+	pods         []v1.Pod
+	pdbs         []policyv1.PodDisruptionBudget
+	replicaSets  map[string]appsv1.ReplicaSet
+	deployments  map[string]appsv1.Deployment
+	statefulSets map[string]appsv1.StatefulSet
+}
+
+// RunPrecheck 在升级或者排空节点前检查集群的风险项，并记录巡检报告
+// 存在阻断项时报告为 no-go，警告项不影响结果
+func (c *cluster) RunPrecheck(ctx context.Context, cluster string, req *types.RunClusterPrecheckRequest) (*types.ClusterPrecheck, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusInternalServerError)
+	}
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := loadPrecheckScope(ctx, cs.Client, req)
+	if err != nil {
+		return nil, err
+	}
+	items := []types.PrecheckItem{
+		scope.checkNodes(),
+		scope.checkDisruptionBudgets(),
+		scope.checkPDBCoverage(),
+		scope.checkSingleReplicas(),
+		scope.checkUnmanagedPods(),
+		scope.checkLocalStorage(),
+		checkPendingCSRs(ctx, cs.Client),
+	}
+	if req.Operation == PrecheckOperationUpgrade {
+		items = append(items, checkDeprecatedAPIs(ctx, cluster, cs, req.TargetVersion))
+	}
+	passed := true
+	for _, item := range items {
+		if item.Level == types.PrecheckBlocker {
+			passed = false
+		}
+	}
+
+	report, err := json.Marshal(items)
+	if err != nil {
+		return nil, errors.ErrServerInternal
+	}
+	object, err := c.factory.ClusterPrecheck().Create(ctx, &model.ClusterPrecheck{
+		Cluster:   cluster,
+		Operation: req.Operation,
+		Nodes:     strings.Join(req.Nodes, ","),
+		Passed:    passed,
+		Report:    string(report),
+		Operator:  user.Name,
+	})
+	if err != nil {
+		klog.Errorf("failed to create cluster(%s) precheck: %v", cluster, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	return precheck2Type(object), nil
+}
+
+func (c *cluster) GetPrecheck(ctx context.Context, cluster string, id int64) (*types.ClusterPrecheck, error) {
+	object, err := c.factory.ClusterPrecheck().Get(ctx, id)
+	if err != nil {
+		klog.Errorf("failed to get cluster precheck(%d): %v", id, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil || object.Cluster != cluster {
+		return nil, errors.ErrPrecheckNotFound
+	}
+	return precheck2Type(object), nil
+}
+
+func (c *cluster) ListPrechecks(ctx context.Context, cluster string) ([]types.ClusterPrecheck, error) {
+	objects, err := c.factory.ClusterPrecheck().List(ctx, db.WithCluster(cluster), db.WithOrderByDesc())
+	if err != nil {
+		klog.Errorf("failed to list cluster(%s) prechecks: %v", cluster, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	prechecks := make([]types.ClusterPrecheck, len(objects))
+	for i := range objects {
+		prechecks[i] = *precheck2Type(&objects[i])
+	}
+	return prechecks, nil
+}
+
+func loadPrecheckScope(ctx context.Context, client kubernetes.Interface, req *types.RunClusterPrecheckRequest) (*precheckScope, error) {
+	scope := &precheckScope{
+		operation:    req.Operation,
+		nodes:        sets.NewString(req.Nodes...),
+		replicaSets:  make(map[string]appsv1.ReplicaSet),
+		deployments:  make(map[string]appsv1.Deployment),
+		statefulSets: make(map[string]appsv1.StatefulSet),
+	}
+
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	scope.allNodes = nodes.Items
+	existing := sets.NewString()
+	for _, node := range nodes.Items {
+		existing.Insert(node.Name)
+	}
+	if missing := scope.nodes.Difference(existing); missing.Len() != 0 {
+		return nil, errors.NewError(fmt.Errorf("nodes %v not found", missing.List()), http.StatusBadRequest)
+	}
+
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods.Items {
+		// 已结束的 pod 不受维护操作影响
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		if scope.nodes.Len() != 0 && !scope.nodes.Has(pod.Spec.NodeName) {
+			continue
+		}
+		scope.pods = append(scope.pods, pod)
+	}
+
+	pdbs, err := client.PolicyV1().PodDisruptionBudgets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	scope.pdbs = pdbs.Items
+
+	replicaSets, err := client.AppsV1().ReplicaSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range replicaSets.Items {
+		scope.replicaSets[rs.Namespace+"/"+rs.Name] = rs
+	}
+	deployments, err := client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range deployments.Items {
+		scope.deployments[deployment.Namespace+"/"+deployment.Name] = deployment
+	}
+	statefulSets, err := client.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, sts := range statefulSets.Items {
+		scope.statefulSets[sts.Namespace+"/"+sts.Name] = sts
+	}
+
+	return scope, nil
+}
+
+// owner 获取 pod 所属的工作负载，ReplicaSet 进一步获取所属的 Deployment
+func (s *precheckScope) owner(pod *v1.Pod) (string, string, bool) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return "", "", false
+	}
+	kind, name := ref.Kind, ref.Name
+	if kind == "ReplicaSet" {
+		if rs, ok := s.replicaSets[pod.Namespace+"/"+name]; ok {
+			if rsRef := metav1.GetControllerOf(&rs); rsRef != nil {
+				kind, name = rsRef.Kind, rsRef.Name
+			}
+		}
+	}
+	return kind, name, true
+}
+
+// replicas 获取工作负载的期望副本数，不支持的类型返回 -1
+func (s *precheckScope) replicas(kind, namespace, name string) int32 {
+	key := namespace + "/" + name
+	switch kind {
+	case KindDeployment:
+		if deployment, ok := s.deployments[key]; ok && deployment.Spec.Replicas != nil {
+			return *deployment.Spec.Replicas
+		}
+	case "StatefulSet":
+		if sts, ok := s.statefulSets[key]; ok && sts.Spec.Replicas != nil {
+			return *sts.Spec.Replicas
+		}
+	case "ReplicaSet":
+		if rs, ok := s.replicaSets[key]; ok && rs.Spec.Replicas != nil {
+			return *rs.Spec.Replicas
+		}
+	}
+	return -1
+}
+
+func (s *precheckScope) coveredByPDB(pod *v1.Pod) bool {
+	for _, pdb := range s.pdbs {
+		if pdb.Namespace != pod.Namespace || pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNodes 升级时存在未就绪节点为阻断项，排空时需保证有其他可调度的节点
+func (s *precheckScope) checkNodes() types.PrecheckItem {
+	objects := make([]string, 0)
+	var schedulable int
+	for _, node := range s.allNodes {
+		ready := false
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == v1.NodeReady && cond.Status == v1.ConditionTrue {
+				ready = true
+			}
+		}
+		if !ready {
+			objects = append(objects, "Node/"+node.Name)
+			continue
+		}
+		if !node.Spec.Unschedulable && !s.nodes.Has(node.Name) {
+			schedulable++
+		}
+	}
+
+	item := types.PrecheckItem{Check: "node_readiness", Level: types.PrecheckPass, Message: "all nodes are ready", Objects: objects}
+	if len(objects) != 0 {
+		item.Level = types.PrecheckWarning
+		if s.operation == PrecheckOperationUpgrade {
+			item.Level = types.PrecheckBlocker
+		}
+		item.Message = fmt.Sprintf("%d nodes are not ready", len(objects))
+	}
+	if s.operation == PrecheckOperationDrain && schedulable == 0 {
+		item.Level = types.PrecheckBlocker
+		item.Message = "no other schedulable node to receive evicted pods"
+	}
+	return item
+}
+
+// checkDisruptionBudgets 不允许中断的 PDB 会导致驱逐失败
+func (s *precheckScope) checkDisruptionBudgets() types.PrecheckItem {
+	objects := sets.NewString()
+	for _, pdb := range s.pdbs {
+		if pdb.Status.DisruptionsAllowed > 0 || pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			continue
+		}
+		for _, pod := range s.pods {
+			if pod.Namespace == pdb.Namespace && selector.Matches(labels.Set(pod.Labels)) {
+				objects.Insert(fmt.Sprintf("PodDisruptionBudget/%s/%s", pdb.Namespace, pdb.Name))
+				break
+			}
+		}
+	}
+
+	item := types.PrecheckItem{Check: "pdb_disruptions", Level: types.PrecheckPass, Message: "all pod disruption budgets allow disruptions", Objects: objects.List()}
+	if objects.Len() != 0 {
+		item.Level = types.PrecheckBlocker
+		item.Message = fmt.Sprintf("%d pod disruption budgets allow no disruptions, eviction will be blocked", objects.Len())
+	}
+	return item
+}
+
+// checkPDBCoverage 多副本的工作负载未配置 PDB 时，驱逐可能同时中断全部副本
+func (s *precheckScope) checkPDBCoverage() types.PrecheckItem {
+	objects := sets.NewString()
+	for i := range s.pods {
+		pod := &s.pods[i]
+		kind, name, ok := s.owner(pod)
+		if !ok || kind == "DaemonSet" || s.replicas(kind, pod.Namespace, name) <= 1 {
+			continue
+		}
+		if !s.coveredByPDB(pod) {
+			objects.Insert(fmt.Sprintf("%s/%s/%s", kind, pod.Namespace, name))
+		}
+	}
+
+	item := types.PrecheckItem{Check: "pdb_coverage", Level: types.PrecheckPass, Message: "all replicated workloads are covered by pod disruption budgets", Objects: objects.List()}
+	if objects.Len() != 0 {
+		item.Level = types.PrecheckWarning
+		item.Message = fmt.Sprintf("%d replicated workloads are not covered by any pod disruption budget", objects.Len())
+	}
+	return item
+}
+
+// checkSingleReplicas 单副本的工作负载在维护期间会中断服务
+func (s *precheckScope) checkSingleReplicas() types.PrecheckItem {
+	objects := sets.NewString()
+	for i := range s.pods {
+		pod := &s.pods[i]
+		kind, name, ok := s.owner(pod)
+		if !ok {
+			continue
+		}
+		if s.replicas(kind, pod.Namespace, name) == 1 {
+			objects.Insert(fmt.Sprintf("%s/%s/%s", kind, pod.Namespace, name))
+		}
+	}
+
+	item := types.PrecheckItem{Check: "single_replica", Level: types.PrecheckPass, Message: "no single-replica workloads", Objects: objects.List()}
+	if objects.Len() != 0 {
+		item.Level = types.PrecheckWarning
+		item.Message = fmt.Sprintf("%d single-replica workloads will be interrupted", objects.Len())
+	}
+	return item
+}
+
+// checkUnmanagedPods 不属于任何控制器的 pod 被驱逐后不会重建，排空节点时为阻断项
+func (s *precheckScope) checkUnmanagedPods() types.PrecheckItem {
+	objects := make([]string, 0)
+	for i := range s.pods {
+		pod := &s.pods[i]
+		// static pod 由 kubelet 管理
+		if _, ok := pod.Annotations[v1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+		if metav1.GetControllerOf(pod) == nil {
+			objects = append(objects, fmt.Sprintf("Pod/%s/%s", pod.Namespace, pod.Name))
+		}
+	}
+
+	item := types.PrecheckItem{Check: "unmanaged_pods", Level: types.PrecheckPass, Message: "all pods are managed by controllers", Objects: objects}
+	if len(objects) != 0 {
+		item.Level = types.PrecheckWarning
+		if s.operation == PrecheckOperationDrain {
+			item.Level = types.PrecheckBlocker
+		}
+		item.Message = fmt.Sprintf("%d pods are not managed by any controller and will not be recreated", len(objects))
+	}
+	return item
+}
+
+// checkLocalStorage 使用 emptyDir 或者 hostPath 的 pod 迁移后本地数据会丢失
+func (s *precheckScope) checkLocalStorage() types.PrecheckItem {
+	objects := make([]string, 0)
+	for i := range s.pods {
+		pod := &s.pods[i]
+		if ref := metav1.GetControllerOf(pod); ref != nil && ref.Kind == "DaemonSet" {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.EmptyDir != nil || volume.HostPath != nil {
+				objects = append(objects, fmt.Sprintf("Pod/%s/%s", pod.Namespace, pod.Name))
+				break
+			}
+		}
+	}
+
+	item := types.PrecheckItem{Check: "local_storage", Level: types.PrecheckPass, Message: "no pods use local storage", Objects: objects}
+	if len(objects) != 0 {
+		item.Level = types.PrecheckWarning
+		item.Message = fmt.Sprintf("%d pods use emptyDir or hostPath volumes, local data will be lost", len(objects))
+	}
+	return item
+}
+
+// checkPendingCSRs 未审批的证书请求可能导致节点升级后无法加入集群
+func checkPendingCSRs(ctx context.Context, client kubernetes.Interface) types.PrecheckItem {
+	item := types.PrecheckItem{Check: "pending_csr", Level: types.PrecheckPass, Message: "no pending certificate signing requests"}
+
+	csrs, err := client.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		item.Level = types.PrecheckWarning
+		item.Message = fmt.Sprintf("failed to list certificate signing requests: %v", err)
+		return item
+	}
+	for _, csr := range csrs.Items {
+		pending := true
+		for _, cond := range csr.Status.Conditions {
+			if cond.Type == certificatesv1.CertificateApproved || cond.Type == certificatesv1.CertificateDenied || cond.Type == certificatesv1.CertificateFailed {
+				pending = false
+			}
+		}
+		if pending {
+			item.Objects = append(item.Objects, "CertificateSigningRequest/"+csr.Name)
+		}
+	}
+	if len(item.Objects) != 0 {
+		item.Level = types.PrecheckWarning
+		item.Message = fmt.Sprintf("%d certificate signing requests are pending", len(item.Objects))
+	}
+	return item
+}
+
+func precheck2Type(o *model.ClusterPrecheck) *types.ClusterPrecheck {
+	precheck := &types.ClusterPrecheck{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Cluster:   o.Cluster,
+		Operation: o.Operation,
+		Passed:    o.Passed,
+		Operator:  o.Operator,
+	}
+	if len(o.Nodes) != 0 {
+		precheck.Nodes = strings.Split(o.Nodes, ",")
+	}
+	if err := json.Unmarshal([]byte(o.Report), &precheck.Items); err != nil {
+		klog.Warningf("failed to unmarshal cluster precheck(%d) report: %v", o.Id, err)
+	}
+	return precheck
+}
@@ -0,0 +1,395 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/gin-gonic/gin"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	pixiuerrors "github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+// 租户的 GPU 配额为所有 nvidia.com/ 前缀资源的总数
+const resourceGPU v1.ResourceName = "gpu"
+
+// AdmitDeployment 通过 pixiu 创建或者更新 deployment 前，计算变更后租户的资源申请量并与租户配额对比
+// old 为空时表示创建，命名空间不属于任何租户或者资源申请量未增加时不做校验
+func (c *cluster) AdmitDeployment(ctx context.Context, cluster string, old, cur *appsv1.Deployment) error {
+	var oldRequests v1.ResourceList
+	if old != nil {
+		oldRequests = workloadRequests(old.Spec.Replicas, old.Spec.Template.Spec)
+	}
+	return c.admit(ctx, cluster, "deployment", cur.Namespace, cur.Name, oldRequests, workloadRequests(cur.Spec.Replicas, cur.Spec.Template.Spec))
+}
+
+// admitStatefulSet 与 AdmitDeployment 相同，校验 statefulset 的资源申请量
+func (c *cluster) admitStatefulSet(ctx context.Context, cluster string, old, cur *appsv1.StatefulSet) error {
+	var oldRequests v1.ResourceList
+	if old != nil {
+		oldRequests = workloadRequests(old.Spec.Replicas, old.Spec.Template.Spec)
+	}
+	return c.admit(ctx, cluster, "statefulset", cur.Namespace, cur.Name, oldRequests, workloadRequests(cur.Spec.Replicas, cur.Spec.Template.Spec))
+}
+
+// AdmitObject 通过 apply 或者重新创建提交 deployment 和 statefulset 前校验租户的配额，其他类型的对象不做校验
+// 对象未设置命名空间时使用 namespace，已存在的对象未设置副本数时沿用当前的副本数
+func (c *cluster) AdmitObject(ctx context.Context, cluster string, namespace string, object *unstructured.Unstructured) error {
+	gvk := object.GroupVersionKind()
+	if gvk.Group != appsv1.GroupName || (gvk.Kind != "Deployment" && gvk.Kind != "StatefulSet") {
+		return nil
+	}
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	if len(object.GetNamespace()) != 0 {
+		namespace = object.GetNamespace()
+	}
+
+	if gvk.Kind == "Deployment" {
+		cur := &appsv1.Deployment{}
+		if err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, cur); err != nil {
+			return errors.NewError(err, http.StatusBadRequest)
+		}
+		cur.Namespace = namespace
+		old, err := cs.Informer.DeploymentsLister().Deployments(namespace).Get(cur.Name)
+		if err != nil {
+			old = nil
+		}
+		if old != nil && cur.Spec.Replicas == nil {
+			cur.Spec.Replicas = old.Spec.Replicas
+		}
+		return c.AdmitDeployment(ctx, cluster, old, cur)
+	}
+
+	cur := &appsv1.StatefulSet{}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, cur); err != nil {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+	cur.Namespace = namespace
+	old, err := cs.Informer.StatefulSetsLister().StatefulSets(namespace).Get(cur.Name)
+	if err != nil {
+		old = nil
+	}
+	if old != nil && cur.Spec.Replicas == nil {
+		cur.Spec.Replicas = old.Spec.Replicas
+	}
+	return c.admitStatefulSet(ctx, cluster, old, cur)
+}
+
+// AdmitPatch 将 patch 应用到当前的 deployment 或者 statefulset 后校验租户的配额，对象不存在时交由 apiserver 处理
+// subresource 为 scale 时 patch 作用于 scale 子资源，仅改变副本数
+func (c *cluster) AdmitPatch(ctx context.Context, cluster string, kind string, namespace string, name string, subresource string, patchType apitypes.PatchType, patch []byte) error {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case "Deployment":
+		old, err := cs.Informer.DeploymentsLister().Deployments(namespace).Get(name)
+		if err != nil {
+			return nil
+		}
+		cur := old.DeepCopy()
+		if subresource == "scale" {
+			if cur.Spec.Replicas, err = patchReplicas(old.Spec.Replicas, patchType, patch); err != nil {
+				return errors.NewError(err, http.StatusBadRequest)
+			}
+		} else {
+			cur = &appsv1.Deployment{}
+			if err = patchObject(old, patchType, patch, cur); err != nil {
+				return errors.NewError(err, http.StatusBadRequest)
+			}
+		}
+		cur.Namespace = namespace
+		return c.AdmitDeployment(ctx, cluster, old, cur)
+	case "StatefulSet":
+		old, err := cs.Informer.StatefulSetsLister().StatefulSets(namespace).Get(name)
+		if err != nil {
+			return nil
+		}
+		cur := old.DeepCopy()
+		if subresource == "scale" {
+			if cur.Spec.Replicas, err = patchReplicas(old.Spec.Replicas, patchType, patch); err != nil {
+				return errors.NewError(err, http.StatusBadRequest)
+			}
+		} else {
+			cur = &appsv1.StatefulSet{}
+			if err = patchObject(old, patchType, patch, cur); err != nil {
+				return errors.NewError(err, http.StatusBadRequest)
+			}
+		}
+		cur.Namespace = namespace
+		return c.admitStatefulSet(ctx, cluster, old, cur)
+	}
+	return nil
+}
+
+// admit 超出配额时根据配置拒绝请求，或者仅记录告警
+// 告警同时写入返回值的附加信息，以及与 apiserver 格式一致的 Warning 响应头，kubectl 等客户端会展示该告警
+func (c *cluster) admit(ctx context.Context, cluster string, kind string, namespace string, name string, old, cur v1.ResourceList) error {
+	err := c.checkQuota(ctx, cluster, namespace, old, cur)
+	if err == nil || !c.cc.Quota.Warn() {
+		return err
+	}
+
+	klog.Warningf("%s %s/%s in cluster(%s) admitted with warning: %v", kind, namespace, name, cluster, err)
+	httputils.AddWarning(ctx, "%v", err)
+	if gc, ok := ctx.(*gin.Context); ok {
+		gc.Writer.Header().Add("Warning", "299 - "+strconv.QuoteToASCII(err.Error()))
+	}
+	return nil
+}
+
+// checkQuota 计算变更后租户的资源申请量并与租户配额对比，old 为空时表示创建
+func (c *cluster) checkQuota(ctx context.Context, cluster string, namespace string, old, cur v1.ResourceList) error {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	ns, err := cs.Informer.NamespacesLister().Get(namespace)
+	if err != nil {
+		// 命名空间不存在时交由 apiserver 处理
+		return nil
+	}
+	tenantName, ok := ns.Labels[types.TenantLabelKey]
+	if !ok {
+		return nil
+	}
+
+	delta := cur
+	subtractResources(delta, old)
+	if !hasPositive(delta) {
+		return nil
+	}
+
+	tenant, err := c.factory.Tenant().GetTenantByName(ctx, tenantName)
+	if err != nil {
+		klog.Errorf("failed to get tenant %s: %v", tenantName, err)
+		return errors.ErrServerInternal
+	}
+	if tenant == nil {
+		return nil
+	}
+	quotas, err := tenantQuotas(tenant.CPUQuota, tenant.MemoryQuota, tenant.GPUQuota)
+	if err != nil {
+		klog.Warningf("invalid quota of tenant %s: %v", tenantName, err)
+		return nil
+	}
+	if len(quotas) == 0 {
+		return nil
+	}
+
+	used := c.tenantRequests(ctx, tenantName)
+	var exceeded []string
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory, resourceGPU} {
+		quota, ok := quotas[name]
+		if !ok {
+			continue
+		}
+		increase, ok := delta[name]
+		if !ok || increase.Sign() <= 0 {
+			continue
+		}
+		total := used[name]
+		total.Add(increase)
+		if total.Cmp(quota) > 0 {
+			exceeded = append(exceeded, fmt.Sprintf("%s requested %s, used %s, quota %s", name, increase.String(), resourceString(used[name]), quota.String()))
+		}
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+	return errors.NewError(fmt.Errorf("%v %s: %s", pixiuerrors.ErrTenantQuotaExceeded, tenantName, strings.Join(exceeded, "; ")), http.StatusForbidden)
+}
+
+// tenantRequests 汇总所有集群中租户未结束的 pod 申请的资源，单个集群获取失败时跳过
+func (c *cluster) tenantRequests(ctx context.Context, tenantName string) v1.ResourceList {
+	used := v1.ResourceList{}
+	objects, err := c.factory.Cluster().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list clusters: %v", err)
+		return used
+	}
+
+	for _, object := range objects {
+		cs, err := c.GetClusterSetByName(ctx, object.Name)
+		if err != nil {
+			klog.Warningf("failed to get cluster(%s) clientSet: %v", object.Name, err)
+			continue
+		}
+		tenants := c.namespaceTenants(cs.Informer)
+		pods, err := cs.Informer.PodsLister().List(labels.Everything())
+		if err != nil {
+			klog.Warningf("failed to list cluster(%s) pods: %v", object.Name, err)
+			continue
+		}
+		for _, pod := range pods {
+			if tenants[pod.Namespace] != tenantName || isPodTerminated(pod) {
+				continue
+			}
+			addResources(used, podSpecRequests(pod.Spec), 1)
+		}
+	}
+	return used
+}
+
+// workloadRequests 计算工作负载全部副本申请的资源，未设置副本数时与 apiserver 的默认值一致为 1
+func workloadRequests(replicas *int32, spec v1.PodSpec) v1.ResourceList {
+	n := int64(1)
+	if replicas != nil {
+		n = int64(*replicas)
+	}
+	requests := v1.ResourceList{}
+	addResources(requests, podSpecRequests(spec), n)
+	return requests
+}
+
+// patchObject 将 patch 应用到 original 并解析到 out，server-side apply 的请求按 merge patch 近似处理
+func patchObject(original interface{}, patchType apitypes.PatchType, patch []byte, out interface{}) error {
+	data, err := json.Marshal(original)
+	if err != nil {
+		return err
+	}
+	switch patchType {
+	case apitypes.JSONPatchType:
+		p, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return err
+		}
+		data, err = p.Apply(data)
+	case apitypes.MergePatchType:
+		data, err = jsonpatch.MergePatch(data, patch)
+	case apitypes.StrategicMergePatchType:
+		data, err = strategicpatch.StrategicMergePatch(data, patch, out)
+	case apitypes.ApplyPatchType:
+		var applied []byte
+		if applied, err = yaml.YAMLToJSON(patch); err == nil {
+			data, err = jsonpatch.MergePatch(data, applied)
+		}
+	default:
+		return fmt.Errorf("unsupported patch type %s", patchType)
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// patchReplicas 将 patch 应用到 scale 子资源，返回变更后的副本数
+func patchReplicas(replicas *int32, patchType apitypes.PatchType, patch []byte) (*int32, error) {
+	scale := &autoscalingv1.Scale{}
+	if replicas != nil {
+		scale.Spec.Replicas = *replicas
+	}
+	cur := &autoscalingv1.Scale{}
+	if err := patchObject(scale, patchType, patch, cur); err != nil {
+		return nil, err
+	}
+	return &cur.Spec.Replicas, nil
+}
+
+// podSpecRequests 获取 pod 申请的 cpu，内存和 GPU，未设置 requests 时使用 limits，与 apiserver 的默认行为一致
+func podSpecRequests(spec v1.PodSpec) v1.ResourceList {
+	requests := v1.ResourceList{}
+	for _, container := range spec.Containers {
+		for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+			quantity, ok := container.Resources.Requests[name]
+			if !ok {
+				quantity, ok = container.Resources.Limits[name]
+			}
+			if ok {
+				addQuantity(requests, name, quantity)
+			}
+		}
+	}
+	for _, value := range podGPURequests(&v1.Pod{Spec: spec}) {
+		addQuantity(requests, resourceGPU, *resource.NewQuantity(value, resource.DecimalSI))
+	}
+	return requests
+}
+
+func tenantQuotas(cpu, memory string, gpu int64) (v1.ResourceList, error) {
+	quotas := v1.ResourceList{}
+	for name, value := range map[v1.ResourceName]string{v1.ResourceCPU: cpu, v1.ResourceMemory: memory} {
+		if len(value) == 0 {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, err
+		}
+		quotas[name] = quantity
+	}
+	// GPU 配额为 0 表示不限制
+	if gpu != 0 {
+		quotas[resourceGPU] = *resource.NewQuantity(gpu, resource.DecimalSI)
+	}
+	return quotas, nil
+}
+
+func addResources(list v1.ResourceList, add v1.ResourceList, times int64) {
+	for name, quantity := range add {
+		addQuantity(list, name, *resource.NewMilliQuantity(quantity.MilliValue()*times, quantity.Format))
+	}
+}
+
+func subtractResources(list v1.ResourceList, sub v1.ResourceList) {
+	for name, quantity := range sub {
+		total := list[name]
+		total.Sub(quantity)
+		list[name] = total
+	}
+}
+
+func hasPositive(list v1.ResourceList) bool {
+	for _, quantity := range list {
+		if quantity.Sign() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceString(quantity resource.Quantity) string {
+	if quantity.IsZero() {
+		return "0"
+	}
+	return quantity.String()
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+	changeCauseAnnotation = "kubernetes.io/change-cause"
+)
+
+// RestartDeployment 通过修改 pod template 的 annotation 触发滚动重启，等同于 kubectl rollout restart
+func (c *cluster) RestartDeployment(ctx context.Context, cluster string, namespace string, name string) error {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	deployment, err := cs.Client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("failed to get deployment %s/%s: %v", namespace, name, err)
+		return err
+	}
+	if deployment.Spec.Paused {
+		return errors.NewError(fmt.Errorf("deployment %s/%s is paused, resume it before restart", namespace, name), http.StatusBadRequest)
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{
+						restartedAtAnnotation: time.Now().Format(time.RFC3339),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = cs.Client.AppsV1().Deployments(namespace).Patch(ctx, name, apitypes.StrategicMergePatchType, data, metav1.PatchOptions{}); err != nil {
+		klog.Errorf("failed to restart deployment %s/%s: %v", namespace, name, err)
+		return err
+	}
+	return nil
+}
+
+// GetDeploymentRolloutHistory 获取 deployment 的发布历史，每个 revision 对应一个 replicaSet，按 revision 倒序
+func (c *cluster) GetDeploymentRolloutHistory(ctx context.Context, cluster string, namespace string, name string) ([]types.DeploymentRevision, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := cs.Informer.DeploymentsLister().Deployments(namespace).Get(name)
+	if err != nil {
+		klog.Errorf("failed to get deployment (%s/%s) from indexer: %v", namespace, name, err)
+		return nil, err
+	}
+	replicaSets, err := listOwnedReplicaSets(cs.Informer, deployment)
+	if err != nil {
+		return nil, err
+	}
+
+	current := deployment.Annotations[revisionAnnotation]
+	revisions := make([]types.DeploymentRevision, 0, len(replicaSets))
+	for _, rs := range replicaSets {
+		images := make([]string, 0)
+		for _, container := range rs.Spec.Template.Spec.Containers {
+			images = append(images, container.Image)
+		}
+		revision, _ := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+		revisions = append(revisions, types.DeploymentRevision{
+			Revision:          revision,
+			ReplicaSet:        rs.Name,
+			Images:            images,
+			ChangeCause:       rs.Annotations[changeCauseAnnotation],
+			Replicas:          rs.Status.Replicas,
+			Current:           rs.Annotations[revisionAnnotation] == current,
+			CreationTimestamp: rs.CreationTimestamp.Time,
+		})
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].Revision > revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// listOwnedReplicaSets 获取 deployment 所属的 replicaSet
+func listOwnedReplicaSets(informer *client.PixiuInformer, deployment *appsv1.Deployment) ([]*appsv1.ReplicaSet, error) {
+	objects, err := informer.ReplicaSetsLister().ReplicaSets(deployment.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	replicaSets := make([]*appsv1.ReplicaSet, 0)
+	for _, rs := range objects {
+		if isOwnedBy(rs.OwnerReferences, deployment.UID) {
+			replicaSets = append(replicaSets, rs)
+		}
+	}
+	return replicaSets, nil
+}
+
+// UndoDeployment 将 deployment 的 pod template 回滚到指定 revision，未指定时回滚到上一个 revision，等同于 kubectl rollout undo
+func (c *cluster) UndoDeployment(ctx context.Context, cluster string, namespace string, name string, req *types.UndoDeploymentRequest) error {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		old, err := cs.Client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if old.Spec.Paused {
+			return errors.NewError(fmt.Errorf("deployment %s/%s is paused, resume it before undo", namespace, name), http.StatusBadRequest)
+		}
+		replicaSets, err := listOwnedReplicaSets(cs.Informer, old)
+		if err != nil {
+			return err
+		}
+		target, err := findRevision(replicaSets, old, req.ToRevision)
+		if err != nil {
+			return errors.NewError(err, http.StatusBadRequest)
+		}
+
+		cur := old.DeepCopy()
+		cur.Spec.Template = *target.Spec.Template.DeepCopy()
+		delete(cur.Spec.Template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+		if err = c.AdmitDeployment(ctx, cluster, old, cur); err != nil {
+			return err
+		}
+		_, err = cs.Client.AppsV1().Deployments(namespace).Update(ctx, cur, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Errorf("failed to undo deployment %s/%s: %v", namespace, name, err)
+		return err
+	}
+	return nil
+}
+
+// findRevision 获取指定 revision 的 replicaSet，revision 为 0 时获取当前 revision 之前最新的一个
+func findRevision(replicaSets []*appsv1.ReplicaSet, deployment *appsv1.Deployment, revision int64) (*appsv1.ReplicaSet, error) {
+	current, _ := strconv.ParseInt(deployment.Annotations[revisionAnnotation], 10, 64)
+	if revision != 0 && revision == current {
+		return nil, fmt.Errorf("deployment %s/%s is already at revision %d", deployment.Namespace, deployment.Name, revision)
+	}
+
+	var (
+		target *appsv1.ReplicaSet
+		latest int64
+	)
+	for _, rs := range replicaSets {
+		v, err := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		if revision != 0 {
+			if v == revision {
+				return rs, nil
+			}
+			continue
+		}
+		if v < current && v > latest {
+			target, latest = rs, v
+		}
+	}
+	if target == nil {
+		if revision != 0 {
+			return nil, fmt.Errorf("revision %d not found for deployment %s/%s", revision, deployment.Namespace, deployment.Name)
+		}
+		return nil, fmt.Errorf("no rollout history found for deployment %s/%s", deployment.Namespace, deployment.Name)
+	}
+	return target, nil
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	ctrlutil "github.com/caoyingjunz/pixiu/pkg/controller/util"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+// 隐藏 secret 明文时使用的值
+const maskedSecretValue = "******"
+
+func (c *cluster) CreateSecret(ctx context.Context, cluster string, namespace string, req *types.CreateSecretRequest) (*types.Secret, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	secretType := req.Type
+	if len(secretType) == 0 {
+		secretType = v1.SecretTypeOpaque
+	}
+	data, err := buildSecretData(secretType, req.SecretContent)
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+
+	object, err := cs.Client.CoreV1().Secrets(namespace).Create(ctx, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      req.Name,
+			Namespace: namespace,
+			Labels:    req.Labels,
+		},
+		Type: secretType,
+		Data: data,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("failed to create secret %s/%s: %v", namespace, req.Name, err)
+		return nil, err
+	}
+	return secret2Type(object, false), nil
+}
+
+// UpdateSecret 修改 labels 或者替换 secret 的内容，冲突时重试
+func (c *cluster) UpdateSecret(ctx context.Context, cluster string, namespace string, name string, req *types.UpdateSecretRequest) (*types.Secret, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var object *v1.Secret
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := cs.Client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if req.Labels != nil {
+			secret.Labels = *req.Labels
+		}
+		if hasSecretContent(req.SecretContent) {
+			data, err := buildSecretData(secret.Type, req.SecretContent)
+			if err != nil {
+				return errors.NewError(err, http.StatusBadRequest)
+			}
+			secret.Data = data
+			secret.StringData = nil
+		}
+		object, err = cs.Client.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Errorf("failed to update secret %s/%s: %v", namespace, name, err)
+		return nil, err
+	}
+	return secret2Type(object, false), nil
+}
+
+func (c *cluster) DeleteSecret(ctx context.Context, cluster string, namespace string, name string) error {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	if err = cs.Client.CoreV1().Secrets(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		klog.Errorf("failed to delete secret %s/%s: %v", namespace, name, err)
+		return err
+	}
+	return nil
+}
+
+func (c *cluster) GetSecret(ctx context.Context, cluster string, namespace string, name string, opts types.SecretOptions) (*types.Secret, error) {
+	if opts.Reveal {
+		if err := c.canRevealSecret(ctx, cluster); err != nil {
+			return nil, err
+		}
+	}
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := cs.Informer.SecretsLister().Secrets(namespace).Get(name)
+	if err != nil {
+		klog.Errorf("failed to get secret (%s/%s) from indexer: %v", namespace, name, err)
+		return nil, err
+	}
+	return secret2Type(object, opts.Reveal), nil
+}
+
+func (c *cluster) ListSecrets(ctx context.Context, cluster string, namespace string, opts types.SecretOptions) ([]types.Secret, error) {
+	if opts.Reveal {
+		if err := c.canRevealSecret(ctx, cluster); err != nil {
+			return nil, err
+		}
+	}
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := cs.Informer.SecretsLister().Secrets(namespace).List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list secrets in %s from indexer: %v", namespace, err)
+		return nil, err
+	}
+	secrets := make([]types.Secret, len(objects))
+	for i, object := range objects {
+		secrets[i] = *secret2Type(object, opts.Reveal)
+	}
+	sort.SliceStable(secrets, func(i, j int) bool {
+		return secrets[i].Name < secrets[j].Name
+	})
+	return secrets, nil
+}
+
+// canRevealSecret kubeproxy 的读请求不经过鉴权，查看明文时需单独校验用户对集群 secrets 的读权限
+// 策略已由鉴权中间件加载
+func (c *cluster) canRevealSecret(ctx context.Context, cluster string) error {
+	if c.cc.Default.Mode.InDebug() {
+		return nil
+	}
+	return ctrlutil.EnforceCluster(ctx, c.factory, c.enforcer, cluster, model.ObjectSecret, model.OpRead)
+}
+
+func hasSecretContent(content types.SecretContent) bool {
+	return content.Data != nil || content.DockerConfig != nil || content.TLS != nil
+}
+
+// buildSecretData 根据 secret 的类型将表单字段转换为 data
+func buildSecretData(secretType v1.SecretType, content types.SecretContent) (map[string][]byte, error) {
+	switch secretType {
+	case v1.SecretTypeOpaque:
+		data := make(map[string][]byte, len(content.Data))
+		for k, v := range content.Data {
+			data[k] = []byte(v)
+		}
+		return data, nil
+	case v1.SecretTypeDockerConfigJson:
+		dc := content.DockerConfig
+		if dc == nil {
+			return nil, fmt.Errorf("docker_config is required for %s secret", secretType)
+		}
+		// 与 kubectl create secret docker-registry 生成的格式保持一致
+		config, err := json.Marshal(map[string]interface{}{
+			"auths": map[string]interface{}{
+				dc.Server: map[string]string{
+					"username": dc.Username,
+					"password": dc.Password,
+					"email":    dc.Email,
+					"auth":     base64.StdEncoding.EncodeToString([]byte(dc.Username + ":" + dc.Password)),
+				},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{v1.DockerConfigJsonKey: config}, nil
+	case v1.SecretTypeTLS:
+		t := content.TLS
+		if t == nil {
+			return nil, fmt.Errorf("tls is required for %s secret", secretType)
+		}
+		if _, err := tls.X509KeyPair([]byte(t.Certificate), []byte(t.Key)); err != nil {
+			return nil, fmt.Errorf("invalid tls certificate or key: %v", err)
+		}
+		return map[string][]byte{
+			v1.TLSCertKey:       []byte(t.Certificate),
+			v1.TLSPrivateKeyKey: []byte(t.Key),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported secret type %s", secretType)
+	}
+}
+
+func secret2Type(object *v1.Secret, reveal bool) *types.Secret {
+	data := make(map[string]string, len(object.Data))
+	for k, v := range object.Data {
+		if reveal {
+			data[k] = string(v)
+		} else {
+			data[k] = maskedSecretValue
+		}
+	}
+	return &types.Secret{
+		Name:              object.Name,
+		Namespace:         object.Namespace,
+		Type:              string(object.Type),
+		Labels:            object.Labels,
+		Data:              data,
+		Masked:            !reveal,
+		ResourceVersion:   object.ResourceVersion,
+		CreationTimestamp: object.CreationTimestamp.Time,
+	}
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+func (c *cluster) CreateService(ctx context.Context, cluster string, namespace string, req *types.CreateServiceRequest) (*v1.Service, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceType := req.Type
+	if len(serviceType) == 0 {
+		serviceType = v1.ServiceTypeClusterIP
+	}
+	ports, err := buildServicePorts(serviceType, req.Ports)
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+
+	object, err := cs.Client.CoreV1().Services(namespace).Create(ctx, &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      req.Name,
+			Namespace: namespace,
+			Labels:    req.Labels,
+		},
+		Spec: v1.ServiceSpec{
+			Type:     serviceType,
+			Selector: req.Selector,
+			Ports:    ports,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("failed to create service %s/%s: %v", namespace, req.Name, err)
+		return nil, err
+	}
+	return object, nil
+}
+
+// UpdateService 修改 service 的类型，selector，端口或者 labels，冲突时重试
+func (c *cluster) UpdateService(ctx context.Context, cluster string, namespace string, name string, req *types.UpdateServiceRequest) (*v1.Service, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var object *v1.Service
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		svc, err := cs.Client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err = mutateService(svc, req); err != nil {
+			return errors.NewError(err, http.StatusBadRequest)
+		}
+		object, err = cs.Client.CoreV1().Services(namespace).Update(ctx, svc, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Errorf("failed to update service %s/%s: %v", namespace, name, err)
+		return nil, err
+	}
+	return object, nil
+}
+
+func mutateService(svc *v1.Service, req *types.UpdateServiceRequest) error {
+	spec := &svc.Spec
+	switch spec.Type {
+	case v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer:
+	default:
+		return fmt.Errorf("unsupported service type %s", spec.Type)
+	}
+
+	if req.Type != nil {
+		spec.Type = *req.Type
+	}
+	if req.Selector != nil {
+		spec.Selector = *req.Selector
+	}
+	if req.Labels != nil {
+		svc.Labels = *req.Labels
+	}
+	if req.Ports != nil {
+		ports, err := buildServicePorts(spec.Type, *req.Ports)
+		if err != nil {
+			return err
+		}
+		spec.Ports = ports
+	}
+
+	// ClusterIP 不允许设置 nodePort，从 NodePort 或者 LoadBalancer 修改时需清理已分配的端口
+	if spec.Type == v1.ServiceTypeClusterIP {
+		for i := range spec.Ports {
+			spec.Ports[i].NodePort = 0
+		}
+		spec.ExternalTrafficPolicy = ""
+		spec.HealthCheckNodePort = 0
+	}
+	if spec.Type != v1.ServiceTypeLoadBalancer {
+		spec.LoadBalancerIP = ""
+		spec.LoadBalancerSourceRanges = nil
+		spec.LoadBalancerClass = nil
+		spec.AllocateLoadBalancerNodePorts = nil
+	}
+	return nil
+}
+
+func buildServicePorts(serviceType v1.ServiceType, reqs []types.ServicePortRequest) ([]v1.ServicePort, error) {
+	if len(reqs) > 1 {
+		names := make(map[string]struct{})
+		for _, req := range reqs {
+			if len(req.Name) == 0 {
+				return nil, fmt.Errorf("port name is required when the service has multiple ports")
+			}
+			if _, ok := names[req.Name]; ok {
+				return nil, fmt.Errorf("duplicated port name %s", req.Name)
+			}
+			names[req.Name] = struct{}{}
+		}
+	}
+
+	ports := make([]v1.ServicePort, len(reqs))
+	for i, req := range reqs {
+		protocol := req.Protocol
+		if len(protocol) == 0 {
+			protocol = v1.ProtocolTCP
+		}
+		targetPort := intstr.FromInt(int(req.Port))
+		if len(req.TargetPort) != 0 {
+			targetPort = intstr.Parse(req.TargetPort)
+		}
+		ports[i] = v1.ServicePort{
+			Name:       req.Name,
+			Protocol:   protocol,
+			Port:       req.Port,
+			TargetPort: targetPort,
+		}
+		if serviceType != v1.ServiceTypeClusterIP {
+			ports[i].NodePort = req.NodePort
+		}
+	}
+	return ports, nil
+}
+
+func (c *cluster) DeleteService(ctx context.Context, cluster string, namespace string, name string) error {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	if err = cs.Client.CoreV1().Services(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		klog.Errorf("failed to delete service %s/%s: %v", namespace, name, err)
+		return err
+	}
+	return nil
+}
+
+// GetService 获取 service 以及 endpoints 中后端的就绪情况
+func (c *cluster) GetService(ctx context.Context, cluster string, namespace string, name string) (*types.ServiceDetail, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := cs.Informer.ServicesLister().Services(namespace).Get(name)
+	if err != nil {
+		klog.Errorf("failed to get service (%s/%s) from indexer: %v", namespace, name, err)
+		return nil, err
+	}
+
+	detail := &types.ServiceDetail{Service: svc, Endpoints: make([]types.ServiceEndpoint, 0)}
+	endpoints, err := cs.Client.CoreV1().Endpoints(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		// 尚未创建 endpoints 时视为没有后端
+		if apierrors.IsNotFound(err) {
+			return detail, nil
+		}
+		klog.Errorf("failed to get endpoints %s/%s: %v", namespace, name, err)
+		return nil, err
+	}
+
+	for _, subset := range endpoints.Subsets {
+		ports := make([]string, len(subset.Ports))
+		for i, port := range subset.Ports {
+			ports[i] = fmt.Sprintf("%s:%d/%s", port.Name, port.Port, port.Protocol)
+		}
+		for _, address := range subset.Addresses {
+			detail.Endpoints = append(detail.Endpoints, parseEndpointAddress(address, ports, true))
+		}
+		for _, address := range subset.NotReadyAddresses {
+			detail.Endpoints = append(detail.Endpoints, parseEndpointAddress(address, ports, false))
+		}
+		detail.Ready += len(subset.Addresses)
+		detail.NotReady += len(subset.NotReadyAddresses)
+	}
+	detail.Healthy = detail.Ready > 0
+	return detail, nil
+}
+
+func parseEndpointAddress(address v1.EndpointAddress, ports []string, ready bool) types.ServiceEndpoint {
+	endpoint := types.ServiceEndpoint{
+		IP:       address.IP,
+		Hostname: address.Hostname,
+		Ready:    ready,
+		Ports:    ports,
+	}
+	if address.NodeName != nil {
+		endpoint.NodeName = *address.NodeName
+	}
+	if address.TargetRef != nil {
+		endpoint.TargetRef = address.TargetRef.Kind + "/" + address.TargetRef.Name
+	}
+	return endpoint
+}
+
+func (c *cluster) ListServices(ctx context.Context, cluster string, namespace string) ([]v1.Service, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := cs.Informer.ServicesLister().Services(namespace).List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list services in %s from indexer: %v", namespace, err)
+		return nil, err
+	}
+
+	services := make([]v1.Service, len(objects))
+	for i, object := range objects {
+		services[i] = *object
+	}
+	sort.SliceStable(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+	return services, nil
+}
@@ -0,0 +1,394 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// ListStorageClasses 获取集群的存储类，并标记默认存储类以及是否支持扩容
+func (c *cluster) ListStorageClasses(ctx context.Context, cluster string) ([]types.StorageClass, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := cs.Client.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("failed to list storage classes: %v", err)
+		return nil, err
+	}
+
+	classes := make([]types.StorageClass, len(objects.Items))
+	for i, object := range objects.Items {
+		classes[i] = storageClass2Type(&object)
+	}
+	sort.SliceStable(classes, func(i, j int) bool {
+		return classes[i].Name < classes[j].Name
+	})
+	return classes, nil
+}
+
+func storageClass2Type(sc *storagev1.StorageClass) types.StorageClass {
+	class := types.StorageClass{
+		Name:                 sc.Name,
+		Provisioner:          sc.Provisioner,
+		Parameters:           sc.Parameters,
+		AllowVolumeExpansion: isExpandable(sc),
+		Default:              sc.Annotations[defaultStorageClassAnnotation] == "true" || sc.Annotations[betaDefaultStorageClassAnnotation] == "true",
+		CreationTimestamp:    sc.CreationTimestamp.Time,
+	}
+	if sc.ReclaimPolicy != nil {
+		class.ReclaimPolicy = string(*sc.ReclaimPolicy)
+	}
+	if sc.VolumeBindingMode != nil {
+		class.VolumeBindingMode = string(*sc.VolumeBindingMode)
+	}
+	return class
+}
+
+func isExpandable(sc *storagev1.StorageClass) bool {
+	return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion
+}
+
+func (c *cluster) CreatePersistentVolumeClaim(ctx context.Context, cluster string, namespace string, req *types.CreatePersistentVolumeClaimRequest) (*v1.PersistentVolumeClaim, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	size, err := resource.ParseQuantity(req.Size)
+	if err != nil {
+		return nil, errors.NewError(fmt.Errorf("invalid size %s: %v", req.Size, err), http.StatusBadRequest)
+	}
+
+	accessModes := req.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}
+	}
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      req.Name,
+			Namespace: namespace,
+			Labels:    req.Labels,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: accessModes,
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: size},
+			},
+		},
+	}
+	// 未指定时使用集群的默认存储类
+	if len(req.StorageClassName) != 0 {
+		pvc.Spec.StorageClassName = &req.StorageClassName
+	}
+	if len(req.VolumeMode) != 0 {
+		pvc.Spec.VolumeMode = &req.VolumeMode
+	}
+
+	object, err := cs.Client.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("failed to create pvc %s/%s: %v", namespace, req.Name, err)
+		return nil, err
+	}
+	return object, nil
+}
+
+// UpdatePersistentVolumeClaim pvc 的 spec 创建后基本不可修改，仅支持修改 labels，扩容使用 ResizePersistentVolumeClaim
+func (c *cluster) UpdatePersistentVolumeClaim(ctx context.Context, cluster string, namespace string, name string, req *types.UpdatePersistentVolumeClaimRequest) (*v1.PersistentVolumeClaim, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var object *v1.PersistentVolumeClaim
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pvc, err := cs.Client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if req.Labels != nil {
+			pvc.Labels = *req.Labels
+		}
+		object, err = cs.Client.CoreV1().PersistentVolumeClaims(namespace).Update(ctx, pvc, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Errorf("failed to update pvc %s/%s: %v", namespace, name, err)
+		return nil, err
+	}
+	return object, nil
+}
+
+// ResizePersistentVolumeClaim 扩容 pvc，要求 pvc 已绑定，存储类允许扩容，且新的容量大于当前申请的容量
+func (c *cluster) ResizePersistentVolumeClaim(ctx context.Context, cluster string, namespace string, name string, req *types.ResizePersistentVolumeClaimRequest) (*v1.PersistentVolumeClaim, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	size, err := resource.ParseQuantity(req.Size)
+	if err != nil {
+		return nil, errors.NewError(fmt.Errorf("invalid size %s: %v", req.Size, err), http.StatusBadRequest)
+	}
+
+	var object *v1.PersistentVolumeClaim
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		pvc, err := cs.Client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err = validateResize(ctx, cs.Client, pvc, size); err != nil {
+			return err
+		}
+		if pvc.Spec.Resources.Requests == nil {
+			pvc.Spec.Resources.Requests = v1.ResourceList{}
+		}
+		pvc.Spec.Resources.Requests[v1.ResourceStorage] = size
+		object, err = cs.Client.CoreV1().PersistentVolumeClaims(namespace).Update(ctx, pvc, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Errorf("failed to resize pvc %s/%s to %s: %v", namespace, name, req.Size, err)
+		return nil, err
+	}
+	return object, nil
+}
+
+func validateResize(ctx context.Context, client kubernetes.Interface, pvc *v1.PersistentVolumeClaim, size resource.Quantity) error {
+	if pvc.Status.Phase != v1.ClaimBound {
+		return errors.NewError(fmt.Errorf("pvc %s/%s is %s, only bound pvc can be resized", pvc.Namespace, pvc.Name, pvc.Status.Phase), http.StatusBadRequest)
+	}
+	if pvc.Spec.StorageClassName == nil || len(*pvc.Spec.StorageClassName) == 0 {
+		return errors.NewError(fmt.Errorf("pvc %s/%s has no storage class", pvc.Namespace, pvc.Name), http.StatusBadRequest)
+	}
+	sc, err := client.StorageV1().StorageClasses().Get(ctx, *pvc.Spec.StorageClassName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if !isExpandable(sc) {
+		return errors.NewError(fmt.Errorf("storage class %s does not allow volume expansion", sc.Name), http.StatusBadRequest)
+	}
+
+	current := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	if size.Cmp(current) <= 0 {
+		return errors.NewError(fmt.Errorf("new size %s must be larger than current size %s", size.String(), current.String()), http.StatusBadRequest)
+	}
+	return nil
+}
+
+func (c *cluster) DeletePersistentVolumeClaim(ctx context.Context, cluster string, namespace string, name string) error {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	if err = cs.Client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		klog.Errorf("failed to delete pvc %s/%s: %v", namespace, name, err)
+		return err
+	}
+	return nil
+}
+
+// GetPersistentVolumeClaim 获取 pvc，绑定的 pv，挂载的 pod 以及通过 kubelet 获取的实际使用量
+func (c *cluster) GetPersistentVolumeClaim(ctx context.Context, cluster string, namespace string, name string) (*types.PersistentVolumeClaimDetail, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	pvc, err := cs.Client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("failed to get pvc %s/%s: %v", namespace, name, err)
+		return nil, err
+	}
+
+	detail := &types.PersistentVolumeClaimDetail{PersistentVolumeClaim: pvc, MountedBy: make([]string, 0)}
+	if len(pvc.Spec.VolumeName) != 0 {
+		pv, err := cs.Client.CoreV1().PersistentVolumes().Get(ctx, pvc.Spec.VolumeName, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("failed to get pv %s: %v", pvc.Spec.VolumeName, err)
+			return nil, err
+		}
+		if err == nil {
+			detail.PersistentVolume = pv
+		}
+	}
+	if pvc.Spec.StorageClassName != nil && len(*pvc.Spec.StorageClassName) != 0 {
+		sc, err := cs.Client.StorageV1().StorageClasses().Get(ctx, *pvc.Spec.StorageClassName, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("failed to get storage class %s: %v", *pvc.Spec.StorageClassName, err)
+			return nil, err
+		}
+		detail.Resizable = err == nil && isExpandable(sc)
+	}
+
+	pods, err := cs.Informer.PodsLister().Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var nodeName string
+	for _, pod := range pods {
+		if !mountsClaim(pod, name) {
+			continue
+		}
+		detail.MountedBy = append(detail.MountedBy, pod.Name)
+		if len(nodeName) == 0 && pod.Status.Phase == v1.PodRunning {
+			nodeName = pod.Spec.NodeName
+		}
+	}
+	sort.Strings(detail.MountedBy)
+
+	// 仅被运行中的 pod 挂载时 kubelet 才会上报使用量，获取失败时不影响返回结果
+	if len(nodeName) != 0 {
+		usage, err := getVolumeUsage(ctx, cs.Client, nodeName, namespace, name)
+		if err != nil {
+			klog.Warningf("failed to get pvc %s/%s usage from node %s: %v", namespace, name, nodeName, err)
+		}
+		detail.Usage = usage
+	}
+	return detail, nil
+}
+
+func mountsClaim(pod *v1.Pod, claimName string) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == claimName {
+			return true
+		}
+	}
+	return false
+}
+
+// statsSummary kubelet /stats/summary 接口中与存储卷相关的字段
+type statsSummary struct {
+	Pods []struct {
+		Volumes []struct {
+			PVCRef *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef,omitempty"`
+			CapacityBytes  *uint64 `json:"capacityBytes,omitempty"`
+			UsedBytes      *uint64 `json:"usedBytes,omitempty"`
+			AvailableBytes *uint64 `json:"availableBytes,omitempty"`
+		} `json:"volume,omitempty"`
+	} `json:"pods"`
+}
+
+// getVolumeUsage 通过 apiserver 代理访问节点 kubelet 的 stats/summary 获取 pvc 的使用量，未上报时返回空
+func getVolumeUsage(ctx context.Context, client kubernetes.Interface, nodeName string, namespace string, name string) (*types.VolumeUsage, error) {
+	data, err := client.CoreV1().RESTClient().Get().
+		Resource("nodes").Name(nodeName).SubResource("proxy").Suffix("stats/summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var summary statsSummary
+	if err = json.Unmarshal(data, &summary); err != nil {
+		return nil, err
+	}
+
+	for _, pod := range summary.Pods {
+		for _, volume := range pod.Volumes {
+			if volume.PVCRef == nil || volume.PVCRef.Namespace != namespace || volume.PVCRef.Name != name {
+				continue
+			}
+			if volume.CapacityBytes == nil || volume.UsedBytes == nil {
+				continue
+			}
+			usage := &types.VolumeUsage{
+				NodeName:      nodeName,
+				CapacityBytes: int64(*volume.CapacityBytes),
+				UsedBytes:     int64(*volume.UsedBytes),
+			}
+			if volume.AvailableBytes != nil {
+				usage.AvailableBytes = int64(*volume.AvailableBytes)
+			}
+			if usage.CapacityBytes > 0 {
+				usage.UsedPercent = float64(usage.UsedBytes) * 100 / float64(usage.CapacityBytes)
+			}
+			return usage, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *cluster) ListPersistentVolumeClaims(ctx context.Context, cluster string, namespace string) ([]v1.PersistentVolumeClaim, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := cs.Client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("failed to list pvcs in %s: %v", namespace, err)
+		return nil, err
+	}
+
+	pvcs := objects.Items
+	sort.SliceStable(pvcs, func(i, j int) bool {
+		return pvcs[i].Name < pvcs[j].Name
+	})
+	return pvcs, nil
+}
+
+func (c *cluster) GetPersistentVolume(ctx context.Context, cluster string, name string) (*v1.PersistentVolume, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	object, err := cs.Client.CoreV1().PersistentVolumes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("failed to get pv %s: %v", name, err)
+		return nil, err
+	}
+	return object, nil
+}
+
+func (c *cluster) ListPersistentVolumes(ctx context.Context, cluster string) ([]v1.PersistentVolume, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := cs.Client.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("failed to list pvs: %v", err)
+		return nil, err
+	}
+
+	pvs := objects.Items
+	sort.SliceStable(pvs, func(i, j int) bool {
+		return pvs[i].Name < pvs[j].Name
+	})
+	return pvs, nil
+}
@@ -0,0 +1,374 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	defaultBundleTailLines int64 = 500
+	// 最多收集日志的 pod 数量和事件数量，避免诊断包过大
+	maxBundleLogPods = 20
+	maxBundleEvents  = 500
+	// 单个容器日志的大小上限
+	maxBundleLogBytes int64 = 2 * 1024 * 1024
+)
+
+// GenerateSupportBundle 生成工作负载或者命名空间的诊断包，包含对象，相关事件，异常 pod 的日志以及引用的 configmap
+// 不包含 secret，单个文件获取失败时记录到 errors.txt，不影响其他内容
+func (c *cluster) GenerateSupportBundle(ctx context.Context, cluster string, namespace string, opts types.SupportBundleOptions) ([]byte, error) {
+	if len(opts.Kind) != 0 && len(opts.Name) == 0 {
+		return nil, errors.NewError(fmt.Errorf("name is required when kind is specified"), http.StatusBadRequest)
+	}
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	tailLines := opts.TailLines
+	if tailLines == 0 {
+		tailLines = defaultBundleTailLines
+	}
+
+	b := newBundle()
+	workload, selector, err := getBundleWorkload(cs.Informer, namespace, opts.Kind, opts.Name)
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+	if workload != nil {
+		b.addObject(path.Join("objects", opts.Kind+"-"+opts.Name+".json"), workload)
+	}
+
+	pods, err := cs.Informer.PodsLister().Pods(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+
+	involved := sets.NewString(opts.Name)
+	configMaps := sets.NewString()
+	var logPods int
+	for _, pod := range pods {
+		involved.Insert(pod.Name)
+		configMaps.Insert(podConfigMaps(pod)...)
+		b.addObject(path.Join("pods", pod.Name+".json"), pod)
+
+		if !isPodFailing(pod) || logPods >= maxBundleLogPods {
+			continue
+		}
+		logPods++
+		for _, status := range pod.Status.ContainerStatuses {
+			b.addLog(ctx, cs, pod, status.Name, tailLines, false)
+			if status.RestartCount > 0 {
+				b.addLog(ctx, cs, pod, status.Name, tailLines, true)
+			}
+		}
+	}
+	if workload != nil {
+		for _, rs := range ownedReplicaSets(cs.Informer, namespace, opts.Kind, workload) {
+			involved.Insert(rs)
+		}
+	}
+
+	for _, name := range configMaps.List() {
+		cm, err := cs.Informer.ConfigMapsLister().ConfigMaps(namespace).Get(name)
+		if err != nil {
+			b.addError("configmap %s: %v", name, err)
+			continue
+		}
+		b.addObject(path.Join("configmaps", name+".json"), cm)
+	}
+
+	events, err := cs.Client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		b.addError("events: %v", err)
+	} else {
+		b.addObject("events.json", recentEvents(events.Items, workload != nil, involved))
+	}
+
+	b.addObject("bundle.json", map[string]interface{}{
+		"cluster":     cluster,
+		"namespace":   namespace,
+		"kind":        opts.Kind,
+		"name":        opts.Name,
+		"pods":        len(pods),
+		"generatedAt": time.Now(),
+	})
+	return b.close()
+}
+
+// getBundleWorkload 未指定工作负载时选择命名空间的全部 pod
+func getBundleWorkload(informer *client.PixiuInformer, namespace, kind, name string) (metav1.Object, labels.Selector, error) {
+	var (
+		object   metav1.Object
+		selector *metav1.LabelSelector
+		err      error
+	)
+	switch kind {
+	case "":
+		return nil, labels.Everything(), nil
+	case "deployment":
+		o, e := informer.DeploymentsLister().Deployments(namespace).Get(name)
+		if e == nil {
+			object, selector = o, o.Spec.Selector
+		}
+		err = e
+	case "statefulset":
+		o, e := informer.StatefulSetsLister().StatefulSets(namespace).Get(name)
+		if e == nil {
+			object, selector = o, o.Spec.Selector
+		}
+		err = e
+	case "daemonset":
+		o, e := informer.DaemonSetsLister().DaemonSets(namespace).Get(name)
+		if e == nil {
+			object, selector = o, o.Spec.Selector
+		}
+		err = e
+	case "job":
+		o, e := informer.JobsLister().Jobs(namespace).Get(name)
+		if e == nil {
+			object, selector = o, o.Spec.Selector
+		}
+		err = e
+	default:
+		return nil, nil, fmt.Errorf("unsupported kind %s", kind)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	return object, s, nil
+}
+
+// ownedReplicaSets deployment 的事件大多记录在 replicaSet 上
+func ownedReplicaSets(informer *client.PixiuInformer, namespace, kind string, workload metav1.Object) []string {
+	if kind != "deployment" {
+		return nil
+	}
+	replicaSets, err := informer.ReplicaSetsLister().ReplicaSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, rs := range replicaSets {
+		if isOwnedBy(rs.OwnerReferences, workload.GetUID()) {
+			names = append(names, rs.Name)
+		}
+	}
+	return names
+}
+
+// isPodFailing 未就绪，已失败或者发生过重启的 pod
+func isPodFailing(pod *v1.Pod) bool {
+	if pod.Status.Phase == v1.PodFailed {
+		return true
+	}
+	if pod.Status.Phase == v1.PodSucceeded {
+		return false
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if !status.Ready || status.RestartCount > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// podConfigMaps 获取 pod 通过 volume，envFrom 以及 env 引用的 configmap
+func podConfigMaps(pod *v1.Pod) []string {
+	names := sets.NewString()
+	for _, volume := range pod.Spec.Volumes {
+		if volume.ConfigMap != nil {
+			names.Insert(volume.ConfigMap.Name)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					names.Insert(source.ConfigMap.Name)
+				}
+			}
+		}
+	}
+	containers := append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				names.Insert(envFrom.ConfigMapRef.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+				names.Insert(env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+		}
+	}
+	// kube-root-ca.crt 由集群自动创建
+	names.Delete("kube-root-ca.crt")
+	return names.List()
+}
+
+// recentEvents 按时间倒序返回最近的事件，指定工作负载时仅返回相关对象的事件
+func recentEvents(events []v1.Event, filter bool, involved sets.String) []v1.Event {
+	items := make([]v1.Event, 0)
+	for _, event := range events {
+		if filter && !involved.Has(event.InvolvedObject.Name) {
+			continue
+		}
+		items = append(items, event)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return eventTime(items[i]).After(eventTime(items[j]))
+	})
+	if len(items) > maxBundleEvents {
+		items = items[:maxBundleEvents]
+	}
+	return items
+}
+
+func eventTime(event v1.Event) time.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	if !event.EventTime.IsZero() {
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// bundle 在内存中生成 tar.gz
+type bundle struct {
+	buf    bytes.Buffer
+	gz     *gzip.Writer
+	tw     *tar.Writer
+	errs   []string
+	prefix string
+}
+
+func newBundle() *bundle {
+	b := &bundle{prefix: "support-bundle"}
+	b.gz = gzip.NewWriter(&b.buf)
+	b.tw = tar.NewWriter(b.gz)
+	return b
+}
+
+func (b *bundle) addFile(name string, data []byte) {
+	if err := b.tw.WriteHeader(&tar.Header{
+		Name:    path.Join(b.prefix, name),
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		b.addError("%s: %v", name, err)
+		return
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		b.addError("%s: %v", name, err)
+	}
+}
+
+func (b *bundle) addObject(name string, object interface{}) {
+	// 对象来自 informer 的缓存，修改前需要复制
+	if o, ok := object.(runtime.Object); ok {
+		object = o.DeepCopyObject()
+		if accessor, ok := object.(metav1.Object); ok {
+			accessor.SetManagedFields(nil)
+		}
+	}
+	data, err := json.MarshalIndent(object, "", "  ")
+	if err != nil {
+		b.addError("%s: %v", name, err)
+		return
+	}
+	b.addFile(name, data)
+}
+
+func (b *bundle) addLog(ctx context.Context, cs client.ClusterSet, pod *v1.Pod, container string, tailLines int64, previous bool) {
+	name := path.Join("logs", pod.Name, container+".log")
+	if previous {
+		name = path.Join("logs", pod.Name, container+".previous.log")
+	}
+	limit := maxBundleLogBytes
+	reader, err := cs.Client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+		Container:  container,
+		TailLines:  &tailLines,
+		Previous:   previous,
+		LimitBytes: &limit,
+	}).Stream(ctx)
+	if err != nil {
+		b.addError("%s: %v", name, err)
+		return
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		b.addError("%s: %v", name, err)
+		return
+	}
+	b.addFile(name, data)
+}
+
+func (b *bundle) addError(format string, args ...interface{}) {
+	b.errs = append(b.errs, fmt.Sprintf(format, args...))
+}
+
+func (b *bundle) close() ([]byte, error) {
+	if len(b.errs) != 0 {
+		var data bytes.Buffer
+		for _, e := range b.errs {
+			data.WriteString(e + "\n")
+		}
+		b.addFile("errors.txt", data.Bytes())
+	}
+	if err := b.tw.Close(); err != nil {
+		klog.Errorf("failed to close support bundle: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	if err := b.gz.Close(); err != nil {
+		klog.Errorf("failed to close support bundle: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	return b.buf.Bytes(), nil
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	"github.com/caoyingjunz/pixiu/pkg/util/uuid"
+)
+
+const (
+	KindDeployment = "Deployment"
+
+	revisionAnnotation = "deployment.kubernetes.io/revision"
+
+	// 变更记录租约的有效期，持有者退出后由其他副本接管
+	workloadChangeLeaseDuration = 30 * time.Second
+	// 未分页时返回最近的变更记录数量
+	defaultTimelineChanges = 100
+)
+
+var (
+	// 已注册变更监听的 informer，避免重复注册
+	trackedInformers sync.Map
+	// 当前实例的租约持有者标识
+	leaseHolder = uuid.NewUUID()
+)
+
+// trackWorkloadChanges 监听 deployment 的更新事件，记录 image，env 和 resources 的变更
+func (c *cluster) trackWorkloadChanges(name string, cs client.ClusterSet) {
+	if cs.Informer == nil {
+		return
+	}
+	if _, loaded := trackedInformers.LoadOrStore(cs.Informer, struct{}{}); loaded {
+		return
+	}
+
+	cs.Informer.Shared.Apps().V1().Deployments().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			old, ok := oldObj.(*appsv1.Deployment)
+			if !ok {
+				return
+			}
+			cur, ok := newObj.(*appsv1.Deployment)
+			if !ok {
+				return
+			}
+			// resync 或者仅 status 变化时忽略
+			if old.Generation == cur.Generation {
+				return
+			}
+			changes := diffPodTemplate(old.Spec.Template.Spec, cur.Spec.Template.Spec)
+			if len(changes) == 0 {
+				return
+			}
+			c.recordWorkloadChange(name, cur, changes)
+		},
+	})
+}
+
+// recordWorkloadChange 多副本部署时每个实例的 informer 都会观察到同一个变更，仅由租约的持有者记录
+func (c *cluster) recordWorkloadChange(cluster string, deployment *appsv1.Deployment, changes types.SpecChanges) {
+	leader, err := c.factory.Lease().TryAcquire(context.TODO(), model.LeaseWorkloadChange, leaseHolder, workloadChangeLeaseDuration)
+	if err != nil {
+		klog.Errorf("failed to acquire workload change lease: %v", err)
+		return
+	}
+	if !leader {
+		return
+	}
+
+	data, err := changes.Marshal()
+	if err != nil {
+		klog.Errorf("failed to marshal deployment(%s/%s) changes: %v", deployment.Namespace, deployment.Name, err)
+		return
+	}
+	if _, err = c.factory.WorkloadChange().Create(context.TODO(), &model.WorkloadChange{
+		Cluster:   cluster,
+		Namespace: deployment.Namespace,
+		Kind:      KindDeployment,
+		Name:      deployment.Name,
+		Revision:  deployment.Annotations[revisionAnnotation],
+		Changes:   data,
+	}); err != nil {
+		klog.Errorf("failed to record deployment(%s/%s) changes: %v", deployment.Namespace, deployment.Name, err)
+	}
+}
+
+// maskedEnvValue 替换变更记录中 env 的明文值，env 中可能包含密码等敏感信息
+const maskedEnvValue = "******"
+
+// diffPodTemplate 对比容器的 image，env 以及 resources
+func diffPodTemplate(old, cur v1.PodSpec) types.SpecChanges {
+	olds := make(map[string]v1.Container)
+	for _, container := range old.Containers {
+		olds[container.Name] = container
+	}
+
+	changes := make(types.SpecChanges, 0)
+	for _, container := range cur.Containers {
+		oc, ok := olds[container.Name]
+		if !ok {
+			changes = append(changes, types.SpecChange{Container: container.Name, Field: "image", After: container.Image})
+			continue
+		}
+		if oc.Image != container.Image {
+			changes = append(changes, types.SpecChange{Container: container.Name, Field: "image", Before: oc.Image, After: container.Image})
+		}
+		// 仅调整了顺序时不记录
+		if before, after := diffEnv(oc.Env, container.Env); len(before) != 0 || len(after) != 0 {
+			changes = append(changes, types.SpecChange{Container: container.Name, Field: "env", Before: toJson(before), After: toJson(after)})
+		}
+		if !reflect.DeepEqual(oc.Resources, container.Resources) {
+			changes = append(changes, types.SpecChange{Container: container.Name, Field: "resources", Before: toJson(oc.Resources), After: toJson(container.Resources)})
+		}
+	}
+	return changes
+}
+
+// diffEnv 仅返回新增，删除以及修改过的 env，value 被替换为掩码，valueFrom 的引用保持不变
+func diffEnv(old, cur []v1.EnvVar) ([]v1.EnvVar, []v1.EnvVar) {
+	olds := make(map[string]v1.EnvVar)
+	for _, env := range old {
+		olds[env.Name] = env
+	}
+	curs := make(map[string]v1.EnvVar)
+	for _, env := range cur {
+		curs[env.Name] = env
+	}
+
+	before := make([]v1.EnvVar, 0)
+	for _, env := range old {
+		if c, ok := curs[env.Name]; !ok || !reflect.DeepEqual(c, env) {
+			before = append(before, maskEnv(env))
+		}
+	}
+	after := make([]v1.EnvVar, 0)
+	for _, env := range cur {
+		if o, ok := olds[env.Name]; !ok || !reflect.DeepEqual(o, env) {
+			after = append(after, maskEnv(env))
+		}
+	}
+	return before, after
+}
+
+func maskEnv(env v1.EnvVar) v1.EnvVar {
+	if len(env.Value) != 0 {
+		env.Value = maskedEnvValue
+	}
+	return env
+}
+
+func toJson(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// GetDeploymentTimeline 获取 deployment 的变更时间线，合并 spec 变更记录，滚动发布以及相关事件，按时间倒序
+// 指定分页时返回 types.PageResponse，否则返回最近的 100 条变更记录以及全部的滚动发布和事件
+func (c *cluster) GetDeploymentTimeline(ctx context.Context, cluster string, namespace string, name string, opts types.PageRequest) (interface{}, error) {
+	cs, err := c.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := cs.Informer.DeploymentsLister().Deployments(namespace).Get(name)
+	if err != nil {
+		klog.Errorf("failed to get deployment (%s/%s) from indexer: %v", namespace, name, err)
+		return nil, err
+	}
+
+	entries := make([]types.TimelineEntry, 0)
+
+	// 1. spec 变更记录，分页时前 page 页最多包含 page*limit 条变更记录
+	limit := defaultTimelineChanges
+	var total int64
+	if opts.IsPaged() {
+		limit = opts.Page * opts.Limit
+		if total, err = c.factory.WorkloadChange().CountByObject(ctx, cluster, namespace, KindDeployment, name); err != nil {
+			klog.Errorf("failed to count deployment(%s/%s) changes: %v", namespace, name, err)
+			return nil, errors.ErrServerInternal
+		}
+	}
+	objects, err := c.factory.WorkloadChange().ListByObject(ctx, cluster, namespace, KindDeployment, name, db.WithLimit(limit))
+	if err != nil {
+		klog.Errorf("failed to list deployment(%s/%s) changes: %v", namespace, name, err)
+		return nil, errors.ErrServerInternal
+	}
+	for _, object := range objects {
+		var changes types.SpecChanges
+		if err = changes.Unmarshal(object.Changes); err != nil {
+			klog.Warningf("failed to unmarshal workload change(%d): %v", object.Id, err)
+			continue
+		}
+		entries = append(entries, types.TimelineEntry{
+			Time:    object.GmtCreate,
+			Type:    types.TimelineSpecChange,
+			Reason:  "Revision " + object.Revision,
+			Changes: changes,
+		})
+	}
+
+	changeEntries := len(entries)
+
+	// 2. 滚动发布，每个 revision 对应一个 replicaSet
+	replicaSets, err := cs.Informer.ReplicaSetsLister().ReplicaSets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range replicaSets {
+		if !isOwnedBy(rs.OwnerReferences, deployment.UID) {
+			continue
+		}
+		images := make([]string, 0)
+		for _, container := range rs.Spec.Template.Spec.Containers {
+			images = append(images, container.Image)
+		}
+		entries = append(entries, types.TimelineEntry{
+			Time:    rs.CreationTimestamp.Time,
+			Type:    types.TimelineRollout,
+			Reason:  "Revision " + rs.Annotations[revisionAnnotation],
+			Message: fmt.Sprintf("replicaset %s: %s", rs.Name, strings.Join(images, ",")),
+		})
+	}
+
+	// 3. deployment，replicaSet 以及 pod 的事件
+	events, err := c.AggregateEvents(ctx, cluster, namespace, name, "deployment")
+	if err != nil {
+		klog.Warningf("failed to aggregate deployment(%s/%s) events: %v", namespace, name, err)
+	} else {
+		for _, event := range events.Items {
+			t := event.LastTimestamp.Time
+			if t.IsZero() {
+				t = event.CreationTimestamp.Time
+			}
+			entries = append(entries, types.TimelineEntry{
+				Time:    t,
+				Type:    types.TimelineEvent,
+				Reason:  event.Reason,
+				Message: fmt.Sprintf("%s/%s: %s", event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Message),
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	if !opts.IsPaged() {
+		return entries, nil
+	}
+
+	// 未查询的变更记录均早于已查询的记录，不影响前 page 页的内容
+	total += int64(len(entries) - changeEntries)
+	offset, end, err := opts.Offset(len(entries))
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+	return types.PageResponse{
+		PageRequest: opts,
+		Total:       int(total),
+		Items:       entries[offset:end],
+	}, nil
+}
+
+func isOwnedBy(refs []metav1.OwnerReference, uid apitypes.UID) bool {
+	for _, ref := range refs {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
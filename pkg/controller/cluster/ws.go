@@ -30,7 +30,9 @@ import (
 	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/klog/v2"
 
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
 	"github.com/caoyingjunz/pixiu/pkg/types"
+	sessionutil "github.com/caoyingjunz/pixiu/pkg/util/session"
 	sshutil "github.com/caoyingjunz/pixiu/pkg/util/ssh"
 )
 
@@ -50,6 +52,7 @@ func (c *cluster) WsHandler(ctx context.Context, opt *types.WebShellOptions, w h
 		_ = session.Close()
 	}()
 	klog.Infof("connecting to %s/%s,", opt.Namespace, opt.Pod)
+	defer trackSession(ctx, sessionutil.KindPodShell, opt.Cluster, opt.Namespace, opt.Pod+"/"+opt.Container)()
 
 	cmd := opt.Command
 	if len(cmd) == 0 {
@@ -92,6 +95,15 @@ func (c *cluster) WsHandler(ctx context.Context, opt *types.WebShellOptions, w h
 	return nil
 }
 
+// trackSession 记录进行中的会话，返回的函数在会话结束时调用
+func trackSession(ctx context.Context, kind, cluster, namespace, target string) func() {
+	s := httputils.NewSessionFromRequest(ctx, kind)
+	s.Cluster = cluster
+	s.Namespace = namespace
+	s.Target = target
+	return sessionutil.Track(s)
+}
+
 var BufPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
 
 func (c *cluster) WsNodeHandler(ctx context.Context, sshConfig *types.WebSSHRequest, w http.ResponseWriter, r *http.Request) error {
@@ -109,6 +121,7 @@ func (c *cluster) WsNodeHandler(ctx context.Context, sshConfig *types.WebSSHRequ
 		return err
 	}
 	defer conn.Close()
+	defer trackSession(ctx, sessionutil.KindNodeShell, "", "", sshConfig.Host)()
 
 	sshClient, err := sshutil.NewSSHClient(sshConfig)
 	if err != nil {
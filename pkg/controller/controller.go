@@ -20,11 +20,39 @@ import (
 	"github.com/casbin/casbin/v2"
 
 	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/controller/announcement"
+	"github.com/caoyingjunz/pixiu/pkg/controller/argocd"
 	"github.com/caoyingjunz/pixiu/pkg/controller/audit"
 	"github.com/caoyingjunz/pixiu/pkg/controller/auth"
+	"github.com/caoyingjunz/pixiu/pkg/controller/capi"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cloud"
 	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/controller/debug"
+	"github.com/caoyingjunz/pixiu/pkg/controller/dns"
+	"github.com/caoyingjunz/pixiu/pkg/controller/fleet"
 	"github.com/caoyingjunz/pixiu/pkg/controller/helm"
+	"github.com/caoyingjunz/pixiu/pkg/controller/injection"
+	"github.com/caoyingjunz/pixiu/pkg/controller/inspection"
+	"github.com/caoyingjunz/pixiu/pkg/controller/karmada"
+	"github.com/caoyingjunz/pixiu/pkg/controller/kubeconfig"
+	"github.com/caoyingjunz/pixiu/pkg/controller/kubectl"
+	"github.com/caoyingjunz/pixiu/pkg/controller/kubevirt"
+	"github.com/caoyingjunz/pixiu/pkg/controller/namespace"
+	"github.com/caoyingjunz/pixiu/pkg/controller/naming"
+	"github.com/caoyingjunz/pixiu/pkg/controller/notification"
+	"github.com/caoyingjunz/pixiu/pkg/controller/operator"
+	"github.com/caoyingjunz/pixiu/pkg/controller/pipeline"
 	"github.com/caoyingjunz/pixiu/pkg/controller/plan"
+	"github.com/caoyingjunz/pixiu/pkg/controller/portforward"
+	"github.com/caoyingjunz/pixiu/pkg/controller/preference"
+	"github.com/caoyingjunz/pixiu/pkg/controller/propagation"
+	"github.com/caoyingjunz/pixiu/pkg/controller/recycle"
+	"github.com/caoyingjunz/pixiu/pkg/controller/replication"
+	"github.com/caoyingjunz/pixiu/pkg/controller/scaling"
+	"github.com/caoyingjunz/pixiu/pkg/controller/session"
+	"github.com/caoyingjunz/pixiu/pkg/controller/slo"
+	"github.com/caoyingjunz/pixiu/pkg/controller/system"
+	"github.com/caoyingjunz/pixiu/pkg/controller/template"
 	"github.com/caoyingjunz/pixiu/pkg/controller/tenant"
 	"github.com/caoyingjunz/pixiu/pkg/controller/user"
 	"github.com/caoyingjunz/pixiu/pkg/db"
@@ -38,6 +66,34 @@ type PixiuInterface interface {
 	audit.AuditGetter
 	auth.AuthGetter
 	helm.HelmGetter
+	dns.DNSGetter
+	recycle.RecycleGetter
+	argocd.ArgoCDGetter
+	pipeline.PipelineGetter
+	kubevirt.KubeVirtGetter
+	karmada.KarmadaGetter
+	propagation.PropagationGetter
+	fleet.FleetGetter
+	scaling.ScalingGetter
+	inspection.InspectionGetter
+	slo.SLOGetter
+	notification.NotificationGetter
+	preference.PreferenceGetter
+	announcement.AnnouncementGetter
+	system.SystemGetter
+	naming.NamingPolicyGetter
+	injection.InjectionGetter
+	namespace.NamespacePolicyGetter
+	template.TemplateGetter
+	replication.ReplicationGetter
+	operator.OperatorGetter
+	kubectl.KubectlGetter
+	portforward.PortForwardGetter
+	kubeconfig.KubeConfigGetter
+	capi.CAPIGetter
+	cloud.CloudGetter
+	debug.DebugGetter
+	session.SessionGetter
 }
 
 type pixiu struct {
@@ -53,6 +109,81 @@ func (p *pixiu) Plan() plan.Interface       { return plan.NewPlan(p.cc, p.factor
 func (p *pixiu) Audit() audit.Interface     { return audit.NewAudit(p.cc, p.factory) }
 func (p *pixiu) Auth() auth.Interface       { return auth.NewAuth(p.factory, p.enforcer) }
 func (p *pixiu) Helm() helm.Interface       { return helm.NewHelm(p.factory) }
+func (p *pixiu) Debug() debug.Interface     { return debug.NewDebug(p.factory) }
+func (p *pixiu) Session() session.Interface { return session.NewSession() }
+func (p *pixiu) KubeVirt(cluster string) kubevirt.Interface {
+	return kubevirt.NewKubeVirt(cluster, p.Cluster())
+}
+func (p *pixiu) Karmada(cluster string) karmada.Interface {
+	return karmada.NewKarmada(cluster, p.Cluster())
+}
+func (p *pixiu) Pipeline() pipeline.Interface {
+	return pipeline.NewPipeline(p.factory)
+}
+func (p *pixiu) Fleet() fleet.Interface { return fleet.NewFleet(p.factory) }
+func (p *pixiu) SLO() slo.Interface     { return slo.NewSLO(p.factory) }
+func (p *pixiu) Notification() notification.Interface {
+	return notification.NewNotification(p.factory)
+}
+func (p *pixiu) Announcement() announcement.Interface {
+	return announcement.NewAnnouncement(p.cc, p.factory)
+}
+func (p *pixiu) Preference() preference.Interface {
+	return preference.NewPreference(p.factory)
+}
+func (p *pixiu) NamingPolicy() naming.Interface { return naming.NewNaming(p.factory) }
+func (p *pixiu) Injection() injection.Interface {
+	return injection.NewInjection(p.factory, p.Cluster())
+}
+func (p *pixiu) Operator() operator.Interface {
+	return operator.NewOperator(p.cc, p.factory, p.Cluster(), p.Plan(), p.Helm())
+}
+func (p *pixiu) Replication() replication.Interface {
+	return replication.NewReplication(p.factory, p.Cluster())
+}
+func (p *pixiu) Template() template.Interface {
+	return template.NewTemplate(p.factory, p.Cluster())
+}
+func (p *pixiu) Propagation() propagation.Interface {
+	return propagation.NewPropagation(p.factory, p.Cluster(), p.Helm())
+}
+func (p *pixiu) DNS(cluster string) dns.Interface {
+	return dns.NewDNS(p.cc, p.factory, cluster, p.Cluster())
+}
+func (p *pixiu) Recycle(cluster string) recycle.Interface {
+	return recycle.NewRecycle(p.cc, p.factory, cluster, p.Cluster())
+}
+func (p *pixiu) ArgoCD(cluster string) argocd.Interface {
+	return argocd.NewArgoCD(cluster, p.Cluster())
+}
+func (p *pixiu) Scaling(cluster string) scaling.Interface {
+	return scaling.NewScaling(p.factory, cluster, p.Cluster())
+}
+func (p *pixiu) Inspection(cluster string) inspection.Interface {
+	return inspection.NewInspection(p.cc, p.factory, cluster, p.Cluster())
+}
+func (p *pixiu) NamespacePolicy(cluster string) namespace.Interface {
+	return namespace.NewNamespace(p.factory, cluster, p.Cluster())
+}
+func (p *pixiu) Kubectl(cluster string) kubectl.Interface {
+	return kubectl.NewKubectl(p.cc, p.factory, p.enforcer, cluster, p.Cluster())
+}
+func (p *pixiu) PortForward(cluster string) portforward.Interface {
+	return portforward.NewPortForward(cluster, p.Cluster())
+}
+func (p *pixiu) KubeConfig() kubeconfig.Interface {
+	return kubeconfig.NewKubeConfig(p.cc, p.factory, p.enforcer, p.Cluster())
+}
+func (p *pixiu) System() system.Interface {
+	return system.NewSystem(p.cc, p.KubeConfig())
+}
+func (p *pixiu) CAPI() capi.Interface {
+	return capi.NewCAPI(p.factory, p.Cluster())
+}
+
+func (p *pixiu) Cloud() cloud.Interface {
+	return cloud.NewCloud(p.factory, p.Cluster())
+}
 
 func New(cfg config.Config, f db.ShareDaoFactory, enforcer *casbin.SyncedEnforcer) PixiuInterface {
 	return &pixiu{
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+type DebugGetter interface {
+	Debug() Interface
+}
+
+type Interface interface {
+	GetLogLevel(ctx context.Context) (*logutil.LevelSetting, error)
+	// UpdateLogLevel 调整日志级别并持久化，服务重启后仍然生效
+	UpdateLogLevel(ctx context.Context, req *types.UpdateLogLevelRequest) error
+	// LoadLogLevel 服务启动时加载已持久化的日志级别
+	LoadLogLevel(ctx context.Context) error
+}
+
+type debug struct {
+	factory db.ShareDaoFactory
+}
+
+func (d *debug) GetLogLevel(ctx context.Context) (*logutil.LevelSetting, error) {
+	level := logutil.CurrentLevel()
+	return &level, nil
+}
+
+func (d *debug) UpdateLogLevel(ctx context.Context, req *types.UpdateLogLevelRequest) error {
+	level := logutil.LevelSetting{
+		Level:     req.Level,
+		Verbosity: req.Verbosity,
+		Modules:   req.Modules,
+	}
+	if err := level.Validate(); err != nil {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+	data, err := json.Marshal(level)
+	if err != nil {
+		return err
+	}
+	if err = d.factory.Setting().Set(ctx, model.SettingLogLevel, string(data)); err != nil {
+		klog.Errorf("failed to save log level setting: %v", err)
+		return errors.ErrServerInternal
+	}
+
+	if err = level.Apply(); err != nil {
+		klog.Errorf("failed to apply log level %s: %v", string(data), err)
+		return errors.ErrServerInternal
+	}
+	klog.Infof("log level changed to %s", string(data))
+	return nil
+}
+
+func (d *debug) LoadLogLevel(ctx context.Context) error {
+	object, err := d.factory.Setting().Get(ctx, model.SettingLogLevel)
+	if err != nil {
+		return err
+	}
+	if object == nil {
+		return nil
+	}
+
+	var level logutil.LevelSetting
+	if err = json.Unmarshal([]byte(object.Value), &level); err != nil {
+		return err
+	}
+	return level.Apply()
+}
+
+func NewDebug(f db.ShareDaoFactory) *debug {
+	return &debug{
+		factory: f,
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	dnsutil "github.com/caoyingjunz/pixiu/pkg/util/dns"
+)
+
+type DNSGetter interface {
+	DNS(cluster string) Interface
+}
+
+type Interface interface {
+	// Publish 为 service 或者 ingress 注册解析记录，并记录到数据库
+	Publish(ctx context.Context, req *types.PublishDNSRecordRequest) (*types.DNSRecord, error)
+	// Unpublish 清理解析记录
+	Unpublish(ctx context.Context, rid int64) error
+	Get(ctx context.Context, rid int64) (*types.DNSRecord, error)
+	List(ctx context.Context) ([]types.DNSRecord, error)
+}
+
+type dns struct {
+	cc      config.Config
+	factory db.ShareDaoFactory
+	cluster string
+
+	clusterGetter cluster.Interface
+}
+
+func (d *dns) provider() (dnsutil.Provider, error) {
+	if !d.cc.DNS.Enable {
+		return nil, errors.ErrDNSNotEnabled
+	}
+	provider, err := dnsutil.NewProvider(d.cc.DNS)
+	if err != nil {
+		klog.Errorf("failed to build dns provider: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	return provider, nil
+}
+
+func (d *dns) Publish(ctx context.Context, req *types.PublishDNSRecordRequest) (*types.DNSRecord, error) {
+	provider, err := d.provider()
+	if err != nil {
+		return nil, err
+	}
+
+	domain := req.Domain
+	if len(domain) == 0 {
+		domain = fmt.Sprintf("%s.%s.%s", req.Name, req.Namespace, d.cc.DNS.Zone)
+	}
+	old, err := d.factory.DNSRecord().GetByDomain(ctx, domain)
+	if err != nil {
+		klog.Errorf("failed to get dns record %s: %v", domain, err)
+		return nil, errors.ErrServerInternal
+	}
+	if old != nil {
+		return nil, errors.ErrDNSRecordExists
+	}
+
+	recordType, value, err := d.resolveTarget(ctx, req.Kind, req.Namespace, req.Name)
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+
+	record := dnsutil.Record{Domain: domain, Type: recordType, Value: value, TTL: d.cc.DNS.TTL}
+	if err = provider.AddRecord(ctx, record); err != nil {
+		klog.Errorf("failed to add dns record %s: %v", domain, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	object, err := d.factory.DNSRecord().Create(ctx, &model.DNSRecord{
+		Cluster:   d.cluster,
+		Namespace: req.Namespace,
+		Kind:      req.Kind,
+		Name:      req.Name,
+		Domain:    domain,
+		Type:      recordType,
+		Value:     value,
+		Provider:  provider.Name(),
+	})
+	if err != nil {
+		klog.Errorf("failed to create dns record %s: %v", domain, err)
+		// 入库失败时回滚已注册的解析记录
+		if err = provider.RemoveRecord(ctx, record); err != nil {
+			klog.Errorf("failed to rollback dns record %s: %v", domain, err)
+		}
+		return nil, errors.ErrServerInternal
+	}
+
+	return d.model2Type(object), nil
+}
+
+func (d *dns) Unpublish(ctx context.Context, rid int64) error {
+	object, err := d.get(ctx, rid)
+	if err != nil {
+		return err
+	}
+	provider, err := d.provider()
+	if err != nil {
+		return err
+	}
+
+	if err = provider.RemoveRecord(ctx, dnsutil.Record{Domain: object.Domain, Type: object.Type, Value: object.Value}); err != nil {
+		klog.Errorf("failed to remove dns record %s: %v", object.Domain, err)
+		return errors.ErrServerInternal
+	}
+	if err = d.factory.DNSRecord().Delete(ctx, rid); err != nil {
+		klog.Errorf("failed to delete dns record(%d): %v", rid, err)
+		return errors.ErrServerInternal
+	}
+
+	return nil
+}
+
+func (d *dns) Get(ctx context.Context, rid int64) (*types.DNSRecord, error) {
+	object, err := d.get(ctx, rid)
+	if err != nil {
+		return nil, err
+	}
+	return d.model2Type(object), nil
+}
+
+func (d *dns) get(ctx context.Context, rid int64) (*model.DNSRecord, error) {
+	object, err := d.factory.DNSRecord().Get(ctx, rid)
+	if err != nil {
+		klog.Errorf("failed to get dns record(%d): %v", rid, err)
+		return nil, errors.ErrServerInternal
+	}
+	// 不允许跨集群操作解析记录
+	if object == nil || object.Cluster != d.cluster {
+		return nil, errors.ErrDNSRecordNotFound
+	}
+	return object, nil
+}
+
+func (d *dns) List(ctx context.Context) ([]types.DNSRecord, error) {
+	objects, err := d.factory.DNSRecord().List(ctx, db.WithCluster(d.cluster))
+	if err != nil {
+		klog.Errorf("failed to list cluster(%s) dns records: %v", d.cluster, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	records := make([]types.DNSRecord, len(objects))
+	for i, object := range objects {
+		records[i] = *d.model2Type(&object)
+	}
+	return records, nil
+}
+
+// resolveTarget 获取对象的对外访问地址，优先使用 IP，不存在时使用 hostname 作为 CNAME
+func (d *dns) resolveTarget(ctx context.Context, kind, namespace, name string) (string, string, error) {
+	cs, err := d.clusterGetter.GetClusterSetByName(ctx, d.cluster)
+	if err != nil {
+		return "", "", err
+	}
+
+	var ingresses []v1.LoadBalancerIngress
+	switch kind {
+	case model.DNSRecordKindService:
+		svc, err := cs.Client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", err
+		}
+		if len(svc.Spec.ExternalIPs) != 0 {
+			return dnsutil.RecordTypeA, svc.Spec.ExternalIPs[0], nil
+		}
+		if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+			return "", "", fmt.Errorf("service %s/%s is not exposed", namespace, name)
+		}
+		ingresses = svc.Status.LoadBalancer.Ingress
+	case model.DNSRecordKindIngress:
+		ing, err := cs.Client.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", err
+		}
+		ingresses = ing.Status.LoadBalancer.Ingress
+	default:
+		return "", "", fmt.Errorf("unsupported kind %s", kind)
+	}
+
+	for _, ingress := range ingresses {
+		if len(ingress.IP) != 0 {
+			return dnsutil.RecordTypeA, ingress.IP, nil
+		}
+		if len(ingress.Hostname) != 0 {
+			return dnsutil.RecordTypeCNAME, ingress.Hostname, nil
+		}
+	}
+	return "", "", fmt.Errorf("%s %s/%s has no external address yet", kind, namespace, name)
+}
+
+func (d *dns) model2Type(o *model.DNSRecord) *types.DNSRecord {
+	return &types.DNSRecord{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Cluster:   o.Cluster,
+		Namespace: o.Namespace,
+		Kind:      o.Kind,
+		Name:      o.Name,
+		Domain:    o.Domain,
+		Type:      o.Type,
+		Value:     o.Value,
+		Provider:  o.Provider,
+	}
+}
+
+func NewDNS(cfg config.Config, f db.ShareDaoFactory, clusterName string, c cluster.Interface) *dns {
+	return &dns{
+		cc:            cfg,
+		factory:       f,
+		cluster:       clusterName,
+		clusterGetter: c,
+	}
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fleet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type FleetGetter interface {
+	Fleet() Interface
+}
+
+type Interface interface {
+	Create(ctx context.Context, req *types.CreateFleetRequest) error
+	Update(ctx context.Context, fid int64, req *types.UpdateFleetRequest) error
+	Delete(ctx context.Context, fid int64) error
+	Get(ctx context.Context, fid int64) (*types.Fleet, error)
+	List(ctx context.Context) ([]types.Fleet, error)
+
+	// ListClusters 获取分组当前包含的集群名称
+	ListClusters(ctx context.Context, fid int64) ([]string, error)
+}
+
+type fleet struct {
+	factory db.ShareDaoFactory
+}
+
+func (f *fleet) Create(ctx context.Context, req *types.CreateFleetRequest) error {
+	if _, err := labels.Parse(req.Selector); err != nil {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+	old, err := f.factory.Fleet().GetByName(ctx, req.Name)
+	if err != nil {
+		klog.Errorf("failed to get fleet %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	if old != nil {
+		return errors.ErrFleetExists
+	}
+
+	clusters, err := json.Marshal(req.Clusters)
+	if err != nil {
+		return errors.ErrInvalidRequest
+	}
+	object := &model.Fleet{
+		Name:     req.Name,
+		Selector: req.Selector,
+		Clusters: string(clusters),
+	}
+	if req.Description != nil {
+		object.Description = *req.Description
+	}
+	if _, err = f.factory.Fleet().Create(ctx, object); err != nil {
+		klog.Errorf("failed to create fleet %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+
+	return nil
+}
+
+func (f *fleet) Update(ctx context.Context, fid int64, req *types.UpdateFleetRequest) error {
+	if _, err := f.get(ctx, fid); err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Selector != nil {
+		if _, err := labels.Parse(*req.Selector); err != nil {
+			return errors.NewError(err, http.StatusBadRequest)
+		}
+		updates["selector"] = *req.Selector
+	}
+	if req.Clusters != nil {
+		clusters, err := json.Marshal(*req.Clusters)
+		if err != nil {
+			return errors.ErrInvalidRequest
+		}
+		updates["clusters"] = string(clusters)
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if err := f.factory.Fleet().Update(ctx, fid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update fleet %d: %v", fid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (f *fleet) Delete(ctx context.Context, fid int64) error {
+	if err := f.factory.Fleet().Delete(ctx, fid); err != nil {
+		klog.Errorf("failed to delete fleet %d: %v", fid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (f *fleet) Get(ctx context.Context, fid int64) (*types.Fleet, error) {
+	object, err := f.get(ctx, fid)
+	if err != nil {
+		return nil, err
+	}
+	return f.model2Type(object), nil
+}
+
+func (f *fleet) get(ctx context.Context, fid int64) (*model.Fleet, error) {
+	object, err := f.factory.Fleet().Get(ctx, fid)
+	if err != nil {
+		klog.Errorf("failed to get fleet %d: %v", fid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrFleetNotFound
+	}
+	return object, nil
+}
+
+func (f *fleet) List(ctx context.Context) ([]types.Fleet, error) {
+	objects, err := f.factory.Fleet().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list fleets: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	fleets := make([]types.Fleet, len(objects))
+	for i, object := range objects {
+		fleets[i] = *f.model2Type(&object)
+	}
+	return fleets, nil
+}
+
+func (f *fleet) ListClusters(ctx context.Context, fid int64) ([]string, error) {
+	object, err := f.get(ctx, fid)
+	if err != nil {
+		return nil, err
+	}
+	return resolveClusters(ctx, f.factory, object)
+}
+
+// ResolveClusters 获取指定名称分组的集群，供多集群查询，分发等功能按分组指定目标集群
+func ResolveClusters(ctx context.Context, factory db.ShareDaoFactory, name string) ([]string, error) {
+	object, err := factory.Fleet().GetByName(ctx, name)
+	if err != nil {
+		klog.Errorf("failed to get fleet %s: %v", name, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrFleetNotFound
+	}
+	return resolveClusters(ctx, factory, object)
+}
+
+// resolveClusters 显式指定的集群与标签选择器匹配的集群取并集，已删除的集群会被忽略
+func resolveClusters(ctx context.Context, factory db.ShareDaoFactory, object *model.Fleet) ([]string, error) {
+	explicit := sets.NewString(parseClusters(object.Clusters)...)
+	var selector labels.Selector
+	if len(object.Selector) != 0 {
+		s, err := labels.Parse(object.Selector)
+		if err != nil {
+			return nil, errors.NewError(err, http.StatusBadRequest)
+		}
+		selector = s
+	}
+
+	clusters, err := factory.Cluster().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list clusters: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	members := sets.NewString()
+	for _, cluster := range clusters {
+		if explicit.Has(cluster.Name) {
+			members.Insert(cluster.Name)
+			continue
+		}
+		if selector == nil {
+			continue
+		}
+		var clusterLabels types.ClusterLabels
+		if len(cluster.Labels) != 0 {
+			if err = clusterLabels.Unmarshal(cluster.Labels); err != nil {
+				klog.Warningf("failed to unmarshal cluster(%s) labels: %v", cluster.Name, err)
+				continue
+			}
+		}
+		if selector.Matches(labels.Set(clusterLabels)) {
+			members.Insert(cluster.Name)
+		}
+	}
+
+	return members.List(), nil
+}
+
+func parseClusters(s string) []string {
+	var clusters []string
+	if len(s) == 0 {
+		return clusters
+	}
+	if err := json.Unmarshal([]byte(s), &clusters); err != nil {
+		klog.Warningf("failed to unmarshal fleet clusters: %v", err)
+	}
+	return clusters
+}
+
+func (f *fleet) model2Type(o *model.Fleet) *types.Fleet {
+	clusters := parseClusters(o.Clusters)
+	sort.Strings(clusters)
+
+	return &types.Fleet{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Name:        o.Name,
+		Selector:    o.Selector,
+		Clusters:    clusters,
+		Description: o.Description,
+	}
+}
+
+func NewFleet(f db.ShareDaoFactory) *fleet {
+	return &fleet{
+		factory: f,
+	}
+}
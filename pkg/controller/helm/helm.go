@@ -35,6 +35,7 @@ type HelmGetter interface {
 type Interface interface {
 	Release(cluster, namespace string) ReleaseInterface
 	Repository() RepositoryInterface
+	Secret() SecretInterface
 }
 
 type Helm struct {
@@ -53,13 +54,17 @@ func (h *Helm) Release(cluster, namespace string) ReleaseInterface {
 		"secrets",
 		klog.Infof,
 	)
-	return NewReleases(actionConfig, settings)
+	return NewReleases(actionConfig, settings, h.factory)
 }
 
 func (h *Helm) Repository() RepositoryInterface {
 	return NewRepository(h.factory)
 }
 
+func (h *Helm) Secret() SecretInterface {
+	return NewSecret(h.factory)
+}
+
 func NewHelm(factory db.ShareDaoFactory) Interface {
 	return &Helm{
 		factory: factory,
@@ -82,7 +87,7 @@ func (h *Helm) MustGetClusterSetByName(ctx context.Context, name string) client.
 	if object == nil {
 		return client.ClusterSet{}
 	}
-	newClusterSet, err := client.NewClusterSet(object.KubeConfig)
+	newClusterSet, err := client.NewClusterSet(object.KubeConfig, client.WithProxy(object.Proxy))
 	if err != nil {
 		return client.ClusterSet{}
 	}
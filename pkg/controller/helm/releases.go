@@ -31,7 +31,9 @@ import (
 	"helm.sh/helm/v3/pkg/release"
 	"k8s.io/klog/v2"
 
+	"github.com/caoyingjunz/pixiu/pkg/db"
 	"github.com/caoyingjunz/pixiu/pkg/types"
+	"github.com/caoyingjunz/pixiu/pkg/util/trace"
 )
 
 type ReleaseInterface interface {
@@ -48,12 +50,14 @@ type ReleaseInterface interface {
 type Releases struct {
 	settings     *cli.EnvSettings
 	actionConfig *action.Configuration
+	factory      db.ShareDaoFactory
 }
 
-func NewReleases(actionConfig *action.Configuration, settings *cli.EnvSettings) *Releases {
+func NewReleases(actionConfig *action.Configuration, settings *cli.EnvSettings, f db.ShareDaoFactory) *Releases {
 	return &Releases{
 		actionConfig: actionConfig,
 		settings:     settings,
+		factory:      f,
 	}
 }
 
@@ -70,7 +74,10 @@ func (r *Releases) List(ctx context.Context) ([]*release.Release, error) {
 }
 
 // InstallRelease install release
-func (r *Releases) Install(ctx context.Context, form *types.Release) (*release.Release, error) {
+func (r *Releases) Install(ctx context.Context, form *types.Release) (out *release.Release, err error) {
+	ctx, span := trace.Start(ctx, "helm.install", trace.String("release", form.Name), trace.String("chart", form.Chart), trace.String("namespace", r.settings.Namespace()))
+	defer func() { span.End(err) }()
+
 	client := action.NewInstall(r.actionConfig)
 	client.ReleaseName = form.Name
 	client.Namespace = r.settings.Namespace()
@@ -79,15 +86,22 @@ func (r *Releases) Install(ctx context.Context, form *types.Release) (*release.R
 	client.DryRun = form.Preview
 	if client.DryRun {
 		client.Description = "server"
+	} else if len(form.ChangeReason) != 0 {
+		client.Description = form.ChangeReason
 	}
 	chart, err := r.locateChart(client.ChartPathOptions, form.Chart, r.settings)
 	if err != nil {
 		return nil, err
 	}
-	out, err := client.Run(chart, form.Values)
+	values, err := resolveValues(ctx, r.factory, form.Values)
+	if err != nil {
+		return nil, err
+	}
+	out, err = client.Run(chart, values)
 	if err != nil {
 		return nil, err
 	}
+	r.restoreSecretRefs(out, form.Values, client.DryRun)
 	return out, nil
 }
 
@@ -97,12 +111,17 @@ func (r *Releases) Uninstall(ctx context.Context, name string) (*release.Uninsta
 }
 
 // UpgradeRelease upgrade release
-func (r *Releases) Upgrade(ctx context.Context, form *types.Release) (*release.Release, error) {
+func (r *Releases) Upgrade(ctx context.Context, form *types.Release) (out *release.Release, err error) {
+	ctx, span := trace.Start(ctx, "helm.upgrade", trace.String("release", form.Name), trace.String("chart", form.Chart), trace.String("namespace", r.settings.Namespace()))
+	defer func() { span.End(err) }()
+
 	client := action.NewUpgrade(r.actionConfig)
 	client.Namespace = r.settings.Namespace()
 	client.DryRun = form.Preview
 	if client.DryRun {
 		client.Description = "server"
+	} else if len(form.ChangeReason) != 0 {
+		client.Description = form.ChangeReason
 	}
 
 	chart, err := r.locateChart(client.ChartPathOptions, form.Chart, r.settings)
@@ -110,10 +129,15 @@ func (r *Releases) Upgrade(ctx context.Context, form *types.Release) (*release.R
 		return nil, err
 	}
 
-	out, err := client.Run(form.Name, chart, form.Values)
+	values, err := resolveValues(ctx, r.factory, form.Values)
+	if err != nil {
+		return nil, err
+	}
+	out, err = client.Run(form.Name, chart, values)
 	if err != nil {
 		return nil, err
 	}
+	r.restoreSecretRefs(out, form.Values, client.DryRun)
 	return out, nil
 }
 
@@ -124,16 +148,63 @@ func (r *Releases) History(ctx context.Context, name string) ([]*release.Release
 
 func (r *Releases) Rollback(ctx context.Context, name string, toVersion int) error {
 	klog.Error("version: ", toVersion)
-	_, err := r.Get(ctx, name)
+	current, err := r.Get(ctx, name)
 	if err != nil {
 		return err
 	}
+	// 与 helm 保持一致，未指定版本时回滚到上一个版本
+	if toVersion <= 0 {
+		toVersion = current.Version - 1
+	}
+	if toVersion < 1 {
+		return fmt.Errorf("release %s has no previous version to rollback", name)
+	}
+
+	// 目标版本保存的是敏感变量引用，直接回滚会部署未替换的引用，需解析后以目标版本的 chart 重新升级
+	target, err := r.actionConfig.Releases.Get(name, toVersion)
+	if err != nil {
+		return err
+	}
+	if hasSecretRefs(target.Config) {
+		return r.rollbackWithSecretRefs(ctx, target)
+	}
 
 	client := action.NewRollback(r.actionConfig)
 	client.Version = toVersion
 	return client.Run(name)
 }
 
+func (r *Releases) rollbackWithSecretRefs(ctx context.Context, target *release.Release) error {
+	values, err := resolveValues(ctx, r.factory, target.Config)
+	if err != nil {
+		return err
+	}
+
+	client := action.NewUpgrade(r.actionConfig)
+	client.Namespace = r.settings.Namespace()
+	client.Description = fmt.Sprintf("Rollback to %d", target.Version)
+	out, err := client.Run(target.Name, target.Chart, values)
+	if err != nil {
+		return err
+	}
+	r.restoreSecretRefs(out, target.Config, false)
+	return nil
+}
+
+// restoreSecretRefs 将 release 记录中的 values 还原为敏感变量引用，避免明文保存在 release 历史中
+func (r *Releases) restoreSecretRefs(out *release.Release, values map[string]interface{}, dryRun bool) {
+	if out == nil || !hasSecretRefs(values) {
+		return
+	}
+	out.Config = values
+	if dryRun {
+		return
+	}
+	if err := r.actionConfig.Releases.Update(out); err != nil {
+		klog.Errorf("failed to restore secret references of release %s(%d): %v", out.Name, out.Version, err)
+	}
+}
+
 func (r *Releases) locateChart(pathOpts action.ChartPathOptions, chart string, settings *cli.EnvSettings) (*chart.Chart, error) {
 	// from cmd/helm/install.go and cmd/helm/upgrade.go
 	cp, err := pathOpts.LocateChart(chart, settings)
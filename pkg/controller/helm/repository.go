@@ -30,6 +30,7 @@ import (
 	"helm.sh/helm/v3/pkg/repo"
 	"k8s.io/apimachinery/pkg/util/yaml"
 
+	"github.com/caoyingjunz/pixiu/api/server/errors"
 	"github.com/caoyingjunz/pixiu/pkg/db"
 	"github.com/caoyingjunz/pixiu/pkg/db/model"
 	"github.com/caoyingjunz/pixiu/pkg/types"
@@ -43,7 +44,8 @@ type RepositoryInterface interface {
 	Create(ctx context.Context, repo *types.CreateRepository) error
 	Delete(ctx context.Context, id int64) error
 	Get(ctx context.Context, id int64) (*model.Repository, error)
-	List(ctx context.Context) ([]*model.Repository, error)
+	// List 获取当前用户可见的仓库，指定集群时仅返回该集群可以使用的仓库
+	List(ctx context.Context, opts types.ListRepositoryOptions) ([]*model.Repository, error)
 	Update(ctx context.Context, id int64, update *types.UpdateRepository) error
 
 	GetChartsById(ctx context.Context, id int64) (*model.ChartIndex, error)
@@ -67,12 +69,19 @@ func NewRepository(f db.ShareDaoFactory) *Repository {
 var _ RepositoryInterface = &Repository{}
 
 func (r *Repository) Create(ctx context.Context, repo *types.CreateRepository) error {
+	if err := r.checkCluster(ctx, repo.Cluster); err != nil {
+		return err
+	}
 
 	repoModel := &model.Repository{
-		Name: repo.Name,
-		URL:  repo.URL,
-	}
-	if res, _ := r.GetByName(ctx, repoModel.Name); res != nil {
+		Name:     repo.Name,
+		URL:      repo.URL,
+		Username: repo.Username,
+		Password: repo.Password,
+		Cluster:  repo.Cluster,
+		TenantId: repo.TenantId,
+	}
+	if res, _ := r.GetByName(ctx, repoModel.Cluster, repoModel.Name); res != nil {
 		return fmt.Errorf("repository %s already exists", repoModel.Name)
 	}
 
@@ -88,24 +97,55 @@ func (r *Repository) Get(ctx context.Context, id int64) (*model.Repository, erro
 	return r.factory.Repository().Get(ctx, id)
 }
 
-func (r *Repository) GetByName(ctx context.Context, name string) (*model.Repository, error) {
-	return r.factory.Repository().GetByName(ctx, name)
+func (r *Repository) GetByName(ctx context.Context, cluster string, name string) (*model.Repository, error) {
+	return r.factory.Repository().GetByName(ctx, cluster, name)
 }
 
-func (r *Repository) List(ctx context.Context) ([]*model.Repository, error) {
-	return r.factory.Repository().List(ctx)
+func (r *Repository) List(ctx context.Context, opts types.ListRepositoryOptions) ([]*model.Repository, error) {
+	var dbOpts []db.Options
+	if len(opts.Cluster) != 0 {
+		dbOpts = append(dbOpts, db.WithClusterIn("", opts.Cluster))
+	}
+	return r.factory.Repository().List(ctx, dbOpts...)
 }
 
 func (r *Repository) Update(ctx context.Context, id int64, update *types.UpdateRepository) error {
+	if err := r.checkCluster(ctx, update.Cluster); err != nil {
+		return err
+	}
+	// 租户用户不允许修改仓库的可见范围
+	tenantId := update.TenantId
+	if tid, ok := db.TenantFromContext(ctx); ok {
+		tenantId = tid
+	}
+
 	updates := map[string]interface{}{
-		"name":     update.Name,
-		"url":      update.URL,
-		"username": update.Username,
-		"password": update.Password,
+		"name":      update.Name,
+		"url":       update.URL,
+		"username":  update.Username,
+		"password":  update.Password,
+		"cluster":   update.Cluster,
+		"tenant_id": tenantId,
 	}
 	return r.factory.Repository().Update(ctx, id, *update.ResourceVersion, updates)
 }
 
+// checkCluster 集群级的仓库需要指定已存在的集群，为空时为全局仓库
+func (r *Repository) checkCluster(ctx context.Context, cluster string) error {
+	if len(cluster) == 0 {
+		return nil
+	}
+	object, err := r.factory.Cluster().GetClusterByName(ctx, cluster)
+	if err != nil {
+		klog.Errorf("failed to get cluster %s: %v", cluster, err)
+		return errors.ErrServerInternal
+	}
+	if object == nil {
+		return errors.ErrClusterNotFound
+	}
+	return nil
+}
+
 func (r *Repository) GetChartsById(ctx context.Context, id int64) (*model.ChartIndex, error) {
 	repository, err := r.Get(ctx, id)
 	if err != nil {
@@ -0,0 +1,216 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+// secretRefPattern 匹配 values 中的 ${secret:name} 引用
+var secretRefPattern = regexp.MustCompile(`\$\{secret:([A-Za-z0-9._-]+)\}`)
+
+type SecretGetter interface {
+	Secret() SecretInterface
+}
+
+// SecretInterface 管理 helm values 中引用的敏感变量，接口不返回变量的值
+// 变量的值以明文保存在数据库的 helm_secrets 表中，仅避免出现在 release 历史和接口返回中
+type SecretInterface interface {
+	Create(ctx context.Context, req *types.CreateHelmSecretRequest) error
+	Update(ctx context.Context, sid int64, req *types.UpdateHelmSecretRequest) error
+	Delete(ctx context.Context, sid int64) error
+	Get(ctx context.Context, sid int64) (*types.HelmSecret, error)
+	List(ctx context.Context) ([]types.HelmSecret, error)
+}
+
+type secret struct {
+	factory db.ShareDaoFactory
+}
+
+func NewSecret(f db.ShareDaoFactory) *secret {
+	return &secret{factory: f}
+}
+
+var _ SecretInterface = &secret{}
+
+func (s *secret) Create(ctx context.Context, req *types.CreateHelmSecretRequest) error {
+	if !secretRefPattern.MatchString("${secret:" + req.Name + "}") {
+		return errors.NewError(fmt.Errorf("invalid secret name %s", req.Name), http.StatusBadRequest)
+	}
+	old, err := s.factory.HelmSecret().GetByName(ctx, req.Name)
+	if err != nil {
+		klog.Errorf("failed to get helm secret %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	if old != nil {
+		return errors.ErrHelmSecretExists
+	}
+
+	if _, err = s.factory.HelmSecret().Create(ctx, &model.HelmSecret{
+		Name:        req.Name,
+		Value:       req.Value,
+		Description: req.Description,
+	}); err != nil {
+		klog.Errorf("failed to create helm secret %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (s *secret) Update(ctx context.Context, sid int64, req *types.UpdateHelmSecretRequest) error {
+	updates := make(map[string]interface{})
+	if req.Value != nil {
+		updates["value"] = *req.Value
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	if err := s.factory.HelmSecret().Update(ctx, sid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update helm secret(%d): %v", sid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (s *secret) Delete(ctx context.Context, sid int64) error {
+	if err := s.factory.HelmSecret().Delete(ctx, sid); err != nil {
+		klog.Errorf("failed to delete helm secret(%d): %v", sid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (s *secret) Get(ctx context.Context, sid int64) (*types.HelmSecret, error) {
+	object, err := s.factory.HelmSecret().Get(ctx, sid)
+	if err != nil {
+		klog.Errorf("failed to get helm secret(%d): %v", sid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrHelmSecretNotFound
+	}
+	return model2Secret(object), nil
+}
+
+func (s *secret) List(ctx context.Context) ([]types.HelmSecret, error) {
+	objects, err := s.factory.HelmSecret().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list helm secrets: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	secrets := make([]types.HelmSecret, len(objects))
+	for i, object := range objects {
+		secrets[i] = *model2Secret(&object)
+	}
+	return secrets, nil
+}
+
+func model2Secret(o *model.HelmSecret) *types.HelmSecret {
+	return &types.HelmSecret{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Name:        o.Name,
+		Description: o.Description,
+	}
+}
+
+// hasSecretRefs 判断 values 中是否包含敏感变量引用
+func hasSecretRefs(values map[string]interface{}) bool {
+	found := false
+	walkValues(values, func(s string) string {
+		if secretRefPattern.MatchString(s) {
+			found = true
+		}
+		return s
+	})
+	return found
+}
+
+// resolveValues 返回替换敏感变量引用后的 values 副本，原 values 保持不变
+func resolveValues(ctx context.Context, f db.ShareDaoFactory, values map[string]interface{}) (map[string]interface{}, error) {
+	var resolveErr error
+	cache := make(map[string]string)
+	resolved := walkValues(values, func(s string) string {
+		return secretRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+			name := secretRefPattern.FindStringSubmatch(ref)[1]
+			if value, ok := cache[name]; ok {
+				return value
+			}
+			object, err := f.HelmSecret().GetByName(ctx, name)
+			if err != nil {
+				klog.Errorf("failed to get helm secret %s: %v", name, err)
+				resolveErr = errors.ErrServerInternal
+				return ref
+			}
+			if object == nil {
+				if resolveErr == nil {
+					resolveErr = errors.NewError(fmt.Errorf("secret %s referenced by values not found", name), http.StatusBadRequest)
+				}
+				return ref
+			}
+			cache[name] = object.Value
+			return object.Value
+		})
+	})
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	return resolved.(map[string]interface{}), nil
+}
+
+// walkValues 深拷贝 values，并对其中的每个字符串调用 fn
+func walkValues(v interface{}, fn func(string) string) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = walkValues(item, fn)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = walkValues(item, fn)
+		}
+		return out
+	case string:
+		return fn(val)
+	default:
+		return v
+	}
+}
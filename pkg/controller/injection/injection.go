@@ -0,0 +1,698 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package injection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pmezard/go-difflib/difflib"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+)
+
+type InjectionGetter interface {
+	Injection() Interface
+}
+
+// Interface 管理 sidecar 以及 init-container 模板，并将模板批量注入到多个命名空间的工作负载中
+type Interface interface {
+	CreateTemplate(ctx context.Context, req *types.CreateSidecarTemplateRequest) error
+	UpdateTemplate(ctx context.Context, tid int64, req *types.UpdateSidecarTemplateRequest) error
+	DeleteTemplate(ctx context.Context, tid int64) error
+	GetTemplate(ctx context.Context, tid int64) (*types.SidecarTemplate, error)
+	ListTemplates(ctx context.Context) ([]types.SidecarTemplate, error)
+
+	// Preview 返回每个工作负载注入前后 pod template 的差异，不会修改工作负载
+	Preview(ctx context.Context, req *types.InjectSidecarRequest) ([]types.InjectionPreview, error)
+	// Inject 执行注入并记录每个工作负载的结果，单个工作负载失败不影响其他工作负载
+	Inject(ctx context.Context, req *types.InjectSidecarRequest) (*types.Injection, error)
+	Get(ctx context.Context, iid int64) (*types.Injection, error)
+	List(ctx context.Context, opts types.ListInjectionOptions) ([]types.Injection, error)
+	// Rollback 移除本次注入的容器以及新增的卷
+	Rollback(ctx context.Context, iid int64) (*types.Injection, error)
+}
+
+type injection struct {
+	factory       db.ShareDaoFactory
+	clusterGetter cluster.Interface
+}
+
+// workload 待注入的工作负载以及当前的 pod template
+type workload struct {
+	target types.InjectionTarget
+	spec   v1.PodSpec
+}
+
+// template 解析后的注入模板
+type template struct {
+	name      string
+	kind      string
+	container v1.Container
+	volumes   []v1.Volume
+}
+
+func (i *injection) CreateTemplate(ctx context.Context, req *types.CreateSidecarTemplateRequest) error {
+	if err := validateContainer(&req.Container); err != nil {
+		return err
+	}
+	old, err := i.factory.Injection().GetTemplateByName(ctx, req.Name)
+	if err != nil {
+		klog.Errorf("failed to get sidecar template %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	if old != nil {
+		return errors.ErrSidecarTemplateExists
+	}
+
+	container, err := toJson(req.Container)
+	if err != nil {
+		return errors.ErrServerInternal
+	}
+	volumes, err := toJson(req.Volumes)
+	if err != nil {
+		return errors.ErrServerInternal
+	}
+	if _, err = i.factory.Injection().CreateTemplate(ctx, &model.SidecarTemplate{
+		Name:        req.Name,
+		Type:        req.Type,
+		Container:   container,
+		Volumes:     volumes,
+		Description: req.Description,
+	}); err != nil {
+		klog.Errorf("failed to create sidecar template %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (i *injection) UpdateTemplate(ctx context.Context, tid int64, req *types.UpdateSidecarTemplateRequest) error {
+	if _, err := i.getTemplate(ctx, tid); err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Container != nil {
+		if err := validateContainer(req.Container); err != nil {
+			return err
+		}
+		container, err := toJson(*req.Container)
+		if err != nil {
+			return errors.ErrServerInternal
+		}
+		updates["container"] = container
+	}
+	if req.Volumes != nil {
+		volumes, err := toJson(*req.Volumes)
+		if err != nil {
+			return errors.ErrServerInternal
+		}
+		updates["volumes"] = volumes
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if err := i.factory.Injection().UpdateTemplate(ctx, tid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update sidecar template(%d): %v", tid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (i *injection) DeleteTemplate(ctx context.Context, tid int64) error {
+	if _, err := i.getTemplate(ctx, tid); err != nil {
+		return err
+	}
+	if err := i.factory.Injection().DeleteTemplate(ctx, tid); err != nil {
+		klog.Errorf("failed to delete sidecar template(%d): %v", tid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (i *injection) GetTemplate(ctx context.Context, tid int64) (*types.SidecarTemplate, error) {
+	object, err := i.getTemplate(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	return template2Type(object), nil
+}
+
+func (i *injection) getTemplate(ctx context.Context, tid int64) (*model.SidecarTemplate, error) {
+	object, err := i.factory.Injection().GetTemplate(ctx, tid)
+	if err != nil {
+		klog.Errorf("failed to get sidecar template(%d): %v", tid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrSidecarTemplateNotFound
+	}
+	return object, nil
+}
+
+func (i *injection) ListTemplates(ctx context.Context) ([]types.SidecarTemplate, error) {
+	objects, err := i.factory.Injection().ListTemplates(ctx)
+	if err != nil {
+		klog.Errorf("failed to list sidecar templates: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	templates := make([]types.SidecarTemplate, len(objects))
+	for idx, object := range objects {
+		templates[idx] = *template2Type(&object)
+	}
+	return templates, nil
+}
+
+func (i *injection) Preview(ctx context.Context, req *types.InjectSidecarRequest) ([]types.InjectionPreview, error) {
+	tpl, _, workloads, err := i.resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([]types.InjectionPreview, 0, len(workloads))
+	for _, w := range workloads {
+		preview := types.InjectionPreview{InjectionTarget: w.target}
+		if reason, injected := isInjected(w.spec, tpl.container.Name); injected {
+			preview.Skipped = true
+			preview.Reason = reason
+			previews = append(previews, preview)
+			continue
+		}
+
+		after := w.spec.DeepCopy()
+		volumes := missingVolumes(w.spec, tpl.volumes)
+		if tpl.kind == model.SidecarTypeInit {
+			after.InitContainers = append(after.InitContainers, tpl.container)
+		} else {
+			after.Containers = append(after.Containers, tpl.container)
+		}
+		after.Volumes = append(after.Volumes, volumes...)
+
+		diff, err := diffPodSpec(w.spec, *after)
+		if err != nil {
+			klog.Errorf("failed to diff %s %s/%s: %v", w.target.Kind, w.target.Namespace, w.target.Name, err)
+			return nil, errors.ErrServerInternal
+		}
+		preview.Diff = diff
+		previews = append(previews, preview)
+	}
+	return previews, nil
+}
+
+func (i *injection) Inject(ctx context.Context, req *types.InjectSidecarRequest) (*types.Injection, error) {
+	tpl, cs, workloads, err := i.resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := i.factory.Injection().Create(ctx, &model.Injection{
+		Cluster:       req.Cluster,
+		TemplateName:  tpl.name,
+		Type:          tpl.kind,
+		ContainerName: tpl.container.Name,
+		Status:        model.InjectionFailed,
+		Operator:      user.Name,
+	})
+	if err != nil {
+		klog.Errorf("failed to create injection of template %s: %v", tpl.name, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	results := make(types.InjectionResults, 0, len(workloads))
+	for _, w := range workloads {
+		result := types.InjectionResult{InjectionTarget: w.target}
+		if reason, injected := isInjected(w.spec, tpl.container.Name); injected {
+			result.Status = types.InjectionTargetSkipped
+			result.Message = reason
+			results = append(results, result)
+			continue
+		}
+
+		volumes := missingVolumes(w.spec, tpl.volumes)
+		podSpec := map[string]interface{}{
+			containersKey(tpl.kind): []v1.Container{tpl.container},
+		}
+		if len(volumes) != 0 {
+			podSpec["volumes"] = volumes
+		}
+		err = i.admitWorkload(ctx, req.Cluster, w.target, podSpec)
+		if err == nil {
+			err = patchWorkload(ctx, cs, w.target, podSpec)
+		}
+		if err != nil {
+			klog.Errorf("failed to inject %s into %s %s/%s: %v", tpl.name, w.target.Kind, w.target.Namespace, w.target.Name, err)
+			result.Status = types.InjectionTargetFailed
+			result.Message = err.Error()
+		} else {
+			result.Status = types.InjectionTargetInjected
+			for _, volume := range volumes {
+				result.Volumes = append(result.Volumes, volume.Name)
+			}
+		}
+		results = append(results, result)
+	}
+
+	if err = i.updateResults(ctx, object, injectionStatus(results), results); err != nil {
+		return nil, err
+	}
+	return model2Type(object)
+}
+
+func (i *injection) Rollback(ctx context.Context, iid int64) (*types.Injection, error) {
+	object, err := i.get(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	if object.Status == model.InjectionRolledBack {
+		return nil, errors.NewError(fmt.Errorf("injection(%d) has already been rolled back", iid), http.StatusConflict)
+	}
+	var results types.InjectionResults
+	if err = results.Unmarshal(object.Results); err != nil {
+		klog.Errorf("failed to unmarshal injection(%d) results: %v", iid, err)
+		return nil, errors.ErrServerInternal
+	}
+	cs, err := i.clusterGetter.GetClusterSetByName(ctx, object.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range results {
+		result := &results[idx]
+		if result.Status != types.InjectionTargetInjected {
+			continue
+		}
+
+		podSpec := map[string]interface{}{
+			containersKey(object.Type): []map[string]interface{}{{"name": object.ContainerName, "$patch": "delete"}},
+		}
+		if len(result.Volumes) != 0 {
+			volumes := make([]map[string]interface{}, 0, len(result.Volumes))
+			for _, name := range result.Volumes {
+				volumes = append(volumes, map[string]interface{}{"name": name, "$patch": "delete"})
+			}
+			podSpec["volumes"] = volumes
+		}
+		if err = patchWorkload(ctx, cs, result.InjectionTarget, podSpec); err != nil {
+			// 工作负载已被删除时无需回滚
+			if apierrors.IsNotFound(err) {
+				result.Status = types.InjectionTargetRolledBack
+				result.Message = "workload not found"
+				continue
+			}
+			klog.Errorf("failed to rollback injection(%d) of %s %s/%s: %v", iid, result.Kind, result.Namespace, result.Name, err)
+			result.Message = err.Error()
+			continue
+		}
+		result.Status = types.InjectionTargetRolledBack
+		result.Message = ""
+	}
+
+	status := model.InjectionRolledBack
+	for _, result := range results {
+		// 存在回滚失败的工作负载时保留原状态，允许再次回滚
+		if result.Status == types.InjectionTargetInjected {
+			status = object.Status
+			break
+		}
+	}
+	if err = i.updateResults(ctx, object, status, results); err != nil {
+		return nil, err
+	}
+	return model2Type(object)
+}
+
+func (i *injection) updateResults(ctx context.Context, object *model.Injection, status string, results types.InjectionResults) error {
+	data, err := results.Marshal()
+	if err != nil {
+		klog.Errorf("failed to marshal injection(%d) results: %v", object.Id, err)
+		return errors.ErrServerInternal
+	}
+	if err = i.factory.Injection().UpdateResults(ctx, object.Id, status, data); err != nil {
+		klog.Errorf("failed to update injection(%d) results: %v", object.Id, err)
+		return errors.ErrServerInternal
+	}
+	object.Status = status
+	object.Results = data
+	return nil
+}
+
+func (i *injection) Get(ctx context.Context, iid int64) (*types.Injection, error) {
+	object, err := i.get(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	return model2Type(object)
+}
+
+func (i *injection) get(ctx context.Context, iid int64) (*model.Injection, error) {
+	object, err := i.factory.Injection().Get(ctx, iid)
+	if err != nil {
+		klog.Errorf("failed to get injection(%d): %v", iid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrInjectionNotFound
+	}
+	return object, nil
+}
+
+func (i *injection) List(ctx context.Context, opts types.ListInjectionOptions) ([]types.Injection, error) {
+	dbOpts := []db.Options{db.WithOrderByDesc()}
+	if len(opts.Cluster) != 0 {
+		dbOpts = append(dbOpts, db.WithCluster(opts.Cluster))
+	}
+	objects, err := i.factory.Injection().List(ctx, dbOpts...)
+	if err != nil {
+		klog.Errorf("failed to list injections: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	injections := make([]types.Injection, 0, len(objects))
+	for _, object := range objects {
+		o, err := model2Type(&object)
+		if err != nil {
+			return nil, err
+		}
+		injections = append(injections, *o)
+	}
+	return injections, nil
+}
+
+// resolve 解析注入模板并获取匹配的工作负载
+func (i *injection) resolve(ctx context.Context, req *types.InjectSidecarRequest) (*template, client.ClusterSet, []workload, error) {
+	object, err := i.factory.Injection().GetTemplateByName(ctx, req.Template)
+	if err != nil {
+		klog.Errorf("failed to get sidecar template %s: %v", req.Template, err)
+		return nil, client.ClusterSet{}, nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, client.ClusterSet{}, nil, errors.ErrSidecarTemplateNotFound
+	}
+	tpl := &template{name: object.Name, kind: object.Type}
+	if err = json.Unmarshal([]byte(object.Container), &tpl.container); err != nil {
+		klog.Errorf("failed to unmarshal sidecar template %s container: %v", object.Name, err)
+		return nil, client.ClusterSet{}, nil, errors.ErrServerInternal
+	}
+	if len(object.Volumes) != 0 {
+		if err = json.Unmarshal([]byte(object.Volumes), &tpl.volumes); err != nil {
+			klog.Errorf("failed to unmarshal sidecar template %s volumes: %v", object.Name, err)
+			return nil, client.ClusterSet{}, nil, errors.ErrServerInternal
+		}
+	}
+
+	cs, err := i.clusterGetter.GetClusterSetByName(ctx, req.Cluster)
+	if err != nil {
+		return nil, client.ClusterSet{}, nil, err
+	}
+
+	kinds := req.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{KindDeployment}
+	}
+	names := sets.NewString(req.Names...)
+	workloads := make([]workload, 0)
+	for _, namespace := range req.Namespaces {
+		for _, kind := range sets.NewString(kinds...).List() {
+			ws, err := listWorkloads(ctx, cs, namespace, kind, req.Selector)
+			if err != nil {
+				klog.Errorf("failed to list %s in %s: %v", kind, namespace, err)
+				return nil, client.ClusterSet{}, nil, errors.NewError(err, http.StatusBadRequest)
+			}
+			for _, w := range ws {
+				if names.Len() != 0 && !names.Has(w.target.Name) {
+					continue
+				}
+				workloads = append(workloads, w)
+			}
+		}
+	}
+	return tpl, cs, workloads, nil
+}
+
+func listWorkloads(ctx context.Context, cs client.ClusterSet, namespace string, kind string, selector string) ([]workload, error) {
+	opts := metav1.ListOptions{LabelSelector: selector}
+	workloads := make([]workload, 0)
+	newWorkload := func(name string, spec v1.PodSpec) workload {
+		return workload{target: types.InjectionTarget{Namespace: namespace, Kind: kind, Name: name}, spec: spec}
+	}
+
+	switch kind {
+	case KindDeployment:
+		objects, err := cs.Client.AppsV1().Deployments(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects.Items {
+			workloads = append(workloads, newWorkload(object.Name, object.Spec.Template.Spec))
+		}
+	case KindStatefulSet:
+		objects, err := cs.Client.AppsV1().StatefulSets(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects.Items {
+			workloads = append(workloads, newWorkload(object.Name, object.Spec.Template.Spec))
+		}
+	case KindDaemonSet:
+		objects, err := cs.Client.AppsV1().DaemonSets(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects.Items {
+			workloads = append(workloads, newWorkload(object.Name, object.Spec.Template.Spec))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind %s", kind)
+	}
+	return workloads, nil
+}
+
+// admitWorkload 注入前校验注入的容器是否超出租户配额，daemonset 不做校验
+func (i *injection) admitWorkload(ctx context.Context, cluster string, target types.InjectionTarget, podSpec map[string]interface{}) error {
+	data, err := podTemplatePatch(podSpec)
+	if err != nil {
+		return err
+	}
+	return i.clusterGetter.AdmitPatch(ctx, cluster, target.Kind, target.Namespace, target.Name, "", apitypes.StrategicMergePatchType, data)
+}
+
+func podTemplatePatch(podSpec map[string]interface{}) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": podSpec,
+			},
+		},
+	})
+}
+
+// patchWorkload 使用 strategic merge patch 修改工作负载的 pod template
+func patchWorkload(ctx context.Context, cs client.ClusterSet, target types.InjectionTarget, podSpec map[string]interface{}) error {
+	data, err := podTemplatePatch(podSpec)
+	if err != nil {
+		return err
+	}
+
+	switch target.Kind {
+	case KindDeployment:
+		_, err = cs.Client.AppsV1().Deployments(target.Namespace).Patch(ctx, target.Name, apitypes.StrategicMergePatchType, data, metav1.PatchOptions{})
+	case KindStatefulSet:
+		_, err = cs.Client.AppsV1().StatefulSets(target.Namespace).Patch(ctx, target.Name, apitypes.StrategicMergePatchType, data, metav1.PatchOptions{})
+	case KindDaemonSet:
+		_, err = cs.Client.AppsV1().DaemonSets(target.Namespace).Patch(ctx, target.Name, apitypes.StrategicMergePatchType, data, metav1.PatchOptions{})
+	default:
+		err = fmt.Errorf("unsupported kind %s", target.Kind)
+	}
+	return err
+}
+
+// isInjected 存在同名的容器或者 init-container 时跳过，避免重复注入
+func isInjected(spec v1.PodSpec, name string) (string, bool) {
+	for _, c := range spec.Containers {
+		if c.Name == name {
+			return fmt.Sprintf("container %s already exists", name), true
+		}
+	}
+	for _, c := range spec.InitContainers {
+		if c.Name == name {
+			return fmt.Sprintf("init container %s already exists", name), true
+		}
+	}
+	return "", false
+}
+
+// missingVolumes 返回工作负载中不存在的模板卷，同名的卷保留工作负载原有的定义
+func missingVolumes(spec v1.PodSpec, volumes []v1.Volume) []v1.Volume {
+	existing := sets.NewString()
+	for _, volume := range spec.Volumes {
+		existing.Insert(volume.Name)
+	}
+	missing := make([]v1.Volume, 0)
+	for _, volume := range volumes {
+		if !existing.Has(volume.Name) {
+			missing = append(missing, volume)
+		}
+	}
+	return missing
+}
+
+func diffPodSpec(before, after v1.PodSpec) (string, error) {
+	a, err := json.MarshalIndent(before, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(after, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: "before",
+		ToFile:   "after",
+		Context:  3,
+	})
+}
+
+// injectionStatus 全部成功或者跳过为 applied，部分失败为 partial，没有成功注入的工作负载为 failed
+func injectionStatus(results types.InjectionResults) string {
+	var injected, failed int
+	for _, result := range results {
+		switch result.Status {
+		case types.InjectionTargetInjected:
+			injected++
+		case types.InjectionTargetFailed:
+			failed++
+		}
+	}
+	if failed == 0 {
+		return model.InjectionApplied
+	}
+	if injected == 0 {
+		return model.InjectionFailed
+	}
+	return model.InjectionPartial
+}
+
+func containersKey(kind string) string {
+	if kind == model.SidecarTypeInit {
+		return "initContainers"
+	}
+	return "containers"
+}
+
+func validateContainer(container *v1.Container) error {
+	if len(container.Name) == 0 || len(container.Image) == 0 {
+		return errors.NewError(fmt.Errorf("container name and image are required"), http.StatusBadRequest)
+	}
+	return nil
+}
+
+func toJson(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func template2Type(o *model.SidecarTemplate) *types.SidecarTemplate {
+	t := &types.SidecarTemplate{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Name:        o.Name,
+		Type:        o.Type,
+		Description: o.Description,
+	}
+	if err := json.Unmarshal([]byte(o.Container), &t.Container); err != nil {
+		klog.Warningf("failed to unmarshal sidecar template(%d) container: %v", o.Id, err)
+	}
+	if len(o.Volumes) != 0 {
+		if err := json.Unmarshal([]byte(o.Volumes), &t.Volumes); err != nil {
+			klog.Warningf("failed to unmarshal sidecar template(%d) volumes: %v", o.Id, err)
+		}
+	}
+	return t
+}
+
+func model2Type(o *model.Injection) (*types.Injection, error) {
+	var results types.InjectionResults
+	if len(o.Results) != 0 {
+		if err := results.Unmarshal(o.Results); err != nil {
+			klog.Errorf("failed to unmarshal injection(%d) results: %v", o.Id, err)
+			return nil, errors.ErrServerInternal
+		}
+	}
+	return &types.Injection{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Cluster:       o.Cluster,
+		TemplateName:  o.TemplateName,
+		Type:          o.Type,
+		ContainerName: o.ContainerName,
+		Status:        o.Status,
+		Operator:      o.Operator,
+		Results:       results,
+	}, nil
+}
+
+func NewInjection(f db.ShareDaoFactory, c cluster.Interface) *injection {
+	return &injection{
+		factory:       f,
+		clusterGetter: c,
+	}
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	inspectionutil "github.com/caoyingjunz/pixiu/pkg/inspection"
+	"github.com/caoyingjunz/pixiu/pkg/notifier"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type InspectionGetter interface {
+	Inspection(cluster string) Interface
+}
+
+// Interface 管理集群的巡检计划，由 jobmanager 的 inspection-scheduler 定期执行
+type Interface interface {
+	Create(ctx context.Context, req *types.CreateInspectionRequest) error
+	Update(ctx context.Context, iid int64, req *types.UpdateInspectionRequest) error
+	Delete(ctx context.Context, iid int64) error
+	Get(ctx context.Context, iid int64) (*types.Inspection, error)
+	List(ctx context.Context) ([]types.Inspection, error)
+
+	// Run 立即执行一次巡检并返回巡检报告
+	Run(ctx context.Context, iid int64) (*types.InspectionReport, error)
+
+	// ListReports 获取巡检计划的历史报告，不包含检查项详情
+	ListReports(ctx context.Context, iid int64) ([]types.InspectionReport, error)
+	GetReport(ctx context.Context, iid int64, rid int64) (*types.InspectionReport, error)
+}
+
+type inspection struct {
+	cc      config.Config
+	factory db.ShareDaoFactory
+	cluster string
+
+	clusterGetter cluster.Interface
+}
+
+func (i *inspection) Create(ctx context.Context, req *types.CreateInspectionRequest) error {
+	if err := validSchedule(req.Schedule); err != nil {
+		return err
+	}
+	if err := validChecks(req.Checks); err != nil {
+		return err
+	}
+
+	object := &model.Inspection{
+		Cluster:   i.cluster,
+		Name:      req.Name,
+		Schedule:  req.Schedule,
+		Checks:    strings.Join(req.Checks, ","),
+		Receivers: inspectionutil.JoinReceivers(req.Receivers),
+		Enabled:   true,
+	}
+	if req.Enabled != nil {
+		object.Enabled = *req.Enabled
+	}
+	if req.Description != nil {
+		object.Description = *req.Description
+	}
+	if _, err := i.factory.Inspection().Create(ctx, object); err != nil {
+		klog.Errorf("failed to create cluster(%s) inspection %s: %v", i.cluster, req.Name, err)
+		return errors.ErrServerInternal
+	}
+
+	return nil
+}
+
+func (i *inspection) Update(ctx context.Context, iid int64, req *types.UpdateInspectionRequest) error {
+	if _, err := i.get(ctx, iid); err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Schedule != nil {
+		if err := validSchedule(*req.Schedule); err != nil {
+			return err
+		}
+		updates["schedule"] = *req.Schedule
+	}
+	if req.Checks != nil {
+		if err := validChecks(*req.Checks); err != nil {
+			return err
+		}
+		updates["checks"] = strings.Join(*req.Checks, ",")
+	}
+	if req.Receivers != nil {
+		updates["receivers"] = inspectionutil.JoinReceivers(*req.Receivers)
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if err := i.factory.Inspection().Update(ctx, iid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update inspection %d: %v", iid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (i *inspection) Delete(ctx context.Context, iid int64) error {
+	if _, err := i.get(ctx, iid); err != nil {
+		return err
+	}
+	if err := i.factory.Inspection().Delete(ctx, iid); err != nil {
+		klog.Errorf("failed to delete inspection %d: %v", iid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (i *inspection) Get(ctx context.Context, iid int64) (*types.Inspection, error) {
+	object, err := i.get(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	return i.model2Type(object), nil
+}
+
+func (i *inspection) get(ctx context.Context, iid int64) (*model.Inspection, error) {
+	object, err := i.factory.Inspection().Get(ctx, iid)
+	if err != nil {
+		klog.Errorf("failed to get inspection %d: %v", iid, err)
+		return nil, errors.ErrServerInternal
+	}
+	// 不允许跨集群操作巡检计划
+	if object == nil || object.Cluster != i.cluster {
+		return nil, errors.ErrInspectionNotFound
+	}
+	return object, nil
+}
+
+func (i *inspection) List(ctx context.Context) ([]types.Inspection, error) {
+	objects, err := i.factory.Inspection().List(ctx, db.WithCluster(i.cluster))
+	if err != nil {
+		klog.Errorf("failed to list cluster(%s) inspections: %v", i.cluster, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	inspections := make([]types.Inspection, len(objects))
+	for idx, object := range objects {
+		inspections[idx] = *i.model2Type(&object)
+	}
+	return inspections, nil
+}
+
+func (i *inspection) Run(ctx context.Context, iid int64) (*types.InspectionReport, error) {
+	object, err := i.get(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := i.clusterGetter.GetClusterSetByName(ctx, i.cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var operator string
+	if user, err := httputils.GetUserFromRequest(ctx); err == nil {
+		operator = user.Name
+	}
+	inspector := inspectionutil.NewInspector(i.factory, notifier.New(i.factory, i.cc.Notification))
+	report, err := inspector.Inspect(ctx, object, cs, model.InspectionTriggerManual, operator)
+	if err != nil {
+		klog.Errorf("failed to run inspection %d: %v", iid, err)
+		return nil, errors.ErrServerInternal
+	}
+	return report2Type(report, true), nil
+}
+
+func (i *inspection) ListReports(ctx context.Context, iid int64) ([]types.InspectionReport, error) {
+	if _, err := i.get(ctx, iid); err != nil {
+		return nil, err
+	}
+	objects, err := i.factory.Inspection().ListReports(ctx, iid, db.WithOrderByDesc())
+	if err != nil {
+		klog.Errorf("failed to list inspection %d reports: %v", iid, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	reports := make([]types.InspectionReport, len(objects))
+	for idx, object := range objects {
+		reports[idx] = *report2Type(&object, false)
+	}
+	return reports, nil
+}
+
+func (i *inspection) GetReport(ctx context.Context, iid int64, rid int64) (*types.InspectionReport, error) {
+	if _, err := i.get(ctx, iid); err != nil {
+		return nil, err
+	}
+	object, err := i.factory.Inspection().GetReport(ctx, rid)
+	if err != nil {
+		klog.Errorf("failed to get inspection report %d: %v", rid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil || object.InspectionId != iid {
+		return nil, errors.ErrInspectionReportNotFound
+	}
+	return report2Type(object, true), nil
+}
+
+func validSchedule(schedule string) error {
+	if _, err := cron.ParseStandard(schedule); err != nil {
+		return errors.NewError(fmt.Errorf("invalid schedule %q: %v", schedule, err), http.StatusBadRequest)
+	}
+	return nil
+}
+
+func validChecks(checks []string) error {
+	for _, check := range checks {
+		if !inspectionutil.IsValidCheck(check) {
+			return errors.NewError(fmt.Errorf("unsupported check %q, must be one of %s", check, strings.Join(inspectionutil.Checks, ",")), http.StatusBadRequest)
+		}
+	}
+	return nil
+}
+
+func (i *inspection) model2Type(o *model.Inspection) *types.Inspection {
+	checks := inspectionutil.SplitChecks(o.Checks)
+	if len(checks) == 0 {
+		checks = inspectionutil.Checks
+	}
+	object := &types.Inspection{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Cluster:     o.Cluster,
+		Name:        o.Name,
+		Schedule:    o.Schedule,
+		Checks:      checks,
+		Receivers:   inspectionutil.SplitReceivers(o.Receivers),
+		Enabled:     o.Enabled,
+		LastRunTime: o.LastRunTime,
+		Description: o.Description,
+	}
+	if o.Enabled {
+		if sched, err := cron.ParseStandard(o.Schedule); err == nil {
+			next := sched.Next(time.Now())
+			object.NextRunTime = &next
+		}
+	}
+	return object
+}
+
+// report2Type withItems 为 false 时不解析检查项详情
+func report2Type(o *model.InspectionReport, withItems bool) *types.InspectionReport {
+	report := &types.InspectionReport{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		InspectionId: o.InspectionId,
+		Cluster:      o.Cluster,
+		Trigger:      o.Trigger,
+		Score:        o.Score,
+		Operator:     o.Operator,
+	}
+	if withItems {
+		if err := json.Unmarshal([]byte(o.Report), &report.Items); err != nil {
+			klog.Warningf("failed to unmarshal inspection report(%d): %v", o.Id, err)
+		}
+	}
+	return report
+}
+
+func NewInspection(cfg config.Config, f db.ShareDaoFactory, clusterName string, c cluster.Interface) *inspection {
+	return &inspection{
+		cc:            cfg,
+		factory:       f,
+		cluster:       clusterName,
+		clusterGetter: c,
+	}
+}
@@ -0,0 +1,407 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package karmada
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	// 控制面和成员集群中存放凭证的命名空间，与 karmadactl join 保持一致
+	clusterNamespace = "karmada-cluster"
+	syncModePush     = "Push"
+
+	// 成员集群对应的 pixiu 集群
+	pixiuClusterLabel = "pixiu.io/cluster"
+
+	tokenTimeout = 30 * time.Second
+)
+
+var (
+	clusterGVR                  = schema.GroupVersionResource{Group: "cluster.karmada.io", Version: "v1alpha1", Resource: "clusters"}
+	propagationPolicyGVR        = schema.GroupVersionResource{Group: "policy.karmada.io", Version: "v1alpha1", Resource: "propagationpolicies"}
+	clusterPropagationPolicyGVR = schema.GroupVersionResource{Group: "policy.karmada.io", Version: "v1alpha1", Resource: "clusterpropagationpolicies"}
+	resourceBindingGVR          = schema.GroupVersionResource{Group: "work.karmada.io", Version: "v1alpha2", Resource: "resourcebindings"}
+)
+
+type KarmadaGetter interface {
+	Karmada(cluster string) Interface
+}
+
+// Interface 对接已部署的 Karmada，cluster 为纳管到 pixiu 的 karmada-apiserver
+type Interface interface {
+	// RegisterMember 以 Push 模式将 pixiu 集群注册为成员集群，等同于 karmadactl join
+	RegisterMember(ctx context.Context, req *types.RegisterKarmadaMemberRequest) (*types.KarmadaMember, error)
+	// UnregisterMember 移除成员集群，并清理成员集群中的凭证
+	UnregisterMember(ctx context.Context, name string) error
+	ListMembers(ctx context.Context) ([]types.KarmadaMember, error)
+
+	// ListPolicies 获取分发策略，namespace 为空时获取 ClusterPropagationPolicy
+	ListPolicies(ctx context.Context, namespace string) ([]types.KarmadaPolicy, error)
+	// ListBindings 获取资源在成员集群中的分发结果
+	ListBindings(ctx context.Context, namespace string) ([]types.KarmadaBinding, error)
+}
+
+type karmada struct {
+	cluster       string
+	clusterGetter cluster.Interface
+}
+
+func (k *karmada) RegisterMember(ctx context.Context, req *types.RegisterKarmadaMemberRequest) (*types.KarmadaMember, error) {
+	name := req.Name
+	if len(name) == 0 {
+		name = req.Cluster
+	}
+	if name == k.cluster {
+		return nil, errors.NewError(fmt.Errorf("不允许将 karmada 控制面注册为成员集群"), http.StatusBadRequest)
+	}
+
+	cp, err := k.clusterGetter.GetClusterSetByName(ctx, k.cluster)
+	if err != nil {
+		return nil, err
+	}
+	member, err := k.clusterGetter.GetClusterSetByName(ctx, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. 在成员集群中创建 karmada 使用的 service account 并获取 token
+	token, caBundle, err := k.prepareMemberCredential(ctx, member.Client, name)
+	if err != nil {
+		klog.Errorf("failed to prepare karmada credential in cluster %s: %v", req.Cluster, err)
+		return nil, err
+	}
+	if len(caBundle) == 0 {
+		caBundle = member.Config.CAData
+	}
+
+	// 2. 在控制面保存凭证并创建 Cluster 对象
+	if err = ensureNamespace(ctx, cp.Client, clusterNamespace); err != nil {
+		return nil, err
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: clusterNamespace},
+		Data: map[string][]byte{
+			"token":    token,
+			"caBundle": caBundle,
+		},
+	}
+	if _, err = cp.Client.CoreV1().Secrets(clusterNamespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		klog.Errorf("failed to create karmada cluster secret %s: %v", name, err)
+		return nil, err
+	}
+
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": clusterGVR.GroupVersion().String(),
+		"kind":       "Cluster",
+		"metadata": map[string]interface{}{
+			"name": name,
+			"labels": map[string]interface{}{
+				pixiuClusterLabel: req.Cluster,
+			},
+		},
+		"spec": map[string]interface{}{
+			"syncMode":    syncModePush,
+			"apiEndpoint": member.Config.Host,
+			"secretRef": map[string]interface{}{
+				"namespace": clusterNamespace,
+				"name":      name,
+			},
+		},
+	}}
+	created, err := cp.Dynamic.Resource(clusterGVR).Create(ctx, object, metav1.CreateOptions{})
+	if err != nil {
+		klog.Errorf("failed to create karmada cluster %s: %v", name, err)
+		// 回滚已创建的凭证
+		_ = cp.Client.CoreV1().Secrets(clusterNamespace).Delete(ctx, name, metav1.DeleteOptions{})
+		return nil, err
+	}
+
+	return parseMember(created), nil
+}
+
+// prepareMemberCredential 创建绑定 cluster-admin 的 service account 以及长期有效的 token
+func (k *karmada) prepareMemberCredential(ctx context.Context, client *kubernetes.Clientset, name string) ([]byte, []byte, error) {
+	if err := ensureNamespace(ctx, client, clusterNamespace); err != nil {
+		return nil, nil, err
+	}
+	saName := "karmada-" + name
+
+	if _, err := client.CoreV1().ServiceAccounts(clusterNamespace).Create(ctx, &v1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: saName, Namespace: clusterNamespace},
+	}, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, nil, err
+	}
+	if _, err := client.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: saName},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     "cluster-admin",
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      saName,
+			Namespace: clusterNamespace,
+		}},
+	}, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, nil, err
+	}
+	if _, err := client.CoreV1().Secrets(clusterNamespace).Create(ctx, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        saName,
+			Namespace:   clusterNamespace,
+			Annotations: map[string]string{v1.ServiceAccountNameKey: saName},
+		},
+		Type: v1.SecretTypeServiceAccountToken,
+	}, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, nil, err
+	}
+
+	// 等待 token controller 写入 token
+	var token, caBundle []byte
+	err := wait.PollImmediate(time.Second, tokenTimeout, func() (bool, error) {
+		secret, err := client.CoreV1().Secrets(clusterNamespace).Get(ctx, saName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		token, caBundle = secret.Data[v1.ServiceAccountTokenKey], secret.Data[v1.ServiceAccountRootCAKey]
+		return len(token) != 0, nil
+	})
+	return token, caBundle, err
+}
+
+func (k *karmada) UnregisterMember(ctx context.Context, name string) error {
+	cp, err := k.clusterGetter.GetClusterSetByName(ctx, k.cluster)
+	if err != nil {
+		return err
+	}
+	object, err := cp.Dynamic.Resource(clusterGVR).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err = cp.Dynamic.Resource(clusterGVR).Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		klog.Errorf("failed to delete karmada cluster %s: %v", name, err)
+		return err
+	}
+	if err = cp.Client.CoreV1().Secrets(clusterNamespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Warningf("failed to delete karmada cluster secret %s: %v", name, err)
+	}
+
+	// 通过 pixiu 注册的成员集群，尽力清理成员集群中的凭证
+	pixiuCluster := object.GetLabels()[pixiuClusterLabel]
+	if len(pixiuCluster) == 0 {
+		return nil
+	}
+	member, err := k.clusterGetter.GetClusterSetByName(ctx, pixiuCluster)
+	if err != nil {
+		klog.Warningf("failed to get member cluster %s, skip cleanup: %v", pixiuCluster, err)
+		return nil
+	}
+	saName := "karmada-" + name
+	if err = member.Client.RbacV1().ClusterRoleBindings().Delete(ctx, saName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Warningf("failed to delete clusterrolebinding %s in cluster %s: %v", saName, pixiuCluster, err)
+	}
+	if err = member.Client.CoreV1().ServiceAccounts(clusterNamespace).Delete(ctx, saName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Warningf("failed to delete serviceaccount %s in cluster %s: %v", saName, pixiuCluster, err)
+	}
+	return nil
+}
+
+func (k *karmada) ListMembers(ctx context.Context) ([]types.KarmadaMember, error) {
+	cp, err := k.clusterGetter.GetClusterSetByName(ctx, k.cluster)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := cp.Dynamic.Resource(clusterGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("failed to list karmada clusters: %v", err)
+		return nil, err
+	}
+
+	members := make([]types.KarmadaMember, len(objects.Items))
+	for i := range objects.Items {
+		members[i] = *parseMember(&objects.Items[i])
+	}
+	return members, nil
+}
+
+func (k *karmada) ListPolicies(ctx context.Context, namespace string) ([]types.KarmadaPolicy, error) {
+	cp, err := k.clusterGetter.GetClusterSetByName(ctx, k.cluster)
+	if err != nil {
+		return nil, err
+	}
+	var objects *unstructured.UnstructuredList
+	if len(namespace) == 0 {
+		objects, err = cp.Dynamic.Resource(clusterPropagationPolicyGVR).List(ctx, metav1.ListOptions{})
+	} else {
+		objects, err = cp.Dynamic.Resource(propagationPolicyGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	}
+	if err != nil {
+		klog.Errorf("failed to list karmada propagation policies: %v", err)
+		return nil, err
+	}
+
+	policies := make([]types.KarmadaPolicy, len(objects.Items))
+	for i := range objects.Items {
+		policies[i] = *parsePolicy(&objects.Items[i])
+	}
+	return policies, nil
+}
+
+func (k *karmada) ListBindings(ctx context.Context, namespace string) ([]types.KarmadaBinding, error) {
+	cp, err := k.clusterGetter.GetClusterSetByName(ctx, k.cluster)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := cp.Dynamic.Resource(resourceBindingGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("failed to list karmada resource bindings in %s: %v", namespace, err)
+		return nil, err
+	}
+
+	bindings := make([]types.KarmadaBinding, len(objects.Items))
+	for i := range objects.Items {
+		bindings[i] = *parseBinding(&objects.Items[i])
+	}
+	return bindings, nil
+}
+
+func ensureNamespace(ctx context.Context, client *kubernetes.Clientset, name string) error {
+	_, err := client.CoreV1().Namespaces().Create(ctx, &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func parseMember(object *unstructured.Unstructured) *types.KarmadaMember {
+	member := &types.KarmadaMember{
+		Name:              object.GetName(),
+		Cluster:           object.GetLabels()[pixiuClusterLabel],
+		CreationTimestamp: object.GetCreationTimestamp().Time,
+	}
+	member.SyncMode, _, _ = unstructured.NestedString(object.Object, "spec", "syncMode")
+	member.APIEndpoint, _, _ = unstructured.NestedString(object.Object, "spec", "apiEndpoint")
+	member.KubernetesVersion, _, _ = unstructured.NestedString(object.Object, "status", "kubernetesVersion")
+
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Ready" {
+			member.Ready = condition["status"] == string(metav1.ConditionTrue)
+		}
+	}
+	return member
+}
+
+func parsePolicy(object *unstructured.Unstructured) *types.KarmadaPolicy {
+	policy := &types.KarmadaPolicy{
+		Name:              object.GetName(),
+		Namespace:         object.GetNamespace(),
+		ResourceSelectors: make([]types.KarmadaResourceSelector, 0),
+		CreationTimestamp: object.GetCreationTimestamp().Time,
+	}
+	selectors, _, _ := unstructured.NestedSlice(object.Object, "spec", "resourceSelectors")
+	for _, s := range selectors {
+		selector, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		policy.ResourceSelectors = append(policy.ResourceSelectors, parseResourceSelector(selector))
+	}
+	policy.Clusters, _, _ = unstructured.NestedStringSlice(object.Object, "spec", "placement", "clusterAffinity", "clusterNames")
+	policy.Priority, _, _ = unstructured.NestedInt64(object.Object, "spec", "priority")
+	policy.Preemption, _, _ = unstructured.NestedString(object.Object, "spec", "preemption")
+	return policy
+}
+
+func parseBinding(object *unstructured.Unstructured) *types.KarmadaBinding {
+	binding := &types.KarmadaBinding{
+		Name:              object.GetName(),
+		Namespace:         object.GetNamespace(),
+		Clusters:          make([]types.KarmadaTargetCluster, 0),
+		CreationTimestamp: object.GetCreationTimestamp().Time,
+	}
+	if resource, ok, _ := unstructured.NestedMap(object.Object, "spec", "resource"); ok {
+		binding.Resource = parseResourceSelector(resource)
+	}
+
+	clusters, _, _ := unstructured.NestedSlice(object.Object, "spec", "clusters")
+	for _, c := range clusters {
+		target, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		tc := types.KarmadaTargetCluster{}
+		tc.Name, _, _ = unstructured.NestedString(target, "name")
+		tc.Replicas, _, _ = unstructured.NestedInt64(target, "replicas")
+		binding.Clusters = append(binding.Clusters, tc)
+	}
+
+	statuses, _, _ := unstructured.NestedSlice(object.Object, "status", "aggregatedStatus")
+	for _, s := range statuses {
+		status, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		bs := types.KarmadaBindingStatus{}
+		bs.Cluster, _, _ = unstructured.NestedString(status, "clusterName")
+		bs.Applied, _, _ = unstructured.NestedBool(status, "applied")
+		bs.Health, _, _ = unstructured.NestedString(status, "health")
+		binding.Statuses = append(binding.Statuses, bs)
+	}
+	return binding
+}
+
+func parseResourceSelector(object map[string]interface{}) types.KarmadaResourceSelector {
+	selector := types.KarmadaResourceSelector{}
+	selector.APIVersion, _, _ = unstructured.NestedString(object, "apiVersion")
+	selector.Kind, _, _ = unstructured.NestedString(object, "kind")
+	selector.Namespace, _, _ = unstructured.NestedString(object, "namespace")
+	selector.Name, _, _ = unstructured.NestedString(object, "name")
+	return selector
+}
+
+func NewKarmada(clusterName string, c cluster.Interface) *karmada {
+	return &karmada{
+		cluster:       clusterName,
+		clusterGetter: c,
+	}
+}
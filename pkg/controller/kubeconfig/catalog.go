@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	KindClusterRole = "ClusterRole"
+	KindRole        = "Role"
+
+	RoleScopeCluster   = "cluster"
+	RoleScopeNamespace = "namespace"
+)
+
+// ListRoles 获取管理员签发时可以绑定的 ClusterRole，指定 namespace 时同时返回该命名空间中的 Role
+func (k *kubeConfig) ListRoles(ctx context.Context, opts types.ListKubeConfigRolesOptions) ([]types.KubeConfigRole, error) {
+	return k.listRoles(ctx, opts, func(kind, name string) bool {
+		if kind == KindRole {
+			return k.cc.KubeConfig.AllowRole(name)
+		}
+		return k.cc.KubeConfig.AllowClusterRole(name)
+	})
+}
+
+// ListMyRoles 获取自助签发时可以绑定的 ClusterRole，自助签发不允许绑定 Role
+func (k *kubeConfig) ListMyRoles(ctx context.Context, opts types.ListKubeConfigRolesOptions) ([]types.KubeConfigRole, error) {
+	policy, err := k.GetPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !sets.NewString(policy.Clusters...).Has(opts.Cluster) {
+		return make([]types.KubeConfigRole, 0), nil
+	}
+
+	allowed := sets.NewString(policy.ClusterRoles...)
+	return k.listRoles(ctx, opts, func(kind, name string) bool {
+		return kind == KindClusterRole && allowed.Has(name) && k.cc.KubeConfig.AllowClusterRole(name)
+	})
+}
+
+func (k *kubeConfig) listRoles(ctx context.Context, opts types.ListKubeConfigRolesOptions, allow func(kind, name string) bool) ([]types.KubeConfigRole, error) {
+	defaults, err := k.clusterGetter.GetDefaults(ctx, opts.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := k.clusterGetter.GetClusterSetByName(ctx, opts.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]types.KubeConfigRole, 0)
+	clusterRoles, err := cs.Client.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("failed to list clusterRoles in cluster(%s): %v", opts.Cluster, err)
+		return nil, err
+	}
+	for _, clusterRole := range clusterRoles.Items {
+		if !allow(KindClusterRole, clusterRole.Name) {
+			continue
+		}
+		roles = append(roles, types.KubeConfigRole{
+			Kind:    KindClusterRole,
+			Name:    clusterRole.Name,
+			Scopes:  []string{RoleScopeCluster, RoleScopeNamespace},
+			Default: clusterRole.Name == defaults.ClusterRole,
+		})
+	}
+
+	if len(opts.Namespace) != 0 {
+		namespaced, err := cs.Client.RbacV1().Roles(opts.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			klog.Errorf("failed to list roles in %s of cluster(%s): %v", opts.Namespace, opts.Cluster, err)
+			return nil, err
+		}
+		for _, role := range namespaced.Items {
+			if !allow(KindRole, role.Name) {
+				continue
+			}
+			roles = append(roles, types.KubeConfigRole{
+				Kind:      KindRole,
+				Name:      role.Name,
+				Namespace: role.Namespace,
+				Scopes:    []string{RoleScopeNamespace},
+			})
+		}
+	}
+
+	sort.SliceStable(roles, func(i, j int) bool {
+		if roles[i].Kind != roles[j].Kind {
+			return roles[i].Kind == KindClusterRole
+		}
+		return roles[i].Name < roles[j].Name
+	})
+	return roles, nil
+}
+
+// checkAllowedRole 在访问集群之前拒绝不在允许范围内的角色
+func (k *kubeConfig) checkAllowedRole(clusterRole, role string) error {
+	if len(role) != 0 {
+		if !k.cc.KubeConfig.AllowRole(role) {
+			return errors.NewError(fmt.Errorf("不允许绑定 Role %s", role), http.StatusForbidden)
+		}
+		return nil
+	}
+	if !k.cc.KubeConfig.AllowClusterRole(clusterRole) {
+		return errors.NewError(fmt.Errorf("不允许绑定 ClusterRole %s", clusterRole), http.StatusForbidden)
+	}
+	return nil
+}
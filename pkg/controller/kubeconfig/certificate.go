@@ -0,0 +1,172 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	// 等待 CSR 签发证书的超时时间
+	certificateTimeout = 30 * time.Second
+
+	// 证书用户名的前缀，避免和集群中已有的用户冲突
+	certificateUserPrefix = "pixiu:"
+)
+
+// issueCertificate 通过 CertificateSigningRequest 签发客户端证书，证书的 CN 为 pixiu:用户名，集群的审计日志中可以直接识别平台用户
+// 同一用户在同一集群中签发的证书共用用户名，授权仍然通过各自的绑定关系管理
+func (k *kubeConfig) issueCertificate(ctx context.Context, cs client.ClusterSet, meta metav1.ObjectMeta, user *model.User, opts issueOptions) (*types.KubeConfig, error) {
+	for _, group := range opts.groups {
+		if strings.HasPrefix(group, "system:") {
+			return nil, errors.NewError(fmt.Errorf("不允许使用系统用户组 %s", group), http.StatusBadRequest)
+		}
+	}
+	opts.certificateUser = certificateUserPrefix + user.Name
+
+	if err := k.bind(ctx, cs, meta, opts); err != nil {
+		klog.Errorf("failed to bind user %s in cluster(%s): %v", meta.Name, opts.cluster, err)
+		return nil, err
+	}
+
+	object, err := k.certificateAndCreate(ctx, cs, meta, user, opts)
+	if err != nil {
+		if cleanupErr := k.cleanup(cs, meta.Namespace, meta.Name); cleanupErr != nil {
+			klog.Errorf("failed to clean up bindings of %s in cluster(%s): %v", meta.Name, opts.cluster, cleanupErr)
+		}
+		return nil, err
+	}
+	return k.withVerification(ctx, object), nil
+}
+
+func (k *kubeConfig) certificateAndCreate(ctx context.Context, cs client.ClusterSet, meta metav1.ObjectMeta, user *model.User, opts issueOptions) (*model.KubeConfig, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keyData, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	csrData, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: opts.certificateUser, Organization: opts.groups},
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+
+	certData, err := k.requestCertificate(ctx, cs, meta, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrData}), opts.ttl)
+	if err != nil {
+		klog.Errorf("failed to request certificate for %s in cluster(%s): %v", meta.Name, opts.cluster, err)
+		return nil, err
+	}
+	block, _ := pem.Decode(certData)
+	if block == nil {
+		return nil, fmt.Errorf("invalid certificate issued for %s", meta.Name)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := buildKubeConfig(cs, opts, meta.Name, &clientcmdapi.AuthInfo{
+		ClientCertificateData: certData,
+		ClientKeyData:         pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyData}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return k.createRecord(ctx, meta, user, opts, cert.NotAfter, data)
+}
+
+// requestCertificate 提交并批准 CSR，等待签发后删除 CSR，返回 PEM 格式的证书
+func (k *kubeConfig) requestCertificate(ctx context.Context, cs client.ClusterSet, meta metav1.ObjectMeta, request []byte, ttl int64) ([]byte, error) {
+	expirationSeconds := int32(ttl)
+	csr, err := cs.Client.CertificatesV1().CertificateSigningRequests().Create(ctx, &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        meta.Name,
+			Labels:      meta.Labels,
+			Annotations: meta.Annotations,
+		},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:           request,
+			SignerName:        certificatesv1.KubeAPIServerClientSignerName,
+			ExpirationSeconds: &expirationSeconds,
+			Usages:            []certificatesv1.KeyUsage{certificatesv1.UsageClientAuth},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := cs.Client.CertificatesV1().CertificateSigningRequests().Delete(context.TODO(), csr.Name, metav1.DeleteOptions{}); err != nil {
+			klog.Warningf("failed to delete certificateSigningRequest %s: %v", csr.Name, err)
+		}
+	}()
+
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+		Type:           certificatesv1.CertificateApproved,
+		Status:         corev1.ConditionTrue,
+		Reason:         "PixiuApprove",
+		Message:        "approved by pixiu for kubeconfig issuance",
+		LastUpdateTime: metav1.Now(),
+	})
+	if _, err = cs.Client.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+
+	var certificate []byte
+	err = wait.PollImmediate(time.Second, certificateTimeout, func() (bool, error) {
+		object, err := cs.Client.CertificatesV1().CertificateSigningRequests().Get(ctx, csr.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, condition := range object.Status.Conditions {
+			if condition.Type == certificatesv1.CertificateDenied || condition.Type == certificatesv1.CertificateFailed {
+				return false, fmt.Errorf("certificateSigningRequest %s %s: %s", csr.Name, condition.Type, condition.Message)
+			}
+		}
+		certificate = object.Status.Certificate
+		return len(certificate) != 0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
@@ -0,0 +1,825 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	ctrlutil "github.com/caoyingjunz/pixiu/pkg/controller/util"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	kubeconfigutil "github.com/caoyingjunz/pixiu/pkg/util/kubeconfig"
+)
+
+const (
+	// 未指定 ttl 时 token 的有效期
+	defaultTTL int64 = 24 * 60 * 60
+	// 未指定 ttl 时证书的有效期，实际有效期不超过集群签发证书的最大有效期
+	defaultCertificateTTL int64 = 365 * 24 * 60 * 60
+
+	// 批量签发的 worker 数量
+	batchConcurrency = 10
+
+	kubeConfigLabelKey = "pixiu.io/kubeconfig"
+	userAnnotation     = "pixiu.io/kubeconfig-user"
+)
+
+// 未设置自助签发限制时，不允许用户自助签发
+var defaultPolicy = types.KubeConfigPolicy{
+	ClusterRoles: []string{"view"},
+	MaxTTL:       defaultTTL,
+}
+
+type KubeConfigGetter interface {
+	KubeConfig() Interface
+}
+
+type Interface interface {
+	// Create 为指定用户签发 kubeconfig，不受自助签发的限制
+	Create(ctx context.Context, req *types.CreateKubeConfigRequest) (*types.KubeConfig, error)
+	// BatchCreate 并发签发多个 kubeconfig，单个失败时不影响其他，按请求顺序返回每个的结果
+	BatchCreate(ctx context.Context, req *types.BatchCreateKubeConfigRequest) ([]types.BatchKubeConfigResult, error)
+	// Delete 清理 kubeconfig 对应的 ServiceAccount 和 ClusterRoleBinding，已签发的 token 随之失效
+	Delete(ctx context.Context, kid int64) error
+	// Revoke 删除 kubeconfig 对应的 ServiceAccount 使所有 token 失效，保留记录并标记为已吊销
+	// 证书凭证无法吊销证书本身，删除绑定关系后证书不再有任何权限
+	Revoke(ctx context.Context, kid int64) error
+	Get(ctx context.Context, kid int64) (*types.KubeConfig, error)
+	// List 指定分页时返回 types.PageResponse，否则返回全部
+	List(ctx context.Context, opts types.ListKubeConfigOptions) (interface{}, error)
+
+	// Issue 用户在管理员设置的限制内为自己签发 kubeconfig
+	Issue(ctx context.Context, req *types.CreateKubeConfigRequest) (*types.KubeConfig, error)
+	ListMine(ctx context.Context, opts types.ListKubeConfigOptions) (interface{}, error)
+	// GetMine 获取当前用户签发的 kubeconfig，包含 config
+	GetMine(ctx context.Context, kid int64) (*types.KubeConfig, error)
+	DeleteMine(ctx context.Context, kid int64) error
+	// GetMerged 将当前用户在各集群中的 kubeconfig 合并为一个，每个集群对应一个 context
+	GetMerged(ctx context.Context) ([]byte, error)
+
+	// ListOrphans 获取删除 kubeconfig 时未清理干净的 ServiceAccount 和绑定关系
+	ListOrphans(ctx context.Context, cluster string) ([]types.KubeConfigOrphan, error)
+	PurgeOrphans(ctx context.Context, cluster string) ([]types.KubeConfigOrphan, error)
+
+	// ListRoles 获取签发时可以绑定的 ClusterRole 和 Role，仅返回配置中允许的角色
+	ListRoles(ctx context.Context, opts types.ListKubeConfigRolesOptions) ([]types.KubeConfigRole, error)
+	// ListMyRoles 获取自助签发时可以绑定的 ClusterRole
+	ListMyRoles(ctx context.Context, opts types.ListKubeConfigRolesOptions) ([]types.KubeConfigRole, error)
+
+	GetPolicy(ctx context.Context) (*types.KubeConfigPolicy, error)
+	UpdatePolicy(ctx context.Context, req *types.UpdateKubeConfigPolicyRequest) error
+}
+
+type kubeConfig struct {
+	cc       config.Config
+	factory  db.ShareDaoFactory
+	enforcer *casbin.SyncedEnforcer
+
+	clusterGetter cluster.Interface
+}
+
+func (k *kubeConfig) Create(ctx context.Context, req *types.CreateKubeConfigRequest) (*types.KubeConfig, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserId != 0 && req.UserId != user.Id {
+		if user, err = k.factory.User().Get(ctx, req.UserId); err != nil {
+			klog.Errorf("failed to get user(%d): %v", req.UserId, err)
+			return nil, errors.ErrServerInternal
+		}
+		if user == nil {
+			return nil, errors.ErrUserNotFound
+		}
+	}
+
+	if len(req.Groups) != 0 && req.CredentialType != model.CredentialTypeCertificate {
+		return nil, errors.NewError(fmt.Errorf("仅证书凭证支持指定用户组"), http.StatusBadRequest)
+	}
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+		if req.CredentialType == model.CredentialTypeCertificate {
+			ttl = defaultCertificateTTL
+		}
+	}
+	clusterRole, contextNamespace, err := k.withClusterDefaults(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err = k.checkAllowedRole(clusterRole, req.Role); err != nil {
+		return nil, err
+	}
+	return k.issue(ctx, user, issueOptions{
+		cluster:          req.Cluster,
+		clusterRole:      clusterRole,
+		role:             req.Role,
+		namespace:        req.Namespace,
+		contextName:      req.ContextName,
+		contextNamespace: contextNamespace,
+		server:           req.Server,
+		saNamespace:      req.ServiceAccountNamespace,
+		ttl:              ttl,
+		credentialType:   req.CredentialType,
+		groups:           req.Groups,
+	})
+}
+
+// BatchCreate 通过固定数量的 worker 从队列中获取并签发，每个集群的并发数由 acquireCluster 限制
+func (k *kubeConfig) BatchCreate(ctx context.Context, req *types.BatchCreateKubeConfigRequest) ([]types.BatchKubeConfigResult, error) {
+	results := make([]types.BatchKubeConfigResult, len(req.Items))
+	queue := make(chan int, len(req.Items))
+	for i := range req.Items {
+		queue <- i
+	}
+	close(queue)
+
+	workers := batchConcurrency
+	if len(req.Items) < workers {
+		workers = len(req.Items)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				results[index].Index = index
+				object, err := k.Create(ctx, &req.Items[index])
+				if err != nil {
+					klog.Warningf("failed to create kubeconfig(%d) for cluster %s: %v", index, req.Items[index].Cluster, err)
+					results[index].Error = err.Error()
+					continue
+				}
+				results[index].KubeConfig = object
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func (k *kubeConfig) Issue(ctx context.Context, req *types.CreateKubeConfigRequest) (*types.KubeConfig, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := k.GetPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sets.NewString(policy.Clusters...).Has(req.Cluster) {
+		return nil, errors.NewError(fmt.Errorf("集群 %s 不允许自助签发 kubeconfig", req.Cluster), http.StatusForbidden)
+	}
+	if len(req.Role) != 0 {
+		return nil, errors.NewError(fmt.Errorf("不允许自助绑定 Role %s", req.Role), http.StatusForbidden)
+	}
+	if req.CredentialType == model.CredentialTypeCertificate || len(req.Groups) != 0 {
+		return nil, errors.NewError(fmt.Errorf("不允许自助签发证书凭证"), http.StatusForbidden)
+	}
+	if len(req.ServiceAccountNamespace) != 0 {
+		return nil, errors.NewError(fmt.Errorf("不允许自助指定 ServiceAccount 的命名空间"), http.StatusForbidden)
+	}
+	clusterRole, contextNamespace, err := k.withClusterDefaults(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if !sets.NewString(policy.ClusterRoles...).Has(clusterRole) {
+		return nil, errors.NewError(fmt.Errorf("不允许自助绑定 ClusterRole %s", clusterRole), http.StatusForbidden)
+	}
+	if err = k.checkAllowedRole(clusterRole, ""); err != nil {
+		return nil, err
+	}
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = policy.MaxTTL
+	}
+	if ttl > policy.MaxTTL {
+		return nil, errors.NewError(fmt.Errorf("ttl 不能超过 %d 秒", policy.MaxTTL), http.StatusBadRequest)
+	}
+
+	// 自助签发时始终使用集群凭证中的地址
+	return k.issue(ctx, user, issueOptions{
+		cluster:          req.Cluster,
+		clusterRole:      clusterRole,
+		namespace:        req.Namespace,
+		contextName:      req.ContextName,
+		contextNamespace: contextNamespace,
+		ttl:              ttl,
+	})
+}
+
+// withClusterDefaults 未指定 cluster_role 和 role 时使用集群默认的 ClusterRole，并返回 context 的默认命名空间
+func (k *kubeConfig) withClusterDefaults(ctx context.Context, req *types.CreateKubeConfigRequest) (string, string, error) {
+	defaults, err := k.clusterGetter.GetDefaults(ctx, req.Cluster)
+	if err != nil {
+		return "", "", err
+	}
+
+	clusterRole := req.ClusterRole
+	if len(clusterRole) == 0 && len(req.Role) == 0 {
+		if clusterRole = defaults.ClusterRole; len(clusterRole) == 0 {
+			return "", "", errors.NewError(fmt.Errorf("未指定 cluster_role 或 role，且集群 %s 未设置默认的 ClusterRole", req.Cluster), http.StatusBadRequest)
+		}
+	}
+	contextNamespace := req.Namespace
+	if len(contextNamespace) == 0 {
+		contextNamespace = defaults.Namespace
+	}
+	return clusterRole, contextNamespace, nil
+}
+
+// issueOptions 签发 kubeconfig 的参数，namespace 不为空时仅在该命名空间内授权
+type issueOptions struct {
+	cluster     string
+	clusterRole string
+	role        string
+	namespace   string
+	server      string
+	ttl         int64
+	// kubeconfig 中 context 的名称和默认命名空间
+	contextName      string
+	contextNamespace string
+	// ServiceAccount 所在的命名空间，为空时使用 namespace 或者配置中的命名空间
+	saNamespace string
+	// 为空时使用 ServiceAccount 的 token
+	credentialType string
+	// 证书凭证的用户名和用户组，即证书的 CN 和 O
+	certificateUser string
+	groups          []string
+}
+
+// issue 创建 ServiceAccount 并绑定 ClusterRole 或者 Role，通过 TokenRequest 获取限时 token 生成 kubeconfig
+func (k *kubeConfig) issue(ctx context.Context, user *model.User, opts issueOptions) (*types.KubeConfig, error) {
+	if len(opts.role) != 0 && len(opts.namespace) == 0 {
+		return nil, errors.NewError(fmt.Errorf("绑定 Role 时需指定 namespace"), http.StatusBadRequest)
+	}
+	cs, err := k.clusterGetter.GetClusterSetByName(ctx, opts.cluster)
+	if err != nil {
+		return nil, err
+	}
+	if opts.server, err = resolveServer(cs, opts.cluster, opts.server); err != nil {
+		return nil, err
+	}
+	if err = k.checkRoleRef(ctx, cs, opts); err != nil {
+		return nil, err
+	}
+	release := acquireCluster(opts.cluster)
+	defer release()
+
+	// 命名空间级的 kubeconfig 默认在该命名空间中创建 ServiceAccount
+	namespace := opts.saNamespace
+	if len(namespace) == 0 {
+		namespace = opts.namespace
+	}
+	if len(namespace) == 0 {
+		namespace = k.cc.KubeConfig.ServiceAccountNamespace(opts.cluster)
+	}
+	name := fmt.Sprintf("pixiu-u%d-%s", user.Id, utilrand.String(5))
+	meta := metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Labels:      map[string]string{kubeConfigLabelKey: name},
+		Annotations: map[string]string{userAnnotation: user.Name},
+	}
+	if opts.credentialType == model.CredentialTypeCertificate {
+		return k.issueCertificate(ctx, cs, meta, user, opts)
+	}
+
+	if namespace != opts.namespace {
+		if err = withRetry(func() error {
+			_, err := cs.Client.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}, metav1.CreateOptions{})
+			return err
+		}); err != nil {
+			klog.Errorf("failed to create namespace %s in cluster(%s): %v", namespace, opts.cluster, err)
+			return nil, err
+		}
+	}
+	if err = withRetry(func() error {
+		_, err := cs.Client.CoreV1().ServiceAccounts(namespace).Create(ctx, &corev1.ServiceAccount{ObjectMeta: meta}, metav1.CreateOptions{})
+		return err
+	}); err != nil {
+		klog.Errorf("failed to create serviceAccount %s/%s in cluster(%s): %v", namespace, name, opts.cluster, err)
+		return nil, err
+	}
+
+	object, err := k.bindAndCreate(ctx, cs, meta, user, opts)
+	if err != nil {
+		if cleanupErr := k.cleanup(cs, namespace, name); cleanupErr != nil {
+			klog.Errorf("failed to clean up serviceAccount %s/%s in cluster(%s): %v", namespace, name, opts.cluster, cleanupErr)
+		}
+		return nil, err
+	}
+	return k.withVerification(ctx, object), nil
+}
+
+func (k *kubeConfig) checkRoleRef(ctx context.Context, cs client.ClusterSet, opts issueOptions) error {
+	var err error
+	if len(opts.role) != 0 {
+		if _, err = cs.Client.RbacV1().Roles(opts.namespace).Get(ctx, opts.role, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+			return errors.NewError(fmt.Errorf("Role %s/%s 不存在", opts.namespace, opts.role), http.StatusBadRequest)
+		}
+		return err
+	}
+
+	if len(opts.namespace) != 0 {
+		if _, err = cs.Client.CoreV1().Namespaces().Get(ctx, opts.namespace, metav1.GetOptions{}); err != nil {
+			if apierrors.IsNotFound(err) {
+				return errors.NewError(fmt.Errorf("命名空间 %s 不存在", opts.namespace), http.StatusBadRequest)
+			}
+			return err
+		}
+	}
+	if _, err = cs.Client.RbacV1().ClusterRoles().Get(ctx, opts.clusterRole, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+		return errors.NewError(fmt.Errorf("ClusterRole %s 不存在", opts.clusterRole), http.StatusBadRequest)
+	}
+	return err
+}
+
+// bind 未指定 namespace 时使用 ClusterRoleBinding，否则在该命名空间中通过 RoleBinding 绑定 Role 或者 ClusterRole
+func (k *kubeConfig) bind(ctx context.Context, cs client.ClusterSet, meta metav1.ObjectMeta, opts issueOptions) error {
+	bindingMeta := metav1.ObjectMeta{
+		Name:        meta.Name,
+		Labels:      meta.Labels,
+		Annotations: meta.Annotations,
+	}
+	subjects := []rbacv1.Subject{{
+		Kind:      rbacv1.ServiceAccountKind,
+		Name:      meta.Name,
+		Namespace: meta.Namespace,
+	}}
+	// 证书凭证的用户名为证书的 CN
+	if opts.credentialType == model.CredentialTypeCertificate {
+		subjects = []rbacv1.Subject{{
+			Kind:     rbacv1.UserKind,
+			APIGroup: rbacv1.GroupName,
+			Name:     opts.certificateUser,
+		}}
+	}
+
+	if len(opts.namespace) == 0 {
+		_, err := cs.Client.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+			ObjectMeta: bindingMeta,
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     opts.clusterRole,
+			},
+			Subjects: subjects,
+		}, metav1.CreateOptions{})
+		return err
+	}
+
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: opts.clusterRole}
+	if len(opts.role) != 0 {
+		roleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: opts.role}
+	}
+	bindingMeta.Namespace = opts.namespace
+	_, err := cs.Client.RbacV1().RoleBindings(opts.namespace).Create(ctx, &rbacv1.RoleBinding{
+		ObjectMeta: bindingMeta,
+		RoleRef:    roleRef,
+		Subjects:   subjects,
+	}, metav1.CreateOptions{})
+	return err
+}
+
+func (k *kubeConfig) bindAndCreate(ctx context.Context, cs client.ClusterSet, meta metav1.ObjectMeta, user *model.User, opts issueOptions) (*model.KubeConfig, error) {
+	clusterName, ttl := opts.cluster, opts.ttl
+	if err := withRetry(func() error { return k.bind(ctx, cs, meta, opts) }); err != nil {
+		klog.Errorf("failed to bind serviceAccount %s/%s in cluster(%s): %v", meta.Namespace, meta.Name, clusterName, err)
+		return nil, err
+	}
+
+	var token *authenticationv1.TokenRequest
+	err := retry.OnError(issueBackoff, isRetriable, func() error {
+		var err error
+		token, err = cs.Client.CoreV1().ServiceAccounts(meta.Namespace).CreateToken(ctx, meta.Name, &authenticationv1.TokenRequest{
+			Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &ttl},
+		}, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.Errorf("failed to create token for serviceAccount %s in cluster(%s): %v", meta.Name, clusterName, err)
+		return nil, err
+	}
+
+	data, err := buildKubeConfig(cs, opts, meta.Name, &clientcmdapi.AuthInfo{Token: token.Status.Token})
+	if err != nil {
+		return nil, err
+	}
+	return k.createRecord(ctx, meta, user, opts, token.Status.ExpirationTimestamp.Time, data)
+}
+
+func (k *kubeConfig) createRecord(ctx context.Context, meta metav1.ObjectMeta, user *model.User, opts issueOptions, expiration time.Time, data []byte) (*model.KubeConfig, error) {
+	credentialType := opts.credentialType
+	if len(credentialType) == 0 {
+		credentialType = model.CredentialTypeToken
+	}
+	object, err := k.factory.KubeConfig().Create(ctx, &model.KubeConfig{
+		UserId:              user.Id,
+		Cluster:             opts.cluster,
+		ServiceAccount:      meta.Name,
+		Namespace:           meta.Namespace,
+		ClusterRole:         opts.clusterRole,
+		Role:                opts.role,
+		Namespaced:          len(opts.namespace) != 0,
+		Server:              opts.server,
+		ContextName:         opts.contextName,
+		CredentialType:      credentialType,
+		CertificateUser:     opts.certificateUser,
+		Groups:              strings.Join(opts.groups, ","),
+		TTL:                 opts.ttl,
+		ExpirationTimestamp: expiration,
+		Config:              string(data),
+	})
+	if err != nil {
+		klog.Errorf("failed to create kubeconfig record of %s: %v", meta.Name, err)
+		return nil, errors.ErrServerInternal
+	}
+	return object, nil
+}
+
+// resolveServer 未指定地址时使用 pixiu 访问集群时的地址，即集群凭证中的 API server 地址
+func resolveServer(cs client.ClusterSet, clusterName string, server string) (string, error) {
+	if len(server) != 0 {
+		return server, nil
+	}
+	if cs.Config == nil || len(cs.Config.Host) == 0 {
+		return "", errors.NewError(fmt.Errorf("无法获取集群 %s 的 API server 地址，请指定 server", clusterName), http.StatusBadRequest)
+	}
+	return cs.Config.Host, nil
+}
+
+// buildKubeConfig CA 使用 pixiu 访问集群时的配置，cluster 以集群名命名，user 以 ServiceAccount 命名
+// context 未自定义时以 ServiceAccount@集群名 命名
+func buildKubeConfig(cs client.ClusterSet, opts issueOptions, user string, authInfo *clientcmdapi.AuthInfo) ([]byte, error) {
+	o := kubeconfigutil.Options{
+		Cluster:   opts.cluster,
+		Server:    opts.server,
+		User:      user,
+		AuthInfo:  authInfo,
+		Context:   opts.contextName,
+		Namespace: opts.contextNamespace,
+	}
+	if cs.Config != nil {
+		o.CAData = cs.Config.TLSClientConfig.CAData
+		o.Insecure = cs.Config.TLSClientConfig.Insecure
+	}
+	if len(o.Context) == 0 {
+		o.Context = user + "@" + opts.cluster
+	}
+	return kubeconfigutil.New(o)
+}
+
+// cleanup 请求的 context 可能已经取消，因此使用独立的 context
+// 绑定关系与 ServiceAccount 同名，ClusterRoleBinding 和 RoleBinding 均尝试清理，不存在时忽略，其余错误汇总返回
+func (k *kubeConfig) cleanup(cs client.ClusterSet, namespace string, name string) error {
+	ctx := context.TODO()
+	var errs []error
+	if err := cs.Client.RbacV1().ClusterRoleBindings().Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		errs = append(errs, fmt.Errorf("failed to delete clusterRoleBinding %s: %v", name, err))
+	}
+	if err := cs.Client.RbacV1().RoleBindings(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		errs = append(errs, fmt.Errorf("failed to delete roleBinding %s/%s: %v", namespace, name, err))
+	}
+	if err := cs.Client.CoreV1().ServiceAccounts(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		errs = append(errs, fmt.Errorf("failed to delete serviceAccount %s/%s: %v", namespace, name, err))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (k *kubeConfig) Delete(ctx context.Context, kid int64) error {
+	object, err := k.get(ctx, kid)
+	if err != nil {
+		return err
+	}
+	return k.delete(ctx, object)
+}
+
+func (k *kubeConfig) DeleteMine(ctx context.Context, kid int64) error {
+	object, err := k.getMine(ctx, kid)
+	if err != nil {
+		return err
+	}
+	return k.delete(ctx, object)
+}
+
+func (k *kubeConfig) GetMine(ctx context.Context, kid int64) (*types.KubeConfig, error) {
+	object, err := k.getMine(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	if object.Revoked {
+		return nil, errors.ErrKubeConfigRevoked
+	}
+	k.auditAccess(ctx, *object)
+	return k.model2Type(object, true), nil
+}
+
+// auditAccess 记录返回明文 kubeconfig 的操作，path 中记录 kubeconfig 的 ID 和所属集群，便于追溯导出凭证的用户
+func (k *kubeConfig) auditAccess(ctx context.Context, objects ...model.KubeConfig) {
+	for _, object := range objects {
+		if object.Revoked {
+			continue
+		}
+		audit := httputils.NewAuditFromRequest(ctx, model.ObjectKubeConfig, fmt.Sprintf("/pixiu/kubeconfigs/%d/config?cluster=%s", object.Id, object.Cluster))
+		if _, err := k.factory.Audit().Create(ctx, audit); err != nil {
+			klog.Errorf("failed to create audit record [%s]: %v", audit.String(), err)
+		}
+	}
+}
+
+// getMine 不允许获取其他用户的 kubeconfig
+func (k *kubeConfig) getMine(ctx context.Context, kid int64) (*model.KubeConfig, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	object, err := k.get(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	if object.UserId != user.Id {
+		return nil, errors.ErrKubeConfigNotFound
+	}
+	return object, nil
+}
+
+func (k *kubeConfig) delete(ctx context.Context, object *model.KubeConfig) error {
+	// 集群已被删除时仅清理记录
+	cs, err := k.clusterGetter.GetClusterSetByName(ctx, object.Cluster)
+	if err == nil {
+		if err = k.cleanup(cs, object.Namespace, object.ServiceAccount); err != nil {
+			klog.Errorf("failed to clean up serviceAccount %s in cluster(%s): %v", object.ServiceAccount, object.Cluster, err)
+			httputils.AddWarning(ctx, "serviceAccount %s in cluster %s not fully cleaned: %v", object.ServiceAccount, object.Cluster, err)
+		}
+	} else {
+		klog.Warningf("failed to get cluster(%s) clientSet, skip cleaning serviceAccount %s: %v", object.Cluster, object.ServiceAccount, err)
+		httputils.AddWarning(ctx, "cluster %s unreachable, serviceAccount %s not cleaned", object.Cluster, object.ServiceAccount)
+	}
+
+	if err = k.factory.KubeConfig().Delete(ctx, object.Id); err != nil {
+		klog.Errorf("failed to delete kubeconfig(%d): %v", object.Id, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (k *kubeConfig) Revoke(ctx context.Context, kid int64) error {
+	object, err := k.get(ctx, kid)
+	if err != nil {
+		return err
+	}
+	if object.Revoked {
+		return errors.ErrKubeConfigRevoked
+	}
+	// 与删除不同，集群不可用时无法确认 token 已失效，直接返回错误
+	cs, err := k.clusterGetter.GetClusterSetByName(ctx, object.Cluster)
+	if err != nil {
+		return err
+	}
+	// 清理失败时 token 可能仍然有效，不能标记为已吊销
+	if err = k.cleanup(cs, object.Namespace, object.ServiceAccount); err != nil {
+		klog.Errorf("failed to revoke kubeconfig(%d) in cluster(%s): %v", kid, object.Cluster, err)
+		return errors.ErrServerInternal
+	}
+
+	now := time.Now()
+	if err = k.factory.KubeConfig().InternalUpdate(ctx, kid, map[string]interface{}{
+		"revoked":    true,
+		"revoked_at": &now,
+		"config":     "",
+	}); err != nil {
+		klog.Errorf("failed to revoke kubeconfig(%d): %v", kid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (k *kubeConfig) Get(ctx context.Context, kid int64) (*types.KubeConfig, error) {
+	object, err := k.get(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+	if object.Revoked {
+		return nil, errors.ErrKubeConfigRevoked
+	}
+	k.auditAccess(ctx, *object)
+	return k.model2Type(object, true), nil
+}
+
+func (k *kubeConfig) get(ctx context.Context, kid int64) (*model.KubeConfig, error) {
+	object, err := k.factory.KubeConfig().Get(ctx, kid)
+	if err != nil {
+		klog.Errorf("failed to get kubeconfig(%d): %v", kid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrKubeConfigNotFound
+	}
+	return object, nil
+}
+
+// List 仅返回有权限的 kubeconfig，返回明文时需要单独的 kubeconfigsecrets 读权限
+func (k *kubeConfig) List(ctx context.Context, opts types.ListKubeConfigOptions) (interface{}, error) {
+	if opts.ShowConfig {
+		if err := k.canShowConfig(ctx); err != nil {
+			return nil, err
+		}
+	}
+	dbOpts := ctrlutil.MakeDbOptions(ctx)
+	if opts.UserId != 0 {
+		dbOpts = append(dbOpts, db.WithUserId(opts.UserId))
+	}
+	return k.list(ctx, opts, dbOpts...)
+}
+
+// canShowConfig 校验用户查看其他用户 kubeconfig 明文的权限，策略已由鉴权中间件加载
+func (k *kubeConfig) canShowConfig(ctx context.Context) error {
+	if k.cc.Default.Mode.InDebug() {
+		return nil
+	}
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return errors.ErrForbidden
+	}
+	ok, err := k.enforcer.Enforce(user.Name, model.ObjectKubeConfigSecret.String(), "", model.OpRead.String())
+	if err != nil {
+		klog.Errorf("failed to enforce user(%s) kubeconfig secrets permission: %v", user.Name, err)
+		return errors.ErrServerInternal
+	}
+	if !ok {
+		return errors.ErrForbidden
+	}
+	return nil
+}
+
+func (k *kubeConfig) ListMine(ctx context.Context, opts types.ListKubeConfigOptions) (interface{}, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return k.list(ctx, opts, db.WithUserId(user.Id))
+}
+
+func (k *kubeConfig) list(ctx context.Context, opts types.ListKubeConfigOptions, dbOpts ...db.Options) (interface{}, error) {
+	if len(opts.Cluster) != 0 {
+		dbOpts = append(dbOpts, db.WithCluster(opts.Cluster))
+	}
+
+	var total int64
+	listOpts := append(dbOpts, db.WithOrderByDesc())
+	if opts.IsPaged() {
+		var err error
+		if total, err = k.factory.KubeConfig().Count(ctx, dbOpts...); err != nil {
+			klog.Errorf("failed to count kubeconfigs: %v", err)
+			return nil, errors.ErrServerInternal
+		}
+		listOpts = append(listOpts, db.WithOffset((opts.Page-1)*opts.Limit), db.WithLimit(opts.Limit))
+	}
+	objects, err := k.factory.KubeConfig().List(ctx, listOpts...)
+	if err != nil {
+		klog.Errorf("failed to list kubeconfigs: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	if opts.ShowConfig {
+		k.auditAccess(ctx, objects...)
+	}
+
+	kubeConfigs := make([]types.KubeConfig, len(objects))
+	for i, object := range objects {
+		kubeConfigs[i] = *k.model2Type(&object, opts.ShowConfig)
+	}
+	if !opts.IsPaged() {
+		return kubeConfigs, nil
+	}
+	return types.PageResponse{
+		PageRequest: opts.PageRequest,
+		Total:       int(total),
+		Items:       kubeConfigs,
+	}, nil
+}
+
+func (k *kubeConfig) GetPolicy(ctx context.Context) (*types.KubeConfigPolicy, error) {
+	object, err := k.factory.Setting().Get(ctx, model.SettingKubeConfigPolicy)
+	if err != nil {
+		klog.Errorf("failed to get kubeconfig policy: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	policy := defaultPolicy
+	if object == nil {
+		return &policy, nil
+	}
+	if err = json.Unmarshal([]byte(object.Value), &policy); err != nil {
+		klog.Errorf("failed to unmarshal kubeconfig policy: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	return &policy, nil
+}
+
+func (k *kubeConfig) UpdatePolicy(ctx context.Context, req *types.UpdateKubeConfigPolicyRequest) error {
+	data, err := json.Marshal(types.KubeConfigPolicy{
+		Clusters:     req.Clusters,
+		ClusterRoles: req.ClusterRoles,
+		MaxTTL:       req.MaxTTL,
+	})
+	if err != nil {
+		return err
+	}
+	if err = k.factory.Setting().Set(ctx, model.SettingKubeConfigPolicy, string(data)); err != nil {
+		klog.Errorf("failed to update kubeconfig policy: %v", err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (k *kubeConfig) model2Type(o *model.KubeConfig, withConfig bool) *types.KubeConfig {
+	kc := &types.KubeConfig{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		UserId:              o.UserId,
+		Cluster:             o.Cluster,
+		ServiceAccount:      o.ServiceAccount,
+		Namespace:           o.Namespace,
+		ClusterRole:         o.ClusterRole,
+		Role:                o.Role,
+		Namespaced:          o.Namespaced,
+		Server:              o.Server,
+		ContextName:         o.ContextName,
+		CredentialType:      o.CredentialType,
+		CertificateUser:     o.CertificateUser,
+		ExpirationTimestamp: o.ExpirationTimestamp,
+		LastRotated:         o.LastRotated,
+		Revoked:             o.Revoked,
+		RevokedAt:           o.RevokedAt,
+	}
+	// 自动轮换在过期前的轮换窗口内执行，证书凭证不轮换
+	if window := k.cc.KubeConfigRotation.WindowDuration(); window != 0 && !o.Revoked && o.CredentialType != model.CredentialTypeCertificate && o.ExpirationTimestamp.After(time.Now()) {
+		next := o.ExpirationTimestamp.Add(-window)
+		kc.NextRotation = &next
+	}
+	if len(o.Groups) != 0 {
+		kc.Groups = strings.Split(o.Groups, ",")
+	}
+	if withConfig && !o.Revoked {
+		kc.Config = o.Config
+	}
+	return kc
+}
+
+func NewKubeConfig(cfg config.Config, f db.ShareDaoFactory, e *casbin.SyncedEnforcer, c cluster.Interface) *kubeConfig {
+	return &kubeConfig{
+		cc:            cfg,
+		factory:       f,
+		enforcer:      e,
+		clusterGetter: c,
+	}
+}
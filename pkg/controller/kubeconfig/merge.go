@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	kubeconfigutil "github.com/caoyingjunz/pixiu/pkg/util/kubeconfig"
+)
+
+// GetMerged 合并当前用户在各集群中有效的 kubeconfig，每个集群一个 context，同一集群存在多个时使用最新签发的
+// context 以集群命名，签发时自定义了名称的保留自定义的名称，认证信息以 用户名@集群名 命名，避免不同集群的认证信息冲突
+func (k *kubeConfig) GetMerged(ctx context.Context) ([]byte, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := k.factory.KubeConfig().List(ctx, db.WithUserId(user.Id), db.WithNotRevoked(), db.WithOrderByDesc())
+	if err != nil {
+		klog.Errorf("failed to list user(%d) kubeconfigs: %v", user.Id, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	merged := clientcmdapi.NewConfig()
+	used := make([]model.KubeConfig, 0)
+	clusters := sets.NewString()
+	now := time.Now()
+	for _, object := range objects {
+		if object.ExpirationTimestamp.Before(now) {
+			continue
+		}
+		if clusters.Has(object.Cluster) {
+			continue
+		}
+		contextName := object.Cluster
+		if len(object.ContextName) != 0 {
+			contextName = object.ContextName
+		}
+		if _, ok := merged.Contexts[contextName]; ok {
+			continue
+		}
+		cfg, err := clientcmd.Load([]byte(object.Config))
+		if err != nil {
+			klog.Warningf("failed to load kubeconfig(%d): %v", object.Id, err)
+			continue
+		}
+		current, ok := cfg.Contexts[cfg.CurrentContext]
+		if !ok {
+			continue
+		}
+		cluster, ok := cfg.Clusters[current.Cluster]
+		if !ok {
+			continue
+		}
+		authInfo, ok := cfg.AuthInfos[current.AuthInfo]
+		if !ok {
+			continue
+		}
+
+		authName := user.Name + "@" + object.Cluster
+		merged.Clusters[object.Cluster] = cluster
+		merged.AuthInfos[authName] = authInfo
+		merged.Contexts[contextName] = &clientcmdapi.Context{Cluster: object.Cluster, AuthInfo: authName, Namespace: current.Namespace}
+		if len(merged.CurrentContext) == 0 {
+			merged.CurrentContext = contextName
+		}
+		clusters.Insert(object.Cluster)
+		used = append(used, object)
+	}
+	if len(used) == 0 {
+		return nil, errors.ErrKubeConfigNotFound
+	}
+
+	k.auditAccess(ctx, used...)
+	return kubeconfigutil.Write(merged)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	KindServiceAccount     = "ServiceAccount"
+	KindClusterRoleBinding = "ClusterRoleBinding"
+	KindRoleBinding        = "RoleBinding"
+
+	// 刚创建的对象可能尚未写入记录，不视为残留
+	orphanGracePeriod = 5 * time.Minute
+)
+
+// ListOrphans 对比集群中带有 kubeconfig 标签的 ServiceAccount 和绑定关系与数据库中的记录，
+// 没有对应记录或者记录已被吊销的对象视为删除时遗留的残留对象
+func (k *kubeConfig) ListOrphans(ctx context.Context, cluster string) ([]types.KubeConfigOrphan, error) {
+	cs, err := k.clusterGetter.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	return k.reconcile(ctx, cs, cluster)
+}
+
+// PurgeOrphans 删除残留对象，返回已删除的对象，单个对象删除失败时跳过
+func (k *kubeConfig) PurgeOrphans(ctx context.Context, cluster string) ([]types.KubeConfigOrphan, error) {
+	cs, err := k.clusterGetter.GetClusterSetByName(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	orphans, err := k.reconcile(ctx, cs, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	purged := make([]types.KubeConfigOrphan, 0, len(orphans))
+	for _, orphan := range orphans {
+		switch orphan.Kind {
+		case KindServiceAccount:
+			err = cs.Client.CoreV1().ServiceAccounts(orphan.Namespace).Delete(ctx, orphan.Name, metav1.DeleteOptions{})
+		case KindClusterRoleBinding:
+			err = cs.Client.RbacV1().ClusterRoleBindings().Delete(ctx, orphan.Name, metav1.DeleteOptions{})
+		case KindRoleBinding:
+			err = cs.Client.RbacV1().RoleBindings(orphan.Namespace).Delete(ctx, orphan.Name, metav1.DeleteOptions{})
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("failed to purge orphaned %s %s/%s in cluster(%s): %v", orphan.Kind, orphan.Namespace, orphan.Name, cluster, err)
+			continue
+		}
+		purged = append(purged, orphan)
+	}
+	return purged, nil
+}
+
+func (k *kubeConfig) reconcile(ctx context.Context, cs client.ClusterSet, cluster string) ([]types.KubeConfigOrphan, error) {
+	objects, err := k.factory.KubeConfig().List(ctx, db.WithCluster(cluster), db.WithNotRevoked())
+	if err != nil {
+		klog.Errorf("failed to list cluster(%s) kubeconfigs: %v", cluster, err)
+		return nil, errors.ErrServerInternal
+	}
+	// ServiceAccount 和绑定关系与记录中的 service_account 同名
+	names := sets.NewString()
+	for _, object := range objects {
+		names.Insert(object.ServiceAccount)
+	}
+
+	opts := metav1.ListOptions{LabelSelector: kubeConfigLabelKey}
+	serviceAccounts, err := cs.Client.CoreV1().ServiceAccounts(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	clusterRoleBindings, err := cs.Client.RbacV1().ClusterRoleBindings().List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	roleBindings, err := cs.Client.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := make([]types.KubeConfigOrphan, 0)
+	deadline := time.Now().Add(-orphanGracePeriod)
+	add := func(kind string, meta metav1.ObjectMeta) {
+		if names.Has(meta.Name) || meta.CreationTimestamp.After(deadline) {
+			return
+		}
+		orphans = append(orphans, types.KubeConfigOrphan{
+			Cluster:           cluster,
+			Kind:              kind,
+			Namespace:         meta.Namespace,
+			Name:              meta.Name,
+			User:              meta.Annotations[userAnnotation],
+			CreationTimestamp: meta.CreationTimestamp.Time,
+		})
+	}
+	for _, sa := range serviceAccounts.Items {
+		add(KindServiceAccount, sa.ObjectMeta)
+	}
+	for _, crb := range clusterRoleBindings.Items {
+		add(KindClusterRoleBinding, crb.ObjectMeta)
+	}
+	for _, rb := range roleBindings.Items {
+		add(KindRoleBinding, rb.ObjectMeta)
+	}
+	return orphans, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// 每个集群同时签发的最大数量，避免批量签发时压垮 API server
+	clusterConcurrency = 3
+)
+
+// 签发时访问 API server 失败的重试间隔，共尝试 4 次
+var issueBackoff = wait.Backoff{
+	Steps:    4,
+	Duration: 200 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// clusterLimiter 限制每个集群同时签发的数量，所有请求共享
+var clusterLimiter = struct {
+	sync.Mutex
+	tokens map[string]chan struct{}
+}{tokens: make(map[string]chan struct{})}
+
+// acquireCluster 等待集群的签发配额，返回释放配额的函数
+func acquireCluster(cluster string) func() {
+	clusterLimiter.Lock()
+	tokens, ok := clusterLimiter.tokens[cluster]
+	if !ok {
+		tokens = make(chan struct{}, clusterConcurrency)
+		clusterLimiter.tokens[cluster] = tokens
+	}
+	clusterLimiter.Unlock()
+
+	tokens <- struct{}{}
+	return func() { <-tokens }
+}
+
+// isRetriable API server 限流，超时或者暂时不可用时重试
+func isRetriable(err error) bool {
+	return apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err)
+}
+
+// withRetry 对创建类请求重试，上一次请求超时但实际已创建成功时，重试返回的 AlreadyExists 视为成功
+func withRetry(fn func() error) error {
+	return retry.OnError(issueBackoff, isRetriable, func() error {
+		err := fn()
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	})
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"context"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	verifyTimeout = 10 * time.Second
+)
+
+// withVerification 返回签发的 kubeconfig 以及校验结果
+func (k *kubeConfig) withVerification(ctx context.Context, object *model.KubeConfig) *types.KubeConfig {
+	kc := k.model2Type(object, true)
+	kc.Verification = verifyKubeConfig(ctx, object)
+	return kc
+}
+
+// verifyKubeConfig 使用生成的 kubeconfig 获取集群版本并通过 SelfSubjectAccessReview 校验权限
+// 校验失败不影响签发，指定的 server 可能仅在用户的网络中可以访问
+func verifyKubeConfig(ctx context.Context, object *model.KubeConfig) *types.KubeConfigVerification {
+	result := &types.KubeConfigVerification{}
+	config, err := clientcmd.RESTConfigFromKubeConfig([]byte(object.Config))
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	config.Timeout = verifyTimeout
+	clientSet, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	version, err := clientSet.Discovery().ServerVersion()
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Reachable = true
+	result.ServerVersion = version.GitVersion
+
+	namespace := metav1.NamespaceDefault
+	if object.Namespaced {
+		namespace = object.Namespace
+	}
+	review, err := clientSet.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "list",
+				Resource:  "pods",
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Allowed = review.Status.Allowed
+	if !review.Status.Allowed {
+		result.Message = review.Status.Reason
+	}
+	return result
+}
@@ -0,0 +1,354 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	ctrlutil "github.com/caoyingjunz/pixiu/pkg/controller/util"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	sessionutil "github.com/caoyingjunz/pixiu/pkg/util/session"
+)
+
+const (
+	// 会话的最长时间，pixiu 异常退出时由 kubelet 终止遗留的 pod
+	sessionDeadline int64 = 2 * 60 * 60
+	podReadyTimeout       = 2 * time.Minute
+
+	// 记录的终端输入上限
+	maxInputSize = 64 * 1024
+
+	sessionLabelKey = "pixiu.io/kubectl-session"
+	userAnnotation  = "pixiu.io/kubectl-user"
+)
+
+type KubectlGetter interface {
+	Kubectl(cluster string) Interface
+}
+
+type Interface interface {
+	// Shell 在集群中启动预装 kubectl 的临时 pod，并通过 websocket 连接到终端
+	// pod 使用的 ServiceAccount 根据用户对集群的授权绑定 ClusterRole，会话结束后清理
+	Shell(ctx context.Context, w http.ResponseWriter, r *http.Request) error
+	// ListSessions 获取会话记录，普通用户仅能获取自己的会话
+	ListSessions(ctx context.Context) ([]types.KubectlSession, error)
+}
+
+type kubectl struct {
+	cc       config.Config
+	factory  db.ShareDaoFactory
+	enforcer *casbin.SyncedEnforcer
+	cluster  string
+
+	clusterGetter cluster.Interface
+}
+
+// clusterRoles 用户对集群的授权对应的 ClusterRole，按权限从高到低匹配
+var clusterRoles = []struct {
+	op   model.Operation
+	role string
+}{
+	{op: model.OpAll, role: "cluster-admin"},
+	{op: model.OpUpdate, role: "edit"},
+	{op: model.OpRead, role: "view"},
+}
+
+// clusterRoleFor 根据用户对集群的授权获取 pod 绑定的 ClusterRole，没有集群的读权限时拒绝
+// 策略已由鉴权中间件加载，debug 模式下不鉴权，与其他请求一致拥有全部权限
+func (k *kubectl) clusterRoleFor(ctx context.Context, user *model.User) (string, error) {
+	if k.cc.Default.Mode.InDebug() {
+		return "cluster-admin", nil
+	}
+	for _, cr := range clusterRoles {
+		err := ctrlutil.EnforceCluster(ctx, k.factory, k.enforcer, k.cluster, model.ObjectCluster, cr.op)
+		if err == nil {
+			return cr.role, nil
+		}
+		if err != errors.ErrForbidden {
+			klog.Errorf("failed to enforce user(%s) permission on cluster(%s): %v", user.Name, k.cluster, err)
+			return "", err
+		}
+	}
+	return "", errors.ErrForbidden
+}
+
+func (k *kubectl) Shell(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return err
+	}
+	role, err := k.clusterRoleFor(ctx, user)
+	if err != nil {
+		return err
+	}
+	cs, err := k.clusterGetter.GetClusterSetByName(ctx, k.cluster)
+	if err != nil {
+		klog.Errorf("failed to get cluster(%s) client set: %v", k.cluster, err)
+		return err
+	}
+
+	session, err := types.NewTerminalSession(w, r)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = session.Close()
+	}()
+
+	// 连接已升级为 websocket，后续的错误直接输出到终端
+	name := "pixiu-kubectl-" + utilrand.String(8)
+	defer k.cleanup(cs, name)
+
+	_, _ = session.Write([]byte(fmt.Sprintf("starting kubectl pod %s with role %s...\r\n", name, role)))
+	if err = k.prepare(ctx, cs, name, user.Name, role); err != nil {
+		klog.Errorf("failed to prepare kubectl pod %s in cluster(%s): %v", name, k.cluster, err)
+		_, _ = session.Write([]byte("start kubectl pod failed, " + err.Error()))
+		return nil
+	}
+
+	object, err := k.factory.KubectlSession().Create(ctx, &model.KubectlSession{
+		Cluster:     k.cluster,
+		User:        user.Name,
+		Pod:         name,
+		ClusterRole: role,
+		StartTime:   time.Now(),
+	})
+	if err != nil {
+		klog.Errorf("failed to record kubectl session: %v", err)
+		_, _ = session.Write([]byte("record kubectl session failed"))
+		return nil
+	}
+
+	tracked := httputils.NewSessionFromRequest(ctx, sessionutil.KindKubectl)
+	tracked.Cluster = k.cluster
+	tracked.Namespace = k.cc.Kubectl.Namespace
+	tracked.Target = name
+	tracked.Metadata = map[string]string{"cluster_role": role, "record_id": fmt.Sprintf("%d", object.Id)}
+	defer sessionutil.Track(tracked)()
+
+	rec := &recorder{TerminalSession: session}
+	defer func() {
+		if err := k.factory.KubectlSession().Finish(context.TODO(), object.Id, time.Now(), rec.input.String()); err != nil {
+			klog.Errorf("failed to finish kubectl session(%d): %v", object.Id, err)
+		}
+	}()
+
+	req := cs.Client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(name).
+		Namespace(k.cc.Kubectl.Namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Command: []string{"/bin/bash"},
+			Stderr:  true,
+			Stdin:   true,
+			Stdout:  true,
+			TTY:     true,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(cs.Config, "POST", req.URL())
+	if err != nil {
+		_, _ = session.Write([]byte("exec kubectl pod failed, " + err.Error()))
+		return nil
+	}
+	if err = executor.Stream(remotecommand.StreamOptions{
+		Stdout:            session,
+		Stdin:             rec,
+		Stderr:            session,
+		TerminalSizeQueue: session,
+		Tty:               true,
+	}); err != nil {
+		_, _ = session.Write([]byte("exec kubectl pod failed, " + err.Error()))
+		session.Done()
+	}
+
+	return nil
+}
+
+// prepare 创建 ServiceAccount，ClusterRoleBinding 和 kubectl pod，并等待 pod 运行
+func (k *kubectl) prepare(ctx context.Context, cs client.ClusterSet, name string, userName string, role string) error {
+	namespace := k.cc.Kubectl.Namespace
+	if _, err := cs.Client.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace},
+	}, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Labels:      map[string]string{sessionLabelKey: name},
+		Annotations: map[string]string{userAnnotation: userName},
+	}
+	if _, err := cs.Client.CoreV1().ServiceAccounts(namespace).Create(ctx, &corev1.ServiceAccount{ObjectMeta: meta}, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+	if _, err := cs.Client.RbacV1().ClusterRoleBindings().Create(ctx, &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      meta.Labels,
+			Annotations: meta.Annotations,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     role,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      name,
+			Namespace: namespace,
+		}},
+	}, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	deadline := sessionDeadline
+	if _, err := cs.Client.CoreV1().Pods(namespace).Create(ctx, &corev1.Pod{
+		ObjectMeta: meta,
+		Spec: corev1.PodSpec{
+			ServiceAccountName:    name,
+			RestartPolicy:         corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds: &deadline,
+			Containers: []corev1.Container{{
+				Name:    "kubectl",
+				Image:   k.cc.Kubectl.Image,
+				Command: []string{"sleep", fmt.Sprintf("%d", sessionDeadline)},
+			}},
+		},
+	}, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	return wait.PollImmediate(time.Second, podReadyTimeout, func() (bool, error) {
+		pod, err := cs.Client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodFailed, corev1.PodSucceeded:
+			return false, fmt.Errorf("pod %s is %s", name, pod.Status.Phase)
+		}
+		return false, nil
+	})
+}
+
+// cleanup 清理会话创建的对象，请求的 context 可能已经取消，因此使用独立的 context
+func (k *kubectl) cleanup(cs client.ClusterSet, name string) {
+	ctx := context.TODO()
+	namespace := k.cc.Kubectl.Namespace
+
+	if err := cs.Client.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("failed to delete kubectl pod %s/%s: %v", namespace, name, err)
+	}
+	if err := cs.Client.RbacV1().ClusterRoleBindings().Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("failed to delete kubectl clusterRoleBinding %s: %v", name, err)
+	}
+	if err := cs.Client.CoreV1().ServiceAccounts(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("failed to delete kubectl serviceAccount %s/%s: %v", namespace, name, err)
+	}
+}
+
+func (k *kubectl) ListSessions(ctx context.Context) ([]types.KubectlSession, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []db.Options{db.WithCluster(k.cluster), db.WithOrderByDesc()}
+	if user.Role == model.RoleUser {
+		opts = append(opts, db.WithUser(user.Name))
+	}
+	objects, err := k.factory.KubectlSession().List(ctx, opts...)
+	if err != nil {
+		klog.Errorf("failed to list cluster(%s) kubectl sessions: %v", k.cluster, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	sessions := make([]types.KubectlSession, len(objects))
+	for i, object := range objects {
+		sessions[i] = *k.model2Type(&object)
+	}
+	return sessions, nil
+}
+
+func (k *kubectl) model2Type(o *model.KubectlSession) *types.KubectlSession {
+	return &types.KubectlSession{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Cluster:     o.Cluster,
+		User:        o.User,
+		Pod:         o.Pod,
+		ClusterRole: o.ClusterRole,
+		StartTime:   o.StartTime,
+		EndTime:     o.EndTime,
+		Input:       o.Input,
+	}
+}
+
+// recorder 记录终端的输入，用于会话审计
+type recorder struct {
+	*types.TerminalSession
+	input bytes.Buffer
+}
+
+func (r *recorder) Read(p []byte) (int, error) {
+	n, err := r.TerminalSession.Read(p)
+	if n > 0 && r.input.Len() < maxInputSize {
+		r.input.Write(p[:n])
+	}
+	return n, err
+}
+
+func NewKubectl(cfg config.Config, f db.ShareDaoFactory, e *casbin.SyncedEnforcer, clusterName string, c cluster.Interface) *kubectl {
+	return &kubectl{
+		cc:            cfg,
+		factory:       f,
+		enforcer:      e,
+		cluster:       clusterName,
+		clusterGetter: c,
+	}
+}
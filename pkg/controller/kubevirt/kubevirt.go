@@ -0,0 +1,303 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubevirt
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	"github.com/caoyingjunz/pixiu/pkg/util"
+)
+
+const (
+	subresourcesGroupVersion = "subresources.kubevirt.io/v1"
+	// KubeVirt console 和 vnc 的 websocket 子协议
+	plainSubprotocol = "plain.kubevirt.io"
+)
+
+var (
+	virtualMachineGVR         = schema.GroupVersionResource{Group: "kubevirt.io", Version: "v1", Resource: "virtualmachines"}
+	virtualMachineInstanceGVR = schema.GroupVersionResource{Group: "kubevirt.io", Version: "v1", Resource: "virtualmachineinstances"}
+)
+
+type KubeVirtGetter interface {
+	KubeVirt(cluster string) Interface
+}
+
+// Interface 管理运行 KubeVirt 集群中的虚拟机
+type Interface interface {
+	Get(ctx context.Context, namespace string, name string) (*types.VirtualMachine, error)
+	List(ctx context.Context, namespace string) ([]types.VirtualMachine, error)
+
+	Start(ctx context.Context, namespace string, name string) error
+	Stop(ctx context.Context, namespace string, name string) error
+	Restart(ctx context.Context, namespace string, name string) error
+
+	// Console 将虚拟机的 vnc 或者串口控制台代理到 websocket 连接
+	Console(ctx context.Context, namespace string, name string, opt *types.VirtualMachineConsoleOptions, w http.ResponseWriter, r *http.Request) error
+}
+
+type kubevirt struct {
+	cluster       string
+	clusterGetter cluster.Interface
+}
+
+func (k *kubevirt) clusterSet(ctx context.Context) (client.ClusterSet, error) {
+	return k.clusterGetter.GetClusterSetByName(ctx, k.cluster)
+}
+
+func (k *kubevirt) Get(ctx context.Context, namespace string, name string) (*types.VirtualMachine, error) {
+	cs, err := k.clusterSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	object, err := cs.Dynamic.Resource(virtualMachineGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	vm := parseVirtualMachine(object)
+	// 虚拟机未运行时不存在 vmi
+	if vmi, err := cs.Dynamic.Resource(virtualMachineInstanceGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+		setInstanceStatus(vm, vmi)
+	}
+	return vm, nil
+}
+
+func (k *kubevirt) List(ctx context.Context, namespace string) ([]types.VirtualMachine, error) {
+	cs, err := k.clusterSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := cs.Dynamic.Resource(virtualMachineGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("failed to list virtual machines in %s: %v", namespace, err)
+		return nil, err
+	}
+
+	instances := make(map[string]*unstructured.Unstructured)
+	vmis, err := cs.Dynamic.Resource(virtualMachineInstanceGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("failed to list virtual machine instances in %s: %v", namespace, err)
+	} else {
+		for i := range vmis.Items {
+			vmi := &vmis.Items[i]
+			instances[vmi.GetNamespace()+"/"+vmi.GetName()] = vmi
+		}
+	}
+
+	vms := make([]types.VirtualMachine, len(objects.Items))
+	for i := range objects.Items {
+		vm := parseVirtualMachine(&objects.Items[i])
+		if vmi, ok := instances[vm.Namespace+"/"+vm.Name]; ok {
+			setInstanceStatus(vm, vmi)
+		}
+		vms[i] = *vm
+	}
+	return vms, nil
+}
+
+func (k *kubevirt) Start(ctx context.Context, namespace string, name string) error {
+	return k.doAction(ctx, namespace, name, "start")
+}
+
+func (k *kubevirt) Stop(ctx context.Context, namespace string, name string) error {
+	return k.doAction(ctx, namespace, name, "stop")
+}
+
+func (k *kubevirt) Restart(ctx context.Context, namespace string, name string) error {
+	return k.doAction(ctx, namespace, name, "restart")
+}
+
+// doAction 调用 KubeVirt 的 subresources 接口，等同于 virtctl start/stop/restart
+func (k *kubevirt) doAction(ctx context.Context, namespace string, name string, action string) error {
+	cs, err := k.clusterSet(ctx)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/apis/%s/namespaces/%s/virtualmachines/%s/%s", subresourcesGroupVersion, namespace, name, action)
+	if err = cs.Client.CoreV1().RESTClient().Put().AbsPath(path).Body([]byte("{}")).Do(ctx).Error(); err != nil {
+		klog.Errorf("failed to %s virtual machine %s/%s: %v", action, namespace, name, err)
+		return err
+	}
+	return nil
+}
+
+func (k *kubevirt) Console(ctx context.Context, namespace string, name string, opt *types.VirtualMachineConsoleOptions, w http.ResponseWriter, r *http.Request) error {
+	cs, err := k.clusterSet(ctx)
+	if err != nil {
+		return err
+	}
+
+	subresource := "console"
+	if opt.Type == types.ConsoleTypeVNC {
+		subresource = "vnc"
+	}
+	upstream, err := dialSubresource(ctx, cs.Config, fmt.Sprintf("/apis/%s/namespaces/%s/virtualmachineinstances/%s/%s", subresourcesGroupVersion, namespace, name, subresource))
+	if err != nil {
+		klog.Errorf("failed to connect virtual machine %s/%s %s: %v", namespace, name, subresource, err)
+		return err
+	}
+	defer upstream.Close()
+
+	conn, err := util.BuildWebSocketConnection(w, r)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	klog.Infof("connecting to virtual machine %s/%s %s", namespace, name, subresource)
+
+	// 任意一端断开时关闭两端连接
+	var once sync.Once
+	closeAll := func() {
+		_ = conn.Close()
+		_ = upstream.Close()
+	}
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		copyMessages(upstream, conn)
+		once.Do(closeAll)
+	}()
+	go func() {
+		defer wg.Done()
+		copyMessages(conn, upstream)
+		once.Do(closeAll)
+	}()
+	wg.Wait()
+
+	return nil
+}
+
+// dialSubresource 使用集群的认证信息与 kube-apiserver 建立 websocket 连接
+func dialSubresource(ctx context.Context, config *rest.Config, path string) (*websocket.Conn, error) {
+	u, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "http" {
+		u.Scheme = "ws"
+	} else {
+		u.Scheme = "wss"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+
+	tlsConfig, err := rest.TLSConfigFor(config)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	if len(config.BearerToken) != 0 {
+		header.Set("Authorization", "Bearer "+config.BearerToken)
+	} else if len(config.Username) != 0 {
+		req := &http.Request{Header: header}
+		req.SetBasicAuth(config.Username, config.Password)
+	}
+
+	dialer := &websocket.Dialer{
+		TLSClientConfig: tlsConfig,
+		Subprotocols:    []string{plainSubprotocol},
+		Proxy:           http.ProxyFromEnvironment,
+	}
+	conn, resp, err := dialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("%v: %s", err, resp.Status)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+func copyMessages(dst, src *websocket.Conn) {
+	for {
+		messageType, data, err := src.ReadMessage()
+		if err != nil {
+			return
+		}
+		if err = dst.WriteMessage(messageType, data); err != nil {
+			return
+		}
+	}
+}
+
+func parseVirtualMachine(object *unstructured.Unstructured) *types.VirtualMachine {
+	vm := &types.VirtualMachine{
+		Name:              object.GetName(),
+		Namespace:         object.GetNamespace(),
+		CreationTimestamp: object.GetCreationTimestamp().Time,
+	}
+
+	vm.RunStrategy, _, _ = unstructured.NestedString(object.Object, "spec", "runStrategy")
+	if len(vm.RunStrategy) == 0 {
+		// 旧版本使用 spec.running 控制虚拟机的启停
+		if running, found, _ := unstructured.NestedBool(object.Object, "spec", "running"); found {
+			if running {
+				vm.RunStrategy = "Always"
+			} else {
+				vm.RunStrategy = "Halted"
+			}
+		}
+	}
+	vm.PrintableStatus, _, _ = unstructured.NestedString(object.Object, "status", "printableStatus")
+	vm.Ready, _, _ = unstructured.NestedBool(object.Object, "status", "ready")
+
+	vm.CPU, _, _ = unstructured.NestedInt64(object.Object, "spec", "template", "spec", "domain", "cpu", "cores")
+	vm.Memory, _, _ = unstructured.NestedString(object.Object, "spec", "template", "spec", "domain", "memory", "guest")
+	if len(vm.Memory) == 0 {
+		vm.Memory, _, _ = unstructured.NestedString(object.Object, "spec", "template", "spec", "domain", "resources", "requests", "memory")
+	}
+	return vm
+}
+
+func setInstanceStatus(vm *types.VirtualMachine, vmi *unstructured.Unstructured) {
+	vm.NodeName, _, _ = unstructured.NestedString(vmi.Object, "status", "nodeName")
+
+	interfaces, _, _ := unstructured.NestedSlice(vmi.Object, "status", "interfaces")
+	for _, item := range interfaces {
+		iface, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ip, ok := iface["ipAddress"].(string); ok && len(ip) != 0 {
+			vm.IPs = append(vm.IPs, ip)
+		}
+	}
+}
+
+func NewKubeVirt(clusterName string, c cluster.Interface) *kubevirt {
+	return &kubevirt{
+		cluster:       clusterName,
+		clusterGetter: c,
+	}
+}
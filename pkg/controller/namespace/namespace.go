@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package namespace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/controller/naming"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type NamespacePolicyGetter interface {
+	NamespacePolicy(cluster string) Interface
+}
+
+// Interface 管理命名空间的过期清理策略，过期的命名空间由 jobmanager 的 namespace-cleaner 删除
+type Interface interface {
+	Create(ctx context.Context, namespace string, req *types.CreateNamespacePolicyRequest) (*types.NamespacePolicy, error)
+	Delete(ctx context.Context, namespace string) error
+	Get(ctx context.Context, namespace string) (*types.NamespacePolicy, error)
+	List(ctx context.Context) ([]types.NamespacePolicy, error)
+
+	// Extend 延长命名空间的过期时间
+	Extend(ctx context.Context, namespace string, req *types.ExtendNamespacePolicyRequest) (*types.NamespacePolicy, error)
+}
+
+type namespace struct {
+	factory db.ShareDaoFactory
+	cluster string
+
+	clusterGetter cluster.Interface
+}
+
+func (n *namespace) Create(ctx context.Context, name string, req *types.CreateNamespacePolicyRequest) (*types.NamespacePolicy, error) {
+	ttl, err := parseTTL(req.TTL)
+	if err != nil {
+		return nil, err
+	}
+	old, err := n.factory.NamespacePolicy().Get(ctx, n.cluster, name)
+	if err != nil {
+		klog.Errorf("failed to get namespace(%s) policy: %v", name, err)
+		return nil, errors.ErrServerInternal
+	}
+	if old != nil {
+		return nil, errors.ErrNamespacePolicyExists
+	}
+	if err = n.ensureNamespace(ctx, name, req.CreateNamespace); err != nil {
+		return nil, err
+	}
+
+	object := &model.NamespacePolicy{
+		Cluster:    n.cluster,
+		Namespace:  name,
+		ExpireTime: time.Now().Add(ttl),
+	}
+	if user, err := httputils.GetUserFromRequest(ctx); err == nil {
+		object.UserId = user.Id
+	}
+	if req.Description != nil {
+		object.Description = *req.Description
+	}
+	if object, err = n.factory.NamespacePolicy().Create(ctx, object); err != nil {
+		klog.Errorf("failed to create namespace(%s) policy: %v", name, err)
+		return nil, errors.ErrServerInternal
+	}
+	return model2Type(object), nil
+}
+
+// ensureNamespace 检查命名空间是否存在，不存在时按需创建
+func (n *namespace) ensureNamespace(ctx context.Context, name string, create bool) error {
+	cs, err := n.clusterGetter.GetClusterSetByName(ctx, n.cluster)
+	if err != nil {
+		return err
+	}
+	_, err = cs.Client.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) || !create {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+	if err = naming.NewNaming(n.factory).Validate(ctx, model.NamingResourceNamespace, name); err != nil {
+		return err
+	}
+
+	if _, err = cs.Client.CoreV1().Namespaces().Create(ctx, &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{types.EphemeralLabelKey: "true"},
+		},
+	}, metav1.CreateOptions{}); err != nil {
+		klog.Errorf("failed to create namespace %s: %v", name, err)
+		return err
+	}
+	return nil
+}
+
+func (n *namespace) Delete(ctx context.Context, name string) error {
+	object, err := n.get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err = n.factory.NamespacePolicy().Delete(ctx, object.Id); err != nil {
+		klog.Errorf("failed to delete namespace(%s) policy: %v", name, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (n *namespace) Get(ctx context.Context, name string) (*types.NamespacePolicy, error) {
+	object, err := n.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return model2Type(object), nil
+}
+
+func (n *namespace) get(ctx context.Context, name string) (*model.NamespacePolicy, error) {
+	object, err := n.factory.NamespacePolicy().Get(ctx, n.cluster, name)
+	if err != nil {
+		klog.Errorf("failed to get namespace(%s) policy: %v", name, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrNamespacePolicyNotFound
+	}
+	return object, nil
+}
+
+func (n *namespace) List(ctx context.Context) ([]types.NamespacePolicy, error) {
+	objects, err := n.factory.NamespacePolicy().List(ctx, db.WithCluster(n.cluster))
+	if err != nil {
+		klog.Errorf("failed to list cluster(%s) namespace policies: %v", n.cluster, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	policies := make([]types.NamespacePolicy, len(objects))
+	for i, object := range objects {
+		policies[i] = *model2Type(&object)
+	}
+	return policies, nil
+}
+
+func (n *namespace) Extend(ctx context.Context, name string, req *types.ExtendNamespacePolicyRequest) (*types.NamespacePolicy, error) {
+	ttl, err := parseTTL(req.TTL)
+	if err != nil {
+		return nil, err
+	}
+	object, err := n.get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	expireTime := object.ExpireTime
+	if now := time.Now(); expireTime.Before(now) {
+		expireTime = now
+	}
+	expireTime = expireTime.Add(ttl)
+	if err = n.factory.NamespacePolicy().Update(ctx, object.Id, *req.ResourceVersion, map[string]interface{}{
+		"expire_time": expireTime,
+		"warned":      false,
+	}); err != nil {
+		klog.Errorf("failed to extend namespace(%s) policy: %v", name, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	return n.Get(ctx, name)
+}
+
+func parseTTL(s string) (time.Duration, error) {
+	ttl, err := time.ParseDuration(s)
+	if err != nil || ttl <= 0 {
+		return 0, errors.NewError(fmt.Errorf("invalid ttl %q", s), http.StatusBadRequest)
+	}
+	return ttl, nil
+}
+
+func model2Type(o *model.NamespacePolicy) *types.NamespacePolicy {
+	return &types.NamespacePolicy{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Cluster:     o.Cluster,
+		Namespace:   o.Namespace,
+		ExpireTime:  o.ExpireTime,
+		Warned:      o.Warned,
+		Description: o.Description,
+		UserId:      o.UserId,
+	}
+}
+
+func NewNamespace(f db.ShareDaoFactory, clusterName string, c cluster.Interface) *namespace {
+	return &namespace{
+		factory:       f,
+		cluster:       clusterName,
+		clusterGetter: c,
+	}
+}
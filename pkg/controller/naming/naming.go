@@ -0,0 +1,263 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package naming
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type NamingPolicyGetter interface {
+	NamingPolicy() Interface
+}
+
+// Interface 管理资源的命名规范，namespaces，deployments，plans 和 clouds 创建时校验名称
+type Interface interface {
+	Create(ctx context.Context, req *types.CreateNamingPolicyRequest) error
+	Update(ctx context.Context, pid int64, req *types.UpdateNamingPolicyRequest) error
+	Delete(ctx context.Context, pid int64) error
+	Get(ctx context.Context, pid int64) (*types.NamingPolicy, error)
+	List(ctx context.Context, opts types.ListNamingPolicyOptions) ([]types.NamingPolicy, error)
+
+	// Test 测试名称是否符合指定租户的命名规范
+	Test(ctx context.Context, req *types.TestNamingPolicyRequest) (*types.NamingTestResult, error)
+	// Validate 使用当前用户所属租户的命名规范校验名称，不符合时返回错误
+	Validate(ctx context.Context, resourceType string, name string) error
+}
+
+type naming struct {
+	factory db.ShareDaoFactory
+}
+
+func (n *naming) Create(ctx context.Context, req *types.CreateNamingPolicyRequest) error {
+	if err := validatePattern(req.Pattern); err != nil {
+		return err
+	}
+	if req.TenantId != 0 {
+		tenant, err := n.factory.Tenant().Get(ctx, req.TenantId)
+		if err != nil {
+			klog.Errorf("failed to get tenant(%d): %v", req.TenantId, err)
+			return errors.ErrServerInternal
+		}
+		if tenant == nil {
+			return errors.ErrTenantNotFound
+		}
+	}
+	old, err := n.factory.NamingPolicy().GetByResource(ctx, req.TenantId, req.ResourceType)
+	if err != nil {
+		klog.Errorf("failed to get tenant(%d) %s naming policy: %v", req.TenantId, req.ResourceType, err)
+		return errors.ErrServerInternal
+	}
+	if old != nil {
+		return errors.ErrNamingPolicyExists
+	}
+
+	if _, err = n.factory.NamingPolicy().Create(ctx, &model.NamingPolicy{
+		TenantId:     req.TenantId,
+		ResourceType: req.ResourceType,
+		Pattern:      req.Pattern,
+		Description:  req.Description,
+	}); err != nil {
+		klog.Errorf("failed to create tenant(%d) %s naming policy: %v", req.TenantId, req.ResourceType, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (n *naming) Update(ctx context.Context, pid int64, req *types.UpdateNamingPolicyRequest) error {
+	if _, err := n.get(ctx, pid); err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Pattern != nil {
+		if err := validatePattern(*req.Pattern); err != nil {
+			return err
+		}
+		updates["pattern"] = *req.Pattern
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if err := n.factory.NamingPolicy().Update(ctx, pid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update naming policy(%d): %v", pid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (n *naming) Delete(ctx context.Context, pid int64) error {
+	if _, err := n.get(ctx, pid); err != nil {
+		return err
+	}
+	if err := n.factory.NamingPolicy().Delete(ctx, pid); err != nil {
+		klog.Errorf("failed to delete naming policy(%d): %v", pid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (n *naming) Get(ctx context.Context, pid int64) (*types.NamingPolicy, error) {
+	object, err := n.get(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+	return model2Type(object), nil
+}
+
+func (n *naming) get(ctx context.Context, pid int64) (*model.NamingPolicy, error) {
+	object, err := n.factory.NamingPolicy().Get(ctx, pid)
+	if err != nil {
+		klog.Errorf("failed to get naming policy(%d): %v", pid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrNamingPolicyNotFound
+	}
+	return object, nil
+}
+
+func (n *naming) List(ctx context.Context, opts types.ListNamingPolicyOptions) ([]types.NamingPolicy, error) {
+	var dbOpts []db.Options
+	if opts.TenantId != nil {
+		dbOpts = append(dbOpts, db.WithTenantId(*opts.TenantId))
+	}
+	if len(opts.ResourceType) != 0 {
+		dbOpts = append(dbOpts, db.WithResourceType(opts.ResourceType))
+	}
+	objects, err := n.factory.NamingPolicy().List(ctx, dbOpts...)
+	if err != nil {
+		klog.Errorf("failed to list naming policies: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	policies := make([]types.NamingPolicy, len(objects))
+	for i, object := range objects {
+		policies[i] = *model2Type(&object)
+	}
+	return policies, nil
+}
+
+func (n *naming) Test(ctx context.Context, req *types.TestNamingPolicyRequest) (*types.NamingTestResult, error) {
+	return n.test(ctx, req.TenantId, req.ResourceType, req.Name)
+}
+
+func (n *naming) Validate(ctx context.Context, resourceType string, name string) error {
+	// 管理员以及后台任务使用全局命名规范
+	tenantId, _ := db.TenantFromContext(ctx)
+	result, err := n.test(ctx, tenantId, resourceType, name)
+	if err != nil {
+		return err
+	}
+	if !result.Allowed {
+		return errors.NewError(fmt.Errorf("%s", result.Message), http.StatusBadRequest)
+	}
+	return nil
+}
+
+func (n *naming) test(ctx context.Context, tenantId int64, resourceType string, name string) (*types.NamingTestResult, error) {
+	policy, err := n.getEffectivePolicy(ctx, tenantId, resourceType)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return &types.NamingTestResult{Allowed: true}, nil
+	}
+
+	result := &types.NamingTestResult{
+		Allowed:  true,
+		Pattern:  policy.Pattern,
+		PolicyId: policy.Id,
+	}
+	re, err := compile(policy.Pattern)
+	if err != nil {
+		// 规范在写入时已校验，此处仅记录日志，不阻塞资源的创建
+		klog.Warningf("invalid naming policy(%d) pattern %q: %v", policy.Id, policy.Pattern, err)
+		return result, nil
+	}
+	if !re.MatchString(name) {
+		result.Allowed = false
+		result.Message = fmt.Sprintf("%s 名称 %q 不符合命名规范 %s", resourceType, name, policy.Pattern)
+	}
+	return result, nil
+}
+
+// getEffectivePolicy 优先使用租户的命名规范，不存在时使用全局规范
+func (n *naming) getEffectivePolicy(ctx context.Context, tenantId int64, resourceType string) (*model.NamingPolicy, error) {
+	tenantIds := []int64{0}
+	if tenantId != 0 {
+		tenantIds = []int64{tenantId, 0}
+	}
+	for _, tid := range tenantIds {
+		policy, err := n.factory.NamingPolicy().GetByResource(ctx, tid, resourceType)
+		if err != nil {
+			klog.Errorf("failed to get tenant(%d) %s naming policy: %v", tid, resourceType, err)
+			return nil, errors.ErrServerInternal
+		}
+		if policy != nil {
+			return policy, nil
+		}
+	}
+	return nil, nil
+}
+
+// compile 命名规范需完整匹配名称
+func compile(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + pattern + ")$")
+}
+
+func validatePattern(pattern string) error {
+	if _, err := compile(pattern); err != nil {
+		return errors.NewError(fmt.Errorf("invalid pattern %q: %v", pattern, err), http.StatusBadRequest)
+	}
+	return nil
+}
+
+func model2Type(o *model.NamingPolicy) *types.NamingPolicy {
+	return &types.NamingPolicy{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		TenantId:     o.TenantId,
+		ResourceType: o.ResourceType,
+		Pattern:      o.Pattern,
+		Description:  o.Description,
+	}
+}
+
+func NewNaming(f db.ShareDaoFactory) *naming {
+	return &naming{
+		factory: f,
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	utilerrors "github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+const defaultPageLimit = 20
+
+type NotificationGetter interface {
+	Notification() Interface
+}
+
+// Interface 当前用户的站内通知以及通知渠道设置
+type Interface interface {
+	List(ctx context.Context, opts *types.ListNotificationOptions) (*types.PageResponse, error)
+	// CountUnread 获取未读通知数
+	CountUnread(ctx context.Context) (int64, error)
+	MarkRead(ctx context.Context, req *types.MarkNotificationsReadRequest) error
+	Delete(ctx context.Context, nid int64) error
+
+	GetPreference(ctx context.Context) (*types.NotificationPreference, error)
+	UpdatePreference(ctx context.Context, req *types.UpdateNotificationPreferenceRequest) error
+}
+
+type notification struct {
+	factory db.ShareDaoFactory
+}
+
+func currentUserId(ctx context.Context) (int64, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return 0, errors.NewError(err, http.StatusUnauthorized)
+	}
+	return user.Id, nil
+}
+
+func (n *notification) List(ctx context.Context, opts *types.ListNotificationOptions) (*types.PageResponse, error) {
+	uid, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := db.WithNotificationFilter(opts.Kind, opts.Unread)
+	total, err := n.factory.Notification().Count(ctx, uid, filter)
+	if err != nil {
+		klog.Errorf("failed to count user(%d) notifications: %v", uid, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	page, limit := opts.Page, opts.Limit
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	objects, err := n.factory.Notification().List(ctx, uid, filter, db.WithOrderByDesc(), db.WithOffset((page-1)*limit), db.WithLimit(limit))
+	if err != nil {
+		klog.Errorf("failed to list user(%d) notifications: %v", uid, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	items := make([]types.Notification, len(objects))
+	for i, o := range objects {
+		items[i] = types.Notification{
+			PixiuMeta: types.PixiuMeta{
+				Id:              o.Id,
+				ResourceVersion: o.ResourceVersion,
+			},
+			TimeMeta: types.TimeMeta{
+				GmtCreate:   o.GmtCreate,
+				GmtModified: o.GmtModified,
+			},
+			Kind:    o.Kind,
+			Title:   o.Title,
+			Content: o.Content,
+			Ref:     o.Ref,
+			Read:    o.Read,
+		}
+	}
+	return &types.PageResponse{
+		PageRequest: types.PageRequest{Page: page, Limit: limit},
+		Total:       int(total),
+		Items:       items,
+	}, nil
+}
+
+func (n *notification) CountUnread(ctx context.Context) (int64, error) {
+	uid, err := currentUserId(ctx)
+	if err != nil {
+		return 0, err
+	}
+	total, err := n.factory.Notification().Count(ctx, uid, db.WithNotificationFilter("", true))
+	if err != nil {
+		klog.Errorf("failed to count user(%d) unread notifications: %v", uid, err)
+		return 0, errors.ErrServerInternal
+	}
+	return total, nil
+}
+
+func (n *notification) MarkRead(ctx context.Context, req *types.MarkNotificationsReadRequest) error {
+	uid, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	if err = n.factory.Notification().MarkRead(ctx, uid, req.Ids...); err != nil {
+		klog.Errorf("failed to mark user(%d) notifications read: %v", uid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (n *notification) Delete(ctx context.Context, nid int64) error {
+	uid, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	if err = n.factory.Notification().Delete(ctx, uid, nid); err != nil {
+		if utilerrors.IsRecordNotFound(err) {
+			return errors.ErrNotificationNotFound
+		}
+		klog.Errorf("failed to delete user(%d) notification(%d): %v", uid, nid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (n *notification) GetPreference(ctx context.Context) (*types.NotificationPreference, error) {
+	uid, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	object, err := n.getPreference(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	pref := &types.NotificationPreference{
+		EmailEnabled:   object.EmailEnabled,
+		WebhookEnabled: object.WebhookEnabled,
+		WebhookURL:     object.WebhookURL,
+		MutedKinds:     []string{},
+	}
+	if len(object.MutedKinds) != 0 {
+		pref.MutedKinds = strings.Split(object.MutedKinds, ",")
+	}
+	return pref, nil
+}
+
+// getPreference 未设置时返回默认值，仅开启站内通知
+func (n *notification) getPreference(ctx context.Context, uid int64) (*model.NotificationPreference, error) {
+	object, err := n.factory.Notification().GetPreference(ctx, uid)
+	if err != nil {
+		klog.Errorf("failed to get user(%d) notification preference: %v", uid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		object = &model.NotificationPreference{UserId: uid}
+	}
+	return object, nil
+}
+
+func (n *notification) UpdatePreference(ctx context.Context, req *types.UpdateNotificationPreferenceRequest) error {
+	uid, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	object, err := n.getPreference(ctx, uid)
+	if err != nil {
+		return err
+	}
+
+	if req.EmailEnabled != nil {
+		object.EmailEnabled = *req.EmailEnabled
+	}
+	if req.WebhookEnabled != nil {
+		object.WebhookEnabled = *req.WebhookEnabled
+	}
+	if req.WebhookURL != nil {
+		object.WebhookURL = *req.WebhookURL
+	}
+	if req.MutedKinds != nil {
+		object.MutedKinds = strings.Join(req.MutedKinds, ",")
+	}
+	if object.WebhookEnabled && len(object.WebhookURL) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if err = n.factory.Notification().SavePreference(ctx, object); err != nil {
+		klog.Errorf("failed to save user(%d) notification preference: %v", uid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func NewNotification(f db.ShareDaoFactory) *notification {
+	return &notification{
+		factory: f,
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/controller/helm"
+	"github.com/caoyingjunz/pixiu/pkg/controller/plan"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+)
+
+const (
+	// 删除 CR 前清理平台对象
+	finalizer = "pixiu.io/operator"
+	// 定期重新同步，修复平台中被手动修改的对象
+	resyncPeriod = 10 * time.Minute
+
+	PhaseReady  = "Ready"
+	PhaseFailed = "Failed"
+)
+
+var (
+	cloudGVR       = schema.GroupVersionResource{Group: "pixiu.io", Version: "v1alpha1", Resource: "clouds"}
+	planGVR        = schema.GroupVersionResource{Group: "pixiu.io", Version: "v1alpha1", Resource: "plans"}
+	helmReleaseGVR = schema.GroupVersionResource{Group: "pixiu.io", Version: "v1alpha1", Resource: "helmreleases"}
+)
+
+type OperatorGetter interface {
+	Operator() Interface
+}
+
+// Interface operator 模式，将管理集群中声明的 Cloud，Plan 和 HelmRelease 同步到平台，实现通过 GitOps 管理 pixiu
+type Interface interface {
+	// Run 启动 operator，未开启时直接返回
+	Run(ctx context.Context, workers int) error
+}
+
+// reconciler 将 CR 同步到平台，cleanup 在 CR 删除时清理平台对象
+type reconciler interface {
+	reconcile(ctx context.Context, object *unstructured.Unstructured) error
+	cleanup(ctx context.Context, object *unstructured.Unstructured) error
+}
+
+type operator struct {
+	cc      config.Config
+	factory db.ShareDaoFactory
+
+	clusterGetter cluster.Interface
+	planGetter    plan.Interface
+	helmGetter    helm.Interface
+
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	informer      dynamicinformer.DynamicSharedInformerFactory
+	queue         workqueue.RateLimitingInterface
+	reconcilers   map[schema.GroupVersionResource]reconciler
+}
+
+func (o *operator) Run(ctx context.Context, workers int) error {
+	if !o.cc.Operator.Enable {
+		return nil
+	}
+
+	klog.Infof("starting pixiu operator")
+	restConfig, err := clientcmd.BuildConfigFromFlags("", o.cc.Operator.KubeConfig)
+	if err != nil {
+		return err
+	}
+	if o.kubeClient, err = kubernetes.NewForConfig(restConfig); err != nil {
+		return err
+	}
+	if o.dynamicClient, err = dynamic.NewForConfig(restConfig); err != nil {
+		return err
+	}
+
+	o.queue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "operator")
+	o.reconcilers = map[schema.GroupVersionResource]reconciler{
+		cloudGVR:       &cloudReconciler{o},
+		planGVR:        &planReconciler{o},
+		helmReleaseGVR: &helmReleaseReconciler{o},
+	}
+	o.informer = dynamicinformer.NewFilteredDynamicSharedInformerFactory(o.dynamicClient, resyncPeriod, o.cc.Operator.Namespace, nil)
+	for gvr := range o.reconcilers {
+		o.informer.ForResource(gvr).Informer().AddEventHandler(o.eventHandler(gvr))
+	}
+	o.informer.Start(ctx.Done())
+	o.informer.WaitForCacheSync(ctx.Done())
+
+	for i := 0; i < workers; i++ {
+		go wait.UntilWithContext(ctx, o.worker, time.Second)
+	}
+	return nil
+}
+
+type queueKey struct {
+	gvr schema.GroupVersionResource
+	key string
+}
+
+func (o *operator) eventHandler(gvr schema.GroupVersionResource) cache.ResourceEventHandlerFuncs {
+	enqueue := func(obj interface{}) {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return
+		}
+		o.queue.Add(queueKey{gvr: gvr, key: key})
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(oldObj, newObj interface{}) { enqueue(newObj) },
+		DeleteFunc: enqueue,
+	}
+}
+
+func (o *operator) worker(ctx context.Context) {
+	for o.process(ctx) {
+	}
+}
+
+func (o *operator) process(ctx context.Context) bool {
+	item, quit := o.queue.Get()
+	if quit {
+		return false
+	}
+	defer o.queue.Done(item)
+
+	key := item.(queueKey)
+	if err := o.syncHandler(ctx, key); err != nil {
+		klog.Warningf("failed to sync %s %s: %v", key.gvr.Resource, key.key, err)
+		o.queue.AddRateLimited(item)
+		return true
+	}
+	o.queue.Forget(item)
+	return true
+}
+
+func (o *operator) syncHandler(ctx context.Context, key queueKey) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key.key)
+	if err != nil {
+		return nil
+	}
+	obj, err := o.informer.ForResource(key.gvr).Lister().ByNamespace(namespace).Get(name)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	object := obj.(*unstructured.Unstructured).DeepCopy()
+
+	ctx, err = o.withUser(ctx)
+	if err != nil {
+		return err
+	}
+	r := o.reconcilers[key.gvr]
+	client := o.dynamicClient.Resource(key.gvr).Namespace(namespace)
+
+	// CR 正在删除，清理平台对象后移除 finalizer
+	if object.GetDeletionTimestamp() != nil {
+		if !hasFinalizer(object) {
+			return nil
+		}
+		if err = r.cleanup(ctx, object); err != nil {
+			return err
+		}
+		removeFinalizer(object)
+		_, err = client.Update(ctx, object, metav1.UpdateOptions{})
+		return err
+	}
+	if !hasFinalizer(object) {
+		object.SetFinalizers(append(object.GetFinalizers(), finalizer))
+		// 更新后会再次触发同步
+		_, err = client.Update(ctx, object, metav1.UpdateOptions{})
+		return err
+	}
+
+	syncErr := r.reconcile(ctx, object)
+	if err = o.updateStatus(ctx, client, object, syncErr); err != nil {
+		klog.Warningf("failed to update %s %s status: %v", key.gvr.Resource, key.key, err)
+	}
+	return syncErr
+}
+
+func (o *operator) updateStatus(ctx context.Context, client dynamic.ResourceInterface, object *unstructured.Unstructured, syncErr error) error {
+	status := map[string]interface{}{
+		"phase":              PhaseReady,
+		"message":            "",
+		"observedGeneration": object.GetGeneration(),
+	}
+	if syncErr != nil {
+		status["phase"] = PhaseFailed
+		status["message"] = syncErr.Error()
+	}
+	// 状态未变化时不更新，避免触发新的同步
+	phase, _, _ := unstructured.NestedString(object.Object, "status", "phase")
+	message, _, _ := unstructured.NestedString(object.Object, "status", "message")
+	generation, _, _ := unstructured.NestedInt64(object.Object, "status", "observedGeneration")
+	if phase == status["phase"] && message == status["message"] && generation == object.GetGeneration() {
+		return nil
+	}
+	if err := unstructured.SetNestedMap(object.Object, status, "status"); err != nil {
+		return err
+	}
+	_, err := client.UpdateStatus(ctx, object, metav1.UpdateOptions{})
+	return err
+}
+
+// withUser 以配置的用户身份操作平台对象
+func (o *operator) withUser(ctx context.Context) (context.Context, error) {
+	user, err := o.factory.User().GetUserByName(ctx, o.cc.Operator.User)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("operator user %s not found", o.cc.Operator.User)
+	}
+	return httputils.WithUser(ctx, user), nil
+}
+
+func hasFinalizer(object *unstructured.Unstructured) bool {
+	for _, f := range object.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFinalizer(object *unstructured.Unstructured) {
+	finalizers := make([]string, 0)
+	for _, f := range object.GetFinalizers() {
+		if f != finalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	object.SetFinalizers(finalizers)
+}
+
+func NewOperator(cfg config.Config, f db.ShareDaoFactory, c cluster.Interface, p plan.Interface, h helm.Interface) *operator {
+	return &operator{
+		cc:            cfg,
+		factory:       f,
+		clusterGetter: c,
+		planGetter:    p,
+		helmGetter:    h,
+	}
+}
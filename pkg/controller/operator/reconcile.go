@@ -0,0 +1,264 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const defaultKubeConfigKey = "config"
+
+func decodeSpec(object *unstructured.Unstructured, spec interface{}) error {
+	raw, _, err := unstructured.NestedMap(object.Object, "spec")
+	if err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(raw, spec)
+}
+
+type cloudSpec struct {
+	AliasName           string            `json:"aliasName"`
+	Description         string            `json:"description"`
+	Labels              map[string]string `json:"labels"`
+	KubeConfigSecretRef struct {
+		Name string `json:"name"`
+		Key  string `json:"key"`
+	} `json:"kubeConfigSecretRef"`
+}
+
+// cloudReconciler 将 Cloud 同步为平台中的集群，集群名称为 Cloud 的名称
+type cloudReconciler struct {
+	*operator
+}
+
+func (r *cloudReconciler) reconcile(ctx context.Context, object *unstructured.Unstructured) error {
+	var spec cloudSpec
+	if err := decodeSpec(object, &spec); err != nil {
+		return err
+	}
+	kubeConfig, err := r.getKubeConfig(ctx, object.GetNamespace(), spec)
+	if err != nil {
+		return err
+	}
+
+	name := object.GetName()
+	cluster, err := r.factory.Cluster().GetClusterByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return r.clusterGetter.Create(ctx, &types.CreateClusterRequest{
+			Name:        name,
+			AliasName:   spec.AliasName,
+			KubeConfig:  kubeConfig,
+			Description: spec.Description,
+			Labels:      spec.Labels,
+		})
+	}
+
+	// 已注册集群的 kubeconfig 不支持修改
+	if cluster.KubeConfig != kubeConfig {
+		return fmt.Errorf("kubeconfig of registered cluster %s can not be changed, recreate the cloud instead", name)
+	}
+	var labels types.ClusterLabels
+	if len(cluster.Labels) != 0 {
+		if err = labels.Unmarshal(cluster.Labels); err != nil {
+			return err
+		}
+	}
+	if cluster.AliasName == spec.AliasName && cluster.Description == spec.Description && (len(labels) == 0 && len(spec.Labels) == 0 || reflect.DeepEqual(map[string]string(labels), spec.Labels)) {
+		return nil
+	}
+	cloudLabels := types.ClusterLabels(spec.Labels)
+	return r.clusterGetter.Update(ctx, cluster.Id, &types.UpdateClusterRequest{
+		AliasName:       &spec.AliasName,
+		Description:     &spec.Description,
+		Labels:          &cloudLabels,
+		ResourceVersion: &cluster.ResourceVersion,
+	})
+}
+
+func (r *cloudReconciler) getKubeConfig(ctx context.Context, namespace string, spec cloudSpec) (string, error) {
+	ref := spec.KubeConfigSecretRef
+	if len(ref.Name) == 0 {
+		return "", fmt.Errorf("kubeConfigSecretRef.name is required")
+	}
+	key := ref.Key
+	if len(key) == 0 {
+		key = defaultKubeConfigKey
+	}
+
+	secret, err := r.kubeClient.CoreV1().Secrets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	data, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s/%s", key, namespace, ref.Name)
+	}
+	return string(data), nil
+}
+
+func (r *cloudReconciler) cleanup(ctx context.Context, object *unstructured.Unstructured) error {
+	cluster, err := r.factory.Cluster().GetClusterByName(ctx, object.GetName())
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return nil
+	}
+	return r.clusterGetter.Delete(ctx, cluster.Id)
+}
+
+type planSpec struct {
+	Description string                        `json:"description"`
+	Config      types.CreatePlanConfigRequest `json:"config"`
+	Nodes       []types.CreatePlanNodeRequest `json:"nodes"`
+	// 创建后是否立即启动部署
+	Start bool `json:"start"`
+}
+
+// planReconciler 将 Plan 同步为平台中的部署计划
+type planReconciler struct {
+	*operator
+}
+
+func (r *planReconciler) reconcile(ctx context.Context, object *unstructured.Unstructured) error {
+	var spec planSpec
+	if err := decodeSpec(object, &spec); err != nil {
+		return err
+	}
+
+	name := object.GetName()
+	pid, rv, err := r.getPlan(ctx, name)
+	if err != nil {
+		return err
+	}
+	if pid == 0 {
+		if err = r.planGetter.Create(ctx, &types.CreatePlanRequest{
+			Name:        name,
+			Description: spec.Description,
+			Config:      spec.Config,
+			Nodes:       spec.Nodes,
+		}); err != nil {
+			return err
+		}
+		if !spec.Start {
+			return nil
+		}
+		if pid, _, err = r.getPlan(ctx, name); err != nil {
+			return err
+		}
+		return r.planGetter.Start(ctx, pid)
+	}
+
+	return r.planGetter.Update(ctx, pid, &types.UpdatePlanRequest{
+		Name:            name,
+		ResourceVersion: &rv,
+		Description:     spec.Description,
+		Config:          spec.Config,
+		Nodes:           spec.Nodes,
+	})
+}
+
+// getPlan 获取指定名称的部署计划的 id 和 resource_version，不存在时 id 为 0
+func (r *planReconciler) getPlan(ctx context.Context, name string) (int64, int64, error) {
+	plans, err := r.factory.Plan().List(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, p := range plans {
+		if p.Name == name {
+			return p.Id, p.ResourceVersion, nil
+		}
+	}
+	return 0, 0, nil
+}
+
+func (r *planReconciler) cleanup(ctx context.Context, object *unstructured.Unstructured) error {
+	pid, _, err := r.getPlan(ctx, object.GetName())
+	if err != nil {
+		return err
+	}
+	if pid == 0 {
+		return nil
+	}
+	return r.planGetter.Delete(ctx, pid)
+}
+
+type helmReleaseSpec struct {
+	Cluster   string                 `json:"cluster"`
+	Namespace string                 `json:"namespace"`
+	Chart     string                 `json:"chart"`
+	Version   string                 `json:"version"`
+	Values    map[string]interface{} `json:"values"`
+}
+
+// helmReleaseReconciler 在目标集群中安装或者升级 helm release，release 名称为 HelmRelease 的名称
+type helmReleaseReconciler struct {
+	*operator
+}
+
+func (r *helmReleaseReconciler) reconcile(ctx context.Context, object *unstructured.Unstructured) error {
+	var spec helmReleaseSpec
+	if err := decodeSpec(object, &spec); err != nil {
+		return err
+	}
+
+	name := object.GetName()
+	release := r.helmGetter.Release(spec.Cluster, spec.Namespace)
+	form := &types.Release{
+		Name:    name,
+		Chart:   spec.Chart,
+		Version: spec.Version,
+		Values:  spec.Values,
+	}
+	old, err := release.Get(ctx, name)
+	if err != nil {
+		_, err = release.Install(ctx, form)
+		return err
+	}
+	// chart 版本和 values 均未变化时无需升级
+	if old.Chart != nil && old.Chart.Metadata != nil && old.Chart.Metadata.Version == spec.Version && (len(old.Config) == 0 && len(spec.Values) == 0 || reflect.DeepEqual(old.Config, spec.Values)) {
+		return nil
+	}
+	_, err = release.Upgrade(ctx, form)
+	return err
+}
+
+func (r *helmReleaseReconciler) cleanup(ctx context.Context, object *unstructured.Unstructured) error {
+	var spec helmReleaseSpec
+	if err := decodeSpec(object, &spec); err != nil {
+		return err
+	}
+
+	release := r.helmGetter.Release(spec.Cluster, spec.Namespace)
+	if _, err := release.Get(ctx, object.GetName()); err != nil {
+		// release 不存在
+		return nil
+	}
+	_, err := release.Uninstall(ctx, object.GetName())
+	return err
+}
@@ -0,0 +1,354 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	"github.com/caoyingjunz/pixiu/pkg/util/ci"
+)
+
+const (
+	// 触发流水线时固定传入的参数名
+	ParamImageTag = "IMAGE_TAG"
+	ParamCluster  = "CLUSTER"
+)
+
+type PipelineGetter interface {
+	Pipeline() Interface
+}
+
+type Interface interface {
+	Create(ctx context.Context, req *types.CreatePipelineRequest) error
+	Update(ctx context.Context, pid int64, req *types.UpdatePipelineRequest) error
+	Delete(ctx context.Context, pid int64) error
+	Get(ctx context.Context, pid int64) (*types.Pipeline, error)
+	List(ctx context.Context) ([]types.Pipeline, error)
+
+	// Trigger 触发流水线并记录本次运行
+	Trigger(ctx context.Context, pid int64, req *types.TriggerPipelineRequest) (*types.PipelineRun, error)
+	// GetRun 获取运行记录，未结束时从 CI 服务刷新状态
+	GetRun(ctx context.Context, pid int64, runId int64) (*types.PipelineRun, error)
+	ListRuns(ctx context.Context, pid int64) ([]types.PipelineRun, error)
+}
+
+type pipeline struct {
+	factory db.ShareDaoFactory
+}
+
+func (p *pipeline) Create(ctx context.Context, req *types.CreatePipelineRequest) error {
+	object, err := p.factory.Pipeline().GetByName(ctx, req.Name)
+	if err != nil {
+		klog.Errorf("failed to get pipeline %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	if object != nil {
+		return errors.ErrPipelineExists
+	}
+
+	pipeline := &model.Pipeline{
+		Name:     req.Name,
+		Provider: req.Provider,
+		URL:      req.URL,
+		Project:  req.Project,
+		Ref:      req.Ref,
+		Username: req.Username,
+		Token:    req.Token,
+	}
+	if req.Description != nil {
+		pipeline.Description = *req.Description
+	}
+	if _, err = p.factory.Pipeline().Create(ctx, pipeline); err != nil {
+		klog.Errorf("failed to create pipeline %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+
+	return nil
+}
+
+func (p *pipeline) Update(ctx context.Context, pid int64, req *types.UpdatePipelineRequest) error {
+	if _, err := p.get(ctx, pid); err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	if req.URL != nil {
+		updates["url"] = *req.URL
+	}
+	if req.Project != nil {
+		updates["project"] = *req.Project
+	}
+	if req.Ref != nil {
+		updates["ref"] = *req.Ref
+	}
+	if req.Username != nil {
+		updates["username"] = *req.Username
+	}
+	if req.Token != nil {
+		updates["token"] = *req.Token
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if err := p.factory.Pipeline().Update(ctx, pid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update pipeline %d: %v", pid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (p *pipeline) Delete(ctx context.Context, pid int64) error {
+	if err := p.factory.Pipeline().Delete(ctx, pid); err != nil {
+		klog.Errorf("failed to delete pipeline %d: %v", pid, err)
+		return errors.ErrServerInternal
+	}
+
+	return nil
+}
+
+func (p *pipeline) Get(ctx context.Context, pid int64) (*types.Pipeline, error) {
+	object, err := p.get(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+	return p.model2Type(object), nil
+}
+
+func (p *pipeline) get(ctx context.Context, pid int64) (*model.Pipeline, error) {
+	object, err := p.factory.Pipeline().Get(ctx, pid)
+	if err != nil {
+		klog.Errorf("failed to get pipeline %d: %v", pid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrPipelineNotFound
+	}
+	return object, nil
+}
+
+func (p *pipeline) List(ctx context.Context) ([]types.Pipeline, error) {
+	objects, err := p.factory.Pipeline().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list pipelines: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	pipelines := make([]types.Pipeline, len(objects))
+	for i, object := range objects {
+		pipelines[i] = *p.model2Type(&object)
+	}
+	return pipelines, nil
+}
+
+func (p *pipeline) Trigger(ctx context.Context, pid int64, req *types.TriggerPipelineRequest) (*types.PipelineRun, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusInternalServerError)
+	}
+	object, err := p.get(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := NewProvider(object)
+	if err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+
+	params := make(types.PipelineParameters)
+	for k, v := range req.Parameters {
+		params[k] = v
+	}
+	if len(req.ImageTag) != 0 {
+		params[ParamImageTag] = req.ImageTag
+	}
+	if len(req.Cluster) != 0 {
+		params[ParamCluster] = req.Cluster
+	}
+	data, err := params.Marshal()
+	if err != nil {
+		return nil, errors.ErrServerInternal
+	}
+
+	run, err := provider.Trigger(ctx, params)
+	if err != nil {
+		klog.Errorf("failed to trigger pipeline %s: %v", object.Name, err)
+		return nil, errors.NewError(err, http.StatusBadGateway)
+	}
+
+	runObject, err := p.factory.Pipeline().CreateRun(ctx, &model.PipelineRun{
+		PipelineId: pid,
+		ExternalId: run.Id,
+		Status:     run.Status,
+		WebURL:     run.WebURL,
+		Cluster:    req.Cluster,
+		ImageTag:   req.ImageTag,
+		Parameters: data,
+		Operator:   user.Name,
+	})
+	if err != nil {
+		klog.Errorf("failed to create pipeline %s run: %v", object.Name, err)
+		return nil, errors.ErrServerInternal
+	}
+	return p.run2Type(runObject), nil
+}
+
+func (p *pipeline) GetRun(ctx context.Context, pid int64, runId int64) (*types.PipelineRun, error) {
+	object, err := p.get(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+	run, err := p.factory.Pipeline().GetRun(ctx, runId)
+	if err != nil {
+		klog.Errorf("failed to get pipeline run %d: %v", runId, err)
+		return nil, errors.ErrServerInternal
+	}
+	if run == nil || run.PipelineId != pid {
+		return nil, errors.ErrPipelineRunNotFound
+	}
+
+	if !ci.IsFinished(run.Status) {
+		// 刷新失败时返回数据库中的状态
+		if err = RefreshRun(ctx, p.factory, object, run); err != nil {
+			klog.Warningf("failed to refresh pipeline run %d: %v", runId, err)
+		}
+	}
+	return p.run2Type(run), nil
+}
+
+func (p *pipeline) ListRuns(ctx context.Context, pid int64) ([]types.PipelineRun, error) {
+	if _, err := p.get(ctx, pid); err != nil {
+		return nil, err
+	}
+	objects, err := p.factory.Pipeline().ListRuns(ctx, db.WithPipeline(pid), db.WithOrderByDesc())
+	if err != nil {
+		klog.Errorf("failed to list pipeline %d runs: %v", pid, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	runs := make([]types.PipelineRun, len(objects))
+	for i, object := range objects {
+		runs[i] = *p.run2Type(&object)
+	}
+	return runs, nil
+}
+
+// NewProvider 根据流水线配置构造 CI 服务的客户端
+func NewProvider(object *model.Pipeline) (ci.Provider, error) {
+	return ci.NewProvider(ci.Options{
+		Provider: object.Provider,
+		URL:      object.URL,
+		Project:  object.Project,
+		Ref:      object.Ref,
+		Username: object.Username,
+		Token:    object.Token,
+	})
+}
+
+// RefreshRun 从 CI 服务获取运行的最新状态，有变化时写回数据库
+func RefreshRun(ctx context.Context, f db.ShareDaoFactory, object *model.Pipeline, run *model.PipelineRun) error {
+	provider, err := NewProvider(object)
+	if err != nil {
+		return err
+	}
+	latest, err := provider.Status(ctx, run.ExternalId)
+	if err != nil {
+		return err
+	}
+	if latest.Id == run.ExternalId && latest.Status == run.Status && latest.WebURL == run.WebURL {
+		return nil
+	}
+
+	updates := map[string]interface{}{
+		"external_id": latest.Id,
+		"status":      latest.Status,
+	}
+	if len(latest.WebURL) != 0 {
+		updates["web_url"] = latest.WebURL
+		run.WebURL = latest.WebURL
+	}
+	if err = f.Pipeline().UpdateRun(ctx, run.Id, updates); err != nil {
+		return err
+	}
+	run.ExternalId, run.Status = latest.Id, latest.Status
+	return nil
+}
+
+func (p *pipeline) model2Type(o *model.Pipeline) *types.Pipeline {
+	return &types.Pipeline{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Name:        o.Name,
+		Provider:    o.Provider,
+		URL:         o.URL,
+		Project:     o.Project,
+		Ref:         o.Ref,
+		Username:    o.Username,
+		Description: o.Description,
+	}
+}
+
+func (p *pipeline) run2Type(o *model.PipelineRun) *types.PipelineRun {
+	var params types.PipelineParameters
+	if len(o.Parameters) != 0 {
+		if err := params.Unmarshal(o.Parameters); err != nil {
+			klog.Warningf("failed to unmarshal pipeline run(%d) parameters: %v", o.Id, err)
+		}
+	}
+
+	return &types.PipelineRun{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		PipelineId: o.PipelineId,
+		ExternalId: o.ExternalId,
+		Status:     o.Status,
+		WebURL:     o.WebURL,
+		Message:    o.Message,
+		Cluster:    o.Cluster,
+		ImageTag:   o.ImageTag,
+		Parameters: params,
+		Operator:   o.Operator,
+	}
+}
+
+func NewPipeline(f db.ShareDaoFactory) *pipeline {
+	return &pipeline{
+		factory: f,
+	}
+}
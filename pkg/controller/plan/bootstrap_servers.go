@@ -39,6 +39,8 @@ func (b BootStrap) Run() error {
 		return err
 	}
 	defer cli.Close()
+	cli.SetLimit(b.data.Limit)
+	cli.SetForks(b.data.execution().Parallelism)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Second)
 	defer cancel()
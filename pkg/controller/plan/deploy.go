@@ -18,8 +18,11 @@ package plan
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"k8s.io/klog/v2"
+
 	"github.com/caoyingjunz/pixiu/pkg/db/model"
 	"github.com/caoyingjunz/pixiu/pkg/util/container"
 )
@@ -33,13 +36,33 @@ type Deploy struct {
 
 func (b Deploy) Name() string { return "部署Master" }
 
-// Run 以容器的形式执行 BootStrap 任务，如果存在旧的容器，则先删除在执行
+// Run 以容器的形式执行 Deploy 任务，配置了分批时按批次依次执行，前一批成功后才执行下一批
 func (b Deploy) Run() error {
+	execution := b.data.execution()
+	batches := b.data.batches(execution.BatchSize)
+	for i, batch := range batches {
+		if len(batches) > 1 {
+			klog.Infof("plan(%d) deploying batch %d/%d: %s", b.GetPlanId(), i+1, len(batches), batch)
+		}
+		if err := b.run(batch, execution.Parallelism); err != nil {
+			if len(batches) > 1 {
+				return fmt.Errorf("第 %d 批节点(%s)部署失败: %v", i+1, batch, err)
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b Deploy) run(limit string, forks int) error {
 	cli, err := container.NewContainer("deploy", b.GetPlanId(), b.dir)
 	if err != nil {
 		return err
 	}
 	defer cli.Close()
+	cli.SetLimit(limit)
+	cli.SetForks(forks)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Second)
 	defer cancel()
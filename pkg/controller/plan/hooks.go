@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	HookPhasePre  = "pre"
+	HookPhasePost = "post"
+)
+
+// hookStages 任务名称与钩子阶段的对应关系
+var hookStages = map[string]string{
+	Check{}.Name():       "check",
+	Render{}.Name():      "render",
+	BootStrap{}.Name():   "bootstrap",
+	Deploy{}.Name():      "deploy",
+	DeployNode{}.Name():  "deploy-node",
+	Register{}.Name():    "register",
+	DeployChart{}.Name(): "deploy-chart",
+}
+
+func validateHooks(hooks types.PlanHooks) error {
+	stages := sets.NewString()
+	for _, stage := range hookStages {
+		stages.Insert(stage)
+	}
+	for _, hook := range hooks {
+		if !stages.Has(hook.Stage) {
+			return fmt.Errorf("不支持的钩子阶段 %s，支持的阶段为 %v", hook.Stage, stages.List())
+		}
+		if hook.Phase != HookPhasePre && hook.Phase != HookPhasePost {
+			return fmt.Errorf("不支持的钩子执行时机 %s", hook.Phase)
+		}
+		if len(strings.TrimSpace(hook.Command)) == 0 {
+			return fmt.Errorf("%s 阶段的钩子命令不能为空", hook.Stage)
+		}
+	}
+	return nil
+}
+
+// hookLogName 钩子的执行日志，与任务日志一同输出
+func hookLogName(stage string) string {
+	return fmt.Sprintf("hook-%s.log", stage)
+}
+
+// hookHandler 在任务执行前后执行对应阶段的钩子
+type hookHandler struct {
+	Handler
+
+	data  TaskData
+	dir   string
+	hooks types.PlanHooks
+}
+
+// withHooks 为配置了钩子的任务封装 hookHandler
+func withHooks(data TaskData, dir string, handlers ...Handler) []Handler {
+	hooks := types.PlanHooks{}
+	if data.Config != nil {
+		if err := hooks.Unmarshal(data.Config.Hooks); err != nil {
+			klog.Warningf("failed to unmarshal plan(%d) hooks: %v", data.PlanId, err)
+		}
+	}
+	if len(hooks) == 0 {
+		return handlers
+	}
+
+	wrapped := make([]Handler, len(handlers))
+	for i, handler := range handlers {
+		var stageHooks types.PlanHooks
+		for _, hook := range hooks {
+			if hook.Stage == hookStages[handler.Name()] {
+				stageHooks = append(stageHooks, hook)
+			}
+		}
+		if len(stageHooks) == 0 {
+			wrapped[i] = handler
+			continue
+		}
+		wrapped[i] = hookHandler{Handler: handler, data: data, dir: dir, hooks: stageHooks}
+	}
+	return wrapped
+}
+
+func (h hookHandler) Run() error {
+	filename, err := GetRenderFile(h.data.PlanId, h.dir, hookLogName(hookStages[h.Name()]))
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = h.runHooks(f, HookPhasePre); err != nil {
+		return err
+	}
+	if err = h.Handler.Run(); err != nil {
+		return err
+	}
+	return h.runHooks(f, HookPhasePost)
+}
+
+func (h hookHandler) runHooks(w io.Writer, phase string) error {
+	for _, hook := range h.hooks {
+		if hook.Phase != phase {
+			continue
+		}
+		for _, node := range h.targetNodes(hook) {
+			_, _ = fmt.Fprintf(w, "[%s %s] %s: %s\n", phase, hook.Stage, node.Name, hook.Command)
+			out, err := runCommand(node, hook.Command)
+			_, _ = w.Write(out)
+			if err == nil {
+				continue
+			}
+
+			_, _ = fmt.Fprintf(w, "[%s %s] %s: %v\n", phase, hook.Stage, node.Name, err)
+			if hook.IgnoreFailure {
+				klog.Warningf("plan(%d) node(%s) %s %s hook failed and ignored: %v", h.data.PlanId, node.Name, phase, hook.Stage, err)
+				continue
+			}
+			return fmt.Errorf("节点 %s 执行 %s 阶段的 %s 钩子失败: %v", node.Name, hook.Stage, phase, err)
+		}
+	}
+	return nil
+}
+
+// targetNodes 获取钩子需要执行的节点，单节点重试时仅在指定节点上执行
+func (h hookHandler) targetNodes(hook types.PlanHook) []model.Node {
+	limits := sets.NewString()
+	if len(h.data.Limit) != 0 {
+		limits.Insert(strings.Split(h.data.Limit, ",")...)
+	}
+
+	var nodes []model.Node
+	for _, node := range h.data.Nodes {
+		if limits.Len() != 0 && !limits.Has(node.Name) {
+			continue
+		}
+		if len(hook.Roles) != 0 && !sets.NewString(strings.Split(node.Role, ",")...).HasAny(hook.Roles...) {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func runCommand(node model.Node, command string) ([]byte, error) {
+	c, err := newSSHClient(node)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	session, err := c.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	return session.CombinedOutput(command)
+}
@@ -26,11 +26,14 @@ import (
 	"k8s.io/klog/v2"
 
 	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
 	"github.com/caoyingjunz/pixiu/cmd/app/config"
 	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/controller/naming"
 	"github.com/caoyingjunz/pixiu/pkg/db"
 	"github.com/caoyingjunz/pixiu/pkg/db/model"
 	"github.com/caoyingjunz/pixiu/pkg/types"
+	utilerrors "github.com/caoyingjunz/pixiu/pkg/util/errors"
 	"github.com/caoyingjunz/pixiu/pkg/util/uuid"
 )
 
@@ -51,6 +54,10 @@ type Interface interface {
 	Start(ctx context.Context, pid int64) error
 	// Stop 终止部署任务
 	Stop(ctx context.Context, pid int64) error
+	// Resume 从失败的任务开始继续执行，已成功的任务直接跳过
+	Resume(ctx context.Context, pid int64) error
+	// RetryNode 仅对指定节点从失败的任务开始重试
+	RetryNode(ctx context.Context, pid int64, nodeId int64) error
 
 	CreateNode(ctx context.Context, pid int64, req *types.CreatePlanNodeRequest) error
 	UpdateNode(ctx context.Context, pid int64, nodeId int64, req *types.UpdatePlanNodeRequest) error
@@ -92,6 +99,9 @@ type plan struct {
 // 4. 创建扩展组件
 // 5. 创建容器服务
 func (p *plan) Create(ctx context.Context, req *types.CreatePlanRequest) error {
+	if err := naming.NewNaming(p.factory).Validate(ctx, model.NamingResourcePlan, req.Name); err != nil {
+		return err
+	}
 	object, err := p.factory.Plan().Create(ctx, &model.Plan{
 		Name:        req.Name,
 		Description: req.Description,
@@ -172,6 +182,14 @@ func (p *plan) Update(ctx context.Context, planId int64, req *types.UpdatePlanRe
 // 删除前检查
 // 有正在运行中的任务则不允许删除
 func (p *plan) preDelete(ctx context.Context, planId int64) error {
+	object, err := p.factory.Plan().Get(ctx, planId)
+	if err != nil {
+		return errors.ErrServerInternal
+	}
+	if object.Backend == model.PlanBackendCAPI {
+		return errors.NewError(fmt.Errorf("通过 Cluster API 部署的计划需通过 capi 接口删除"), http.StatusBadRequest)
+	}
+
 	isRunning, err := p.TaskIsRunning(ctx, planId)
 	if err != nil {
 		return errors.ErrServerInternal
@@ -222,6 +240,9 @@ func (p *plan) Delete(ctx context.Context, planId int64) error {
 func (p *plan) Get(ctx context.Context, pid int64) (*types.Plan, error) {
 	object, err := p.factory.Plan().Get(ctx, pid)
 	if err != nil {
+		if utilerrors.IsRecordNotFound(err) {
+			return nil, errors.ErrPlanNotFound
+		}
 		klog.Errorf("failed to get plan %d: %v", pid, err)
 		return nil, errors.ErrServerInternal
 	}
@@ -229,6 +250,12 @@ func (p *plan) Get(ctx context.Context, pid int64) (*types.Plan, error) {
 	return p.model2Type(object)
 }
 
+// checkPlan 通过带租户隔离的 plan 查询校验子资源所属的部署计划，子表本身没有租户字段
+func (p *plan) checkPlan(ctx context.Context, pid int64) error {
+	_, err := p.Get(ctx, pid)
+	return err
+}
+
 // GetWithSubResources
 // 获取 plan
 // 获取 configs
@@ -304,8 +331,17 @@ func (p *plan) SyncTaskStatus(ctx context.Context) error {
 // 1. 配置
 // 2. 节点
 // 3. 校验runner
-// 3. 运行任务
+// 4. 校验节点的架构和操作系统
+// 5. 运行任务
 func (p *plan) preStart(ctx context.Context, pid int64) error {
+	object, err := p.factory.Plan().Get(ctx, pid)
+	if err != nil {
+		return fmt.Errorf("failed to get plan(%d) %v", pid, err)
+	}
+	if object.Backend == model.PlanBackendCAPI {
+		return fmt.Errorf("通过 Cluster API 部署的计划无需启动")
+	}
+
 	// 1. 校验配置
 	cfg, err := p.GetConfig(ctx, pid)
 	if err != nil {
@@ -323,13 +359,22 @@ func (p *plan) preStart(ctx context.Context, pid int64) error {
 	}
 
 	// 3. 校验runner
-	runner, err := p.GetRunner(cfg.OSImage)
+	engine, err := p.getEngine(cfg.OSImage)
 	if err != nil {
 		return err
 	}
-	klog.Infof("plan(%d) runner is %s", pid, runner)
+	klog.Infof("plan(%d) runner is %s", pid, engine.Image)
 
-	// 4. 校验运行任务
+	// 4. 校验节点的架构和操作系统，未指定时自动探测
+	objects, err := p.ensureNodePlatforms(ctx, pid)
+	if err != nil {
+		return err
+	}
+	if err = validatePlatforms(cfg.OSImage, engine, objects); err != nil {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+
+	// 5. 校验运行任务
 	isRunning, err := p.TaskIsRunning(ctx, pid)
 	if err != nil {
 		return errors.ErrServerInternal
@@ -366,10 +411,74 @@ func (p *plan) Start(ctx context.Context, pid int64) error {
 		return err
 	}
 
-	taskQueue.Add(pid)
+	taskQueue.Add(runOptions{PlanId: pid, UserId: operatorId(ctx)})
 	return nil
 }
 
+func (p *plan) Resume(ctx context.Context, pid int64) error {
+	if err := p.preStart(ctx, pid); err != nil {
+		return err
+	}
+	from, err := p.getResumeTask(ctx, pid)
+	if err != nil {
+		return err
+	}
+
+	klog.Infof("resuming plan(%d) from task(%s)", pid, from)
+	taskQueue.Add(runOptions{PlanId: pid, From: from, UserId: operatorId(ctx)})
+	return nil
+}
+
+func (p *plan) RetryNode(ctx context.Context, pid int64, nodeId int64) error {
+	node, err := p.factory.Plan().GetNode(ctx, nodeId)
+	if err != nil && !utilerrors.IsRecordNotFound(err) {
+		klog.Errorf("failed to get plan(%d) node(%d): %v", pid, nodeId, err)
+		return errors.ErrServerInternal
+	}
+	if node == nil || node.PlanId != pid {
+		return errors.NewError(fmt.Errorf("部署计划的节点不存在"), http.StatusNotFound)
+	}
+	if err = p.preStart(ctx, pid); err != nil {
+		return err
+	}
+	from, err := p.getResumeTask(ctx, pid)
+	if err != nil {
+		return err
+	}
+
+	klog.Infof("retrying plan(%d) node(%s) from task(%s)", pid, node.Name, from)
+	taskQueue.Add(runOptions{PlanId: pid, From: from, Node: node.Name, UserId: operatorId(ctx)})
+	return nil
+}
+
+// operatorId 获取发起部署的用户，由 operator 等内部调用时为 0，不发送通知
+func operatorId(ctx context.Context) int64 {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return 0
+	}
+	return user.Id
+}
+
+// getResumeTask 获取第一个未成功的任务，任务的执行结果即为断点
+func (p *plan) getResumeTask(ctx context.Context, pid int64) (string, error) {
+	tasks, err := p.factory.Plan().ListTasks(ctx, pid)
+	if err != nil {
+		klog.Errorf("failed to get plan(%d) tasks: %v", pid, err)
+		return "", errors.ErrServerInternal
+	}
+	if len(tasks) == 0 {
+		return "", errors.NewError(fmt.Errorf("部署计划尚未执行，请直接启动"), http.StatusBadRequest)
+	}
+
+	for _, task := range tasks {
+		if task.Status != model.SuccessPlanStatus {
+			return task.Name, nil
+		}
+	}
+	return "", errors.NewError(fmt.Errorf("部署计划的任务均已成功，无需重试"), http.StatusBadRequest)
+}
+
 func (p *plan) Stop(ctx context.Context, pid int64) error {
 	return nil
 }
@@ -401,9 +510,13 @@ func (p *plan) model2Type(o *model.Plan) (*types.Plan, error) {
 			GmtCreate:   o.GmtCreate,
 			GmtModified: o.GmtModified,
 		},
-		Name:        o.Name,
-		Description: o.Description,
-		Step:        status,
+		Name:              o.Name,
+		Description:       o.Description,
+		Step:              status,
+		Backend:           o.Backend,
+		ManagementCluster: o.ManagementCluster,
+		Namespace:         o.Namespace,
+		TenantId:          o.TenantId,
 	}, nil
 }
 
@@ -19,14 +19,73 @@ package plan
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 
 	"k8s.io/klog/v2"
 
 	"github.com/caoyingjunz/pixiu/api/server/errors"
 	"github.com/caoyingjunz/pixiu/pkg/db/model"
 	"github.com/caoyingjunz/pixiu/pkg/types"
+	utilerrors "github.com/caoyingjunz/pixiu/pkg/util/errors"
 )
 
+const (
+	dockerHubRegistry = "docker.io"
+	dockerHubServer   = "https://registry-1.docker.io"
+)
+
+// validateRuntime 校验容器运行时的仓库和代理配置
+func validateRuntime(rs types.RuntimeSpec) error {
+	for _, mirror := range rs.Mirrors {
+		if len(mirror.Registry) == 0 || strings.Contains(mirror.Registry, "://") {
+			return fmt.Errorf("镜像仓库 %s 不合法，不需要包含协议", mirror.Registry)
+		}
+		if rs.IsDocker() && mirror.Registry != dockerHubRegistry {
+			return fmt.Errorf("docker 仅支持配置 %s 的加速地址", dockerHubRegistry)
+		}
+		if len(mirror.Endpoints) == 0 {
+			return fmt.Errorf("镜像仓库 %s 未配置加速地址", mirror.Registry)
+		}
+		for _, endpoint := range mirror.Endpoints {
+			if err := validateURL(endpoint); err != nil {
+				return fmt.Errorf("镜像仓库 %s 的加速地址 %s 不合法: %v", mirror.Registry, endpoint, err)
+			}
+		}
+	}
+	for _, registry := range rs.InsecureRegistries {
+		if len(registry) == 0 || strings.Contains(registry, "://") || strings.Contains(registry, "/") {
+			return fmt.Errorf("非安全仓库 %s 不合法，格式为 host[:port]", registry)
+		}
+	}
+	if rs.Proxy != nil {
+		for _, proxy := range []string{rs.Proxy.HttpProxy, rs.Proxy.HttpsProxy} {
+			if len(proxy) == 0 {
+				continue
+			}
+			if err := validateURL(proxy); err != nil {
+				return fmt.Errorf("代理地址 %s 不合法: %v", proxy, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("仅支持 http 或者 https")
+	}
+	if len(u.Host) == 0 {
+		return fmt.Errorf("地址为空")
+	}
+	return nil
+}
+
 func (p *plan) preCreateConfig(ctx context.Context, planId int64, req *types.CreatePlanConfigRequest) error {
 	_, err := p.factory.Plan().GetConfigByPlan(ctx, planId)
 	if err == nil {
@@ -95,6 +154,9 @@ func (p *plan) UpdateConfigIfNeeded(ctx context.Context, planId int64, req *type
 		updates["network"] = newNetwork
 	}
 
+	if err = validateRuntime(newConfig.Runtime); err != nil {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
 	newRuntime, err := newConfig.Runtime.Marshal()
 	if err != nil {
 		return err
@@ -111,6 +173,25 @@ func (p *plan) UpdateConfigIfNeeded(ctx context.Context, planId int64, req *type
 		updates["component"] = newComponent
 	}
 
+	newExecution, err := newConfig.Execution.Marshal()
+	if err != nil {
+		return err
+	}
+	if oldConfig.Execution != newExecution {
+		updates["execution"] = newExecution
+	}
+
+	if err = validateHooks(newConfig.Hooks); err != nil {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+	newHooks, err := newConfig.Hooks.Marshal()
+	if err != nil {
+		return err
+	}
+	if oldConfig.Hooks != newHooks {
+		updates["hooks"] = newHooks
+	}
+
 	// 没有更新，则直接返回
 	if len(updates) == 0 {
 		return nil
@@ -124,6 +205,20 @@ func (p *plan) UpdateConfigIfNeeded(ctx context.Context, planId int64, req *type
 }
 
 func (p *plan) DeleteConfig(ctx context.Context, pid int64, cfgId int64) error {
+	if err := p.checkPlan(ctx, pid); err != nil {
+		return err
+	}
+	object, err := p.factory.Plan().GetConfig(ctx, cfgId)
+	if err != nil {
+		if utilerrors.IsRecordNotFound(err) {
+			return errors.ErrPlanConfigNotFound
+		}
+		klog.Errorf("failed to get plan(%d) config(%d): %v", pid, cfgId, err)
+		return errors.ErrServerInternal
+	}
+	if object.PlanId != pid {
+		return errors.ErrPlanConfigNotFound
+	}
 	if _, err := p.factory.Plan().DeleteConfig(ctx, cfgId); err != nil {
 		klog.Errorf("failed to delete plan(%d) config(%d): %v", pid, cfgId, err)
 		return errors.ErrServerInternal
@@ -133,8 +228,14 @@ func (p *plan) DeleteConfig(ctx context.Context, pid int64, cfgId int64) error {
 }
 
 func (p *plan) GetConfig(ctx context.Context, pid int64) (*types.PlanConfig, error) {
+	if err := p.checkPlan(ctx, pid); err != nil {
+		return nil, err
+	}
 	object, err := p.factory.Plan().GetConfigByPlan(ctx, pid)
 	if err != nil {
+		if utilerrors.IsRecordNotFound(err) {
+			return nil, errors.ErrPlanConfigNotFound
+		}
 		klog.Errorf("failed to get plan(%d) config: %v", pid, err)
 		return nil, errors.ErrServerInternal
 	}
@@ -164,6 +265,9 @@ func (p *plan) buildPlanConfig(ctx context.Context, req *types.CreatePlanConfigR
 	if err != nil {
 		return nil, err
 	}
+	if err = validateRuntime(req.Runtime); err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
 	runtimeConfig, err := req.Runtime.Marshal()
 	if err != nil {
 		return nil, err
@@ -172,6 +276,17 @@ func (p *plan) buildPlanConfig(ctx context.Context, req *types.CreatePlanConfigR
 	if err != nil {
 		return nil, err
 	}
+	executionConfig, err := req.Execution.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if err = validateHooks(req.Hooks); err != nil {
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+	hooksConfig, err := req.Hooks.Marshal()
+	if err != nil {
+		return nil, err
+	}
 
 	return &model.Config{
 		Region:     req.Region,
@@ -180,6 +295,8 @@ func (p *plan) buildPlanConfig(ctx context.Context, req *types.CreatePlanConfigR
 		Network:    networkConfig,
 		Runtime:    runtimeConfig,
 		Component:  componentConfig,
+		Execution:  executionConfig,
+		Hooks:      hooksConfig,
 	}, nil
 }
 
@@ -200,6 +317,14 @@ func (p *plan) modelConfig2Type(o *model.Config) (*types.PlanConfig, error) {
 	if err := cs.Unmarshal(o.Component); err != nil {
 		return nil, err
 	}
+	es := &types.ExecutionSpec{}
+	if err := es.Unmarshal(o.Execution); err != nil {
+		return nil, err
+	}
+	hooks := types.PlanHooks{}
+	if err := hooks.Unmarshal(o.Hooks); err != nil {
+		return nil, err
+	}
 
 	return &types.PlanConfig{
 		PixiuMeta: types.PixiuMeta{
@@ -217,5 +342,7 @@ func (p *plan) modelConfig2Type(o *model.Config) (*types.PlanConfig, error) {
 		Network:    *ns,
 		Runtime:    *rs,
 		Component:  *cs,
+		Execution:  *es,
+		Hooks:      hooks,
 	}, nil
 }
@@ -118,12 +118,14 @@ func (p *plan) buildNodeFromRequest(planId int64, req *types.CreatePlanNodeReque
 	}
 
 	return &model.Node{
-		Name:   req.Name,
-		PlanId: planId,
-		Role:   strings.Join(req.Role, ","),
-		CRI:    req.CRI,
-		Ip:     req.Ip,
-		Auth:   auth,
+		Name:     req.Name,
+		PlanId:   planId,
+		Role:     strings.Join(req.Role, ","),
+		CRI:      req.CRI,
+		Ip:       req.Ip,
+		Auth:     auth,
+		Arch:     req.Arch,
+		OSFamily: req.OSFamily,
 	}, nil
 }
 
@@ -142,6 +144,9 @@ func (p *plan) createNode(ctx context.Context, planId int64, req *types.CreatePl
 }
 
 func (p *plan) DeleteNode(ctx context.Context, pid int64, nodeId int64) error {
+	if _, err := p.getNode(ctx, pid, nodeId); err != nil {
+		return err
+	}
 	if _, err := p.factory.Plan().DeleteNode(ctx, nodeId); err != nil {
 		klog.Errorf("failed to delete plan(%d) node(%d): %v", pid, nodeId, err)
 		return errors.ErrServerInternal
@@ -151,16 +156,38 @@ func (p *plan) DeleteNode(ctx context.Context, pid int64, nodeId int64) error {
 }
 
 func (p *plan) GetNode(ctx context.Context, pid int64, nodeId int64) (*types.PlanNode, error) {
+	object, err := p.getNode(ctx, pid, nodeId)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.modelNode2Type(object)
+}
+
+// getNode 获取部署计划下的节点，节点不属于该计划时视为不存在
+func (p *plan) getNode(ctx context.Context, pid int64, nodeId int64) (*model.Node, error) {
+	if err := p.checkPlan(ctx, pid); err != nil {
+		return nil, err
+	}
 	object, err := p.factory.Plan().GetNode(ctx, nodeId)
 	if err != nil {
+		if utilerrors.IsRecordNotFound(err) {
+			return nil, errors.ErrPlanNodeNotFound
+		}
 		klog.Errorf("failed to get plan(%d) node(%d): %v", pid, nodeId, err)
 		return nil, errors.ErrServerInternal
 	}
+	if object.PlanId != pid {
+		return nil, errors.ErrPlanNodeNotFound
+	}
 
-	return p.modelNode2Type(object)
+	return object, nil
 }
 
 func (p *plan) ListNodes(ctx context.Context, pid int64) ([]types.PlanNode, error) {
+	if err := p.checkPlan(ctx, pid); err != nil {
+		return nil, err
+	}
 	objects, err := p.factory.Plan().ListNodes(ctx, pid)
 	if err != nil {
 		klog.Errorf("failed to get plan(%d) nodes: %v", pid, err)
@@ -220,11 +247,13 @@ func (p *plan) modelNode2Type(o *model.Node) (*types.PlanNode, error) {
 			GmtCreate:   o.GmtCreate,
 			GmtModified: o.GmtModified,
 		},
-		PlanId: o.PlanId,
-		Name:   o.Name,
-		Role:   strings.Split(o.Role, ","),
-		Ip:     o.Ip,
-		Auth:   auth,
+		PlanId:   o.PlanId,
+		Name:     o.Name,
+		Role:     strings.Split(o.Role, ","),
+		Ip:       o.Ip,
+		Auth:     auth,
+		Arch:     o.Arch,
+		OSFamily: o.OSFamily,
 	}, nil
 }
 
@@ -239,6 +268,13 @@ func (p *plan) buildNodeUpdates(old, object *model.Node) map[string]interface{}
 	if old.Auth != object.Auth {
 		updates["auth"] = object.Auth
 	}
+	// 未指定时保留已探测的结果
+	if len(object.Arch) != 0 && old.Arch != object.Arch {
+		updates["arch"] = object.Arch
+	}
+	if len(object.OSFamily) != 0 && old.OSFamily != object.OSFamily {
+		updates["os_family"] = object.OSFamily
+	}
 
 	return updates
 }
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -39,6 +40,9 @@ func (p *plan) RunTask(ctx context.Context, planId int64, taskId int64) error {
 }
 
 func (p *plan) ListTasks(ctx context.Context, planId int64) ([]types.PlanTask, error) {
+	if err := p.checkPlan(ctx, planId); err != nil {
+		return nil, err
+	}
 	objects, err := p.factory.Plan().ListTasks(ctx, planId)
 	if err != nil {
 		klog.Errorf("failed to get plan(%d) tasks: %v", planId, err)
@@ -113,6 +117,15 @@ func (p *plan) WatchTaskLog(ctx context.Context, planId int64, taskId int64, w h
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
+	// 先输出钩子的执行日志
+	if stage, ok := hookStages[task.Name]; ok {
+		if filename, err := GetRenderFile(planId, p.WorkDir(), hookLogName(stage)); err == nil {
+			if data, err := os.ReadFile(filename); err == nil {
+				_, _ = w.Write(data)
+			}
+		}
+	}
+
 	// TODO 临时指定，后期根据步骤id去做查询判断
 	var step string
 	switch task.Name {
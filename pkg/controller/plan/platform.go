@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+// 获取节点架构和操作系统信息的命令
+const detectPlatformCommand = "uname -m && cat /etc/os-release"
+
+// osFamilyOf 根据部署计划的操作系统获取操作系统类型，无法识别时返回空
+func osFamilyOf(osImage string) string {
+	image := strings.ToLower(osImage)
+	for _, prefix := range []string{"debian", "ubuntu"} {
+		if strings.HasPrefix(image, prefix) {
+			return model.OSFamilyDebian
+		}
+	}
+	for _, prefix := range []string{"centos", "rocky", "openeuler", "kylin", "rhel"} {
+		if strings.HasPrefix(image, prefix) {
+			return model.OSFamilyRedHat
+		}
+	}
+	return ""
+}
+
+func normalizeArch(arch string) string {
+	switch arch {
+	case "x86_64", model.ArchAMD64:
+		return model.ArchAMD64
+	case "aarch64", model.ArchARM64:
+		return model.ArchARM64
+	}
+	return arch
+}
+
+// ensureNodePlatforms 探测未指定架构或者操作系统类型的节点，并保存探测结果
+func (p *plan) ensureNodePlatforms(ctx context.Context, planId int64) ([]model.Node, error) {
+	nodes, err := p.factory.Plan().ListNodes(ctx, planId)
+	if err != nil {
+		klog.Errorf("failed to get plan(%d) nodes: %v", planId, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	for i := range nodes {
+		node := &nodes[i]
+		if len(node.Arch) != 0 && len(node.OSFamily) != 0 {
+			continue
+		}
+		arch, osFamily, err := detectPlatform(node)
+		if err != nil {
+			klog.Errorf("failed to detect plan(%d) node(%s) platform: %v", planId, node.Name, err)
+			return nil, errors.NewError(fmt.Errorf("探测节点 %s 的架构和操作系统失败: %v", node.Name, err), http.StatusBadRequest)
+		}
+
+		updates := make(map[string]interface{})
+		if len(node.Arch) == 0 {
+			node.Arch = arch
+			updates["arch"] = arch
+		}
+		if len(node.OSFamily) == 0 {
+			node.OSFamily = osFamily
+			updates["os_family"] = osFamily
+		}
+		if err = p.factory.Plan().UpdateNode(ctx, node.Id, node.ResourceVersion, updates); err != nil {
+			klog.Errorf("failed to update plan(%d) node(%s) platform: %v", planId, node.Name, err)
+			return nil, errors.ErrServerInternal
+		}
+		node.ResourceVersion++
+	}
+
+	return nodes, nil
+}
+
+// detectPlatform 登录节点获取 CPU 架构和操作系统类型
+func detectPlatform(node *model.Node) (string, string, error) {
+	c, err := newSSHClient(*node)
+	if err != nil {
+		return "", "", err
+	}
+	defer c.Close()
+	session, err := c.NewSession()
+	if err != nil {
+		return "", "", err
+	}
+	defer session.Close()
+
+	out, err := session.Output(detectPlatformCommand)
+	if err != nil {
+		return "", "", err
+	}
+	arch, osFamily := parsePlatform(string(out))
+	return arch, osFamily, nil
+}
+
+// parsePlatform 解析 uname -m 和 /etc/os-release 的输出
+func parsePlatform(out string) (string, string) {
+	var arch, id, idLike string
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(arch) == 0 && len(line) != 0 {
+			arch = normalizeArch(line)
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.ToLower(strings.Trim(parts[1], "\""))
+		switch parts[0] {
+		case "ID":
+			id = value
+		case "ID_LIKE":
+			idLike = value
+		}
+	}
+
+	osFamily := osFamilyOf(id)
+	if len(osFamily) == 0 {
+		for _, like := range strings.Fields(idLike) {
+			if osFamily = osFamilyOf(like); len(osFamily) != 0 {
+				break
+			}
+		}
+	}
+	if len(osFamily) == 0 && strings.Contains(idLike, "fedora") {
+		osFamily = model.OSFamilyRedHat
+	}
+	return arch, osFamily
+}
+
+// validatePlatforms 校验节点的架构和操作系统类型
+// 1. 架构必须被部署镜像支持，未声明时仅支持 amd64
+// 2. 操作系统类型必须和部署计划的操作系统一致，不允许混合部署
+func validatePlatforms(osImage string, engine config.Engine, nodes []model.Node) error {
+	archs := sets.NewString(engine.ArchSupported...)
+	if archs.Len() == 0 {
+		archs.Insert(model.ArchAMD64)
+	}
+	osFamily := osFamilyOf(osImage)
+
+	var errs []error
+	for _, node := range nodes {
+		if !archs.Has(node.Arch) {
+			errs = append(errs, fmt.Errorf("节点 %s 的架构 %s 不被操作系统 %s 的部署镜像支持，支持的架构为 %v", node.Name, node.Arch, osImage, archs.List()))
+		}
+		if len(osFamily) != 0 && node.OSFamily != osFamily {
+			errs = append(errs, fmt.Errorf("节点 %s 的操作系统类型 %s 与部署计划的操作系统 %s 不一致", node.Name, node.OSFamily, osImage))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
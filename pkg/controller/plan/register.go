@@ -110,6 +110,16 @@ func getKubeConfigFromMasterNode(maserNode model.Node) ([]byte, error) {
 }
 
 func newSftpClient(node model.Node) (*sftp.Client, error) {
+	sshClient, err := newSSHClient(node)
+	if err != nil {
+		return nil, err
+	}
+
+	return sftp.NewClient(sshClient)
+}
+
+// newSSHClient 使用节点的认证信息建立 ssh 连接
+func newSSHClient(node model.Node) (*ssh.Client, error) {
 	nodeAuth := types.PlanNodeAuth{}
 	if err := nodeAuth.Unmarshal(node.Auth); err != nil {
 		return nil, err
@@ -150,10 +160,5 @@ func newSftpClient(node model.Node) (*sftp.Client, error) {
 	}
 
 	addr := fmt.Sprintf("%s:%d", node.Ip, 22)
-	sshClient, err := ssh.Dial("tcp", addr, clientConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	return sftp.NewClient(sshClient)
+	return ssh.Dial("tcp", addr, clientConfig)
 }
@@ -18,7 +18,9 @@ package plan
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"text/template"
@@ -62,6 +64,82 @@ func (r Render) Run() error {
 	if err := r.doRender("globals.yml", pixiutpl.GlobalsTemplate, cfg); err != nil {
 		return err
 	}
+	// 渲染容器运行时的仓库和代理配置
+	if err := r.renderRuntime(cfg.Runtime); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// containerdHosts containerd 单个仓库的 hosts.toml 配置
+type containerdHosts struct {
+	Server    string
+	Endpoints []string
+	Insecure  bool
+}
+
+// renderRuntime 渲染容器运行时的配置文件到 runtime 目录，由部署镜像分发到全部节点
+// containerd 渲染 certs.d/<registry>/hosts.toml，docker 渲染 daemon.json
+func (r Render) renderRuntime(rs types.RuntimeSpec) error {
+	// 清理上次渲染的配置，避免已删除的仓库配置残留
+	if err := os.RemoveAll(filepath.Join(r.dir, fmt.Sprintf("%d", r.GetPlanId()), "runtime")); err != nil {
+		return err
+	}
+	if rs.Proxy != nil {
+		if err := r.doRender("runtime/http-proxy.conf", pixiutpl.RuntimeProxyTemplate, rs.Proxy); err != nil {
+			return err
+		}
+	}
+	if !rs.HasRegistryConfig() {
+		return nil
+	}
+
+	if rs.IsDocker() {
+		daemon := map[string][]string{}
+		for _, mirror := range rs.Mirrors {
+			daemon["registry-mirrors"] = append(daemon["registry-mirrors"], mirror.Endpoints...)
+		}
+		if len(rs.InsecureRegistries) != 0 {
+			daemon["insecure-registries"] = rs.InsecureRegistries
+		}
+		data, err := json.MarshalIndent(daemon, "", "  ")
+		if err != nil {
+			return err
+		}
+		filename, err := GetRenderFile(r.GetPlanId(), r.dir, "runtime/daemon.json")
+		if err != nil {
+			return err
+		}
+		return util.WriteToFile(filename, data)
+	}
+
+	hosts := make(map[string]*containerdHosts)
+	get := func(registry string) *containerdHosts {
+		if h, ok := hosts[registry]; ok {
+			return h
+		}
+		server := "https://" + registry
+		if registry == dockerHubRegistry {
+			server = dockerHubServer
+		}
+		hosts[registry] = &containerdHosts{Server: server}
+		return hosts[registry]
+	}
+	for _, mirror := range rs.Mirrors {
+		h := get(mirror.Registry)
+		h.Endpoints = append(h.Endpoints, mirror.Endpoints...)
+	}
+	for _, registry := range rs.InsecureRegistries {
+		h := get(registry)
+		h.Endpoints = append(h.Endpoints, "https://"+registry, "http://"+registry)
+		h.Insecure = true
+	}
+	for registry, h := range hosts {
+		if err := r.doRender(filepath.Join("runtime", "certs.d", registry, "hosts.toml"), pixiutpl.ContainerdHostsTemplate, h); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -117,7 +195,7 @@ func ParseMultinode(data TaskData, workDir string) (Multinode, error) {
 			return multinode, err
 		}
 		nodeAuth.Key.File = fmt.Sprintf("/configs/ssh/%s/id_rsa", node.Name)
-		planNode := types.PlanNode{Name: node.Name, Auth: nodeAuth}
+		planNode := types.PlanNode{Name: node.Name, Auth: nodeAuth, Arch: node.Arch, OSFamily: node.OSFamily}
 
 		roles := strings.Split(node.Role, ",")
 		if runtime.IsDocker() {
@@ -148,12 +226,13 @@ func ParseMultinode(data TaskData, workDir string) (Multinode, error) {
 // GetRenderFile
 // TODO: 后续优化
 func GetRenderFile(planId int64, workDir string, f string) (string, error) {
-	planDir := filepath.Join(workDir, fmt.Sprintf("%d", planId))
-	if err := util.EnsureDirectoryExists(planDir); err != nil {
+	filename := filepath.Join(workDir, fmt.Sprintf("%d", planId), f)
+	// f 可能包含子目录，例如 runtime/daemon.json
+	if err := util.EnsureDirectoryExists(filepath.Dir(filename)); err != nil {
 		return "", err
 	}
 
-	return filepath.Join(planDir, f), nil
+	return filename, nil
 }
 
 func RenderRSA(planId int64, name string, workDir string, auth types.PlanNodeAuth) (string, error) {
@@ -196,9 +275,15 @@ func ParseConfig(data TaskData) (*types.PlanConfig, error) {
 		return nil, err
 	}
 
+	runtime := types.RuntimeSpec{}
+	if err := runtime.Unmarshal(config.Runtime); err != nil {
+		return nil, err
+	}
+
 	return &types.PlanConfig{
 		Kubernetes: kubernetes,
 		Network:    network,
+		Runtime:    runtime,
 		Component:  component,
 	}, nil
 }
@@ -19,13 +19,18 @@ package plan
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
 	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/notifier"
+	"github.com/caoyingjunz/pixiu/pkg/types"
 	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+	"github.com/caoyingjunz/pixiu/pkg/util/trace"
 )
 
 type Handler interface {
@@ -74,20 +79,72 @@ func (p *plan) process(ctx context.Context) bool {
 	}
 	defer taskQueue.Done(key)
 
-	p.syncHandler(ctx, key.(int64))
+	p.syncHandler(ctx, key.(runOptions))
 	return true
 }
 
+// runOptions 部署计划的执行参数，作为 taskQueue 的 key
+type runOptions struct {
+	PlanId int64
+	// 从指定的任务开始执行，之前的任务已成功，直接跳过
+	From string
+	// 仅对指定的节点执行，为空时执行全部节点
+	Node string
+	// 发起部署的用户，执行完成后通知
+	UserId int64
+}
+
 type TaskData struct {
 	PlanId int64
 	Config *model.Config
 	Nodes  []model.Node
+	// 部署容器仅作用于该节点，渲染时仍使用全部节点
+	Limit string
 }
 
 func (t TaskData) validate() error {
 	return nil
 }
 
+func (t TaskData) execution() types.ExecutionSpec {
+	execution := types.ExecutionSpec{}
+	if t.Config == nil {
+		return execution
+	}
+	if err := execution.Unmarshal(t.Config.Execution); err != nil {
+		klog.Warningf("failed to unmarshal plan(%d) execution: %v", t.PlanId, err)
+	}
+	return execution
+}
+
+// batches 按批次大小对节点分组，master 节点优先，返回每批的 LIMIT
+// 已指定 Limit 或者未配置分批时仅有一批
+func (t TaskData) batches(size int) []string {
+	if len(t.Limit) != 0 || size <= 0 || size >= len(t.Nodes) {
+		return []string{t.Limit}
+	}
+
+	var masters, others []string
+	for _, node := range t.Nodes {
+		if strings.Contains(node.Role, model.MasterRole) {
+			masters = append(masters, node.Name)
+		} else {
+			others = append(others, node.Name)
+		}
+	}
+	names := append(masters, others...)
+
+	var batches []string
+	for i := 0; i < len(names); i += size {
+		end := i + size
+		if end > len(names) {
+			end = len(names)
+		}
+		batches = append(batches, strings.Join(names[i:end], ","))
+	}
+	return batches
+}
+
 func (p *plan) getTaskData(ctx context.Context, planId int64) (TaskData, error) {
 	nodes, err := p.factory.Plan().ListNodes(ctx, planId)
 	if err != nil {
@@ -111,15 +168,24 @@ func (p *plan) getTaskData(ctx context.Context, planId int64) (TaskData, error)
 // 2. 渲染环境
 // 3. 执行部署
 // 4. 部署后环境清理
-func (p *plan) syncHandler(ctx context.Context, planId int64) {
+func (p *plan) syncHandler(ctx context.Context, opts runOptions) {
+	planId := opts.PlanId
 	klog.Infof("starting plan(%d) task", planId)
 	defer klog.Infof("completed plan(%d) task", planId)
 
+	var err error
+	ctx, span := trace.Start(ctx, "plan.run", trace.Int("plan.id", planId), trace.String("plan.from", opts.From), trace.String("plan.node", opts.Node))
+	defer func() {
+		span.End(err)
+		p.notifyFinished(ctx, opts, err)
+	}()
+
 	taskData, err := p.getTaskData(ctx, planId)
 	if err != nil {
 		klog.Errorf("failed to get task data: %v", err)
 		return
 	}
+	taskData.Limit = opts.Node
 	runner, err := p.GetRunner(taskData.Config.OSImage)
 	if err != nil {
 		klog.Errorf("failed to get image(%s) for worker: %v", taskData.Config.OSImage, err)
@@ -129,7 +195,7 @@ func (p *plan) syncHandler(ctx context.Context, planId int64) {
 	dir := p.WorkDir()
 
 	task := newHandlerTask(taskData)
-	handlers := []Handler{
+	handlers := withHooks(taskData, dir,
 		Check{handlerTask: task},
 		Render{handlerTask: task, dir: dir},
 		BootStrap{handlerTask: task, dir: dir, runner: runner},
@@ -137,8 +203,8 @@ func (p *plan) syncHandler(ctx context.Context, planId int64) {
 		DeployNode{handlerTask: task},
 		Register{handlerTask: task, factory: p.factory},
 		DeployChart{handlerTask: task},
-	}
-	if err = p.syncTasks(handlers...); err != nil {
+	)
+	if err = p.syncTasks(ctx, opts.From, handlers...); err != nil {
 		klog.Errorf("failed to sync task: %v", err)
 	}
 }
@@ -181,15 +247,23 @@ func (p *plan) WorkDir() string {
 }
 
 func (p *plan) GetRunner(osImage string) (string, error) {
+	engine, err := p.getEngine(osImage)
+	if err != nil {
+		return "", err
+	}
+	return engine.Image, nil
+}
+
+func (p *plan) getEngine(osImage string) (config.Engine, error) {
 	engines := p.cc.Worker.Engines
 	for _, engine := range engines {
 		for _, os := range engine.OSSupported {
 			if os == osImage {
-				return engine.Image, nil
+				return engine, nil
 			}
 		}
 	}
-	return "", fmt.Errorf("osImage(%s) runner not found", osImage)
+	return config.Engine{}, fmt.Errorf("osImage(%s) runner not found", osImage)
 }
 
 // 同步任务状态
@@ -215,16 +289,52 @@ func (p *plan) syncStatus(ctx context.Context, planId int64) error {
 	return nil
 }
 
-func (p *plan) syncTasks(tasks ...Handler) error {
+// notifyFinished 通知发起部署的用户执行结果
+func (p *plan) notifyFinished(ctx context.Context, opts runOptions, runErr error) {
+	if opts.UserId == 0 {
+		return
+	}
+	name := fmt.Sprintf("%d", opts.PlanId)
+	if object, err := p.factory.Plan().Get(ctx, opts.PlanId); err == nil && object != nil {
+		name = object.Name
+	}
+
+	msg := notifier.Message{
+		UserId:  opts.UserId,
+		Kind:    notifier.KindPlan,
+		Title:   fmt.Sprintf("部署计划 %s 执行完成", name),
+		Content: fmt.Sprintf("部署计划 %s 已执行完成", name),
+		Ref:     fmt.Sprintf("plan/%d", opts.PlanId),
+	}
+	if runErr != nil {
+		msg.Title = fmt.Sprintf("部署计划 %s 执行失败", name)
+		msg.Content = fmt.Sprintf("部署计划 %s 执行失败: %v", name, runErr)
+	}
+	if err := notifier.New(p.factory, p.cc.Notification).Notify(ctx, msg); err != nil {
+		klog.Errorf("failed to notify user(%d) of plan(%d): %v", opts.UserId, opts.PlanId, err)
+	}
+}
+
+// syncTasks 依次执行任务，from 不为空时跳过之前已成功的任务
+// 任务的状态持久化作为断点，各任务需支持重复执行
+func (p *plan) syncTasks(ctx context.Context, from string, tasks ...Handler) error {
 	// 初始化记录
 	if err := p.createPlanTasksIfNotExist(tasks...); err != nil {
 		return err
 	}
 
 	// 执行任务并更新状态
+	resumed := len(from) == 0
 	for _, task := range tasks {
 		planId := task.GetPlanId()
 		name := task.Name()
+		if !resumed {
+			if name != from {
+				klog.Infof("plan(%d) task(%s) already succeeded, skipping", planId, name)
+				continue
+			}
+			resumed = true
+		}
 		klog.Infof("starting plan(%d) task(%s)", planId, name)
 
 		// TODO: 通过闭包方式优化
@@ -242,7 +352,9 @@ func (p *plan) syncTasks(tasks ...Handler) error {
 		message := ""
 
 		// 执行检查
+		_, span := trace.Start(ctx, "plan.task", trace.Int("plan.id", planId), trace.String("plan.task", name))
 		runErr := task.Run()
+		span.End(runErr)
 		if runErr != nil {
 			status = model.FailedPlanStatus
 			step = model.FailedPlanStep
@@ -0,0 +1,398 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	"github.com/caoyingjunz/pixiu/pkg/util"
+	sessionutil "github.com/caoyingjunz/pixiu/pkg/util/session"
+)
+
+const (
+	defaultSessionTTL = 30 * 60
+
+	KindPod     = "pod"
+	KindService = "service"
+
+	baseURL = "/pixiu/kubeproxy/clusters/%s/portforwards/%s"
+)
+
+type PortForwardGetter interface {
+	PortForward(cluster string) Interface
+}
+
+type Interface interface {
+	// Create 建立到 pod 或者 service 的端口转发会话，会话到期后自动关闭
+	Create(ctx context.Context, req *types.CreatePortForwardRequest) (*types.PortForwardSession, error)
+	// Delete 提前关闭会话
+	Delete(ctx context.Context, sid string) error
+	// List 获取集群的会话，普通用户仅能获取自己的会话
+	List(ctx context.Context) ([]types.PortForwardSession, error)
+
+	// ProxyHTTP 通过会话转发 http 请求，path 为转发到 pod 的请求路径
+	ProxyHTTP(ctx context.Context, sid string, path string, w http.ResponseWriter, r *http.Request) error
+	// ProxyTCP 通过 websocket 转发 tcp 流量
+	ProxyTCP(ctx context.Context, sid string, w http.ResponseWriter, r *http.Request) error
+}
+
+type portForward struct {
+	cluster string
+
+	clusterGetter cluster.Interface
+}
+
+func (p *portForward) Create(ctx context.Context, req *types.CreatePortForwardRequest) (*types.PortForwardSession, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cs, err := p.clusterGetter.GetClusterSetByName(ctx, p.cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	pod, port := req.Name, req.Port
+	if req.Kind == KindService {
+		if pod, port, err = p.resolveService(ctx, cs.Client.CoreV1(), req.Namespace, req.Name, req.Port); err != nil {
+			return nil, errors.NewError(err, http.StatusBadRequest)
+		}
+	}
+	// 使用当前用户的访问配置，集群为模拟用户访问时遵循用户的权限
+	config, err := p.clusterGetter.GetUserKubeConfigByName(ctx, p.cluster)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := dial(config, cs.Client.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(req.Namespace).Name(pod).SubResource("portforward").URL())
+	if err != nil {
+		klog.Errorf("failed to port forward to pod %s/%s in cluster(%s): %v", req.Namespace, pod, p.cluster, err)
+		return nil, errors.NewError(err, http.StatusBadRequest)
+	}
+
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = defaultSessionTTL
+	}
+	id := utilrand.String(16)
+	s := &session{
+		PortForwardSession: types.PortForwardSession{
+			Id:        id,
+			Cluster:   p.cluster,
+			Namespace: req.Namespace,
+			Kind:      req.Kind,
+			Name:      req.Name,
+			Pod:       pod,
+			Port:      port,
+			User:      user.Name,
+			ProxyPath: fmt.Sprintf(baseURL, p.cluster, id) + "/proxy/",
+			TCPPath:   fmt.Sprintf(baseURL, p.cluster, id) + "/tcp",
+			ExpireAt:  time.Now().Add(time.Duration(ttl) * time.Second),
+		},
+		userId: user.Id,
+		conn:   conn,
+	}
+	s.transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return s.openStream()
+		},
+		DisableKeepAlives: true,
+	}
+	s.untrack = sessionutil.Track(newTrackedSession(ctx, s))
+	sessions.add(s, time.Duration(ttl)*time.Second)
+
+	klog.Infof("user %s started port forward session %s to %s/%s:%d in cluster %s", user.Name, id, req.Namespace, pod, port, p.cluster)
+	object := s.PortForwardSession
+	return &object, nil
+}
+
+func (p *portForward) Delete(ctx context.Context, sid string) error {
+	if _, err := p.get(ctx, sid); err != nil {
+		return err
+	}
+	sessions.remove(sid)
+	return nil
+}
+
+func (p *portForward) List(ctx context.Context) ([]types.PortForwardSession, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]types.PortForwardSession, 0)
+	for _, s := range sessions.list() {
+		if s.Cluster != p.cluster || !canAccess(user, s) {
+			continue
+		}
+		objects = append(objects, s.PortForwardSession)
+	}
+	// 多副本部署时合并其他实例的会话，请求由其他实例转发到会话所在的实例
+	tracked, err := sessionutil.List(ctx)
+	if err != nil {
+		klog.Warningf("failed to list sessions: %v", err)
+		httputils.AddWarning(ctx, "sessions of other replicas skipped: %v", err)
+	}
+	for _, t := range tracked {
+		if t.Kind != sessionutil.KindPortForward || t.Cluster != p.cluster || sessionutil.IsLocal(&t) {
+			continue
+		}
+		if t.UserId != user.Id && user.Role != model.RoleAdmin && user.Role != model.RoleRoot {
+			continue
+		}
+		objects = append(objects, trackedToPortForward(t))
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].ExpireAt.Before(objects[j].ExpireAt)
+	})
+	return objects, nil
+}
+
+func (p *portForward) ProxyHTTP(ctx context.Context, sid string, path string, w http.ResponseWriter, r *http.Request) error {
+	s, err := p.get(ctx, sid)
+	if err != nil {
+		return err
+	}
+
+	reverseProxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = "http"
+			req.URL.Host = fmt.Sprintf("localhost:%d", s.Port)
+			req.URL.Path = path
+			req.Host = req.URL.Host
+			// 不向后端泄露 pixiu 的认证信息
+			req.Header.Del("Authorization")
+		},
+		Transport: s.transport,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			klog.Warningf("failed to proxy port forward session %s: %v", sid, err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+	reverseProxy.ServeHTTP(w, r)
+	return nil
+}
+
+func (p *portForward) ProxyTCP(ctx context.Context, sid string, w http.ResponseWriter, r *http.Request) error {
+	s, err := p.get(ctx, sid)
+	if err != nil {
+		return err
+	}
+	stream, err := s.openStream()
+	if err != nil {
+		klog.Errorf("failed to open port forward stream of session %s: %v", sid, err)
+		return err
+	}
+	defer stream.Close()
+
+	conn, err := util.BuildWebSocketConnection(w, r)
+	if err != nil {
+		klog.Errorf("failed to build websocket connection: %v", err)
+		return err
+	}
+	defer conn.Close()
+
+	// 任意一端关闭时结束转发
+	done := make(chan struct{}, 2)
+	go func() {
+		defer func() { done <- struct{}{} }()
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if _, err = stream.Write(message); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer func() { done <- struct{}{} }()
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := stream.Read(buf)
+			if n > 0 {
+				if werr := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-s.conn.CloseChan():
+	}
+	return nil
+}
+
+func (p *portForward) get(ctx context.Context, sid string) (*session, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := sessions.get(sid)
+	if !ok || s.Cluster != p.cluster || !canAccess(user, s) {
+		return nil, errors.ErrPortForwardNotFound
+	}
+	return s, nil
+}
+
+// newTrackedSession 构造会话的注册信息，metadata 中保存转发所需的字段
+func newTrackedSession(ctx context.Context, s *session) *types.Session {
+	expireAt := s.ExpireAt
+	tracked := httputils.NewSessionFromRequest(ctx, sessionutil.KindPortForward)
+	tracked.Id = s.Id
+	tracked.Cluster = s.Cluster
+	tracked.Namespace = s.Namespace
+	tracked.Target = fmt.Sprintf("%s:%d", s.Pod, s.Port)
+	tracked.ExpireAt = &expireAt
+	tracked.Metadata = map[string]string{
+		"kind":       s.Kind,
+		"name":       s.Name,
+		"pod":        s.Pod,
+		"port":       strconv.Itoa(int(s.Port)),
+		"proxy_path": s.ProxyPath,
+		"tcp_path":   s.TCPPath,
+	}
+	return tracked
+}
+
+func trackedToPortForward(t types.Session) types.PortForwardSession {
+	port, _ := strconv.Atoi(t.Metadata["port"])
+	object := types.PortForwardSession{
+		Id:        t.Id,
+		Cluster:   t.Cluster,
+		Namespace: t.Namespace,
+		Kind:      t.Metadata["kind"],
+		Name:      t.Metadata["name"],
+		Pod:       t.Metadata["pod"],
+		Port:      int32(port),
+		User:      t.User,
+		ProxyPath: t.Metadata["proxy_path"],
+		TCPPath:   t.Metadata["tcp_path"],
+	}
+	if t.ExpireAt != nil {
+		object.ExpireAt = *t.ExpireAt
+	}
+	return object
+}
+
+// canAccess 会话仅能被创建者和管理员使用
+func canAccess(user *model.User, s *session) bool {
+	return s.userId == user.Id || user.Role == model.RoleAdmin || user.Role == model.RoleRoot
+}
+
+// resolveService 选择 service 的一个就绪的后端 pod，并将 service 的端口转换为 pod 的端口
+func (p *portForward) resolveService(ctx context.Context, client corev1.CoreV1Interface, namespace, name string, port int32) (string, int32, error) {
+	svc, err := client.Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return "", 0, fmt.Errorf("service %s/%s has no selector", namespace, name)
+	}
+	var target *intstr.IntOrString
+	for _, sp := range svc.Spec.Ports {
+		if sp.Port == port {
+			t := sp.TargetPort
+			target = &t
+			break
+		}
+	}
+	if target == nil {
+		return "", 0, fmt.Errorf("service %s/%s has no port %d", namespace, name, port)
+	}
+
+	pods, err := client.Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String()})
+	if err != nil {
+		return "", 0, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil || !isPodReady(pod) {
+			continue
+		}
+		podPort, ok := resolvePodPort(pod, *target, port)
+		if !ok {
+			continue
+		}
+		return pod.Name, podPort, nil
+	}
+	return "", 0, fmt.Errorf("service %s/%s has no ready pod", namespace, name)
+}
+
+// resolvePodPort 未设置 targetPort 时与 service 端口一致，命名端口需在容器中查找
+func resolvePodPort(pod *v1.Pod, target intstr.IntOrString, port int32) (int32, bool) {
+	if target.Type == intstr.Int {
+		if target.IntVal == 0 {
+			return port, true
+		}
+		return target.IntVal, true
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, cp := range container.Ports {
+			if cp.Name == target.StrVal {
+				return cp.ContainerPort, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func isPodReady(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func NewPortForward(clusterName string, c cluster.Interface) *portForward {
+	return &portForward{
+		cluster:       clusterName,
+		clusterGetter: c,
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package portforward
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+// 所有进行中的端口转发会话
+var sessions = &sessionStore{items: make(map[string]*session)}
+
+type session struct {
+	types.PortForwardSession
+
+	userId    int64
+	conn      httpstream.Connection
+	transport *http.Transport
+	requestId int64
+	// 取消会话的注册，多副本部署时其他实例通过注册信息转发请求
+	untrack func()
+}
+
+type sessionStore struct {
+	lock  sync.RWMutex
+	items map[string]*session
+}
+
+// add 保存会话，到期或者和 kubelet 的连接断开时关闭
+func (ss *sessionStore) add(s *session, ttl time.Duration) {
+	ss.lock.Lock()
+	ss.items[s.Id] = s
+	ss.lock.Unlock()
+
+	timer := time.AfterFunc(ttl, func() {
+		ss.remove(s.Id)
+	})
+	go func() {
+		<-s.conn.CloseChan()
+		timer.Stop()
+		ss.remove(s.Id)
+	}()
+}
+
+func (ss *sessionStore) get(id string) (*session, bool) {
+	ss.lock.RLock()
+	defer ss.lock.RUnlock()
+
+	s, ok := ss.items[id]
+	return s, ok
+}
+
+func (ss *sessionStore) list() []*session {
+	ss.lock.RLock()
+	defer ss.lock.RUnlock()
+
+	items := make([]*session, 0, len(ss.items))
+	for _, s := range ss.items {
+		items = append(items, s)
+	}
+	return items
+}
+
+func (ss *sessionStore) remove(id string) {
+	ss.lock.Lock()
+	s, ok := ss.items[id]
+	delete(ss.items, id)
+	ss.lock.Unlock()
+	if !ok {
+		return
+	}
+
+	s.untrack()
+	s.transport.CloseIdleConnections()
+	_ = s.conn.Close()
+	klog.Infof("port forward session %s to %s/%s:%d closed", id, s.Namespace, s.Pod, s.Port)
+}
+
+// dial 建立到 pod portforward 子资源的 spdy 连接
+func dial(config *restclient.Config, target *url.URL) (httpstream.Connection, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, err
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, target)
+	conn, _, err := dialer.Dial(portforward.PortForwardProtocolV1Name)
+	return conn, err
+}
+
+// openStream 每个 tcp 连接对应一组 error 和 data stream，与 kubectl port-forward 的实现一致
+func (s *session) openStream() (net.Conn, error) {
+	headers := http.Header{}
+	headers.Set(v1.PortHeader, strconv.Itoa(int(s.Port)))
+	headers.Set(v1.PortForwardRequestIDHeader, strconv.FormatInt(atomic.AddInt64(&s.requestId, 1), 10))
+
+	headers.Set(v1.StreamType, v1.StreamTypeError)
+	errorStream, err := s.conn.CreateStream(headers)
+	if err != nil {
+		return nil, err
+	}
+	// 不会向 error stream 写入数据
+	_ = errorStream.Close()
+	go func() {
+		message, err := io.ReadAll(errorStream)
+		if err == nil && len(message) != 0 {
+			klog.Warningf("port forward session %s: %s", s.Id, message)
+		}
+	}()
+
+	headers.Set(v1.StreamType, v1.StreamTypeData)
+	dataStream, err := s.conn.CreateStream(headers)
+	if err != nil {
+		s.conn.RemoveStreams(errorStream)
+		return nil, err
+	}
+	return &streamConn{Stream: dataStream, errorStream: errorStream, session: s}, nil
+}
+
+// streamConn 将 data stream 封装为 net.Conn，用于 http.Transport
+type streamConn struct {
+	httpstream.Stream
+
+	errorStream httpstream.Stream
+	session     *session
+	closeOnce   sync.Once
+}
+
+func (c *streamConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		err = c.Stream.Close()
+		c.session.conn.RemoveStreams(c.Stream, c.errorStream)
+	})
+	return err
+}
+
+func (c *streamConn) LocalAddr() net.Addr { return streamAddr("pixiu") }
+func (c *streamConn) RemoteAddr() net.Addr {
+	return streamAddr(fmt.Sprintf("%s/%s:%d", c.session.Namespace, c.session.Pod, c.session.Port))
+}
+
+// stream 不支持超时，由会话的有效期和请求的 context 控制
+func (c *streamConn) SetDeadline(time.Time) error      { return nil }
+func (c *streamConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *streamConn) SetWriteDeadline(time.Time) error { return nil }
+
+type streamAddr string
+
+func (a streamAddr) Network() string { return "portforward" }
+func (a streamAddr) String() string  { return string(a) }
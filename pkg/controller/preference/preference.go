@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preference
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	maxPreferences     = 100
+	maxNameLength      = 128
+	maxPreferenceValue = 64 * 1024
+)
+
+type PreferenceGetter interface {
+	Preference() Interface
+}
+
+// Interface 当前登陆用户的前端设置，便于在不同的设备上保持一致
+type Interface interface {
+	Get(ctx context.Context) (types.Preferences, error)
+	// Update 仅更新请求中的设置，value 为 null 时删除该设置
+	Update(ctx context.Context, req types.UpdatePreferencesRequest) error
+}
+
+type preference struct {
+	factory db.ShareDaoFactory
+}
+
+func currentUserId(ctx context.Context) (int64, error) {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return 0, errors.NewError(err, http.StatusUnauthorized)
+	}
+	return user.Id, nil
+}
+
+func (p *preference) Get(ctx context.Context) (types.Preferences, error) {
+	uid, err := currentUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := p.factory.Preference().List(ctx, uid)
+	if err != nil {
+		klog.Errorf("failed to list user(%d) preferences: %v", uid, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	prefs := make(types.Preferences)
+	for _, object := range objects {
+		prefs[object.Name] = json.RawMessage(object.Value)
+	}
+	return prefs, nil
+}
+
+func (p *preference) Update(ctx context.Context, req types.UpdatePreferencesRequest) error {
+	uid, err := currentUserId(ctx)
+	if err != nil {
+		return err
+	}
+	if len(req) > maxPreferences {
+		return errors.NewError(fmt.Errorf("一次最多更新 %d 个设置", maxPreferences), http.StatusBadRequest)
+	}
+
+	var (
+		objects []model.UserPreference
+		deleted []string
+	)
+	for name, value := range req {
+		if len(name) == 0 || len(name) > maxNameLength {
+			return errors.NewError(fmt.Errorf("设置名称 %q 不合法", name), http.StatusBadRequest)
+		}
+		if len(value) > maxPreferenceValue {
+			return errors.NewError(fmt.Errorf("设置 %s 的值不能超过 %d 字节", name, maxPreferenceValue), http.StatusBadRequest)
+		}
+		if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+			deleted = append(deleted, name)
+			continue
+		}
+		objects = append(objects, model.UserPreference{Name: name, Value: string(value)})
+	}
+
+	if err = p.factory.Preference().Save(ctx, uid, objects, deleted); err != nil {
+		klog.Errorf("failed to save user(%d) preferences: %v", uid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func NewPreference(f db.ShareDaoFactory) *preference {
+	return &preference{
+		factory: f,
+	}
+}
@@ -0,0 +1,414 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package propagation
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/controller/fleet"
+	"github.com/caoyingjunz/pixiu/pkg/controller/helm"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	"github.com/caoyingjunz/pixiu/pkg/util/trace"
+)
+
+const (
+	// 单个集群分发失败时的自动重试次数
+	maxAttempts  = 3
+	retryBackoff = 5 * time.Second
+)
+
+type PropagationGetter interface {
+	Propagation() Interface
+}
+
+type Interface interface {
+	// Create 创建分发任务，并异步分发到所有目标集群
+	Create(ctx context.Context, req *types.CreatePropagationRequest) (*types.Propagation, error)
+	// Delete 删除分发任务，已分发到集群中的资源不会被删除
+	Delete(ctx context.Context, pid int64) error
+	Get(ctx context.Context, pid int64) (*types.Propagation, error)
+	List(ctx context.Context) ([]types.Propagation, error)
+
+	// Retry 重新分发失败的集群
+	Retry(ctx context.Context, pid int64) error
+}
+
+type propagation struct {
+	factory db.ShareDaoFactory
+
+	clusterGetter cluster.Interface
+	helmGetter    helm.Interface
+}
+
+func (p *propagation) Create(ctx context.Context, req *types.CreatePropagationRequest) (*types.Propagation, error) {
+	old, err := p.factory.Propagation().GetByName(ctx, req.Name)
+	if err != nil {
+		klog.Errorf("failed to get propagation %s: %v", req.Name, err)
+		return nil, errors.ErrServerInternal
+	}
+	if old != nil {
+		return nil, errors.ErrPropagationExists
+	}
+
+	values, err := req.Values.Marshal()
+	if err != nil {
+		return nil, errors.ErrInvalidRequest
+	}
+	object := &model.Propagation{
+		Name:      req.Name,
+		Kind:      req.Kind,
+		Namespace: req.Namespace,
+		Manifest:  req.Manifest,
+		Chart:     req.Chart,
+		Version:   req.Version,
+		Values:    values,
+	}
+	if req.Description != nil {
+		object.Description = *req.Description
+	}
+
+	targets, err := p.buildTargets(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, errors.NewError(fmt.Errorf("no target clusters"), http.StatusBadRequest)
+	}
+
+	if object, err = p.factory.Propagation().Create(ctx, object, targets); err != nil {
+		klog.Errorf("failed to create propagation %s: %v", req.Name, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	go p.propagate(object, targets)
+	return p.Get(ctx, object.Id)
+}
+
+// buildTargets 解析目标集群，指定分组时使用分组内的集群，clusters 中的 values 按集群名称覆盖
+func (p *propagation) buildTargets(ctx context.Context, req *types.CreatePropagationRequest) ([]model.PropagationTarget, error) {
+	overrides := make(map[string]types.PropagationValues)
+	names := make([]string, 0)
+	for _, c := range req.Clusters {
+		if _, ok := overrides[c.Cluster]; !ok {
+			names = append(names, c.Cluster)
+		}
+		overrides[c.Cluster] = c.Overrides
+	}
+	if len(req.Fleet) != 0 {
+		var err error
+		if names, err = fleet.ResolveClusters(ctx, p.factory, req.Fleet); err != nil {
+			return nil, err
+		}
+	}
+
+	targets := make([]model.PropagationTarget, 0, len(names))
+	for _, name := range names {
+		data, err := overrides[name].Marshal()
+		if err != nil {
+			return nil, errors.ErrInvalidRequest
+		}
+		targets = append(targets, model.PropagationTarget{
+			Cluster:   name,
+			Overrides: data,
+			Status:    types.PropagationPending,
+		})
+	}
+	return targets, nil
+}
+
+func (p *propagation) Delete(ctx context.Context, pid int64) error {
+	if err := p.factory.Propagation().Delete(ctx, pid); err != nil {
+		klog.Errorf("failed to delete propagation %d: %v", pid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (p *propagation) Get(ctx context.Context, pid int64) (*types.Propagation, error) {
+	object, err := p.get(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := p.factory.Propagation().ListTargets(ctx, pid)
+	if err != nil {
+		klog.Errorf("failed to list propagation %d targets: %v", pid, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	return p.model2Type(object, targets), nil
+}
+
+func (p *propagation) get(ctx context.Context, pid int64) (*model.Propagation, error) {
+	object, err := p.factory.Propagation().Get(ctx, pid)
+	if err != nil {
+		klog.Errorf("failed to get propagation %d: %v", pid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrPropagationNotFound
+	}
+	return object, nil
+}
+
+func (p *propagation) List(ctx context.Context) ([]types.Propagation, error) {
+	objects, err := p.factory.Propagation().List(ctx, db.WithOrderByDesc())
+	if err != nil {
+		klog.Errorf("failed to list propagations: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	propagations := make([]types.Propagation, len(objects))
+	for i, object := range objects {
+		targets, err := p.factory.Propagation().ListTargets(ctx, object.Id)
+		if err != nil {
+			klog.Errorf("failed to list propagation %d targets: %v", object.Id, err)
+			return nil, errors.ErrServerInternal
+		}
+		propagations[i] = *p.model2Type(&object, targets)
+	}
+	return propagations, nil
+}
+
+func (p *propagation) Retry(ctx context.Context, pid int64) error {
+	object, err := p.get(ctx, pid)
+	if err != nil {
+		return err
+	}
+	targets, err := p.factory.Propagation().ListTargets(ctx, pid, db.WithStatusIn(types.PropagationFailed))
+	if err != nil {
+		klog.Errorf("failed to list propagation %d targets: %v", pid, err)
+		return errors.ErrServerInternal
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(targets))
+	for i := range targets {
+		ids[i] = targets[i].Id
+		targets[i].Attempts = 0
+	}
+	if err = p.factory.Propagation().UpdateTargets(ctx, ids, map[string]interface{}{
+		"status":   types.PropagationPending,
+		"message":  "",
+		"attempts": 0,
+	}); err != nil {
+		klog.Errorf("failed to reset propagation %d targets: %v", pid, err)
+		return errors.ErrServerInternal
+	}
+
+	go p.propagate(object, targets)
+	return nil
+}
+
+// propagate 并发分发到各个集群，单个集群失败时按 retryBackoff 自动重试
+// 每次分发记录为一个 trace，各集群及每次重试分别记录为子 span
+func (p *propagation) propagate(object *model.Propagation, targets []model.PropagationTarget) {
+	ctx, span := trace.Start(context.Background(), "propagation.propagate",
+		trace.String("propagation.name", object.Name), trace.String("propagation.kind", object.Kind), trace.Int("propagation.targets", int64(len(targets))))
+
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(target model.PropagationTarget) {
+			defer wg.Done()
+
+			ctx, targetSpan := trace.Start(ctx, "propagation.target", trace.String("cluster", target.Cluster))
+			var err error
+			defer func() { targetSpan.End(err) }()
+
+			for target.Attempts < maxAttempts {
+				target.Attempts++
+				p.updateTarget(ctx, target.Id, types.PropagationRunning, "", target.Attempts)
+
+				attemptCtx, attemptSpan := trace.Start(ctx, "propagation.attempt", trace.String("cluster", target.Cluster), trace.Int("attempt", int64(target.Attempts)))
+				err = p.apply(attemptCtx, object, &target)
+				attemptSpan.End(err)
+				if err == nil {
+					p.updateTarget(ctx, target.Id, types.PropagationSucceeded, "", target.Attempts)
+					return
+				}
+				klog.Warningf("failed to propagate %s to cluster %s (attempt %d): %v", object.Name, target.Cluster, target.Attempts, err)
+				time.Sleep(retryBackoff * time.Duration(target.Attempts))
+			}
+			p.updateTarget(ctx, target.Id, types.PropagationFailed, err.Error(), target.Attempts)
+		}(targets[i])
+	}
+
+	go func() {
+		wg.Wait()
+		span.End(nil)
+	}()
+}
+
+func (p *propagation) updateTarget(ctx context.Context, targetId int64, status string, message string, attempts int) {
+	if err := p.factory.Propagation().UpdateTarget(ctx, targetId, map[string]interface{}{
+		"status":   status,
+		"message":  message,
+		"attempts": attempts,
+	}); err != nil {
+		klog.Errorf("failed to update propagation target %d: %v", targetId, err)
+	}
+}
+
+// apply 合并集群的 values 后分发，manifest 渲染模板并校验租户配额后 server-side apply，helm 不存在时安装否则升级
+// helm 渲染的工作负载不做配额校验
+func (p *propagation) apply(ctx context.Context, object *model.Propagation, target *model.PropagationTarget) error {
+	values, err := mergeValues(object.Values, target.Overrides)
+	if err != nil {
+		return err
+	}
+	cs, err := p.clusterGetter.GetClusterSetByName(ctx, target.Cluster)
+	if err != nil {
+		return err
+	}
+
+	switch object.Kind {
+	case model.PropagationKindManifest:
+		manifest, err := renderManifest(object.Manifest, target.Cluster, values)
+		if err != nil {
+			return err
+		}
+		objects, err := client.DecodeManifest(manifest)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objects {
+			if err = p.clusterGetter.AdmitObject(ctx, target.Cluster, object.Namespace, obj); err != nil {
+				return err
+			}
+		}
+		_, err = client.ApplyObjects(ctx, cs, object.Namespace, objects, false)
+		return err
+	case model.PropagationKindHelm:
+		release := p.helmGetter.Release(target.Cluster, object.Namespace)
+		form := &types.Release{
+			Name:    object.Name,
+			Chart:   object.Chart,
+			Version: object.Version,
+			Values:  values,
+		}
+		if _, err = release.Get(ctx, object.Name); err != nil {
+			_, err = release.Install(ctx, form)
+		} else {
+			_, err = release.Upgrade(ctx, form)
+		}
+		return err
+	default:
+		return fmt.Errorf("unsupported propagation kind %s", object.Kind)
+	}
+}
+
+// mergeValues 集群级别的 values 深度覆盖公共 values
+func mergeValues(base, overrides string) (map[string]interface{}, error) {
+	var baseValues, overrideValues types.PropagationValues
+	if len(base) != 0 {
+		if err := baseValues.Unmarshal(base); err != nil {
+			return nil, err
+		}
+	}
+	if len(overrides) != 0 {
+		if err := overrideValues.Unmarshal(overrides); err != nil {
+			return nil, err
+		}
+	}
+	if overrideValues == nil {
+		overrideValues = make(types.PropagationValues)
+	}
+	return chartutil.CoalesceTables(overrideValues, baseValues), nil
+}
+
+func renderManifest(manifest string, cluster string, values map[string]interface{}) ([]byte, error) {
+	tpl, err := template.New("manifest").Option("missingkey=error").Parse(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = tpl.Execute(&buf, map[string]interface{}{
+		"Cluster": cluster,
+		"Values":  values,
+	}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (p *propagation) model2Type(o *model.Propagation, targets []model.PropagationTarget) *types.Propagation {
+	var values types.PropagationValues
+	if len(o.Values) != 0 {
+		_ = values.Unmarshal(o.Values)
+	}
+
+	ts := make([]types.PropagationTarget, len(targets))
+	for i, target := range targets {
+		var overrides types.PropagationValues
+		if len(target.Overrides) != 0 {
+			_ = overrides.Unmarshal(target.Overrides)
+		}
+		ts[i] = types.PropagationTarget{
+			Id:          target.Id,
+			Cluster:     target.Cluster,
+			Overrides:   overrides,
+			Status:      target.Status,
+			Message:     target.Message,
+			Attempts:    target.Attempts,
+			GmtModified: target.GmtModified,
+		}
+	}
+
+	return &types.Propagation{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Name:        o.Name,
+		Kind:        o.Kind,
+		Namespace:   o.Namespace,
+		Manifest:    o.Manifest,
+		Chart:       o.Chart,
+		Version:     o.Version,
+		Values:      values,
+		Description: o.Description,
+		Targets:     ts,
+	}
+}
+
+func NewPropagation(f db.ShareDaoFactory, c cluster.Interface, h helm.Interface) *propagation {
+	return &propagation{
+		factory:       f,
+		clusterGetter: c,
+		helmGetter:    h,
+	}
+}
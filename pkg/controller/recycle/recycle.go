@@ -0,0 +1,244 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recycle
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/api/server/httputils"
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type RecycleGetter interface {
+	Recycle(cluster string) Interface
+}
+
+// Interface 工作负载回收站，删除前保存对象快照，过期前可以重新创建
+type Interface interface {
+	// Snapshot 删除工作负载前保存快照，未开启回收站或者对象不存在时忽略
+	Snapshot(ctx context.Context, gvr schema.GroupVersionResource, namespace string, name string) error
+	// Restore 使用快照重新创建对象，成功后从回收站中移除
+	Restore(ctx context.Context, rid int64) error
+	Delete(ctx context.Context, rid int64) error
+	Get(ctx context.Context, rid int64) (*types.RecycledObject, error)
+	List(ctx context.Context) ([]types.RecycledObject, error)
+}
+
+type recycle struct {
+	cc      config.Config
+	factory db.ShareDaoFactory
+	cluster string
+
+	clusterGetter cluster.Interface
+}
+
+func (r *recycle) Snapshot(ctx context.Context, gvr schema.GroupVersionResource, namespace string, name string) error {
+	if !r.cc.Recycle.Enable {
+		return nil
+	}
+	cs, err := r.clusterGetter.GetClusterSetByName(ctx, r.cluster)
+	if err != nil {
+		return err
+	}
+	object, err := cs.Dynamic.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		// 对象不存在时由 apiserver 返回错误
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	data, err := json.Marshal(object.Object)
+	if err != nil {
+		return err
+	}
+
+	var deletedBy string
+	if user, err := httputils.GetUserFromRequest(ctx); err == nil {
+		deletedBy = user.Name
+	}
+	if _, err = r.factory.Recycle().Create(ctx, &model.RecycledObject{
+		Cluster:   r.cluster,
+		Namespace: namespace,
+		Group:     gvr.Group,
+		Version:   gvr.Version,
+		Resource:  gvr.Resource,
+		Kind:      object.GetKind(),
+		Name:      name,
+		DeletedBy: deletedBy,
+		ExpireAt:  time.Now().AddDate(0, 0, r.cc.Recycle.DaysReserved),
+		Manifest:  string(data),
+	}); err != nil {
+		klog.Errorf("failed to snapshot %s %s/%s: %v", gvr.Resource, namespace, name, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (r *recycle) Restore(ctx context.Context, rid int64) error {
+	object, err := r.get(ctx, rid)
+	if err != nil {
+		return err
+	}
+	cs, err := r.clusterGetter.GetClusterSetByName(ctx, r.cluster)
+	if err != nil {
+		return err
+	}
+
+	var obj unstructured.Unstructured
+	if err = json.Unmarshal([]byte(object.Manifest), &obj.Object); err != nil {
+		klog.Errorf("failed to unmarshal recycled object(%d): %v", rid, err)
+		return errors.ErrServerInternal
+	}
+	cleanObject(&obj)
+	if err = r.clusterGetter.AdmitObject(ctx, r.cluster, object.Namespace, &obj); err != nil {
+		return err
+	}
+
+	gvr := schema.GroupVersionResource{Group: object.Group, Version: object.Version, Resource: object.Resource}
+	if _, err = cs.Dynamic.Resource(gvr).Namespace(object.Namespace).Create(ctx, &obj, metav1.CreateOptions{}); err != nil {
+		klog.Errorf("failed to restore %s %s/%s: %v", object.Resource, object.Namespace, object.Name, err)
+		if apierrors.IsAlreadyExists(err) {
+			return errors.NewError(err, http.StatusConflict)
+		}
+		return err
+	}
+
+	if err = r.factory.Recycle().Delete(ctx, rid); err != nil {
+		klog.Errorf("failed to delete recycled object(%d): %v", rid, err)
+	}
+	return nil
+}
+
+// cleanObject 清理由 apiserver 维护的字段，重新创建时使用新的 uid
+func cleanObject(obj *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(obj.Object, "status")
+	for _, field := range []string{"uid", "resourceVersion", "creationTimestamp", "deletionTimestamp",
+		"deletionGracePeriodSeconds", "generation", "managedFields", "selfLink", "ownerReferences"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+
+	// job 的 selector 和标签包含旧的 controller-uid，未手动指定 selector 时由 apiserver 重新生成，否则创建时校验失败
+	if obj.GetAPIVersion() == "batch/v1" && obj.GetKind() == "Job" {
+		if manual, _, _ := unstructured.NestedBool(obj.Object, "spec", "manualSelector"); !manual {
+			unstructured.RemoveNestedField(obj.Object, "spec", "selector")
+			for _, label := range []string{"controller-uid", "batch.kubernetes.io/controller-uid"} {
+				unstructured.RemoveNestedField(obj.Object, "metadata", "labels", label)
+				unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "labels", label)
+			}
+		}
+	}
+}
+
+func (r *recycle) Delete(ctx context.Context, rid int64) error {
+	if _, err := r.get(ctx, rid); err != nil {
+		return err
+	}
+	if err := r.factory.Recycle().Delete(ctx, rid); err != nil {
+		klog.Errorf("failed to delete recycled object(%d): %v", rid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (r *recycle) Get(ctx context.Context, rid int64) (*types.RecycledObject, error) {
+	object, err := r.get(ctx, rid)
+	if err != nil {
+		return nil, err
+	}
+	return r.model2Type(object, true), nil
+}
+
+func (r *recycle) get(ctx context.Context, rid int64) (*model.RecycledObject, error) {
+	if !r.cc.Recycle.Enable {
+		return nil, errors.ErrRecycleNotEnabled
+	}
+	object, err := r.factory.Recycle().Get(ctx, rid)
+	if err != nil {
+		klog.Errorf("failed to get recycled object(%d): %v", rid, err)
+		return nil, errors.ErrServerInternal
+	}
+	// 不允许跨集群操作，已过期未清理的对象视为不存在
+	if object == nil || object.Cluster != r.cluster || object.ExpireAt.Before(time.Now()) {
+		return nil, errors.ErrRecycledObjectNotFound
+	}
+	return object, nil
+}
+
+func (r *recycle) List(ctx context.Context) ([]types.RecycledObject, error) {
+	if !r.cc.Recycle.Enable {
+		return nil, errors.ErrRecycleNotEnabled
+	}
+	objects, err := r.factory.Recycle().List(ctx, db.WithCluster(r.cluster), db.WithNotExpired(), db.WithOrderByDesc())
+	if err != nil {
+		klog.Errorf("failed to list cluster(%s) recycled objects: %v", r.cluster, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	items := make([]types.RecycledObject, len(objects))
+	for i, object := range objects {
+		items[i] = *r.model2Type(&object, false)
+	}
+	return items, nil
+}
+
+func (r *recycle) model2Type(o *model.RecycledObject, withManifest bool) *types.RecycledObject {
+	object := &types.RecycledObject{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Cluster:    o.Cluster,
+		Namespace:  o.Namespace,
+		APIVersion: schema.GroupVersion{Group: o.Group, Version: o.Version}.String(),
+		Kind:       o.Kind,
+		Name:       o.Name,
+		DeletedBy:  o.DeletedBy,
+		ExpireAt:   o.ExpireAt,
+	}
+	if withManifest {
+		object.Manifest = o.Manifest
+	}
+	return object
+}
+
+func NewRecycle(cfg config.Config, f db.ShareDaoFactory, clusterName string, c cluster.Interface) *recycle {
+	return &recycle{
+		cc:            cfg,
+		factory:       f,
+		cluster:       clusterName,
+		clusterGetter: c,
+	}
+}
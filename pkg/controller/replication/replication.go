@@ -0,0 +1,266 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type ReplicationGetter interface {
+	Replication() Interface
+}
+
+// Interface 管理 Secret 和 ConfigMap 的跨集群同步任务，源对象变化时由控制器同步到所有目标
+type Interface interface {
+	Create(ctx context.Context, req *types.CreateReplicationRequest) error
+	Update(ctx context.Context, rid int64, req *types.UpdateReplicationRequest) error
+	// Delete 删除同步任务，同时清理已同步到目标的对象
+	Delete(ctx context.Context, rid int64) error
+	Get(ctx context.Context, rid int64) (*types.Replication, error)
+	List(ctx context.Context) ([]types.Replication, error)
+
+	// Sync 立即触发一次同步
+	Sync(ctx context.Context, rid int64) error
+
+	// Run 启动同步控制器
+	Run(ctx context.Context, workers int) error
+}
+
+var replicationQueue workqueue.RateLimitingInterface
+
+func init() {
+	replicationQueue = workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "replications")
+}
+
+type replication struct {
+	factory       db.ShareDaoFactory
+	clusterGetter cluster.Interface
+}
+
+func (r *replication) Create(ctx context.Context, req *types.CreateReplicationRequest) error {
+	if err := validateTargets(req.Cluster, req.Namespace, req.Targets); err != nil {
+		return err
+	}
+	old, err := r.factory.Replication().GetByName(ctx, req.Name)
+	if err != nil {
+		klog.Errorf("failed to get replication %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	if old != nil {
+		return errors.ErrReplicationExists
+	}
+
+	targets, err := types.ReplicationTargets(req.Targets).Marshal()
+	if err != nil {
+		return errors.ErrInvalidRequest
+	}
+	object := &model.Replication{
+		Name:       req.Name,
+		Cluster:    req.Cluster,
+		Namespace:  req.Namespace,
+		Kind:       req.Kind,
+		SourceName: req.SourceName,
+		Targets:    targets,
+	}
+	if req.Description != nil {
+		object.Description = *req.Description
+	}
+	if object, err = r.factory.Replication().Create(ctx, object); err != nil {
+		klog.Errorf("failed to create replication %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+
+	replicationQueue.Add(object.Id)
+	return nil
+}
+
+func (r *replication) Update(ctx context.Context, rid int64, req *types.UpdateReplicationRequest) error {
+	object, err := r.get(ctx, rid)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	var removed []types.ReplicationTarget
+	if req.Targets != nil {
+		if err = validateTargets(object.Cluster, object.Namespace, *req.Targets); err != nil {
+			return err
+		}
+		targets, err := types.ReplicationTargets(*req.Targets).Marshal()
+		if err != nil {
+			return errors.ErrInvalidRequest
+		}
+		updates["targets"] = targets
+		removed = diffTargets(parseTargets(object), *req.Targets)
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if err = r.factory.Replication().Update(ctx, rid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update replication %d: %v", rid, err)
+		return errors.ErrServerInternal
+	}
+
+	// 清理已移除的目标中的对象
+	for _, target := range removed {
+		if err = r.deleteReplica(ctx, object, target); err != nil {
+			klog.Warningf("failed to clean replica of %s in %s/%s: %v", object.Name, target.Cluster, target.Namespace, err)
+		}
+	}
+	replicationQueue.Add(rid)
+	return nil
+}
+
+func (r *replication) Delete(ctx context.Context, rid int64) error {
+	object, err := r.get(ctx, rid)
+	if err != nil {
+		return err
+	}
+	if err = r.factory.Replication().Delete(ctx, rid); err != nil {
+		klog.Errorf("failed to delete replication %d: %v", rid, err)
+		return errors.ErrServerInternal
+	}
+
+	for _, target := range parseTargets(object) {
+		if err = r.deleteReplica(ctx, object, target); err != nil {
+			klog.Warningf("failed to clean replica of %s in %s/%s: %v", object.Name, target.Cluster, target.Namespace, err)
+		}
+	}
+	return nil
+}
+
+func (r *replication) Get(ctx context.Context, rid int64) (*types.Replication, error) {
+	object, err := r.get(ctx, rid)
+	if err != nil {
+		return nil, err
+	}
+	return r.model2Type(object), nil
+}
+
+func (r *replication) get(ctx context.Context, rid int64) (*model.Replication, error) {
+	object, err := r.factory.Replication().Get(ctx, rid)
+	if err != nil {
+		klog.Errorf("failed to get replication %d: %v", rid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrReplicationNotFound
+	}
+	return object, nil
+}
+
+func (r *replication) List(ctx context.Context) ([]types.Replication, error) {
+	objects, err := r.factory.Replication().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list replications: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	replications := make([]types.Replication, len(objects))
+	for i, object := range objects {
+		replications[i] = *r.model2Type(&object)
+	}
+	return replications, nil
+}
+
+func (r *replication) Sync(ctx context.Context, rid int64) error {
+	if _, err := r.get(ctx, rid); err != nil {
+		return err
+	}
+	replicationQueue.Add(rid)
+	return nil
+}
+
+// validateTargets 目标不能包含源对象所在的命名空间
+func validateTargets(cluster, namespace string, targets []types.ReplicationTarget) error {
+	for _, target := range targets {
+		if target.Cluster == cluster && target.Namespace == namespace {
+			return errors.NewError(fmt.Errorf("target %s/%s is the source namespace", cluster, namespace), http.StatusBadRequest)
+		}
+	}
+	return nil
+}
+
+// diffTargets 返回 old 中存在而 cur 中不存在的目标
+func diffTargets(old, cur []types.ReplicationTarget) []types.ReplicationTarget {
+	exists := make(map[types.ReplicationTarget]struct{})
+	for _, target := range cur {
+		exists[target] = struct{}{}
+	}
+
+	var removed []types.ReplicationTarget
+	for _, target := range old {
+		if _, ok := exists[target]; !ok {
+			removed = append(removed, target)
+		}
+	}
+	return removed
+}
+
+func parseTargets(o *model.Replication) types.ReplicationTargets {
+	var targets types.ReplicationTargets
+	if len(o.Targets) != 0 {
+		if err := targets.Unmarshal(o.Targets); err != nil {
+			klog.Warningf("failed to unmarshal replication(%d) targets: %v", o.Id, err)
+		}
+	}
+	return targets
+}
+
+func (r *replication) model2Type(o *model.Replication) *types.Replication {
+	return &types.Replication{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Name:         o.Name,
+		Cluster:      o.Cluster,
+		Namespace:    o.Namespace,
+		Kind:         o.Kind,
+		SourceName:   o.SourceName,
+		Targets:      parseTargets(o),
+		Status:       o.Status,
+		Message:      o.Message,
+		LastSyncTime: o.LastSyncTime,
+		Description:  o.Description,
+	}
+}
+
+func NewReplication(f db.ShareDaoFactory, c cluster.Interface) *replication {
+	return &replication{
+		factory:       f,
+		clusterGetter: c,
+	}
+}
@@ -0,0 +1,319 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	// 刷新源对象索引并为新集群注册监听的周期
+	refreshInterval = 10 * time.Second
+	// 全量同步的周期，用于修复目标对象被手动修改的情况
+	resyncInterval = 5 * time.Minute
+)
+
+// sourceIndex 源对象到同步任务的索引，key 为 cluster/kind/namespace/name
+type sourceIndex struct {
+	sync.RWMutex
+	items map[string][]int64
+}
+
+func (s *sourceIndex) set(items map[string][]int64) {
+	s.Lock()
+	defer s.Unlock()
+	s.items = items
+}
+
+func (s *sourceIndex) get(key string) []int64 {
+	s.RLock()
+	defer s.RUnlock()
+	return s.items[key]
+}
+
+var (
+	index = &sourceIndex{items: make(map[string][]int64)}
+
+	// 已注册监听的 informer，避免重复注册
+	trackedInformers sync.Map
+)
+
+func indexKey(cluster, kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", cluster, kind, namespace, name)
+}
+
+func (r *replication) Run(ctx context.Context, workers int) error {
+	klog.Infof("starting replication manager")
+	go wait.UntilWithContext(ctx, r.refresh, refreshInterval)
+	go wait.UntilWithContext(ctx, r.resync, resyncInterval)
+
+	for i := 0; i < workers; i++ {
+		go wait.UntilWithContext(ctx, r.worker, time.Second)
+	}
+	return nil
+}
+
+// refresh 重建源对象索引，并监听源集群的 Secret 和 ConfigMap 变化
+func (r *replication) refresh(ctx context.Context) {
+	objects, err := r.factory.Replication().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list replications: %v", err)
+		return
+	}
+
+	items := make(map[string][]int64)
+	for _, object := range objects {
+		key := indexKey(object.Cluster, object.Kind, object.Namespace, object.SourceName)
+		items[key] = append(items[key], object.Id)
+	}
+	index.set(items)
+
+	for _, object := range objects {
+		cs, err := r.clusterGetter.GetClusterSetByName(ctx, object.Cluster)
+		if err != nil {
+			klog.Warningf("failed to get cluster(%s) clientSet: %v", object.Cluster, err)
+			continue
+		}
+		r.track(object.Cluster, cs)
+	}
+}
+
+func (r *replication) resync(ctx context.Context) {
+	objects, err := r.factory.Replication().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list replications: %v", err)
+		return
+	}
+	for _, object := range objects {
+		replicationQueue.Add(object.Id)
+	}
+}
+
+func (r *replication) track(cluster string, cs client.ClusterSet) {
+	if cs.Informer == nil {
+		return
+	}
+	if _, loaded := trackedInformers.LoadOrStore(cs.Informer, struct{}{}); loaded {
+		return
+	}
+
+	handler := func(kind string) cache.ResourceEventHandlerFuncs {
+		enqueue := func(obj interface{}) {
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return
+			}
+			namespace, name, err := cache.SplitMetaNamespaceKey(key)
+			if err != nil {
+				return
+			}
+			for _, rid := range index.get(indexKey(cluster, kind, namespace, name)) {
+				replicationQueue.Add(rid)
+			}
+		}
+		return cache.ResourceEventHandlerFuncs{
+			AddFunc:    enqueue,
+			UpdateFunc: func(oldObj, newObj interface{}) { enqueue(newObj) },
+			DeleteFunc: enqueue,
+		}
+	}
+	cs.Informer.Shared.Core().V1().Secrets().Informer().AddEventHandler(handler(model.ReplicationKindSecret))
+	cs.Informer.Shared.Core().V1().ConfigMaps().Informer().AddEventHandler(handler(model.ReplicationKindConfigMap))
+}
+
+func (r *replication) worker(ctx context.Context) {
+	for r.process(ctx) {
+	}
+}
+
+func (r *replication) process(ctx context.Context) bool {
+	key, quit := replicationQueue.Get()
+	if quit {
+		return false
+	}
+	defer replicationQueue.Done(key)
+
+	if err := r.syncHandler(ctx, key.(int64)); err != nil {
+		klog.Warningf("failed to sync replication(%d): %v", key, err)
+		replicationQueue.AddRateLimited(key)
+		return true
+	}
+	replicationQueue.Forget(key)
+	return true
+}
+
+// syncHandler 将源对象同步到所有目标，返回错误时重新入队
+func (r *replication) syncHandler(ctx context.Context, rid int64) error {
+	object, err := r.factory.Replication().Get(ctx, rid)
+	if err != nil {
+		return err
+	}
+	// 同步任务已被删除
+	if object == nil {
+		return nil
+	}
+
+	syncErr := r.replicate(ctx, object)
+	status, message := model.ReplicationSynced, ""
+	if syncErr != nil {
+		status, message = model.ReplicationFailed, syncErr.Error()
+	}
+	if err = r.factory.Replication().UpdateStatus(ctx, rid, status, message); err != nil {
+		klog.Errorf("failed to update replication(%d) status: %v", rid, err)
+	}
+	return syncErr
+}
+
+func (r *replication) replicate(ctx context.Context, object *model.Replication) error {
+	cs, err := r.clusterGetter.GetClusterSetByName(ctx, object.Cluster)
+	if err != nil {
+		return err
+	}
+
+	var source metav1.Object
+	switch object.Kind {
+	case model.ReplicationKindSecret:
+		source, err = cs.Informer.SecretsLister().Secrets(object.Namespace).Get(object.SourceName)
+	case model.ReplicationKindConfigMap:
+		source, err = cs.Informer.ConfigMapsLister().ConfigMaps(object.Namespace).Get(object.SourceName)
+	default:
+		return fmt.Errorf("unsupported kind %s", object.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get source %s %s/%s: %v", object.Kind, object.Namespace, object.SourceName, err)
+	}
+
+	var errs []error
+	for _, target := range parseTargets(object) {
+		if err = r.replicateTo(ctx, object, source, target); err != nil {
+			errs = append(errs, fmt.Errorf("%s/%s: %v", target.Cluster, target.Namespace, err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (r *replication) replicateTo(ctx context.Context, object *model.Replication, source metav1.Object, target types.ReplicationTarget) error {
+	cs, err := r.clusterGetter.GetClusterSetByName(ctx, target.Cluster)
+	if err != nil {
+		return err
+	}
+
+	labels := make(map[string]string)
+	for k, v := range source.GetLabels() {
+		labels[k] = v
+	}
+	labels[types.ReplicatedFromLabelKey] = object.Name
+	meta := metav1.ObjectMeta{Name: object.SourceName, Namespace: target.Namespace, Labels: labels}
+
+	switch s := source.(type) {
+	case *v1.Secret:
+		secrets := cs.Client.CoreV1().Secrets(target.Namespace)
+		replica := &v1.Secret{ObjectMeta: meta, Type: s.Type, Data: s.Data}
+		old, err := secrets.Get(ctx, object.SourceName, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			_, err = secrets.Create(ctx, replica, metav1.CreateOptions{})
+			return err
+		}
+		if err = checkManaged(old, object.Name); err != nil {
+			return err
+		}
+		replica.ResourceVersion = old.ResourceVersion
+		_, err = secrets.Update(ctx, replica, metav1.UpdateOptions{})
+		return err
+	case *v1.ConfigMap:
+		configMaps := cs.Client.CoreV1().ConfigMaps(target.Namespace)
+		replica := &v1.ConfigMap{ObjectMeta: meta, Data: s.Data, BinaryData: s.BinaryData}
+		old, err := configMaps.Get(ctx, object.SourceName, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			_, err = configMaps.Create(ctx, replica, metav1.CreateOptions{})
+			return err
+		}
+		if err = checkManaged(old, object.Name); err != nil {
+			return err
+		}
+		replica.ResourceVersion = old.ResourceVersion
+		_, err = configMaps.Update(ctx, replica, metav1.UpdateOptions{})
+		return err
+	}
+	return nil
+}
+
+// checkManaged 不覆盖非当前同步任务创建的同名对象
+func checkManaged(object metav1.Object, name string) error {
+	if object.GetLabels()[types.ReplicatedFromLabelKey] != name {
+		return fmt.Errorf("%s/%s already exists and is not managed by replication %s", object.GetNamespace(), object.GetName(), name)
+	}
+	return nil
+}
+
+// deleteReplica 删除目标中由同步任务创建的对象
+func (r *replication) deleteReplica(ctx context.Context, object *model.Replication, target types.ReplicationTarget) error {
+	cs, err := r.clusterGetter.GetClusterSetByName(ctx, target.Cluster)
+	if err != nil {
+		return err
+	}
+
+	var replica metav1.Object
+	switch object.Kind {
+	case model.ReplicationKindSecret:
+		replica, err = cs.Client.CoreV1().Secrets(target.Namespace).Get(ctx, object.SourceName, metav1.GetOptions{})
+	case model.ReplicationKindConfigMap:
+		replica, err = cs.Client.CoreV1().ConfigMaps(target.Namespace).Get(ctx, object.SourceName, metav1.GetOptions{})
+	default:
+		return nil
+	}
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if checkManaged(replica, object.Name) != nil {
+		return nil
+	}
+
+	if object.Kind == model.ReplicationKindSecret {
+		err = cs.Client.CoreV1().Secrets(target.Namespace).Delete(ctx, object.SourceName, metav1.DeleteOptions{})
+	} else {
+		err = cs.Client.CoreV1().ConfigMaps(target.Namespace).Delete(ctx, object.SourceName, metav1.DeleteOptions{})
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scaling
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+type ScalingGetter interface {
+	Scaling(cluster string) Interface
+}
+
+// Interface 管理集群中 deployment 的定时伸缩计划，由 jobmanager 的 scaling-scheduler 执行
+type Interface interface {
+	Create(ctx context.Context, req *types.CreateScalingScheduleRequest) error
+	Update(ctx context.Context, sid int64, req *types.UpdateScalingScheduleRequest) error
+	Delete(ctx context.Context, sid int64) error
+	Get(ctx context.Context, sid int64) (*types.ScalingSchedule, error)
+	List(ctx context.Context) ([]types.ScalingSchedule, error)
+
+	// ListHistories 获取计划的执行记录
+	ListHistories(ctx context.Context, sid int64) ([]types.ScalingHistory, error)
+}
+
+type scaling struct {
+	factory db.ShareDaoFactory
+	cluster string
+
+	clusterGetter cluster.Interface
+}
+
+func (s *scaling) Create(ctx context.Context, req *types.CreateScalingScheduleRequest) error {
+	if _, err := cron.ParseStandard(req.Schedule); err != nil {
+		return errors.NewError(fmt.Errorf("invalid schedule %q: %v", req.Schedule, err), http.StatusBadRequest)
+	}
+	cs, err := s.clusterGetter.GetClusterSetByName(ctx, s.cluster)
+	if err != nil {
+		return err
+	}
+	if _, err = cs.Client.AppsV1().Deployments(req.Namespace).Get(ctx, req.Name, metav1.GetOptions{}); err != nil {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+
+	object := &model.ScalingSchedule{
+		Cluster:   s.cluster,
+		Namespace: req.Namespace,
+		Name:      req.Name,
+		Schedule:  req.Schedule,
+		Replicas:  *req.Replicas,
+		Enabled:   true,
+	}
+	if req.Enabled != nil {
+		object.Enabled = *req.Enabled
+	}
+	if req.Description != nil {
+		object.Description = *req.Description
+	}
+	if _, err = s.factory.Scaling().Create(ctx, object); err != nil {
+		klog.Errorf("failed to create scaling schedule for %s/%s: %v", req.Namespace, req.Name, err)
+		return errors.ErrServerInternal
+	}
+
+	return nil
+}
+
+func (s *scaling) Update(ctx context.Context, sid int64, req *types.UpdateScalingScheduleRequest) error {
+	if _, err := s.get(ctx, sid); err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Schedule != nil {
+		if _, err := cron.ParseStandard(*req.Schedule); err != nil {
+			return errors.NewError(fmt.Errorf("invalid schedule %q: %v", *req.Schedule, err), http.StatusBadRequest)
+		}
+		updates["schedule"] = *req.Schedule
+	}
+	if req.Replicas != nil {
+		updates["replicas"] = *req.Replicas
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if err := s.factory.Scaling().Update(ctx, sid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update scaling schedule %d: %v", sid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (s *scaling) Delete(ctx context.Context, sid int64) error {
+	if _, err := s.get(ctx, sid); err != nil {
+		return err
+	}
+	if err := s.factory.Scaling().Delete(ctx, sid); err != nil {
+		klog.Errorf("failed to delete scaling schedule %d: %v", sid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (s *scaling) Get(ctx context.Context, sid int64) (*types.ScalingSchedule, error) {
+	object, err := s.get(ctx, sid)
+	if err != nil {
+		return nil, err
+	}
+	return s.model2Type(object), nil
+}
+
+func (s *scaling) get(ctx context.Context, sid int64) (*model.ScalingSchedule, error) {
+	object, err := s.factory.Scaling().Get(ctx, sid)
+	if err != nil {
+		klog.Errorf("failed to get scaling schedule %d: %v", sid, err)
+		return nil, errors.ErrServerInternal
+	}
+	// 不允许跨集群操作伸缩计划
+	if object == nil || object.Cluster != s.cluster {
+		return nil, errors.ErrScalingNotFound
+	}
+	return object, nil
+}
+
+func (s *scaling) List(ctx context.Context) ([]types.ScalingSchedule, error) {
+	objects, err := s.factory.Scaling().List(ctx, db.WithCluster(s.cluster))
+	if err != nil {
+		klog.Errorf("failed to list cluster(%s) scaling schedules: %v", s.cluster, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	schedules := make([]types.ScalingSchedule, len(objects))
+	for i, object := range objects {
+		schedules[i] = *s.model2Type(&object)
+	}
+	return schedules, nil
+}
+
+func (s *scaling) ListHistories(ctx context.Context, sid int64) ([]types.ScalingHistory, error) {
+	if _, err := s.get(ctx, sid); err != nil {
+		return nil, err
+	}
+	objects, err := s.factory.Scaling().ListHistories(ctx, sid, db.WithOrderByDesc())
+	if err != nil {
+		klog.Errorf("failed to list scaling schedule %d histories: %v", sid, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	histories := make([]types.ScalingHistory, len(objects))
+	for i, o := range objects {
+		histories[i] = types.ScalingHistory{
+			PixiuMeta: types.PixiuMeta{
+				Id:              o.Id,
+				ResourceVersion: o.ResourceVersion,
+			},
+			TimeMeta: types.TimeMeta{
+				GmtCreate:   o.GmtCreate,
+				GmtModified: o.GmtModified,
+			},
+			ScheduleId:   o.ScheduleId,
+			FromReplicas: o.FromReplicas,
+			ToReplicas:   o.ToReplicas,
+			Status:       o.Status,
+			Message:      o.Message,
+		}
+	}
+	return histories, nil
+}
+
+func (s *scaling) model2Type(o *model.ScalingSchedule) *types.ScalingSchedule {
+	schedule := &types.ScalingSchedule{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Cluster:          o.Cluster,
+		Namespace:        o.Namespace,
+		Name:             o.Name,
+		Schedule:         o.Schedule,
+		Replicas:         o.Replicas,
+		Enabled:          o.Enabled,
+		LastScheduleTime: o.LastScheduleTime,
+		Description:      o.Description,
+	}
+	if o.Enabled {
+		if sched, err := cron.ParseStandard(o.Schedule); err == nil {
+			next := sched.Next(time.Now())
+			schedule.NextScheduleTime = &next
+		}
+	}
+	return schedule
+}
+
+func NewScaling(f db.ShareDaoFactory, clusterName string, c cluster.Interface) *scaling {
+	return &scaling{
+		factory:       f,
+		cluster:       clusterName,
+		clusterGetter: c,
+	}
+}
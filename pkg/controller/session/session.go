@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+	sessionutil "github.com/caoyingjunz/pixiu/pkg/util/session"
+)
+
+type SessionGetter interface {
+	Session() Interface
+}
+
+// Interface 查询进行中的 webshell，日志和端口转发会话，多副本部署时包括所有实例的会话
+type Interface interface {
+	Get(ctx context.Context, sid string) (*types.Session, error)
+	List(ctx context.Context, opts types.ListSessionOptions) ([]types.Session, error)
+}
+
+type session struct{}
+
+func (s *session) Get(ctx context.Context, sid string) (*types.Session, error) {
+	object, err := sessionutil.Get(ctx, sid)
+	if err != nil {
+		klog.Errorf("failed to get session %s: %v", sid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrSessionNotFound
+	}
+	return object, nil
+}
+
+func (s *session) List(ctx context.Context, opts types.ListSessionOptions) ([]types.Session, error) {
+	objects, err := sessionutil.List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list sessions: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	sessions := make([]types.Session, 0)
+	for _, object := range objects {
+		if len(opts.Kind) != 0 && object.Kind != opts.Kind {
+			continue
+		}
+		if len(opts.Cluster) != 0 && object.Cluster != opts.Cluster {
+			continue
+		}
+		if len(opts.User) != 0 && object.User != opts.User {
+			continue
+		}
+		sessions = append(sessions, object)
+	}
+	return sessions, nil
+}
+
+func NewSession() *session {
+	return &session{}
+}
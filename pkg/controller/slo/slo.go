@@ -0,0 +1,321 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slo
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	defaultWindowDays = 30
+
+	// 多窗口的 burn rate 告警阈值，分别对应 1h 内消耗 2% 和 6h 内消耗 5% 的 30 天错误预算
+	fastBurnThreshold = 14.4
+	slowBurnThreshold = 6
+)
+
+type SLOGetter interface {
+	SLO() Interface
+}
+
+// Interface 管理集群和租户的 SLO，由 jobmanager 的 slo-evaluator 定期采样
+type Interface interface {
+	Create(ctx context.Context, req *types.CreateSLORequest) error
+	Update(ctx context.Context, sid int64, req *types.UpdateSLORequest) error
+	Delete(ctx context.Context, sid int64) error
+	Get(ctx context.Context, sid int64) (*types.SLO, error)
+	List(ctx context.Context, opts types.ListSLOOptions) ([]types.SLO, error)
+
+	// GetStatus 计算 SLO 在统计窗口内的达成率，剩余错误预算以及 burn rate
+	GetStatus(ctx context.Context, sid int64) (*types.SLOStatus, error)
+	ListStatus(ctx context.Context, opts types.ListSLOOptions) ([]types.SLOStatus, error)
+}
+
+type slo struct {
+	factory db.ShareDaoFactory
+}
+
+func (s *slo) Create(ctx context.Context, req *types.CreateSLORequest) error {
+	if err := s.validate(ctx, req); err != nil {
+		return err
+	}
+	old, err := s.factory.SLO().GetByName(ctx, req.Name)
+	if err != nil {
+		klog.Errorf("failed to get slo %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	if old != nil {
+		return errors.ErrSLOExists
+	}
+
+	object := &model.SLO{
+		Name:          req.Name,
+		Cluster:       req.Cluster,
+		Tenant:        req.Tenant,
+		Kind:          req.Kind,
+		Objective:     req.Objective,
+		WindowDays:    req.WindowDays,
+		PrometheusURL: req.PrometheusURL,
+		Query:         req.Query,
+		Enabled:       true,
+		Description:   req.Description,
+	}
+	if object.WindowDays == 0 {
+		object.WindowDays = defaultWindowDays
+	}
+	if req.Enabled != nil {
+		object.Enabled = *req.Enabled
+	}
+	if _, err = s.factory.SLO().Create(ctx, object); err != nil {
+		klog.Errorf("failed to create slo %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+
+	return nil
+}
+
+func (s *slo) validate(ctx context.Context, req *types.CreateSLORequest) error {
+	if req.Kind == model.SLOKindPrometheus {
+		if len(req.PrometheusURL) == 0 || len(req.Query) == 0 {
+			return errors.NewError(fmt.Errorf("prometheus_url and query are required for prometheus slo"), http.StatusBadRequest)
+		}
+	} else if len(req.Cluster) == 0 {
+		return errors.NewError(fmt.Errorf("cluster is required for %s slo", req.Kind), http.StatusBadRequest)
+	}
+	if len(req.Cluster) == 0 {
+		return nil
+	}
+
+	cluster, err := s.factory.Cluster().GetClusterByName(ctx, req.Cluster)
+	if err != nil {
+		klog.Errorf("failed to get cluster %s: %v", req.Cluster, err)
+		return errors.ErrServerInternal
+	}
+	if cluster == nil {
+		return errors.ErrClusterNotFound
+	}
+	return nil
+}
+
+func (s *slo) Update(ctx context.Context, sid int64, req *types.UpdateSLORequest) error {
+	object, err := s.get(ctx, sid)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Objective != nil {
+		updates["objective"] = *req.Objective
+	}
+	if req.WindowDays != nil {
+		updates["window_days"] = *req.WindowDays
+	}
+	if req.PrometheusURL != nil || req.Query != nil {
+		if object.Kind != model.SLOKindPrometheus {
+			return errors.NewError(fmt.Errorf("prometheus_url and query are only supported by prometheus slo"), http.StatusBadRequest)
+		}
+		if req.PrometheusURL != nil {
+			updates["prometheus_url"] = *req.PrometheusURL
+		}
+		if req.Query != nil {
+			updates["query"] = *req.Query
+		}
+	}
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if err = s.factory.SLO().Update(ctx, sid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update slo %d: %v", sid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (s *slo) Delete(ctx context.Context, sid int64) error {
+	if _, err := s.get(ctx, sid); err != nil {
+		return err
+	}
+	if err := s.factory.SLO().Delete(ctx, sid); err != nil {
+		klog.Errorf("failed to delete slo %d: %v", sid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (s *slo) Get(ctx context.Context, sid int64) (*types.SLO, error) {
+	object, err := s.get(ctx, sid)
+	if err != nil {
+		return nil, err
+	}
+	return s.model2Type(object), nil
+}
+
+func (s *slo) get(ctx context.Context, sid int64) (*model.SLO, error) {
+	object, err := s.factory.SLO().Get(ctx, sid)
+	if err != nil {
+		klog.Errorf("failed to get slo %d: %v", sid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrSLONotFound
+	}
+	return object, nil
+}
+
+func (s *slo) List(ctx context.Context, opts types.ListSLOOptions) ([]types.SLO, error) {
+	objects, err := s.list(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	slos := make([]types.SLO, len(objects))
+	for i, object := range objects {
+		slos[i] = *s.model2Type(&object)
+	}
+	return slos, nil
+}
+
+func (s *slo) list(ctx context.Context, opts types.ListSLOOptions) ([]model.SLO, error) {
+	var dbOpts []db.Options
+	if len(opts.Cluster) != 0 {
+		dbOpts = append(dbOpts, db.WithCluster(opts.Cluster))
+	}
+	if len(opts.Tenant) != 0 {
+		dbOpts = append(dbOpts, db.WithTenant(opts.Tenant))
+	}
+	objects, err := s.factory.SLO().List(ctx, dbOpts...)
+	if err != nil {
+		klog.Errorf("failed to list slos: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+	return objects, nil
+}
+
+func (s *slo) GetStatus(ctx context.Context, sid int64) (*types.SLOStatus, error) {
+	object, err := s.get(ctx, sid)
+	if err != nil {
+		return nil, err
+	}
+	return s.getStatus(ctx, object)
+}
+
+func (s *slo) ListStatus(ctx context.Context, opts types.ListSLOOptions) ([]types.SLOStatus, error) {
+	objects, err := s.list(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]types.SLOStatus, 0, len(objects))
+	for i := range objects {
+		status, err := s.getStatus(ctx, &objects[i])
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+func (s *slo) getStatus(ctx context.Context, object *model.SLO) (*types.SLOStatus, error) {
+	now := time.Now()
+	avg, count, err := s.factory.SLO().AvgSamples(ctx, object.Id, now.AddDate(0, 0, -object.WindowDays))
+	if err != nil {
+		klog.Errorf("failed to get slo %d samples: %v", object.Id, err)
+		return nil, errors.ErrServerInternal
+	}
+
+	status := &types.SLOStatus{
+		SLO:     *s.model2Type(object),
+		Samples: count,
+		Status:  types.SLOUnknown,
+	}
+	if count == 0 {
+		return status, nil
+	}
+
+	budget := 1 - object.Objective/100
+	status.Compliance = avg * 100
+	status.ErrorBudgetRemaining = (1 - (1-avg)/budget) * 100
+
+	burnRates := []*float64{&status.BurnRate1h, &status.BurnRate6h, &status.BurnRate24h}
+	for i, window := range []time.Duration{time.Hour, 6 * time.Hour, 24 * time.Hour} {
+		windowAvg, windowCount, err := s.factory.SLO().AvgSamples(ctx, object.Id, now.Add(-window))
+		if err != nil {
+			klog.Errorf("failed to get slo %d samples: %v", object.Id, err)
+			return nil, errors.ErrServerInternal
+		}
+		if windowCount != 0 {
+			*burnRates[i] = (1 - windowAvg) / budget
+		}
+	}
+
+	switch {
+	case status.Compliance < object.Objective:
+		status.Status = types.SLOBreached
+	case status.BurnRate1h > fastBurnThreshold || status.BurnRate6h > slowBurnThreshold:
+		status.Status = types.SLOBurning
+	default:
+		status.Status = types.SLOHealthy
+	}
+	return status, nil
+}
+
+func (s *slo) model2Type(o *model.SLO) *types.SLO {
+	return &types.SLO{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Name:             o.Name,
+		Cluster:          o.Cluster,
+		Tenant:           o.Tenant,
+		Kind:             o.Kind,
+		Objective:        o.Objective,
+		WindowDays:       o.WindowDays,
+		PrometheusURL:    o.PrometheusURL,
+		Query:            o.Query,
+		Enabled:          o.Enabled,
+		LastEvaluateTime: o.LastEvaluateTime,
+		Description:      o.Description,
+	}
+}
+
+func NewSLO(f db.ShareDaoFactory) *slo {
+	return &slo{
+		factory: f,
+	}
+}
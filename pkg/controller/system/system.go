@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package system
+
+import (
+	"context"
+
+	"github.com/caoyingjunz/pixiu/cmd/app/config"
+	"github.com/caoyingjunz/pixiu/pkg/controller/kubeconfig"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	AuthModePassword          = "password"
+	AuthModeClientCertificate = "client_certificate"
+)
+
+type SystemGetter interface {
+	System() Interface
+}
+
+type Interface interface {
+	// GetCapabilities 获取当前部署开启的模块，认证方式以及限制，前端和命令行据此调整展示和行为
+	GetCapabilities(ctx context.Context) (*types.Capabilities, error)
+}
+
+type system struct {
+	cc         config.Config
+	kubeConfig kubeconfig.Interface
+}
+
+func (s *system) GetCapabilities(ctx context.Context) (*types.Capabilities, error) {
+	policy, err := s.kubeConfig.GetPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	authModes := []string{AuthModePassword}
+	if s.cc.TLS != nil && len(s.cc.TLS.ClientCAFile) != 0 {
+		authModes = append(authModes, AuthModeClientCertificate)
+	}
+
+	limits := types.CapabilityLimits{
+		KubeConfigMaxTTL: policy.MaxTTL,
+		LogBufferSize:    s.cc.Default.LogBufferSize,
+	}
+	if s.cc.Event.Enable {
+		limits.EventDaysReserved = s.cc.Event.DaysReserved
+	}
+	if s.cc.Recycle.Enable {
+		limits.RecycleDaysReserved = s.cc.Recycle.DaysReserved
+	}
+
+	return &types.Capabilities{
+		Mode: string(s.cc.Default.Mode),
+		// helm，plans，web kubectl 和基于 prometheus 的 SLO 监控始终提供
+		Modules: map[string]bool{
+			"helm":                true,
+			"plans":               true,
+			"terminal":            true,
+			"monitoring":          true,
+			"dns":                 s.cc.DNS.Enable,
+			"events":              s.cc.Event.Enable,
+			"recycle":             s.cc.Recycle.Enable,
+			"operator":            s.cc.Operator.Enable,
+			"trace":               s.cc.Trace.Enable,
+			"email":               len(s.cc.Notification.SMTP.Host) != 0,
+			"kubeconfig_rotation": s.cc.KubeConfigRotation.Enable,
+		},
+		AuthModes: authModes,
+		Limits:    limits,
+	}, nil
+}
+
+func NewSystem(cfg config.Config, k kubeconfig.Interface) *system {
+	return &system{
+		cc:         cfg,
+		kubeConfig: k,
+	}
+}
@@ -0,0 +1,319 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/api/server/errors"
+	"github.com/caoyingjunz/pixiu/pkg/controller/cluster"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+// simple 引擎的变量，例如 ${name}
+var simpleVariableRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+type TemplateGetter interface {
+	Template() Interface
+}
+
+type Interface interface {
+	Create(ctx context.Context, req *types.CreateTemplateRequest) error
+	Update(ctx context.Context, tid int64, req *types.UpdateTemplateRequest) error
+	Delete(ctx context.Context, tid int64) error
+	Get(ctx context.Context, tid int64) (*types.Template, error)
+	List(ctx context.Context) ([]types.Template, error)
+
+	// Render 使用变量渲染模板，返回渲染后的 manifest
+	Render(ctx context.Context, tid int64, req *types.RenderTemplateRequest) (string, error)
+	// Instantiate 渲染模板并通过 apply 提交到集群，dry_run 时仅预览
+	Instantiate(ctx context.Context, tid int64, req *types.InstantiateTemplateRequest) ([]*unstructured.Unstructured, error)
+}
+
+type tpl struct {
+	factory       db.ShareDaoFactory
+	clusterGetter cluster.Interface
+}
+
+func (t *tpl) Create(ctx context.Context, req *types.CreateTemplateRequest) error {
+	engine := req.Engine
+	if len(engine) == 0 {
+		engine = model.TemplateEngineSimple
+	}
+	if err := validate(engine, req.Content); err != nil {
+		return err
+	}
+	old, err := t.factory.Template().GetByName(ctx, req.Name)
+	if err != nil {
+		klog.Errorf("failed to get template %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+	if old != nil {
+		return errors.ErrTemplateExists
+	}
+
+	variables, err := types.TemplateVariables(req.Variables).Marshal()
+	if err != nil {
+		return errors.ErrInvalidRequest
+	}
+	object := &model.Template{
+		Name:      req.Name,
+		Engine:    engine,
+		Content:   req.Content,
+		Variables: variables,
+	}
+	if req.Description != nil {
+		object.Description = *req.Description
+	}
+	if _, err = t.factory.Template().Create(ctx, object); err != nil {
+		klog.Errorf("failed to create template %s: %v", req.Name, err)
+		return errors.ErrServerInternal
+	}
+
+	return nil
+}
+
+func (t *tpl) Update(ctx context.Context, tid int64, req *types.UpdateTemplateRequest) error {
+	object, err := t.get(ctx, tid)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	engine, content := object.Engine, object.Content
+	if req.Engine != nil {
+		engine = *req.Engine
+		updates["engine"] = engine
+	}
+	if req.Content != nil {
+		content = *req.Content
+		updates["content"] = content
+	}
+	if err = validate(engine, content); err != nil {
+		return err
+	}
+	if req.Variables != nil {
+		variables, err := types.TemplateVariables(*req.Variables).Marshal()
+		if err != nil {
+			return errors.ErrInvalidRequest
+		}
+		updates["variables"] = variables
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		return errors.ErrInvalidRequest
+	}
+	if err = t.factory.Template().Update(ctx, tid, *req.ResourceVersion, updates); err != nil {
+		klog.Errorf("failed to update template %d: %v", tid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (t *tpl) Delete(ctx context.Context, tid int64) error {
+	if err := t.factory.Template().Delete(ctx, tid); err != nil {
+		klog.Errorf("failed to delete template %d: %v", tid, err)
+		return errors.ErrServerInternal
+	}
+	return nil
+}
+
+func (t *tpl) Get(ctx context.Context, tid int64) (*types.Template, error) {
+	object, err := t.get(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	return t.model2Type(object), nil
+}
+
+func (t *tpl) get(ctx context.Context, tid int64) (*model.Template, error) {
+	object, err := t.factory.Template().Get(ctx, tid)
+	if err != nil {
+		klog.Errorf("failed to get template %d: %v", tid, err)
+		return nil, errors.ErrServerInternal
+	}
+	if object == nil {
+		return nil, errors.ErrTemplateNotFound
+	}
+	return object, nil
+}
+
+func (t *tpl) List(ctx context.Context) ([]types.Template, error) {
+	objects, err := t.factory.Template().List(ctx)
+	if err != nil {
+		klog.Errorf("failed to list templates: %v", err)
+		return nil, errors.ErrServerInternal
+	}
+
+	templates := make([]types.Template, len(objects))
+	for i, object := range objects {
+		templates[i] = *t.model2Type(&object)
+	}
+	return templates, nil
+}
+
+func (t *tpl) Render(ctx context.Context, tid int64, req *types.RenderTemplateRequest) (string, error) {
+	object, err := t.get(ctx, tid)
+	if err != nil {
+		return "", err
+	}
+	return t.render(object, req.Variables)
+}
+
+func (t *tpl) Instantiate(ctx context.Context, tid int64, req *types.InstantiateTemplateRequest) ([]*unstructured.Unstructured, error) {
+	object, err := t.get(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := t.render(object, req.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.clusterGetter.Apply(ctx, req.Cluster, &types.ApplyManifestRequest{
+		Namespace: req.Namespace,
+		Manifest:  manifest,
+		DryRun:    req.DryRun,
+
+		WithDefaultNodeSelector: true,
+		NodePool:                req.NodePool,
+	})
+}
+
+// render 合并变量的默认值并校验必填变量后渲染模板
+func (t *tpl) render(object *model.Template, values map[string]string) (string, error) {
+	var variables types.TemplateVariables
+	if len(object.Variables) != 0 {
+		if err := variables.Unmarshal(object.Variables); err != nil {
+			klog.Errorf("failed to unmarshal template(%d) variables: %v", object.Id, err)
+			return "", errors.ErrServerInternal
+		}
+	}
+
+	data := make(map[string]string)
+	for _, variable := range variables {
+		if len(variable.Default) != 0 {
+			data[variable.Name] = variable.Default
+		}
+	}
+	for k, v := range values {
+		data[k] = v
+	}
+	for _, variable := range variables {
+		if variable.Required && len(data[variable.Name]) == 0 {
+			return "", errors.NewError(fmt.Errorf("variable %s is required", variable.Name), http.StatusBadRequest)
+		}
+	}
+
+	var (
+		out string
+		err error
+	)
+	switch object.Engine {
+	case model.TemplateEngineGo:
+		out, err = renderGo(object.Content, data)
+	default:
+		out, err = renderSimple(object.Content, data)
+	}
+	if err != nil {
+		return "", errors.NewError(err, http.StatusBadRequest)
+	}
+	return out, nil
+}
+
+func renderSimple(content string, data map[string]string) (string, error) {
+	var missing []string
+	out := simpleVariableRegexp.ReplaceAllStringFunc(content, func(s string) string {
+		name := simpleVariableRegexp.FindStringSubmatch(s)[1]
+		v, ok := data[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) != 0 {
+		return "", fmt.Errorf("variables %v are not set", missing)
+	}
+	return out, nil
+}
+
+func renderGo(content string, data map[string]string) (string, error) {
+	tmpl, err := template.New("template").Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// validate 校验 go 引擎的模板语法
+func validate(engine string, content string) error {
+	if engine != model.TemplateEngineGo {
+		return nil
+	}
+	if _, err := template.New("template").Parse(content); err != nil {
+		return errors.NewError(err, http.StatusBadRequest)
+	}
+	return nil
+}
+
+func (t *tpl) model2Type(o *model.Template) *types.Template {
+	var variables types.TemplateVariables
+	if len(o.Variables) != 0 {
+		if err := variables.Unmarshal(o.Variables); err != nil {
+			klog.Warningf("failed to unmarshal template(%d) variables: %v", o.Id, err)
+		}
+	}
+
+	return &types.Template{
+		PixiuMeta: types.PixiuMeta{
+			Id:              o.Id,
+			ResourceVersion: o.ResourceVersion,
+		},
+		TimeMeta: types.TimeMeta{
+			GmtCreate:   o.GmtCreate,
+			GmtModified: o.GmtModified,
+		},
+		Name:        o.Name,
+		Engine:      o.Engine,
+		Content:     o.Content,
+		Variables:   variables,
+		Description: o.Description,
+	}
+}
+
+func NewTemplate(f db.ShareDaoFactory, c cluster.Interface) *tpl {
+	return &tpl{
+		factory:       f,
+		clusterGetter: c,
+	}
+}
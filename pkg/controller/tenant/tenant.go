@@ -18,7 +18,10 @@ package tenant
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog/v2"
 
 	"github.com/caoyingjunz/pixiu/api/server/errors"
@@ -54,6 +57,9 @@ func (t *tenant) Create(ctx context.Context, req *types.CreateTenantRequest) err
 	if object != nil {
 		return errors.ErrTenantExists
 	}
+	if err = validQuotas(req.CPUQuota, req.MemoryQuota); err != nil {
+		return err
+	}
 
 	tenant := &model.Tenant{
 		Name: req.Name,
@@ -61,6 +67,15 @@ func (t *tenant) Create(ctx context.Context, req *types.CreateTenantRequest) err
 	if req.Description != nil {
 		tenant.Description = *req.Description
 	}
+	if req.GPUQuota != nil {
+		tenant.GPUQuota = *req.GPUQuota
+	}
+	if req.CPUQuota != nil {
+		tenant.CPUQuota = *req.CPUQuota
+	}
+	if req.MemoryQuota != nil {
+		tenant.MemoryQuota = *req.MemoryQuota
+	}
 
 	if _, err = t.factory.Tenant().Create(ctx, tenant); err != nil {
 		klog.Errorf("failed to create tenant %s: %v", req.Name, err)
@@ -86,6 +101,18 @@ func (t *tenant) Update(ctx context.Context, tid int64, req *types.UpdateTenantR
 	if req.Description != nil {
 		updates["description"] = *req.Description
 	}
+	if req.GPUQuota != nil {
+		updates["gpu_quota"] = *req.GPUQuota
+	}
+	if err = validQuotas(req.CPUQuota, req.MemoryQuota); err != nil {
+		return err
+	}
+	if req.CPUQuota != nil {
+		updates["cpu_quota"] = *req.CPUQuota
+	}
+	if req.MemoryQuota != nil {
+		updates["memory_quota"] = *req.MemoryQuota
+	}
 	if len(updates) == 0 {
 		return errors.ErrInvalidRequest
 	}
@@ -132,6 +159,19 @@ func (t *tenant) List(ctx context.Context) ([]types.Tenant, error) {
 	return ts, nil
 }
 
+// validQuotas 配额需为合法的 kubernetes 资源数量，为空表示不限制
+func validQuotas(quotas ...*string) error {
+	for _, quota := range quotas {
+		if quota == nil || len(*quota) == 0 {
+			continue
+		}
+		if _, err := resource.ParseQuantity(*quota); err != nil {
+			return errors.NewError(fmt.Errorf("invalid quota %q: %v", *quota, err), http.StatusBadRequest)
+		}
+	}
+	return nil
+}
+
 func (t *tenant) model2Type(o *model.Tenant) *types.Tenant {
 	return &types.Tenant{
 		PixiuMeta: types.PixiuMeta{
@@ -144,6 +184,9 @@ func (t *tenant) model2Type(o *model.Tenant) *types.Tenant {
 		},
 		Name:        o.Name,
 		Description: o.Description,
+		GPUQuota:    o.GPUQuota,
+		CPUQuota:    o.CPUQuota,
+		MemoryQuota: o.MemoryQuota,
 	}
 }
 
@@ -27,6 +27,7 @@ import (
 	"github.com/caoyingjunz/pixiu/api/server/httputils"
 	"github.com/caoyingjunz/pixiu/cmd/app/config"
 	"github.com/caoyingjunz/pixiu/pkg/client"
+	ctrlutil "github.com/caoyingjunz/pixiu/pkg/controller/util"
 	"github.com/caoyingjunz/pixiu/pkg/db"
 	"github.com/caoyingjunz/pixiu/pkg/db/model"
 	"github.com/caoyingjunz/pixiu/pkg/types"
@@ -90,6 +91,12 @@ func (u *user) Create(ctx context.Context, req *types.CreateUserRequest) error {
 		return err
 	}
 
+	if req.TenantId != 0 {
+		if err = ctrlutil.ValidateTenantAssignment(ctx, u.factory, req.TenantId); err != nil {
+			return err
+		}
+	}
+
 	txFunc := func() (err error) {
 		if req.Role == model.RoleRoot {
 			bindings := model.NewGroupBinding(req.Name, model.AdminGroup)
@@ -105,6 +112,7 @@ func (u *user) Create(ctx context.Context, req *types.CreateUserRequest) error {
 		Role:        req.Role,
 		Email:       req.Email,
 		Description: req.Description,
+		TenantId:    req.TenantId,
 	}, txFunc); err != nil {
 		klog.Errorf("failed to create user %s: %v", req.Name, err)
 		return errors.ErrServerInternal
@@ -119,6 +127,12 @@ func (u *user) Update(ctx context.Context, uid int64, req *types.UpdateUserReque
 		"email":       req.Email,
 		"description": req.Description,
 	}
+	if req.TenantId != nil {
+		if err := ctrlutil.ValidateTenantAssignment(ctx, u.factory, *req.TenantId); err != nil {
+			return err
+		}
+		updates["tenant_id"] = *req.TenantId
+	}
 	if err := u.factory.User().Update(ctx, uid, *req.ResourceVersion, updates); err != nil {
 		klog.Errorf("failed to update user(%d): %v", uid, err)
 		return errors.ErrServerInternal
@@ -191,6 +205,8 @@ func (u *user) UpdatePassword(ctx context.Context, userId int64, req *types.Upda
 	}
 	if err = u.factory.User().Update(ctx, userId, *req.ResourceVersion, map[string]interface{}{
 		"password": newPass,
+		// 管理员重置密码后，用户需要再次修改密码
+		"password_change_required": req.Reset,
 	}); err != nil {
 		klog.Errorf("failed to update user(%d) password: %v", userId, err)
 		return errors.ErrServerInternal
@@ -301,6 +317,8 @@ func (u *user) Login(ctx context.Context, req *types.LoginRequest) (*types.Login
 		Token:    token,
 		Role:     object.Role,
 		User:     object,
+
+		PasswordChangeRequired: object.PasswordChangeRequired,
 	}, nil
 }
 
@@ -338,6 +356,7 @@ func model2Type(o *model.User) *types.User {
 		Status:      o.Status,
 		Role:        o.Role,
 		Email:       o.Email,
+		TenantId:    o.TenantId,
 		TimeMeta: types.TimeMeta{
 			GmtCreate:   o.GmtCreate,
 			GmtModified: o.GmtModified,
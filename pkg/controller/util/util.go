@@ -18,10 +18,14 @@ package util
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/util/sets"
 
+	"github.com/caoyingjunz/pixiu/api/server/errors"
 	"github.com/caoyingjunz/pixiu/api/server/httputils"
 	"github.com/caoyingjunz/pixiu/pkg/db"
 	"github.com/caoyingjunz/pixiu/pkg/db/model"
@@ -36,6 +40,24 @@ func MakeDbOptions(ctx context.Context) (opts []db.Options) {
 	return
 }
 
+// ValidateTenantAssignment 校验对象归属的租户，租户用户不允许修改归属，指定的租户必须存在
+func ValidateTenantAssignment(ctx context.Context, f db.ShareDaoFactory, tenantId int64) error {
+	if _, scoped := db.TenantFromContext(ctx); scoped {
+		return errors.NewError(fmt.Errorf("租户用户不允许修改所属租户"), http.StatusForbidden)
+	}
+	if tenantId == 0 {
+		return nil
+	}
+	object, err := f.Tenant().Get(ctx, tenantId)
+	if err != nil {
+		return errors.ErrServerInternal
+	}
+	if object == nil {
+		return errors.NewError(fmt.Errorf("租户(%d)不存在", tenantId), http.StatusBadRequest)
+	}
+	return nil
+}
+
 func SetIdRangeContext(c *gin.Context, enforcer *casbin.SyncedEnforcer, user *model.User, obj string) error {
 	bindings, err := GetGroupBindings(enforcer, QueryWithUserName(user.Name))
 	if err != nil {
@@ -159,6 +181,68 @@ func GetUserPolicies(enforcer *casbin.SyncedEnforcer, user *model.User, conds ..
 	return policies, nil
 }
 
+// SetGroupBindings 将用户的用户组绑定调整为 groups，新增和解除的绑定分别批量写入，不处理管理员用户组
+func SetGroupBindings(enforcer *casbin.SyncedEnforcer, user string, groups ...string) error {
+	bindings, err := GetGroupBindings(enforcer, QueryWithUserName(user))
+	if err != nil {
+		return err
+	}
+	want := sets.NewString(groups...).Delete(model.AdminGroup)
+	current := sets.NewString()
+	var removes [][]string
+	for _, binding := range bindings {
+		group := binding.GetGroupName()
+		if group == model.AdminGroup {
+			continue
+		}
+		current.Insert(group)
+		if !want.Has(group) {
+			removes = append(removes, binding.Raw())
+		}
+	}
+	var adds [][]string
+	for _, group := range want.Difference(current).List() {
+		adds = append(adds, model.NewGroupBinding(user, group).Raw())
+	}
+
+	if len(adds) != 0 {
+		if _, err = enforcer.AddGroupingPolicies(adds); err != nil {
+			return err
+		}
+	}
+	if len(removes) != 0 {
+		if _, err = enforcer.RemoveGroupingPolicies(removes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnforceCluster 校验当前用户对集群的操作权限，用于 kubeproxy 等按集群名称访问的请求
+// 调用前需已加载策略，kubeproxy 的请求由鉴权中间件加载
+func EnforceCluster(ctx context.Context, f db.ShareDaoFactory, enforcer *casbin.SyncedEnforcer, name string, obj model.ObjectType, op model.Operation) error {
+	user, err := httputils.GetUserFromRequest(ctx)
+	if err != nil {
+		return errors.ErrForbidden
+	}
+	object, err := f.Cluster().GetClusterByName(ctx, name)
+	if err != nil {
+		return errors.ErrServerInternal
+	}
+	if object == nil {
+		return errors.ErrClusterNotFound
+	}
+
+	ok, err := enforcer.Enforce(user.Name, obj.String(), object.GetSID(), op.String())
+	if err != nil {
+		return errors.ErrServerInternal
+	}
+	if !ok {
+		return errors.ErrForbidden
+	}
+	return nil
+}
+
 func GetGroupPolicy(enforcer *casbin.SyncedEnforcer, name string) (*model.GroupPolicy, error) {
 	rp, err := enforcer.GetFilteredNamedPolicy("p", 0, name)
 	if err != nil {
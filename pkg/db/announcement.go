@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type AnnouncementInterface interface {
+	Create(ctx context.Context, object *model.Announcement) (*model.Announcement, error)
+	Update(ctx context.Context, aid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, aid int64) error
+	Get(ctx context.Context, aid int64) (*model.Announcement, error)
+	List(ctx context.Context, opts ...Options) ([]model.Announcement, error)
+}
+
+type announcement struct {
+	db *gorm.DB
+}
+
+func newAnnouncement(db *gorm.DB) AnnouncementInterface {
+	return &announcement{db}
+}
+
+func (a *announcement) Create(ctx context.Context, object *model.Announcement) (*model.Announcement, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := a.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (a *announcement) Update(ctx context.Context, aid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	f := a.db.WithContext(ctx).Model(&model.Announcement{}).Where("id = ? and resource_version = ?", aid, resourceVersion).Updates(updates)
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotUpdate
+	}
+
+	return nil
+}
+
+func (a *announcement) Delete(ctx context.Context, aid int64) error {
+	f := a.db.WithContext(ctx).Where("id = ?", aid).Delete(&model.Announcement{})
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (a *announcement) Get(ctx context.Context, aid int64) (*model.Announcement, error) {
+	var object model.Announcement
+	if err := a.db.WithContext(ctx).Where("id = ?", aid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (a *announcement) List(ctx context.Context, opts ...Options) ([]model.Announcement, error) {
+	var objects []model.Announcement
+	tx := a.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// WithActiveAt 在指定时间生效的公告
+func WithActiveAt(t time.Time) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("start_time <= ? and end_time > ?", t, t)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// joinTable 关联表的批量关联和解除关联，避免逐条写入时事务过长
+// owner 列为关联的主体，target 列为被关联的对象，例如 propagation_targets 的 propagation_id 和 cluster
+type joinTable struct {
+	model        interface{}
+	ownerColumn  string
+	targetColumn string
+}
+
+// associate 批量写入 owner 的关联记录，rows 为关联表模型切片的指针
+// 自动设置 owner 列和时间字段，已关联的 target 被忽略，写入后 rows 中的 id 被回填
+func (j joinTable) associate(tx *gorm.DB, ownerId int64, rows interface{}) error {
+	rv := reflect.ValueOf(rows)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("rows must be a pointer to slice, got %T", rows)
+	}
+	rv = rv.Elem()
+	if rv.Len() == 0 {
+		return nil
+	}
+
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(j.model); err != nil {
+		return err
+	}
+	owner := stmt.Schema.LookUpField(j.ownerColumn)
+	target := stmt.Schema.LookUpField(j.targetColumn)
+	if owner == nil || target == nil {
+		return fmt.Errorf("table %s has no column %s or %s", stmt.Schema.Table, j.ownerColumn, j.targetColumn)
+	}
+
+	var existing []string
+	if err := tx.Model(j.model).Where(j.ownerColumn+" = ?", ownerId).Pluck(j.targetColumn, &existing).Error; err != nil {
+		return err
+	}
+	skip := sets.NewString(existing...)
+
+	ctx := tx.Statement.Context
+	now := time.Now()
+	indexes := make([]int, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		row := rv.Index(i)
+		value, _ := target.ValueOf(ctx, row)
+		// 同时去除 rows 中重复的 target
+		key := fmt.Sprint(value)
+		if skip.Has(key) {
+			continue
+		}
+		skip.Insert(key)
+
+		if err := owner.Set(ctx, row, ownerId); err != nil {
+			return err
+		}
+		for _, column := range []string{"gmt_create", "gmt_modified"} {
+			if field := stmt.Schema.LookUpField(column); field != nil {
+				if err := field.Set(ctx, row, now); err != nil {
+					return err
+				}
+			}
+		}
+		indexes = append(indexes, i)
+	}
+
+	if len(indexes) == 0 {
+		return nil
+	}
+	if len(indexes) == rv.Len() {
+		return tx.CreateInBatches(rows, defaultBatchSize).Error
+	}
+	part := reflect.New(rv.Type())
+	for _, i := range indexes {
+		part.Elem().Set(reflect.Append(part.Elem(), rv.Index(i)))
+	}
+	if err := tx.CreateInBatches(part.Interface(), defaultBatchSize).Error; err != nil {
+		return err
+	}
+	for k, i := range indexes {
+		rv.Index(i).Set(part.Elem().Index(k))
+	}
+	return nil
+}
+
+// dissociate 批量解除 owner 与 targets 的关联，未指定 targets 时解除 owner 的全部关联
+func (j joinTable) dissociate(tx *gorm.DB, ownerId int64, targets ...interface{}) error {
+	tx = tx.Where(j.ownerColumn+" = ?", ownerId)
+	if len(targets) != 0 {
+		tx = tx.Where(j.targetColumn+" IN ?", targets)
+	}
+	return tx.Delete(j.model).Error
+}
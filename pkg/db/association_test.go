@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+func TestJoinTableAssociate(t *testing.T) {
+	db := newTestDB(t, &model.PropagationTarget{})
+	ctx := context.TODO()
+
+	targets := []model.PropagationTarget{{Cluster: "c1"}, {Cluster: "c2"}, {Cluster: "c2"}}
+	if err := propagationTargets.associate(db.WithContext(ctx), 1, &targets); err != nil {
+		t.Fatalf("associate() error: %v", err)
+	}
+	if targets[0].Id == 0 || targets[1].Id == 0 {
+		t.Errorf("associate() ids are not backfilled: %+v", targets)
+	}
+	if targets[0].PropagationId != 1 || targets[0].GmtCreate.IsZero() {
+		t.Errorf("associate() owner or time is not set: %+v", targets[0])
+	}
+
+	// 已关联的集群被忽略，新增的集群回填 id
+	more := []model.PropagationTarget{{Cluster: "c2"}, {Cluster: "c3"}}
+	if err := propagationTargets.associate(db.WithContext(ctx), 1, &more); err != nil {
+		t.Fatalf("associate() error: %v", err)
+	}
+	if more[0].Id != 0 || more[1].Id == 0 {
+		t.Errorf("associate() = %+v, want only c3 created", more)
+	}
+	if err := propagationTargets.associate(db.WithContext(ctx), 2, &[]model.PropagationTarget{{Cluster: "c1"}}); err != nil {
+		t.Fatalf("associate() error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		owner   int64
+		targets []interface{}
+		want1   []string
+		want2   []string
+	}{
+		{name: "dissociate targets", owner: 1, targets: []interface{}{"c1", "c3"}, want1: []string{"c2"}, want2: []string{"c1"}},
+		{name: "dissociate all", owner: 1, want1: nil, want2: []string{"c1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := propagationTargets.dissociate(db.WithContext(ctx), tt.owner, tt.targets...); err != nil {
+				t.Fatalf("dissociate() error: %v", err)
+			}
+			for owner, want := range map[int64][]string{1: tt.want1, 2: tt.want2} {
+				var got []string
+				if err := db.Model(&model.PropagationTarget{}).Where("propagation_id = ?", owner).Pluck("cluster", &got).Error; err != nil {
+					t.Fatalf("failed to list targets: %v", err)
+				}
+				sort.Strings(got)
+				if len(got) != len(want) || (len(want) != 0 && got[0] != want[0]) {
+					t.Errorf("owner %d targets = %v, want %v", owner, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestJoinTableAssociateInvalidRows(t *testing.T) {
+	db := newTestDB(t, &model.PropagationTarget{})
+	if err := propagationTargets.associate(db, 1, []model.PropagationTarget{{Cluster: "c1"}}); err == nil {
+		t.Errorf("associate() expected error for non-pointer rows")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/caoyingjunz/pixiu/pkg/db/model"
 	"github.com/caoyingjunz/pixiu/pkg/util/errors"
@@ -30,11 +31,18 @@ type AuditInterface interface {
 	List(ctx context.Context, opts ...Options) ([]model.Audit, error)
 	Get(ctx context.Context, id int64) (*model.Audit, error)
 	Create(ctx context.Context, object *model.Audit) (*model.Audit, error)
+	// BatchDelete 删除符合条件的审计记录，并更新哈希链中保留的记录数量和最早一条记录
 	BatchDelete(ctx context.Context, opts ...Options) (int64, error)
 
 	Count(ctx context.Context, opts ...Options) (int64, error)
+
+	// GetChain 获取哈希链的状态，尚未写入过审计记录时返回 nil
+	GetChain(ctx context.Context) (*model.AuditChain, error)
 }
 
+// auditChainId 哈希链状态记录的 ID
+const auditChainId = 1
+
 type audit struct {
 	db *gorm.DB
 }
@@ -43,17 +51,72 @@ func newAudit(db *gorm.DB) AuditInterface {
 	return &audit{db: db}
 }
 
+// Create 写入审计记录，并锁定哈希链状态记录以串行化哈希链的追加
 func (a *audit) Create(ctx context.Context, object *model.Audit) (*model.Audit, error) {
-	now := time.Now()
+	now := time.Now().Truncate(time.Second)
 	object.GmtCreate = now
 	object.GmtModified = now
+	object.Cluster = model.AuditClusterFromPath(object.Path)
+
+	if err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chain, err := lockAuditChain(tx)
+		if err != nil {
+			return err
+		}
 
-	if err := a.db.WithContext(ctx).Create(object).Error; err != nil {
+		object.PrevHash = chain.HeadHash
+		object.Hash = object.ComputeHash()
+		if err = tx.Create(object).Error; err != nil {
+			return err
+		}
+		return tx.Model(chain).Updates(map[string]interface{}{
+			"head_id":   object.Id,
+			"head_hash": object.Hash,
+			"records":   chain.Records + 1,
+		}).Error
+	}); err != nil {
 		return nil, err
 	}
 	return object, nil
 }
 
+// lockAuditChain 锁定哈希链状态记录，不存在时创建并使用已有的审计记录初始化
+// 状态记录总是存在，因此在任意隔离级别下都只锁定这一行，不会产生间隙锁
+func lockAuditChain(tx *gorm.DB) (*model.AuditChain, error) {
+	chain := &model.AuditChain{Id: auditChainId}
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(chain)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return chain, initAuditChain(tx, chain)
+	}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", auditChainId).First(chain).Error; err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// initAuditChain 使用启用状态记录之前写入的带哈希的审计记录初始化哈希链状态
+func initAuditChain(tx *gorm.DB, chain *model.AuditChain) error {
+	var first, last model.Audit
+	if err := tx.Select("id", "prev_hash").Where("hash <> ''").Order("id ASC").Limit(1).Find(&first).Error; err != nil {
+		return err
+	}
+	if err := tx.Select("id", "hash").Where("hash <> ''").Order("id DESC").Limit(1).Find(&last).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.Audit{}).Where("hash <> ''").Count(&chain.Records).Error; err != nil {
+		return err
+	}
+
+	chain.HeadId = last.Id
+	chain.HeadHash = last.Hash
+	chain.AnchorPrevHash = first.PrevHash
+	return tx.Save(chain).Error
+}
+
 func (a *audit) Get(ctx context.Context, aid int64) (*model.Audit, error) {
 	var audit *model.Audit
 	if err := a.db.WithContext(ctx).Where("id = ?", aid).First(audit).Error; err != nil {
@@ -79,13 +142,49 @@ func (a *audit) List(ctx context.Context, opts ...Options) ([]model.Audit, error
 }
 
 func (a *audit) BatchDelete(ctx context.Context, opts ...Options) (int64, error) {
-	tx := a.db.WithContext(ctx)
-	for _, opt := range opts {
-		tx = opt(tx)
+	scope := func(tx *gorm.DB) *gorm.DB {
+		for _, opt := range opts {
+			tx = opt(tx)
+		}
+		return tx
 	}
 
-	err := tx.Delete(&model.Audit{}).Error
-	return tx.RowsAffected, err
+	var deleted int64
+	err := a.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		chain, err := lockAuditChain(tx)
+		if err != nil {
+			return err
+		}
+
+		var hashed int64
+		if err = tx.Model(&model.Audit{}).Scopes(scope).Where("hash <> ''").Count(&hashed).Error; err != nil {
+			return err
+		}
+		res := tx.Scopes(scope).Delete(&model.Audit{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected
+
+		var first model.Audit
+		res = tx.Select("id", "prev_hash").Where("hash <> ''").Order("id ASC").Limit(1).Find(&first)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			// 记录全部被删除时，下一条记录的 PrevHash 为最新一条记录的 Hash
+			first.PrevHash = chain.HeadHash
+		}
+		records := chain.Records - hashed
+		if records < 0 {
+			records = 0
+		}
+		return tx.Model(chain).Updates(map[string]interface{}{
+			"anchor_prev_hash": first.PrevHash,
+			"records":          records,
+		}).Error
+	})
+	return deleted, err
 }
 
 func (a *audit) Count(ctx context.Context, opts ...Options) (int64, error) {
@@ -98,3 +197,73 @@ func (a *audit) Count(ctx context.Context, opts ...Options) (int64, error) {
 	err := tx.Model(&model.Audit{}).Count(&total).Error
 	return total, err
 }
+
+func (a *audit) GetChain(ctx context.Context) (*model.AuditChain, error) {
+	var chain model.AuditChain
+	if err := a.db.WithContext(ctx).Where("id = ?", auditChainId).First(&chain).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &chain, nil
+}
+
+// WithIdAfter 获取 id 大于指定值的记录，用于按 id 分批遍历
+func WithIdAfter(id int64) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("id > ?", id)
+	}
+}
+
+// WithIdNotAfter 获取 id 不大于指定值的记录
+func WithIdNotAfter(id int64) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("id <= ?", id)
+	}
+}
+
+// WithAuditFields 按审计记录的字段精确匹配，为空的字段不限制
+func WithAuditFields(fields map[string]string) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		for column, value := range fields {
+			if len(value) != 0 {
+				tx = tx.Where(column+" = ?", value)
+			}
+		}
+		return tx
+	}
+}
+
+// WithAuditStatus 按执行结果过滤，为 nil 时不限制
+func WithAuditStatus(status *model.AuditOperationStatus) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		if status == nil {
+			return tx
+		}
+		return tx.Where("status = ?", *status)
+	}
+}
+
+// WithAuditCluster 匹配审计记录的集群名称，集群名称在写入时由请求路径解析
+func WithAuditCluster(cluster string) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		if len(cluster) == 0 {
+			return tx
+		}
+		return tx.Where("cluster = ?", cluster)
+	}
+}
+
+// WithCreatedBetween 创建时间的范围，为零值时不限制
+func WithCreatedBetween(start, end time.Time) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		if !start.IsZero() {
+			tx = tx.Where("gmt_create >= ?", start)
+		}
+		if !end.IsZero() {
+			tx = tx.Where("gmt_create <= ?", end)
+		}
+		return tx
+	}
+}
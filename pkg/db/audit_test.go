@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+func TestAuditChain(t *testing.T) {
+	db := newTestDB(t, &model.Audit{}, &model.AuditChain{})
+	dao := newAudit(db)
+	ctx := context.TODO()
+
+	// 启用哈希链状态之前已经写入的记录
+	legacy := &model.Audit{Operator: "admin", Path: "/pixiu/clusters/c1/pods"}
+	legacy.Hash = legacy.ComputeHash()
+	if err := db.Create(legacy).Error; err != nil {
+		t.Fatalf("failed to create legacy audit: %v", err)
+	}
+
+	var audits []*model.Audit
+	for i := 0; i < 3; i++ {
+		object, err := dao.Create(ctx, &model.Audit{Operator: "admin", Path: "/pixiu/proxy/c2/api/v1/pods"})
+		if err != nil {
+			t.Fatalf("Create() error: %v", err)
+		}
+		audits = append(audits, object)
+	}
+	if audits[0].PrevHash != legacy.Hash || audits[2].PrevHash != audits[1].Hash {
+		t.Errorf("Create() records are not chained: %+v", audits)
+	}
+	if audits[0].Cluster != "c2" {
+		t.Errorf("Create() cluster = %q, want c2", audits[0].Cluster)
+	}
+
+	chain, err := dao.GetChain(ctx)
+	if err != nil {
+		t.Fatalf("GetChain() error: %v", err)
+	}
+	if chain.HeadId != audits[2].Id || chain.HeadHash != audits[2].Hash || chain.Records != 4 || chain.AnchorPrevHash != "" {
+		t.Errorf("GetChain() after create = %+v", chain)
+	}
+
+	deleted, err := dao.BatchDelete(ctx, WithIdNotAfter(audits[0].Id))
+	if err != nil {
+		t.Fatalf("BatchDelete() error: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("BatchDelete() deleted = %d, want 2", deleted)
+	}
+	if chain, err = dao.GetChain(ctx); err != nil {
+		t.Fatalf("GetChain() error: %v", err)
+	}
+	if chain.Records != 2 || chain.AnchorPrevHash != audits[1].PrevHash || chain.HeadId != audits[2].Id {
+		t.Errorf("GetChain() after delete = %+v", chain)
+	}
+
+	if _, err = dao.BatchDelete(ctx, WithIdNotAfter(audits[2].Id)); err != nil {
+		t.Fatalf("BatchDelete() error: %v", err)
+	}
+	if chain, err = dao.GetChain(ctx); err != nil {
+		t.Fatalf("GetChain() error: %v", err)
+	}
+	if chain.Records != 0 || chain.AnchorPrevHash != audits[2].Hash {
+		t.Errorf("GetChain() after deleting all = %+v", chain)
+	}
+}
+
+// BenchmarkAuditList 审计记录列表常用的过滤条件，每次查询一页并统计总数
+func BenchmarkAuditList(b *testing.B) {
+	db := newTestDB(b, &model.Audit{})
+	if err := newMigrator(db).CreateIndexes(&model.Audit{}); err != nil {
+		b.Fatalf("CreateIndexes() error: %v", err)
+	}
+
+	start := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	audits := make([]model.Audit, 0, 10000)
+	for i := 0; i < cap(audits); i++ {
+		audit := model.Audit{
+			Operator: fmt.Sprintf("user-%d", i%50),
+			Path:     fmt.Sprintf("/pixiu/clusters/cluster-%d/pods", i%20),
+			Status:   model.AuditOperationStatus(i % 3),
+		}
+		audit.Cluster = model.AuditClusterFromPath(audit.Path)
+		audit.GmtCreate = start.Add(time.Duration(i) * time.Second)
+		audits = append(audits, audit)
+	}
+	if err := db.CreateInBatches(audits, defaultBatchSize).Error; err != nil {
+		b.Fatalf("failed to create audits: %v", err)
+	}
+
+	status := model.AuditOpFail
+	benchmarks := []struct {
+		name string
+		opts []Options
+	}{
+		{name: "operator", opts: []Options{WithAuditFields(map[string]string{"operator": "user-7"})}},
+		{name: "cluster", opts: []Options{WithAuditCluster("cluster-3")}},
+		{name: "status", opts: []Options{WithAuditStatus(&status)}},
+		{name: "created", opts: []Options{WithCreatedBetween(start.Add(time.Hour), start.Add(2*time.Hour))}},
+		{name: "combined", opts: []Options{
+			WithAuditFields(map[string]string{"operator": "user-7"}),
+			WithAuditCluster("cluster-7"),
+			WithCreatedBetween(start, start.Add(2*time.Hour)),
+		}},
+	}
+
+	dao := newAudit(db)
+	ctx := context.TODO()
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := dao.Count(ctx, bm.opts...); err != nil {
+					b.Fatalf("Count() error: %v", err)
+				}
+				if _, err := dao.List(ctx, append(bm.opts, WithOrderByDesc(), WithLimit(10))...); err != nil {
+					b.Fatalf("List() error: %v", err)
+				}
+			}
+		})
+	}
+}
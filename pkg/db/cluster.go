@@ -48,6 +48,7 @@ func (c *cluster) Create(ctx context.Context, object *model.Cluster, fns ...func
 	now := time.Now()
 	object.GmtCreate = now
 	object.GmtModified = now
+	setTenant(ctx, &object.TenantId)
 
 	if err := c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(object).Error; err != nil {
@@ -71,7 +72,7 @@ func (c *cluster) Update(ctx context.Context, cid int64, resourceVersion int64,
 	updates["gmt_modified"] = time.Now()
 	updates["resource_version"] = resourceVersion + 1
 
-	f := c.db.WithContext(ctx).Model(&model.Cluster{}).Where("id = ? and resource_version = ?", cid, resourceVersion).Updates(updates)
+	f := c.db.WithContext(ctx).Scopes(tenantScope(ctx)).Model(&model.Cluster{}).Where("id = ? and resource_version = ?", cid, resourceVersion).Updates(updates)
 	if f.Error != nil {
 		return f.Error
 	}
@@ -86,7 +87,7 @@ func (c *cluster) Update(ctx context.Context, cid int64, resourceVersion int64,
 func (c *cluster) InternalUpdate(ctx context.Context, cid int64, updates map[string]interface{}) error {
 	// 系统维护字段
 	updates["gmt_modified"] = time.Now()
-	f := c.db.WithContext(ctx).Model(&model.Cluster{}).Where("id = ?", cid).Updates(updates)
+	f := c.db.WithContext(ctx).Scopes(tenantScope(ctx)).Model(&model.Cluster{}).Where("id = ?", cid).Updates(updates)
 	if f.Error != nil {
 		return f.Error
 	}
@@ -103,7 +104,7 @@ func (c *cluster) Delete(ctx context.Context, cluster *model.Cluster, fns ...fun
 	//	return nil, err
 	//}
 	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Delete(cluster).Error; err != nil {
+		if err := tx.Scopes(tenantScope(ctx)).Delete(cluster).Error; err != nil {
 			return err
 		}
 
@@ -118,7 +119,7 @@ func (c *cluster) Delete(ctx context.Context, cluster *model.Cluster, fns ...fun
 
 func (c *cluster) Get(ctx context.Context, cid int64, opts ...Options) (*model.Cluster, error) {
 	var object model.Cluster
-	tx := c.db.WithContext(ctx)
+	tx := c.db.WithContext(ctx).Scopes(tenantScope(ctx))
 	for _, opt := range opts {
 		tx = opt(tx)
 	}
@@ -134,7 +135,7 @@ func (c *cluster) Get(ctx context.Context, cid int64, opts ...Options) (*model.C
 
 func (c *cluster) List(ctx context.Context, opts ...Options) ([]model.Cluster, error) {
 	var cs []model.Cluster
-	tx := c.db.WithContext(ctx)
+	tx := c.db.WithContext(ctx).Scopes(tenantScope(ctx))
 	for _, opt := range opts {
 		tx = opt(tx)
 	}
@@ -147,7 +148,7 @@ func (c *cluster) List(ctx context.Context, opts ...Options) ([]model.Cluster, e
 
 func (c *cluster) GetClusterByName(ctx context.Context, name string) (*model.Cluster, error) {
 	var object model.Cluster
-	if err := c.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+	if err := c.db.WithContext(ctx).Scopes(tenantScope(ctx)).Where("name = ?", name).First(&object).Error; err != nil {
 		if errors.IsRecordNotFound(err) {
 			return nil, nil
 		}
@@ -160,7 +161,7 @@ func (c *cluster) GetClusterByName(ctx context.Context, name string) (*model.Clu
 func (c *cluster) UpdateByPlan(ctx context.Context, planId int64, updates map[string]interface{}) error {
 	updates["gmt_modified"] = time.Now()
 
-	f := c.db.WithContext(ctx).Model(&model.Cluster{}).Where("plan_id = ?", planId).Updates(updates)
+	f := c.db.WithContext(ctx).Scopes(tenantScope(ctx)).Model(&model.Cluster{}).Where("plan_id = ?", planId).Updates(updates)
 	if f.Error != nil {
 		return f.Error
 	}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type ClusterBootstrapInterface interface {
+	Create(ctx context.Context, object *model.ClusterBootstrap) (*model.ClusterBootstrap, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.ClusterBootstrap, error)
+	// Claim 标记 token 已被使用，已被使用时返回 ErrRecordNotUpdate
+	Claim(ctx context.Context, id int64) error
+	// Release 注册失败时释放 token，允许 agent 重试
+	Release(ctx context.Context, id int64) error
+}
+
+type clusterBootstrap struct {
+	db *gorm.DB
+}
+
+func newClusterBootstrap(db *gorm.DB) ClusterBootstrapInterface {
+	return &clusterBootstrap{db}
+}
+
+func (c *clusterBootstrap) Create(ctx context.Context, object *model.ClusterBootstrap) (*model.ClusterBootstrap, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := c.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (c *clusterBootstrap) GetByTokenHash(ctx context.Context, tokenHash string) (*model.ClusterBootstrap, error) {
+	var object model.ClusterBootstrap
+	if err := c.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (c *clusterBootstrap) Claim(ctx context.Context, id int64) error {
+	now := time.Now()
+	f := c.db.WithContext(ctx).Model(&model.ClusterBootstrap{}).Where("id = ? and registered_at is null", id).Updates(map[string]interface{}{
+		"gmt_modified":  now,
+		"registered_at": now,
+	})
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotUpdate
+	}
+	return nil
+}
+
+func (c *clusterBootstrap) Release(ctx context.Context, id int64) error {
+	return c.db.WithContext(ctx).Model(&model.ClusterBootstrap{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"gmt_modified":  time.Now(),
+		"registered_at": nil,
+	}).Error
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+type ClusterEventInterface interface {
+	// Upsert 按 cluster 和 uid 写入事件，已存在时更新次数，消息和最近发生时间
+	Upsert(ctx context.Context, object *model.ClusterEvent) error
+	List(ctx context.Context, opts ...Options) ([]model.ClusterEvent, error)
+	Count(ctx context.Context, opts ...Options) (int64, error)
+	BatchDelete(ctx context.Context, opts ...Options) (int64, error)
+}
+
+type clusterEvent struct {
+	db *gorm.DB
+}
+
+func newClusterEvent(db *gorm.DB) ClusterEventInterface {
+	return &clusterEvent{db}
+}
+
+func (c *clusterEvent) Upsert(ctx context.Context, object *model.ClusterEvent) error {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	return c.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cluster"}, {Name: "uid"}},
+		DoUpdates: clause.AssignmentColumns([]string{"gmt_modified", "message", "count", "last_timestamp"}),
+	}).Create(object).Error
+}
+
+func (c *clusterEvent) List(ctx context.Context, opts ...Options) ([]model.ClusterEvent, error) {
+	var objects []model.ClusterEvent
+	tx := c.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (c *clusterEvent) Count(ctx context.Context, opts ...Options) (int64, error) {
+	tx := c.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+
+	var total int64
+	err := tx.Model(&model.ClusterEvent{}).Count(&total).Error
+	return total, err
+}
+
+func (c *clusterEvent) BatchDelete(ctx context.Context, opts ...Options) (int64, error) {
+	tx := c.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+
+	err := tx.Delete(&model.ClusterEvent{}).Error
+	return tx.RowsAffected, err
+}
+
+// WithLastSeenBetween 事件最近发生时间的范围，为零值时不限制
+func WithLastSeenBetween(start, end time.Time) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		if !start.IsZero() {
+			tx = tx.Where("last_timestamp >= ?", start)
+		}
+		if !end.IsZero() {
+			tx = tx.Where("last_timestamp <= ?", end)
+		}
+		return tx
+	}
+}
+
+// WithEventFields 按事件的字段精确匹配，为空的字段不限制
+func WithEventFields(fields map[string]string) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		for column, value := range fields {
+			if len(value) != 0 {
+				tx = tx.Where(column+" = ?", value)
+			}
+		}
+		return tx
+	}
+}
+
+func WithOrderByLastSeen() Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("last_timestamp DESC")
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type ClusterPrecheckInterface interface {
+	Create(ctx context.Context, object *model.ClusterPrecheck) (*model.ClusterPrecheck, error)
+	Get(ctx context.Context, id int64) (*model.ClusterPrecheck, error)
+	List(ctx context.Context, opts ...Options) ([]model.ClusterPrecheck, error)
+}
+
+type clusterPrecheck struct {
+	db *gorm.DB
+}
+
+func newClusterPrecheck(db *gorm.DB) ClusterPrecheckInterface {
+	return &clusterPrecheck{db}
+}
+
+func (c *clusterPrecheck) Create(ctx context.Context, object *model.ClusterPrecheck) (*model.ClusterPrecheck, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := c.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (c *clusterPrecheck) Get(ctx context.Context, id int64) (*model.ClusterPrecheck, error) {
+	var object model.ClusterPrecheck
+	if err := c.db.WithContext(ctx).Where("id = ?", id).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (c *clusterPrecheck) List(ctx context.Context, opts ...Options) ([]model.ClusterPrecheck, error) {
+	var objects []model.ClusterPrecheck
+	tx := c.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type DNSRecordInterface interface {
+	Create(ctx context.Context, object *model.DNSRecord) (*model.DNSRecord, error)
+	Delete(ctx context.Context, id int64) error
+	Get(ctx context.Context, id int64) (*model.DNSRecord, error)
+	List(ctx context.Context, opts ...Options) ([]model.DNSRecord, error)
+
+	GetByDomain(ctx context.Context, domain string) (*model.DNSRecord, error)
+}
+
+type dnsRecord struct {
+	db *gorm.DB
+}
+
+func newDNSRecord(db *gorm.DB) DNSRecordInterface {
+	return &dnsRecord{db}
+}
+
+func (d *dnsRecord) Create(ctx context.Context, object *model.DNSRecord) (*model.DNSRecord, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := d.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (d *dnsRecord) Delete(ctx context.Context, id int64) error {
+	f := d.db.WithContext(ctx).Where("id = ?", id).Delete(&model.DNSRecord{})
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (d *dnsRecord) Get(ctx context.Context, id int64) (*model.DNSRecord, error) {
+	var object model.DNSRecord
+	if err := d.db.WithContext(ctx).Where("id = ?", id).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (d *dnsRecord) List(ctx context.Context, opts ...Options) ([]model.DNSRecord, error) {
+	var objects []model.DNSRecord
+	tx := d.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (d *dnsRecord) GetByDomain(ctx context.Context, domain string) (*model.DNSRecord, error) {
+	var object model.DNSRecord
+	if err := d.db.WithContext(ctx).Where("domain = ?", domain).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
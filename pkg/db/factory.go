@@ -27,6 +27,33 @@ type ShareDaoFactory interface {
 	Plan() PlanInterface
 	Audit() AuditInterface
 	Repository() RepositoryInterface
+	DNSRecord() DNSRecordInterface
+	WorkloadChange() WorkloadChangeInterface
+	Pipeline() PipelineInterface
+	Propagation() PropagationInterface
+	Fleet() FleetInterface
+	Scaling() ScalingInterface
+	NamespacePolicy() NamespacePolicyInterface
+	Template() TemplateInterface
+	Replication() ReplicationInterface
+	KubectlSession() KubectlSessionInterface
+	Setting() SettingInterface
+	HelmSecret() HelmSecretInterface
+	KubeConfig() KubeConfigInterface
+	CloudAccount() CloudAccountInterface
+	ClusterEvent() ClusterEventInterface
+	SLO() SLOInterface
+	ClusterPrecheck() ClusterPrecheckInterface
+	Notification() NotificationInterface
+	NamingPolicy() NamingPolicyInterface
+	Injection() InjectionInterface
+	Recycle() RecycleInterface
+	Preference() PreferenceInterface
+	Announcement() AnnouncementInterface
+	ClusterBootstrap() ClusterBootstrapInterface
+	Inspection() InspectionInterface
+	Menu() MenuInterface
+	Lease() LeaseInterface
 }
 
 type shareDaoFactory struct {
@@ -39,6 +66,67 @@ func (f *shareDaoFactory) User() UserInterface             { return newUser(f.db
 func (f *shareDaoFactory) Plan() PlanInterface             { return newPlan(f.db) }
 func (f *shareDaoFactory) Audit() AuditInterface           { return newAudit(f.db) }
 func (f *shareDaoFactory) Repository() RepositoryInterface { return newRepository(f.db) }
+func (f *shareDaoFactory) DNSRecord() DNSRecordInterface   { return newDNSRecord(f.db) }
+func (f *shareDaoFactory) WorkloadChange() WorkloadChangeInterface {
+	return newWorkloadChange(f.db)
+}
+func (f *shareDaoFactory) Pipeline() PipelineInterface { return newPipeline(f.db) }
+func (f *shareDaoFactory) Fleet() FleetInterface       { return newFleet(f.db) }
+func (f *shareDaoFactory) Scaling() ScalingInterface   { return newScaling(f.db) }
+func (f *shareDaoFactory) Propagation() PropagationInterface {
+	return newPropagation(f.db)
+}
+func (f *shareDaoFactory) Template() TemplateInterface { return newTemplate(f.db) }
+func (f *shareDaoFactory) Replication() ReplicationInterface {
+	return newReplication(f.db)
+}
+func (f *shareDaoFactory) NamespacePolicy() NamespacePolicyInterface {
+	return newNamespacePolicy(f.db)
+}
+
+func (f *shareDaoFactory) KubectlSession() KubectlSessionInterface {
+	return newKubectlSession(f.db)
+}
+func (f *shareDaoFactory) Setting() SettingInterface { return newSetting(f.db) }
+func (f *shareDaoFactory) HelmSecret() HelmSecretInterface {
+	return newHelmSecret(f.db)
+}
+func (f *shareDaoFactory) KubeConfig() KubeConfigInterface {
+	return newKubeConfig(f.db)
+}
+func (f *shareDaoFactory) CloudAccount() CloudAccountInterface {
+	return newCloudAccount(f.db)
+}
+func (f *shareDaoFactory) ClusterEvent() ClusterEventInterface {
+	return newClusterEvent(f.db)
+}
+func (f *shareDaoFactory) SLO() SLOInterface { return newSLO(f.db) }
+func (f *shareDaoFactory) ClusterPrecheck() ClusterPrecheckInterface {
+	return newClusterPrecheck(f.db)
+}
+func (f *shareDaoFactory) Notification() NotificationInterface {
+	return newNotification(f.db)
+}
+func (f *shareDaoFactory) NamingPolicy() NamingPolicyInterface {
+	return newNamingPolicy(f.db)
+}
+
+func (f *shareDaoFactory) Injection() InjectionInterface {
+	return newInjection(f.db)
+}
+func (f *shareDaoFactory) Recycle() RecycleInterface { return newRecycle(f.db) }
+func (f *shareDaoFactory) Preference() PreferenceInterface {
+	return newPreference(f.db)
+}
+func (f *shareDaoFactory) Announcement() AnnouncementInterface {
+	return newAnnouncement(f.db)
+}
+func (f *shareDaoFactory) ClusterBootstrap() ClusterBootstrapInterface {
+	return newClusterBootstrap(f.db)
+}
+func (f *shareDaoFactory) Inspection() InspectionInterface { return newInspection(f.db) }
+func (f *shareDaoFactory) Menu() MenuInterface             { return newMenu(f.db) }
+func (f *shareDaoFactory) Lease() LeaseInterface           { return newLease(f.db) }
 
 func NewDaoFactory(db *gorm.DB, migrate bool) (ShareDaoFactory, error) {
 	if migrate {
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type FleetInterface interface {
+	Create(ctx context.Context, object *model.Fleet) (*model.Fleet, error)
+	Update(ctx context.Context, fid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, fid int64) error
+	Get(ctx context.Context, fid int64) (*model.Fleet, error)
+	List(ctx context.Context, opts ...Options) ([]model.Fleet, error)
+
+	GetByName(ctx context.Context, name string) (*model.Fleet, error)
+}
+
+type fleet struct {
+	db *gorm.DB
+}
+
+func newFleet(db *gorm.DB) FleetInterface {
+	return &fleet{db}
+}
+
+func (f *fleet) Create(ctx context.Context, object *model.Fleet) (*model.Fleet, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := f.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (f *fleet) Update(ctx context.Context, fid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	tx := f.db.WithContext(ctx).Model(&model.Fleet{}).Where("id = ? and resource_version = ?", fid, resourceVersion).Updates(updates)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (f *fleet) Delete(ctx context.Context, fid int64) error {
+	return f.db.WithContext(ctx).Where("id = ?", fid).Delete(&model.Fleet{}).Error
+}
+
+func (f *fleet) Get(ctx context.Context, fid int64) (*model.Fleet, error) {
+	var object model.Fleet
+	if err := f.db.WithContext(ctx).Where("id = ?", fid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (f *fleet) List(ctx context.Context, opts ...Options) ([]model.Fleet, error) {
+	var objects []model.Fleet
+	tx := f.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (f *fleet) GetByName(ctx context.Context, name string) (*model.Fleet, error) {
+	var object model.Fleet
+	if err := f.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type HelmSecretInterface interface {
+	Create(ctx context.Context, object *model.HelmSecret) (*model.HelmSecret, error)
+	Update(ctx context.Context, sid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, sid int64) error
+	Get(ctx context.Context, sid int64) (*model.HelmSecret, error)
+	List(ctx context.Context, opts ...Options) ([]model.HelmSecret, error)
+
+	GetByName(ctx context.Context, name string) (*model.HelmSecret, error)
+}
+
+type helmSecret struct {
+	db *gorm.DB
+}
+
+func newHelmSecret(db *gorm.DB) HelmSecretInterface {
+	return &helmSecret{db}
+}
+
+func (h *helmSecret) Create(ctx context.Context, object *model.HelmSecret) (*model.HelmSecret, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := h.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (h *helmSecret) Update(ctx context.Context, sid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	tx := h.db.WithContext(ctx).Model(&model.HelmSecret{}).Where("id = ? and resource_version = ?", sid, resourceVersion).Updates(updates)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (h *helmSecret) Delete(ctx context.Context, sid int64) error {
+	return h.db.WithContext(ctx).Where("id = ?", sid).Delete(&model.HelmSecret{}).Error
+}
+
+func (h *helmSecret) Get(ctx context.Context, sid int64) (*model.HelmSecret, error) {
+	var object model.HelmSecret
+	if err := h.db.WithContext(ctx).Where("id = ?", sid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (h *helmSecret) List(ctx context.Context, opts ...Options) ([]model.HelmSecret, error) {
+	var objects []model.HelmSecret
+	tx := h.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (h *helmSecret) GetByName(ctx context.Context, name string) (*model.HelmSecret, error) {
+	var object model.HelmSecret
+	if err := h.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type InjectionInterface interface {
+	CreateTemplate(ctx context.Context, object *model.SidecarTemplate) (*model.SidecarTemplate, error)
+	UpdateTemplate(ctx context.Context, tid int64, resourceVersion int64, updates map[string]interface{}) error
+	DeleteTemplate(ctx context.Context, tid int64) error
+	GetTemplate(ctx context.Context, tid int64) (*model.SidecarTemplate, error)
+	GetTemplateByName(ctx context.Context, name string) (*model.SidecarTemplate, error)
+	ListTemplates(ctx context.Context, opts ...Options) ([]model.SidecarTemplate, error)
+
+	Create(ctx context.Context, object *model.Injection) (*model.Injection, error)
+	// UpdateResults 记录注入或者回滚的结果
+	UpdateResults(ctx context.Context, iid int64, status string, results string) error
+	Get(ctx context.Context, iid int64) (*model.Injection, error)
+	List(ctx context.Context, opts ...Options) ([]model.Injection, error)
+}
+
+type injection struct {
+	db *gorm.DB
+}
+
+func newInjection(db *gorm.DB) InjectionInterface {
+	return &injection{db}
+}
+
+func (i *injection) CreateTemplate(ctx context.Context, object *model.SidecarTemplate) (*model.SidecarTemplate, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := i.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (i *injection) UpdateTemplate(ctx context.Context, tid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	f := i.db.WithContext(ctx).Model(&model.SidecarTemplate{}).Where("id = ? and resource_version = ?", tid, resourceVersion).Updates(updates)
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (i *injection) DeleteTemplate(ctx context.Context, tid int64) error {
+	return i.db.WithContext(ctx).Where("id = ?", tid).Delete(&model.SidecarTemplate{}).Error
+}
+
+func (i *injection) GetTemplate(ctx context.Context, tid int64) (*model.SidecarTemplate, error) {
+	var object model.SidecarTemplate
+	if err := i.db.WithContext(ctx).Where("id = ?", tid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (i *injection) GetTemplateByName(ctx context.Context, name string) (*model.SidecarTemplate, error) {
+	var object model.SidecarTemplate
+	if err := i.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (i *injection) ListTemplates(ctx context.Context, opts ...Options) ([]model.SidecarTemplate, error) {
+	var objects []model.SidecarTemplate
+	tx := i.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (i *injection) Create(ctx context.Context, object *model.Injection) (*model.Injection, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := i.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (i *injection) UpdateResults(ctx context.Context, iid int64, status string, results string) error {
+	return i.db.WithContext(ctx).Model(&model.Injection{}).Where("id = ?", iid).Updates(map[string]interface{}{
+		"status":       status,
+		"results":      results,
+		"gmt_modified": time.Now(),
+	}).Error
+}
+
+func (i *injection) Get(ctx context.Context, iid int64) (*model.Injection, error) {
+	var object model.Injection
+	if err := i.db.WithContext(ctx).Where("id = ?", iid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (i *injection) List(ctx context.Context, opts ...Options) ([]model.Injection, error) {
+	var objects []model.Injection
+	tx := i.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
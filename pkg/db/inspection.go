@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type InspectionInterface interface {
+	Create(ctx context.Context, object *model.Inspection) (*model.Inspection, error)
+	Update(ctx context.Context, iid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, iid int64) error
+	Get(ctx context.Context, iid int64) (*model.Inspection, error)
+	List(ctx context.Context, opts ...Options) ([]model.Inspection, error)
+
+	// UpdateRunTime 记录巡检的执行时间，由调度任务调用
+	UpdateRunTime(ctx context.Context, iid int64, t time.Time) error
+
+	CreateReport(ctx context.Context, object *model.InspectionReport) (*model.InspectionReport, error)
+	GetReport(ctx context.Context, rid int64) (*model.InspectionReport, error)
+	ListReports(ctx context.Context, iid int64, opts ...Options) ([]model.InspectionReport, error)
+	// DeleteReportsBefore 清理过期的巡检报告
+	DeleteReportsBefore(ctx context.Context, iid int64, t time.Time) error
+}
+
+type inspection struct {
+	db *gorm.DB
+}
+
+func newInspection(db *gorm.DB) InspectionInterface {
+	return &inspection{db}
+}
+
+func (i *inspection) Create(ctx context.Context, object *model.Inspection) (*model.Inspection, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := i.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (i *inspection) Update(ctx context.Context, iid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	f := i.db.WithContext(ctx).Model(&model.Inspection{}).Where("id = ? and resource_version = ?", iid, resourceVersion).Updates(updates)
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete 删除巡检计划，同时删除其巡检报告
+func (i *inspection) Delete(ctx context.Context, iid int64) error {
+	return i.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("inspection_id = ?", iid).Delete(&model.InspectionReport{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", iid).Delete(&model.Inspection{}).Error
+	})
+}
+
+func (i *inspection) Get(ctx context.Context, iid int64) (*model.Inspection, error) {
+	var object model.Inspection
+	if err := i.db.WithContext(ctx).Where("id = ?", iid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (i *inspection) List(ctx context.Context, opts ...Options) ([]model.Inspection, error) {
+	var objects []model.Inspection
+	tx := i.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// UpdateRunTime 不修改 resource_version，避免与用户的更新冲突
+func (i *inspection) UpdateRunTime(ctx context.Context, iid int64, t time.Time) error {
+	return i.db.WithContext(ctx).Model(&model.Inspection{}).Where("id = ?", iid).Update("last_run_time", t).Error
+}
+
+func (i *inspection) CreateReport(ctx context.Context, object *model.InspectionReport) (*model.InspectionReport, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := i.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (i *inspection) GetReport(ctx context.Context, rid int64) (*model.InspectionReport, error) {
+	var object model.InspectionReport
+	if err := i.db.WithContext(ctx).Where("id = ?", rid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (i *inspection) ListReports(ctx context.Context, iid int64, opts ...Options) ([]model.InspectionReport, error) {
+	var objects []model.InspectionReport
+	tx := i.db.WithContext(ctx).Where("inspection_id = ?", iid)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (i *inspection) DeleteReportsBefore(ctx context.Context, iid int64, t time.Time) error {
+	return i.db.WithContext(ctx).Where("inspection_id = ? and gmt_create < ?", iid, t).Delete(&model.InspectionReport{}).Error
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type KubeConfigInterface interface {
+	Create(ctx context.Context, object *model.KubeConfig) (*model.KubeConfig, error)
+	Delete(ctx context.Context, kid int64) error
+	// InternalUpdate 内部更新，不更新版本号
+	InternalUpdate(ctx context.Context, kid int64, updates map[string]interface{}) error
+	Get(ctx context.Context, kid int64) (*model.KubeConfig, error)
+	List(ctx context.Context, opts ...Options) ([]model.KubeConfig, error)
+	Count(ctx context.Context, opts ...Options) (int64, error)
+}
+
+type kubeConfig struct {
+	db *gorm.DB
+}
+
+func newKubeConfig(db *gorm.DB) KubeConfigInterface {
+	return &kubeConfig{db}
+}
+
+func (k *kubeConfig) Create(ctx context.Context, object *model.KubeConfig) (*model.KubeConfig, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := k.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (k *kubeConfig) Delete(ctx context.Context, kid int64) error {
+	return k.db.WithContext(ctx).Where("id = ?", kid).Delete(&model.KubeConfig{}).Error
+}
+
+func (k *kubeConfig) InternalUpdate(ctx context.Context, kid int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	f := k.db.WithContext(ctx).Model(&model.KubeConfig{}).Where("id = ?", kid).Updates(updates)
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotUpdate
+	}
+
+	return nil
+}
+
+func (k *kubeConfig) Get(ctx context.Context, kid int64) (*model.KubeConfig, error) {
+	var object model.KubeConfig
+	if err := k.db.WithContext(ctx).Where("id = ?", kid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (k *kubeConfig) List(ctx context.Context, opts ...Options) ([]model.KubeConfig, error) {
+	var objects []model.KubeConfig
+	tx := k.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (k *kubeConfig) Count(ctx context.Context, opts ...Options) (int64, error) {
+	tx := k.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+
+	var total int64
+	err := tx.Model(&model.KubeConfig{}).Count(&total).Error
+	return total, err
+}
+
+// WithExpirationBetween 获取在指定时间范围内过期的 kubeconfig
+func WithExpirationBetween(start, end time.Time) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("expiration_timestamp > ? and expiration_timestamp <= ?", start, end)
+	}
+}
+
+// WithNotRevoked 未被吊销的 kubeconfig
+func WithNotRevoked() Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("revoked = ?", false)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+type KubectlSessionInterface interface {
+	Create(ctx context.Context, object *model.KubectlSession) (*model.KubectlSession, error)
+	// Finish 记录会话的结束时间和终端输入
+	Finish(ctx context.Context, sid int64, endTime time.Time, input string) error
+	List(ctx context.Context, opts ...Options) ([]model.KubectlSession, error)
+}
+
+type kubectlSession struct {
+	db *gorm.DB
+}
+
+func newKubectlSession(db *gorm.DB) KubectlSessionInterface {
+	return &kubectlSession{db}
+}
+
+func (k *kubectlSession) Create(ctx context.Context, object *model.KubectlSession) (*model.KubectlSession, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := k.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (k *kubectlSession) Finish(ctx context.Context, sid int64, endTime time.Time, input string) error {
+	return k.db.WithContext(ctx).Model(&model.KubectlSession{}).Where("id = ?", sid).Updates(map[string]interface{}{
+		"gmt_modified": time.Now(),
+		"end_time":     endTime,
+		"input":        input,
+	}).Error
+}
+
+func (k *kubectlSession) List(ctx context.Context, opts ...Options) ([]model.KubectlSession, error) {
+	var objects []model.KubectlSession
+	tx := k.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
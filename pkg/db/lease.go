@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+type LeaseInterface interface {
+	// TryAcquire 租约不存在，已过期或者由 holder 持有时获取并续约，返回 holder 是否持有租约
+	TryAcquire(ctx context.Context, name string, holder string, duration time.Duration) (bool, error)
+}
+
+type lease struct {
+	db *gorm.DB
+}
+
+func newLease(db *gorm.DB) LeaseInterface {
+	return &lease{db}
+}
+
+func (l *lease) TryAcquire(ctx context.Context, name string, holder string, duration time.Duration) (bool, error) {
+	now := time.Now()
+	object := &model.Lease{Name: name, Holder: holder, RenewTime: now}
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	tx := l.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(object)
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	if tx.RowsAffected == 1 {
+		return true, nil
+	}
+
+	if err := l.db.WithContext(ctx).Model(&model.Lease{}).
+		Where("name = ? and (holder = ? or renew_time < ?)", name, holder, now.Add(-duration)).
+		Updates(map[string]interface{}{
+			"gmt_modified": now,
+			"holder":       holder,
+			"renew_time":   now,
+		}).Error; err != nil {
+		return false, err
+	}
+	// 续约的时间与上一次相同时 mysql 返回的影响行数为 0，因此重新查询持有者
+	var current model.Lease
+	if err := l.db.WithContext(ctx).Where("name = ?", name).First(&current).Error; err != nil {
+		return false, err
+	}
+	return current.Holder == holder, nil
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+func TestLeaseTryAcquire(t *testing.T) {
+	db := newTestDB(t, &model.Lease{})
+	dao := newLease(db)
+	ctx := context.TODO()
+
+	steps := []struct {
+		name   string
+		holder string
+		expire bool
+		want   bool
+	}{
+		{name: "create", holder: "a", want: true},
+		{name: "renew", holder: "a", want: true},
+		{name: "held by others", holder: "b", want: false},
+		{name: "take over expired", holder: "b", expire: true, want: true},
+		{name: "lost", holder: "a", want: false},
+	}
+	for _, step := range steps {
+		if step.expire {
+			if err := db.Model(&model.Lease{}).Where("name = ?", model.LeaseWorkloadChange).
+				Update("renew_time", time.Now().Add(-time.Hour)).Error; err != nil {
+				t.Fatalf("failed to expire lease: %v", err)
+			}
+		}
+		got, err := dao.TryAcquire(ctx, model.LeaseWorkloadChange, step.holder, time.Minute)
+		if err != nil {
+			t.Fatalf("%s: TryAcquire() error: %v", step.name, err)
+		}
+		if got != step.want {
+			t.Errorf("%s: TryAcquire() = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
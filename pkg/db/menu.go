@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type MenuInterface interface {
+	Create(ctx context.Context, object *model.Menu) (*model.Menu, error)
+	GetByName(ctx context.Context, name string) (*model.Menu, error)
+	// List 按 sort 升序返回全部菜单
+	List(ctx context.Context) ([]model.Menu, error)
+}
+
+type menu struct {
+	db *gorm.DB
+}
+
+func newMenu(db *gorm.DB) MenuInterface {
+	return &menu{db}
+}
+
+func (m *menu) Create(ctx context.Context, object *model.Menu) (*model.Menu, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := m.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (m *menu) GetByName(ctx context.Context, name string) (*model.Menu, error) {
+	var object model.Menu
+	if err := m.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &object, nil
+}
+
+func (m *menu) List(ctx context.Context) ([]model.Menu, error) {
+	var objects []model.Menu
+	if err := m.db.WithContext(ctx).Order("sort ASC, id ASC").Find(&objects).Error; err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
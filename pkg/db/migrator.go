@@ -17,18 +17,64 @@ limitations under the License.
 package db
 
 import (
-	"github.com/caoyingjunz/pixiu/pkg/db/model"
-
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
 )
 
+// compositeIndex 无法通过模型 tag 声明的组合索引，例如包含 pixiu.Model 中字段的索引
+type compositeIndex struct {
+	table   string
+	name    string
+	columns []string
+}
+
+var compositeIndexes = []compositeIndex{
+	// 审计记录按时间清理和查询，以及按操作人和时间查询，gmt_create 的范围查询使用该索引的前缀
+	{table: (&model.Audit{}).TableName(), name: "idx_gmt_create_operator", columns: []string{"gmt_create", "operator"}},
+}
+
+// legacyIndex 模型调整后不再使用的索引，存在时删除
+type legacyIndex struct {
+	table string
+	name  string
+}
+
+var legacyIndexes = []legacyIndex{
+	// 仓库名称由全局唯一调整为在集群范围内唯一，由 idx_cluster_name 代替
+	{table: (&model.Repository{}).TableName(), name: "idx_name"},
+}
+
+// columnBackfill 字段新增后使用已有的数据填充历史记录，仅在添加字段时执行一次
+type columnBackfill struct {
+	table  string
+	column string
+	fill   func(db *gorm.DB) error
+}
+
+var columnBackfills = []columnBackfill{
+	// 审计记录按集群过滤依赖 cluster 字段，历史记录由请求路径解析
+	{table: (&model.Audit{}).TableName(), column: "cluster", fill: backfillAuditCluster},
+}
+
 type migrator struct {
 	db *gorm.DB
 }
 
-// AutoMigrate 自动创建指定模型的数据库表结构
+// AutoMigrate 自动创建指定模型的数据库表结构，已存在的表补齐缺少的字段和索引
 func (m *migrator) AutoMigrate() error {
-	return m.CreateTables(model.GetMigrationModels()...)
+	models := model.GetMigrationModels()
+	if err := m.CreateTables(models...); err != nil {
+		return err
+	}
+	if err := m.AddColumns(models...); err != nil {
+		return err
+	}
+	if err := m.DropLegacyIndexes(); err != nil {
+		return err
+	}
+	return m.CreateIndexes(models...)
 }
 
 func (m *migrator) CreateTables(dst ...interface{}) error {
@@ -45,6 +91,114 @@ func (m *migrator) CreateTables(dst ...interface{}) error {
 	return nil
 }
 
+// AddColumns 为已存在的表添加模型中新增的字段，已有的记录使用字段的零值或者 columnBackfills 中的填充
+// 仅添加字段，不删除或修改已存在的字段
+func (m *migrator) AddColumns(dst ...interface{}) error {
+	mg := m.db.Migrator()
+	for _, d := range dst {
+		stmt := &gorm.Statement{DB: m.db}
+		if err := stmt.Parse(d); err != nil {
+			return err
+		}
+		for _, field := range stmt.Schema.Fields {
+			if len(field.DBName) == 0 || mg.HasColumn(d, field.DBName) {
+				continue
+			}
+			if err := mg.AddColumn(d, field.Name); err != nil {
+				return err
+			}
+			for _, backfill := range columnBackfills {
+				if backfill.table != stmt.Schema.Table || backfill.column != field.DBName {
+					continue
+				}
+				if err := backfill.fill(m.db); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// backfillAuditCluster 按 id 分批解析历史审计记录请求路径中的集群名称，同一集群的记录一次更新
+func backfillAuditCluster(db *gorm.DB) error {
+	var lastId int64
+	for {
+		var audits []model.Audit
+		if err := db.Select("id", "path").
+			Where("id > ?", lastId).
+			Where("(path LIKE ? OR path LIKE ?)", "%/clusters/%", "%/proxy/%").
+			Order("id ASC").
+			Limit(defaultBatchSize).
+			Find(&audits).Error; err != nil {
+			return err
+		}
+
+		ids := make(map[string][]int64)
+		for _, audit := range audits {
+			lastId = audit.Id
+			if cluster := model.AuditClusterFromPath(audit.Path); len(cluster) != 0 {
+				ids[cluster] = append(ids[cluster], audit.Id)
+			}
+		}
+		for cluster, clusterIds := range ids {
+			if err := db.Model(&model.Audit{}).Where("id IN ?", clusterIds).UpdateColumn("cluster", cluster).Error; err != nil {
+				return err
+			}
+		}
+		if len(audits) < defaultBatchSize {
+			return nil
+		}
+	}
+}
+
+func (m *migrator) DropLegacyIndexes() error {
+	mg := m.db.Migrator()
+	for _, idx := range legacyIndexes {
+		if !mg.HasIndex(idx.table, idx.name) {
+			continue
+		}
+		if err := mg.DropIndex(idx.table, idx.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateIndexes 创建模型中声明但是数据库中不存在的索引，以及 compositeIndexes 中的组合索引
+// 仅创建索引，不删除或修改已存在的索引
+func (m *migrator) CreateIndexes(dst ...interface{}) error {
+	mg := m.db.Migrator()
+	for _, d := range dst {
+		stmt := &gorm.Statement{DB: m.db}
+		if err := stmt.Parse(d); err != nil {
+			return err
+		}
+		for name := range stmt.Schema.ParseIndexes() {
+			if mg.HasIndex(d, name) {
+				continue
+			}
+			if err := mg.CreateIndex(d, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, idx := range compositeIndexes {
+		if mg.HasIndex(idx.table, idx.name) {
+			continue
+		}
+		columns := make([]interface{}, len(idx.columns))
+		for i, column := range idx.columns {
+			columns[i] = clause.Column{Name: column}
+		}
+		if err := m.db.Exec("CREATE INDEX ? ON ? ?", clause.Column{Name: idx.name}, clause.Table{Name: idx.table}, columns).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func newMigrator(db *gorm.DB) *migrator {
 	return &migrator{db}
 }
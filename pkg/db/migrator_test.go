@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"testing"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+func TestCreateIndexesIdempotent(t *testing.T) {
+	db := newTestDB(t, &model.Audit{})
+	mg := db.Migrator()
+	table := (&model.Audit{}).TableName()
+
+	// 模拟已存在的表缺少部分索引
+	for _, name := range []string{"idx_operator", "idx_cluster"} {
+		if err := mg.DropIndex(table, name); err != nil {
+			t.Fatalf("failed to drop index %s: %v", name, err)
+		}
+	}
+
+	m := newMigrator(db)
+	for i := 0; i < 2; i++ {
+		if err := m.CreateIndexes(&model.Audit{}); err != nil {
+			t.Fatalf("CreateIndexes() round %d error: %v", i+1, err)
+		}
+	}
+
+	for _, name := range []string{"idx_operator", "idx_cluster", "idx_status", "idx_gmt_create_operator"} {
+		if !mg.HasIndex(table, name) {
+			t.Errorf("index %s is not created", name)
+		}
+	}
+}
+
+func TestAddColumnsBackfillAuditCluster(t *testing.T) {
+	db := newTestDB(t, &model.Audit{})
+	mg := db.Migrator()
+
+	// 模拟添加 cluster 字段之前的审计表
+	if err := mg.DropIndex(&model.Audit{}, "idx_cluster"); err != nil {
+		t.Fatalf("failed to drop index: %v", err)
+	}
+	if err := mg.DropColumn(&model.Audit{}, "cluster"); err != nil {
+		t.Fatalf("failed to drop column: %v", err)
+	}
+	paths := []string{"/pixiu/clusters/c1/pods", "/pixiu/proxy/c2/api/v1/pods?watch=true", "/pixiu/users/1", "/pixiu/clusters/c1/nodes"}
+	for _, path := range paths {
+		if err := db.Exec("INSERT INTO audits (path) VALUES (?)", path).Error; err != nil {
+			t.Fatalf("failed to insert audit: %v", err)
+		}
+	}
+
+	if err := newMigrator(db).AddColumns(&model.Audit{}); err != nil {
+		t.Fatalf("AddColumns() error: %v", err)
+	}
+
+	var audits []model.Audit
+	if err := db.Order("id ASC").Find(&audits).Error; err != nil {
+		t.Fatalf("failed to list audits: %v", err)
+	}
+	want := []string{"c1", "c2", "", "c1"}
+	for i, audit := range audits {
+		if audit.Cluster != want[i] {
+			t.Errorf("audit %s cluster = %q, want %q", audit.Path, audit.Cluster, want[i])
+		}
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&Announcement{})
+}
+
+const (
+	AnnouncementSeverityInfo     = "info"
+	AnnouncementSeverityWarning  = "warning"
+	AnnouncementSeverityCritical = "critical"
+)
+
+// Announcement 平台公告，例如维护通知，仅在生效时间内展示
+type Announcement struct {
+	pixiu.Model
+
+	Title    string `gorm:"type:varchar(255)" json:"title"`
+	Content  string `gorm:"type:text" json:"content"`
+	Severity string `gorm:"type:varchar(32)" json:"severity"`
+
+	StartTime time.Time `gorm:"index:idx_time" json:"start_time"`
+	EndTime   time.Time `gorm:"index:idx_time" json:"end_time"`
+	// 发布公告的管理员
+	Publisher string `gorm:"type:varchar(255)" json:"publisher"`
+}
+
+func (*Announcement) TableName() string {
+	return "announcements"
+}
@@ -17,13 +17,17 @@ limitations under the License.
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
 )
 
 func init() {
-	register(&Audit{})
+	register(&Audit{}, &AuditChain{})
 }
 
 type AuditOperationStatus uint8
@@ -51,10 +55,52 @@ type Audit struct {
 	RequestId  string               `gorm:"column:request_id;type:varchar(32);index" json:"request_id"`  // 请求 ID
 	Ip         string               `gorm:"type:varchar(128)" json:"ip"`                                 // 客户端 IP
 	Action     string               `gorm:"type:varchar(255)" json:"action"`                             // HTTP 方法 [POST/DELETE/PUT/GET]
-	Operator   string               `gorm:"type:varchar(255)" json:"operator"`                           // 操作人 ID
+	Operator   string               `gorm:"type:varchar(255);index:idx_operator" json:"operator"`        // 操作人 ID
 	Path       string               `gorm:"type:varchar(255)" json:"path"`                               // HTTP 路径
+	Cluster    string               `gorm:"type:varchar(128);index:idx_cluster" json:"cluster"`          // 请求路径中的集群名称，由 Path 解析，不参与哈希计算
 	ObjectType ObjectType           `gorm:"column:resource_type;type:varchar(128)" json:"resource_type"` // 操作资源类型 [cluster/plan...]
-	Status     AuditOperationStatus `gorm:"type:tinyint" json:"status"`                                  // 记录操作运行结果[OperationStatus]
+	Status     AuditOperationStatus `gorm:"type:tinyint;index:idx_status" json:"status"`                 // 记录操作运行结果[OperationStatus]
+
+	ChangeTicket string `gorm:"type:varchar(128)" json:"change_ticket"` // 变更单号
+	ChangeReason string `gorm:"type:varchar(512)" json:"change_reason"` // 变更原因
+
+	// 哈希链，PrevHash 为上一条审计记录的 Hash，用于检测记录被篡改或者删除
+	PrevHash string `gorm:"type:varchar(64)" json:"prev_hash"`
+	Hash     string `gorm:"type:varchar(64)" json:"hash"`
+}
+
+// ComputeHash 计算审计记录内容以及 PrevHash 的 sha256
+// gmt_create 字段精度为秒，因此按秒参与计算
+func (a *Audit) ComputeHash() string {
+	data, _ := json.Marshal([]interface{}{
+		a.PrevHash,
+		a.RequestId,
+		a.Ip,
+		a.Action,
+		a.Operator,
+		a.Path,
+		a.ObjectType,
+		a.Status,
+		a.ChangeTicket,
+		a.ChangeReason,
+		a.GmtCreate.Unix(),
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditClusterFromPath 解析 /clusters/{cluster}/ 和 /proxy/{cluster}/ 形式请求路径中的集群名称
+func AuditClusterFromPath(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	parts := strings.Split(path, "/")
+	for i := 0; i < len(parts)-2; i++ {
+		if parts[i] == "clusters" || parts[i] == "proxy" {
+			return parts[i+1]
+		}
+	}
+	return ""
 }
 
 func (a *Audit) String() string {
@@ -65,3 +111,17 @@ func (a *Audit) String() string {
 func (a *Audit) TableName() string {
 	return "audits"
 }
+
+// AuditChain 审计哈希链的状态，只有一条记录
+// 追加审计记录时锁定该记录以串行化哈希链的写入，校验时用于检测最早和最新的记录是否被删除
+type AuditChain struct {
+	Id             int64  `gorm:"primaryKey" json:"id"`
+	HeadId         int64  `json:"head_id"`                                  // 最新一条记录的 ID
+	HeadHash       string `gorm:"type:varchar(64)" json:"head_hash"`        // 最新一条记录的 Hash
+	AnchorPrevHash string `gorm:"type:varchar(64)" json:"anchor_prev_hash"` // 保留的最早一条记录的 PrevHash，过期清理后更新
+	Records        int64  `json:"records"`                                  // 哈希链中保留的记录数量
+}
+
+func (a *AuditChain) TableName() string {
+	return "audit_chains"
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "testing"
+
+func TestClusterFromPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/pixiu/clusters/c1/namespaces/default", want: "c1"},
+		{path: "/pixiu/proxy/c2/api/v1/pods?watch=true", want: "c2"},
+		{path: "/pixiu/clusters/c3", want: ""},
+		{path: "/pixiu/users/1", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := AuditClusterFromPath(tt.path); got != tt.want {
+				t.Errorf("AuditClusterFromPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&CloudAccount{})
+}
+
+// CloudAccount 云厂商账号，用于发现和导入托管的 kubernetes 集群
+type CloudAccount struct {
+	pixiu.Model
+
+	// 账号名称，全局唯一
+	Name string `gorm:"type:varchar(128);index:idx_name,unique" json:"name"`
+	// 云厂商，支持 ack，eks 和 gke
+	Provider string `gorm:"type:varchar(32)" json:"provider"`
+	Region   string `gorm:"type:varchar(64)" json:"region"`
+	// GKE 的项目 ID
+	ProjectId string `gorm:"type:varchar(128)" json:"project_id"`
+
+	AccessKey string `gorm:"type:varchar(255)" json:"access_key"`
+	// GKE 为 service account 的 json 密钥，不对外返回
+	SecretKey string `gorm:"type:text" json:"-"`
+
+	Description string `gorm:"type:text" json:"description"`
+}
+
+func (*CloudAccount) TableName() string {
+	return "cloud_accounts"
+}
@@ -16,7 +16,11 @@ limitations under the License.
 
 package model
 
-import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
 
 func init() {
 	register(&Cluster{})
@@ -30,14 +34,25 @@ const (
 	ClusterTypeCustom                      // 自建集群
 )
 
+// ClusterAccessMode 代理用户请求时访问集群的方式
+type ClusterAccessMode string
+
+const (
+	// ClusterAccessDirect 直接使用 pixiu 的凭证访问，为空时同样视为直接访问
+	ClusterAccessDirect ClusterAccessMode = "direct"
+	// ClusterAccessImpersonate 使用 pixiu 的凭证并模拟当前用户访问，由集群的 RBAC 控制用户权限
+	ClusterAccessImpersonate ClusterAccessMode = "impersonate"
+)
+
 type ClusterStatus uint8
 
 const (
-	ClusterStatusRunning ClusterStatus = iota // 运行中
-	ClusterStatusDeploy                       // 部署中
-	ClusterStatusUnStart                      // 等待部署
-	ClusterStatusFailed                       // 部署失败
-	ClusterStatusError                        // 集群失联，API不可用
+	ClusterStatusRunning  ClusterStatus = iota // 运行中
+	ClusterStatusDeploy                        // 部署中
+	ClusterStatusUnStart                       // 等待部署
+	ClusterStatusFailed                        // 部署失败
+	ClusterStatusError                         // 集群失联，API不可用
+	ClusterStatusDegraded                      // API 可用，但存在不健康的 node
 )
 
 // Cluster kubernetes 集群信息
@@ -54,8 +69,10 @@ type Cluster struct {
 	// 自建集群关联的 PlanId
 	PlanId int64
 
-	// 集群运行状态 0: 运行中 1: 部署中 2: 等待部署 3: 部署失败 4: 运行中断 5: 所有的 node 不健康
+	// 集群运行状态 0: 运行中 1: 部署中 2: 等待部署 3: 部署失败 4: 运行中断 5: 存在不健康的 node
 	ClusterStatus `gorm:"column:status;type:tinyint" json:"status"`
+	// 最近一次健康检查的时间
+	LastProbeTime *time.Time `json:"last_probe_time"`
 
 	// 集群的版本
 	KubernetesVersion string `gorm:"type:varchar(255)" json:"kubernetes_version,omitempty"`
@@ -72,6 +89,27 @@ type Cluster struct {
 
 	// 集群用途描述，可以为空
 	Description string `gorm:"type:text" json:"description"`
+
+	// 集群标签，json 字符串，用于集群分组的标签选择
+	Labels string `gorm:"type:text" json:"labels"`
+
+	// 代理用户请求时访问集群的方式
+	AccessMode ClusterAccessMode `gorm:"type:varchar(32)" json:"access_mode"`
+
+	// 集群的默认设置，json 字符串，请求中未指定时使用
+	Defaults string `gorm:"type:text" json:"defaults"`
+
+	// 访问集群 API server 时使用的代理，json 字符串，为空时直连
+	Proxy string `gorm:"type:text" json:"proxy"`
+
+	// 从云账号导入的托管集群，关联的云账号和集群在云厂商中的标识
+	CloudAccountId int64  `json:"cloud_account_id"`
+	CloudClusterId string `gorm:"type:varchar(255)" json:"cloud_cluster_id"`
+	// 托管集群凭证的过期时间，为空时凭证长期有效
+	CredentialExpiration *time.Time `json:"credential_expiration"`
+
+	// 集群所属的租户，0 表示未归属任何租户，仅管理员可见
+	TenantId int64 `gorm:"index" json:"tenant_id"`
 }
 
 func (*Cluster) TableName() string {
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&ClusterBootstrap{})
+}
+
+// ClusterBootstrap 通过 agent 导入集群时生成的一次性引导 token，仅保存 token 的摘要
+type ClusterBootstrap struct {
+	pixiu.Model
+
+	TokenHash string `gorm:"type:varchar(64);index:idx_token_hash,unique" json:"-"`
+	// 注册后创建的集群
+	Name        string `gorm:"type:varchar(255)" json:"name"`
+	AliasName   string `json:"alias_name"`
+	Description string `gorm:"type:text" json:"description"`
+	// pixiu 访问集群时使用的 API server 地址
+	Server string `gorm:"type:varchar(255)" json:"server"`
+	// 生成 token 的用户，注册后的集群权限归属于该用户
+	UserId int64 `json:"user_id"`
+	// 通过 agent 建立的反向隧道访问集群，注册后 token 继续用于建立隧道
+	Tunnel bool `json:"tunnel"`
+
+	ExpirationTimestamp time.Time  `json:"expiration_timestamp"`
+	RegisteredAt        *time.Time `json:"registered_at"`
+}
+
+func (*ClusterBootstrap) TableName() string {
+	return "cluster_bootstraps"
+}
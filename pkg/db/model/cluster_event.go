@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&ClusterEvent{})
+}
+
+// ClusterEvent 持久化的 kubernetes 事件，同一个事件的多次更新合并为一条记录
+type ClusterEvent struct {
+	pixiu.Model
+
+	Cluster string `gorm:"type:varchar(255);index:idx_cluster_uid,unique,priority:1" json:"cluster"`
+	// kubernetes 中 event 的 uid
+	UID string `gorm:"column:uid;type:varchar(64);index:idx_cluster_uid,unique,priority:2" json:"uid"`
+
+	Namespace string `gorm:"type:varchar(255);index:idx_namespace" json:"namespace"`
+	// 事件关联对象的类型和名称
+	Kind string `gorm:"type:varchar(64)" json:"kind"`
+	Name string `gorm:"type:varchar(255)" json:"name"`
+
+	// Normal 或者 Warning
+	Type    string `gorm:"type:varchar(32)" json:"type"`
+	Reason  string `gorm:"type:varchar(128);index:idx_reason" json:"reason"`
+	Message string `gorm:"type:text" json:"message"`
+	Source  string `gorm:"type:varchar(255)" json:"source"`
+	Count   int32  `json:"count"`
+
+	FirstTimestamp time.Time `json:"first_timestamp"`
+	LastTimestamp  time.Time `gorm:"index:idx_last_timestamp" json:"last_timestamp"`
+}
+
+func (*ClusterEvent) TableName() string {
+	return "cluster_events"
+}
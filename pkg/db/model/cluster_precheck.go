@@ -0,0 +1,43 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&ClusterPrecheck{})
+}
+
+// ClusterPrecheck 维护操作（升级，节点排空等）前的集群巡检记录
+type ClusterPrecheck struct {
+	pixiu.Model
+
+	Cluster string `gorm:"type:varchar(255);index:idx_cluster" json:"cluster"`
+	// upgrade，drain 或者 maintenance
+	Operation string `gorm:"type:varchar(32)" json:"operation"`
+	// 目标节点，逗号分隔，为空时检查整个集群
+	Nodes string `gorm:"type:text" json:"nodes"`
+	// 是否可以执行，存在阻断项时为 false
+	Passed bool `json:"passed"`
+	// 检查项的 json 序列化
+	Report   string `gorm:"type:text" json:"report"`
+	Operator string `gorm:"type:varchar(128)" json:"operator"`
+}
+
+func (*ClusterPrecheck) TableName() string {
+	return "cluster_prechecks"
+}
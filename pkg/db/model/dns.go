@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&DNSRecord{})
+}
+
+const (
+	DNSRecordKindService = "service"
+	DNSRecordKindIngress = "ingress"
+)
+
+// DNSRecord 对外暴露的 service/ingress 在 DNS 服务中注册的解析记录
+type DNSRecord struct {
+	pixiu.Model
+
+	Cluster   string `gorm:"type:varchar(255);index:idx_cluster" json:"cluster"`
+	Namespace string `gorm:"type:varchar(255)" json:"namespace"`
+	// 暴露的对象类型，service 或者 ingress
+	Kind string `gorm:"type:varchar(64)" json:"kind"`
+	Name string `gorm:"type:varchar(255)" json:"name"`
+
+	// 完整域名，全局唯一
+	Domain string `gorm:"type:varchar(255);index:idx_domain,unique" json:"domain"`
+	// 记录类型，A 或者 CNAME
+	Type     string `gorm:"type:varchar(32)" json:"type"`
+	Value    string `gorm:"type:varchar(255)" json:"value"`
+	Provider string `gorm:"type:varchar(64)" json:"provider"`
+}
+
+func (*DNSRecord) TableName() string {
+	return "dns_records"
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&Fleet{})
+}
+
+// Fleet 集群分组，成员为标签选择器匹配的集群与显式指定的集群的并集
+type Fleet struct {
+	pixiu.Model
+
+	Name string `gorm:"type:varchar(255);index:idx_name,unique" json:"name"`
+	// 集群标签选择器，语法与 kubernetes label selector 一致，例如 env=prod,region in (sh,bj)
+	Selector string `gorm:"type:varchar(1024)" json:"selector"`
+	// 显式指定的集群名称列表，json 字符串
+	Clusters    string `gorm:"type:text" json:"clusters"`
+	Description string `gorm:"type:text" json:"description"`
+}
+
+func (*Fleet) TableName() string {
+	return "fleets"
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&HelmSecret{})
+}
+
+// HelmSecret 平台保存的敏感变量，helm values 中通过 ${secret:name} 引用，安装时在服务端替换
+// 注意: value 以明文保存在数据库中，接口不会返回，需要通过数据库的访问控制和备份加密保护
+type HelmSecret struct {
+	pixiu.Model
+
+	Name        string `gorm:"type:varchar(255);index:idx_name,unique" json:"name"`
+	Value       string `gorm:"type:text" json:"-"`
+	Description string `gorm:"type:text" json:"description"`
+}
+
+func (*HelmSecret) TableName() string {
+	return "helm_secrets"
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&SidecarTemplate{}, &Injection{})
+}
+
+const (
+	SidecarTypeSidecar = "sidecar"
+	SidecarTypeInit    = "init"
+
+	InjectionApplied    = "applied"
+	InjectionPartial    = "partial"
+	InjectionFailed     = "failed"
+	InjectionRolledBack = "rolled_back"
+)
+
+// SidecarTemplate 由管理员维护的 sidecar 或者 init-container 模板，例如日志采集或者服务网格代理
+type SidecarTemplate struct {
+	pixiu.Model
+
+	Name string `gorm:"type:varchar(255);index:idx_name,unique" json:"name"`
+	// sidecar 或者 init
+	Type string `gorm:"type:varchar(32)" json:"type"`
+	// 注入的容器以及依赖的卷，json 字符串
+	Container   string `gorm:"type:text" json:"container"`
+	Volumes     string `gorm:"type:text" json:"volumes"`
+	Description string `gorm:"type:text" json:"description"`
+}
+
+func (*SidecarTemplate) TableName() string {
+	return "sidecar_templates"
+}
+
+// Injection 一次注入操作，记录注入时的模板快照和每个工作负载的结果，用于回滚
+type Injection struct {
+	pixiu.Model
+
+	Cluster      string `gorm:"type:varchar(255);index:idx_cluster" json:"cluster"`
+	TemplateName string `gorm:"type:varchar(255)" json:"template_name"`
+	Type         string `gorm:"type:varchar(32)" json:"type"`
+	// 注入时的容器名称
+	ContainerName string `gorm:"type:varchar(255)" json:"container_name"`
+	// 每个工作负载的注入结果，json 字符串
+	Results  string `gorm:"type:text" json:"results"`
+	Status   string `gorm:"type:varchar(32)" json:"status"`
+	Operator string `gorm:"type:varchar(255)" json:"operator"`
+}
+
+func (*Injection) TableName() string {
+	return "injections"
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&Inspection{}, &InspectionReport{})
+}
+
+const (
+	InspectionTriggerSchedule = "schedule"
+	InspectionTriggerManual   = "manual"
+)
+
+// Inspection 集群的定期巡检计划，由 jobmanager 的 inspection-scheduler 按 cron 表达式执行
+type Inspection struct {
+	pixiu.Model
+
+	Cluster string `gorm:"type:varchar(255);index:idx_cluster" json:"cluster"`
+	Name    string `gorm:"type:varchar(128)" json:"name"`
+
+	// 标准 5 位 cron 表达式，例如 0 8 * * *
+	Schedule string `gorm:"type:varchar(128)" json:"schedule"`
+	// 检查项，逗号分隔，为空时执行全部检查项
+	Checks string `gorm:"type:text" json:"checks"`
+	// 接收巡检摘要的用户 id，逗号分隔
+	Receivers string `gorm:"type:text" json:"receivers"`
+	Enabled   bool   `json:"enabled"`
+
+	// 最近一次执行的时间
+	LastRunTime *time.Time `json:"last_run_time"`
+	Description string     `gorm:"type:text" json:"description"`
+}
+
+func (*Inspection) TableName() string {
+	return "inspections"
+}
+
+// InspectionReport 巡检报告，score 为各检查项得分的平均值
+type InspectionReport struct {
+	pixiu.Model
+
+	InspectionId int64  `gorm:"index:idx_inspection" json:"inspection_id"`
+	Cluster      string `gorm:"type:varchar(255)" json:"cluster"`
+	// schedule 或者 manual
+	Trigger string `gorm:"type:varchar(32)" json:"trigger"`
+	Score   int    `json:"score"`
+	// 检查项的 json 序列化
+	Report   string `gorm:"type:text" json:"report"`
+	Operator string `gorm:"type:varchar(128)" json:"operator"`
+}
+
+func (*InspectionReport) TableName() string {
+	return "inspection_reports"
+}
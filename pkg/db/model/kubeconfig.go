@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&KubeConfig{})
+}
+
+const (
+	CredentialTypeToken       = "token"
+	CredentialTypeCertificate = "certificate"
+)
+
+// KubeConfig 为平台用户签发的 kubeconfig，凭证为 ServiceAccount 的限时 token
+type KubeConfig struct {
+	pixiu.Model
+
+	UserId  int64  `gorm:"index:idx_user" json:"user_id"`
+	Cluster string `gorm:"type:varchar(255);index:idx_cluster" json:"cluster"`
+
+	ServiceAccount string `gorm:"type:varchar(255)" json:"service_account"`
+	Namespace      string `gorm:"type:varchar(255)" json:"namespace"`
+	ClusterRole    string `gorm:"type:varchar(255)" json:"cluster_role"`
+	// 命名空间级的 kubeconfig 通过 RoleBinding 授权，role 不为空时绑定该命名空间中的 Role
+	Namespaced bool   `json:"namespaced"`
+	Role       string `gorm:"type:varchar(255)" json:"role"`
+	// kubeconfig 中使用的 API server 地址
+	Server string `gorm:"type:varchar(255)" json:"server"`
+	// 签发时自定义的 context 名称，为空时使用生成的名称
+	ContextName string `gorm:"type:varchar(255)" json:"context_name"`
+	// 凭证类型，token 或者 certificate，为 certificate 时 service_account 为绑定关系的名称，不创建 ServiceAccount
+	CredentialType string `gorm:"type:varchar(32)" json:"credential_type"`
+	// 证书凭证的用户名和用户组，用户组以逗号分隔
+	CertificateUser string `gorm:"type:varchar(255)" json:"certificate_user"`
+	Groups          string `gorm:"type:varchar(1024)" json:"groups"`
+
+	ExpirationTimestamp time.Time `json:"expiration_timestamp"`
+	Config              string    `gorm:"type:text" json:"-"`
+
+	// token 的有效期，单位为秒，轮换时使用相同的有效期
+	TTL         int64      `json:"ttl"`
+	LastRotated *time.Time `json:"last_rotated"`
+
+	// 吊销后 ServiceAccount 已被删除，仅保留记录
+	Revoked   bool       `json:"revoked"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+func (*KubeConfig) TableName() string {
+	return "kubeconfigs"
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&KubectlSession{})
+}
+
+// KubectlSession web kubectl 的会话记录
+type KubectlSession struct {
+	pixiu.Model
+
+	Cluster string `gorm:"type:varchar(255);index:idx_cluster" json:"cluster"`
+	User    string `gorm:"type:varchar(255)" json:"user"`
+	Pod     string `gorm:"type:varchar(255)" json:"pod"`
+	// 会话绑定的 ClusterRole，由用户的角色决定
+	ClusterRole string `gorm:"type:varchar(128)" json:"cluster_role"`
+
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	// 会话中的终端输入
+	Input string `gorm:"type:text" json:"input"`
+}
+
+func (*KubectlSession) TableName() string {
+	return "kubectl_sessions"
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&Lease{})
+}
+
+const (
+	// LeaseWorkloadChange 多副本部署时仅由持有者记录工作负载的变更，避免重复记录
+	LeaseWorkloadChange = "workload_change"
+)
+
+// Lease 多副本部署时的租约，持有者在 RenewTime 之后的有效期内独占
+type Lease struct {
+	pixiu.Model
+
+	Name      string    `gorm:"type:varchar(128);index:idx_name,unique" json:"name"`
+	Holder    string    `gorm:"type:varchar(128)" json:"holder"`
+	RenewTime time.Time `json:"renew_time"`
+}
+
+func (*Lease) TableName() string {
+	return "leases"
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&Menu{})
+}
+
+// Menu 前端的菜单，parent 为父菜单的 name，为空时为一级菜单
+// object_type 为查看菜单需要具备权限的资源，为空时所有登陆用户可见
+type Menu struct {
+	pixiu.Model
+
+	Name       string     `gorm:"type:varchar(128);index:idx_name,unique" json:"name"`
+	Title      string     `gorm:"type:varchar(128)" json:"title"`
+	Path       string     `gorm:"type:varchar(255)" json:"path"`
+	Icon       string     `gorm:"type:varchar(128)" json:"icon"`
+	Parent     string     `gorm:"type:varchar(128);index" json:"parent"`
+	ObjectType ObjectType `gorm:"type:varchar(128)" json:"object_type"`
+	Sort       int        `json:"sort"`
+}
+
+func (*Menu) TableName() string {
+	return "menus"
+}
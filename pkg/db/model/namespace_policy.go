@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&NamespacePolicy{})
+}
+
+// NamespacePolicy 命名空间的过期清理策略，用于预览等临时环境，过期后自动删除命名空间
+type NamespacePolicy struct {
+	pixiu.Model
+
+	Cluster   string `gorm:"type:varchar(255);uniqueIndex:idx_cluster_namespace" json:"cluster"`
+	Namespace string `gorm:"type:varchar(255);uniqueIndex:idx_cluster_namespace" json:"namespace"`
+
+	ExpireTime time.Time `json:"expire_time"`
+	// 是否已发送即将过期的提醒，延期后重置
+	Warned      bool   `json:"warned"`
+	Description string `gorm:"type:text" json:"description"`
+	// 创建策略的用户，过期前向其发送提醒
+	UserId int64 `json:"user_id"`
+}
+
+func (*NamespacePolicy) TableName() string {
+	return "namespace_policies"
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&NamingPolicy{})
+}
+
+// 支持命名规范的资源类型
+const (
+	NamingResourceNamespace  = "namespaces"
+	NamingResourceDeployment = "deployments"
+	NamingResourcePlan       = "plans"
+	NamingResourceCloud      = "clouds"
+)
+
+// NamingPolicy 资源的命名规范，创建资源时名称需完整匹配 Pattern
+// TenantId 为 0 时为全局规范，租户存在自己的规范时优先使用租户的规范
+type NamingPolicy struct {
+	pixiu.Model
+
+	TenantId     int64  `gorm:"index:idx_tenant_resource,unique" json:"tenant_id"`
+	ResourceType string `gorm:"type:varchar(64);index:idx_tenant_resource,unique" json:"resource_type"`
+	Pattern      string `gorm:"type:varchar(512)" json:"pattern"`
+	Description  string `gorm:"type:text" json:"description"`
+}
+
+func (*NamingPolicy) TableName() string {
+	return "naming_policies"
+}
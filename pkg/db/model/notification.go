@@ -0,0 +1,59 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&Notification{}, &NotificationPreference{})
+}
+
+// Notification 用户站内通知
+type Notification struct {
+	pixiu.Model
+
+	UserId int64 `gorm:"index:idx_user_ref,priority:1" json:"user_id"`
+	// 通知类型，例如 plan，kubeconfig 和 approval
+	Kind    string `gorm:"type:varchar(32)" json:"kind"`
+	Title   string `gorm:"type:varchar(255)" json:"title"`
+	Content string `gorm:"type:text" json:"content"`
+	// 通知关联的对象，例如 kubeconfig/1，用于避免重复通知
+	Ref string `gorm:"type:varchar(255);index:idx_user_ref,priority:2" json:"ref"`
+	// read 为 mysql 的保留字
+	Read bool `gorm:"column:is_read;index:idx_read" json:"read"`
+}
+
+func (*Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationPreference 用户的通知渠道设置，站内通知始终开启
+type NotificationPreference struct {
+	pixiu.Model
+
+	UserId int64 `gorm:"index:idx_user,unique" json:"user_id"`
+
+	EmailEnabled   bool   `json:"email_enabled"`
+	WebhookEnabled bool   `json:"webhook_enabled"`
+	WebhookURL     string `gorm:"type:varchar(255)" json:"webhook_url"`
+	// 不通过邮件和 webhook 发送的通知类型，逗号分隔
+	MutedKinds string `gorm:"type:varchar(255)" json:"muted_kinds"`
+}
+
+func (*NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
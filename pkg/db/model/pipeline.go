@@ -0,0 +1,67 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&Pipeline{}, &PipelineRun{})
+}
+
+// Pipeline 外部 CI 服务(jenkins/gitlab)中的流水线
+type Pipeline struct {
+	pixiu.Model
+
+	Name string `gorm:"type:varchar(255);index:idx_name,unique" json:"name"`
+	// CI 服务类型，jenkins 或者 gitlab
+	Provider string `gorm:"type:varchar(64)" json:"provider"`
+	URL      string `gorm:"type:varchar(255)" json:"url"`
+	// jenkins 的 job 名称，或者 gitlab 的 project id
+	Project string `gorm:"type:varchar(255)" json:"project"`
+	// gitlab 触发的分支或者 tag
+	Ref         string `gorm:"type:varchar(255)" json:"ref"`
+	Username    string `gorm:"type:varchar(255)" json:"username"`
+	Token       string `gorm:"type:varchar(255)" json:"-"`
+	Description string `gorm:"type:text" json:"description"`
+}
+
+func (*Pipeline) TableName() string {
+	return "pipelines"
+}
+
+// PipelineRun 由 pixiu 触发的一次流水线运行
+type PipelineRun struct {
+	pixiu.Model
+
+	PipelineId int64 `gorm:"index:idx_pipeline" json:"pipeline_id"`
+	// CI 服务中的运行 id
+	ExternalId string `gorm:"type:varchar(128)" json:"external_id"`
+	Status     string `gorm:"type:varchar(32);index:idx_status" json:"status"`
+	WebURL     string `gorm:"type:varchar(255)" json:"web_url"`
+	Message    string `gorm:"type:text" json:"message"`
+
+	// 部署的集群和镜像 tag，用于关联平台的部署与 CI 运行
+	Cluster  string `gorm:"type:varchar(255)" json:"cluster"`
+	ImageTag string `gorm:"type:varchar(255)" json:"image_tag"`
+	// 触发时的全部参数，json 字符串
+	Parameters string `gorm:"type:text" json:"parameters"`
+	Operator   string `gorm:"type:varchar(255)" json:"operator"`
+}
+
+func (*PipelineRun) TableName() string {
+	return "pipeline_runs"
+}
@@ -24,11 +24,29 @@ func init() {
 	register(&Plan{}, &Node{}, &Config{}, &Task{})
 }
 
+// PlanBackend 部署计划的部署方式
+type PlanBackend string
+
+const (
+	// PlanBackendSSH 通过 ssh 登陆节点部署，为空时同样视为 ssh 部署
+	PlanBackendSSH PlanBackend = "ssh"
+	// PlanBackendCAPI 由管理集群中的 Cluster API 创建和管理集群
+	PlanBackendCAPI PlanBackend = "capi"
+)
+
 type Plan struct {
 	pixiu.Model
 
 	Name        string `gorm:"index:idx_name,unique" json:"name"`
 	Description string `gorm:"type:text" json:"description"`
+
+	Backend PlanBackend `gorm:"type:varchar(32)" json:"backend"`
+	// Cluster API 部署时的管理集群，以及 Cluster 对象所在的命名空间，Cluster 对象的名称与计划名称相同
+	ManagementCluster string `gorm:"type:varchar(255)" json:"management_cluster"`
+	Namespace         string `gorm:"type:varchar(255)" json:"namespace"`
+
+	// 部署计划所属的租户，由创建人所属的租户决定
+	TenantId int64 `gorm:"index" json:"tenant_id"`
 }
 
 func (plan *Plan) TableName() string {
@@ -49,6 +67,18 @@ const (
 	ContainerdCRI CRI = "containerd"
 )
 
+// 节点支持的 CPU 架构
+const (
+	ArchAMD64 = "amd64"
+	ArchARM64 = "arm64"
+)
+
+// 节点的操作系统类型，决定部署时使用的软件包格式
+const (
+	OSFamilyDebian = "debian" // ubuntu，debian 等使用 deb 包的系统
+	OSFamilyRedHat = "redhat" // centos，rocky，openEuler 等使用 rpm 包的系统
+)
+
 type Node struct {
 	pixiu.Model
 
@@ -58,6 +88,9 @@ type Node struct {
 	CRI    CRI    `json:"cri"`
 	Ip     string `json:"ip"`
 	Auth   string `json:"auth"`
+	// 未指定时在启动部署计划时自动探测
+	Arch     string `json:"arch"`
+	OSFamily string `json:"os_family"`
 }
 
 func (node *Node) TableName() string {
@@ -74,6 +107,8 @@ type Config struct {
 	Network    string `json:"network"`
 	Runtime    string `json:"runtime"`
 	Component  string `json:"component"`
+	Execution  string `json:"execution"`
+	Hooks      string `gorm:"type:text" json:"hooks"`
 }
 
 func (config *Config) TableName() string {
@@ -0,0 +1,36 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&UserPreference{})
+}
+
+// UserPreference 用户的前端设置，例如表格列布局，默认集群和语言，value 为 json 格式
+type UserPreference struct {
+	pixiu.Model
+
+	UserId int64  `gorm:"index:idx_user_name,unique" json:"user_id"`
+	Name   string `gorm:"type:varchar(128);index:idx_user_name,unique" json:"name"`
+	Value  string `gorm:"type:text" json:"value"`
+}
+
+func (*UserPreference) TableName() string {
+	return "user_preferences"
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&Propagation{}, &PropagationTarget{})
+}
+
+const (
+	PropagationKindManifest = "manifest"
+	PropagationKindHelm     = "helm"
+)
+
+// Propagation 将同一份 manifest 或者 helm release 分发到多个集群
+type Propagation struct {
+	pixiu.Model
+
+	Name string `gorm:"type:varchar(255);index:idx_name,unique" json:"name"`
+	// 分发类型，manifest 或者 helm
+	Kind      string `gorm:"type:varchar(32)" json:"kind"`
+	Namespace string `gorm:"type:varchar(255)" json:"namespace"`
+	// kind 为 manifest 时的 yaml 模板
+	Manifest string `gorm:"type:text" json:"manifest"`
+	// kind 为 helm 时的 chart 和版本，release 名称与 name 一致
+	Chart   string `gorm:"type:varchar(255)" json:"chart"`
+	Version string `gorm:"type:varchar(64)" json:"version"`
+	// 所有集群共用的 values，json 字符串
+	Values      string `gorm:"type:text" json:"values"`
+	Description string `gorm:"type:text" json:"description"`
+}
+
+func (*Propagation) TableName() string {
+	return "propagations"
+}
+
+// PropagationTarget 分发到单个集群的状态
+type PropagationTarget struct {
+	pixiu.Model
+
+	PropagationId int64  `gorm:"index:idx_propagation" json:"propagation_id"`
+	Cluster       string `gorm:"type:varchar(255)" json:"cluster"`
+	// 集群级别覆盖的 values，json 字符串
+	Overrides string `gorm:"type:text" json:"overrides"`
+	Status    string `gorm:"type:varchar(32)" json:"status"`
+	Message   string `gorm:"type:text" json:"message"`
+	// 已执行的次数，包含自动重试
+	Attempts int `json:"attempts"`
+}
+
+func (*PropagationTarget) TableName() string {
+	return "propagation_targets"
+}
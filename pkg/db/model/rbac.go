@@ -54,12 +54,28 @@ var OperationMap = map[Operation]struct{}{
 type ObjectType string
 
 const (
-	ObjectUser    ObjectType = "users"
-	ObjectCluster ObjectType = "clusters"
-	ObjectTenant  ObjectType = "tenants"
-	ObjectPlan    ObjectType = "plans"
-	ObjectAuth    ObjectType = "auth"
-	ObjectAll     ObjectType = "*"
+	ObjectUser        ObjectType = "users"
+	ObjectCluster     ObjectType = "clusters"
+	ObjectTenant      ObjectType = "tenants"
+	ObjectPlan        ObjectType = "plans"
+	ObjectAuth        ObjectType = "auth"
+	ObjectPipeline    ObjectType = "pipelines"
+	ObjectPropagation ObjectType = "propagations"
+	ObjectFleet       ObjectType = "fleets"
+	ObjectTemplate    ObjectType = "templates"
+	ObjectReplication ObjectType = "replications"
+	ObjectKubeConfig  ObjectType = "kubeconfigs"
+	ObjectSecret      ObjectType = "secrets" // 查看集群中 secret 的明文，sid 为集群的 id
+	// ObjectKubeConfigSecret 在 kubeconfig 列表中查看其他用户 kubeconfig 的明文，与 kubeconfigs 的读权限分开授权
+	ObjectKubeConfigSecret ObjectType = "kubeconfigsecrets"
+	ObjectAll              ObjectType = "*"
+
+	// ObjectDebug 服务的调试接口，仅管理员可以访问，不允许授权给其他用户
+	ObjectDebug ObjectType = "debug"
+	// ObjectAnnouncement 平台公告的管理接口，仅管理员可以访问，用户通过 /pixiu/users/me/announcements 获取
+	ObjectAnnouncement ObjectType = "announcements"
+	// ObjectSession 所有实例进行中的长连接会话，仅管理员可以访问
+	ObjectSession ObjectType = "sessions"
 )
 
 func (o ObjectType) String() string {
@@ -67,12 +83,20 @@ func (o ObjectType) String() string {
 }
 
 var ObjectTypeMap = map[ObjectType]struct{}{
-	ObjectUser:    {},
-	ObjectCluster: {},
-	ObjectTenant:  {},
-	ObjectPlan:    {},
-	ObjectAuth:    {},
-	ObjectAll:     {},
+	ObjectUser:             {},
+	ObjectCluster:          {},
+	ObjectTenant:           {},
+	ObjectPlan:             {},
+	ObjectAuth:             {},
+	ObjectPipeline:         {},
+	ObjectPropagation:      {},
+	ObjectFleet:            {},
+	ObjectTemplate:         {},
+	ObjectReplication:      {},
+	ObjectKubeConfig:       {},
+	ObjectSecret:           {},
+	ObjectKubeConfigSecret: {},
+	ObjectAll:              {},
 }
 
 // TODO:
@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&RecycledObject{})
+}
+
+// RecycledObject 通过 pixiu 删除的工作负载快照，过期前可以恢复
+type RecycledObject struct {
+	pixiu.Model
+
+	Cluster   string    `gorm:"type:varchar(255);index:idx_cluster" json:"cluster"`
+	Namespace string    `gorm:"type:varchar(255)" json:"namespace"`
+	Group     string    `gorm:"type:varchar(255)" json:"group"`
+	Version   string    `gorm:"type:varchar(64)" json:"version"`
+	Resource  string    `gorm:"type:varchar(255)" json:"resource"`
+	Kind      string    `gorm:"type:varchar(64)" json:"kind"`
+	Name      string    `gorm:"type:varchar(255)" json:"name"`
+	DeletedBy string    `gorm:"type:varchar(255)" json:"deleted_by"`
+	ExpireAt  time.Time `gorm:"column:expire_at;type:datetime;index:idx_expire_at" json:"expire_at"`
+
+	// 删除前的对象，json 字符串
+	Manifest string `gorm:"type:longtext" json:"manifest"`
+}
+
+func (*RecycledObject) TableName() string {
+	return "recycled_objects"
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&Replication{})
+}
+
+const (
+	ReplicationKindSecret    = "Secret"
+	ReplicationKindConfigMap = "ConfigMap"
+
+	ReplicationSynced = "synced"
+	ReplicationFailed = "failed"
+)
+
+// Replication 将源集群中的 Secret 或 ConfigMap 同步到多个集群的命名空间
+type Replication struct {
+	pixiu.Model
+
+	Name string `gorm:"type:varchar(255);index:idx_name,unique" json:"name"`
+
+	// 源对象
+	Cluster    string `gorm:"type:varchar(255)" json:"cluster"`
+	Namespace  string `gorm:"type:varchar(255)" json:"namespace"`
+	Kind       string `gorm:"type:varchar(32)" json:"kind"`
+	SourceName string `gorm:"type:varchar(255)" json:"source_name"`
+
+	// 目标集群和命名空间，json 字符串
+	Targets string `gorm:"type:text" json:"targets"`
+
+	Status       string     `gorm:"type:varchar(32)" json:"status"`
+	Message      string     `gorm:"type:text" json:"message"`
+	LastSyncTime *time.Time `json:"last_sync_time"`
+	Description  string     `gorm:"type:text" json:"description"`
+}
+
+func (*Replication) TableName() string {
+	return "replications"
+}
@@ -26,12 +26,17 @@ func init() {
 	register(&Repository{})
 }
 
+// Repository helm 仓库，名称在同一个集群范围内唯一
 type Repository struct {
 	pixiu.Model
-	Name     string `gorm:"column:name; index:idx_name,unique; not null" json:"name"`
+	// 仓库所属的集群，为空时为全局仓库，所有集群均可使用
+	Cluster  string `gorm:"column:cluster;index:idx_cluster_name,unique,priority:1" json:"cluster"`
+	Name     string `gorm:"column:name;index:idx_cluster_name,unique,priority:2;not null" json:"name"`
 	URL      string `gorm:"column:url;not null" json:"url"`
 	Username string `gorm:"column:username" json:"username"`
 	Password string `gorm:"column:password" json:"password"`
+	// 可见的租户，为 0 时所有租户可见
+	TenantId int64 `gorm:"index" json:"tenant_id"`
 }
 
 func (*Repository) TableName() string {
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&ScalingSchedule{}, &ScalingHistory{})
+}
+
+const (
+	ScalingSucceeded = "succeeded"
+	ScalingFailed    = "failed"
+)
+
+// ScalingSchedule 定时伸缩计划，按 cron 表达式将 deployment 调整为指定副本数
+type ScalingSchedule struct {
+	pixiu.Model
+
+	Cluster   string `gorm:"type:varchar(255);index:idx_cluster" json:"cluster"`
+	Namespace string `gorm:"type:varchar(255)" json:"namespace"`
+	Name      string `gorm:"type:varchar(255)" json:"name"` // deployment 名称
+
+	// 标准 5 位 cron 表达式，例如 0 20 * * 1-5
+	Schedule string `gorm:"type:varchar(128)" json:"schedule"`
+	Replicas int32  `json:"replicas"`
+	Enabled  bool   `json:"enabled"`
+
+	// 最近一次执行的时间
+	LastScheduleTime *time.Time `json:"last_schedule_time"`
+	Description      string     `gorm:"type:text" json:"description"`
+}
+
+func (*ScalingSchedule) TableName() string {
+	return "scaling_schedules"
+}
+
+// ScalingHistory 定时伸缩的执行记录
+type ScalingHistory struct {
+	pixiu.Model
+
+	ScheduleId   int64  `gorm:"index:idx_schedule" json:"schedule_id"`
+	FromReplicas int32  `json:"from_replicas"`
+	ToReplicas   int32  `json:"to_replicas"`
+	Status       string `gorm:"type:varchar(32)" json:"status"`
+	Message      string `gorm:"type:text" json:"message"`
+}
+
+func (*ScalingHistory) TableName() string {
+	return "scaling_histories"
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&Setting{})
+}
+
+const (
+	// SettingLogLevel 运行时调整的日志级别
+	SettingLogLevel = "log_level"
+	// SettingKubeConfigPolicy 用户自助签发 kubeconfig 的限制
+	SettingKubeConfigPolicy = "kubeconfig_policy"
+)
+
+// Setting 服务运行时的配置，value 为 json 格式
+type Setting struct {
+	pixiu.Model
+
+	Name  string `gorm:"type:varchar(128);index:idx_name,unique" json:"name"`
+	Value string `gorm:"type:text" json:"value"`
+}
+
+func (*Setting) TableName() string {
+	return "settings"
+}
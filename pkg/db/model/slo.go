@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import (
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+)
+
+func init() {
+	register(&SLO{}, &SLOSample{})
+}
+
+const (
+	// SLOKindAPIAvailability 集群 apiserver 的可用性，每次探测 /readyz
+	SLOKindAPIAvailability = "api_availability"
+	// SLOKindNodeReadiness 集群中 Ready 节点的比例
+	SLOKindNodeReadiness = "node_readiness"
+	// SLOKindPrometheus 通过 prometheus 查询获取 SLI，查询结果需为 0 到 1 之间的比例
+	SLOKindPrometheus = "prometheus"
+)
+
+// SLO 集群或者租户的服务等级目标，由 jobmanager 的 slo-evaluator 定期采样
+type SLO struct {
+	pixiu.Model
+
+	// 名称，全局唯一
+	Name    string `gorm:"type:varchar(128);index:idx_name,unique" json:"name"`
+	Cluster string `gorm:"type:varchar(255);index:idx_cluster" json:"cluster"`
+	Tenant  string `gorm:"type:varchar(128)" json:"tenant"`
+	Kind    string `gorm:"type:varchar(32)" json:"kind"`
+
+	// 目标值，百分比，例如 99.9
+	Objective float64 `json:"objective"`
+	// 统计窗口的天数
+	WindowDays int `json:"window_days"`
+
+	PrometheusURL string `gorm:"type:varchar(255)" json:"prometheus_url"`
+	Query         string `gorm:"type:text" json:"query"`
+
+	Enabled bool `json:"enabled"`
+	// 最近一次采样的时间
+	LastEvaluateTime *time.Time `json:"last_evaluate_time"`
+	Description      string     `gorm:"type:text" json:"description"`
+}
+
+func (*SLO) TableName() string {
+	return "slos"
+}
+
+// SLOSample SLO 的采样记录，value 为 0 到 1 之间的 SLI
+type SLOSample struct {
+	pixiu.Model
+
+	SLOId   int64   `gorm:"column:slo_id;index:idx_slo" json:"slo_id"`
+	Value   float64 `json:"value"`
+	Message string  `gorm:"type:text" json:"message"`
+}
+
+func (*SLOSample) TableName() string {
+	return "slo_samples"
+}
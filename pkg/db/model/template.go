@@ -0,0 +1,45 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&Template{})
+}
+
+const (
+	TemplateEngineSimple = "simple"
+	TemplateEngineGo     = "go"
+)
+
+// Template 参数化的资源模板，由管理员维护，用户填写变量后实例化到集群
+type Template struct {
+	pixiu.Model
+
+	Name string `gorm:"type:varchar(255);index:idx_name,unique" json:"name"`
+	// 渲染引擎，simple 为 ${var} 替换，go 为 Go template
+	Engine  string `gorm:"type:varchar(32)" json:"engine"`
+	Content string `gorm:"type:text" json:"content"`
+	// 变量定义，json 字符串
+	Variables   string `gorm:"type:text" json:"variables"`
+	Description string `gorm:"type:text" json:"description"`
+}
+
+func (*Template) TableName() string {
+	return "templates"
+}
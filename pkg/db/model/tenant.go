@@ -28,6 +28,11 @@ type Tenant struct {
 	Name        string `gorm:"index:idx_name,unique" json:"name"`
 	Description string `gorm:"type:text" json:"description"`
 	Extension   string `gorm:"type:text" json:"extension,omitempty"`
+	// GPU 配额，0 表示不限制
+	GPUQuota int64 `gorm:"column:gpu_quota" json:"gpu_quota"`
+	// CPU 和内存配额，例如 32 和 64Gi，为空表示不限制
+	CPUQuota    string `gorm:"column:cpu_quota" json:"cpu_quota"`
+	MemoryQuota string `gorm:"column:memory_quota" json:"memory_quota"`
 }
 
 func (tenant *Tenant) TableName() string {
@@ -42,6 +42,10 @@ type User struct {
 	Email       string     `gorm:"type:varchar(128)" json:"email"`
 	Description string     `gorm:"type:text" json:"description"`
 	Extension   string     `gorm:"type:text" json:"extension,omitempty"`
+	// 初始密码或者被管理员重置密码后，需要用户修改密码后才能继续使用
+	PasswordChangeRequired bool `gorm:"default:false" json:"password_change_required"`
+	// 用户所属的租户，普通用户绑定租户后只能访问该租户的数据
+	TenantId int64 `gorm:"index" json:"tenant_id"`
 }
 
 func (user *User) TableName() string {
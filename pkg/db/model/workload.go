@@ -0,0 +1,42 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package model
+
+import "github.com/caoyingjunz/pixiu/pkg/db/model/pixiu"
+
+func init() {
+	register(&WorkloadChange{})
+}
+
+// WorkloadChange 通过 informer 观察到的工作负载 spec 变更记录
+type WorkloadChange struct {
+	pixiu.Model
+
+	Cluster   string `gorm:"type:varchar(255);index:idx_object" json:"cluster"`
+	Namespace string `gorm:"type:varchar(255);index:idx_object" json:"namespace"`
+	Kind      string `gorm:"type:varchar(64);index:idx_object" json:"kind"`
+	Name      string `gorm:"type:varchar(255);index:idx_object" json:"name"`
+
+	// 变更后的 deployment revision
+	Revision string `gorm:"type:varchar(64)" json:"revision"`
+	// 具体的变更内容，json 字符串
+	Changes string `gorm:"type:text" json:"changes"`
+}
+
+func (*WorkloadChange) TableName() string {
+	return "workload_changes"
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type NamespacePolicyInterface interface {
+	Create(ctx context.Context, object *model.NamespacePolicy) (*model.NamespacePolicy, error)
+	Update(ctx context.Context, pid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, pid int64) error
+	Get(ctx context.Context, cluster string, namespace string) (*model.NamespacePolicy, error)
+	List(ctx context.Context, opts ...Options) ([]model.NamespacePolicy, error)
+
+	// MarkWarned 记录已发送过期提醒，由清理任务调用
+	MarkWarned(ctx context.Context, pid int64) error
+}
+
+type namespacePolicy struct {
+	db *gorm.DB
+}
+
+func newNamespacePolicy(db *gorm.DB) NamespacePolicyInterface {
+	return &namespacePolicy{db}
+}
+
+func (n *namespacePolicy) Create(ctx context.Context, object *model.NamespacePolicy) (*model.NamespacePolicy, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := n.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (n *namespacePolicy) Update(ctx context.Context, pid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	f := n.db.WithContext(ctx).Model(&model.NamespacePolicy{}).Where("id = ? and resource_version = ?", pid, resourceVersion).Updates(updates)
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (n *namespacePolicy) Delete(ctx context.Context, pid int64) error {
+	return n.db.WithContext(ctx).Where("id = ?", pid).Delete(&model.NamespacePolicy{}).Error
+}
+
+func (n *namespacePolicy) Get(ctx context.Context, cluster string, namespace string) (*model.NamespacePolicy, error) {
+	var object model.NamespacePolicy
+	if err := n.db.WithContext(ctx).Where("cluster = ? and namespace = ?", cluster, namespace).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (n *namespacePolicy) List(ctx context.Context, opts ...Options) ([]model.NamespacePolicy, error) {
+	var objects []model.NamespacePolicy
+	tx := n.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// MarkWarned 不修改 resource_version，避免与用户的延期操作冲突
+func (n *namespacePolicy) MarkWarned(ctx context.Context, pid int64) error {
+	return n.db.WithContext(ctx).Model(&model.NamespacePolicy{}).Where("id = ?", pid).Update("warned", true).Error
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type NamingPolicyInterface interface {
+	Create(ctx context.Context, object *model.NamingPolicy) (*model.NamingPolicy, error)
+	Update(ctx context.Context, pid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, pid int64) error
+	Get(ctx context.Context, pid int64) (*model.NamingPolicy, error)
+	List(ctx context.Context, opts ...Options) ([]model.NamingPolicy, error)
+
+	GetByResource(ctx context.Context, tenantId int64, resourceType string) (*model.NamingPolicy, error)
+}
+
+type namingPolicy struct {
+	db *gorm.DB
+}
+
+func newNamingPolicy(db *gorm.DB) NamingPolicyInterface {
+	return &namingPolicy{db}
+}
+
+func (n *namingPolicy) Create(ctx context.Context, object *model.NamingPolicy) (*model.NamingPolicy, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := n.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (n *namingPolicy) Update(ctx context.Context, pid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	f := n.db.WithContext(ctx).Model(&model.NamingPolicy{}).Where("id = ? and resource_version = ?", pid, resourceVersion).Updates(updates)
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (n *namingPolicy) Delete(ctx context.Context, pid int64) error {
+	return n.db.WithContext(ctx).Where("id = ?", pid).Delete(&model.NamingPolicy{}).Error
+}
+
+func (n *namingPolicy) Get(ctx context.Context, pid int64) (*model.NamingPolicy, error) {
+	var object model.NamingPolicy
+	if err := n.db.WithContext(ctx).Where("id = ?", pid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (n *namingPolicy) List(ctx context.Context, opts ...Options) ([]model.NamingPolicy, error) {
+	var objects []model.NamingPolicy
+	tx := n.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (n *namingPolicy) GetByResource(ctx context.Context, tenantId int64, resourceType string) (*model.NamingPolicy, error) {
+	var object model.NamingPolicy
+	if err := n.db.WithContext(ctx).Where("tenant_id = ? and resource_type = ?", tenantId, resourceType).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+// WithResourceType 按资源类型过滤
+func WithResourceType(resourceType string) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("resource_type = ?", resourceType)
+	}
+}
+
+// WithTenantId 按租户 ID 过滤
+func WithTenantId(tenantId int64) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("tenant_id = ?", tenantId)
+	}
+}
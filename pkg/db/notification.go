@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type NotificationInterface interface {
+	Create(ctx context.Context, object *model.Notification) (*model.Notification, error)
+	// BatchCreate 批量写入通知，写入后 objects 中的 id 被回填
+	BatchCreate(ctx context.Context, objects []model.Notification) error
+	Delete(ctx context.Context, uid int64, id int64) error
+	List(ctx context.Context, uid int64, opts ...Options) ([]model.Notification, error)
+	Count(ctx context.Context, uid int64, opts ...Options) (int64, error)
+
+	// MarkRead 标记通知为已读，ids 为空时标记全部
+	MarkRead(ctx context.Context, uid int64, ids ...int64) error
+	// ExistsByRef 判断是否已发送过关联对象的通知
+	ExistsByRef(ctx context.Context, uid int64, ref string) (bool, error)
+
+	// GetPreference 获取用户的通知渠道设置，不存在时返回 nil
+	GetPreference(ctx context.Context, uid int64) (*model.NotificationPreference, error)
+	// ListPreferences 批量获取用户的通知渠道设置，未设置的用户不包含在结果中
+	ListPreferences(ctx context.Context, uids ...int64) ([]model.NotificationPreference, error)
+	SavePreference(ctx context.Context, object *model.NotificationPreference) error
+}
+
+type notification struct {
+	db *gorm.DB
+}
+
+func newNotification(db *gorm.DB) NotificationInterface {
+	return &notification{db}
+}
+
+func (n *notification) Create(ctx context.Context, object *model.Notification) (*model.Notification, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := n.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (n *notification) BatchCreate(ctx context.Context, objects []model.Notification) error {
+	if len(objects) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range objects {
+		objects[i].GmtCreate = now
+		objects[i].GmtModified = now
+	}
+
+	return n.db.WithContext(ctx).CreateInBatches(&objects, defaultBatchSize).Error
+}
+
+func (n *notification) Delete(ctx context.Context, uid int64, id int64) error {
+	f := n.db.WithContext(ctx).Where("id = ? and user_id = ?", id, uid).Delete(&model.Notification{})
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (n *notification) List(ctx context.Context, uid int64, opts ...Options) ([]model.Notification, error) {
+	var objects []model.Notification
+	tx := n.db.WithContext(ctx).Where("user_id = ?", uid)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (n *notification) Count(ctx context.Context, uid int64, opts ...Options) (int64, error) {
+	var total int64
+	tx := n.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ?", uid)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+func (n *notification) MarkRead(ctx context.Context, uid int64, ids ...int64) error {
+	tx := n.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? and is_read = ?", uid, false)
+	if len(ids) != 0 {
+		tx = tx.Where("id IN ?", ids)
+	}
+	return tx.Updates(map[string]interface{}{"is_read": true, "gmt_modified": time.Now()}).Error
+}
+
+func (n *notification) ExistsByRef(ctx context.Context, uid int64, ref string) (bool, error) {
+	var total int64
+	if err := n.db.WithContext(ctx).Model(&model.Notification{}).Where("user_id = ? and ref = ?", uid, ref).Count(&total).Error; err != nil {
+		return false, err
+	}
+	return total != 0, nil
+}
+
+func (n *notification) GetPreference(ctx context.Context, uid int64) (*model.NotificationPreference, error) {
+	var object model.NotificationPreference
+	if err := n.db.WithContext(ctx).Where("user_id = ?", uid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (n *notification) ListPreferences(ctx context.Context, uids ...int64) ([]model.NotificationPreference, error) {
+	var objects []model.NotificationPreference
+	if len(uids) == 0 {
+		return objects, nil
+	}
+	if err := n.db.WithContext(ctx).Where("user_id IN ?", uids).Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (n *notification) SavePreference(ctx context.Context, object *model.NotificationPreference) error {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	return n.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"gmt_modified", "email_enabled", "webhook_enabled", "webhook_url", "muted_kinds"}),
+	}).Create(object).Error
+}
+
+// WithNotificationFilter 按通知类型和已读状态过滤，为空时不过滤
+func WithNotificationFilter(kind string, unread bool) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		if len(kind) != 0 {
+			tx = tx.Where("kind = ?", kind)
+		}
+		if unread {
+			tx = tx.Where("is_read = ?", false)
+		}
+		return tx
+	}
+}
@@ -24,6 +24,9 @@ import (
 
 type Options func(*gorm.DB) *gorm.DB
 
+// 批量写入时每条 insert 语句包含的最大记录数
+const defaultBatchSize = 100
+
 func WithOrderByASC() Options {
 	return func(tx *gorm.DB) *gorm.DB {
 		return tx.Order("id ASC")
@@ -64,3 +67,45 @@ func WithIDIn(ids ...int64) Options {
 		return tx.Where("id IN ?", ids)
 	}
 }
+
+func WithCluster(cluster string) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("cluster = ?", cluster)
+	}
+}
+
+func WithClusterIn(clusters ...string) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("cluster IN ?", clusters)
+	}
+}
+
+func WithPipeline(pid int64) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("pipeline_id = ?", pid)
+	}
+}
+
+func WithStatusIn(status ...string) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("status IN ?", status)
+	}
+}
+
+func WithUserId(uid int64) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("user_id = ?", uid)
+	}
+}
+
+func WithUser(user string) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("user = ?", user)
+	}
+}
+
+func WithTenant(tenant string) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("tenant = ?", tenant)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type PipelineInterface interface {
+	Create(ctx context.Context, object *model.Pipeline) (*model.Pipeline, error)
+	Update(ctx context.Context, pid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, pid int64) error
+	Get(ctx context.Context, pid int64) (*model.Pipeline, error)
+	List(ctx context.Context, opts ...Options) ([]model.Pipeline, error)
+
+	GetByName(ctx context.Context, name string) (*model.Pipeline, error)
+
+	CreateRun(ctx context.Context, object *model.PipelineRun) (*model.PipelineRun, error)
+	UpdateRun(ctx context.Context, runId int64, updates map[string]interface{}) error
+	GetRun(ctx context.Context, runId int64) (*model.PipelineRun, error)
+	ListRuns(ctx context.Context, opts ...Options) ([]model.PipelineRun, error)
+}
+
+type pipeline struct {
+	db *gorm.DB
+}
+
+func newPipeline(db *gorm.DB) PipelineInterface {
+	return &pipeline{db}
+}
+
+func (p *pipeline) Create(ctx context.Context, object *model.Pipeline) (*model.Pipeline, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := p.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (p *pipeline) Update(ctx context.Context, pid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	f := p.db.WithContext(ctx).Model(&model.Pipeline{}).Where("id = ? and resource_version = ?", pid, resourceVersion).Updates(updates)
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete 删除流水线，同时删除其运行记录
+func (p *pipeline) Delete(ctx context.Context, pid int64) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("pipeline_id = ?", pid).Delete(&model.PipelineRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", pid).Delete(&model.Pipeline{}).Error
+	})
+}
+
+func (p *pipeline) Get(ctx context.Context, pid int64) (*model.Pipeline, error) {
+	var object model.Pipeline
+	if err := p.db.WithContext(ctx).Where("id = ?", pid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (p *pipeline) List(ctx context.Context, opts ...Options) ([]model.Pipeline, error) {
+	var objects []model.Pipeline
+	tx := p.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (p *pipeline) GetByName(ctx context.Context, name string) (*model.Pipeline, error) {
+	var object model.Pipeline
+	if err := p.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (p *pipeline) CreateRun(ctx context.Context, object *model.PipelineRun) (*model.PipelineRun, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := p.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// UpdateRun 运行记录仅由 pixiu 内部刷新状态，不校验 resource_version
+func (p *pipeline) UpdateRun(ctx context.Context, runId int64, updates map[string]interface{}) error {
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = gorm.Expr("resource_version + 1")
+
+	return p.db.WithContext(ctx).Model(&model.PipelineRun{}).Where("id = ?", runId).Updates(updates).Error
+}
+
+func (p *pipeline) GetRun(ctx context.Context, runId int64) (*model.PipelineRun, error) {
+	var object model.PipelineRun
+	if err := p.db.WithContext(ctx).Where("id = ?", runId).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (p *pipeline) ListRuns(ctx context.Context, opts ...Options) ([]model.PipelineRun, error) {
+	var objects []model.PipelineRun
+	tx := p.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
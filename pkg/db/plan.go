@@ -71,6 +71,7 @@ func (p *plan) Create(ctx context.Context, object *model.Plan) (*model.Plan, err
 	now := time.Now()
 	object.GmtCreate = now
 	object.GmtModified = now
+	setTenant(ctx, &object.TenantId)
 
 	if err := p.db.WithContext(ctx).Create(object).Error; err != nil {
 		return nil, err
@@ -83,7 +84,7 @@ func (p *plan) Update(ctx context.Context, pid int64, resourceVersion int64, upd
 	updates["gmt_modified"] = time.Now()
 	updates["resource_version"] = resourceVersion + 1
 
-	f := p.db.WithContext(ctx).Model(&model.Plan{}).Where("id = ? and resource_version = ?", pid, resourceVersion).Updates(updates)
+	f := p.db.WithContext(ctx).Scopes(tenantScope(ctx)).Model(&model.Plan{}).Where("id = ? and resource_version = ?", pid, resourceVersion).Updates(updates)
 	if f.Error != nil {
 		return f.Error
 	}
@@ -100,7 +101,7 @@ func (p *plan) Delete(ctx context.Context, pid int64) (*model.Plan, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err = p.db.WithContext(ctx).Where("id = ?", pid).Delete(&model.Plan{}).Error; err != nil {
+	if err = p.db.WithContext(ctx).Scopes(tenantScope(ctx)).Where("id = ?", pid).Delete(&model.Plan{}).Error; err != nil {
 		return nil, err
 	}
 
@@ -109,7 +110,7 @@ func (p *plan) Delete(ctx context.Context, pid int64) (*model.Plan, error) {
 
 func (p *plan) Get(ctx context.Context, pid int64) (*model.Plan, error) {
 	var object model.Plan
-	if err := p.db.WithContext(ctx).Where("id = ?", pid).First(&object).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(tenantScope(ctx)).Where("id = ?", pid).First(&object).Error; err != nil {
 		return nil, err
 	}
 
@@ -118,7 +119,7 @@ func (p *plan) Get(ctx context.Context, pid int64) (*model.Plan, error) {
 
 func (p *plan) List(ctx context.Context, opts ...Options) ([]model.Plan, error) {
 	var objects []model.Plan
-	tx := p.db.WithContext(ctx)
+	tx := p.db.WithContext(ctx).Scopes(tenantScope(ctx))
 	for _, opt := range opts {
 		tx = opt(tx)
 	}
@@ -145,7 +146,7 @@ func (p *plan) UpdateNode(ctx context.Context, nodeId int64, resourceVersion int
 	updates["gmt_modified"] = time.Now()
 	updates["resource_version"] = resourceVersion + 1
 
-	f := p.db.WithContext(ctx).Model(&model.Node{}).Where("id = ? and resource_version = ?", nodeId, resourceVersion).Updates(updates)
+	f := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Model(&model.Node{}).Where("id = ? and resource_version = ?", nodeId, resourceVersion).Updates(updates)
 	if f.Error != nil {
 		return f.Error
 	}
@@ -161,7 +162,7 @@ func (p *plan) DeleteNode(ctx context.Context, nodeId int64) (*model.Node, error
 	if err != nil {
 		return nil, err
 	}
-	if err = p.db.WithContext(ctx).Where("id = ?", nodeId).Delete(&model.Node{}).Error; err != nil {
+	if err = p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("id = ?", nodeId).Delete(&model.Node{}).Error; err != nil {
 		return nil, err
 	}
 
@@ -169,7 +170,7 @@ func (p *plan) DeleteNode(ctx context.Context, nodeId int64) (*model.Node, error
 }
 
 func (p *plan) DeleteNodesByPlan(ctx context.Context, planId int64) error {
-	if err := p.db.WithContext(ctx).Where("plan_id = ?", planId).Delete(&model.Node{}).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("plan_id = ?", planId).Delete(&model.Node{}).Error; err != nil {
 		return err
 	}
 
@@ -177,7 +178,7 @@ func (p *plan) DeleteNodesByPlan(ctx context.Context, planId int64) error {
 }
 
 func (p *plan) DeleteNodesByNames(ctx context.Context, planId int64, names []string) error {
-	if err := p.db.WithContext(ctx).Where("plan_id = ? and name in (?)", planId, names).Delete(&model.Node{}).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("plan_id = ? and name in (?)", planId, names).Delete(&model.Node{}).Error; err != nil {
 		return err
 	}
 
@@ -186,7 +187,7 @@ func (p *plan) DeleteNodesByNames(ctx context.Context, planId int64, names []str
 
 func (p *plan) GetNodeByName(ctx context.Context, planId int64, name string) (*model.Node, error) {
 	var object model.Node
-	if err := p.db.WithContext(ctx).Where("plan_id = ? and name = ?", planId, name).First(&object).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("plan_id = ? and name = ?", planId, name).First(&object).Error; err != nil {
 		return nil, err
 	}
 
@@ -195,7 +196,7 @@ func (p *plan) GetNodeByName(ctx context.Context, planId int64, name string) (*m
 
 func (p *plan) GetNode(ctx context.Context, nodeId int64) (*model.Node, error) {
 	var object model.Node
-	if err := p.db.WithContext(ctx).Where("id = ?", nodeId).First(&object).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("id = ?", nodeId).First(&object).Error; err != nil {
 		return nil, err
 	}
 
@@ -204,7 +205,7 @@ func (p *plan) GetNode(ctx context.Context, nodeId int64) (*model.Node, error) {
 
 func (p *plan) ListNodes(ctx context.Context, pid int64, opts ...Options) ([]model.Node, error) {
 	var objects []model.Node
-	tx := p.db.WithContext(ctx).Where("plan_id = ?", pid)
+	tx := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("plan_id = ?", pid)
 	for _, opt := range opts {
 		tx = opt(tx)
 	}
@@ -231,7 +232,7 @@ func (p *plan) UpdateConfig(ctx context.Context, cid int64, resourceVersion int6
 	updates["gmt_modified"] = time.Now()
 	updates["resource_version"] = resourceVersion + 1
 
-	f := p.db.WithContext(ctx).Model(&model.Config{}).Where("id = ? and resource_version = ?", cid, resourceVersion).Updates(updates)
+	f := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Model(&model.Config{}).Where("id = ? and resource_version = ?", cid, resourceVersion).Updates(updates)
 	if f.Error != nil {
 		return f.Error
 	}
@@ -247,7 +248,7 @@ func (p *plan) DeleteConfig(ctx context.Context, cid int64) (*model.Config, erro
 	if err != nil {
 		return nil, err
 	}
-	if err = p.db.WithContext(ctx).Where("id = ?", cid).Delete(&model.Config{}).Error; err != nil {
+	if err = p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("id = ?", cid).Delete(&model.Config{}).Error; err != nil {
 		return nil, err
 	}
 
@@ -255,7 +256,7 @@ func (p *plan) DeleteConfig(ctx context.Context, cid int64) (*model.Config, erro
 }
 
 func (p *plan) DeleteConfigByPlan(ctx context.Context, planId int64) error {
-	if err := p.db.WithContext(ctx).Where("plan_id = ?", planId).Delete(&model.Config{}).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("plan_id = ?", planId).Delete(&model.Config{}).Error; err != nil {
 		return err
 	}
 	return nil
@@ -263,7 +264,7 @@ func (p *plan) DeleteConfigByPlan(ctx context.Context, planId int64) error {
 
 func (p *plan) GetConfig(ctx context.Context, cid int64) (*model.Config, error) {
 	var object model.Config
-	if err := p.db.WithContext(ctx).Where("id = ?", cid).First(&object).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("id = ?", cid).First(&object).Error; err != nil {
 		return nil, err
 	}
 
@@ -272,7 +273,7 @@ func (p *plan) GetConfig(ctx context.Context, cid int64) (*model.Config, error)
 
 func (p *plan) ListConfigs(ctx context.Context, opts ...Options) ([]model.Config, error) {
 	var objects []model.Config
-	tx := p.db.WithContext(ctx)
+	tx := p.db.WithContext(ctx).Scopes(planTenantScope(ctx))
 	for _, opt := range opts {
 		tx = opt(tx)
 	}
@@ -285,7 +286,7 @@ func (p *plan) ListConfigs(ctx context.Context, opts ...Options) ([]model.Config
 
 func (p *plan) GetConfigByPlan(ctx context.Context, planId int64) (*model.Config, error) {
 	var object model.Config
-	if err := p.db.WithContext(ctx).Where("plan_id = ?", planId).First(&object).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("plan_id = ?", planId).First(&object).Error; err != nil {
 		return nil, err
 	}
 
@@ -304,7 +305,7 @@ func (p *plan) CreatTask(ctx context.Context, object *model.Task) (*model.Task,
 }
 
 func (p *plan) UpdateTask(ctx context.Context, pid int64, name string, updates map[string]interface{}) (*model.Task, error) {
-	f := p.db.WithContext(ctx).Model(&model.Task{}).Where("plan_id = ? and name = ?", pid, name).Updates(updates)
+	f := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Model(&model.Task{}).Where("plan_id = ? and name = ?", pid, name).Updates(updates)
 	if f.Error != nil {
 		return nil, f.Error
 	}
@@ -316,7 +317,7 @@ func (p *plan) UpdateTask(ctx context.Context, pid int64, name string, updates m
 }
 
 func (p *plan) DeleteTask(ctx context.Context, pid int64) error {
-	if err := p.db.WithContext(ctx).Where("plan_id = ?", pid).Delete(&model.Task{}).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("plan_id = ?", pid).Delete(&model.Task{}).Error; err != nil {
 		return err
 	}
 
@@ -325,7 +326,7 @@ func (p *plan) DeleteTask(ctx context.Context, pid int64) error {
 
 func (p *plan) ListTasks(ctx context.Context, pid int64, opts ...Options) ([]model.Task, error) {
 	var objects []model.Task
-	tx := p.db.WithContext(ctx).Where("plan_id = ?", pid)
+	tx := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("plan_id = ?", pid)
 	for _, opt := range opts {
 		tx = opt(tx)
 	}
@@ -338,7 +339,7 @@ func (p *plan) ListTasks(ctx context.Context, pid int64, opts ...Options) ([]mod
 
 func (p *plan) GetNewestTask(ctx context.Context, pid int64) (*model.Task, error) {
 	var objects []model.Task
-	if err := p.db.WithContext(ctx).Where("plan_id = ?", pid).Order("id DESC").Limit(1).Find(&objects).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("plan_id = ?", pid).Order("id DESC").Limit(1).Find(&objects).Error; err != nil {
 		return nil, err
 	}
 
@@ -350,7 +351,7 @@ func (p *plan) GetNewestTask(ctx context.Context, pid int64) (*model.Task, error
 
 func (p *plan) GetTaskById(ctx context.Context, taskId int64) (*model.Task, error) {
 	var object model.Task
-	if err := p.db.WithContext(ctx).Where("id = ?", taskId).First(&object).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("id = ?", taskId).First(&object).Error; err != nil {
 		return nil, err
 	}
 
@@ -359,7 +360,7 @@ func (p *plan) GetTaskById(ctx context.Context, taskId int64) (*model.Task, erro
 
 func (p *plan) GetTaskByName(ctx context.Context, planId int64, name string) (*model.Task, error) {
 	var object model.Task
-	if err := p.db.WithContext(ctx).Where("plan_id = ? and name = ?", planId, name).First(&object).Error; err != nil {
+	if err := p.db.WithContext(ctx).Scopes(planTenantScope(ctx)).Where("plan_id = ? and name = ?", planId, name).First(&object).Error; err != nil {
 		return nil, err
 	}
 
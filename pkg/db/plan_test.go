@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+func newTestDB(t testing.TB, models ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	// 内存数据库仅对当前连接可见
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err = db.AutoMigrate(models...); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return db
+}
+
+func TestPlanSubResourcesTenantScope(t *testing.T) {
+	p := newPlan(newTestDB(t, &model.Plan{}, &model.Node{}, &model.Config{}, &model.Task{}))
+
+	tenant1 := WithTenantContext(context.TODO(), 1)
+	tenant2 := WithTenantContext(context.TODO(), 2)
+
+	plan, err := p.Create(tenant1, &model.Plan{Name: "plan1"})
+	if err != nil {
+		t.Fatalf("failed to create plan: %v", err)
+	}
+	node, err := p.CreatNode(tenant1, &model.Node{Name: "node1", PlanId: plan.Id})
+	if err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+	if _, err = p.CreatConfig(tenant1, &model.Config{PlanId: plan.Id}); err != nil {
+		t.Fatalf("failed to create config: %v", err)
+	}
+	if _, err = p.CreatTask(tenant1, &model.Task{Name: "task1", PlanId: plan.Id}); err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantFound bool
+	}{
+		{name: "owner tenant", ctx: tenant1, wantFound: true},
+		{name: "other tenant", ctx: tenant2, wantFound: false},
+		{name: "admin without tenant", ctx: context.TODO(), wantFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.GetNode(tt.ctx, node.Id)
+			if found := err == nil; found != tt.wantFound {
+				t.Errorf("GetNode() found = %v, want %v, err: %v", found, tt.wantFound, err)
+			}
+			nodes, err := p.ListNodes(tt.ctx, plan.Id)
+			if err != nil {
+				t.Fatalf("ListNodes() error: %v", err)
+			}
+			if found := len(nodes) != 0; found != tt.wantFound {
+				t.Errorf("ListNodes() found = %v, want %v", found, tt.wantFound)
+			}
+			_, err = p.GetConfigByPlan(tt.ctx, plan.Id)
+			if found := err == nil; found != tt.wantFound {
+				t.Errorf("GetConfigByPlan() found = %v, want %v, err: %v", found, tt.wantFound, err)
+			}
+			tasks, err := p.ListTasks(tt.ctx, plan.Id)
+			if err != nil {
+				t.Fatalf("ListTasks() error: %v", err)
+			}
+			if found := len(tasks) != 0; found != tt.wantFound {
+				t.Errorf("ListTasks() found = %v, want %v", found, tt.wantFound)
+			}
+		})
+	}
+
+	// 其他租户不能删除
+	if err = p.DeleteNodesByPlan(tenant2, plan.Id); err != nil {
+		t.Fatalf("DeleteNodesByPlan() error: %v", err)
+	}
+	if _, err = p.GetNode(tenant1, node.Id); err != nil {
+		t.Errorf("node is deleted by other tenant: %v", err)
+	}
+}
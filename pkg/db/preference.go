@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+type PreferenceInterface interface {
+	List(ctx context.Context, uid int64) ([]model.UserPreference, error)
+	// Save 设置不存在时创建，存在时更新，names 中的设置会被删除
+	Save(ctx context.Context, uid int64, objects []model.UserPreference, names []string) error
+}
+
+type preference struct {
+	db *gorm.DB
+}
+
+func newPreference(db *gorm.DB) PreferenceInterface {
+	return &preference{db}
+}
+
+func (p *preference) List(ctx context.Context, uid int64) ([]model.UserPreference, error) {
+	var objects []model.UserPreference
+	if err := p.db.WithContext(ctx).Where("user_id = ?", uid).Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (p *preference) Save(ctx context.Context, uid int64, objects []model.UserPreference, names []string) error {
+	now := time.Now()
+	for i := range objects {
+		objects[i].UserId = uid
+		objects[i].GmtCreate = now
+		objects[i].GmtModified = now
+	}
+
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(names) != 0 {
+			if err := tx.Where("user_id = ? and name in ?", uid, names).Delete(&model.UserPreference{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(objects) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"gmt_modified", "value"}),
+		}).Create(&objects).Error
+	})
+}
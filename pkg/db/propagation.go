@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type PropagationInterface interface {
+	// Create 创建分发任务以及所有集群的分发目标
+	Create(ctx context.Context, object *model.Propagation, targets []model.PropagationTarget) (*model.Propagation, error)
+	Delete(ctx context.Context, pid int64) error
+	Get(ctx context.Context, pid int64) (*model.Propagation, error)
+	List(ctx context.Context, opts ...Options) ([]model.Propagation, error)
+
+	GetByName(ctx context.Context, name string) (*model.Propagation, error)
+
+	UpdateTarget(ctx context.Context, targetId int64, updates map[string]interface{}) error
+	// UpdateTargets 使用同一条更新语句批量更新分发目标
+	UpdateTargets(ctx context.Context, targetIds []int64, updates map[string]interface{}) error
+	ListTargets(ctx context.Context, pid int64, opts ...Options) ([]model.PropagationTarget, error)
+}
+
+type propagation struct {
+	db *gorm.DB
+}
+
+// propagationTargets 分发任务与集群的关联表
+var propagationTargets = joinTable{
+	model:        &model.PropagationTarget{},
+	ownerColumn:  "propagation_id",
+	targetColumn: "cluster",
+}
+
+func newPropagation(db *gorm.DB) PropagationInterface {
+	return &propagation{db}
+}
+
+func (p *propagation) Create(ctx context.Context, object *model.Propagation, targets []model.PropagationTarget) (*model.Propagation, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(object).Error; err != nil {
+			return err
+		}
+		return propagationTargets.associate(tx, object.Id, &targets)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// Delete 删除分发任务，同时删除其分发目标
+func (p *propagation) Delete(ctx context.Context, pid int64) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := propagationTargets.dissociate(tx, pid); err != nil {
+			return err
+		}
+		return tx.Where("id = ?", pid).Delete(&model.Propagation{}).Error
+	})
+}
+
+func (p *propagation) Get(ctx context.Context, pid int64) (*model.Propagation, error) {
+	var object model.Propagation
+	if err := p.db.WithContext(ctx).Where("id = ?", pid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (p *propagation) List(ctx context.Context, opts ...Options) ([]model.Propagation, error) {
+	var objects []model.Propagation
+	tx := p.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (p *propagation) GetByName(ctx context.Context, name string) (*model.Propagation, error) {
+	var object model.Propagation
+	if err := p.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (p *propagation) UpdateTarget(ctx context.Context, targetId int64, updates map[string]interface{}) error {
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = gorm.Expr("resource_version + 1")
+
+	return p.db.WithContext(ctx).Model(&model.PropagationTarget{}).Where("id = ?", targetId).Updates(updates).Error
+}
+
+func (p *propagation) UpdateTargets(ctx context.Context, targetIds []int64, updates map[string]interface{}) error {
+	if len(targetIds) == 0 {
+		return nil
+	}
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = gorm.Expr("resource_version + 1")
+
+	return p.db.WithContext(ctx).Model(&model.PropagationTarget{}).Where("id IN ?", targetIds).Updates(updates).Error
+}
+
+func (p *propagation) ListTargets(ctx context.Context, pid int64, opts ...Options) ([]model.PropagationTarget, error) {
+	var objects []model.PropagationTarget
+	tx := p.db.WithContext(ctx).Where("propagation_id = ?", pid)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
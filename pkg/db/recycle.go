@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type RecycleInterface interface {
+	Create(ctx context.Context, object *model.RecycledObject) (*model.RecycledObject, error)
+	Delete(ctx context.Context, id int64) error
+	Get(ctx context.Context, id int64) (*model.RecycledObject, error)
+	List(ctx context.Context, opts ...Options) ([]model.RecycledObject, error)
+
+	// BatchDelete 批量删除回收站中的对象，返回删除的数量
+	BatchDelete(ctx context.Context, opts ...Options) (int64, error)
+}
+
+type recycle struct {
+	db *gorm.DB
+}
+
+func newRecycle(db *gorm.DB) RecycleInterface {
+	return &recycle{db}
+}
+
+func (r *recycle) Create(ctx context.Context, object *model.RecycledObject) (*model.RecycledObject, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := r.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (r *recycle) Delete(ctx context.Context, id int64) error {
+	f := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.RecycledObject{})
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *recycle) Get(ctx context.Context, id int64) (*model.RecycledObject, error) {
+	var object model.RecycledObject
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (r *recycle) List(ctx context.Context, opts ...Options) ([]model.RecycledObject, error) {
+	var objects []model.RecycledObject
+	tx := r.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (r *recycle) BatchDelete(ctx context.Context, opts ...Options) (int64, error) {
+	tx := r.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+
+	f := tx.Delete(&model.RecycledObject{})
+	return f.RowsAffected, f.Error
+}
+
+// WithExpireBefore 过期时间早于指定时间
+func WithExpireBefore(t time.Time) Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("expire_at < ?", t)
+	}
+}
+
+// WithNotExpired 尚未过期
+func WithNotExpired() Options {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("expire_at >= ?", time.Now())
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type ReplicationInterface interface {
+	Create(ctx context.Context, object *model.Replication) (*model.Replication, error)
+	Update(ctx context.Context, rid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, rid int64) error
+	Get(ctx context.Context, rid int64) (*model.Replication, error)
+	List(ctx context.Context, opts ...Options) ([]model.Replication, error)
+
+	GetByName(ctx context.Context, name string) (*model.Replication, error)
+
+	// UpdateStatus 记录同步结果，由同步控制器调用
+	UpdateStatus(ctx context.Context, rid int64, status string, message string) error
+}
+
+type replication struct {
+	db *gorm.DB
+}
+
+func newReplication(db *gorm.DB) ReplicationInterface {
+	return &replication{db}
+}
+
+func (r *replication) Create(ctx context.Context, object *model.Replication) (*model.Replication, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := r.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (r *replication) Update(ctx context.Context, rid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	tx := r.db.WithContext(ctx).Model(&model.Replication{}).Where("id = ? and resource_version = ?", rid, resourceVersion).Updates(updates)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *replication) Delete(ctx context.Context, rid int64) error {
+	return r.db.WithContext(ctx).Where("id = ?", rid).Delete(&model.Replication{}).Error
+}
+
+func (r *replication) Get(ctx context.Context, rid int64) (*model.Replication, error) {
+	var object model.Replication
+	if err := r.db.WithContext(ctx).Where("id = ?", rid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (r *replication) List(ctx context.Context, opts ...Options) ([]model.Replication, error) {
+	var objects []model.Replication
+	tx := r.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (r *replication) GetByName(ctx context.Context, name string) (*model.Replication, error) {
+	var object model.Replication
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+// UpdateStatus 不修改 resource_version，避免与用户的更新冲突
+func (r *replication) UpdateStatus(ctx context.Context, rid int64, status string, message string) error {
+	return r.db.WithContext(ctx).Model(&model.Replication{}).Where("id = ?", rid).Updates(map[string]interface{}{
+		"status":         status,
+		"message":        message,
+		"last_sync_time": time.Now(),
+	}).Error
+}
@@ -30,8 +30,9 @@ type RepositoryInterface interface {
 	Update(ctx context.Context, id int64, resourceVersion int64, updates map[string]interface{}) error
 	Delete(ctx context.Context, id int64) error
 	Get(ctx context.Context, id int64) (*model.Repository, error)
-	GetByName(ctx context.Context, name string) (*model.Repository, error)
-	List(ctx context.Context) ([]*model.Repository, error)
+	// GetByName 获取集群中指定名称的仓库，cluster 为空时获取全局仓库
+	GetByName(ctx context.Context, cluster string, name string) (*model.Repository, error)
+	List(ctx context.Context, opts ...Options) ([]*model.Repository, error)
 }
 
 type repository struct {
@@ -48,6 +49,7 @@ func (r *repository) Create(ctx context.Context, object *model.Repository) (*mod
 	now := time.Now()
 	object.GmtCreate = now
 	object.GmtModified = now
+	setTenant(ctx, &object.TenantId)
 
 	if err := r.db.WithContext(ctx).Create(object).Error; err != nil {
 		return nil, err
@@ -59,7 +61,7 @@ func (r *repository) Update(ctx context.Context, id int64, resourceVersion int64
 	updates["gmt_modified"] = time.Now()
 	updates["resource_version"] = resourceVersion + 1
 
-	f := r.db.WithContext(ctx).Model(&model.Repository{}).Where("id = ? and resource_version = ? ", id, resourceVersion).Updates(updates)
+	f := r.db.WithContext(ctx).Scopes(tenantScope(ctx)).Model(&model.Repository{}).Where("id = ? and resource_version = ? ", id, resourceVersion).Updates(updates)
 	if f.Error != nil {
 		return f.Error
 	}
@@ -73,7 +75,7 @@ func (r *repository) Update(ctx context.Context, id int64, resourceVersion int64
 }
 
 func (r *repository) Delete(ctx context.Context, id int64) error {
-	f := r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Repository{})
+	f := r.db.WithContext(ctx).Scopes(tenantScope(ctx)).Where("id = ?", id).Delete(&model.Repository{})
 	if f.Error != nil {
 		return f.Error
 	}
@@ -87,25 +89,29 @@ func (r *repository) Delete(ctx context.Context, id int64) error {
 
 func (r *repository) Get(ctx context.Context, id int64) (*model.Repository, error) {
 	var repo model.Repository
-	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&repo).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(visibleScope(ctx)).Where("id = ?", id).First(&repo).Error; err != nil {
 		return nil, err
 	}
 
 	return &repo, nil
 }
 
-func (r *repository) GetByName(ctx context.Context, name string) (*model.Repository, error) {
+func (r *repository) GetByName(ctx context.Context, cluster string, name string) (*model.Repository, error) {
 	var repo model.Repository
-	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&repo).Error; err != nil {
+	if err := r.db.WithContext(ctx).Scopes(visibleScope(ctx)).Where("cluster = ? and name = ?", cluster, name).First(&repo).Error; err != nil {
 		return nil, err
 	}
 
 	return &repo, nil
 }
 
-func (r *repository) List(ctx context.Context) ([]*model.Repository, error) {
+func (r *repository) List(ctx context.Context, opts ...Options) ([]*model.Repository, error) {
 	var repos []*model.Repository
-	if err := r.db.WithContext(ctx).Find(&repos).Error; err != nil {
+	tx := r.db.WithContext(ctx).Scopes(visibleScope(ctx))
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&repos).Error; err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type ScalingInterface interface {
+	Create(ctx context.Context, object *model.ScalingSchedule) (*model.ScalingSchedule, error)
+	Update(ctx context.Context, sid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, sid int64) error
+	Get(ctx context.Context, sid int64) (*model.ScalingSchedule, error)
+	List(ctx context.Context, opts ...Options) ([]model.ScalingSchedule, error)
+
+	// UpdateScheduleTime 记录计划的执行时间，由调度任务调用
+	UpdateScheduleTime(ctx context.Context, sid int64, t time.Time) error
+
+	CreateHistory(ctx context.Context, object *model.ScalingHistory) (*model.ScalingHistory, error)
+	ListHistories(ctx context.Context, sid int64, opts ...Options) ([]model.ScalingHistory, error)
+}
+
+type scaling struct {
+	db *gorm.DB
+}
+
+func newScaling(db *gorm.DB) ScalingInterface {
+	return &scaling{db}
+}
+
+func (s *scaling) Create(ctx context.Context, object *model.ScalingSchedule) (*model.ScalingSchedule, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := s.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (s *scaling) Update(ctx context.Context, sid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	f := s.db.WithContext(ctx).Model(&model.ScalingSchedule{}).Where("id = ? and resource_version = ?", sid, resourceVersion).Updates(updates)
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete 删除伸缩计划，同时删除其执行记录
+func (s *scaling) Delete(ctx context.Context, sid int64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", sid).Delete(&model.ScalingHistory{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", sid).Delete(&model.ScalingSchedule{}).Error
+	})
+}
+
+func (s *scaling) Get(ctx context.Context, sid int64) (*model.ScalingSchedule, error) {
+	var object model.ScalingSchedule
+	if err := s.db.WithContext(ctx).Where("id = ?", sid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (s *scaling) List(ctx context.Context, opts ...Options) ([]model.ScalingSchedule, error) {
+	var objects []model.ScalingSchedule
+	tx := s.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// UpdateScheduleTime 不修改 resource_version，避免与用户的更新冲突
+func (s *scaling) UpdateScheduleTime(ctx context.Context, sid int64, t time.Time) error {
+	return s.db.WithContext(ctx).Model(&model.ScalingSchedule{}).Where("id = ?", sid).Update("last_schedule_time", t).Error
+}
+
+func (s *scaling) CreateHistory(ctx context.Context, object *model.ScalingHistory) (*model.ScalingHistory, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := s.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (s *scaling) ListHistories(ctx context.Context, sid int64, opts ...Options) ([]model.ScalingHistory, error) {
+	var objects []model.ScalingHistory
+	tx := s.db.WithContext(ctx).Where("schedule_id = ?", sid)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type SettingInterface interface {
+	Get(ctx context.Context, name string) (*model.Setting, error)
+	// Set 配置不存在时创建，存在时更新
+	Set(ctx context.Context, name string, value string) error
+}
+
+type setting struct {
+	db *gorm.DB
+}
+
+func newSetting(db *gorm.DB) SettingInterface {
+	return &setting{db}
+}
+
+func (s *setting) Get(ctx context.Context, name string) (*model.Setting, error) {
+	var object model.Setting
+	if err := s.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (s *setting) Set(ctx context.Context, name string, value string) error {
+	object, err := s.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if object == nil {
+		object = &model.Setting{Name: name, Value: value}
+		object.GmtCreate = now
+		object.GmtModified = now
+		return s.db.WithContext(ctx).Create(object).Error
+	}
+
+	return s.db.WithContext(ctx).Model(&model.Setting{}).Where("id = ?", object.Id).Updates(map[string]interface{}{
+		"gmt_modified":     now,
+		"resource_version": object.ResourceVersion + 1,
+		"value":            value,
+	}).Error
+}
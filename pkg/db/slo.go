@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type SLOInterface interface {
+	Create(ctx context.Context, object *model.SLO) (*model.SLO, error)
+	Update(ctx context.Context, sid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, sid int64) error
+	Get(ctx context.Context, sid int64) (*model.SLO, error)
+	List(ctx context.Context, opts ...Options) ([]model.SLO, error)
+
+	GetByName(ctx context.Context, name string) (*model.SLO, error)
+	// UpdateEvaluateTime 记录 SLO 的采样时间，由采样任务调用
+	UpdateEvaluateTime(ctx context.Context, sid int64, t time.Time) error
+
+	CreateSample(ctx context.Context, object *model.SLOSample) (*model.SLOSample, error)
+	// AvgSamples 获取指定时间之后采样的平均值和采样数
+	AvgSamples(ctx context.Context, sid int64, since time.Time) (float64, int64, error)
+	// DeleteSamplesBefore 清理统计窗口之外的采样
+	DeleteSamplesBefore(ctx context.Context, sid int64, t time.Time) error
+}
+
+type slo struct {
+	db *gorm.DB
+}
+
+func newSLO(db *gorm.DB) SLOInterface {
+	return &slo{db}
+}
+
+func (s *slo) Create(ctx context.Context, object *model.SLO) (*model.SLO, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := s.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (s *slo) Update(ctx context.Context, sid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	f := s.db.WithContext(ctx).Model(&model.SLO{}).Where("id = ? and resource_version = ?", sid, resourceVersion).Updates(updates)
+	if f.Error != nil {
+		return f.Error
+	}
+	if f.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Delete 删除 SLO，同时删除其采样记录
+func (s *slo) Delete(ctx context.Context, sid int64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("slo_id = ?", sid).Delete(&model.SLOSample{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", sid).Delete(&model.SLO{}).Error
+	})
+}
+
+func (s *slo) Get(ctx context.Context, sid int64) (*model.SLO, error) {
+	var object model.SLO
+	if err := s.db.WithContext(ctx).Where("id = ?", sid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (s *slo) List(ctx context.Context, opts ...Options) ([]model.SLO, error) {
+	var objects []model.SLO
+	tx := s.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (s *slo) GetByName(ctx context.Context, name string) (*model.SLO, error) {
+	var object model.SLO
+	if err := s.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+// UpdateEvaluateTime 不修改 resource_version，避免与用户的更新冲突
+func (s *slo) UpdateEvaluateTime(ctx context.Context, sid int64, t time.Time) error {
+	return s.db.WithContext(ctx).Model(&model.SLO{}).Where("id = ?", sid).Update("last_evaluate_time", t).Error
+}
+
+func (s *slo) CreateSample(ctx context.Context, object *model.SLOSample) (*model.SLOSample, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := s.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (s *slo) AvgSamples(ctx context.Context, sid int64, since time.Time) (float64, int64, error) {
+	var result struct {
+		Avg   float64
+		Count int64
+	}
+	if err := s.db.WithContext(ctx).Model(&model.SLOSample{}).
+		Select("coalesce(avg(value), 0) as avg, count(*) as count").
+		Where("slo_id = ? and gmt_create >= ?", sid, since).
+		Scan(&result).Error; err != nil {
+		return 0, 0, err
+	}
+
+	return result.Avg, result.Count, nil
+}
+
+func (s *slo) DeleteSamplesBefore(ctx context.Context, sid int64, t time.Time) error {
+	return s.db.WithContext(ctx).Where("slo_id = ? and gmt_create < ?", sid, t).Delete(&model.SLOSample{}).Error
+}
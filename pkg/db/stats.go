@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"expvar"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	queryStartKey = "pixiu:query_start"
+
+	// 记录的慢查询语句的最大数量，超过时不再记录新的语句
+	maxSlowQueries = 500
+)
+
+type (
+	// SlowQuery 按语句聚合的慢查询，耗时单位为毫秒
+	SlowQuery struct {
+		SQL      string    `json:"sql"`
+		Table    string    `json:"table"`
+		Count    int64     `json:"count"`
+		AvgMs    float64   `json:"avg_ms"`
+		MaxMs    float64   `json:"max_ms"`
+		LastSeen time.Time `json:"last_seen"`
+
+		total time.Duration
+		max   time.Duration
+	}
+
+	// QueryLatency 按表和操作聚合的执行耗时，耗时单位为毫秒
+	QueryLatency struct {
+		Count  int64   `json:"count"`
+		Errors int64   `json:"errors"`
+		AvgMs  float64 `json:"avg_ms"`
+		MaxMs  float64 `json:"max_ms"`
+
+		total time.Duration
+		max   time.Duration
+	}
+
+	// QueryStats 通过 gorm callback 记录每条语句的执行耗时
+	QueryStats struct {
+		slowThreshold time.Duration
+
+		lock      sync.Mutex
+		latencies map[string]*QueryLatency
+		slows     map[string]*SlowQuery
+	}
+)
+
+var defaultQueryStats *QueryStats
+
+// DefaultQueryStats 返回服务的数据库耗时统计，未注册时返回 nil
+func DefaultQueryStats() *QueryStats {
+	return defaultQueryStats
+}
+
+// RegisterQueryStats 注册记录执行耗时的 callback，并通过 expvar 发布各表的耗时
+func RegisterQueryStats(db *gorm.DB, slowThreshold time.Duration) error {
+	stats := &QueryStats{
+		slowThreshold: slowThreshold,
+		latencies:     make(map[string]*QueryLatency),
+		slows:         make(map[string]*SlowQuery),
+	}
+
+	cb := db.Callback()
+	errs := []error{
+		cb.Create().Before("gorm:create").Register("pixiu:before_create", stats.before),
+		cb.Create().After("gorm:create").Register("pixiu:after_create", stats.after("create")),
+		cb.Query().Before("gorm:query").Register("pixiu:before_query", stats.before),
+		cb.Query().After("gorm:query").Register("pixiu:after_query", stats.after("query")),
+		cb.Update().Before("gorm:update").Register("pixiu:before_update", stats.before),
+		cb.Update().After("gorm:update").Register("pixiu:after_update", stats.after("update")),
+		cb.Delete().Before("gorm:delete").Register("pixiu:before_delete", stats.before),
+		cb.Delete().After("gorm:delete").Register("pixiu:after_delete", stats.after("delete")),
+		cb.Row().Before("gorm:row").Register("pixiu:before_row", stats.before),
+		cb.Row().After("gorm:row").Register("pixiu:after_row", stats.after("row")),
+		cb.Raw().Before("gorm:raw").Register("pixiu:before_raw", stats.before),
+		cb.Raw().After("gorm:raw").Register("pixiu:after_raw", stats.after("raw")),
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	defaultQueryStats = stats
+	expvar.Publish("db_queries", expvar.Func(func() interface{} {
+		return stats.Latencies()
+	}))
+	return nil
+}
+
+func (s *QueryStats) before(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func (s *QueryStats) after(op string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		s.record(op, db.Statement.Table, db.Statement.SQL.String(), time.Since(start), db.Error)
+	}
+}
+
+func (s *QueryStats) record(op, table, sql string, cost time.Duration, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := table + "/" + op
+	latency, ok := s.latencies[key]
+	if !ok {
+		latency = &QueryLatency{}
+		s.latencies[key] = latency
+	}
+	latency.Count++
+	if err != nil && err != gorm.ErrRecordNotFound {
+		latency.Errors++
+	}
+	latency.total += cost
+	if cost > latency.max {
+		latency.max = cost
+	}
+
+	if cost < s.slowThreshold || len(sql) == 0 {
+		return
+	}
+	slow, ok := s.slows[sql]
+	if !ok {
+		if len(s.slows) >= maxSlowQueries {
+			return
+		}
+		slow = &SlowQuery{SQL: sql, Table: table}
+		s.slows[sql] = slow
+	}
+	slow.Count++
+	slow.total += cost
+	if cost > slow.max {
+		slow.max = cost
+	}
+	slow.LastSeen = time.Now()
+}
+
+// Latencies 返回各表和操作的执行耗时，key 为 table/operation
+func (s *QueryStats) Latencies() map[string]QueryLatency {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	latencies := make(map[string]QueryLatency, len(s.latencies))
+	for key, latency := range s.latencies {
+		l := *latency
+		l.AvgMs = toMs(l.total) / float64(l.Count)
+		l.MaxMs = toMs(l.max)
+		latencies[key] = l
+	}
+	return latencies
+}
+
+// SlowQueries 返回最慢的 limit 条语句，按最大耗时倒序
+func (s *QueryStats) SlowQueries(limit int) []SlowQuery {
+	s.lock.Lock()
+	slows := make([]SlowQuery, 0, len(s.slows))
+	for _, slow := range s.slows {
+		q := *slow
+		q.AvgMs = toMs(q.total) / float64(q.Count)
+		q.MaxMs = toMs(q.max)
+		slows = append(slows, q)
+	}
+	s.lock.Unlock()
+
+	sort.Slice(slows, func(i, j int) bool {
+		return slows[i].max > slows[j].max
+	})
+	if limit > 0 && len(slows) > limit {
+		slows = slows[:limit]
+	}
+	return slows
+}
+
+func toMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/errors"
+)
+
+type TemplateInterface interface {
+	Create(ctx context.Context, object *model.Template) (*model.Template, error)
+	Update(ctx context.Context, tid int64, resourceVersion int64, updates map[string]interface{}) error
+	Delete(ctx context.Context, tid int64) error
+	Get(ctx context.Context, tid int64) (*model.Template, error)
+	List(ctx context.Context, opts ...Options) ([]model.Template, error)
+
+	GetByName(ctx context.Context, name string) (*model.Template, error)
+}
+
+type template struct {
+	db *gorm.DB
+}
+
+func newTemplate(db *gorm.DB) TemplateInterface {
+	return &template{db}
+}
+
+func (t *template) Create(ctx context.Context, object *model.Template) (*model.Template, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := t.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (t *template) Update(ctx context.Context, tid int64, resourceVersion int64, updates map[string]interface{}) error {
+	// 系统维护字段
+	updates["gmt_modified"] = time.Now()
+	updates["resource_version"] = resourceVersion + 1
+
+	tx := t.db.WithContext(ctx).Model(&model.Template{}).Where("id = ? and resource_version = ?", tid, resourceVersion).Updates(updates)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return errors.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (t *template) Delete(ctx context.Context, tid int64) error {
+	return t.db.WithContext(ctx).Where("id = ?", tid).Delete(&model.Template{}).Error
+}
+
+func (t *template) Get(ctx context.Context, tid int64) (*model.Template, error) {
+	var object model.Template
+	if err := t.db.WithContext(ctx).Where("id = ?", tid).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
+
+func (t *template) List(ctx context.Context, opts ...Options) ([]model.Template, error) {
+	var objects []model.Template
+	tx := t.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (t *template) GetByName(ctx context.Context, name string) (*model.Template, error) {
+	var object model.Template
+	if err := t.db.WithContext(ctx).Where("name = ?", name).First(&object).Error; err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &object, nil
+}
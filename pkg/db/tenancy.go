@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// TenantContextKey 请求上下文中的租户 ID，设置后共享表的查询仅作用于该租户的数据
+const TenantContextKey = "tenant_id"
+
+func WithTenantContext(ctx context.Context, tenantId int64) context.Context {
+	return context.WithValue(ctx, TenantContextKey, tenantId)
+}
+
+// TenantFromContext 获取上下文中的租户 ID，未设置时(管理员或者后台任务)不做租户隔离
+func TenantFromContext(ctx context.Context) (int64, bool) {
+	tid, ok := ctx.Value(TenantContextKey).(int64)
+	return tid, ok && tid != 0
+}
+
+// tenantScope 为查询追加租户过滤条件，由 DAO 统一使用，避免调用方遗漏
+func tenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if tid, ok := TenantFromContext(ctx); ok {
+			return tx.Where("tenant_id = ?", tid)
+		}
+		return tx
+	}
+}
+
+// visibleScope 与 tenantScope 相同，但 tenant_id 为 0 的共享数据对所有租户可见，用于只读查询
+func visibleScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if tid, ok := TenantFromContext(ctx); ok {
+			return tx.Where("tenant_id IN ?", []int64{0, tid})
+		}
+		return tx
+	}
+}
+
+// planTenantScope 用于部署计划的子表(nodes，configs，tasks)，子表没有租户字段，通过所属的 plan 过滤
+func planTenantScope(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if tid, ok := TenantFromContext(ctx); ok {
+			return tx.Where("plan_id IN (SELECT id FROM plans WHERE tenant_id = ?)", tid)
+		}
+		return tx
+	}
+}
+
+// setTenant 创建对象时强制使用上下文中的租户，不允许租户用户写入其他租户的数据
+func setTenant(ctx context.Context, tenantId *int64) {
+	if tid, ok := TenantFromContext(ctx); ok {
+		*tenantId = tid
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+type WorkloadChangeInterface interface {
+	Create(ctx context.Context, object *model.WorkloadChange) (*model.WorkloadChange, error)
+	// ListByObject 获取指定工作负载的变更记录，按时间倒序
+	ListByObject(ctx context.Context, cluster, namespace, kind, name string, opts ...Options) ([]model.WorkloadChange, error)
+	CountByObject(ctx context.Context, cluster, namespace, kind, name string) (int64, error)
+	// BatchDelete 删除符合条件的变更记录，用于清理过期的记录
+	BatchDelete(ctx context.Context, opts ...Options) (int64, error)
+}
+
+type workloadChange struct {
+	db *gorm.DB
+}
+
+func newWorkloadChange(db *gorm.DB) WorkloadChangeInterface {
+	return &workloadChange{db}
+}
+
+func (w *workloadChange) Create(ctx context.Context, object *model.WorkloadChange) (*model.WorkloadChange, error) {
+	now := time.Now()
+	object.GmtCreate = now
+	object.GmtModified = now
+
+	if err := w.db.WithContext(ctx).Create(object).Error; err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func (w *workloadChange) ListByObject(ctx context.Context, cluster, namespace, kind, name string, opts ...Options) ([]model.WorkloadChange, error) {
+	var objects []model.WorkloadChange
+	tx := w.db.WithContext(ctx).Where("cluster = ? and namespace = ? and kind = ? and name = ?", cluster, namespace, kind, name).Order("id DESC")
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+	if err := tx.Find(&objects).Error; err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+func (w *workloadChange) CountByObject(ctx context.Context, cluster, namespace, kind, name string) (int64, error) {
+	var total int64
+	err := w.db.WithContext(ctx).Model(&model.WorkloadChange{}).
+		Where("cluster = ? and namespace = ? and kind = ? and name = ?", cluster, namespace, kind, name).
+		Count(&total).Error
+	return total, err
+}
+
+func (w *workloadChange) BatchDelete(ctx context.Context, opts ...Options) (int64, error) {
+	tx := w.db.WithContext(ctx)
+	for _, opt := range opts {
+		tx = opt(tx)
+	}
+
+	result := tx.Delete(&model.WorkloadChange{})
+	return result.RowsAffected, result.Error
+}
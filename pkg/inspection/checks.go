@@ -0,0 +1,269 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspection
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	restclient "k8s.io/client-go/rest"
+
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	// 证书在该时间内过期时告警，过期或者即将过期时失败
+	certWarningWindow = 30 * 24 * time.Hour
+	certFailedWindow  = 7 * 24 * time.Hour
+
+	// 统计最近一小时的 Warning 事件
+	eventWindow           = time.Hour
+	eventWarningThreshold = 20
+	eventFailedThreshold  = 100
+	topEventReasons       = 5
+
+	systemNamespace = "kube-system"
+)
+
+// 不可用时巡检失败的核心组件，其他 kube-system 下的组件不可用时仅告警
+var coreAddons = sets.NewString("coredns", "kube-dns", "kube-proxy")
+
+// checkNodePressure 存在 NotReady 节点时失败，存在内存，磁盘或者 PID 压力时告警
+func checkNodePressure(ctx context.Context, cs client.ClusterSet) types.InspectionItem {
+	nodes, err := cs.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return failedItem(err)
+	}
+
+	var notReady, pressure []string
+	for _, node := range nodes.Items {
+		for _, cond := range node.Status.Conditions {
+			switch cond.Type {
+			case v1.NodeReady:
+				if cond.Status != v1.ConditionTrue {
+					notReady = append(notReady, "node/"+node.Name)
+				}
+			case v1.NodeMemoryPressure, v1.NodeDiskPressure, v1.NodePIDPressure:
+				if cond.Status == v1.ConditionTrue {
+					pressure = append(pressure, fmt.Sprintf("node/%s(%s)", node.Name, cond.Type))
+				}
+			}
+		}
+	}
+
+	item := types.InspectionItem{
+		Status:  types.InspectionPass,
+		Message: fmt.Sprintf("%d nodes, %d not ready, %d under pressure", len(nodes.Items), len(notReady), len(pressure)),
+		Objects: append(notReady, pressure...),
+	}
+	switch {
+	case len(notReady) != 0:
+		item.Status = types.InspectionFailed
+	case len(pressure) != 0:
+		item.Status = types.InspectionWarning
+	}
+	return item
+}
+
+// checkCertificateExpiry 检查 apiserver 的服务证书以及集群中 kubernetes.io/tls 类型 secret 的证书有效期
+func checkCertificateExpiry(ctx context.Context, cs client.ClusterSet) types.InspectionItem {
+	now := time.Now()
+	var expiring, expired []string
+	check := func(object string, cert *x509.Certificate) {
+		left := cert.NotAfter.Sub(now)
+		switch {
+		case left < certFailedWindow:
+			expired = append(expired, fmt.Sprintf("%s(%s)", object, cert.NotAfter.Format("2006-01-02")))
+		case left < certWarningWindow:
+			expiring = append(expiring, fmt.Sprintf("%s(%s)", object, cert.NotAfter.Format("2006-01-02")))
+		}
+	}
+
+	cert, err := apiServerCertificate(ctx, cs.Config)
+	if err != nil {
+		return failedItem(fmt.Errorf("failed to get apiserver certificate: %v", err))
+	}
+	if cert != nil {
+		check("apiserver", cert)
+	}
+
+	secrets, err := cs.Client.CoreV1().Secrets("").List(ctx, metav1.ListOptions{FieldSelector: "type=" + string(v1.SecretTypeTLS)})
+	if err != nil {
+		return failedItem(err)
+	}
+	for _, secret := range secrets.Items {
+		block, _ := pem.Decode(secret.Data[v1.TLSCertKey])
+		if block == nil {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		check(fmt.Sprintf("secret/%s/%s", secret.Namespace, secret.Name), cert)
+	}
+
+	item := types.InspectionItem{
+		Status:  types.InspectionPass,
+		Message: fmt.Sprintf("%d tls secrets, %d expiring in %d days, %d expired or expiring in %d days", len(secrets.Items), len(expiring), int(certWarningWindow.Hours()/24), len(expired), int(certFailedWindow.Hours()/24)),
+		Objects: append(expired, expiring...),
+	}
+	switch {
+	case len(expired) != 0:
+		item.Status = types.InspectionFailed
+	case len(expiring) != 0:
+		item.Status = types.InspectionWarning
+	}
+	return item
+}
+
+// apiServerCertificate 获取 apiserver 的服务证书，非 https 访问时返回空
+func apiServerCertificate(ctx context.Context, config *restclient.Config) (*x509.Certificate, error) {
+	transport, err := restclient.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(config.Host, "/")+"/livez", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return nil, nil
+	}
+	return resp.TLS.PeerCertificates[0], nil
+}
+
+// checkAddonHealth 检查 kube-system 下 deployment 和 daemonset 的就绪情况
+func checkAddonHealth(ctx context.Context, cs client.ClusterSet) types.InspectionItem {
+	deployments, err := cs.Client.AppsV1().Deployments(systemNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return failedItem(err)
+	}
+	daemonSets, err := cs.Client.AppsV1().DaemonSets(systemNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return failedItem(err)
+	}
+
+	var unavailable []string
+	var coreUnavailable bool
+	for _, deployment := range deployments.Items {
+		desired := int32(1)
+		if deployment.Spec.Replicas != nil {
+			desired = *deployment.Spec.Replicas
+		}
+		if deployment.Status.ReadyReplicas < desired {
+			unavailable = append(unavailable, fmt.Sprintf("deployment/%s/%s(%d/%d)", systemNamespace, deployment.Name, deployment.Status.ReadyReplicas, desired))
+			coreUnavailable = coreUnavailable || coreAddons.Has(deployment.Name)
+		}
+	}
+	for _, ds := range daemonSets.Items {
+		if ds.Status.NumberReady < ds.Status.DesiredNumberScheduled {
+			unavailable = append(unavailable, fmt.Sprintf("daemonset/%s/%s(%d/%d)", systemNamespace, ds.Name, ds.Status.NumberReady, ds.Status.DesiredNumberScheduled))
+			coreUnavailable = coreUnavailable || coreAddons.Has(ds.Name)
+		}
+	}
+
+	item := types.InspectionItem{
+		Status:  types.InspectionPass,
+		Message: fmt.Sprintf("%d addons, %d unavailable", len(deployments.Items)+len(daemonSets.Items), len(unavailable)),
+		Objects: unavailable,
+	}
+	switch {
+	case coreUnavailable:
+		item.Status = types.InspectionFailed
+	case len(unavailable) != 0:
+		item.Status = types.InspectionWarning
+	}
+	return item
+}
+
+// checkWarningEvents 统计最近一小时的 Warning 事件，超过阈值时告警或者失败
+func checkWarningEvents(ctx context.Context, cs client.ClusterSet) types.InspectionItem {
+	events, err := cs.Client.CoreV1().Events("").List(ctx, metav1.ListOptions{FieldSelector: "type=" + v1.EventTypeWarning})
+	if err != nil {
+		return failedItem(err)
+	}
+
+	since := time.Now().Add(-eventWindow)
+	reasons := make(map[string]int)
+	var count int
+	for _, event := range events.Items {
+		t := event.LastTimestamp.Time
+		if t.IsZero() {
+			t = event.EventTime.Time
+		}
+		if t.IsZero() {
+			t = event.CreationTimestamp.Time
+		}
+		if t.Before(since) {
+			continue
+		}
+		n := int(event.Count)
+		if n == 0 {
+			n = 1
+		}
+		count += n
+		reasons[event.Reason] += n
+	}
+
+	keys := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		keys = append(keys, reason)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if reasons[keys[i]] != reasons[keys[j]] {
+			return reasons[keys[i]] > reasons[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > topEventReasons {
+		keys = keys[:topEventReasons]
+	}
+	top := make([]string, len(keys))
+	for i, reason := range keys {
+		top[i] = fmt.Sprintf("%s(%d)", reason, reasons[reason])
+	}
+
+	item := types.InspectionItem{
+		Status:  types.InspectionPass,
+		Message: fmt.Sprintf("%d warning events in the last %s", count, eventWindow),
+		Objects: top,
+	}
+	switch {
+	case count >= eventFailedThreshold:
+		item.Status = types.InspectionFailed
+	case count >= eventWarningThreshold:
+		item.Status = types.InspectionWarning
+	}
+	return item
+}
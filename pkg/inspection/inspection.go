@@ -0,0 +1,189 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inspection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/notifier"
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	CheckNodePressure      = "node_pressure"
+	CheckCertificateExpiry = "certificate_expiry"
+	CheckAddonHealth       = "addon_health"
+	CheckWarningEvents     = "warning_events"
+)
+
+type checkFunc func(ctx context.Context, cs client.ClusterSet) types.InspectionItem
+
+// Checks 所有检查项，按执行顺序排列
+var Checks = []string{CheckNodePressure, CheckCertificateExpiry, CheckAddonHealth, CheckWarningEvents}
+
+var checkFuncs = map[string]checkFunc{
+	CheckNodePressure:      checkNodePressure,
+	CheckCertificateExpiry: checkCertificateExpiry,
+	CheckAddonHealth:       checkAddonHealth,
+	CheckWarningEvents:     checkWarningEvents,
+}
+
+func IsValidCheck(check string) bool {
+	_, ok := checkFuncs[check]
+	return ok
+}
+
+// Run 执行检查项，checks 为空时执行全部检查项，返回检查结果和 0 到 100 的总得分
+func Run(ctx context.Context, cs client.ClusterSet, checks []string) ([]types.InspectionItem, int) {
+	if len(checks) == 0 {
+		checks = Checks
+	}
+
+	items := make([]types.InspectionItem, 0, len(checks))
+	var total int
+	for _, check := range checks {
+		fn, ok := checkFuncs[check]
+		if !ok {
+			continue
+		}
+		item := fn(ctx, cs)
+		item.Check = check
+		item.Score = scoreOf(item.Status)
+		total += item.Score
+		items = append(items, item)
+	}
+	if len(items) == 0 {
+		return items, 100
+	}
+	return items, total / len(items)
+}
+
+func scoreOf(status string) int {
+	switch status {
+	case types.InspectionPass:
+		return 100
+	case types.InspectionWarning:
+		return 60
+	default:
+		return 0
+	}
+}
+
+// failedItem 检查项无法执行时视为失败
+func failedItem(err error) types.InspectionItem {
+	return types.InspectionItem{Status: types.InspectionFailed, Message: err.Error()}
+}
+
+// Inspector 执行巡检计划，保存巡检报告并向接收人发送摘要
+type Inspector struct {
+	factory  db.ShareDaoFactory
+	notifier *notifier.Notifier
+}
+
+func NewInspector(f db.ShareDaoFactory, n *notifier.Notifier) *Inspector {
+	return &Inspector{
+		factory:  f,
+		notifier: n,
+	}
+}
+
+func (i *Inspector) Inspect(ctx context.Context, object *model.Inspection, cs client.ClusterSet, trigger string, operator string) (*model.InspectionReport, error) {
+	items, score := Run(ctx, cs, SplitChecks(object.Checks))
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	report, err := i.factory.Inspection().CreateReport(ctx, &model.InspectionReport{
+		InspectionId: object.Id,
+		Cluster:      object.Cluster,
+		Trigger:      trigger,
+		Score:        score,
+		Report:       string(data),
+		Operator:     operator,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 摘要发送失败不影响巡检结果
+	if receivers := SplitReceivers(object.Receivers); len(receivers) != 0 {
+		if err = i.notifier.Broadcast(ctx, receivers, notifier.Message{
+			Kind:    notifier.KindInspection,
+			Title:   fmt.Sprintf("集群 %s 巡检完成，得分 %d", object.Cluster, score),
+			Content: summary(object, items),
+			Ref:     fmt.Sprintf("inspection-report/%d", report.Id),
+		}); err != nil {
+			klog.Errorf("failed to notify inspection(%d) report(%d): %v", object.Id, report.Id, err)
+		}
+	}
+	return report, nil
+}
+
+// summary 摘要仅包含未通过的检查项
+func summary(object *model.Inspection, items []types.InspectionItem) string {
+	lines := []string{fmt.Sprintf("巡检计划 %s 已完成", object.Name)}
+	for _, item := range items {
+		if item.Status == types.InspectionPass {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s: %s", item.Status, item.Check, item.Message))
+	}
+	if len(lines) == 1 {
+		lines = append(lines, "全部检查项均已通过")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func SplitChecks(checks string) []string {
+	if len(checks) == 0 {
+		return nil
+	}
+	return strings.Split(checks, ",")
+}
+
+func SplitReceivers(receivers string) []int64 {
+	ids := make([]int64, 0)
+	for _, s := range strings.Split(receivers, ",") {
+		if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func JoinReceivers(ids []int64) string {
+	seen := sets.NewInt64()
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if seen.Has(id) {
+			continue
+		}
+		seen.Insert(id)
+		parts = append(parts, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"encoding/base64"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/cloudprovider"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultCloudCredentialRefreshInterval = "@every 5m"
+
+	// 凭证在该时间内过期时刷新，需大于任务的执行间隔
+	cloudCredentialRefreshWindow = 10 * time.Minute
+)
+
+// CloudCredentialRefresher 刷新从云账号导入的托管集群即将过期的凭证
+// 刷新后通过 invalidate 移除控制器中使用旧凭证的集群缓存
+type CloudCredentialRefresher struct {
+	factory    db.ShareDaoFactory
+	invalidate func(name string)
+}
+
+func NewCloudCredentialRefresher(f db.ShareDaoFactory, invalidate func(name string)) *CloudCredentialRefresher {
+	return &CloudCredentialRefresher{
+		factory:    f,
+		invalidate: invalidate,
+	}
+}
+
+func (cr *CloudCredentialRefresher) Name() string {
+	return "cloud-credential-refresher"
+}
+
+func (cr *CloudCredentialRefresher) CronSpec() string {
+	return DefaultCloudCredentialRefreshInterval
+}
+
+func (cr *CloudCredentialRefresher) LogLevel() logutil.LogLevel {
+	return logutil.InfoLevel
+}
+
+func (cr *CloudCredentialRefresher) Do(ctx *JobContext) error {
+	clusters, err := cr.factory.Cluster().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(cloudCredentialRefreshWindow)
+	accounts := make(map[int64]*model.CloudAccount)
+	var refreshed int
+	for _, object := range clusters {
+		if object.CloudAccountId == 0 || object.CredentialExpiration == nil || object.CredentialExpiration.After(deadline) {
+			continue
+		}
+
+		account, ok := accounts[object.CloudAccountId]
+		if !ok {
+			if account, err = cr.factory.CloudAccount().Get(ctx, object.CloudAccountId); err != nil {
+				klog.Errorf("[CloudCredentialRefresher] failed to get cloud account(%d): %v", object.CloudAccountId, err)
+				continue
+			}
+			accounts[object.CloudAccountId] = account
+		}
+		if account == nil {
+			klog.Warningf("[CloudCredentialRefresher] cloud account(%d) of cluster %s not found", object.CloudAccountId, object.Name)
+			continue
+		}
+
+		provider, err := cloudprovider.NewProvider(cloudprovider.Credential{
+			Provider:  account.Provider,
+			Region:    account.Region,
+			AccessKey: account.AccessKey,
+			SecretKey: account.SecretKey,
+			ProjectId: account.ProjectId,
+		})
+		if err != nil {
+			klog.Errorf("[CloudCredentialRefresher] failed to build provider for cloud account %s: %v", account.Name, err)
+			continue
+		}
+		kubeConfig, err := provider.GetKubeConfig(ctx, object.CloudClusterId)
+		if err != nil {
+			klog.Errorf("[CloudCredentialRefresher] failed to refresh cluster %s kubeconfig: %v", object.Name, err)
+			continue
+		}
+		if err = cr.factory.Cluster().InternalUpdate(ctx, object.Id, map[string]interface{}{
+			"kube_config":           base64.StdEncoding.EncodeToString(kubeConfig.Data),
+			"credential_expiration": kubeConfig.ExpirationTimestamp,
+		}); err != nil {
+			klog.Errorf("[CloudCredentialRefresher] failed to update cluster %s kubeconfig: %v", object.Name, err)
+			continue
+		}
+
+		// 移除使用旧凭证的缓存，下次访问时使用新凭证重建
+		cr.invalidate(object.Name)
+		indexer.Delete(object.Name)
+		refreshed++
+	}
+
+	ctx.WithLogFields(map[string]interface{}{"clusters_refreshed": refreshed})
+	return nil
+}
@@ -19,6 +19,7 @@ package jobmanager
 import (
 	"context"
 	"sync"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -33,6 +34,10 @@ import (
 
 const (
 	DefaultSyncInterval = "@every 5s"
+
+	// 状态未变化时，健康检查时间的最小更新间隔，避免每次同步都写库
+	probeRecordInterval = time.Minute
+	healthzTimeout      = 5 * time.Second
 )
 
 type ClusterSyncer struct {
@@ -117,10 +122,18 @@ func doSync(f db.ShareDaoFactory, cluster model.Cluster) error {
 	nodeData, kubernetesVersion, err = getNewestKubeStatus(cluster)
 	if err != nil {
 		status = model.ClusterStatusError
+		// 失联时保留最后一次获取到的节点和版本
+		nodeData, kubernetesVersion = cluster.Nodes, cluster.KubernetesVersion
+	} else if hasNotReadyNode(nodeData) {
+		status = model.ClusterStatusDegraded
 	}
 
 	updates := make(map[string]interface{})
 	parseStatus(updates, status, kubernetesVersion, nodeData, cluster)
+	now := time.Now()
+	if len(updates) != 0 || cluster.LastProbeTime == nil || now.Sub(*cluster.LastProbeTime) >= probeRecordInterval {
+		updates["last_probe_time"] = now
+	}
 	if len(updates) == 0 {
 		return nil
 	}
@@ -152,7 +165,7 @@ func getNewestKubeStatus(cluster model.Cluster) (string, string, error) {
 	)
 	cs, ok = indexer.Get(name)
 	if !ok {
-		clusterSet, err := client.NewClusterSet(cluster.KubeConfig)
+		clusterSet, err := client.NewClusterSet(cluster.KubeConfig, client.WithProxy(cluster.Proxy))
 		if err != nil {
 			return "", "", err
 		}
@@ -160,6 +173,13 @@ func getNewestKubeStatus(cluster model.Cluster) (string, string, error) {
 		indexer.Set(name, cs)
 	}
 
+	// informer 缓存在集群失联后仍然可用，需直接检查 API server 的健康状态
+	ctx, cancel := context.WithTimeout(context.TODO(), healthzTimeout)
+	defer cancel()
+	if _, err := cs.Client.Discovery().RESTClient().Get().AbsPath("/healthz").DoRaw(ctx); err != nil {
+		return "", "", err
+	}
+
 	nodes, err := cs.Informer.NodesLister().List(labels.Everything())
 	if err != nil {
 		return "", "", err
@@ -190,6 +210,14 @@ func getNewestKubeStatus(cluster model.Cluster) (string, string, error) {
 	return nodeData, kubernetesVersion, nil
 }
 
+func hasNotReadyNode(nodeData string) bool {
+	kubeNode := types.KubeNode{}
+	if err := kubeNode.Unmarshal(nodeData); err != nil {
+		return false
+	}
+	return len(kubeNode.NotReady) != 0
+}
+
 func cleanLister(clusters []model.Cluster) {
 	cs := make(map[string]bool)
 	for _, cluster := range clusters {
@@ -33,6 +33,7 @@ func NewJobContext(name string, cfg *logutil.LogOptions) *JobContext {
 		Context: db.WithDBContext(context.Background()),
 		Logger:  logutil.NewLogger(cfg),
 	}
+	jc.WithLogField("module", "jobmanager")
 	jc.WithLogField("job", name)
 	return jc
 }
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/dns"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultDNSCleanInterval = "@every 5m"
+)
+
+// DNSCleaner 清理已被删除的 service/ingress 遗留的解析记录
+type DNSCleaner struct {
+	provider dns.Provider
+	factory  db.ShareDaoFactory
+}
+
+func NewDNSCleaner(p dns.Provider, f db.ShareDaoFactory) *DNSCleaner {
+	return &DNSCleaner{
+		provider: p,
+		factory:  f,
+	}
+}
+
+func (dc *DNSCleaner) Name() string {
+	return "dns-cleaner"
+}
+
+func (dc *DNSCleaner) CronSpec() string {
+	return DefaultDNSCleanInterval
+}
+
+func (dc *DNSCleaner) LogLevel() logutil.LogLevel {
+	return logutil.InfoLevel
+}
+
+func (dc *DNSCleaner) Do(ctx *JobContext) error {
+	records, err := dc.factory.DNSRecord().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var cleaned int
+	for _, record := range records {
+		exists, err := dc.objectExists(ctx, record)
+		if err != nil {
+			klog.Warningf("[DNSCleaner] failed to check %s %s/%s: %v", record.Kind, record.Namespace, record.Name, err)
+			continue
+		}
+		if exists {
+			continue
+		}
+
+		if err = dc.provider.RemoveRecord(ctx, dns.Record{Domain: record.Domain, Type: record.Type, Value: record.Value}); err != nil {
+			klog.Errorf("[DNSCleaner] failed to remove dns record %s: %v", record.Domain, err)
+			continue
+		}
+		if err = dc.factory.DNSRecord().Delete(ctx, record.Id); err != nil {
+			klog.Errorf("[DNSCleaner] failed to delete dns record(%d): %v", record.Id, err)
+			continue
+		}
+		cleaned++
+	}
+
+	ctx.WithLogFields(map[string]interface{}{"records_cleaned": cleaned})
+	return nil
+}
+
+// objectExists 判断解析记录所属的对象是否仍然存在，集群已被删除时视为不存在
+func (dc *DNSCleaner) objectExists(ctx context.Context, record model.DNSRecord) (bool, error) {
+	cluster, err := dc.factory.Cluster().GetClusterByName(ctx, record.Cluster)
+	if err != nil {
+		return false, err
+	}
+	if cluster == nil {
+		return false, nil
+	}
+
+	cs, ok := indexer.Get(cluster.Name)
+	if !ok {
+		clusterSet, err := client.NewClusterSet(cluster.KubeConfig, client.WithProxy(cluster.Proxy))
+		if err != nil {
+			return false, err
+		}
+		cs = *clusterSet
+		indexer.Set(cluster.Name, cs)
+	}
+
+	switch record.Kind {
+	case model.DNSRecordKindService:
+		_, err = cs.Client.CoreV1().Services(record.Namespace).Get(ctx, record.Name, metav1.GetOptions{})
+	case model.DNSRecordKindIngress:
+		_, err = cs.Client.NetworkingV1().Ingresses(record.Namespace).Get(ctx, record.Name, metav1.GetOptions{})
+	default:
+		return true, nil
+	}
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultEventCleanSchedule = "0 1 * * *" // 每天 1 点执行
+	DefaultEventDaysReserved  = 7           // 保留 7 天的集群事件
+	DefaultEventSampleRate    = 100
+)
+
+// EventOptions 集群事件的持久化配置，开启后将各集群的 events 写入数据库，便于事后检索
+type EventOptions struct {
+	Enable       bool `yaml:"enable"`
+	DaysReserved int  `yaml:"days_reserved"`
+	// Normal 类型事件的采样百分比，Warning 类型的事件全部保留
+	NormalSampleRate int `yaml:"normal_sample_rate"`
+}
+
+func (o *EventOptions) Valid() error {
+	if !o.Enable {
+		return nil
+	}
+	if o.DaysReserved < 0 {
+		return fmt.Errorf("invalid days_reserved %d", o.DaysReserved)
+	}
+	if o.NormalSampleRate < 0 || o.NormalSampleRate > 100 {
+		return fmt.Errorf("invalid normal_sample_rate %d, must be in range 0-100", o.NormalSampleRate)
+	}
+	return nil
+}
+
+// EventsCleaner 清理超过保留天数的集群事件
+type EventsCleaner struct {
+	cfg EventOptions
+	dao db.ShareDaoFactory
+}
+
+func NewEventsCleaner(cfg EventOptions, dao db.ShareDaoFactory) *EventsCleaner {
+	return &EventsCleaner{
+		cfg: cfg,
+		dao: dao,
+	}
+}
+
+func (ec *EventsCleaner) Name() string {
+	return "events-cleaner"
+}
+
+func (ec *EventsCleaner) CronSpec() string {
+	return DefaultEventCleanSchedule
+}
+
+func (ec *EventsCleaner) LogLevel() logutil.LogLevel {
+	return logutil.InfoLevel
+}
+
+func (ec *EventsCleaner) Do(ctx *JobContext) (err error) {
+	resv := ec.cfg.DaysReserved
+	before := time.Now().AddDate(0, 0, -resv)
+	entries := map[string]interface{}{
+		"days_reserved": resv,
+		"deadline":      before,
+	}
+	entries["records_deleted"], err = ec.dao.ClusterEvent().BatchDelete(ctx, db.WithLastSeenBetween(time.Time{}, before))
+	ctx.WithLogFields(entries)
+
+	return
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/inspection"
+	"github.com/caoyingjunz/pixiu/pkg/notifier"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultInspectionInterval = "@every 1m"
+
+	// 巡检报告的保留时间
+	inspectionReportRetention = 30 * 24 * time.Hour
+)
+
+// InspectionScheduler 执行已到期的巡检计划，错过的多次调度只执行一次
+type InspectionScheduler struct {
+	factory   db.ShareDaoFactory
+	inspector *inspection.Inspector
+}
+
+func NewInspectionScheduler(f db.ShareDaoFactory, n *notifier.Notifier) *InspectionScheduler {
+	return &InspectionScheduler{
+		factory:   f,
+		inspector: inspection.NewInspector(f, n),
+	}
+}
+
+func (is *InspectionScheduler) Name() string {
+	return "inspection-scheduler"
+}
+
+func (is *InspectionScheduler) CronSpec() string {
+	return DefaultInspectionInterval
+}
+
+func (is *InspectionScheduler) LogLevel() logutil.LogLevel {
+	return logutil.DebugLevel
+}
+
+func (is *InspectionScheduler) Do(ctx *JobContext) error {
+	inspections, err := is.factory.Inspection().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var inspected int
+	for _, object := range inspections {
+		if !object.Enabled {
+			continue
+		}
+		sched, err := cron.ParseStandard(object.Schedule)
+		if err != nil {
+			klog.Warningf("[InspectionScheduler] invalid inspection(%d) schedule %q: %v", object.Id, object.Schedule, err)
+			continue
+		}
+		last := object.GmtCreate
+		if object.LastRunTime != nil {
+			last = *object.LastRunTime
+		}
+		if sched.Next(last).After(now) {
+			continue
+		}
+
+		// 先记录执行时间，避免执行失败时每轮重复执行
+		if err = is.factory.Inspection().UpdateRunTime(ctx, object.Id, now); err != nil {
+			klog.Errorf("[InspectionScheduler] failed to update inspection(%d) run time: %v", object.Id, err)
+			continue
+		}
+		if err = is.inspect(ctx, object); err != nil {
+			klog.Errorf("[InspectionScheduler] failed to run inspection(%d) in cluster %s: %v", object.Id, object.Cluster, err)
+			continue
+		}
+		if err = is.factory.Inspection().DeleteReportsBefore(ctx, object.Id, now.Add(-inspectionReportRetention)); err != nil {
+			klog.Warningf("[InspectionScheduler] failed to clean inspection(%d) reports: %v", object.Id, err)
+		}
+		inspected++
+	}
+
+	ctx.WithLogFields(map[string]interface{}{"inspections_executed": inspected})
+	return nil
+}
+
+func (is *InspectionScheduler) inspect(ctx context.Context, object model.Inspection) error {
+	cluster, err := is.factory.Cluster().GetClusterByName(ctx, object.Cluster)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %s not found", object.Cluster)
+	}
+
+	cs, ok := indexer.Get(cluster.Name)
+	if !ok {
+		clusterSet, err := client.NewClusterSet(cluster.KubeConfig, client.WithProxy(cluster.Proxy))
+		if err != nil {
+			return err
+		}
+		cs = *clusterSet
+		indexer.Set(cluster.Name, cs)
+	}
+
+	_, err = is.inspector.Inspect(ctx, &object, cs, model.InspectionTriggerSchedule, "")
+	return err
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/notifier"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultKubeConfigExpiryInterval = "@every 10m"
+
+	// 提前通知的时间
+	kubeConfigExpiryWindow = 24 * time.Hour
+)
+
+// KubeConfigExpiryNotifier 通知用户即将过期的 kubeconfig，每个 kubeconfig 仅通知一次
+type KubeConfigExpiryNotifier struct {
+	factory  db.ShareDaoFactory
+	notifier *notifier.Notifier
+}
+
+func NewKubeConfigExpiryNotifier(f db.ShareDaoFactory, n *notifier.Notifier) *KubeConfigExpiryNotifier {
+	return &KubeConfigExpiryNotifier{
+		factory:  f,
+		notifier: n,
+	}
+}
+
+func (kn *KubeConfigExpiryNotifier) Name() string {
+	return "kubeconfig-expiry-notifier"
+}
+
+func (kn *KubeConfigExpiryNotifier) CronSpec() string {
+	return DefaultKubeConfigExpiryInterval
+}
+
+func (kn *KubeConfigExpiryNotifier) LogLevel() logutil.LogLevel {
+	return logutil.DebugLevel
+}
+
+func (kn *KubeConfigExpiryNotifier) Do(ctx *JobContext) error {
+	now := time.Now()
+	objects, err := kn.factory.KubeConfig().List(ctx, db.WithExpirationBetween(now, now.Add(kubeConfigExpiryWindow)), db.WithNotRevoked())
+	if err != nil {
+		return err
+	}
+
+	var notified int
+	for _, object := range objects {
+		ref := fmt.Sprintf("kubeconfig/%d", object.Id)
+		exists, err := kn.factory.Notification().ExistsByRef(ctx, object.UserId, ref)
+		if err != nil {
+			klog.Errorf("[KubeConfigExpiryNotifier] failed to check kubeconfig(%d) notification: %v", object.Id, err)
+			continue
+		}
+		if exists {
+			continue
+		}
+
+		if err = kn.notifier.Notify(ctx, notifier.Message{
+			UserId:  object.UserId,
+			Kind:    notifier.KindKubeConfig,
+			Title:   fmt.Sprintf("集群 %s 的 kubeconfig 即将过期", object.Cluster),
+			Content: fmt.Sprintf("集群 %s 的 kubeconfig(%d) 将于 %s 过期，请及时重新签发", object.Cluster, object.Id, object.ExpirationTimestamp.Format(time.RFC3339)),
+			Ref:     ref,
+		}); err != nil {
+			klog.Errorf("[KubeConfigExpiryNotifier] failed to notify user(%d) of kubeconfig(%d): %v", object.UserId, object.Id, err)
+			continue
+		}
+		notified++
+	}
+
+	ctx.WithLogFields(map[string]interface{}{"kubeconfigs_notified": notified})
+	return nil
+}
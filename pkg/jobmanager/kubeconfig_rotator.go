@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"fmt"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	kubeconfigutil "github.com/caoyingjunz/pixiu/pkg/util/kubeconfig"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultKubeConfigRotateInterval = "@every 10m"
+	DefaultKubeConfigRotateWindow   = 60 // 过期前 60 分钟轮换
+	// 与 DefaultKubeConfigRotateInterval 保持一致
+	kubeConfigRotateInterval = 10 * time.Minute
+
+	// token 的最小有效期，与签发时的限制保持一致
+	minKubeConfigTTL int64 = 600
+)
+
+// KubeConfigRotationOptions kubeconfig 的自动轮换配置，开启后在过期前为其重新签发 token
+type KubeConfigRotationOptions struct {
+	Enable bool `yaml:"enable"`
+	// 过期前多少分钟轮换，需大于任务的执行间隔
+	Window int `yaml:"window"`
+}
+
+func (o *KubeConfigRotationOptions) Valid() error {
+	if !o.Enable {
+		return nil
+	}
+	if o.Window <= 10 {
+		return fmt.Errorf("invalid window %d, must be greater than 10", o.Window)
+	}
+	return nil
+}
+
+// WindowDuration 返回轮换窗口，未开启时返回 0
+func (o *KubeConfigRotationOptions) WindowDuration() time.Duration {
+	if !o.Enable {
+		return 0
+	}
+	return time.Duration(o.Window) * time.Minute
+}
+
+// KubeConfigRotator 为即将过期的 kubeconfig 重新签发 token 并更新记录，ServiceAccount 和绑定关系保持不变
+type KubeConfigRotator struct {
+	cfg     KubeConfigRotationOptions
+	factory db.ShareDaoFactory
+}
+
+func NewKubeConfigRotator(cfg KubeConfigRotationOptions, f db.ShareDaoFactory) *KubeConfigRotator {
+	return &KubeConfigRotator{
+		cfg:     cfg,
+		factory: f,
+	}
+}
+
+func (kr *KubeConfigRotator) Name() string {
+	return "kubeconfig-rotator"
+}
+
+func (kr *KubeConfigRotator) CronSpec() string {
+	return DefaultKubeConfigRotateInterval
+}
+
+func (kr *KubeConfigRotator) LogLevel() logutil.LogLevel {
+	return logutil.InfoLevel
+}
+
+func (kr *KubeConfigRotator) Do(ctx *JobContext) error {
+	now := time.Now()
+	objects, err := kr.factory.KubeConfig().List(ctx, db.WithExpirationBetween(now, now.Add(kr.cfg.WindowDuration())), db.WithNotRevoked())
+	if err != nil {
+		return err
+	}
+
+	var rotated int
+	for _, object := range objects {
+		// 证书凭证无法通过 TokenRequest 轮换
+		if object.CredentialType == model.CredentialTypeCertificate {
+			continue
+		}
+		ttl := rotationTTL(object)
+		if object.ExpirationTimestamp.Sub(now) > kr.rotateBefore(ttl) {
+			continue
+		}
+		if err = kr.rotate(ctx, object, ttl); err != nil {
+			klog.Errorf("[KubeConfigRotator] failed to rotate kubeconfig(%d) of cluster %s: %v", object.Id, object.Cluster, err)
+			continue
+		}
+		rotated++
+	}
+
+	ctx.WithLogFields(map[string]interface{}{"kubeconfigs_rotated": rotated})
+	return nil
+}
+
+// rotateBefore 返回过期前多久轮换，有效期短于轮换窗口的 token 在剩余一半有效期时轮换，避免每次执行都重新签发
+// 不小于任务的执行间隔，确保下一次执行前不会过期
+func (kr *KubeConfigRotator) rotateBefore(ttl int64) time.Duration {
+	before := kr.cfg.WindowDuration()
+	if half := time.Duration(ttl) * time.Second / 2; half < before {
+		before = half
+	}
+	if before < kubeConfigRotateInterval {
+		before = kubeConfigRotateInterval
+	}
+	return before
+}
+
+func (kr *KubeConfigRotator) rotate(ctx *JobContext, object model.KubeConfig, ttl int64) error {
+	cs, err := kr.getClusterSet(ctx, object.Cluster)
+	if err != nil {
+		return err
+	}
+
+	token, err := cs.Client.CoreV1().ServiceAccounts(object.Namespace).CreateToken(ctx, object.ServiceAccount, &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &ttl},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		// ServiceAccount 已被删除时，kubeconfig 到期后自然失效
+		if apierrors.IsNotFound(err) {
+			klog.Warningf("[KubeConfigRotator] serviceAccount %s/%s of kubeconfig(%d) not found, skip rotating", object.Namespace, object.ServiceAccount, object.Id)
+			return nil
+		}
+		return err
+	}
+
+	// 仅替换 token，保留 context 等其他配置，优先使用当前 context 关联的认证信息
+	authName := kubeconfigutil.CurrentUser([]byte(object.Config))
+	if len(authName) == 0 {
+		authName = object.ServiceAccount
+	}
+	data, err := kubeconfigutil.SetToken([]byte(object.Config), authName, token.Status.Token)
+	if err != nil {
+		return err
+	}
+
+	return kr.factory.KubeConfig().InternalUpdate(ctx, object.Id, map[string]interface{}{
+		"config":               string(data),
+		"ttl":                  ttl,
+		"expiration_timestamp": token.Status.ExpirationTimestamp.Time,
+		"last_rotated":         time.Now(),
+	})
+}
+
+func (kr *KubeConfigRotator) getClusterSet(ctx *JobContext, name string) (client.ClusterSet, error) {
+	if cs, ok := indexer.Get(name); ok {
+		return cs, nil
+	}
+	cluster, err := kr.factory.Cluster().GetClusterByName(ctx, name)
+	if err != nil {
+		return client.ClusterSet{}, err
+	}
+	if cluster == nil {
+		return client.ClusterSet{}, fmt.Errorf("cluster %s not found", name)
+	}
+	cs, err := client.NewClusterSet(cluster.KubeConfig, client.WithProxy(cluster.Proxy))
+	if err != nil {
+		return client.ClusterSet{}, err
+	}
+	indexer.Set(name, *cs)
+	return *cs, nil
+}
+
+// rotationTTL 使用签发时的有效期，早期的记录未保存 ttl 时使用上一次签发至过期的时长
+func rotationTTL(object model.KubeConfig) int64 {
+	if object.TTL != 0 {
+		return object.TTL
+	}
+	issued := object.GmtCreate
+	if object.LastRotated != nil {
+		issued = *object.LastRotated
+	}
+	ttl := int64(object.ExpirationTimestamp.Sub(issued).Seconds())
+	if ttl < minKubeConfigTTL {
+		ttl = minKubeConfigTTL
+	}
+	return ttl
+}
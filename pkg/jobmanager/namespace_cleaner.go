@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/notifier"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultNamespaceCleanInterval = "@every 5m"
+
+	// 过期前一小时发送提醒
+	namespaceWarnBefore = time.Hour
+)
+
+// NamespaceCleaner 删除已过期的临时命名空间，并在过期前通知命名空间策略的创建人
+type NamespaceCleaner struct {
+	factory  db.ShareDaoFactory
+	notifier *notifier.Notifier
+}
+
+func NewNamespaceCleaner(f db.ShareDaoFactory, n *notifier.Notifier) *NamespaceCleaner {
+	return &NamespaceCleaner{
+		factory:  f,
+		notifier: n,
+	}
+}
+
+func (nc *NamespaceCleaner) Name() string {
+	return "namespace-cleaner"
+}
+
+func (nc *NamespaceCleaner) CronSpec() string {
+	return DefaultNamespaceCleanInterval
+}
+
+func (nc *NamespaceCleaner) LogLevel() logutil.LogLevel {
+	return logutil.InfoLevel
+}
+
+func (nc *NamespaceCleaner) Do(ctx *JobContext) error {
+	policies, err := nc.factory.NamespacePolicy().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var cleaned, warned int
+	for _, policy := range policies {
+		expired := !policy.ExpireTime.After(now)
+		if !expired && (policy.Warned || policy.ExpireTime.Sub(now) > namespaceWarnBefore) {
+			continue
+		}
+
+		cs, err := nc.getClusterSet(ctx, policy.Cluster)
+		if err != nil {
+			klog.Warningf("[NamespaceCleaner] failed to get cluster(%s) clientSet: %v", policy.Cluster, err)
+			continue
+		}
+		if cs == nil {
+			// 集群已被删除，清理遗留的策略
+			if err = nc.factory.NamespacePolicy().Delete(ctx, policy.Id); err != nil {
+				klog.Errorf("[NamespaceCleaner] failed to delete namespace policy(%d): %v", policy.Id, err)
+			}
+			continue
+		}
+
+		if !expired {
+			if err = nc.warn(ctx, policy); err != nil {
+				klog.Errorf("[NamespaceCleaner] failed to warn namespace %s in cluster %s: %v", policy.Namespace, policy.Cluster, err)
+				continue
+			}
+			warned++
+			continue
+		}
+
+		err = cs.Client.CoreV1().Namespaces().Delete(ctx, policy.Namespace, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("[NamespaceCleaner] failed to delete namespace %s in cluster %s: %v", policy.Namespace, policy.Cluster, err)
+			continue
+		}
+		if err = nc.factory.NamespacePolicy().Delete(ctx, policy.Id); err != nil {
+			klog.Errorf("[NamespaceCleaner] failed to delete namespace policy(%d): %v", policy.Id, err)
+			continue
+		}
+		cleaned++
+	}
+
+	ctx.WithLogFields(map[string]interface{}{"namespaces_cleaned": cleaned, "namespaces_warned": warned})
+	return nil
+}
+
+// warn 通知策略的创建人命名空间即将过期，没有创建人的历史策略仅记录日志
+func (nc *NamespaceCleaner) warn(ctx context.Context, policy model.NamespacePolicy) error {
+	if policy.UserId == 0 {
+		klog.Warningf("[NamespaceCleaner] namespace %s in cluster %s has no owner, skip the expiry notification", policy.Namespace, policy.Cluster)
+	} else if err := nc.notifier.Notify(ctx, notifier.Message{
+		UserId:  policy.UserId,
+		Kind:    notifier.KindNamespace,
+		Title:   fmt.Sprintf("集群 %s 的命名空间 %s 即将过期", policy.Cluster, policy.Namespace),
+		Content: fmt.Sprintf("集群 %s 的命名空间 %s 将于 %s 被删除，如需继续使用请及时延期", policy.Cluster, policy.Namespace, policy.ExpireTime.Format(time.RFC3339)),
+		Ref:     fmt.Sprintf("namespace/%d", policy.Id),
+	}); err != nil {
+		return err
+	}
+	return nc.factory.NamespacePolicy().MarkWarned(ctx, policy.Id)
+}
+
+// getClusterSet 集群不存在时返回 nil
+func (nc *NamespaceCleaner) getClusterSet(ctx context.Context, name string) (*client.ClusterSet, error) {
+	cluster, err := nc.factory.Cluster().GetClusterByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, nil
+	}
+
+	cs, ok := indexer.Get(cluster.Name)
+	if !ok {
+		clusterSet, err := client.NewClusterSet(cluster.KubeConfig, client.WithProxy(cluster.Proxy))
+		if err != nil {
+			return nil, err
+		}
+		cs = *clusterSet
+		indexer.Set(cluster.Name, cs)
+	}
+	return &cs, nil
+}
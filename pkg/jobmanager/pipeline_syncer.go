@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/controller/pipeline"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	"github.com/caoyingjunz/pixiu/pkg/util/ci"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultPipelineSyncInterval = "@every 30s"
+)
+
+// PipelineSyncer 定时刷新未结束的流水线运行状态
+type PipelineSyncer struct {
+	factory db.ShareDaoFactory
+}
+
+func NewPipelineSyncer(f db.ShareDaoFactory) *PipelineSyncer {
+	return &PipelineSyncer{
+		factory: f,
+	}
+}
+
+func (ps *PipelineSyncer) Name() string {
+	return "pipeline-syncer"
+}
+
+func (ps *PipelineSyncer) CronSpec() string {
+	return DefaultPipelineSyncInterval
+}
+
+func (ps *PipelineSyncer) LogLevel() logutil.LogLevel {
+	return logutil.DebugLevel
+}
+
+func (ps *PipelineSyncer) Do(ctx *JobContext) error {
+	runs, err := ps.factory.Pipeline().ListRuns(ctx, db.WithStatusIn(ci.StatusPending, ci.StatusRunning, ci.StatusUnknown))
+	if err != nil {
+		return err
+	}
+
+	pipelines := make(map[int64]*model.Pipeline)
+	var synced int
+	for i := range runs {
+		run := &runs[i]
+		object, ok := pipelines[run.PipelineId]
+		if !ok {
+			if object, err = ps.factory.Pipeline().Get(ctx, run.PipelineId); err != nil {
+				klog.Errorf("[PipelineSyncer] failed to get pipeline(%d): %v", run.PipelineId, err)
+				continue
+			}
+			pipelines[run.PipelineId] = object
+		}
+		if object == nil {
+			continue
+		}
+
+		if err = pipeline.RefreshRun(ctx, ps.factory, object, run); err != nil {
+			klog.Warningf("[PipelineSyncer] failed to refresh pipeline run(%d): %v", run.Id, err)
+			continue
+		}
+		synced++
+	}
+
+	ctx.WithLogFields(map[string]interface{}{"runs_synced": synced})
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultRecycleCleanInterval = "@every 1h"
+	DefaultRecycleDaysReserved  = 7 // 回收站中的对象保留 7 天
+)
+
+// RecycleOptions 工作负载回收站配置，开启后通过 pixiu 删除的工作负载会先保存快照，过期前可以恢复
+type RecycleOptions struct {
+	Enable       bool `yaml:"enable"`
+	DaysReserved int  `yaml:"days_reserved"`
+}
+
+func (o *RecycleOptions) Valid() error {
+	if !o.Enable {
+		return nil
+	}
+	if o.DaysReserved < 0 {
+		return fmt.Errorf("invalid days_reserved %d", o.DaysReserved)
+	}
+	return nil
+}
+
+// RecycleCleaner 清理回收站中已过期的对象
+type RecycleCleaner struct {
+	dao db.ShareDaoFactory
+}
+
+func NewRecycleCleaner(dao db.ShareDaoFactory) *RecycleCleaner {
+	return &RecycleCleaner{
+		dao: dao,
+	}
+}
+
+func (rc *RecycleCleaner) Name() string {
+	return "recycle-cleaner"
+}
+
+func (rc *RecycleCleaner) CronSpec() string {
+	return DefaultRecycleCleanInterval
+}
+
+func (rc *RecycleCleaner) LogLevel() logutil.LogLevel {
+	return logutil.InfoLevel
+}
+
+func (rc *RecycleCleaner) Do(ctx *JobContext) (err error) {
+	entries := map[string]interface{}{}
+	entries["records_deleted"], err = rc.dao.Recycle().BatchDelete(ctx, db.WithExpireBefore(time.Now()))
+	ctx.WithLogFields(entries)
+
+	return
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultScalingInterval = "@every 1m"
+)
+
+// ScalingScheduler 执行已到期的定时伸缩计划，错过的多次调度只执行一次
+type ScalingScheduler struct {
+	factory db.ShareDaoFactory
+	// admit 扩容前校验租户的配额
+	admit func(ctx context.Context, cluster string, old, cur *appsv1.Deployment) error
+}
+
+func NewScalingScheduler(f db.ShareDaoFactory, admit func(ctx context.Context, cluster string, old, cur *appsv1.Deployment) error) *ScalingScheduler {
+	return &ScalingScheduler{
+		factory: f,
+		admit:   admit,
+	}
+}
+
+func (ss *ScalingScheduler) Name() string {
+	return "scaling-scheduler"
+}
+
+func (ss *ScalingScheduler) CronSpec() string {
+	return DefaultScalingInterval
+}
+
+func (ss *ScalingScheduler) LogLevel() logutil.LogLevel {
+	return logutil.DebugLevel
+}
+
+func (ss *ScalingScheduler) Do(ctx *JobContext) error {
+	schedules, err := ss.factory.Scaling().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var scaled int
+	for _, schedule := range schedules {
+		if !schedule.Enabled {
+			continue
+		}
+		sched, err := cron.ParseStandard(schedule.Schedule)
+		if err != nil {
+			klog.Warningf("[ScalingScheduler] invalid schedule(%d) %q: %v", schedule.Id, schedule.Schedule, err)
+			continue
+		}
+		last := schedule.GmtCreate
+		if schedule.LastScheduleTime != nil {
+			last = *schedule.LastScheduleTime
+		}
+		if sched.Next(last).After(now) {
+			continue
+		}
+
+		// 先记录执行时间，避免执行失败时每轮重复执行
+		if err = ss.factory.Scaling().UpdateScheduleTime(ctx, schedule.Id, now); err != nil {
+			klog.Errorf("[ScalingScheduler] failed to update schedule(%d) time: %v", schedule.Id, err)
+			continue
+		}
+		ss.scale(ctx, schedule)
+		scaled++
+	}
+
+	ctx.WithLogFields(map[string]interface{}{"schedules_executed": scaled})
+	return nil
+}
+
+func (ss *ScalingScheduler) scale(ctx context.Context, schedule model.ScalingSchedule) {
+	history := &model.ScalingHistory{
+		ScheduleId: schedule.Id,
+		ToReplicas: schedule.Replicas,
+		Status:     model.ScalingSucceeded,
+	}
+	if err := ss.doScale(ctx, schedule, history); err != nil {
+		klog.Errorf("[ScalingScheduler] failed to scale deployment %s/%s in cluster %s: %v", schedule.Namespace, schedule.Name, schedule.Cluster, err)
+		history.Status = model.ScalingFailed
+		history.Message = err.Error()
+	}
+
+	if _, err := ss.factory.Scaling().CreateHistory(ctx, history); err != nil {
+		klog.Errorf("[ScalingScheduler] failed to create schedule(%d) history: %v", schedule.Id, err)
+	}
+}
+
+func (ss *ScalingScheduler) doScale(ctx context.Context, schedule model.ScalingSchedule, history *model.ScalingHistory) error {
+	cluster, err := ss.factory.Cluster().GetClusterByName(ctx, schedule.Cluster)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %s not found", schedule.Cluster)
+	}
+
+	cs, ok := indexer.Get(cluster.Name)
+	if !ok {
+		clusterSet, err := client.NewClusterSet(cluster.KubeConfig, client.WithProxy(cluster.Proxy))
+		if err != nil {
+			return err
+		}
+		cs = *clusterSet
+		indexer.Set(cluster.Name, cs)
+	}
+
+	deployments := cs.Client.AppsV1().Deployments(schedule.Namespace)
+	scale, err := deployments.GetScale(ctx, schedule.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	history.FromReplicas = scale.Spec.Replicas
+	if scale.Spec.Replicas == schedule.Replicas {
+		return nil
+	}
+
+	old, err := deployments.Get(ctx, schedule.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	cur := old.DeepCopy()
+	cur.Spec.Replicas = &schedule.Replicas
+	if err = ss.admit(ctx, cluster.Name, old, cur); err != nil {
+		return err
+	}
+
+	scale.Spec.Replicas = schedule.Replicas
+	_, err = deployments.UpdateScale(ctx, schedule.Name, scale, metav1.UpdateOptions{})
+	return err
+}
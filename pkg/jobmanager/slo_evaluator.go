@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/client"
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultSLOEvaluateInterval = "@every 1m"
+
+	sloProbeTimeout = 10 * time.Second
+)
+
+// SLOEvaluator 定期对已启用的 SLO 采样，并清理统计窗口之外的采样
+type SLOEvaluator struct {
+	factory    db.ShareDaoFactory
+	httpClient *http.Client
+}
+
+func NewSLOEvaluator(f db.ShareDaoFactory) *SLOEvaluator {
+	return &SLOEvaluator{
+		factory:    f,
+		httpClient: &http.Client{Timeout: sloProbeTimeout},
+	}
+}
+
+func (se *SLOEvaluator) Name() string {
+	return "slo-evaluator"
+}
+
+func (se *SLOEvaluator) CronSpec() string {
+	return DefaultSLOEvaluateInterval
+}
+
+func (se *SLOEvaluator) LogLevel() logutil.LogLevel {
+	return logutil.DebugLevel
+}
+
+func (se *SLOEvaluator) Do(ctx *JobContext) error {
+	slos, err := se.factory.SLO().List(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var evaluated int
+	for _, slo := range slos {
+		if !slo.Enabled {
+			continue
+		}
+		sample := &model.SLOSample{SLOId: slo.Id}
+		if sample.Value, err = se.evaluate(ctx, slo); err != nil {
+			klog.Warningf("[SLOEvaluator] failed to evaluate slo %s: %v", slo.Name, err)
+			sample.Message = err.Error()
+		}
+		if _, err = se.factory.SLO().CreateSample(ctx, sample); err != nil {
+			klog.Errorf("[SLOEvaluator] failed to create slo(%d) sample: %v", slo.Id, err)
+			continue
+		}
+		if err = se.factory.SLO().UpdateEvaluateTime(ctx, slo.Id, now); err != nil {
+			klog.Errorf("[SLOEvaluator] failed to update slo(%d) evaluate time: %v", slo.Id, err)
+		}
+		if err = se.factory.SLO().DeleteSamplesBefore(ctx, slo.Id, now.AddDate(0, 0, -slo.WindowDays)); err != nil {
+			klog.Errorf("[SLOEvaluator] failed to clean slo(%d) samples: %v", slo.Id, err)
+		}
+		evaluated++
+	}
+
+	ctx.WithLogFields(map[string]interface{}{"slos_evaluated": evaluated})
+	return nil
+}
+
+// evaluate 获取本次采样的 SLI，采样失败时视为不可用
+func (se *SLOEvaluator) evaluate(ctx context.Context, slo model.SLO) (float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, sloProbeTimeout)
+	defer cancel()
+
+	if slo.Kind == model.SLOKindPrometheus {
+		return se.queryPrometheus(ctx, slo.PrometheusURL, slo.Query)
+	}
+
+	cs, err := se.getClusterSet(ctx, slo.Cluster)
+	if err != nil {
+		return 0, err
+	}
+	switch slo.Kind {
+	case model.SLOKindAPIAvailability:
+		if _, err = cs.Client.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	case model.SLOKindNodeReadiness:
+		nodes, err := cs.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return 0, err
+		}
+		if len(nodes.Items) == 0 {
+			return 0, fmt.Errorf("no nodes found")
+		}
+		var ready int
+		for _, node := range nodes.Items {
+			for _, cond := range node.Status.Conditions {
+				if cond.Type == v1.NodeReady && cond.Status == v1.ConditionTrue {
+					ready++
+				}
+			}
+		}
+		return float64(ready) / float64(len(nodes.Items)), nil
+	default:
+		return 0, fmt.Errorf("unsupported slo kind %s", slo.Kind)
+	}
+}
+
+func (se *SLOEvaluator) getClusterSet(ctx context.Context, name string) (client.ClusterSet, error) {
+	if cs, ok := indexer.Get(name); ok {
+		return cs, nil
+	}
+	cluster, err := se.factory.Cluster().GetClusterByName(ctx, name)
+	if err != nil {
+		return client.ClusterSet{}, err
+	}
+	if cluster == nil {
+		return client.ClusterSet{}, fmt.Errorf("cluster %s not found", name)
+	}
+	clusterSet, err := client.NewClusterSet(cluster.KubeConfig, client.WithProxy(cluster.Proxy))
+	if err != nil {
+		return client.ClusterSet{}, err
+	}
+	indexer.Set(name, *clusterSet)
+	return *clusterSet, nil
+}
+
+type prometheusResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// queryPrometheus 执行即时查询，结果需为 scalar 或者仅包含一个元素的 vector
+func (se *SLOEvaluator) queryPrometheus(ctx context.Context, endpoint string, query string) (float64, error) {
+	u := strings.TrimSuffix(endpoint, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := se.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var result prometheusResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode prometheus response: %v", err)
+	}
+	if result.Status != "success" {
+		return 0, fmt.Errorf("prometheus query failed: %s", result.Error)
+	}
+
+	// scalar 为 [时间戳, "值"]，vector 为 [{"metric": {}, "value": [时间戳, "值"]}]
+	var value []interface{}
+	switch result.Data.ResultType {
+	case "scalar":
+		err = json.Unmarshal(result.Data.Result, &value)
+	case "vector":
+		var vector []struct {
+			Value []interface{} `json:"value"`
+		}
+		if err = json.Unmarshal(result.Data.Result, &vector); err == nil {
+			if len(vector) != 1 {
+				return 0, fmt.Errorf("expected 1 series, got %d", len(vector))
+			}
+			value = vector[0].Value
+		}
+	default:
+		return 0, fmt.Errorf("unsupported result type %s", result.Data.ResultType)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(value) != 2 {
+		return 0, fmt.Errorf("unexpected prometheus value %v", value)
+	}
+	s, ok := value[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected prometheus value %v", value[1])
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 || v > 1 {
+		return 0, fmt.Errorf("sli %v out of range [0, 1]", v)
+	}
+	return v, nil
+}
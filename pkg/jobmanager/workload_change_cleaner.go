@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jobmanager
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	logutil "github.com/caoyingjunz/pixiu/pkg/util/log"
+)
+
+const (
+	DefaultWorkloadChangeCleanSchedule = "0 2 * * *" // 每天 2 点执行
+	DefaultWorkloadChangeDaysReserved  = 30          // 保留 30 天的工作负载变更记录
+)
+
+// WorkloadChangeOptions 工作负载 spec 变更记录的保留配置
+type WorkloadChangeOptions struct {
+	DaysReserved int `yaml:"days_reserved"`
+}
+
+func (o *WorkloadChangeOptions) Valid() error {
+	if o.DaysReserved < 0 {
+		return fmt.Errorf("invalid days_reserved %d", o.DaysReserved)
+	}
+	return nil
+}
+
+// WorkloadChangesCleaner 清理超过保留天数的工作负载变更记录
+type WorkloadChangesCleaner struct {
+	cfg WorkloadChangeOptions
+	dao db.ShareDaoFactory
+}
+
+func NewWorkloadChangesCleaner(cfg WorkloadChangeOptions, dao db.ShareDaoFactory) *WorkloadChangesCleaner {
+	return &WorkloadChangesCleaner{
+		cfg: cfg,
+		dao: dao,
+	}
+}
+
+func (wc *WorkloadChangesCleaner) Name() string {
+	return "workload-changes-cleaner"
+}
+
+func (wc *WorkloadChangesCleaner) CronSpec() string {
+	return DefaultWorkloadChangeCleanSchedule
+}
+
+func (wc *WorkloadChangesCleaner) LogLevel() logutil.LogLevel {
+	return logutil.InfoLevel
+}
+
+func (wc *WorkloadChangesCleaner) Do(ctx *JobContext) (err error) {
+	resv := wc.cfg.DaysReserved
+	before := time.Now().AddDate(0, 0, -resv)
+	entries := map[string]interface{}{
+		"days_reserved": resv,
+		"deadline":      before,
+	}
+	entries["records_deleted"], err = wc.dao.WorkloadChange().BatchDelete(ctx, db.WithCreatedBefore(before))
+	ctx.WithLogFields(entries)
+
+	return
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/db"
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
+
+const (
+	KindPlan       = "plan"
+	KindKubeConfig = "kubeconfig"
+	// KindApproval 待处理的审批
+	KindApproval = "approval"
+	// KindAnnouncement 平台公告
+	KindAnnouncement = "announcement"
+	// KindInspection 集群巡检的摘要
+	KindInspection = "inspection"
+	// KindNamespace 临时命名空间即将过期
+	KindNamespace = "namespace"
+
+	deliverTimeout = 10 * time.Second
+)
+
+// Options 通知渠道的配置，未配置 smtp 时不发送邮件
+type Options struct {
+	SMTP SMTPOptions `yaml:"smtp"`
+}
+
+type SMTPOptions struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+func (o *Options) Valid() error {
+	if len(o.SMTP.Host) == 0 {
+		return nil
+	}
+	if o.SMTP.Port <= 0 {
+		return fmt.Errorf("invalid smtp port %d", o.SMTP.Port)
+	}
+	if len(o.SMTP.From) == 0 {
+		return fmt.Errorf("smtp enabled, no from address found")
+	}
+	return nil
+}
+
+// Message 发送给指定用户的通知
+type Message struct {
+	UserId  int64
+	Kind    string
+	Title   string
+	Content string
+	// 关联的对象，用于避免重复通知
+	Ref string
+}
+
+// Notifier 记录站内通知，并根据用户的设置发送到邮件和 webhook
+type Notifier struct {
+	factory db.ShareDaoFactory
+	opts    Options
+	client  *http.Client
+}
+
+func New(f db.ShareDaoFactory, o Options) *Notifier {
+	return &Notifier{
+		factory: f,
+		opts:    o,
+		client:  &http.Client{Timeout: deliverTimeout},
+	}
+}
+
+// Notify 写入站内通知，邮件和 webhook 异步发送，发送失败时仅记录日志
+func (n *Notifier) Notify(ctx context.Context, msg Message) error {
+	object, err := n.factory.Notification().Create(ctx, &model.Notification{
+		UserId:  msg.UserId,
+		Kind:    msg.Kind,
+		Title:   msg.Title,
+		Content: msg.Content,
+		Ref:     msg.Ref,
+	})
+	if err != nil {
+		return err
+	}
+
+	pref, err := n.factory.Notification().GetPreference(ctx, msg.UserId)
+	if err != nil {
+		return err
+	}
+	if pref == nil || sets.NewString(strings.Split(pref.MutedKinds, ",")...).Has(msg.Kind) {
+		return nil
+	}
+	go n.deliver(object, pref)
+	return nil
+}
+
+// Broadcast 将同一条通知发送给多个用户，批量写入站内通知并批量获取用户的通知渠道设置
+func (n *Notifier) Broadcast(ctx context.Context, userIds []int64, msg Message) error {
+	objects := make([]model.Notification, len(userIds))
+	for i, uid := range userIds {
+		objects[i] = model.Notification{
+			UserId:  uid,
+			Kind:    msg.Kind,
+			Title:   msg.Title,
+			Content: msg.Content,
+			Ref:     msg.Ref,
+		}
+	}
+	if err := n.factory.Notification().BatchCreate(ctx, objects); err != nil {
+		return err
+	}
+
+	prefs, err := n.factory.Notification().ListPreferences(ctx, userIds...)
+	if err != nil {
+		return err
+	}
+	prefMap := make(map[int64]model.NotificationPreference, len(prefs))
+	for _, pref := range prefs {
+		prefMap[pref.UserId] = pref
+	}
+	for i := range objects {
+		pref, ok := prefMap[objects[i].UserId]
+		if !ok || sets.NewString(strings.Split(pref.MutedKinds, ",")...).Has(msg.Kind) {
+			continue
+		}
+		go n.deliver(&objects[i], &pref)
+	}
+	return nil
+}
+
+func (n *Notifier) deliver(object *model.Notification, pref *model.NotificationPreference) {
+	ctx, cancel := context.WithTimeout(context.Background(), deliverTimeout)
+	defer cancel()
+
+	if pref.WebhookEnabled && len(pref.WebhookURL) != 0 {
+		if err := n.sendWebhook(ctx, pref.WebhookURL, object); err != nil {
+			klog.Warningf("failed to send notification(%d) to webhook: %v", object.Id, err)
+		}
+	}
+	if pref.EmailEnabled && len(n.opts.SMTP.Host) != 0 {
+		user, err := n.factory.User().Get(ctx, object.UserId)
+		if err != nil || user == nil || len(user.Email) == 0 {
+			klog.Warningf("failed to get user(%d) email for notification(%d): %v", object.UserId, object.Id, err)
+			return
+		}
+		if err = n.sendEmail(user.Email, object); err != nil {
+			klog.Warningf("failed to send notification(%d) by email: %v", object.Id, err)
+		}
+	}
+}
+
+func (n *Notifier) sendWebhook(ctx context.Context, url string, object *model.Notification) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"id":      object.Id,
+		"kind":    object.Kind,
+		"title":   object.Title,
+		"content": object.Content,
+		"time":    object.GmtCreate,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (n *Notifier) sendEmail(to string, object *model.Notification) error {
+	o := n.opts.SMTP
+	var auth smtp.Auth
+	if len(o.Username) != 0 {
+		auth = smtp.PlainAuth("", o.Username, o.Password, o.Host)
+	}
+
+	msg := "From: " + o.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("UTF-8", object.Title) + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + object.Content + "\r\n"
+	return smtp.SendMail(o.Host+":"+strconv.Itoa(o.Port), auth, o.From, []string{to}, []byte(msg))
+}
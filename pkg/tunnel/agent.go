@@ -0,0 +1,215 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	"k8s.io/klog/v2"
+)
+
+const (
+	dialTimeout   = 10 * time.Second
+	retryInterval = 5 * time.Second
+)
+
+// Agent 运行在无法直接访问的集群中，主动连接 pixiu 建立隧道，并将隧道中的 stream 转发到集群的 API server
+type Agent struct {
+	// pixiu 的访问地址，例如 https://pixiu.example.com
+	PixiuURL string
+	// 引导 token，注册后继续用于建立隧道
+	Token string
+	// 转发的目标地址，通常为集群内 API server 的地址
+	Target string
+	// 跳过 pixiu 证书校验
+	InsecureSkipVerify bool
+
+	// 隧道建立后调用，失败时重试，返回 nil 后不再调用，用于向 pixiu 注册集群
+	OnConnected func(ctx context.Context) error
+
+	// 1 表示 OnConnected 已执行成功
+	hooked int32
+}
+
+// Run 保持隧道连接，断开后重连，直到 ctx 结束
+func (a *Agent) Run(ctx context.Context) {
+	for {
+		if err := a.serve(ctx); err != nil {
+			klog.Errorf("tunnel to %s disconnected: %v", a.PixiuURL, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func (a *Agent) serve(ctx context.Context) error {
+	netConn, err := a.dial(ctx)
+	if err != nil {
+		return err
+	}
+	conn, err := spdy.NewServerConnectionWithPings(netConn, a.handleStream, pingPeriod)
+	if err != nil {
+		_ = netConn.Close()
+		return err
+	}
+	defer conn.Close()
+	klog.Infof("tunnel to %s established", a.PixiuURL)
+
+	if a.OnConnected != nil && atomic.LoadInt32(&a.hooked) == 0 {
+		go a.runHook(ctx, conn)
+	}
+
+	select {
+	case <-conn.CloseChan():
+		return fmt.Errorf("connection closed")
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// runHook 执行 OnConnected 直到成功，或者隧道断开
+func (a *Agent) runHook(ctx context.Context, conn httpstream.Connection) {
+	for {
+		err := a.OnConnected(ctx)
+		if err == nil {
+			atomic.StoreInt32(&a.hooked, 1)
+			return
+		}
+		klog.Errorf("failed to run connected hook: %v", err)
+
+		select {
+		case <-conn.CloseChan():
+			return
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// dial 连接 pixiu 并完成协议升级，返回升级后的连接
+func (a *Agent) dial(ctx context.Context) (net.Conn, error) {
+	u, err := url.Parse(a.PixiuURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = Path
+
+	host := u.Host
+	if len(u.Port()) == 0 {
+		if u.Scheme == "https" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var conn net.Conn
+	if u.Scheme == "https" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: a.InsecureSkipVerify,
+		})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", UpgradeProtocol)
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	if err = req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = conn.Close()
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	return &bufferedConn{Conn: conn, r: br}, nil
+}
+
+func (a *Agent) handleStream(stream httpstream.Stream, replySent <-chan struct{}) error {
+	go func() {
+		<-replySent
+		a.forward(stream)
+	}()
+	return nil
+}
+
+// forward 将 stream 转发到目标地址，任意一端结束后关闭两端
+func (a *Agent) forward(stream httpstream.Stream) {
+	defer stream.Reset()
+
+	target, err := net.DialTimeout("tcp", a.Target, dialTimeout)
+	if err != nil {
+		klog.Errorf("failed to dial %s: %v", a.Target, err)
+		return
+	}
+	defer target.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(target, stream)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(stream, target)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// bufferedConn 读取升级响应时可能已缓存了后续的数据
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
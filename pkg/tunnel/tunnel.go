@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tunnel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// Path agent 建立隧道的地址
+	Path = "/pixiu/clusters/tunnel"
+	// UpgradeProtocol 建立隧道时 Upgrade 头的协议名称
+	UpgradeProtocol = "pixiu-tunnel"
+
+	// 定时发送 ping，避免 NAT 或者负载均衡回收空闲连接
+	pingPeriod = 30 * time.Second
+)
+
+// Agents 已连接的 agent 隧道，key 为集群名称
+var Agents = &store{items: make(map[string]*Tunnel)}
+
+// Tunnel agent 建立的反向隧道，每个 stream 对应一个到集群 API server 的 tcp 连接
+type Tunnel struct {
+	Cluster     string
+	RemoteAddr  string
+	ConnectedAt time.Time
+
+	conn httpstream.Connection
+}
+
+// DialContext 通过隧道建立到集群 API server 的连接，agent 仅转发到所在集群的 API server，忽略 addr
+func (t *Tunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	stream, err := t.conn.CreateStream(http.Header{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tunnel stream: %v", err)
+	}
+	return &streamConn{Stream: stream, tunnel: t}, nil
+}
+
+// Serve 完成协议升级后接管连接，直到 agent 断开，同一集群重复连接时替换旧的隧道
+// 仅在接管连接前返回错误
+func Serve(cluster string, w http.ResponseWriter, r *http.Request) error {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return fmt.Errorf("unable to hijack response writer")
+	}
+	netConn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return fmt.Errorf("failed to hijack connection: %v", err)
+	}
+	// 接管后由 net/http 写入的响应头不再生效，直接写入升级响应
+	if _, err = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: " + UpgradeProtocol + "\r\n\r\n"); err == nil {
+		err = rw.Flush()
+	}
+	var conn httpstream.Connection
+	if err == nil {
+		conn, err = spdy.NewClientConnectionWithPings(netConn, pingPeriod)
+	}
+	if err != nil {
+		// 连接已被接管，无法再返回错误响应
+		klog.Errorf("failed to establish tunnel of cluster %s: %v", cluster, err)
+		_ = netConn.Close()
+		return nil
+	}
+
+	t := &Tunnel{
+		Cluster:     cluster,
+		RemoteAddr:  r.RemoteAddr,
+		ConnectedAt: time.Now(),
+		conn:        conn,
+	}
+	Agents.set(t)
+	klog.Infof("agent of cluster %s connected from %s", cluster, t.RemoteAddr)
+
+	<-conn.CloseChan()
+	Agents.delete(t)
+	klog.Infof("agent of cluster %s disconnected", cluster)
+	return nil
+}
+
+type store struct {
+	sync.RWMutex
+	items map[string]*Tunnel
+}
+
+func (s *store) Get(cluster string) (*Tunnel, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	t, ok := s.items[cluster]
+	return t, ok
+}
+
+func (s *store) set(t *Tunnel) {
+	s.Lock()
+	defer s.Unlock()
+
+	if old, ok := s.items[t.Cluster]; ok {
+		_ = old.conn.Close()
+	}
+	s.items[t.Cluster] = t
+}
+
+// delete 仅删除当前的隧道，避免删除重连后新建的隧道
+func (s *store) delete(t *Tunnel) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.items[t.Cluster] == t {
+		delete(s.items, t.Cluster)
+	}
+}
+
+// streamConn 将 stream 封装为 net.Conn，用于 http.Transport
+type streamConn struct {
+	httpstream.Stream
+
+	tunnel    *Tunnel
+	closeOnce sync.Once
+}
+
+func (c *streamConn) Close() error {
+	c.closeOnce.Do(func() {
+		_ = c.Stream.Reset()
+		c.tunnel.conn.RemoveStreams(c.Stream)
+	})
+	return nil
+}
+
+func (c *streamConn) LocalAddr() net.Addr  { return streamAddr("pixiu") }
+func (c *streamConn) RemoteAddr() net.Addr { return streamAddr(c.tunnel.Cluster) }
+
+// stream 不支持超时，由请求的 context 控制
+func (c *streamConn) SetDeadline(time.Time) error      { return nil }
+func (c *streamConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *streamConn) SetWriteDeadline(time.Time) error { return nil }
+
+type streamAddr string
+
+func (a streamAddr) Network() string { return "tunnel" }
+func (a streamAddr) String() string  { return string(a) }
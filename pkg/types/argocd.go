@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "time"
+
+// ArgoApplication Argo CD 的 Application 对象，仅保留 pixiu 展示所需字段
+type ArgoApplication struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Project   string `json:"project"`
+
+	RepoURL        string `json:"repo_url"`
+	Path           string `json:"path,omitempty"`
+	Chart          string `json:"chart,omitempty"`
+	TargetRevision string `json:"target_revision"`
+
+	DestinationServer    string `json:"destination_server"`
+	DestinationNamespace string `json:"destination_namespace"`
+	AutoSync             bool   `json:"auto_sync"`
+
+	SyncStatus   string `json:"sync_status"`   // Synced, OutOfSync 或者 Unknown
+	HealthStatus string `json:"health_status"` // Healthy, Progressing, Degraded 等
+	Revision     string `json:"revision,omitempty"`
+	// 最近一次同步操作的状态
+	OperationPhase string `json:"operation_phase,omitempty"`
+
+	CreationTimestamp time.Time `json:"creation_timestamp"`
+}
+
+type CreateArgoApplicationRequest struct {
+	Name    string `json:"name" binding:"required"`     // required
+	Project string `json:"project" binding:"omitempty"` // optional, 默认 default
+	RepoURL string `json:"repo_url" binding:"required"` // required
+	Path    string `json:"path" binding:"omitempty"`    // optional, git 仓库的路径
+	Chart   string `json:"chart" binding:"omitempty"`   // optional, helm 仓库的 chart
+	// optional, 默认 HEAD
+	TargetRevision string `json:"target_revision" binding:"omitempty"`
+
+	// optional, 默认为 argo 所在集群
+	DestinationServer    string `json:"destination_server" binding:"omitempty"`
+	DestinationNamespace string `json:"destination_namespace" binding:"required"` // required
+	AutoSync             bool   `json:"auto_sync" binding:"omitempty"`            // optional
+}
+
+type SyncArgoApplicationRequest struct {
+	Revision string `json:"revision" binding:"omitempty"` // optional, 默认使用 target revision
+	Prune    bool   `json:"prune" binding:"omitempty"`    // optional
+}
@@ -22,6 +22,10 @@ type Release struct {
 	Version string                 `json:"version" binding:"required"`
 	Values  map[string]interface{} `json:"values"`
 	Preview bool                   `json:"preview"`
+
+	// 可选的变更单号和变更原因，记录在审计中，变更原因同时作为 release 的描述
+	ChangeTicket string `json:"change_ticket"`
+	ChangeReason string `json:"change_reason"`
 }
 
 type RepoId struct {
@@ -45,11 +49,14 @@ type ReleaseHistory struct {
 	Version int `form:"version"`
 }
 
+// CreateRepository cluster 为空时创建全局仓库，tenant_id 为 0 时所有租户可见，租户用户创建时仅所在租户可见
 type CreateRepository struct {
 	Name     string `json:"name" binding:"required"`
 	URL      string `json:"url" binding:"required"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Cluster  string `json:"cluster"`
+	TenantId int64  `json:"tenant_id"`
 }
 
 type UpdateRepository struct {
@@ -57,5 +64,37 @@ type UpdateRepository struct {
 	URL             string `json:"url" binding:"required"`
 	Username        string `json:"username"`
 	Password        string `json:"password"`
+	Cluster         string `json:"cluster"`
+	TenantId        int64  `json:"tenant_id"`
 	ResourceVersion *int64 `json:"resource_version" binding:"required"`
 }
+
+// ListRepositoryOptions 指定 cluster 时返回全局仓库以及该集群的仓库
+type ListRepositoryOptions struct {
+	Cluster string `form:"cluster"`
+}
+
+// HelmSecret 不返回敏感变量的值
+type HelmSecret struct {
+	PixiuMeta `json:",inline"`
+	TimeMeta  `json:",inline"`
+
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type CreateHelmSecretRequest struct {
+	Name        string `json:"name" binding:"required,max=128"`
+	Value       string `json:"value" binding:"required"`
+	Description string `json:"description"`
+}
+
+type UpdateHelmSecretRequest struct {
+	Value           *string `json:"value"`
+	Description     *string `json:"description"`
+	ResourceVersion *int64  `json:"resource_version" binding:"required"`
+}
+
+type HelmSecretMeta struct {
+	Id int64 `uri:"secretId" binding:"required"`
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "time"
+
+// KarmadaMember Karmada 的成员集群，通过 pixiu 注册时 cluster 为对应的 pixiu 集群名称
+type KarmadaMember struct {
+	Name              string `json:"name"`
+	SyncMode          string `json:"sync_mode"` // Push 或者 Pull
+	APIEndpoint       string `json:"api_endpoint"`
+	KubernetesVersion string `json:"kubernetes_version,omitempty"`
+	Ready             bool   `json:"ready"`
+	Cluster           string `json:"cluster,omitempty"`
+
+	CreationTimestamp time.Time `json:"creation_timestamp"`
+}
+
+// KarmadaResourceSelector 分发策略选择的资源，或者 ResourceBinding 关联的资源
+type KarmadaResourceSelector struct {
+	APIVersion string `json:"api_version"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
+
+// KarmadaPolicy PropagationPolicy 或者 ClusterPropagationPolicy，后者的 namespace 为空
+type KarmadaPolicy struct {
+	Name              string                    `json:"name"`
+	Namespace         string                    `json:"namespace,omitempty"`
+	ResourceSelectors []KarmadaResourceSelector `json:"resource_selectors"`
+	// placement 中指定的目标集群，为空时由 label 或者 field 选择
+	Clusters   []string `json:"clusters,omitempty"`
+	Priority   int64    `json:"priority"`
+	Preemption string   `json:"preemption,omitempty"`
+
+	CreationTimestamp time.Time `json:"creation_timestamp"`
+}
+
+// KarmadaBinding ResourceBinding 记录资源在成员集群中的分发结果
+type KarmadaBinding struct {
+	Name      string                  `json:"name"`
+	Namespace string                  `json:"namespace"`
+	Resource  KarmadaResourceSelector `json:"resource"`
+
+	Clusters []KarmadaTargetCluster `json:"clusters"`
+	Statuses []KarmadaBindingStatus `json:"statuses,omitempty"`
+
+	CreationTimestamp time.Time `json:"creation_timestamp"`
+}
+
+type KarmadaTargetCluster struct {
+	Name     string `json:"name"`
+	Replicas int64  `json:"replicas,omitempty"`
+}
+
+type KarmadaBindingStatus struct {
+	Cluster string `json:"cluster"`
+	Applied bool   `json:"applied"`
+	Health  string `json:"health,omitempty"`
+}
+
+// RegisterKarmadaMemberRequest 将 pixiu 中的集群以 Push 模式注册为 Karmada 的成员集群
+type RegisterKarmadaMemberRequest struct {
+	Cluster string `json:"cluster" binding:"required"` // required, pixiu 中的集群名称
+	// optional, 成员集群的名称，默认与 pixiu 集群同名
+	Name string `json:"name" binding:"omitempty"`
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "time"
+
+const (
+	ConsoleTypeVNC    = "vnc"
+	ConsoleTypeSerial = "serial"
+)
+
+// VirtualMachine KubeVirt 的 VirtualMachine 对象，合并了运行中 VirtualMachineInstance 的信息
+type VirtualMachine struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	RunStrategy string `json:"run_strategy,omitempty"`
+	// Running, Stopped, Starting, Migrating 等
+	PrintableStatus string `json:"printable_status"`
+	Ready           bool   `json:"ready"`
+
+	CPU    int64  `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+
+	// 运行中的虚拟机所在节点以及 IP
+	NodeName string   `json:"node_name,omitempty"`
+	IPs      []string `json:"ips,omitempty"`
+
+	CreationTimestamp time.Time `json:"creation_timestamp"`
+}
+
+type VirtualMachineConsoleOptions struct {
+	Type string `form:"type" binding:"omitempty,oneof=vnc serial"` // optional, 默认 serial
+}
@@ -389,6 +389,178 @@ func (cs *ComponentSpec) Unmarshal(s string) error {
 	return nil
 }
 
+func (es ExecutionSpec) Marshal() (string, error) {
+	data, err := json.Marshal(es)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Unmarshal 兼容未配置执行控制的历史数据
+func (es *ExecutionSpec) Unmarshal(s string) error {
+	if len(s) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(s), es); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ph PlanHooks) Marshal() (string, error) {
+	data, err := json.Marshal(ph)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (ph *PlanHooks) Unmarshal(s string) error {
+	if len(s) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(s), ph); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sc SpecChanges) Marshal() (string, error) {
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (sc *SpecChanges) Unmarshal(s string) error {
+	if err := json.Unmarshal([]byte(s), sc); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cl ClusterLabels) Marshal() (string, error) {
+	data, err := json.Marshal(cl)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (cl *ClusterLabels) Unmarshal(s string) error {
+	if err := json.Unmarshal([]byte(s), cl); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cd ClusterDefaults) Marshal() (string, error) {
+	data, err := json.Marshal(cd)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (cd *ClusterDefaults) Unmarshal(s string) error {
+	if err := json.Unmarshal([]byte(s), cd); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cp ClusterProxy) Marshal() (string, error) {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (cp *ClusterProxy) Unmarshal(s string) error {
+	if err := json.Unmarshal([]byte(s), cp); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pp PipelineParameters) Marshal() (string, error) {
+	data, err := json.Marshal(pp)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (pp *PipelineParameters) Unmarshal(s string) error {
+	if err := json.Unmarshal([]byte(s), pp); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pv PropagationValues) Marshal() (string, error) {
+	data, err := json.Marshal(pv)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (pv *PropagationValues) Unmarshal(s string) error {
+	if err := json.Unmarshal([]byte(s), pv); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (tv TemplateVariables) Marshal() (string, error) {
+	data, err := json.Marshal(tv)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (tv *TemplateVariables) Unmarshal(s string) error {
+	if err := json.Unmarshal([]byte(s), tv); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rt ReplicationTargets) Marshal() (string, error) {
+	data, err := json.Marshal(rt)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (rt *ReplicationTargets) Unmarshal(s string) error {
+	if err := json.Unmarshal([]byte(s), rt); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ir InjectionResults) Marshal() (string, error) {
+	data, err := json.Marshal(ir)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (ir *InjectionResults) Unmarshal(s string) error {
+	if err := json.Unmarshal([]byte(s), ir); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (rs *RuntimeSpec) IsDocker() bool {
 	return rs.Runtime == string(model.DockerCRI)
 }
@@ -397,6 +569,11 @@ func (rs *RuntimeSpec) IsContainerd() bool {
 	return rs.Runtime == string(model.ContainerdCRI)
 }
 
+// HasRegistryConfig 是否配置了镜像仓库的加速或者非安全仓库
+func (rs *RuntimeSpec) HasRegistryConfig() bool {
+	return len(rs.Mirrors) != 0 || len(rs.InsecureRegistries) != 0
+}
+
 func (p PageRequest) IsPaged() bool {
 	return p.Page != 0 && p.Limit != 0
 }
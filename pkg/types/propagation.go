@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "time"
+
+// 集群的分发状态
+const (
+	PropagationPending   = "pending"
+	PropagationRunning   = "running"
+	PropagationSucceeded = "succeeded"
+	PropagationFailed    = "failed"
+)
+
+// Propagation 多集群分发任务
+type Propagation struct {
+	PixiuMeta `json:",inline"`
+	TimeMeta  `json:",inline"`
+
+	Name        string            `json:"name"`
+	Kind        string            `json:"kind"` // manifest 或者 helm
+	Namespace   string            `json:"namespace"`
+	Manifest    string            `json:"manifest,omitempty"`
+	Chart       string            `json:"chart,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	Values      PropagationValues `json:"values,omitempty"`
+	Description string            `json:"description"`
+
+	Targets []PropagationTarget `json:"targets"`
+}
+
+// PropagationTarget 单个集群的分发状态
+type PropagationTarget struct {
+	Id          int64             `json:"id"`
+	Cluster     string            `json:"cluster"`
+	Overrides   PropagationValues `json:"overrides,omitempty"`
+	Status      string            `json:"status"`
+	Message     string            `json:"message,omitempty"`
+	Attempts    int               `json:"attempts"`
+	GmtModified time.Time         `json:"gmt_modified"`
+}
+
+// PropagationValues helm 的 values，manifest 模板中通过 .Values 引用
+type PropagationValues map[string]interface{}
+
+type CreatePropagationRequest struct {
+	Name      string            `json:"name" binding:"required"`                      // required
+	Kind      string            `json:"kind" binding:"required,oneof=manifest helm"`  // required
+	Namespace string            `json:"namespace" binding:"required"`                 // required
+	Manifest  string            `json:"manifest" binding:"required_if=Kind manifest"` // kind 为 manifest 时必填，支持 go template
+	Chart     string            `json:"chart" binding:"required_if=Kind helm"`        // kind 为 helm 时必填
+	Version   string            `json:"version" binding:"required_if=Kind helm"`      // kind 为 helm 时必填
+	Values    PropagationValues `json:"values" binding:"omitempty"`                   // optional
+	// 目标集群分组，与 clusters 至少指定一个
+	Fleet string `json:"fleet" binding:"required_without=Clusters"`
+	// 目标集群以及集群级别覆盖的 values，指定 fleet 时仅用于覆盖分组内集群的 values
+	Clusters    []PropagationClusterRequest `json:"clusters" binding:"omitempty,dive"`
+	Description *string                     `json:"description" binding:"omitempty"` // optional
+}
+
+type PropagationClusterRequest struct {
+	Cluster   string            `json:"cluster" binding:"required"`    // required
+	Overrides PropagationValues `json:"overrides" binding:"omitempty"` // optional
+}
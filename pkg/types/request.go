@@ -16,7 +16,14 @@ limitations under the License.
 
 package types
 
-import "github.com/caoyingjunz/pixiu/pkg/db/model"
+import (
+	"encoding/json"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/caoyingjunz/pixiu/pkg/db/model"
+)
 
 const AllNamespace = "all_namespaces"
 
@@ -32,8 +39,9 @@ type (
 		Password    string           `json:"password" binding:"required,password"` // required
 		Role        model.UserRole   `json:"role" binding:"omitempty,oneof=0 1 2"` // optional
 		Status      model.UserStatus `json:"status" binding:"omitempty"`
-		Email       string           `json:"email" binding:"omitempty,email"` // optional
-		Description string           `json:"description" binding:"omitempty"` // optional
+		Email       string           `json:"email" binding:"omitempty,email"`     // optional
+		Description string           `json:"description" binding:"omitempty"`     // optional
+		TenantId    int64            `json:"tenant_id" binding:"omitempty,min=0"` // optional
 	}
 
 	// UpdateUserRequest
@@ -43,6 +51,7 @@ type (
 		Status          model.UserStatus `json:"status" binding:"omitempty,oneof=0 1 2"` // required
 		Email           string           `json:"email" binding:"omitempty,email"`        // optional
 		Description     string           `json:"description" binding:"omitempty"`        // optional
+		TenantId        *int64           `json:"tenant_id" binding:"omitempty,min=0"`    // optional
 		ResourceVersion *int64           `json:"resource_version" binding:"required"`    // required
 	}
 
@@ -60,28 +69,71 @@ type (
 		KubeConfig  string            `json:"kube_config" binding:"required"`             // required
 		Description string            `json:"description" binding:"omitempty"`            // optional
 		Protected   bool              `json:"protected" binding:"omitempty"`              // optional
+		Labels      ClusterLabels     `json:"labels" binding:"omitempty"`                 // optional
+		// 为空时直接使用 pixiu 的凭证访问集群
+		AccessMode model.ClusterAccessMode `json:"access_mode" binding:"omitempty,oneof=direct impersonate"` // optional
+		// 集群所属的租户，租户用户创建时固定为其所属租户
+		TenantId int64 `json:"tenant_id" binding:"omitempty,min=0"` // optional
+		// 集群的默认设置
+		Defaults ClusterDefaults `json:"defaults" binding:"omitempty"` // optional
+		// 访问集群使用的代理，为空时直连
+		Proxy *ClusterProxy `json:"proxy" binding:"omitempty"` // optional
 	}
 
 	UpdateClusterRequest struct {
-		AliasName   *string `json:"alias_name" binding:"omitempty"`  // optional
-		Description *string `json:"description" binding:"omitempty"` // optional
+		AliasName   *string                  `json:"alias_name" binding:"omitempty"`                           // optional
+		Description *string                  `json:"description" binding:"omitempty"`                          // optional
+		Labels      *ClusterLabels           `json:"labels" binding:"omitempty"`                               // optional
+		AccessMode  *model.ClusterAccessMode `json:"access_mode" binding:"omitempty,oneof=direct impersonate"` // optional
+		TenantId    *int64                   `json:"tenant_id" binding:"omitempty,min=0"`                      // optional
+		// 替换集群的 kubeconfig，更新前会检查连通性
+		KubeConfig *string          `json:"kube_config" binding:"omitempty"` // optional
+		Defaults   *ClusterDefaults `json:"defaults" binding:"omitempty"`    // optional
+		// 替换集群的代理，type 为空时改为直连，更新前会检查连通性
+		Proxy *ClusterProxy `json:"proxy" binding:"omitempty"` // optional
 		// TODO: put resource version in a common struct for updating request only
 		ResourceVersion *int64 `json:"resource_version" binding:"required"` // required
 	}
 
+	// CreateClusterBootstrapRequest 生成通过 agent 导入集群的引导 token，server 为 pixiu 访问集群 API server 的地址
+	// pixiu_url 为集群中 agent 回调 pixiu 的地址，为空时使用当前请求的地址，ttl 单位为秒
+	// tunnel 为 true 时 agent 常驻集群并主动连接 pixiu 建立反向隧道，用于 pixiu 无法直接访问的集群，此时无需指定 server
+	CreateClusterBootstrapRequest struct {
+		Name        string `json:"name" binding:"omitempty"`                  // optional
+		AliasName   string `json:"alias_name" binding:"omitempty"`            // optional
+		Description string `json:"description" binding:"omitempty"`           // optional
+		Server      string `json:"server" binding:"omitempty,url"`            // optional, 非 tunnel 模式时必须
+		PixiuURL    string `json:"pixiu_url" binding:"omitempty,url"`         // optional
+		TTL         int64  `json:"ttl" binding:"omitempty,min=300,max=86400"` // optional
+		Tunnel      bool   `json:"tunnel" binding:"omitempty"`                // optional
+	}
+
+	// RegisterClusterRequest agent 使用引导 token 注册集群，token 和 ca_data 为 agent ServiceAccount 的凭证
+	RegisterClusterRequest struct {
+		BootstrapToken string `json:"bootstrap_token" binding:"required"` // required
+		Token          string `json:"token" binding:"required"`           // required
+		CAData         string `json:"ca_data" binding:"required"`         // required, base64 编码
+	}
+
 	ProtectClusterRequest struct {
 		ResourceVersion *int64 `json:"resource_version" binding:"required"` // required
 		Protected       bool   `json:"protected" binding:"omitempty"`       // optional
 	}
 
 	CreateTenantRequest struct {
-		Name        string  `json:"name" binding:"required"`         // required
-		Description *string `json:"description" binding:"omitempty"` // optional
+		Name        string  `json:"name" binding:"required"`             // required
+		Description *string `json:"description" binding:"omitempty"`     // optional
+		GPUQuota    *int64  `json:"gpu_quota" binding:"omitempty,min=0"` // optional
+		CPUQuota    *string `json:"cpu_quota" binding:"omitempty"`       // optional
+		MemoryQuota *string `json:"memory_quota" binding:"omitempty"`    // optional
 	}
 
 	UpdateTenantRequest struct {
 		Name            *string `json:"name" binding:"omitempty"`            // optional
 		Description     *string `json:"description" binding:"omitempty"`     // optional
+		GPUQuota        *int64  `json:"gpu_quota" binding:"omitempty,min=0"` // optional
+		CPUQuota        *string `json:"cpu_quota" binding:"omitempty"`       // optional
+		MemoryQuota     *string `json:"memory_quota" binding:"omitempty"`    // optional
 		ResourceVersion *int64  `json:"resource_version" binding:"required"` // required
 	}
 
@@ -109,6 +161,9 @@ type (
 		CRI    model.CRI    `json:"cri"`
 		Ip     string       `json:"ip"`
 		Auth   PlanNodeAuth `json:"auth"`
+		// 节点的 CPU 架构和操作系统类型，为空时自动探测
+		Arch     string `json:"arch" binding:"omitempty,oneof=amd64 arm64"`
+		OSFamily string `json:"os_family" binding:"omitempty,oneof=debian redhat"`
 	}
 
 	UpdatePlanNodeRequest struct {
@@ -119,6 +174,8 @@ type (
 		CRI             model.CRI    `json:"cri"`
 		Ip              string       `json:"ip"`
 		Auth            PlanNodeAuth `json:"auth"`
+		Arch            string       `json:"arch" binding:"omitempty,oneof=amd64 arm64"`
+		OSFamily        string       `json:"os_family" binding:"omitempty,oneof=debian redhat"`
 	}
 
 	CreatePlanConfigRequest struct {
@@ -131,6 +188,8 @@ type (
 		Network    NetworkSpec    `json:"network"`
 		Runtime    RuntimeSpec    `json:"runtime"`
 		Component  ComponentSpec  `json:"component"` // 支持的扩展组件配置
+		Execution  ExecutionSpec  `json:"execution"` // 并发和分批控制
+		Hooks      PlanHooks      `json:"hooks" binding:"omitempty,dive"`
 	}
 
 	UpdatePlanConfigRequest struct {
@@ -153,16 +212,322 @@ type (
 		Operation  *model.Operation  `form:"operation" binding:"omitempty,required_with=SID,rbac_operation"`
 	}
 
+	// ImportRBACOptions prune 时删除文档中未声明的策略和绑定关系，dry_run 时仅返回变更
+	ImportRBACOptions struct {
+		Prune  bool `form:"prune" binding:"omitempty"`   // optional
+		DryRun bool `form:"dry_run" binding:"omitempty"` // optional
+	}
+
 	GroupBindingRequest struct {
 		UserId    int64  `json:"user_id" binding:"required"`
 		GroupName string `json:"group_name" binding:"required"`
 	}
 
+	// SetGroupBindingsRequest 整体设置用户所属的用户组，未包含的用户组被解除绑定
+	SetGroupBindingsRequest struct {
+		GroupNames []string `json:"group_names" binding:"omitempty"`
+	}
+
 	ListGroupBindingRequest struct {
 		UserId    *int64  `form:"user_id" binding:"omitempty"`
 		GroupName *string `form:"group_name" binding:"omitempty"`
 	}
 
+	// PublishDNSRecordRequest 为 service 或者 ingress 注册解析记录
+	PublishDNSRecordRequest struct {
+		Namespace string `json:"namespace" binding:"required"`                  // required
+		Kind      string `json:"kind" binding:"required,oneof=service ingress"` // required
+		Name      string `json:"name" binding:"required"`                       // required
+		Domain    string `json:"domain" binding:"omitempty"`                    // optional, 为空时使用 <name>.<namespace>.<zone>
+	}
+
+	CreatePipelineRequest struct {
+		Name        string  `json:"name" binding:"required"`                          // required
+		Provider    string  `json:"provider" binding:"required,oneof=jenkins gitlab"` // required
+		URL         string  `json:"url" binding:"required,url"`                       // required
+		Project     string  `json:"project" binding:"required"`                       // required, jenkins 的 job 名称或者 gitlab 的 project id
+		Ref         string  `json:"ref" binding:"omitempty"`                          // optional, gitlab 触发的分支，默认 main
+		Username    string  `json:"username" binding:"omitempty"`                     // optional, jenkins 用户名
+		Token       string  `json:"token" binding:"omitempty"`                        // optional, jenkins api token 或者 gitlab access token
+		Description *string `json:"description" binding:"omitempty"`                  // optional
+	}
+
+	UpdatePipelineRequest struct {
+		URL             *string `json:"url" binding:"omitempty,url"`         // optional
+		Project         *string `json:"project" binding:"omitempty"`         // optional
+		Ref             *string `json:"ref" binding:"omitempty"`             // optional
+		Username        *string `json:"username" binding:"omitempty"`        // optional
+		Token           *string `json:"token" binding:"omitempty"`           // optional
+		Description     *string `json:"description" binding:"omitempty"`     // optional
+		ResourceVersion *int64  `json:"resource_version" binding:"required"` // required
+	}
+
+	CreateFleetRequest struct {
+		Name        string   `json:"name" binding:"required"`         // required
+		Selector    string   `json:"selector" binding:"omitempty"`    // optional, 集群标签选择器
+		Clusters    []string `json:"clusters" binding:"omitempty"`    // optional, 显式指定的集群
+		Description *string  `json:"description" binding:"omitempty"` // optional
+	}
+
+	UpdateFleetRequest struct {
+		Selector        *string   `json:"selector" binding:"omitempty"`        // optional
+		Clusters        *[]string `json:"clusters" binding:"omitempty"`        // optional
+		Description     *string   `json:"description" binding:"omitempty"`     // optional
+		ResourceVersion *int64    `json:"resource_version" binding:"required"` // required
+	}
+
+	CreateScalingScheduleRequest struct {
+		Namespace   string  `json:"namespace" binding:"required"`      // required
+		Name        string  `json:"name" binding:"required"`           // required, deployment 名称
+		Schedule    string  `json:"schedule" binding:"required"`       // required, 标准 5 位 cron 表达式
+		Replicas    *int32  `json:"replicas" binding:"required,min=0"` // required
+		Enabled     *bool   `json:"enabled" binding:"omitempty"`       // optional, 默认启用
+		Description *string `json:"description" binding:"omitempty"`   // optional
+	}
+
+	UpdateScalingScheduleRequest struct {
+		Schedule        *string `json:"schedule" binding:"omitempty"`        // optional
+		Replicas        *int32  `json:"replicas" binding:"omitempty,min=0"`  // optional
+		Enabled         *bool   `json:"enabled" binding:"omitempty"`         // optional
+		Description     *string `json:"description" binding:"omitempty"`     // optional
+		ResourceVersion *int64  `json:"resource_version" binding:"required"` // required
+	}
+
+	// CreateInspectionRequest checks 为空时执行全部检查项
+	CreateInspectionRequest struct {
+		Name        string   `json:"name" binding:"required"`         // required
+		Schedule    string   `json:"schedule" binding:"required"`     // required, 标准 5 位 cron 表达式
+		Checks      []string `json:"checks" binding:"omitempty"`      // optional
+		Receivers   []int64  `json:"receivers" binding:"omitempty"`   // optional, 接收巡检摘要的用户 id
+		Enabled     *bool    `json:"enabled" binding:"omitempty"`     // optional, 默认启用
+		Description *string  `json:"description" binding:"omitempty"` // optional
+	}
+
+	UpdateInspectionRequest struct {
+		Name            *string   `json:"name" binding:"omitempty"`            // optional
+		Schedule        *string   `json:"schedule" binding:"omitempty"`        // optional
+		Checks          *[]string `json:"checks" binding:"omitempty"`          // optional
+		Receivers       *[]int64  `json:"receivers" binding:"omitempty"`       // optional
+		Enabled         *bool     `json:"enabled" binding:"omitempty"`         // optional
+		Description     *string   `json:"description" binding:"omitempty"`     // optional
+		ResourceVersion *int64    `json:"resource_version" binding:"required"` // required
+	}
+
+	// CreateNamespacePolicyRequest ttl 为 Go duration 格式，例如 72h
+	CreateNamespacePolicyRequest struct {
+		TTL string `json:"ttl" binding:"required"` // required
+		// 命名空间不存在时是否创建
+		CreateNamespace bool    `json:"create_namespace" binding:"omitempty"` // optional
+		Description     *string `json:"description" binding:"omitempty"`      // optional
+	}
+
+	// ExtendNamespacePolicyRequest 在当前过期时间上延长 ttl，已过期时从当前时间开始计算
+	ExtendNamespacePolicyRequest struct {
+		TTL             string `json:"ttl" binding:"required"`              // required
+		ResourceVersion *int64 `json:"resource_version" binding:"required"` // required
+	}
+
+	// ApplyManifestRequest 等同于 kubectl apply --server-side，dry_run 时仅预览
+	ApplyManifestRequest struct {
+		Namespace string `json:"namespace" binding:"omitempty"` // optional, 未指定命名空间的对象使用的命名空间
+		Manifest  string `json:"manifest" binding:"required"`   // required, 支持多文档 yaml
+		DryRun    bool   `json:"dry_run" binding:"omitempty"`   // optional
+		// 为未设置 nodeSelector 的工作负载设置集群默认的 nodeSelector，仅通过模板创建时使用
+		WithDefaultNodeSelector bool `json:"-"`
+		// 调度到指定的节点池，为未设置 nodeSelector 的工作负载设置节点池的 nodeSelector 并容忍节点池的污点
+		NodePool string `json:"-"`
+	}
+
+	CreateTemplateRequest struct {
+		Name        string             `json:"name" binding:"required"`                    // required
+		Engine      string             `json:"engine" binding:"omitempty,oneof=simple go"` // optional, 默认 simple
+		Content     string             `json:"content" binding:"required"`                 // required
+		Variables   []TemplateVariable `json:"variables" binding:"omitempty,dive"`         // optional
+		Description *string            `json:"description" binding:"omitempty"`            // optional
+	}
+
+	UpdateTemplateRequest struct {
+		Engine          *string             `json:"engine" binding:"omitempty,oneof=simple go"` // optional
+		Content         *string             `json:"content" binding:"omitempty"`                // optional
+		Variables       *[]TemplateVariable `json:"variables" binding:"omitempty"`              // optional
+		Description     *string             `json:"description" binding:"omitempty"`            // optional
+		ResourceVersion *int64              `json:"resource_version" binding:"required"`        // required
+	}
+
+	RenderTemplateRequest struct {
+		Variables map[string]string `json:"variables" binding:"omitempty"` // optional
+	}
+
+	// InstantiateTemplateRequest 渲染模板并提交到指定集群
+	InstantiateTemplateRequest struct {
+		Cluster   string            `json:"cluster" binding:"required"`    // required
+		Namespace string            `json:"namespace" binding:"omitempty"` // optional
+		Variables map[string]string `json:"variables" binding:"omitempty"` // optional
+		DryRun    bool              `json:"dry_run" binding:"omitempty"`   // optional
+		NodePool  string            `json:"node_pool" binding:"omitempty"` // optional, 工作负载调度的节点池
+	}
+
+	// CreatePortForwardRequest 建立到 pod 或者 service 端口的转发会话
+	CreatePortForwardRequest struct {
+		Namespace string `json:"namespace" binding:"required"`              // required
+		Kind      string `json:"kind" binding:"required,oneof=pod service"` // required
+		Name      string `json:"name" binding:"required"`                   // required
+		Port      int32  `json:"port" binding:"required,min=1,max=65535"`   // required, kind 为 service 时为 service 的端口
+		TTL       int64  `json:"ttl" binding:"omitempty,min=60,max=7200"`   // optional, 会话有效期，单位秒，默认 1800
+	}
+
+	// UpdateNodePoolLabelsRequest 为节点池内的所有节点设置或者删除标签
+	UpdateNodePoolLabelsRequest struct {
+		Labels map[string]string `json:"labels" binding:"omitempty"` // optional
+		Remove []string          `json:"remove" binding:"omitempty"` // optional, 需删除的标签 key
+	}
+
+	// UpdateNodePoolTaintsRequest 为节点池内的所有节点设置或者删除污点，key 和 effect 相同的污点会被覆盖
+	UpdateNodePoolTaintsRequest struct {
+		Taints []v1.Taint `json:"taints" binding:"omitempty"` // optional
+		Remove []string   `json:"remove" binding:"omitempty"` // optional, 需删除的污点 key
+	}
+
+	// DrainNodePoolRequest 逐个节点滚动驱逐，当前节点完成后才驱逐下一个节点，任一节点失败时停止
+	DrainNodePoolRequest struct {
+		GracePeriodSeconds *int64 `json:"grace_period_seconds" binding:"omitempty,min=0"` // optional, 默认使用 pod 的配置
+		Timeout            int    `json:"timeout" binding:"omitempty,min=0"`              // optional, 单个节点的超时时间，单位秒，默认 300
+	}
+
+	// CreateCronJobRequest 创建单容器的定时任务，schedule 为 cron 表达式
+	CreateCronJobRequest struct {
+		Name                       string                  `json:"name" binding:"required,max=52"`                                    // required, job 名称会追加 11 位后缀
+		Schedule                   string                  `json:"schedule" binding:"required"`                                       // required
+		Image                      string                  `json:"image" binding:"required"`                                          // required
+		Command                    []string                `json:"command" binding:"omitempty"`                                       // optional
+		Args                       []string                `json:"args" binding:"omitempty"`                                          // optional
+		Env                        []v1.EnvVar             `json:"env" binding:"omitempty"`                                           // optional
+		Resources                  v1.ResourceRequirements `json:"resources" binding:"omitempty"`                                     // optional
+		RestartPolicy              v1.RestartPolicy        `json:"restart_policy" binding:"omitempty,oneof=OnFailure Never"`          // optional, 默认 OnFailure
+		ConcurrencyPolicy          string                  `json:"concurrency_policy" binding:"omitempty,oneof=Allow Forbid Replace"` // optional, 默认 Allow
+		Suspend                    bool                    `json:"suspend" binding:"omitempty"`                                       // optional
+		SuccessfulJobsHistoryLimit *int32                  `json:"successful_jobs_history_limit" binding:"omitempty,min=0"`           // optional
+		FailedJobsHistoryLimit     *int32                  `json:"failed_jobs_history_limit" binding:"omitempty,min=0"`               // optional
+	}
+
+	// UpdateCronJobRequest 按需修改定时任务，image，command，args 和 env 作用于第一个容器
+	UpdateCronJobRequest struct {
+		Schedule                   *string      `json:"schedule" binding:"omitempty"`                                      // optional
+		Image                      *string      `json:"image" binding:"omitempty"`                                         // optional
+		Command                    *[]string    `json:"command" binding:"omitempty"`                                       // optional
+		Args                       *[]string    `json:"args" binding:"omitempty"`                                          // optional
+		Env                        *[]v1.EnvVar `json:"env" binding:"omitempty"`                                           // optional
+		ConcurrencyPolicy          *string      `json:"concurrency_policy" binding:"omitempty,oneof=Allow Forbid Replace"` // optional
+		SuccessfulJobsHistoryLimit *int32       `json:"successful_jobs_history_limit" binding:"omitempty,min=0"`           // optional
+		FailedJobsHistoryLimit     *int32       `json:"failed_jobs_history_limit" binding:"omitempty,min=0"`               // optional
+	}
+
+	// CreateSecretRequest 根据 type 使用对应的字段生成 secret 的 data，默认为 Opaque
+	CreateSecretRequest struct {
+		Name          string            `json:"name" binding:"required"`                                                                // required
+		Type          v1.SecretType     `json:"type" binding:"omitempty,oneof=Opaque kubernetes.io/dockerconfigjson kubernetes.io/tls"` // optional
+		Labels        map[string]string `json:"labels" binding:"omitempty"`                                                             // optional
+		SecretContent `json:",inline"`
+	}
+
+	// UpdateSecretRequest 不允许修改 type，设置内容时替换原有的 data
+	UpdateSecretRequest struct {
+		Labels        *map[string]string `json:"labels" binding:"omitempty"` // optional
+		SecretContent `json:",inline"`
+	}
+
+	// SecretContent secret 的内容，Opaque 使用 data，dockerconfigjson 使用 docker_config，tls 使用 tls
+	SecretContent struct {
+		Data         map[string]string   `json:"data" binding:"omitempty"`          // optional, 明文，无需 base64 编码
+		DockerConfig *DockerConfigSecret `json:"docker_config" binding:"omitempty"` // optional
+		TLS          *TLSSecret          `json:"tls" binding:"omitempty"`           // optional
+	}
+
+	DockerConfigSecret struct {
+		Server   string `json:"server" binding:"required"`   // required, 镜像仓库地址
+		Username string `json:"username" binding:"required"` // required
+		Password string `json:"password" binding:"required"` // required
+		Email    string `json:"email" binding:"omitempty"`   // optional
+	}
+
+	// TLSSecret 证书和私钥均为 PEM 格式
+	TLSSecret struct {
+		Certificate string `json:"certificate" binding:"required"` // required
+		Key         string `json:"key" binding:"required"`         // required
+	}
+
+	// CreateServiceRequest type 默认为 ClusterIP，未指定 selector 时需自行维护 endpoints
+	CreateServiceRequest struct {
+		Name     string               `json:"name" binding:"required"`                                        // required
+		Type     v1.ServiceType       `json:"type" binding:"omitempty,oneof=ClusterIP NodePort LoadBalancer"` // optional
+		Selector map[string]string    `json:"selector" binding:"omitempty"`                                   // optional
+		Ports    []ServicePortRequest `json:"ports" binding:"required,min=1,dive"`                            // required
+		Labels   map[string]string    `json:"labels" binding:"omitempty"`                                     // optional
+	}
+
+	// UpdateServiceRequest 设置 ports 时替换原有的端口
+	UpdateServiceRequest struct {
+		Type     *v1.ServiceType       `json:"type" binding:"omitempty,oneof=ClusterIP NodePort LoadBalancer"` // optional
+		Selector *map[string]string    `json:"selector" binding:"omitempty"`                                   // optional
+		Ports    *[]ServicePortRequest `json:"ports" binding:"omitempty,min=1,dive"`                           // optional
+		Labels   *map[string]string    `json:"labels" binding:"omitempty"`                                     // optional
+	}
+
+	ServicePortRequest struct {
+		Name       string      `json:"name" binding:"omitempty"`                        // optional, 多个端口时必填
+		Protocol   v1.Protocol `json:"protocol" binding:"omitempty,oneof=TCP UDP SCTP"` // optional, 默认 TCP
+		Port       int32       `json:"port" binding:"required,min=1,max=65535"`         // required
+		TargetPort string      `json:"target_port" binding:"omitempty"`                 // optional, 端口号或者容器端口名称，默认与 port 相同
+		NodePort   int32       `json:"node_port" binding:"omitempty,min=1,max=65535"`   // optional, 仅 NodePort 和 LoadBalancer 生效，默认随机分配
+	}
+
+	// UndoDeploymentRequest to_revision 为 0 时回滚到上一个 revision
+	UndoDeploymentRequest struct {
+		ToRevision int64 `json:"to_revision" binding:"omitempty,min=0"` // optional
+	}
+
+	// CreatePersistentVolumeClaimRequest 未指定 storage_class_name 时使用集群的默认存储类
+	CreatePersistentVolumeClaimRequest struct {
+		Name             string                          `json:"name" binding:"required"`                                // required
+		StorageClassName string                          `json:"storage_class_name" binding:"omitempty"`                 // optional
+		AccessModes      []v1.PersistentVolumeAccessMode `json:"access_modes" binding:"omitempty"`                       // optional, 默认 ReadWriteOnce
+		VolumeMode       v1.PersistentVolumeMode         `json:"volume_mode" binding:"omitempty,oneof=Filesystem Block"` // optional
+		Size             string                          `json:"size" binding:"required"`                                // required, 例如 10Gi
+		Labels           map[string]string               `json:"labels" binding:"omitempty"`                             // optional
+	}
+
+	UpdatePersistentVolumeClaimRequest struct {
+		Labels *map[string]string `json:"labels" binding:"omitempty"` // optional
+	}
+
+	// ResizePersistentVolumeClaimRequest 仅支持扩容，size 需大于当前申请的容量
+	ResizePersistentVolumeClaimRequest struct {
+		Size string `json:"size" binding:"required"` // required, 例如 20Gi
+	}
+
+	CreateReplicationRequest struct {
+		Name        string              `json:"name" binding:"required"`                        // required
+		Cluster     string              `json:"cluster" binding:"required"`                     // required, 源集群
+		Namespace   string              `json:"namespace" binding:"required"`                   // required
+		Kind        string              `json:"kind" binding:"required,oneof=Secret ConfigMap"` // required
+		SourceName  string              `json:"source_name" binding:"required"`                 // required
+		Targets     []ReplicationTarget `json:"targets" binding:"required,min=1,dive"`          // required
+		Description *string             `json:"description" binding:"omitempty"`                // optional
+	}
+
+	UpdateReplicationRequest struct {
+		Targets         *[]ReplicationTarget `json:"targets" binding:"omitempty,min=1,dive"` // optional
+		Description     *string              `json:"description" binding:"omitempty"`        // optional
+		ResourceVersion *int64               `json:"resource_version" binding:"required"`    // required
+	}
+
+	// TriggerPipelineRequest 触发流水线，image_tag 和 cluster 会作为 IMAGE_TAG 和 CLUSTER 参数传入
+	TriggerPipelineRequest struct {
+		ImageTag   string            `json:"image_tag" binding:"omitempty"`  // optional
+		Cluster    string            `json:"cluster" binding:"omitempty"`    // optional
+		Parameters map[string]string `json:"parameters" binding:"omitempty"` // optional
+	}
+
 	// PageRequest 分页配置
 	PageRequest struct {
 		Page  int `form:"page" json:"page"`   // 页数，表示第几页
@@ -181,15 +546,282 @@ type (
 		User     string `form:"user" json:"user" binding:"required"`
 		Password string `form:"password" json:"password"`
 	}
+
+	// UpdateLogLevelRequest 运行时调整日志级别，modules 为 klog 源文件名到日志级别的映射
+	UpdateLogLevelRequest struct {
+		Level     string         `json:"level" binding:"required,oneof=error info debug"`
+		Verbosity int            `json:"verbosity" binding:"omitempty,min=0,max=10"`
+		Modules   map[string]int `json:"modules"`
+	}
+
+	// CreateCAPIPlanRequest 通过管理集群中 ClusterClass 的 topology 创建集群，name 同时作为 Cluster 对象的名称
+	CreateCAPIPlanRequest struct {
+		Name                 string              `json:"name" binding:"required"`                // required
+		Description          string              `json:"description" binding:"omitempty"`        // optional
+		ManagementCluster    string              `json:"management_cluster" binding:"required"`  // required
+		Namespace            string              `json:"namespace" binding:"required"`           // required
+		ClusterClass         string              `json:"cluster_class" binding:"required"`       // required
+		Version              string              `json:"version" binding:"required"`             // required
+		ControlPlaneReplicas int64               `json:"control_plane_replicas" binding:"min=1"` // required
+		Workers              []CAPIWorkerReplica `json:"workers" binding:"omitempty,dive"`       // optional
+	}
+
+	// ScaleCAPIPlanRequest 调整控制面或者 worker 的副本数，仅调整指定的部分
+	ScaleCAPIPlanRequest struct {
+		ControlPlaneReplicas *int64              `json:"control_plane_replicas" binding:"omitempty,min=1"` // optional
+		Workers              []CAPIWorkerReplica `json:"workers" binding:"omitempty,dive"`                 // optional
+	}
+
+	// ImportCAPIPlanRequest 将 Cluster API 创建完成的集群注册到 pixiu
+	ImportCAPIPlanRequest struct {
+		AliasName   string `json:"alias_name" binding:"omitempty"`  // optional
+		Description string `json:"description" binding:"omitempty"` // optional
+	}
+
+	// CreateKubeConfigRequest 签发 kubeconfig，ttl 单位为秒，user_id，role 和 server 仅管理员签发时生效
+	// server 为空时使用集群凭证中的 API server 地址
+	// namespace 不为空时仅在该命名空间内绑定 cluster_role，指定 role 时绑定该命名空间中的 Role
+	// credential_type 为 certificate 时通过 CSR 签发客户端证书，仅管理员签发时生效
+	// service_account_namespace 为 ServiceAccount 所在的命名空间，不存在时自动创建，仅管理员签发时生效
+	CreateKubeConfigRequest struct {
+		Cluster     string `json:"cluster" binding:"required"`       // required
+		ClusterRole string `json:"cluster_role" binding:"omitempty"` // optional, 和 role 均为空时使用集群默认的 ClusterRole
+		Role        string `json:"role" binding:"omitempty"`         // optional
+		Namespace   string `json:"namespace" binding:"omitempty"`    // optional
+		TTL         int64  `json:"ttl" binding:"omitempty,min=600"`  // optional
+		UserId      int64  `json:"user_id" binding:"omitempty"`      // optional
+		Server      string `json:"server" binding:"omitempty,url"`   // optional
+		// kubeconfig 中 context 的名称，默认为 ServiceAccount@集群名
+		ContextName string `json:"context_name" binding:"omitempty,max=253"` // optional
+
+		CredentialType          string `json:"credential_type" binding:"omitempty,oneof=token certificate"` // optional
+		ServiceAccountNamespace string `json:"service_account_namespace" binding:"omitempty"`               // optional, 不存在时自动创建，默认使用 namespace 或者配置中的命名空间
+		// 证书凭证的用户组，写入证书的 O，用于匹配集群中已有的 Group 授权
+		Groups []string `json:"groups" binding:"omitempty,max=10,dive,required"` // optional
+	}
+
+	// BatchCreateKubeConfigRequest 管理员批量为多个用户签发 kubeconfig
+	BatchCreateKubeConfigRequest struct {
+		Items []CreateKubeConfigRequest `json:"items" binding:"required,min=1,max=100,dive"` // required
+	}
+
+	// ListKubeConfigOptions 查询签发的 kubeconfig，默认不返回 config，showConfig 为 true 时返回明文并记录审计
+	// 管理员列表返回明文需要 kubeconfigsecrets 的读权限
+	// 指定 page 和 limit 时分页返回，user_id 仅管理员查询时生效
+	ListKubeConfigOptions struct {
+		UserId     int64  `form:"user_id"`
+		Cluster    string `form:"cluster"`
+		ShowConfig bool   `form:"showConfig"`
+
+		PageRequest `json:",inline"`
+	}
+
+	// ListKubeConfigRolesOptions 查询签发时可以绑定的角色，指定 namespace 时同时返回该命名空间中的 Role
+	ListKubeConfigRolesOptions struct {
+		Cluster   string `form:"cluster" binding:"required"`
+		Namespace string `form:"namespace"`
+	}
+
+	// CreateCloudAccountRequest 添加云账号，gke 的 secret_key 为 service account 的 json 密钥
+	CreateCloudAccountRequest struct {
+		Name        string `json:"name" binding:"required"`                       // required
+		Provider    string `json:"provider" binding:"required,oneof=ack eks gke"` // required
+		Region      string `json:"region" binding:"omitempty"`                    // optional, gke 为空时查询所有地域
+		ProjectId   string `json:"project_id" binding:"omitempty"`                // optional, gke 必填
+		AccessKey   string `json:"access_key" binding:"omitempty"`                // optional, ack 和 eks 必填
+		SecretKey   string `json:"secret_key" binding:"required"`                 // required
+		Description string `json:"description" binding:"omitempty"`               // optional
+	}
+
+	// ImportCloudClusterRequest 导入云账号下的托管集群，name 为空时随机生成
+	ImportCloudClusterRequest struct {
+		ClusterId   string        `json:"cluster_id" binding:"required"`   // required
+		Name        string        `json:"name" binding:"omitempty"`        // optional
+		AliasName   string        `json:"alias_name" binding:"omitempty"`  // optional
+		Description string        `json:"description" binding:"omitempty"` // optional
+		Protected   bool          `json:"protected" binding:"omitempty"`   // optional
+		Labels      ClusterLabels `json:"labels" binding:"omitempty"`      // optional
+	}
+
+	// CreateSLORequest kind 为 prometheus 时需指定 prometheus_url 和 query，其他类型需指定集群
+	CreateSLORequest struct {
+		Name          string  `json:"name" binding:"required"`                                                  // required
+		Kind          string  `json:"kind" binding:"required,oneof=api_availability node_readiness prometheus"` // required
+		Cluster       string  `json:"cluster" binding:"omitempty"`                                              // optional
+		Tenant        string  `json:"tenant" binding:"omitempty"`                                               // optional
+		Objective     float64 `json:"objective" binding:"required,gt=0,lt=100"`                                 // required, 百分比，例如 99.9
+		WindowDays    int     `json:"window_days" binding:"omitempty,min=1,max=90"`                             // optional, 默认 30 天
+		PrometheusURL string  `json:"prometheus_url" binding:"omitempty,url"`                                   // optional
+		Query         string  `json:"query" binding:"omitempty"`                                                // optional
+		Enabled       *bool   `json:"enabled" binding:"omitempty"`                                              // optional, 默认启用
+		Description   string  `json:"description" binding:"omitempty"`                                          // optional
+	}
+
+	UpdateSLORequest struct {
+		Objective       *float64 `json:"objective" binding:"omitempty,gt=0,lt=100"`    // optional
+		WindowDays      *int     `json:"window_days" binding:"omitempty,min=1,max=90"` // optional
+		PrometheusURL   *string  `json:"prometheus_url" binding:"omitempty,url"`       // optional
+		Query           *string  `json:"query" binding:"omitempty"`                    // optional
+		Enabled         *bool    `json:"enabled" binding:"omitempty"`                  // optional
+		Description     *string  `json:"description" binding:"omitempty"`              // optional
+		ResourceVersion *int64   `json:"resource_version" binding:"required"`          // required
+	}
+
+	// ListSLOOptions 按集群或者租户过滤
+	ListSLOOptions struct {
+		Cluster string `form:"cluster"`
+		Tenant  string `form:"tenant"`
+	}
+
+	// RunClusterPrecheckRequest nodes 为空时检查整个集群，否则仅检查目标节点上的工作负载
+	// 升级时检查 target_version 前移除的 API，未指定时检查下一个版本
+	RunClusterPrecheckRequest struct {
+		Operation     string   `json:"operation" binding:"required,oneof=upgrade drain maintenance"` // required
+		Nodes         []string `json:"nodes" binding:"omitempty"`                                    // optional
+		TargetVersion string   `json:"target_version" binding:"omitempty"`                           // optional
+	}
+
+	// ScanDeprecationOptions 扫描 target_version 前移除的 API，未指定时扫描之后 versions 个版本，默认为 1
+	ScanDeprecationOptions struct {
+		TargetVersion string `form:"target_version"`
+		Versions      int    `form:"versions" binding:"omitempty,min=1"`
+	}
+
+	// MarkNotificationsReadRequest ids 为空时标记全部通知为已读
+	MarkNotificationsReadRequest struct {
+		Ids []int64 `json:"ids" binding:"omitempty"` // optional
+	}
+
+	// UpdatePreferencesRequest 仅更新指定的设置，value 为 null 时删除该设置
+	UpdatePreferencesRequest map[string]json.RawMessage
+
+	UpdateNotificationPreferenceRequest struct {
+		EmailEnabled   *bool    `json:"email_enabled" binding:"omitempty"`                                                // optional
+		WebhookEnabled *bool    `json:"webhook_enabled" binding:"omitempty"`                                              // optional
+		WebhookURL     *string  `json:"webhook_url" binding:"omitempty,url"`                                              // optional
+		MutedKinds     []string `json:"muted_kinds" binding:"omitempty,dive,oneof=plan kubeconfig approval announcement"` // optional, 不通过邮件和 webhook 发送的通知类型
+	}
+
+	// CreateAnnouncementRequest 发布平台公告，notify 为 true 时同时通过通知渠道推送给所有用户
+	CreateAnnouncementRequest struct {
+		Title     string    `json:"title" binding:"required"`                                // required
+		Content   string    `json:"content" binding:"omitempty"`                             // optional
+		Severity  string    `json:"severity" binding:"required,oneof=info warning critical"` // required
+		StartTime time.Time `json:"start_time" binding:"required"`                           // required
+		EndTime   time.Time `json:"end_time" binding:"required,gtfield=StartTime"`           // required
+		Notify    bool      `json:"notify" binding:"omitempty"`                              // optional
+	}
+
+	UpdateAnnouncementRequest struct {
+		Title           *string    `json:"title" binding:"omitempty"`                                // optional
+		Content         *string    `json:"content" binding:"omitempty"`                              // optional
+		Severity        *string    `json:"severity" binding:"omitempty,oneof=info warning critical"` // optional
+		StartTime       *time.Time `json:"start_time" binding:"omitempty"`                           // optional
+		EndTime         *time.Time `json:"end_time" binding:"omitempty"`                             // optional
+		ResourceVersion *int64     `json:"resource_version" binding:"required"`                      // required
+	}
+
+	// CreateNamingPolicyRequest tenant_id 为 0 时创建全局命名规范
+	CreateNamingPolicyRequest struct {
+		TenantId     int64  `json:"tenant_id" binding:"omitempty,min=0"`                                        // optional
+		ResourceType string `json:"resource_type" binding:"required,oneof=namespaces deployments plans clouds"` // required
+		Pattern      string `json:"pattern" binding:"required"`                                                 // required, 正则表达式，需完整匹配名称
+		Description  string `json:"description" binding:"omitempty"`                                            // optional
+	}
+
+	UpdateNamingPolicyRequest struct {
+		Pattern         *string `json:"pattern" binding:"omitempty"`         // optional
+		Description     *string `json:"description" binding:"omitempty"`     // optional
+		ResourceVersion *int64  `json:"resource_version" binding:"required"` // required
+	}
+
+	// TestNamingPolicyRequest 测试名称是否符合租户的命名规范
+	TestNamingPolicyRequest struct {
+		TenantId     int64  `json:"tenant_id" binding:"omitempty,min=0"`                                        // optional
+		ResourceType string `json:"resource_type" binding:"required,oneof=namespaces deployments plans clouds"` // required
+		Name         string `json:"name" binding:"required"`                                                    // required
+	}
+
+	// SetDeploymentEnvRequest 添加或者更新容器的环境变量，value 和 value_from 二选一
+	SetDeploymentEnvRequest struct {
+		Name      string           `json:"name" binding:"required"`        // required
+		Value     string           `json:"value" binding:"omitempty"`      // optional
+		ValueFrom *v1.EnvVarSource `json:"value_from" binding:"omitempty"` // optional
+	}
+
+	// AddDeploymentEnvFromRequest 通过 configmap 或者 secret 批量注入环境变量
+	AddDeploymentEnvFromRequest struct {
+		Kind     string `json:"kind" binding:"required,oneof=configmap secret"` // required
+		Name     string `json:"name" binding:"required"`                        // required
+		Prefix   string `json:"prefix" binding:"omitempty"`                     // optional
+		Optional *bool  `json:"optional" binding:"omitempty"`                   // optional
+	}
+
+	// SetDeploymentVolumeMountRequest 添加或者更新容器的挂载，以 mount_path 区分
+	// 指定 volume 时同时为 pod 添加同名的卷，否则卷必须已存在
+	SetDeploymentVolumeMountRequest struct {
+		Name      string           `json:"name" binding:"required"`       // required
+		MountPath string           `json:"mount_path" binding:"required"` // required
+		SubPath   string           `json:"sub_path" binding:"omitempty"`  // optional
+		ReadOnly  bool             `json:"read_only" binding:"omitempty"` // optional
+		Volume    *v1.VolumeSource `json:"volume" binding:"omitempty"`    // optional
+	}
+
+	RemoveDeploymentVolumeMountOptions struct {
+		MountPath string `form:"mount_path" binding:"required"` // required
+	}
+
+	// CreateSidecarTemplateRequest container 至少需指定 name 和 image
+	CreateSidecarTemplateRequest struct {
+		Name        string       `json:"name" binding:"required"`                    // required
+		Type        string       `json:"type" binding:"required,oneof=sidecar init"` // required
+		Container   v1.Container `json:"container" binding:"required"`               // required
+		Volumes     []v1.Volume  `json:"volumes" binding:"omitempty"`                // optional
+		Description string       `json:"description" binding:"omitempty"`            // optional
+	}
+
+	UpdateSidecarTemplateRequest struct {
+		Container       *v1.Container `json:"container" binding:"omitempty"`       // optional
+		Volumes         *[]v1.Volume  `json:"volumes" binding:"omitempty"`         // optional
+		Description     *string       `json:"description" binding:"omitempty"`     // optional
+		ResourceVersion *int64        `json:"resource_version" binding:"required"` // required
+	}
+
+	// InjectSidecarRequest 在指定命名空间中按 selector 选择工作负载注入，names 不为空时仅注入指定名称的工作负载
+	InjectSidecarRequest struct {
+		Cluster    string   `json:"cluster" binding:"required"`                                            // required
+		Template   string   `json:"template" binding:"required"`                                           // required
+		Namespaces []string `json:"namespaces" binding:"required,min=1"`                                   // required
+		Kinds      []string `json:"kinds" binding:"omitempty,dive,oneof=Deployment StatefulSet DaemonSet"` // optional, 默认 Deployment
+		Selector   string   `json:"selector" binding:"omitempty"`                                          // optional, label selector
+		Names      []string `json:"names" binding:"omitempty"`                                             // optional
+	}
+
+	ListInjectionOptions struct {
+		Cluster string `form:"cluster"`
+	}
+
+	// GetCRDFormOptions version 为空时使用 CRD 的存储版本
+	GetCRDFormOptions struct {
+		Version string `form:"version"`
+	}
+
+	UpdateKubeConfigPolicyRequest struct {
+		Clusters     []string `json:"clusters"`
+		ClusterRoles []string `json:"cluster_roles" binding:"required,min=1"`
+		MaxTTL       int64    `json:"max_ttl" binding:"required,min=600"`
+	}
 )
 
 type (
 	LoginResponse struct {
-		UserId      int64          `json:"user_id"`
-		UserName    string         `json:"user_name"`
-		Token       string         `json:"token"`
-		Role        model.UserRole `json:"role"`
-		*model.User `json:"-"`
+		UserId   int64          `json:"user_id"`
+		UserName string         `json:"user_name"`
+		Token    string         `json:"token"`
+		Role     model.UserRole `json:"role"`
+		// 需要修改密码后才能继续使用
+		PasswordChangeRequired bool `json:"password_change_required"`
+		*model.User            `json:"-"`
 	}
 
 	// PageResponse 分页查询返回值
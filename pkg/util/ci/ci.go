@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ci
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderJenkins = "jenkins"
+	ProviderGitLab  = "gitlab"
+)
+
+// 流水线运行状态
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	StatusAborted = "aborted"
+	StatusUnknown = "unknown"
+)
+
+// IsFinished 判断流水线是否已运行结束
+func IsFinished(status string) bool {
+	return status == StatusSuccess || status == StatusFailed || status == StatusAborted
+}
+
+// Options 流水线的访问配置
+type Options struct {
+	Provider string
+	// jenkins 或者 gitlab 的访问地址，例如 https://jenkins.pixiu.io
+	URL string
+	// jenkins 的 job 名称，或者 gitlab 的 project id
+	Project string
+	// gitlab 的分支或者 tag，jenkins 忽略
+	Ref string
+
+	Username string
+	Token    string
+}
+
+// Run 流水线的一次运行
+type Run struct {
+	// jenkins 为 queue item 或者 build number，gitlab 为 pipeline id
+	Id     string
+	Status string
+	WebURL string
+}
+
+// Provider CI 服务的接口，不同的 CI 服务需实现该接口
+type Provider interface {
+	// Trigger 触发流水线，params 为流水线参数
+	Trigger(ctx context.Context, params map[string]string) (*Run, error)
+	// Status 查询流水线运行状态
+	Status(ctx context.Context, id string) (*Run, error)
+}
+
+func NewProvider(o Options) (Provider, error) {
+	if len(o.URL) == 0 || len(o.Project) == 0 {
+		return nil, fmt.Errorf("url and project are required")
+	}
+	o.URL = strings.TrimSuffix(o.URL, "/")
+	c := &http.Client{Timeout: 10 * time.Second}
+
+	switch o.Provider {
+	case ProviderJenkins:
+		return &jenkins{opt: o, client: c}, nil
+	case ProviderGitLab:
+		return &gitlab{opt: o, client: c}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ci provider %q", o.Provider)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const defaultRef = "main"
+
+type gitlab struct {
+	opt    Options
+	client *http.Client
+}
+
+type gitlabPipeline struct {
+	Id     int    `json:"id"`
+	Status string `json:"status"`
+	WebURL string `json:"web_url"`
+}
+
+func (g *gitlab) projectURL() string {
+	return g.opt.URL + "/api/v4/projects/" + url.PathEscape(g.opt.Project)
+}
+
+// Trigger 调用 gitlab 的 create pipeline 接口，params 作为流水线变量传入
+func (g *gitlab) Trigger(ctx context.Context, params map[string]string) (*Run, error) {
+	ref := g.opt.Ref
+	if len(ref) == 0 {
+		ref = defaultRef
+	}
+	variables := make([]map[string]string, 0, len(params))
+	for k, v := range params {
+		variables = append(variables, map[string]string{"key": k, "value": v})
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"ref":       ref,
+		"variables": variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var pipeline gitlabPipeline
+	if err = g.do(ctx, http.MethodPost, g.projectURL()+"/pipeline", body, http.StatusCreated, &pipeline); err != nil {
+		return nil, err
+	}
+	return g.parseRun(pipeline), nil
+}
+
+func (g *gitlab) Status(ctx context.Context, id string) (*Run, error) {
+	var pipeline gitlabPipeline
+	if err := g.do(ctx, http.MethodGet, g.projectURL()+"/pipelines/"+id, nil, http.StatusOK, &pipeline); err != nil {
+		return nil, err
+	}
+	return g.parseRun(pipeline), nil
+}
+
+func (g *gitlab) parseRun(p gitlabPipeline) *Run {
+	run := &Run{Id: strconv.Itoa(p.Id), WebURL: p.WebURL}
+	switch p.Status {
+	case "created", "waiting_for_resource", "preparing", "pending", "scheduled", "manual":
+		run.Status = StatusPending
+	case "running":
+		run.Status = StatusRunning
+	case "success":
+		run.Status = StatusSuccess
+	case "failed":
+		run.Status = StatusFailed
+	case "canceled", "skipped":
+		run.Status = StatusAborted
+	default:
+		run.Status = StatusUnknown
+	}
+	return run
+}
+
+func (g *gitlab) do(ctx context.Context, method string, u string, body []byte, expected int, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("PRIVATE-TOKEN", g.opt.Token)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != expected {
+		return fmt.Errorf("gitlab returned status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
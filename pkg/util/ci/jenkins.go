@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// queuePrefix jenkins 构建尚未开始时，使用 queue item 作为运行 id
+const queuePrefix = "queue-"
+
+type jenkins struct {
+	opt    Options
+	client *http.Client
+}
+
+func (j *jenkins) jobURL() string {
+	return j.opt.URL + "/job/" + url.PathEscape(j.opt.Project)
+}
+
+// Trigger 调用 buildWithParameters 触发构建，jenkins 返回的 Location 为 queue item 地址
+func (j *jenkins) Trigger(ctx context.Context, params map[string]string) (*Run, error) {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	path := "/build"
+	if len(values) != 0 {
+		path = "/buildWithParameters"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.jobURL()+path, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := j.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("jenkins returned status %d", resp.StatusCode)
+	}
+
+	// e.g. https://jenkins.pixiu.io/queue/item/10/
+	location := strings.TrimSuffix(resp.Header.Get("Location"), "/")
+	item := location[strings.LastIndex(location, "/")+1:]
+	if _, err = strconv.Atoi(item); err != nil {
+		return nil, fmt.Errorf("unexpected jenkins queue location %q", location)
+	}
+
+	return &Run{Id: queuePrefix + item, Status: StatusPending, WebURL: j.jobURL()}, nil
+}
+
+func (j *jenkins) Status(ctx context.Context, id string) (*Run, error) {
+	if strings.HasPrefix(id, queuePrefix) {
+		return j.queueStatus(ctx, id)
+	}
+
+	var build struct {
+		Building bool   `json:"building"`
+		Result   string `json:"result"`
+		URL      string `json:"url"`
+	}
+	if err := j.get(ctx, j.jobURL()+"/"+id+"/api/json", &build); err != nil {
+		return nil, err
+	}
+
+	run := &Run{Id: id, WebURL: build.URL}
+	switch {
+	case build.Building:
+		run.Status = StatusRunning
+	case build.Result == "SUCCESS":
+		run.Status = StatusSuccess
+	case build.Result == "FAILURE" || build.Result == "UNSTABLE":
+		run.Status = StatusFailed
+	case build.Result == "ABORTED":
+		run.Status = StatusAborted
+	default:
+		run.Status = StatusUnknown
+	}
+	return run, nil
+}
+
+// queueStatus 构建仍在排队时返回 pending，开始构建后返回 build number 作为新的运行 id
+func (j *jenkins) queueStatus(ctx context.Context, id string) (*Run, error) {
+	var item struct {
+		Cancelled  bool `json:"cancelled"`
+		Executable *struct {
+			Number int    `json:"number"`
+			URL    string `json:"url"`
+		} `json:"executable"`
+	}
+	if err := j.get(ctx, j.opt.URL+"/queue/item/"+strings.TrimPrefix(id, queuePrefix)+"/api/json", &item); err != nil {
+		return nil, err
+	}
+
+	if item.Cancelled {
+		return &Run{Id: id, Status: StatusAborted}, nil
+	}
+	if item.Executable == nil {
+		return &Run{Id: id, Status: StatusPending}, nil
+	}
+	return j.Status(ctx, strconv.Itoa(item.Executable.Number))
+}
+
+func (j *jenkins) get(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jenkins returned status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (j *jenkins) do(req *http.Request) (*http.Response, error) {
+	if len(j.opt.Username) != 0 {
+		req.SetBasicAuth(j.opt.Username, j.opt.Token)
+	}
+	return j.client.Do(req)
+}
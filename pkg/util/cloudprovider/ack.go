@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ackAPIVersion = "2015-12-15"
+	ackPageSize   = 100
+)
+
+type ack struct {
+	credential Credential
+	client     *http.Client
+}
+
+func newACK(c Credential, client *http.Client) *ack {
+	return &ack{credential: c, client: client}
+}
+
+func (a *ack) Name() string {
+	return ProviderACK
+}
+
+func (a *ack) ListClusters(ctx context.Context) ([]ManagedCluster, error) {
+	clusters := make([]ManagedCluster, 0)
+	for page := 1; ; page++ {
+		query := url.Values{
+			"region_id":   []string{a.credential.Region},
+			"page_size":   []string{strconv.Itoa(ackPageSize)},
+			"page_number": []string{strconv.Itoa(page)},
+		}
+		var out struct {
+			Clusters []struct {
+				ClusterId      string `json:"cluster_id"`
+				Name           string `json:"name"`
+				RegionId       string `json:"region_id"`
+				State          string `json:"state"`
+				CurrentVersion string `json:"current_version"`
+			} `json:"clusters"`
+			PageInfo struct {
+				TotalCount int `json:"total_count"`
+			} `json:"page_info"`
+		}
+		if err := a.do(ctx, "/api/v1/clusters", query, &out); err != nil {
+			return nil, err
+		}
+
+		for _, c := range out.Clusters {
+			clusters = append(clusters, ManagedCluster{
+				Id:      c.ClusterId,
+				Name:    c.Name,
+				Region:  c.RegionId,
+				Version: c.CurrentVersion,
+				Status:  c.State,
+			})
+		}
+		if len(out.Clusters) < ackPageSize || len(clusters) >= out.PageInfo.TotalCount {
+			break
+		}
+	}
+
+	return clusters, nil
+}
+
+func (a *ack) GetKubeConfig(ctx context.Context, clusterId string) (*KubeConfig, error) {
+	var out struct {
+		Config     string `json:"config"`
+		Expiration string `json:"expiration"`
+	}
+	query := url.Values{"PrivateIpAddress": []string{"false"}}
+	if err := a.do(ctx, "/k8s/"+url.PathEscape(clusterId)+"/user_config", query, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Config) == 0 {
+		return nil, fmt.Errorf("ack cluster %s has no kubeconfig", clusterId)
+	}
+
+	kubeConfig := &KubeConfig{Data: []byte(out.Config)}
+	if len(out.Expiration) != 0 {
+		if expiration, err := time.Parse(time.RFC3339, out.Expiration); err == nil {
+			kubeConfig.ExpirationTimestamp = &expiration
+		}
+	}
+	return kubeConfig, nil
+}
+
+func (a *ack) do(ctx context.Context, path string, query url.Values, out interface{}) error {
+	u := url.URL{
+		Scheme:   "https",
+		Host:     fmt.Sprintf("cs.%s.aliyuncs.com", a.credential.Region),
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if err = a.sign(req, path, query); err != nil {
+		return err
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ack %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// sign 使用阿里云 ROA 风格的 HMAC-SHA1 签名请求
+func (a *ack) sign(req *http.Request, path string, query url.Values) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	acsHeaders := map[string]string{
+		"x-acs-signature-method":  "HMAC-SHA1",
+		"x-acs-signature-nonce":   hex.EncodeToString(nonce),
+		"x-acs-signature-version": "1.0",
+		"x-acs-version":           ackAPIVersion,
+	}
+	keys := make([]string, 0, len(acsHeaders))
+	for k, v := range acsHeaders {
+		req.Header.Set(k, v)
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(strings.Join([]string{req.Method, req.Header.Get("Accept"), "", "", req.Header.Get("Date")}, "\n") + "\n")
+	for _, k := range keys {
+		b.WriteString(k + ":" + acsHeaders[k] + "\n")
+	}
+	b.WriteString(path)
+	if len(query) != 0 {
+		params := make([]string, 0, len(query))
+		for k := range query {
+			params = append(params, k+"="+query.Get(k))
+		}
+		sort.Strings(params)
+		b.WriteString("?" + strings.Join(params, "&"))
+	}
+
+	h := hmac.New(sha1.New, []byte(a.credential.SecretKey))
+	h.Write([]byte(b.String()))
+	req.Header.Set("Authorization", fmt.Sprintf("acs %s:%s", a.credential.AccessKey, base64.StdEncoding.EncodeToString(h.Sum(nil))))
+	return nil
+}
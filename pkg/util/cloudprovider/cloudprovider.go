@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	ProviderACK = "ack"
+	ProviderEKS = "eks"
+	ProviderGKE = "gke"
+
+	defaultTimeout = 10 * time.Second
+)
+
+// Credential 云账号的访问凭证
+type Credential struct {
+	Provider string
+	// 集群所在的地域，GKE 为空时查询所有地域
+	Region    string
+	AccessKey string
+	// GKE 为 service account 的 json 密钥
+	SecretKey string
+	// GKE 的项目 ID
+	ProjectId string
+}
+
+// ManagedCluster 云厂商托管的 kubernetes 集群
+type ManagedCluster struct {
+	// 集群在云厂商中的唯一标识，GKE 为 location/name
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Region  string `json:"region"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
+}
+
+// KubeConfig 访问托管集群的 kubeconfig
+type KubeConfig struct {
+	Data []byte
+	// 凭证的过期时间，凭证长期有效时为 nil
+	ExpirationTimestamp *time.Time
+}
+
+// Provider 云厂商托管集群的接口，不同的云厂商需实现该接口
+type Provider interface {
+	// Name 返回 provider 的名称
+	Name() string
+
+	// ListClusters 获取云账号下的托管集群
+	ListClusters(ctx context.Context) ([]ManagedCluster, error)
+	// GetKubeConfig 获取托管集群的 kubeconfig，凭证有过期时间时需定期刷新
+	GetKubeConfig(ctx context.Context, clusterId string) (*KubeConfig, error)
+}
+
+func (c *Credential) Valid() error {
+	switch c.Provider {
+	case ProviderACK, ProviderEKS:
+		if len(c.Region) == 0 || len(c.AccessKey) == 0 || len(c.SecretKey) == 0 {
+			return fmt.Errorf("%s provider, region, access key and secret key are required", c.Provider)
+		}
+	case ProviderGKE:
+		if len(c.ProjectId) == 0 || len(c.SecretKey) == 0 {
+			return fmt.Errorf("gke provider, project id and service account key are required")
+		}
+	default:
+		return fmt.Errorf("unsupported cloud provider %q", c.Provider)
+	}
+	return nil
+}
+
+// NewProvider 根据云账号凭证构造 provider
+func NewProvider(c Credential) (Provider, error) {
+	if err := c.Valid(); err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: defaultTimeout}
+	switch c.Provider {
+	case ProviderACK:
+		return newACK(c, client), nil
+	case ProviderEKS:
+		return newEKS(c, client), nil
+	case ProviderGKE:
+		return newGKE(c, client)
+	default:
+		return nil, fmt.Errorf("unsupported cloud provider %q", c.Provider)
+	}
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	kubeconfigutil "github.com/caoyingjunz/pixiu/pkg/util/kubeconfig"
+)
+
+const (
+	eksService = "eks"
+
+	// EKS 的 token 为预签名的 sts GetCallerIdentity 请求，由 aws-iam-authenticator 校验
+	stsHost         = "sts.amazonaws.com"
+	stsRegion       = "us-east-1"
+	eksTokenPrefix  = "k8s-aws-v1."
+	eksClusterIdKey = "x-k8s-aws-id"
+	// aws-iam-authenticator 仅接受签发 15 分钟内的 token
+	eksTokenTTL = 15 * time.Minute
+
+	awsAlgorithm  = "AWS4-HMAC-SHA256"
+	awsTimeFormat = "20060102T150405Z"
+	// 空请求体的 sha256
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+type eks struct {
+	credential Credential
+	client     *http.Client
+}
+
+func newEKS(c Credential, client *http.Client) *eks {
+	return &eks{credential: c, client: client}
+}
+
+func (e *eks) Name() string {
+	return ProviderEKS
+}
+
+type eksCluster struct {
+	Name                 string `json:"name"`
+	Version              string `json:"version"`
+	Endpoint             string `json:"endpoint"`
+	Status               string `json:"status"`
+	CertificateAuthority struct {
+		Data string `json:"data"`
+	} `json:"certificateAuthority"`
+}
+
+func (e *eks) ListClusters(ctx context.Context) ([]ManagedCluster, error) {
+	clusters := make([]ManagedCluster, 0)
+
+	var nextToken string
+	for {
+		query := url.Values{"maxResults": []string{"100"}}
+		if len(nextToken) != 0 {
+			query.Set("nextToken", nextToken)
+		}
+		var out struct {
+			Clusters  []string `json:"clusters"`
+			NextToken *string  `json:"nextToken"`
+		}
+		if err := e.do(ctx, "/clusters", query, &out); err != nil {
+			return nil, err
+		}
+
+		for _, name := range out.Clusters {
+			object, err := e.describeCluster(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			clusters = append(clusters, ManagedCluster{
+				Id:      object.Name,
+				Name:    object.Name,
+				Region:  e.credential.Region,
+				Version: object.Version,
+				Status:  object.Status,
+			})
+		}
+		if out.NextToken == nil || len(*out.NextToken) == 0 {
+			break
+		}
+		nextToken = *out.NextToken
+	}
+
+	return clusters, nil
+}
+
+func (e *eks) GetKubeConfig(ctx context.Context, clusterId string) (*KubeConfig, error) {
+	object, err := e.describeCluster(ctx, clusterId)
+	if err != nil {
+		return nil, err
+	}
+	caData, err := base64.StdEncoding.DecodeString(object.CertificateAuthority.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode eks cluster %s certificate authority: %v", clusterId, err)
+	}
+
+	now := time.Now()
+	token := e.token(clusterId, now)
+	data, err := kubeconfigutil.NewForToken(object.Name, object.Endpoint, caData, token)
+	if err != nil {
+		return nil, err
+	}
+	expiration := now.Add(eksTokenTTL)
+	return &KubeConfig{Data: data, ExpirationTimestamp: &expiration}, nil
+}
+
+func (e *eks) describeCluster(ctx context.Context, name string) (*eksCluster, error) {
+	var out struct {
+		Cluster eksCluster `json:"cluster"`
+	}
+	if err := e.do(ctx, "/clusters/"+url.PathEscape(name), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out.Cluster, nil
+}
+
+func (e *eks) do(ctx context.Context, path string, query url.Values, out interface{}) error {
+	u := url.URL{
+		Scheme:   "https",
+		Host:     fmt.Sprintf("eks.%s.amazonaws.com", e.credential.Region),
+		Path:     path,
+		RawQuery: awsCanonicalQuery(query),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	e.sign(req, time.Now())
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("eks %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// sign 使用 AWS Signature Version 4 签名请求
+func (e *eks) sign(req *http.Request, now time.Time) {
+	amzDate := now.UTC().Format(awsTimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{
+		"host":       req.URL.Host,
+		"x-amz-date": amzDate,
+	}
+	scope, signedHeaders, signature := awsSignature(e.credential, e.credential.Region, eksService, req.Method,
+		req.URL.EscapedPath(), req.URL.RawQuery, headers, emptyPayloadHash, amzDate)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsAlgorithm, e.credential.AccessKey, scope, signedHeaders, signature))
+}
+
+// token 预签名 sts GetCallerIdentity 请求并编码为 EKS 识别的 bearer token
+func (e *eks) token(clusterId string, now time.Time) string {
+	amzDate := now.UTC().Format(awsTimeFormat)
+	scope := fmt.Sprintf("%s/%s/sts/aws4_request", amzDate[:8], stsRegion)
+
+	query := url.Values{
+		"Action":              []string{"GetCallerIdentity"},
+		"Version":             []string{"2011-06-15"},
+		"X-Amz-Algorithm":     []string{awsAlgorithm},
+		"X-Amz-Credential":    []string{e.credential.AccessKey + "/" + scope},
+		"X-Amz-Date":          []string{amzDate},
+		"X-Amz-Expires":       []string{"60"},
+		"X-Amz-SignedHeaders": []string{"host;" + eksClusterIdKey},
+	}
+	headers := map[string]string{
+		"host":          stsHost,
+		eksClusterIdKey: clusterId,
+	}
+	_, _, signature := awsSignature(e.credential, stsRegion, "sts", http.MethodGet,
+		"/", awsCanonicalQuery(query), headers, emptyPayloadHash, amzDate)
+	query.Set("X-Amz-Signature", signature)
+
+	presigned := fmt.Sprintf("https://%s/?%s", stsHost, awsCanonicalQuery(query))
+	return eksTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(presigned))
+}
+
+// awsSignature 计算 SigV4 签名，返回 credential scope，signed headers 和签名
+func awsSignature(c Credential, region, service, method, path, query string, headers map[string]string, payloadHash, amzDate string) (string, string, string) {
+	if len(path) == 0 {
+		path = "/"
+	}
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range keys {
+		canonicalHeaders.WriteString(k + ":" + strings.TrimSpace(headers[k]) + "\n")
+	}
+	signedHeaders := strings.Join(keys, ";")
+	canonicalRequest := strings.Join([]string{method, path, query, canonicalHeaders.String(), signedHeaders, payloadHash}, "\n")
+
+	date := amzDate[:8]
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	stringToSign := strings.Join([]string{awsAlgorithm, amzDate, scope, hexSHA256(canonicalRequest)}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	return scope, signedHeaders, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// awsCanonicalQuery 按 key 排序并使用 RFC 3986 编码查询参数
+func awsCanonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloudprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+
+	kubeconfigutil "github.com/caoyingjunz/pixiu/pkg/util/kubeconfig"
+)
+
+const (
+	gkeEndpoint     = "https://container.googleapis.com/v1"
+	gkeScope        = "https://www.googleapis.com/auth/cloud-platform"
+	googleTokenURL  = "https://oauth2.googleapis.com/token"
+	gkeAllLocations = "-"
+)
+
+// serviceAccountKey GCP service account 的 json 密钥
+type serviceAccountKey struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyId string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+type gke struct {
+	credential Credential
+	client     *http.Client
+	jwt        *jwt.Config
+}
+
+func newGKE(c Credential, client *http.Client) (*gke, error) {
+	var key serviceAccountKey
+	if err := json.Unmarshal([]byte(c.SecretKey), &key); err != nil {
+		return nil, fmt.Errorf("invalid gke service account key: %v", err)
+	}
+	if len(key.ClientEmail) == 0 || len(key.PrivateKey) == 0 {
+		return nil, fmt.Errorf("invalid gke service account key: client_email and private_key are required")
+	}
+	tokenURL := key.TokenURI
+	if len(tokenURL) == 0 {
+		tokenURL = googleTokenURL
+	}
+
+	return &gke{
+		credential: c,
+		client:     client,
+		jwt: &jwt.Config{
+			Email:        key.ClientEmail,
+			PrivateKey:   []byte(key.PrivateKey),
+			PrivateKeyID: key.PrivateKeyId,
+			Scopes:       []string{gkeScope},
+			TokenURL:     tokenURL,
+		},
+	}, nil
+}
+
+func (g *gke) Name() string {
+	return ProviderGKE
+}
+
+type gkeCluster struct {
+	Name                 string `json:"name"`
+	Location             string `json:"location"`
+	CurrentMasterVersion string `json:"currentMasterVersion"`
+	Status               string `json:"status"`
+	Endpoint             string `json:"endpoint"`
+	MasterAuth           struct {
+		ClusterCaCertificate string `json:"clusterCaCertificate"`
+	} `json:"masterAuth"`
+}
+
+func (g *gke) ListClusters(ctx context.Context) ([]ManagedCluster, error) {
+	token, err := g.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	location := g.credential.Region
+	if len(location) == 0 {
+		location = gkeAllLocations
+	}
+
+	var out struct {
+		Clusters []gkeCluster `json:"clusters"`
+	}
+	if err = g.do(ctx, token, fmt.Sprintf("/projects/%s/locations/%s/clusters", g.credential.ProjectId, location), &out); err != nil {
+		return nil, err
+	}
+
+	clusters := make([]ManagedCluster, len(out.Clusters))
+	for i, c := range out.Clusters {
+		clusters[i] = ManagedCluster{
+			Id:      c.Location + "/" + c.Name,
+			Name:    c.Name,
+			Region:  c.Location,
+			Version: c.CurrentMasterVersion,
+			Status:  c.Status,
+		}
+	}
+	return clusters, nil
+}
+
+// GetKubeConfig 使用 service account 的 access token 访问集群，token 通常一小时后过期
+func (g *gke) GetKubeConfig(ctx context.Context, clusterId string) (*KubeConfig, error) {
+	parts := strings.SplitN(clusterId, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid gke cluster id %q, expected location/name", clusterId)
+	}
+	token, err := g.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var object gkeCluster
+	if err = g.do(ctx, token, fmt.Sprintf("/projects/%s/locations/%s/clusters/%s", g.credential.ProjectId, parts[0], parts[1]), &object); err != nil {
+		return nil, err
+	}
+	caData, err := base64.StdEncoding.DecodeString(object.MasterAuth.ClusterCaCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode gke cluster %s certificate authority: %v", clusterId, err)
+	}
+
+	data, err := kubeconfigutil.NewForToken(object.Name, "https://"+object.Endpoint, caData, token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	kubeConfig := &KubeConfig{Data: data}
+	if !token.Expiry.IsZero() {
+		expiration := token.Expiry
+		kubeConfig.ExpirationTimestamp = &expiration
+	}
+	return kubeConfig, nil
+}
+
+func (g *gke) token(ctx context.Context) (*oauth2.Token, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, g.client)
+	token, err := g.jwt.TokenSource(ctx).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gke access token: %v", err)
+	}
+	return token, nil
+}
+
+func (g *gke) do(ctx context.Context, token *oauth2.Token, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gkeEndpoint+path, nil)
+	if err != nil {
+		return err
+	}
+	token.SetAuthHeader(req)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("gke %s: %s: %s", path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, out)
+}
@@ -37,6 +37,7 @@ type Container struct {
 	name   string
 	planId int64
 	dir    string
+	env    []string
 }
 
 func NewContainer(action string, planId int64, dir string) (*Container, error) {
@@ -53,6 +54,26 @@ func NewContainer(action string, planId int64, dir string) (*Container, error) {
 		dir:    dir}, nil
 }
 
+// SetEnv 设置传递给部署镜像的环境变量，值为空时忽略
+func (c *Container) SetEnv(name string, value string) {
+	if len(value) == 0 {
+		return
+	}
+	c.env = append(c.env, fmt.Sprintf("%s=%s", name, value))
+}
+
+// SetLimit 限制部署仅作用于指定的节点，通过 LIMIT 环境变量传递给部署镜像
+func (c *Container) SetLimit(limit string) {
+	c.SetEnv("LIMIT", limit)
+}
+
+// SetForks 设置节点级任务的并发数，通过 FORKS 环境变量传递给部署镜像
+func (c *Container) SetForks(forks int) {
+	if forks > 0 {
+		c.SetEnv("FORKS", fmt.Sprintf("%d", forks))
+	}
+}
+
 // StartAndWaitForContainer 创建，启动容器，并等待容器退出
 func (c *Container) StartAndWaitForContainer(ctx context.Context, image string) error {
 	// 已经存在，则先删除运行的容器
@@ -66,7 +87,7 @@ func (c *Container) StartAndWaitForContainer(ctx context.Context, image string)
 			"pixiuName": c.name,
 		},
 		Image: image,
-		Env:   []string{fmt.Sprintf("COMMAND=%s", c.action)},
+		Env:   append([]string{fmt.Sprintf("COMMAND=%s", c.action)}, c.env...),
 	}
 	hostConfig := &container.HostConfig{
 		Binds: []string{fmt.Sprintf("%s/%d:/configs", c.dir, c.planId)},
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultCoreDNSPrefix = "/skydns"
+
+// CoreDNSOptions CoreDNS etcd 插件的后端配置，通过 etcd v3 的 grpc-gateway 写入记录
+type CoreDNSOptions struct {
+	// etcd 的访问地址，例如 http://127.0.0.1:2379
+	Endpoint string `yaml:"endpoint"`
+	// 与 CoreDNS etcd 插件的 path 保持一致，默认 /skydns
+	Prefix string `yaml:"prefix"`
+}
+
+func (o *CoreDNSOptions) Valid() error {
+	if len(o.Endpoint) == 0 {
+		return fmt.Errorf("coredns provider, no endpoint found")
+	}
+	return nil
+}
+
+type coreDNS struct {
+	endpoint string
+	prefix   string
+	ttl      int
+
+	client *http.Client
+}
+
+func newCoreDNS(o CoreDNSOptions, ttl int) *coreDNS {
+	prefix := o.Prefix
+	if len(prefix) == 0 {
+		prefix = defaultCoreDNSPrefix
+	}
+	return &coreDNS{
+		endpoint: strings.TrimSuffix(o.Endpoint, "/"),
+		prefix:   strings.TrimSuffix(prefix, "/"),
+		ttl:      ttl,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (c *coreDNS) Name() string {
+	return ProviderCoreDNS
+}
+
+// skydnsRecord CoreDNS etcd 插件识别的记录格式
+type skydnsRecord struct {
+	Host string `json:"host"`
+	TTL  int    `json:"ttl"`
+}
+
+func (c *coreDNS) AddRecord(ctx context.Context, record Record) error {
+	ttl := record.TTL
+	if ttl == 0 {
+		ttl = c.ttl
+	}
+	value, err := json.Marshal(skydnsRecord{Host: record.Value, TTL: ttl})
+	if err != nil {
+		return err
+	}
+
+	return c.do(ctx, "/v3/kv/put", map[string]string{
+		"key":   base64.StdEncoding.EncodeToString([]byte(c.key(record.Domain))),
+		"value": base64.StdEncoding.EncodeToString(value),
+	})
+}
+
+func (c *coreDNS) RemoveRecord(ctx context.Context, record Record) error {
+	return c.do(ctx, "/v3/kv/deleterange", map[string]string{
+		"key": base64.StdEncoding.EncodeToString([]byte(c.key(record.Domain))),
+	})
+}
+
+// key 将域名转换成 etcd 的存储路径
+// www.apps.pixiu.io -> /skydns/io/pixiu/apps/www
+func (c *coreDNS) key(domain string) string {
+	labels := strings.Split(strings.TrimSuffix(domain, "."), ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return c.prefix + "/" + strings.Join(labels, "/")
+}
+
+func (c *coreDNS) do(ctx context.Context, path string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("coredns etcd backend returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	ProviderCoreDNS = "coredns"
+
+	RecordTypeA     = "A"
+	RecordTypeCNAME = "CNAME"
+
+	defaultTTL = 60
+)
+
+// Record 需要向 DNS 服务注册的解析记录
+type Record struct {
+	Domain string
+	Type   string
+	Value  string
+	TTL    int
+}
+
+// Provider DNS 服务的接口，不同的 DNS 服务需实现该接口
+type Provider interface {
+	// Name 返回 provider 的名称
+	Name() string
+
+	// AddRecord 注册（覆盖）解析记录
+	AddRecord(ctx context.Context, record Record) error
+	// RemoveRecord 清理解析记录
+	RemoveRecord(ctx context.Context, record Record) error
+}
+
+// Options DNS 集成的配置，未开启时不会注册解析记录
+type Options struct {
+	Enable   bool   `yaml:"enable"`
+	Provider string `yaml:"provider"`
+	// 解析记录的生效域名后缀，例如 apps.pixiu.io
+	Zone string `yaml:"zone"`
+	TTL  int    `yaml:"ttl"`
+
+	CoreDNS CoreDNSOptions `yaml:"coredns"`
+}
+
+func (o *Options) Valid() error {
+	if !o.Enable {
+		return nil
+	}
+	if len(o.Zone) == 0 {
+		return fmt.Errorf("dns enabled, no zone found")
+	}
+
+	switch o.Provider {
+	case ProviderCoreDNS:
+		return o.CoreDNS.Valid()
+	default:
+		return fmt.Errorf("unsupported dns provider %q", o.Provider)
+	}
+}
+
+// NewProvider 根据配置构造 DNS provider
+func NewProvider(o Options) (Provider, error) {
+	if !o.Enable {
+		return nil, fmt.Errorf("dns integration is not enabled")
+	}
+	ttl := o.TTL
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	switch o.Provider {
+	case ProviderCoreDNS:
+		return newCoreDNS(o.CoreDNS, ttl), nil
+	default:
+		return nil, fmt.Errorf("unsupported dns provider %q", o.Provider)
+	}
+}
@@ -25,21 +25,71 @@ import (
 )
 
 var (
-	ErrRecordNotFound     = gorm.ErrRecordNotFound
-	ErrRecordNotUpdate    = errors.New("record not updated")
-	ErrBusySystem         = errors.New("系统繁忙，请稍后再试")
-	ErrReqParams          = errors.New("请求参数错误")
-	ErrCloudNotRegister   = errors.New("cloud 集群未注册")
-	ErrUserNotFound       = errors.New("用户不存在")
-	ErrNotAcceptable      = errors.New("有任务正在执行，请稍后再试")
-	ErrClusterNotFound    = errors.New("集群不存在")
-	ErrUserPassword       = errors.New("密码错误")
-	ErrInternal           = errors.New("服务器内部错误")
-	ErrTenantNotFound     = errors.New("租户不存在")
-	ErrDuplicatedPassword = errors.New("新密码与旧密码相同")
-	ErrAuditNotFound      = errors.New("审计记录不存在")
-
-	ErrContainerNotFound = errors.New("容器不存在")
+	ErrRecordNotFound      = gorm.ErrRecordNotFound
+	ErrRecordNotUpdate     = errors.New("record not updated")
+	ErrBusySystem          = errors.New("系统繁忙，请稍后再试")
+	ErrReqParams           = errors.New("请求参数错误")
+	ErrCloudNotRegister    = errors.New("cloud 集群未注册")
+	ErrUserNotFound        = errors.New("用户不存在")
+	ErrNotAcceptable       = errors.New("有任务正在执行，请稍后再试")
+	ErrClusterNotFound     = errors.New("集群不存在")
+	ErrUserPassword        = errors.New("密码错误")
+	ErrInternal            = errors.New("服务器内部错误")
+	ErrTenantNotFound      = errors.New("租户不存在")
+	ErrDuplicatedPassword  = errors.New("新密码与旧密码相同")
+	ErrAuditNotFound       = errors.New("审计记录不存在")
+	ErrDNSNotEnabled       = errors.New("未开启 DNS 集成")
+	ErrDNSRecordNotFound   = errors.New("解析记录不存在")
+	ErrDNSRecordExists     = errors.New("解析记录已存在")
+	ErrPipelineNotFound    = errors.New("流水线不存在")
+	ErrPipelineExists      = errors.New("流水线已存在")
+	ErrPipelineRunNotFound = errors.New("流水线运行记录不存在")
+	ErrPropagationNotFound = errors.New("分发任务不存在")
+	ErrPropagationExists   = errors.New("分发任务已存在")
+	ErrFleetNotFound       = errors.New("集群分组不存在")
+	ErrFleetExists         = errors.New("集群分组已存在")
+	ErrScalingNotFound     = errors.New("伸缩计划不存在")
+
+	ErrNamespacePolicyNotFound = errors.New("命名空间清理策略不存在")
+	ErrNamespacePolicyExists   = errors.New("命名空间清理策略已存在")
+	ErrTemplateNotFound        = errors.New("资源模板不存在")
+	ErrTemplateExists          = errors.New("资源模板已存在")
+	ErrReplicationNotFound     = errors.New("同步任务不存在")
+	ErrReplicationExists       = errors.New("同步任务已存在")
+
+	ErrContainerNotFound       = errors.New("容器不存在")
+	ErrLogBufferDisabled       = errors.New("未开启日志缓存")
+	ErrQueryStatsDisabled      = errors.New("未开启数据库耗时统计")
+	ErrBootstrapTokenInvalid   = errors.New("引导 token 无效，已过期或者已被使用")
+	ErrHelmSecretNotFound      = errors.New("敏感变量不存在")
+	ErrHelmSecretExists        = errors.New("敏感变量已存在")
+	ErrKubeConfigNotFound      = errors.New("kubeconfig 不存在")
+	ErrKubeConfigRevoked       = errors.New("kubeconfig 已被吊销")
+	ErrPlanNotFound            = errors.New("部署计划不存在")
+	ErrPlanNodeNotFound        = errors.New("部署计划节点不存在")
+	ErrPlanConfigNotFound      = errors.New("部署计划配置不存在")
+	ErrCloudAccountNotFound    = errors.New("云账号不存在")
+	ErrCloudAccountExists      = errors.New("云账号已存在")
+	ErrCloudClusterImported    = errors.New("托管集群已导入")
+	ErrSLONotFound             = errors.New("SLO 不存在")
+	ErrSLOExists               = errors.New("SLO 已存在")
+	ErrPrecheckNotFound        = errors.New("巡检记录不存在")
+	ErrNotificationNotFound    = errors.New("通知不存在")
+	ErrNamingPolicyNotFound    = errors.New("命名规范不存在")
+	ErrNamingPolicyExists      = errors.New("命名规范已存在")
+	ErrSidecarTemplateNotFound = errors.New("注入模板不存在")
+	ErrSidecarTemplateExists   = errors.New("注入模板已存在")
+	ErrInjectionNotFound       = errors.New("注入记录不存在")
+	ErrRecycleNotEnabled       = errors.New("未开启回收站")
+	ErrRecycledObjectNotFound  = errors.New("回收站中不存在该对象")
+	ErrAnnouncementNotFound    = errors.New("公告不存在")
+	ErrNodePoolNotFound        = errors.New("节点池不存在")
+	ErrPortForwardNotFound     = errors.New("端口转发会话不存在或已过期")
+	ErrSessionNotFound         = errors.New("会话不存在或已结束")
+	ErrTenantQuotaExceeded     = errors.New("超出租户的资源配额")
+
+	ErrInspectionNotFound       = errors.New("巡检计划不存在")
+	ErrInspectionReportNotFound = errors.New("巡检报告不存在")
 
 	ParamsError         = errors.New("参数错误")
 	OperateFailed       = errors.New("操作失败")
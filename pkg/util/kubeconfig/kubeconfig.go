@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
+)
+
+// Options 生成单集群 kubeconfig 的配置，authInfo 支持 token，证书以及 exec 插件
+type Options struct {
+	Cluster  string
+	Server   string
+	CAData   []byte
+	Insecure bool
+
+	User     string
+	AuthInfo *clientcmdapi.AuthInfo
+
+	// context 为空时以集群名命名
+	Context   string
+	Namespace string
+}
+
+// New 生成只包含一个集群，用户和 context 的 kubeconfig
+func New(o Options) ([]byte, error) {
+	contextName := o.Context
+	if len(contextName) == 0 {
+		contextName = o.Cluster
+	}
+
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters[o.Cluster] = &clientcmdapi.Cluster{
+		Server:                   o.Server,
+		CertificateAuthorityData: o.CAData,
+		InsecureSkipTLSVerify:    o.Insecure,
+	}
+	cfg.AuthInfos[o.User] = o.AuthInfo
+	cfg.Contexts[contextName] = &clientcmdapi.Context{Cluster: o.Cluster, AuthInfo: o.User, Namespace: o.Namespace}
+	cfg.CurrentContext = contextName
+	return Write(cfg)
+}
+
+// NewForToken 使用 bearer token 生成 kubeconfig，集群，用户和 context 均以 name 命名
+func NewForToken(name, server string, caData []byte, token string) ([]byte, error) {
+	return New(Options{
+		Cluster:  name,
+		Server:   server,
+		CAData:   caData,
+		User:     name,
+		AuthInfo: &clientcmdapi.AuthInfo{Token: token},
+	})
+}
+
+// Write 校验后序列化 kubeconfig，支持包含多个集群的配置
+func Write(cfg *clientcmdapi.Config) ([]byte, error) {
+	if err := clientcmd.Validate(*cfg); err != nil {
+		return nil, err
+	}
+	return clientcmd.Write(*cfg)
+}
+
+// SetToken 替换 kubeconfig 中指定用户的 token
+// 使用 map 解析 kubeconfig，未修改的字段原样保留，避免结构体未定义的字段在重新序列化时丢失
+func SetToken(data []byte, user string, token string) ([]byte, error) {
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("empty kubeconfig")
+	}
+
+	named, ok := findNamed(cfg, "users", user)
+	if !ok {
+		return nil, fmt.Errorf("user %s not found in kubeconfig", user)
+	}
+	authInfo, ok := named["user"].(map[string]interface{})
+	if !ok {
+		authInfo = make(map[string]interface{})
+		named["user"] = authInfo
+	}
+	authInfo["token"] = token
+
+	return yaml.Marshal(cfg)
+}
+
+// CurrentUser 获取当前 context 关联的用户，不存在时返回空
+func CurrentUser(data []byte) string {
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return ""
+	}
+	current, _ := cfg["current-context"].(string)
+	ctx, ok := findNamed(cfg, "contexts", current)
+	if !ok {
+		return ""
+	}
+	context, _ := ctx["context"].(map[string]interface{})
+	user, _ := context["user"].(string)
+	return user
+}
+
+// findNamed 在 clusters，contexts 或者 users 列表中查找指定名称的条目
+func findNamed(cfg map[string]interface{}, key string, name string) (map[string]interface{}, bool) {
+	items, _ := cfg[key].([]interface{})
+	for _, item := range items {
+		named, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if n, _ := named["name"].(string); n == name {
+			return named, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/yaml"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{
+			name: "token",
+			opts: Options{
+				Cluster:  "foo",
+				Server:   "https://127.0.0.1:6443",
+				CAData:   []byte("ca"),
+				User:     "bar",
+				AuthInfo: &clientcmdapi.AuthInfo{Token: "token"},
+			},
+		},
+		{
+			name: "exec plugin",
+			opts: Options{
+				Cluster: "foo",
+				Server:  "https://127.0.0.1:6443",
+				User:    "bar",
+				AuthInfo: &clientcmdapi.AuthInfo{Exec: &clientcmdapi.ExecConfig{
+					APIVersion:      "client.authentication.k8s.io/v1beta1",
+					Command:         "aws",
+					Args:            []string{"eks", "get-token", "--cluster-name", "foo"},
+					Env:             []clientcmdapi.ExecEnvVar{{Name: "AWS_PROFILE", Value: "default"}},
+					InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+				}},
+				Context:   "bar@foo",
+				Namespace: "default",
+			},
+		},
+		{
+			name: "missing server",
+			opts: Options{
+				Cluster:  "foo",
+				User:     "bar",
+				AuthInfo: &clientcmdapi.AuthInfo{Token: "token"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflicting credentials",
+			opts: Options{
+				Cluster:  "foo",
+				Server:   "https://127.0.0.1:6443",
+				User:     "bar",
+				AuthInfo: &clientcmdapi.AuthInfo{Token: "token", Username: "bar", Password: "bar"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := New(test.opts)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+
+			cfg, err := clientcmd.Load(data)
+			if err != nil {
+				t.Fatalf("failed to load kubeconfig: %v", err)
+			}
+			contextName := test.opts.Context
+			if len(contextName) == 0 {
+				contextName = test.opts.Cluster
+			}
+			if cfg.CurrentContext != contextName {
+				t.Errorf("current context = %s, want %s", cfg.CurrentContext, contextName)
+			}
+			context := cfg.Contexts[contextName]
+			if context == nil || context.Cluster != test.opts.Cluster || context.AuthInfo != test.opts.User || context.Namespace != test.opts.Namespace {
+				t.Errorf("unexpected context %+v", context)
+			}
+			cluster := cfg.Clusters[test.opts.Cluster]
+			if cluster == nil || cluster.Server != test.opts.Server || string(cluster.CertificateAuthorityData) != string(test.opts.CAData) {
+				t.Errorf("unexpected cluster %+v", cluster)
+			}
+			authInfo := cfg.AuthInfos[test.opts.User]
+			if authInfo == nil {
+				t.Fatalf("user %s not found", test.opts.User)
+			}
+			if authInfo.Token != test.opts.AuthInfo.Token {
+				t.Errorf("token = %s, want %s", authInfo.Token, test.opts.AuthInfo.Token)
+			}
+			if !reflect.DeepEqual(authInfo.Exec, test.opts.AuthInfo.Exec) {
+				t.Errorf("exec = %+v, want %+v", authInfo.Exec, test.opts.AuthInfo.Exec)
+			}
+		})
+	}
+}
+
+func TestWriteMultipleClusters(t *testing.T) {
+	cfg := clientcmdapi.NewConfig()
+	for _, name := range []string{"foo", "bar"} {
+		cfg.Clusters[name] = &clientcmdapi.Cluster{Server: "https://" + name + ":6443"}
+		cfg.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: name}
+		cfg.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	}
+	cfg.CurrentContext = "foo"
+
+	data, err := Write(cfg)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	loaded, err := clientcmd.Load(data)
+	if err != nil {
+		t.Fatalf("failed to load kubeconfig: %v", err)
+	}
+	if len(loaded.Clusters) != 2 || len(loaded.AuthInfos) != 2 || len(loaded.Contexts) != 2 {
+		t.Errorf("unexpected kubeconfig: %s", data)
+	}
+
+	// context 引用不存在的集群时校验失败
+	cfg.Contexts["baz"] = &clientcmdapi.Context{Cluster: "baz", AuthInfo: "foo"}
+	if _, err = Write(cfg); err == nil {
+		t.Errorf("Write() expected error for context with unknown cluster")
+	}
+}
+
+func TestSetToken(t *testing.T) {
+	data := []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: foo
+  cluster:
+    server: https://127.0.0.1:6443
+    tls-server-name: kubernetes
+    extensions:
+    - name: pixiu
+      extension:
+        owner: pixiu
+contexts:
+- name: bar@foo
+  context:
+    cluster: foo
+    user: bar
+    namespace: default
+current-context: bar@foo
+preferences:
+  colors: true
+users:
+- name: bar
+  user:
+    token: old
+- name: other
+  user:
+    token: other
+`)
+
+	user := CurrentUser(data)
+	if user != "bar" {
+		t.Fatalf("CurrentUser() = %s, want bar", user)
+	}
+	out, err := SetToken(data, user, "new")
+	if err != nil {
+		t.Fatalf("SetToken() error = %v", err)
+	}
+	if _, err = SetToken(data, "unknown", "new"); err == nil {
+		t.Errorf("SetToken() expected error for unknown user")
+	}
+
+	var want, got map[string]interface{}
+	if err = yaml.Unmarshal([]byte(strings.Replace(string(data), "token: old", "token: new", 1)), &want); err != nil {
+		t.Fatal(err)
+	}
+	if err = yaml.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	// 仅替换当前用户的 token，其他字段保持不变
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("SetToken() = %s", out)
+	}
+}
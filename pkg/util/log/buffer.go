@@ -0,0 +1,220 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultQueryLimit = 200
+	maxQueryLimit     = 5000
+)
+
+// Entry 日志缓存中的单条日志
+type Entry struct {
+	Time    time.Time              `json:"time"`
+	Level   string                 `json:"level"`
+	Module  string                 `json:"module"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+
+	level logrus.Level
+}
+
+// QueryOptions 日志的查询条件，level 为最低日志级别，module 为空时不过滤
+type QueryOptions struct {
+	Level  string    `form:"level"`
+	Module string    `form:"module"`
+	Since  time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until  time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit  int       `form:"limit"`
+}
+
+// Buffer 保存最近日志的环形缓存，同时接收 logrus 和 klog 的日志
+type Buffer struct {
+	lock    sync.RWMutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+func NewBuffer(size int) *Buffer {
+	return &Buffer{entries: make([]Entry, size)}
+}
+
+var defaultBuffer *Buffer
+
+// DefaultBuffer 返回服务的日志缓存，未开启时返回 nil
+func DefaultBuffer() *Buffer {
+	return defaultBuffer
+}
+
+func (b *Buffer) add(e Entry) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.entries[b.next] = e
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Levels 实现 logrus.Hook
+func (b *Buffer) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire 实现 logrus.Hook
+func (b *Buffer) Fire(e *logrus.Entry) error {
+	fields := make(map[string]interface{}, len(e.Data))
+	module := ""
+	for k, v := range e.Data {
+		if k == "module" {
+			module, _ = v.(string)
+			continue
+		}
+		// error 类型序列化为 json 时会丢失内容
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		fields[k] = v
+	}
+	b.add(Entry{
+		Time:    e.Time,
+		Level:   e.Level.String(),
+		Module:  module,
+		Message: e.Message,
+		Fields:  fields,
+		level:   e.Level,
+	})
+	return nil
+}
+
+// Query 按照时间顺序返回符合条件的最近日志
+func (b *Buffer) Query(opts QueryOptions) ([]Entry, error) {
+	level := logrus.TraceLevel
+	if len(opts.Level) != 0 {
+		var err error
+		if level, err = logrus.ParseLevel(opts.Level); err != nil {
+			return nil, err
+		}
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	size := b.next
+	if b.full {
+		size = len(b.entries)
+	}
+	// 从最新的日志开始倒序查找
+	matched := make([]Entry, 0)
+	for i := 0; i < size && len(matched) < limit; i++ {
+		e := b.entries[(b.next-1-i+len(b.entries))%len(b.entries)]
+		if e.level > level {
+			continue
+		}
+		if len(opts.Module) != 0 && e.Module != opts.Module {
+			continue
+		}
+		if !opts.Since.IsZero() && e.Time.Before(opts.Since) {
+			continue
+		}
+		if !opts.Until.IsZero() && e.Time.After(opts.Until) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	return matched, nil
+}
+
+var klogLevels = map[byte]logrus.Level{
+	'I': logrus.InfoLevel,
+	'W': logrus.WarnLevel,
+	'E': logrus.ErrorLevel,
+	'F': logrus.FatalLevel,
+}
+
+// klogWriter 解析 klog 的日志写入缓存，module 为打印日志的文件名
+// 例如: I1014 12:00:00.000000   10086 plan.go:100] message
+type klogWriter struct {
+	buffer *Buffer
+}
+
+func (w *klogWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	level, ok := klogLevels[p[0]]
+	if !ok {
+		level = logrus.InfoLevel
+	}
+	// 错误及以上级别的日志 klog 已经写到 stderr
+	if level > logrus.ErrorLevel {
+		os.Stderr.Write(p)
+	}
+
+	module, message := "", string(bytes.TrimSpace(p))
+	if idx := strings.Index(message, "] "); idx != -1 {
+		header := strings.Fields(message[:idx])
+		if len(header) != 0 {
+			caller := header[len(header)-1]
+			module = strings.TrimSuffix(filepath.Base(strings.SplitN(caller, ":", 2)[0]), ".go")
+		}
+		message = message[idx+2:]
+	}
+	w.buffer.add(Entry{
+		Time:    time.Now(),
+		Level:   level.String(),
+		Module:  module,
+		Message: message,
+		level:   level,
+	})
+	return len(p), nil
+}
+
+// redirectKlog 将 klog 的日志同时写到缓存，输出到 stderr 的行为保持不变
+func redirectKlog(b *Buffer) {
+	klog.LogToStderr(false)
+	klog.SetOutputBySeverity("INFO", &klogWriter{buffer: b})
+	// 所有级别的日志都会写到 INFO，其他级别丢弃避免重复，同时避免 klog 创建日志文件
+	for _, s := range []string{"WARNING", "ERROR", "FATAL"} {
+		klog.SetOutputBySeverity(s, io.Discard)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/klog/v2"
+)
+
+// LevelSetting 运行时的日志级别
+type LevelSetting struct {
+	// 服务日志的级别，可选 error，info 和 debug
+	Level string `json:"level"`
+	// klog 的日志级别，等同于 -v
+	Verbosity int `json:"verbosity"`
+	// 按模块设置 klog 的日志级别，模块为源文件名，等同于 -vmodule
+	Modules map[string]int `json:"modules,omitempty"`
+}
+
+var (
+	levelLock    sync.RWMutex
+	currentLevel LevelSetting
+)
+
+// CurrentLevel 返回当前生效的日志级别
+func CurrentLevel() LevelSetting {
+	levelLock.RLock()
+	defer levelLock.RUnlock()
+
+	return currentLevel
+}
+
+// initLevel 记录启动时配置文件和命令行指定的日志级别
+func initLevel(level LogLevel) {
+	levelLock.Lock()
+	defer levelLock.Unlock()
+
+	currentLevel = LevelSetting{Level: level.String()}
+	if f := flag.CommandLine.Lookup("v"); f != nil {
+		currentLevel.Verbosity, _ = strconv.Atoi(f.Value.String())
+	}
+}
+
+func (s LevelSetting) Validate() error {
+	level, err := logrus.ParseLevel(s.Level)
+	if err != nil {
+		return err
+	}
+	switch level {
+	case ErrorLevel, InfoLevel, DebugLevel:
+	default:
+		return fmt.Errorf("unsupported log level %s", s.Level)
+	}
+	if s.Verbosity < 0 {
+		return fmt.Errorf("verbosity must not be negative")
+	}
+	for module, v := range s.Modules {
+		if len(module) == 0 || strings.ContainsAny(module, "=,") {
+			return fmt.Errorf("invalid module %q", module)
+		}
+		if v < 0 {
+			return fmt.Errorf("verbosity of module %s must not be negative", module)
+		}
+	}
+	return nil
+}
+
+// Apply 调整 logrus 和 klog 的日志级别，无需重启服务
+func (s LevelSetting) Apply() error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	var verbosity klog.Level
+	if err := verbosity.Set(strconv.Itoa(s.Verbosity)); err != nil {
+		return err
+	}
+	// vmodule 的 flag 由 klog.InitFlags 注册
+	if f := flag.CommandLine.Lookup("vmodule"); f != nil {
+		modules := make([]string, 0, len(s.Modules))
+		for module, v := range s.Modules {
+			modules = append(modules, fmt.Sprintf("%s=%d", module, v))
+		}
+		sort.Strings(modules)
+		if err := f.Value.Set(strings.Join(modules, ",")); err != nil {
+			return err
+		}
+	}
+	level, _ := logrus.ParseLevel(s.Level)
+	logrus.SetLevel(level)
+
+	levelLock.Lock()
+	defer levelLock.Unlock()
+	currentLevel = s
+	return nil
+}
@@ -48,10 +48,14 @@ const (
 	DebugLevel LogLevel = klog.DebugLevel
 )
 
+const DefaultLogBufferSize = 2000
+
 type LogOptions struct {
 	LogFormat `yaml:"log_format"`
 	LogSQL    bool `yaml:"log_sql"`
 	LogLevel  `yaml:"log_level"`
+	// 缓存的最近日志条数，用于在线查询，小于 0 时关闭
+	LogBufferSize int `yaml:"log_buffer_size"`
 }
 
 // DefaultLogOptions returns the default configs.
@@ -60,6 +64,8 @@ func DefaultLogOptions() *LogOptions {
 		LogFormat: LogFormatJson,
 		LogSQL:    false,
 		LogLevel:  InfoLevel,
+
+		LogBufferSize: DefaultLogBufferSize,
 	}
 }
 
@@ -76,6 +82,7 @@ func (o *LogOptions) Valid() error {
 func (o *LogOptions) Init() {
 	once.Do(func() {
 		klog.SetLevel(o.LogLevel)
+		initLevel(o.LogLevel)
 		switch o.LogFormat {
 		case LogFormatJson:
 			klog.SetFormatter(&klog.JSONFormatter{
@@ -87,6 +94,12 @@ func (o *LogOptions) Init() {
 				TimestampFormat: time.RFC3339Nano,
 			})
 		}
+
+		if o.LogBufferSize > 0 {
+			defaultBuffer = NewBuffer(o.LogBufferSize)
+			klog.AddHook(defaultBuffer)
+			redirectKlog(defaultBuffer)
+		}
 	})
 }
 
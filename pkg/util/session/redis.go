@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	sessionKeyPrefix = "pixiu:session:"
+	// 所有会话 id 的集合，会话信息过期后在获取列表时清理
+	sessionIndexKey = "pixiu:sessions"
+)
+
+// redisRegistry 多副本部署时的会话存储，会话信息保存为带过期时间的 key
+type redisRegistry struct {
+	pool *redis.Pool
+}
+
+func newRedisRegistry(o RedisOptions) (*redisRegistry, error) {
+	pool := &redis.Pool{
+		MaxIdle:     8,
+		IdleTimeout: 5 * time.Minute,
+		DialContext: func(ctx context.Context) (redis.Conn, error) {
+			return redis.DialContext(ctx, "tcp", o.Address,
+				redis.DialPassword(o.Password),
+				redis.DialDatabase(o.DB),
+				redis.DialConnectTimeout(5*time.Second),
+			)
+		},
+	}
+
+	conn := pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		_ = pool.Close()
+		return nil, err
+	}
+	return &redisRegistry{pool: pool}, nil
+}
+
+func (rr *redisRegistry) Register(ctx context.Context, s *types.Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	conn, err := rr.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.Do("SET", sessionKeyPrefix+s.Id, data, "EX", int(sessionTTL.Seconds())); err != nil {
+		return err
+	}
+	_, err = conn.Do("SADD", sessionIndexKey, s.Id)
+	return err
+}
+
+func (rr *redisRegistry) Unregister(ctx context.Context, id string) error {
+	conn, err := rr.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err = conn.Do("DEL", sessionKeyPrefix+id); err != nil {
+		return err
+	}
+	_, err = conn.Do("SREM", sessionIndexKey, id)
+	return err
+}
+
+func (rr *redisRegistry) Get(ctx context.Context, id string) (*types.Session, error) {
+	conn, err := rr.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	data, err := redis.Bytes(conn.Do("GET", sessionKeyPrefix+id))
+	if err != nil {
+		if err == redis.ErrNil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var s types.Session
+	if err = json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (rr *redisRegistry) List(ctx context.Context) ([]types.Session, error) {
+	conn, err := rr.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ids, err := redis.Strings(conn.Do("SMEMBERS", sessionIndexKey))
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]types.Session, 0, len(ids))
+	if len(ids) == 0 {
+		return sessions, nil
+	}
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = sessionKeyPrefix + id
+	}
+	values, err := redis.ByteSlices(conn.Do("MGET", args...))
+	if err != nil {
+		return nil, err
+	}
+	var expired []interface{}
+	for i, data := range values {
+		if data == nil {
+			expired = append(expired, ids[i])
+			continue
+		}
+		var s types.Session
+		if err = json.Unmarshal(data, &s); err != nil {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	// 清理已过期的会话，例如所在实例异常退出
+	if len(expired) != 0 {
+		_, _ = conn.Do("SREM", append([]interface{}{sessionIndexKey}, expired...)...)
+	}
+	return sessions, nil
+}
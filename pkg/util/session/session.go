@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
+
+	"github.com/caoyingjunz/pixiu/pkg/types"
+)
+
+const (
+	KindPodShell    = "pod_shell"
+	KindNodeShell   = "node_shell"
+	KindKubectl     = "kubectl"
+	KindPodLog      = "pod_log"
+	KindPortForward = "port_forward"
+
+	// HandOffHeader 转发到会话所在实例的请求携带的请求头，避免实例之间循环转发
+	HandOffHeader = "X-Pixiu-Handoff"
+
+	// 会话信息的过期时间，会话所在的实例定期续期，实例异常退出后会话自动过期
+	sessionTTL        = 60 * time.Second
+	heartbeatInterval = sessionTTL / 3
+)
+
+// Options 多副本部署时通过 redis 共享会话信息，未配置时会话仅保存在当前实例
+type Options struct {
+	Redis RedisOptions `yaml:"redis"`
+	// 当前实例供其他实例访问的地址，例如 http://10.0.0.1:8090
+	// 请求被负载均衡到其他实例时，转发到会话所在的实例
+	Advertise string `yaml:"advertise"`
+}
+
+type RedisOptions struct {
+	Address  string `yaml:"address"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+func (o *Options) Valid() error {
+	if len(o.Redis.Address) == 0 {
+		return nil
+	}
+	if len(o.Advertise) == 0 {
+		return fmt.Errorf("redis configured, no advertise address found")
+	}
+	if u, err := url.Parse(o.Advertise); err != nil || len(u.Host) == 0 || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid advertise address %q", o.Advertise)
+	}
+	return nil
+}
+
+// Registry 保存进行中的会话
+type Registry interface {
+	// Register 保存或者续期会话
+	Register(ctx context.Context, s *types.Session) error
+	Unregister(ctx context.Context, id string) error
+	// Get 获取会话，不存在时返回 nil
+	Get(ctx context.Context, id string) (*types.Session, error)
+	List(ctx context.Context) ([]types.Session, error)
+}
+
+var (
+	registry Registry = newMemoryRegistry()
+	// 当前实例的访问地址，仅保存在当前实例时为空
+	advertise string
+)
+
+// Setup 根据配置初始化会话的存储，未配置 redis 时使用内存存储
+func Setup(o Options) error {
+	if len(o.Redis.Address) == 0 {
+		return nil
+	}
+	r, err := newRedisRegistry(o.Redis)
+	if err != nil {
+		return fmt.Errorf("failed to connect redis %s: %v", o.Redis.Address, err)
+	}
+	registry = r
+	advertise = o.Advertise
+	return nil
+}
+
+func Get(ctx context.Context, id string) (*types.Session, error) { return registry.Get(ctx, id) }
+
+// List 获取所有实例的会话，按开始时间倒序
+func List(ctx context.Context) ([]types.Session, error) {
+	sessions, err := registry.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.After(sessions[j].StartTime)
+	})
+	return sessions, nil
+}
+
+// IsLocal 判断会话是否在当前实例
+func IsLocal(s *types.Session) bool {
+	return s.Owner == advertise
+}
+
+// Track 记录会话，返回的函数在会话结束时调用
+// 会话信息仅用于展示和转发，存储异常时不影响会话本身
+func Track(s *types.Session) func() {
+	if len(s.Id) == 0 {
+		s.Id = utilrand.String(16)
+	}
+	if s.StartTime.IsZero() {
+		s.StartTime = time.Now()
+	}
+	s.Owner = advertise
+
+	if err := registry.Register(context.TODO(), s); err != nil {
+		klog.Warningf("failed to register %s session %s: %v", s.Kind, s.Id, err)
+	}
+	stopCh := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := registry.Register(context.TODO(), s); err != nil {
+					klog.Warningf("failed to refresh %s session %s: %v", s.Kind, s.Id, err)
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stopCh)
+			if err := registry.Unregister(context.TODO(), s.Id); err != nil {
+				klog.Warningf("failed to unregister %s session %s: %v", s.Kind, s.Id, err)
+			}
+		})
+	}
+}
+
+// HandOff 会话在其他实例时将请求转发到会话所在的实例，返回是否已转发
+// websocket 的升级请求由 ReverseProxy 原样转发
+func HandOff(w http.ResponseWriter, r *http.Request, id string) bool {
+	if len(advertise) == 0 || len(r.Header.Get(HandOffHeader)) != 0 {
+		return false
+	}
+	s, err := registry.Get(r.Context(), id)
+	if err != nil {
+		klog.Warningf("failed to get session %s: %v", id, err)
+		return false
+	}
+	if s == nil || IsLocal(s) {
+		return false
+	}
+	target, err := url.Parse(s.Owner)
+	if err != nil {
+		klog.Warningf("invalid owner %q of session %s: %v", s.Owner, id, err)
+		return false
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+		req.Header.Set(HandOffHeader, advertise)
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		klog.Warningf("failed to hand off session %s to %s: %v", id, s.Owner, err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	klog.V(4).Infof("hand off session %s to %s", id, s.Owner)
+	proxy.ServeHTTP(w, r)
+	return true
+}
+
+// memoryRegistry 单实例部署时的会话存储
+type memoryRegistry struct {
+	lock  sync.RWMutex
+	items map[string]types.Session
+}
+
+func newMemoryRegistry() *memoryRegistry {
+	return &memoryRegistry{items: make(map[string]types.Session)}
+}
+
+func (m *memoryRegistry) Register(ctx context.Context, s *types.Session) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.items[s.Id] = *s
+	return nil
+}
+
+func (m *memoryRegistry) Unregister(ctx context.Context, id string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.items, id)
+	return nil
+}
+
+func (m *memoryRegistry) Get(ctx context.Context, id string) (*types.Session, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	s, ok := m.items[id]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (m *memoryRegistry) List(ctx context.Context) ([]types.Session, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	sessions := make([]types.Session, 0, len(m.items))
+	for _, s := range m.items {
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	tracesPath = "/v1/traces"
+
+	queueSize     = 2048
+	batchSize     = 256
+	flushInterval = 5 * time.Second
+
+	// OTLP 的 span kind 和 status code
+	spanKindInternal = 1
+	statusOk         = 1
+	statusError      = 2
+)
+
+// otlpExporter 使用 OTLP/HTTP 的 json 编码批量导出 span，队列满时丢弃
+type otlpExporter struct {
+	url         string
+	serviceName string
+	headers     map[string]string
+
+	queue  chan *Span
+	client *http.Client
+}
+
+func newOTLPExporter(o Options) *otlpExporter {
+	serviceName := o.ServiceName
+	if len(serviceName) == 0 {
+		serviceName = defaultServiceName
+	}
+	return &otlpExporter{
+		url:         strings.TrimSuffix(o.Endpoint, "/") + tracesPath,
+		serviceName: serviceName,
+		headers:     o.Headers,
+		queue:       make(chan *Span, queueSize),
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (e *otlpExporter) export(s *Span) {
+	select {
+	case e.queue <- s:
+	default:
+		klog.V(4).Infof("trace queue is full, dropping span %s", s.name)
+	}
+}
+
+func (e *otlpExporter) run() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, batchSize)
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		if err := e.send(batch); err != nil {
+			klog.Warningf("failed to export %d spans: %v", len(batch), err)
+		}
+		batch = make([]*Span, 0, batchSize)
+	}
+}
+
+type keyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceId           string     `json:"traceId"`
+	SpanId            string     `json:"spanId"`
+	ParentSpanId      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            otlpStatus `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func (e *otlpExporter) send(batch []*Span) error {
+	spans := make([]otlpSpan, len(batch))
+	for i, s := range batch {
+		status := otlpStatus{Code: statusOk}
+		if s.err != nil {
+			status = otlpStatus{Code: statusError, Message: s.err.Error()}
+		}
+		spans[i] = otlpSpan{
+			TraceId:           s.traceId,
+			SpanId:            s.spanId,
+			ParentSpanId:      s.parentId,
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        toKeyValues(s.attrs),
+			Status:            status,
+		}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": toKeyValues([]Attribute{String("service.name", e.serviceName)}),
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{"name": e.serviceName},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+func toKeyValues(attrs []Attribute) []keyValue {
+	kvs := make([]keyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		var value map[string]interface{}
+		switch v := attr.Value.(type) {
+		case string:
+			value = map[string]interface{}{"stringValue": v}
+		case int64:
+			// OTLP json 中 int64 编码为字符串
+			value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		kvs = append(kvs, keyValue{Key: attr.Key, Value: value})
+	}
+	return kvs
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const defaultServiceName = "pixiu"
+
+// Options OTLP 链路导出的配置，开启后多步骤的操作会以 trace 的形式导出
+type Options struct {
+	Enable bool `yaml:"enable"`
+	// OTLP/HTTP 的接收地址，例如 http://otel-collector:4318
+	Endpoint    string `yaml:"endpoint"`
+	ServiceName string `yaml:"service_name"`
+	// 额外的请求头，例如鉴权信息
+	Headers map[string]string `yaml:"headers"`
+}
+
+func (o *Options) Valid() error {
+	if !o.Enable {
+		return nil
+	}
+	if len(o.Endpoint) == 0 {
+		return fmt.Errorf("trace enabled, no endpoint found")
+	}
+	if u, err := url.Parse(o.Endpoint); err != nil || len(u.Host) == 0 {
+		return fmt.Errorf("invalid endpoint %q", o.Endpoint)
+	}
+	return nil
+}
+
+// Attribute span 的属性
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key, value string) Attribute    { return Attribute{Key: key, Value: value} }
+func Int(key string, value int64) Attribute { return Attribute{Key: key, Value: value} }
+func Bool(key string, value bool) Attribute { return Attribute{Key: key, Value: value} }
+
+// Span 一个操作步骤，未开启导出时为 nil，所有方法均可安全调用
+type Span struct {
+	lock sync.Mutex
+
+	traceId  string
+	spanId   string
+	parentId string
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    []Attribute
+	err      error
+	ended    bool
+}
+
+type spanKey struct{}
+
+var exporter *otlpExporter
+
+// Setup 根据配置启动导出，未开启时不记录任何 span
+func Setup(o Options) {
+	if !o.Enable {
+		return
+	}
+	exporter = newOTLPExporter(o)
+	go exporter.run()
+}
+
+// Start 创建 span，ctx 中存在 span 时作为其子 span
+func Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if exporter == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		spanId: randomHex(8),
+		name:   name,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.traceId = parent.traceId
+		span.parentId = parent.spanId
+	} else {
+		span.traceId = randomHex(16)
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext 获取 ctx 中的 span
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End 结束 span 并导出，err 不为空时标记为失败
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.err = err
+	s.lock.Unlock()
+
+	exporter.export(s)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
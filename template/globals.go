@@ -49,4 +49,11 @@ enable_calico: "yes"
 {{- end }}
 
 enable_nfs: "no"
+
+{{- if or .Runtime.Mirrors .Runtime.InsecureRegistries }}
+runtime_registry_config_dir: "/configs/runtime"
+{{- end }}
+{{- if .Runtime.Proxy }}
+runtime_proxy_config: "/configs/runtime/http-proxy.conf"
+{{- end }}
 `
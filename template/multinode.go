@@ -20,40 +20,40 @@ const MultiModeTemplate = `# Render below by Pixiu engine
 [docker-master]
 {{- range .DockerMaster }}
 {{- if eq .Auth.Type "password" }}
-{{ .Name }} ansible_ssh_user={{ .Auth.Password.User }} ansible_ssh_pass={{ .Auth.Password.Password }}
+{{ .Name }} ansible_ssh_user={{ .Auth.Password.User }} ansible_ssh_pass={{ .Auth.Password.Password }}{{ if .Arch }} node_arch={{ .Arch }}{{ end }}{{ if .OSFamily }} node_os_family={{ .OSFamily }}{{ end }}
 {{- end }}
 {{- if eq .Auth.Type "key" }}
-{{ .Name }} ansible_ssh_user=root ansible_ssh_private_key_file={{ .Auth.Key.File }}
+{{ .Name }} ansible_ssh_user=root ansible_ssh_private_key_file={{ .Auth.Key.File }}{{ if .Arch }} node_arch={{ .Arch }}{{ end }}{{ if .OSFamily }} node_os_family={{ .OSFamily }}{{ end }}
 {{- end }}
 {{- end }}
 
 [docker-node]
 {{- range .DockerNode }}
 {{- if eq .Auth.Type "password" }}
-{{ .Name }} ansible_ssh_user={{ .Auth.Password.User }} ansible_ssh_pass={{ .Auth.Password.Password }}
+{{ .Name }} ansible_ssh_user={{ .Auth.Password.User }} ansible_ssh_pass={{ .Auth.Password.Password }}{{ if .Arch }} node_arch={{ .Arch }}{{ end }}{{ if .OSFamily }} node_os_family={{ .OSFamily }}{{ end }}
 {{- end }}
 {{- if eq .Auth.Type "key" }}
-{{ .Name }} ansible_ssh_user=root ansible_ssh_private_key_file={{ .Auth.Key.File }}
+{{ .Name }} ansible_ssh_user=root ansible_ssh_private_key_file={{ .Auth.Key.File }}{{ if .Arch }} node_arch={{ .Arch }}{{ end }}{{ if .OSFamily }} node_os_family={{ .OSFamily }}{{ end }}
 {{- end }}
 {{- end }}
 
 [containerd-master]
 {{- range .ContainerdMaster }}
 {{- if eq .Auth.Type "password" }}
-{{ .Name }} ansible_ssh_user={{ .Auth.Password.User }} ansible_ssh_pass={{ .Auth.Password.Password }}
+{{ .Name }} ansible_ssh_user={{ .Auth.Password.User }} ansible_ssh_pass={{ .Auth.Password.Password }}{{ if .Arch }} node_arch={{ .Arch }}{{ end }}{{ if .OSFamily }} node_os_family={{ .OSFamily }}{{ end }}
 {{- end }}
 {{- if eq .Auth.Type "key" }}
-{{ .Name }} ansible_ssh_user=root ansible_ssh_private_key_file={{ .Auth.Key.File }}
+{{ .Name }} ansible_ssh_user=root ansible_ssh_private_key_file={{ .Auth.Key.File }}{{ if .Arch }} node_arch={{ .Arch }}{{ end }}{{ if .OSFamily }} node_os_family={{ .OSFamily }}{{ end }}
 {{- end }}
 {{- end }}
 
 [containerd-node]
 {{- range .ContainerdNode }}
 {{- if eq .Auth.Type "password" }}
-{{ .Name }} ansible_ssh_user={{ .Auth.Password.User }} ansible_ssh_pass={{ .Auth.Password.Password }}
+{{ .Name }} ansible_ssh_user={{ .Auth.Password.User }} ansible_ssh_pass={{ .Auth.Password.Password }}{{ if .Arch }} node_arch={{ .Arch }}{{ end }}{{ if .OSFamily }} node_os_family={{ .OSFamily }}{{ end }}
 {{- end }}
 {{- if eq .Auth.Type "key" }}
-{{ .Name }} ansible_ssh_user=root ansible_ssh_private_key_file={{ .Auth.Key.File }}
+{{ .Name }} ansible_ssh_user=root ansible_ssh_private_key_file={{ .Auth.Key.File }}{{ if .Arch }} node_arch={{ .Arch }}{{ end }}{{ if .OSFamily }} node_os_family={{ .OSFamily }}{{ end }}
 {{- end }}
 {{- end }}
 
@@ -0,0 +1,44 @@
+/*
+Copyright 2024 The Pixiu Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+// ContainerdHostsTemplate containerd 的仓库配置，渲染为 certs.d/<registry>/hosts.toml
+const ContainerdHostsTemplate = `# Render below by Pixiu engine
+server = "{{ .Server }}"
+{{- range .Endpoints }}
+
+[host."{{ . }}"]
+  capabilities = ["pull", "resolve"]
+{{- if $.Insecure }}
+  skip_verify = true
+{{- end }}
+{{- end }}
+`
+
+// RuntimeProxyTemplate 容器运行时的代理配置，渲染为 systemd drop-in
+const RuntimeProxyTemplate = `# Render below by Pixiu engine
+[Service]
+{{- if .HttpProxy }}
+Environment="HTTP_PROXY={{ .HttpProxy }}"
+{{- end }}
+{{- if .HttpsProxy }}
+Environment="HTTPS_PROXY={{ .HttpsProxy }}"
+{{- end }}
+{{- if .NoProxy }}
+Environment="NO_PROXY={{ .NoProxy }}"
+{{- end }}
+`